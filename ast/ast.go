@@ -37,6 +37,14 @@ func ParseAddress(address string) Address {
 func Parse(fullline string) Node {
 	line := fullline
 
+	// The type of a GNU typeof() depends on where its expression is, so it is
+	// replaced by the type that it stands for:
+	//
+	//    'typeof (*p)':'int'   =>   'int'
+	if strings.Contains(line, "typeof") {
+		line = util.GetRegex(`'[^']*typeof[^']*':('[^']*')`).ReplaceAllString(line, "$1")
+	}
+
 	// This is a special case. I'm not sure if it's a bug in the clang AST
 	// dumper. It should have children.
 	//
@@ -47,6 +55,14 @@ func Parse(fullline string) Node {
 		return parseArrayFiller(line)
 	}
 
+	// The associations of a _Generic selection do not have an address:
+	//
+	//    case 'int' selected
+	//    default
+	if strings.HasPrefix(line, "case '") || strings.HasPrefix(line, "default") {
+		return parseGenericAssociation(line)
+	}
+
 	// In Clang 9.0, array_filler may be used as a prefix to a
 	// ImplicitValueInitExpr, when implicitly initializing one or more elements of
 	// an array.
@@ -84,6 +100,8 @@ func Parse(fullline string) Node {
 		return parseAttributedType(line)
 	case "AvailabilityAttr":
 		return parseAvailabilityAttr(line)
+	case "BinaryConditionalOperator":
+		return parseBinaryConditionalOperator(line)
 	case "BinaryOperator":
 		return parseBinaryOperator(line)
 	case "BlockCommandComment":
@@ -168,6 +186,8 @@ func Parse(fullline string) Node {
 		return parseHTMLEndTagComment(line)
 	case "GCCAsmStmt":
 		return parseGCCAsmStmt(line)
+	case "GenericSelectionExpr":
+		return parseGenericSelectionExpr(line)
 	case "GotoStmt":
 		return parseGotoStmt(line)
 	case "IfStmt":
@@ -210,6 +230,8 @@ func Parse(fullline string) Node {
 		return parseNotTailCalledAttr(line)
 	case "OffsetOfExpr":
 		return parseOffsetOfExpr(line)
+	case "OpaqueValueExpr":
+		return parseOpaqueValueExpr(line)
 	case "PackedAttr":
 		return parsePackedAttr(line)
 	case "ParagraphComment":
@@ -246,6 +268,8 @@ func Parse(fullline string) Node {
 		return parseReturnsTwiceAttr(line)
 	case "SentinelAttr":
 		return parseSentinelAttr(line)
+	case "StaticAssertDecl":
+		return parseStaticAssertDecl(line)
 	case "StmtExpr":
 		return parseStmtExpr(line)
 	case "StringLiteral":
@@ -262,6 +286,8 @@ func Parse(fullline string) Node {
 		return parseTypedef(line)
 	case "TypedefDecl":
 		return parseTypedefDecl(line)
+	case "TypeOfExprType":
+		return parseTypeOfExprType(line)
 	case "TypedefType":
 		return parseTypedefType(line)
 	case "UnaryExprOrTypeTraitExpr":
@@ -0,0 +1,18 @@
+package ast
+
+import (
+	"testing"
+)
+
+func TestBinaryConditionalOperator(t *testing.T) {
+	nodes := map[string]Node{
+		`0x7fe2ab864f30 <col:10, col:19> 'int'`: &BinaryConditionalOperator{
+			Addr:       0x7fe2ab864f30,
+			Pos:        NewPositionFromString("col:10, col:19"),
+			Type:       "int",
+			ChildNodes: []Node{},
+		},
+	}
+
+	runNodeTests(t, nodes)
+}
@@ -0,0 +1,68 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+// GenericAssociation is an association of a GenericSelectionExpr, like
+// "case 'int' selected" or "default". The children are the type of the
+// association (that the default association does not have) and the
+// expression.
+type GenericAssociation struct {
+	Type       string
+	Type2      string
+	IsDefault  bool
+	IsSelected bool
+	ChildNodes []Node
+}
+
+func parseGenericAssociation(line string) *GenericAssociation {
+	if strings.HasPrefix(line, "default") {
+		return &GenericAssociation{
+			IsDefault:  true,
+			IsSelected: strings.HasSuffix(line, " selected"),
+			ChildNodes: []Node{},
+		}
+	}
+
+	// The association does not have an address, so groupsFromRegex() cannot
+	// be used.
+	match := util.GetRegex(`^case '(.*?)'(:'(.*?)')?( selected)?$`).
+		FindStringSubmatch(line)
+	if match == nil {
+		panic("could not match generic association: " + line)
+	}
+
+	return &GenericAssociation{
+		Type:       match[1],
+		Type2:      match[3],
+		IsSelected: len(match[4]) > 0,
+		ChildNodes: []Node{},
+	}
+}
+
+// AddChild adds a new child node. Child nodes can then be accessed with the
+// Children attribute.
+func (n *GenericAssociation) AddChild(node Node) {
+	n.ChildNodes = append(n.ChildNodes, node)
+}
+
+// Address returns the numeric address of the node. For a GenericAssociation
+// this will always be zero. See the documentation for the Address type for
+// more information.
+func (n *GenericAssociation) Address() Address {
+	return 0
+}
+
+// Children returns the child nodes. If this node does not have any children or
+// this node does not support children it will always return an empty slice.
+func (n *GenericAssociation) Children() []Node {
+	return n.ChildNodes
+}
+
+// Position returns the position in the original source code.
+func (n *GenericAssociation) Position() Position {
+	return Position{}
+}
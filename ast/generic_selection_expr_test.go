@@ -0,0 +1,65 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+func TestGenericSelectionExpr(t *testing.T) {
+	nodes := map[string]Node{
+		`0x55f7c1b2e3a8 <col:10, col:45> 'int'`: &GenericSelectionExpr{
+			Addr:       0x55f7c1b2e3a8,
+			Pos:        NewPositionFromString("col:10, col:45"),
+			Type:       "int",
+			Type2:      "",
+			Lvalue:     false,
+			ChildNodes: []Node{},
+		},
+		`0x55f7c1b2e4c0 <col:3, col:40> 'const char *'`: &GenericSelectionExpr{
+			Addr:       0x55f7c1b2e4c0,
+			Pos:        NewPositionFromString("col:3, col:40"),
+			Type:       "const char *",
+			Type2:      "",
+			Lvalue:     false,
+			ChildNodes: []Node{},
+		},
+	}
+
+	runNodeTests(t, nodes)
+}
+
+func TestGenericAssociation(t *testing.T) {
+	nodes := map[string]*GenericAssociation{
+		`case 'int' selected`: {
+			Type:       "int",
+			IsSelected: true,
+			ChildNodes: []Node{},
+		},
+		`case 'size_t':'unsigned long'`: {
+			Type:       "size_t",
+			Type2:      "unsigned long",
+			ChildNodes: []Node{},
+		},
+		`default`: {
+			IsDefault:  true,
+			ChildNodes: []Node{},
+		},
+		`default selected`: {
+			IsDefault:  true,
+			IsSelected: true,
+			ChildNodes: []Node{},
+		},
+	}
+
+	for line, expected := range nodes {
+		t.Run(line, func(t *testing.T) {
+			actual := Parse(line)
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("%s", util.ShowDiff(formatMultiLine(expected),
+					formatMultiLine(actual)))
+			}
+		})
+	}
+}
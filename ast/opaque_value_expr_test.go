@@ -0,0 +1,24 @@
+package ast
+
+import (
+	"testing"
+)
+
+func TestOpaqueValueExpr(t *testing.T) {
+	nodes := map[string]Node{
+		`0x7fe2ab864ee8 <col:10, col:12> 'int'`: &OpaqueValueExpr{
+			Addr:       0x7fe2ab864ee8,
+			Pos:        NewPositionFromString("col:10, col:12"),
+			Type:       "int",
+			ChildNodes: []Node{},
+		},
+		`0x7fe2ab864ee8 <col:10> 'char *'`: &OpaqueValueExpr{
+			Addr:       0x7fe2ab864ee8,
+			Pos:        NewPositionFromString("col:10"),
+			Type:       "char *",
+			ChildNodes: []Node{},
+		},
+	}
+
+	runNodeTests(t, nodes)
+}
@@ -243,6 +243,8 @@ func setPosition(node Node, position Position) {
 		n.Pos = position
 	case *AvailabilityAttr:
 		n.Pos = position
+	case *BinaryConditionalOperator:
+		n.Pos = position
 	case *BinaryOperator:
 		n.Pos = position
 	case *BlockCommandComment:
@@ -311,6 +313,8 @@ func setPosition(node Node, position Position) {
 		n.Pos = position
 	case *HTMLEndTagComment:
 		n.Pos = position
+	case *GenericSelectionExpr:
+		n.Pos = position
 	case *GotoStmt:
 		n.Pos = position
 	case *IfStmt:
@@ -349,6 +353,8 @@ func setPosition(node Node, position Position) {
 		n.Pos = position
 	case *OffsetOfExpr:
 		n.Pos = position
+	case *OpaqueValueExpr:
+		n.Pos = position
 	case *PackedAttr:
 		n.Pos = position
 	case *ParagraphComment:
@@ -373,6 +379,8 @@ func setPosition(node Node, position Position) {
 		n.Pos = position
 	case *SentinelAttr:
 		n.Pos = position
+	case *StaticAssertDecl:
+		n.Pos = position
 	case *StmtExpr:
 		n.Pos = position
 	case *StringLiteral:
@@ -410,10 +418,10 @@ func setPosition(node Node, position Position) {
 	case *WhileStmt:
 		n.Pos = position
 	case *TypedefType, *Typedef, *TranslationUnitDecl, *RecordType, *Record,
-		*QualType, *PointerType, *DecayedType, *ParenType,
+		*QualType, *PointerType, *DecayedType, *ParenType, *TypeOfExprType,
 		*IncompleteArrayType, *FunctionNoProtoType, *FunctionProtoType,
 		*EnumType, *Enum, *ElaboratedType, *ConstantArrayType, *BuiltinType,
-		*ArrayFiller, *Field, *AttributedType:
+		*ArrayFiller, *Field, *AttributedType, *GenericAssociation:
 
 		// These do not have positions so they can be ignored.
 	default:
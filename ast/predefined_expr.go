@@ -12,7 +12,7 @@ type PredefinedExpr struct {
 
 func parsePredefinedExpr(line string) *PredefinedExpr {
 	groups := groupsFromRegex(
-		"<(?P<position>.*)> '(?P<type>.*)' lvalue (?P<name>.*)",
+		"<(?P<position>.*)> '(?P<type>.*)'(?P<lvalue> lvalue)? (?P<name>.*)",
 		line,
 	)
 
@@ -21,7 +21,7 @@ func parsePredefinedExpr(line string) *PredefinedExpr {
 		Pos:        NewPositionFromString(groups["position"]),
 		Type:       groups["type"],
 		Name:       groups["name"],
-		Lvalue:     true,
+		Lvalue:     len(groups["lvalue"]) > 0,
 		ChildNodes: []Node{},
 	}
 }
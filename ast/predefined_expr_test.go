@@ -14,6 +14,14 @@ func TestPredefinedExpr(t *testing.T) {
 			Name:       "__PRETTY_FUNCTION__",
 			ChildNodes: []Node{},
 		},
+		`0x55d5e7a1c2a8 <col:23> 'const char[7]' __func__`: &PredefinedExpr{
+			Addr:       0x55d5e7a1c2a8,
+			Pos:        NewPositionFromString("col:23"),
+			Type:       "const char[7]",
+			Lvalue:     false,
+			Name:       "__func__",
+			ChildNodes: []Node{},
+		},
 	}
 
 	runNodeTests(t, nodes)
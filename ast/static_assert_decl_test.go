@@ -0,0 +1,18 @@
+package ast
+
+import (
+	"testing"
+)
+
+func TestStaticAssertDecl(t *testing.T) {
+	nodes := map[string]Node{
+		`0x55d0f8a3c2e8 <line:3:1, col:46> col:1`: &StaticAssertDecl{
+			Addr:       0x55d0f8a3c2e8,
+			Pos:        NewPositionFromString("line:3:1, col:46"),
+			Position2:  NewPositionFromString("col:1"),
+			ChildNodes: []Node{},
+		},
+	}
+
+	runNodeTests(t, nodes)
+}
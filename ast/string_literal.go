@@ -1,15 +1,23 @@
 package ast
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
+	"unicode/utf8"
 )
 
 // StringLiteral is type of string literal
 type StringLiteral struct {
-	Addr       Address
-	Pos        Position
-	Type       string
+	Addr Address
+	Pos  Position
+	Type string
+
+	// Prefix is the encoding prefix of a wide or a Unicode string, like "L"
+	// for L"abc". It is empty for a plain string.
+	Prefix string
+
+	// Value is the string that is encoded as UTF-8, see unquoteCString().
 	Value      string
 	Lvalue     bool
 	ChildNodes []Node
@@ -17,19 +25,20 @@ type StringLiteral struct {
 
 func parseStringLiteral(line string) *StringLiteral {
 	groups := groupsFromRegex(
-		`<(?P<position>.*)> '(?P<type>.*)'(?P<lvalue> lvalue)? L?(?P<value>".*")`,
+		`<(?P<position>.*)> '(?P<type>.*)'(?P<lvalue> lvalue)? (?P<prefix>L|u8|u|U)?(?P<value>".*")`,
 		line,
 	)
 
-	s, err := strconv.Unquote(groups["value"])
+	s, err := unquoteCString(groups["value"])
 	if err != nil {
-		panic(fmt.Sprintf("Unable to unquote %s\n", groups["value"]))
+		panic(fmt.Sprintf("Unable to unquote %s : %v\n", groups["value"], err))
 	}
 
 	return &StringLiteral{
 		Addr:       ParseAddress(groups["address"]),
 		Pos:        NewPositionFromString(groups["position"]),
 		Type:       groups["type"],
+		Prefix:     groups["prefix"],
 		Value:      s,
 		Lvalue:     len(groups["lvalue"]) > 0,
 		ChildNodes: []Node{},
@@ -58,3 +67,94 @@ func (n *StringLiteral) Children() []Node {
 func (n *StringLiteral) Position() Position {
 	return n.Pos
 }
+
+// unquoteCString decodes a quoted C string literal. The escape sequences of C
+// are not the same as the ones of Go:
+//
+//     \0 \12 \101   octal escapes have one to three digits
+//     \x41 \x4F60   hex escapes have any number of digits
+//     \? \' \e      are a question mark, a quote and the escape character
+//
+// A hex escape or universal character name that does not fit in a byte is
+// encoded as UTF-8. The string may contain null characters.
+func unquoteCString(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string literal: %s", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("unfinished escape sequence at the end of: %s", s)
+		}
+
+		switch c := s[i]; c {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'e', 'E':
+			b.WriteByte(0x1b)
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '\'', '"', '?':
+			b.WriteByte(c)
+
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			end := i + 1
+			for end < len(s) && end < i+3 && s[end] >= '0' && s[end] <= '7' {
+				end++
+			}
+			v, _ := strconv.ParseUint(s[i:end], 8, 16)
+			b.WriteByte(byte(v))
+			i = end - 1
+
+		case 'x', 'u', 'U':
+			digits := map[byte]int{'x': len(s), 'u': 4, 'U': 8}[c]
+			end := i + 1
+			for end < len(s) && end <= i+digits && isHexDigit(s[end]) {
+				end++
+			}
+			if end == i+1 || (c != 'x' && end != i+1+digits) {
+				return "", fmt.Errorf("invalid escape sequence \\%s in: %s", s[i:end], s)
+			}
+			v, err := strconv.ParseUint(s[i+1:end], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence \\%s in: %s", s[i:end], s)
+			}
+			if c == 'x' && v <= 0xff {
+				b.WriteByte(byte(v))
+			} else {
+				var r [utf8.UTFMax]byte
+				b.Write(r[:utf8.EncodeRune(r[:], rune(v))])
+			}
+			i = end - 1
+
+		default:
+			// An unknown escape sequence is the character itself, like in
+			// clang and GCC.
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
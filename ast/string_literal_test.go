@@ -34,11 +34,68 @@ func TestStringLiteral(t *testing.T) {
 			Addr:       0x61b80c8,
 			Pos:        NewPositionFromString("col:19"),
 			Type:       "wchar_t [21]",
+			Prefix:     "L",
 			Lvalue:     true,
-			Value:      "hello$$\x4F60\x597D\242\242\x4E16\x754C\x20AC\x20ACworld",
+			Value:      "hello$$你好\242\242世界€€world",
+			ChildNodes: []Node{},
+		},
+		`0x61b80e0 <col:20> 'unsigned short [3]' lvalue u"\u00e9t"`: &StringLiteral{
+			Addr:       0x61b80e0,
+			Pos:        NewPositionFromString("col:20"),
+			Type:       "unsigned short [3]",
+			Prefix:     "u",
+			Lvalue:     true,
+			Value:      "ét",
+			ChildNodes: []Node{},
+		},
+		`0x22ac560 <col:14> 'char [9]' lvalue "ab\x00-cd\0e"`: &StringLiteral{
+			Addr:       0x22ac560,
+			Pos:        NewPositionFromString("col:14"),
+			Type:       "char [9]",
+			Lvalue:     true,
+			Value:      "ab\x00-cd\x00e",
+			ChildNodes: []Node{},
+		},
+		`0x22ac578 <col:14> 'char [9]' lvalue "\101\12\0\1777\?\'\e"`: &StringLiteral{
+			Addr:       0x22ac578,
+			Pos:        NewPositionFromString("col:14"),
+			Type:       "char [9]",
+			Lvalue:     true,
+			Value:      "A\n\x00\x7f7?'\x1b",
 			ChildNodes: []Node{},
 		},
 	}
 
 	runNodeTests(t, nodes)
 }
+
+func TestUnquoteCString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{`""`, "", false},
+		{`"a\"b\\c"`, "a\"b\\c", false},
+		{`"\x41\x4a"`, "AJ", false},
+		{`"\x00\x01x"`, "\x00\x01x", false},
+		{`"\x00cd"`, "\xcd", false},
+		{`"\u00e9\U0001F600"`, "é😀", false},
+		{`"\q"`, "q", false},
+		{`"\x"`, "", true},
+		{`"\u12"`, "", true},
+		{`"abc\"`, "", true},
+		{`abc`, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := unquoteCString(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
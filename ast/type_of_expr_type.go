@@ -0,0 +1,47 @@
+package ast
+
+// TypeOfExprType is the type of a typeof(expression). Its children are the
+// expression and the type that it stands for.
+type TypeOfExprType struct {
+	Addr       Address
+	Type       string
+	Tags       string
+	ChildNodes []Node
+}
+
+func parseTypeOfExprType(line string) *TypeOfExprType {
+	groups := groupsFromRegex(
+		"'(?P<type>.*)' (?P<tags>.+)",
+		line,
+	)
+
+	return &TypeOfExprType{
+		Addr:       ParseAddress(groups["address"]),
+		Type:       groups["type"],
+		Tags:       groups["tags"],
+		ChildNodes: []Node{},
+	}
+}
+
+// AddChild adds a new child node. Child nodes can then be accessed with the
+// Children attribute.
+func (n *TypeOfExprType) AddChild(node Node) {
+	n.ChildNodes = append(n.ChildNodes, node)
+}
+
+// Address returns the numeric address of the node. See the documentation for
+// the Address type for more information.
+func (n *TypeOfExprType) Address() Address {
+	return n.Addr
+}
+
+// Children returns the child nodes. If this node does not have any children or
+// this node does not support children it will always return an empty slice.
+func (n *TypeOfExprType) Children() []Node {
+	return n.ChildNodes
+}
+
+// Position returns the position in the original source code.
+func (n *TypeOfExprType) Position() Position {
+	return Position{}
+}
@@ -0,0 +1,18 @@
+package ast
+
+import (
+	"testing"
+)
+
+func TestTypeOfExprType(t *testing.T) {
+	nodes := map[string]Node{
+		`0x55d4e5e3c2a0 'typeof (a)' sugar`: &TypeOfExprType{
+			Addr:       0x55d4e5e3c2a0,
+			Type:       "typeof (a)",
+			Tags:       "sugar",
+			ChildNodes: []Node{},
+		},
+	}
+
+	runNodeTests(t, nodes)
+}
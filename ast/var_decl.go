@@ -7,6 +7,7 @@ import (
 // VarDecl is node represents a variable declaration.
 type VarDecl struct {
 	Addr         Address
+	Prev         string
 	Parent       Address
 	Pos          Position
 	Position2    string
@@ -35,9 +36,9 @@ func parseVarDecl(line string) *VarDecl {
 		(?P<type2>:'.*?')?
 		(?P<extern> extern)?
 		(?P<static> static)?
+		(?P<register> register)?
 		(?P<nrvo> nrvo)?
 		(?P<cinit> cinit)?
-		(?P<register> register)?
 		`,
 		line,
 	)
@@ -49,6 +50,7 @@ func parseVarDecl(line string) *VarDecl {
 
 	return &VarDecl{
 		Addr:         ParseAddress(groups["address"]),
+		Prev:         groups["prev"],
 		Parent:       ParseAddress(groups["parent"]),
 		Pos:          NewPositionFromString(groups["position"]),
 		Position2:    strings.TrimSpace(groups["position2"]),
@@ -6,6 +6,24 @@ import (
 
 func TestVarDecl(t *testing.T) {
 	nodes := map[string]Node{
+		// The type of typeof() is replaced by the type that it stands for.
+		`0x55d4e5e3c3b8 <col:3, col:19> col:13 used b 'typeof (*a) *':'int *' cinit`: &VarDecl{
+			Addr:         0x55d4e5e3c3b8,
+			Pos:          NewPositionFromString("col:3, col:19"),
+			Position2:    "col:13",
+			Name:         "b",
+			Type:         "int *",
+			Type2:        "",
+			IsExtern:     false,
+			IsUsed:       true,
+			IsNRVO:       false,
+			IsCInit:      true,
+			IsReferenced: false,
+			IsStatic:     false,
+			IsRegister:   false,
+			Parent:       0,
+			ChildNodes:   []Node{},
+		},
 		`0x7fd5e90e5a00 <col:14> col:17 'int'`: &VarDecl{
 			Addr:         0x7fd5e90e5a00,
 			Pos:          NewPositionFromString("col:14"),
@@ -142,6 +160,23 @@ func TestVarDecl(t *testing.T) {
 			Parent:       0,
 			ChildNodes:   []Node{},
 		},
+		`0x55772c7774e0 <col:3, col:20> col:16 used n 'int' register cinit`: &VarDecl{
+			Addr:         0x55772c7774e0,
+			Pos:          NewPositionFromString("col:3, col:20"),
+			Position2:    "col:16",
+			Name:         "n",
+			Type:         "int",
+			Type2:        "",
+			IsExtern:     false,
+			IsUsed:       true,
+			IsNRVO:       false,
+			IsCInit:      true,
+			IsReferenced: false,
+			IsStatic:     false,
+			IsRegister:   true,
+			Parent:       0,
+			ChildNodes:   []Node{},
+		},
 		`0x26fd180 <col:4, col:32> col:13 used aExt 'extCoord':'extCoord' cinit`: &VarDecl{
 			Addr:         0x26fd180,
 			Pos:          NewPositionFromString("col:4, col:32"),
@@ -176,6 +211,23 @@ func TestVarDecl(t *testing.T) {
 			Parent:       0x7f985e0246d0,
 			ChildNodes:   []Node{},
 		},
+		`0x5632e9eb7f88 prev 0x5632e9eb7e40 <line:3:1, col:9> col:5 used g 'int' cinit`: &VarDecl{
+			Addr:         0x5632e9eb7f88,
+			Prev:         "0x5632e9eb7e40",
+			Pos:          NewPositionFromString("line:3:1, col:9"),
+			Position2:    "col:5",
+			Name:         "g",
+			Type:         "int",
+			Type2:        "",
+			IsExtern:     false,
+			IsUsed:       true,
+			IsNRVO:       false,
+			IsCInit:      true,
+			IsReferenced: false,
+			IsStatic:     false,
+			IsRegister:   false,
+			ChildNodes:   []Node{},
+		},
 		`0x55d681e87428 <col:5, col:23> col:23 used variable 'union programming':'union programming' nrvo`: &VarDecl{
 			Addr:         0x55d681e87428,
 			Pos:          NewPositionFromString("col:5, col:23"),
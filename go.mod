@@ -1,3 +1,5 @@
 module github.com/elliotchance/c2go
 
+go 1.27.1
+
 require golang.org/x/tools v0.0.0-20181109182537-4e34152f1676
@@ -19,6 +19,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 
@@ -43,12 +44,73 @@ var stderr io.Writer = os.Stderr
 // modify any specific attributes.
 type ProgramArgs struct {
 	verbose     bool
+	summary     bool
 	ast         bool
 	inputFiles  []string
 	clangFlags  []string
 	outputFile  string
 	packageName string
 
+	// The name of the target ABI for sizeof and the integer types, see
+	// program.GetABI().
+	abi string
+
+	// The default maximum alignment of the fields of structs in bytes, see
+	// program.Program.Pack.
+	pack int
+
+	// Read and write volatile integers with sync/atomic, see
+	// program.Program.VolatileAtomic.
+	volatileAtomic bool
+
+	// Rewrite self-recursive tail calls into loops, see
+	// program.Program.TailCalls.
+	tailCalls bool
+
+	// Inline the small inline functions at their calls, see
+	// program.Program.InlineFunctions.
+	inlineFunctions bool
+
+	// Pass the read-only C strings as Go strings, see
+	// program.Program.StringParameters.
+	stringParameters bool
+
+	// Check the signed integer arithmetic for overflows at run time, see
+	// program.Program.CheckedOverflow.
+	checkedOverflow bool
+
+	// Transpile the integer macros into constants, see
+	// program.Program.Macros.
+	macroConsts bool
+
+	// How the members of a union share their memory, see
+	// program.UnionMemoryArray.
+	unionMemory string
+
+	// The mappings of macros to Go build constraints, like "__linux__=linux".
+	// See preprocessor.BuildConstraint().
+	buildTags []string
+
+	// The C functions that are transpiled into methods of the struct of their
+	// first parameter, see program.Program.MethodFunctions.
+	methodFunctions []string
+
+	// The regular expressions of the C functions that are exported from the
+	// Go package, see program.Program.ExportFunctions.
+	exportFunctions []string
+
+	// Write each transpiled function into a file of its own next to the
+	// output file, see program.Program.SplitFiles.
+	splitFunctions bool
+
+	// Attach the location in the C code to each declaration, see
+	// program.Program.LineComments.
+	lineComments bool
+
+	// Only report the constructs of the C code that cannot be transpiled,
+	// without writing the Go code. See program.Program.Messages().
+	dryRun bool
+
 	// A private option to output the Go as a *_test.go file.
 	outputAsTest bool
 }
@@ -59,6 +121,8 @@ func DefaultProgramArgs() ProgramArgs {
 		verbose:      false,
 		ast:          false,
 		packageName:  "main",
+		abi:          program.LP64.Name,
+		unionMemory:  program.UnionMemoryArray,
 		clangFlags:   []string{},
 		outputAsTest: false,
 	}
@@ -177,6 +241,22 @@ func Start(args ProgramArgs) (err error) {
 		fmt.Println("Start tanspiling ...")
 	}
 
+	abi, err := program.GetABI(args.abi)
+	if err != nil {
+		return err
+	}
+
+	if args.pack < 0 || args.pack&(args.pack-1) != 0 {
+		return fmt.Errorf("the packing %d must be a power of 2", args.pack)
+	}
+
+	switch args.unionMemory {
+	case program.UnionMemoryArray, program.UnionMemoryPointer:
+	default:
+		return fmt.Errorf("unknown union memory %q, it must be %s or %s",
+			args.unionMemory, program.UnionMemoryArray, program.UnionMemoryPointer)
+	}
+
 	// 1. Compile it first (checking for errors)
 	for _, in := range args.inputFiles {
 		_, err := os.Stat(in)
@@ -239,8 +319,43 @@ func Start(args ProgramArgs) (err error) {
 
 	p := program.NewProgram()
 	p.Verbose = args.verbose
+	p.FunctionMessageSummary = args.summary
 	p.OutputAsTest = args.outputAsTest
+	p.LineComments = args.lineComments
+	p.ABI = abi
+	p.Pack = args.pack
+	p.VolatileAtomic = args.volatileAtomic
+	p.TailCalls = args.tailCalls
+	p.InlineFunctions = args.inlineFunctions
+	p.StringParameters = args.stringParameters
+	p.CheckedOverflow = args.checkedOverflow
+	p.UnionMemory = args.unionMemory
+	p.Defines = preprocessor.UserDefines(args.clangFlags)
+	p.MethodFunctions = args.methodFunctions
+	for _, pattern := range args.exportFunctions {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid -export %s: %v", pattern, err)
+		}
+	}
+	p.ExportFunctions = args.exportFunctions
+	if len(args.buildTags) > 0 {
+		defines, err := preprocessor.GetDefines(args.clangFlags)
+		if err != nil {
+			return err
+		}
+		p.BuildConstraint, p.PlusBuild, err = preprocessor.BuildConstraint(args.buildTags, defines)
+		if err != nil {
+			return err
+		}
+	}
+	if args.macroConsts {
+		p.Macros, err = preprocessor.GetMacros(args.inputFiles, args.clangFlags)
+		if err != nil {
+			return err
+		}
+	}
 	p.Comments = comments
+	p.SourceLines = preprocessor.SourceLines(pp)
 	p.IncludeHeaders = includes
 
 	// Converting to nodes
@@ -300,18 +415,31 @@ func Start(args ProgramArgs) (err error) {
 		return fmt.Errorf("cannot transpile AST : %v", err)
 	}
 
+	if args.dryRun {
+		for _, message := range p.Messages() {
+			fmt.Println(message)
+		}
+		return nil
+	}
+
 	// write the output Go code
 	if args.verbose {
 		fmt.Println("Writing the output Go code...")
 	}
-	err = ioutil.WriteFile(outputFilePath, []byte(p.String()), 0644)
-	if err != nil {
-		return fmt.Errorf("writing Go output file failed: %v", err)
+	files := map[string]string{outputFilePath: p.String()}
+	if args.splitFunctions {
+		files = p.SplitFiles(outputFilePath)
 	}
+	for filePath, src := range files {
+		err = ioutil.WriteFile(filePath, []byte(src), 0644)
+		if err != nil {
+			return fmt.Errorf("writing Go output file failed: %v", err)
+		}
 
-	// simplify Go code by `gofmt`
-	// error ignored, because it is not change the workflow
-	_, _ = exec.Command("gofmt", "-w", outputFilePath).Output()
+		// simplify Go code by `gofmt`
+		// error ignored, because it is not change the workflow
+		_, _ = exec.Command("gofmt", "-w", filePath).Output()
+	}
 
 	return nil
 }
@@ -331,18 +459,37 @@ func (i *inputDataFlags) Set(value string) error {
 }
 
 var clangFlags inputDataFlags
+var buildTagFlags inputDataFlags
+var methodFlags inputDataFlags
+var exportFlags inputDataFlags
 
 func init() {
 	transpileCommand.Var(&clangFlags, "clang-flag", "Pass arguments to clang. You may provide multiple -clang-flag items.")
 	astCommand.Var(&clangFlags, "clang-flag", "Pass arguments to clang. You may provide multiple -clang-flag items.")
+	transpileCommand.Var(&buildTagFlags, "build-tag", "Add a Go build constraint when a macro is defined, like __linux__=linux. You may provide multiple -build-tag items.")
+	transpileCommand.Var(&methodFlags, "method", "Transpile a C function into a method of the struct of its first parameter. You may provide multiple -method items.")
+	transpileCommand.Var(&exportFlags, "export", "Export the C functions that match a regular expression from the Go package. You may provide multiple -export items.")
 }
 
 var (
 	versionFlag       = flag.Bool("v", false, "print the version and exit")
 	transpileCommand  = flag.NewFlagSet("transpile", flag.ContinueOnError)
 	verboseFlag       = transpileCommand.Bool("V", false, "print progress as comments")
+	summaryFlag       = transpileCommand.Bool("s", false, "add the warnings of each function to its comment")
 	outputFlag        = transpileCommand.String("o", "", "output Go generated code to the specified file")
 	packageFlag       = transpileCommand.String("p", "main", "set the name of the generated package")
+	abiFlag           = transpileCommand.String("abi", program.LP64.Name, "set the ABI of the target platform for sizeof and the width of long: "+strings.Join(program.ABINames(), ", "))
+	packFlag          = transpileCommand.Int("pack", 0, "set the maximum alignment of struct fields in bytes, like #pragma pack(n)")
+	volatileFlag      = transpileCommand.Bool("volatile-atomic", false, "read and write volatile integers with sync/atomic")
+	tailCallsFlag     = transpileCommand.Bool("tail-calls", false, "rewrite the self-recursive tail calls of functions into loops")
+	inlineFlag        = transpileCommand.Bool("inline", false, "inline the inline functions that only return an expression at their calls")
+	stringParamsFlag  = transpileCommand.Bool("string-params", false, "transpile the const char * parameters that are only read into Go strings")
+	checkedFlag       = transpileCommand.Bool("checked-overflow", false, "warn at run time when the signed integer arithmetic overflows")
+	macroConstsFlag   = transpileCommand.Bool("macro-consts", false, "transpile the integer macros of the C files into constants")
+	lineCommentsFlag  = transpileCommand.Bool("line-comments", false, "add the file and the line of the C code to the comment of each declaration")
+	dryRunFlag        = transpileCommand.Bool("dry-run", false, "print the warnings about the C code that cannot be transpiled without writing the Go code")
+	splitFlag         = transpileCommand.Bool("split-functions", false, "write each transpiled function into its own file next to the output file")
+	unionFlag         = transpileCommand.String("union", program.UnionMemoryArray, "set the memory of unions: "+program.UnionMemoryArray+" or "+program.UnionMemoryPointer)
 	transpileHelpFlag = transpileCommand.Bool("h", false, "print help information")
 	astCommand        = flag.NewFlagSet("ast", flag.ContinueOnError)
 	astHelpFlag       = astCommand.Bool("h", false, "print help information")
@@ -411,7 +558,7 @@ func runCommand() int {
 		}
 
 		if *transpileHelpFlag || transpileCommand.NArg() == 0 {
-			fmt.Fprintf(stderr, "Usage: %s transpile [-V] [-o file.go] [-p package] file1.c ...\n", os.Args[0])
+			fmt.Fprintf(stderr, "Usage: %s transpile [-V] [-s] [-o file.go] [-p package] [-method name] [-export regexp] [-abi name] [-pack n] [-volatile-atomic] [-tail-calls] [-inline] [-string-params] [-checked-overflow] [-macro-consts] [-line-comments] [-split-functions] [-dry-run] [-union memory] [-build-tag macro=constraint] file1.c ...\n", os.Args[0])
 			transpileCommand.PrintDefaults()
 			return 1
 		}
@@ -419,8 +566,24 @@ func runCommand() int {
 		args.inputFiles = transpileCommand.Args()
 		args.outputFile = *outputFlag
 		args.packageName = *packageFlag
+		args.abi = *abiFlag
+		args.pack = *packFlag
+		args.volatileAtomic = *volatileFlag
+		args.tailCalls = *tailCallsFlag
+		args.inlineFunctions = *inlineFlag
+		args.stringParameters = *stringParamsFlag
+		args.checkedOverflow = *checkedFlag
+		args.macroConsts = *macroConstsFlag
+		args.splitFunctions = *splitFlag
+		args.dryRun = *dryRunFlag
+		args.lineComments = *lineCommentsFlag
+		args.unionMemory = *unionFlag
 		args.verbose = *verboseFlag
+		args.summary = *summaryFlag
 		args.clangFlags = clangFlags
+		args.buildTags = buildTagFlags
+		args.methodFunctions = methodFlags
+		args.exportFunctions = exportFlags
 	default:
 		flag.Usage()
 		return 1
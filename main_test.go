@@ -359,6 +359,20 @@ func TestMultiFileTranspilation(t *testing.T) {
 			},
 			"42\n",
 		},
+		{
+			[]string{
+				"./tests/multi-extern/main.c",
+				"./tests/multi-extern/helper.c",
+			},
+			"13 2 131\n",
+		},
+		{
+			[]string{
+				"./tests/multi-static/main.c",
+				"./tests/multi-static/helper.c",
+			},
+			"20 201\n",
+		},
 	}
 
 	for pos, tc := range tcs {
@@ -323,3 +323,20 @@ func setCurrentErrno(errno int32) {
 func Errno() *int32 {
 	return &currentErrno
 }
+
+// ErrnoError is an errno value that is returned as a Go error.
+type ErrnoError int32
+
+// Error returns the same message as strerror().
+func (e ErrnoError) Error() string {
+	return CStringToString(Strerror(int32(e)))
+}
+
+// GetErrnoError returns the current errno as an error, or nil if errno is
+// zero.
+func GetErrnoError() error {
+	if currentErrno == 0 {
+		return nil
+	}
+	return ErrnoError(currentErrno)
+}
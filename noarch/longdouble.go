@@ -0,0 +1,187 @@
+package noarch
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// LongDoublePrecision is the number of mantissa bits of LongDouble. It is the
+// same precision as the 80-bit extended type used for long double on x86.
+const LongDoublePrecision = 64
+
+// LongDouble is a C "long double" that keeps more precision than float64. It
+// is only used when the transpiler is asked to (see Program.LongDoubleType),
+// otherwise long double is a float64.
+//
+// LongDouble is immutable, all of the operations return a new value. The zero
+// value is 0.
+type LongDouble struct {
+	f *big.Float
+}
+
+func newLongDouble() *big.Float {
+	return new(big.Float).SetPrec(LongDoublePrecision)
+}
+
+func (a LongDouble) float() *big.Float {
+	if a.f == nil {
+		return newLongDouble()
+	}
+	return a.f
+}
+
+// Add returns a + b.
+func (a LongDouble) Add(b LongDouble) LongDouble {
+	return LongDouble{newLongDouble().Add(a.float(), b.float())}
+}
+
+// Sub returns a - b.
+func (a LongDouble) Sub(b LongDouble) LongDouble {
+	return LongDouble{newLongDouble().Sub(a.float(), b.float())}
+}
+
+// Mul returns a * b.
+func (a LongDouble) Mul(b LongDouble) LongDouble {
+	return LongDouble{newLongDouble().Mul(a.float(), b.float())}
+}
+
+// Quo returns a / b.
+func (a LongDouble) Quo(b LongDouble) LongDouble {
+	return LongDouble{newLongDouble().Quo(a.float(), b.float())}
+}
+
+// Neg returns -a.
+func (a LongDouble) Neg() LongDouble {
+	return LongDouble{newLongDouble().Neg(a.float())}
+}
+
+// Cmp compares a and b and returns -1, 0 or +1 for a < b, a == b and a > b.
+func (a LongDouble) Cmp(b LongDouble) int {
+	return a.float().Cmp(b.float())
+}
+
+// String returns the value with the same formatting as %g.
+func (a LongDouble) String() string {
+	return a.float().Text('g', 10)
+}
+
+// Format implements fmt.Formatter so that the values can be printed with the
+// floating-point verbs of printf().
+func (a LongDouble) Format(s fmt.State, verb rune) {
+	a.float().Format(s, verb)
+}
+
+// Float64ToLongDouble converts a float64 to a LongDouble.
+func Float64ToLongDouble(x float64) LongDouble {
+	return LongDouble{newLongDouble().SetFloat64(x)}
+}
+
+// Float32ToLongDouble converts a float32 to a LongDouble.
+func Float32ToLongDouble(x float32) LongDouble {
+	return Float64ToLongDouble(float64(x))
+}
+
+// Int64ToLongDouble converts an int64 to a LongDouble.
+func Int64ToLongDouble(x int64) LongDouble {
+	return LongDouble{newLongDouble().SetInt64(x)}
+}
+
+// Int32ToLongDouble converts an int32 to a LongDouble.
+func Int32ToLongDouble(x int32) LongDouble {
+	return Int64ToLongDouble(int64(x))
+}
+
+// Int16ToLongDouble converts an int16 to a LongDouble.
+func Int16ToLongDouble(x int16) LongDouble {
+	return Int64ToLongDouble(int64(x))
+}
+
+// Int8ToLongDouble converts an int8 to a LongDouble.
+func Int8ToLongDouble(x int8) LongDouble {
+	return Int64ToLongDouble(int64(x))
+}
+
+// Uint64ToLongDouble converts an uint64 to a LongDouble.
+func Uint64ToLongDouble(x uint64) LongDouble {
+	return LongDouble{newLongDouble().SetUint64(x)}
+}
+
+// Uint32ToLongDouble converts an uint32 to a LongDouble.
+func Uint32ToLongDouble(x uint32) LongDouble {
+	return Uint64ToLongDouble(uint64(x))
+}
+
+// Uint16ToLongDouble converts an uint16 to a LongDouble.
+func Uint16ToLongDouble(x uint16) LongDouble {
+	return Uint64ToLongDouble(uint64(x))
+}
+
+// ByteToLongDouble converts a byte to a LongDouble.
+func ByteToLongDouble(x byte) LongDouble {
+	return Uint64ToLongDouble(uint64(x))
+}
+
+// LongDoubleToFloat64 converts a LongDouble to the nearest float64.
+func LongDoubleToFloat64(x LongDouble) float64 {
+	f, _ := x.float().Float64()
+	return f
+}
+
+// LongDoubleToFloat32 converts a LongDouble to the nearest float32.
+func LongDoubleToFloat32(x LongDouble) float32 {
+	f, _ := x.float().Float32()
+	return f
+}
+
+// LongDoubleToInt64 converts a LongDouble to an int64. The fraction is
+// truncated like it is in C.
+func LongDoubleToInt64(x LongDouble) int64 {
+	i, _ := x.float().Int64()
+	return i
+}
+
+// LongDoubleToInt32 converts a LongDouble to an int32.
+func LongDoubleToInt32(x LongDouble) int32 {
+	return int32(LongDoubleToInt64(x))
+}
+
+// LongDoubleToInt16 converts a LongDouble to an int16.
+func LongDoubleToInt16(x LongDouble) int16 {
+	return int16(LongDoubleToInt64(x))
+}
+
+// LongDoubleToInt8 converts a LongDouble to an int8.
+func LongDoubleToInt8(x LongDouble) int8 {
+	return int8(LongDoubleToInt64(x))
+}
+
+// LongDoubleToUint64 converts a LongDouble to an uint64.
+func LongDoubleToUint64(x LongDouble) uint64 {
+	i, _ := x.float().Uint64()
+	return i
+}
+
+// LongDoubleToUint32 converts a LongDouble to an uint32.
+func LongDoubleToUint32(x LongDouble) uint32 {
+	return uint32(LongDoubleToUint64(x))
+}
+
+// LongDoubleToUint16 converts a LongDouble to an uint16.
+func LongDoubleToUint16(x LongDouble) uint16 {
+	return uint16(LongDoubleToUint64(x))
+}
+
+// LongDoubleToByte converts a LongDouble to a byte.
+func LongDoubleToByte(x LongDouble) byte {
+	return byte(LongDoubleToUint64(x))
+}
+
+// LongDoubleToBool returns true if the value is not zero.
+func LongDoubleToBool(x LongDouble) bool {
+	return x.float().Sign() != 0
+}
+
+// BoolToLongDouble converts true to 1 and false to 0.
+func BoolToLongDouble(x bool) LongDouble {
+	return Int32ToLongDouble(BoolToInt(x))
+}
@@ -0,0 +1,34 @@
+package noarch
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLongDouble(t *testing.T) {
+	three := Int32ToLongDouble(3)
+	third := Int32ToLongDouble(1).Quo(three)
+
+	if got := LongDoubleToFloat64(third.Mul(three)); got != 1 {
+		t.Errorf("1/3*3 = %v, want 1", got)
+	}
+
+	// 2^60 + 1 cannot be represented by a float64.
+	big := Int64ToLongDouble(1 << 60).Add(Int32ToLongDouble(1))
+	if got := LongDoubleToInt64(big.Sub(Int64ToLongDouble(1 << 60))); got != 1 {
+		t.Errorf("(2^60 + 1) - 2^60 = %v, want 1", got)
+	}
+
+	if three.Cmp(third) <= 0 {
+		t.Errorf("3 > 1/3 is false")
+	}
+	if got := LongDoubleToInt32(three.Neg()); got != -3 {
+		t.Errorf("-3 = %v", got)
+	}
+	if LongDoubleToBool(LongDouble{}) {
+		t.Errorf("zero value is not 0")
+	}
+	if got := fmt.Sprintf("%.3f", Float64ToLongDouble(2.5)); got != "2.500" {
+		t.Errorf("formatted as %s", got)
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"math"
 )
 
+// Signbitf returns 1 if the sign of x is negative, including -0 and -NaN.
 func Signbitf(x float32) int32 {
 	return BoolToInt(math.Signbit(float64(x)))
 }
 
+// Signbitd is Signbitf for a double.
 func Signbitd(x float64) int32 {
 	return BoolToInt(math.Signbit(x))
 }
 
+// Signbitl is Signbitf for a long double.
 func Signbitl(x float64) int32 {
 	return BoolToInt(math.Signbit(x))
 }
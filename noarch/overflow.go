@@ -0,0 +1,75 @@
+package noarch
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// overflowOutput is where the warnings of the checked arithmetic are written.
+var overflowOutput io.Writer = os.Stderr
+
+// The checked functions are used for the signed integer arithmetic when c2go
+// is run with -checked-overflow. A signed overflow is undefined behaviour in
+// C, so a warning is printed when the result overflows. The result wraps
+// around like in Go.
+
+// CheckedAddInt32 returns a + b.
+func CheckedAddInt32(a, b int32) int32 {
+	r := a + b
+	if (r > a) != (b > 0) {
+		overflowWarning(int64(a), "+", int64(b))
+	}
+	return r
+}
+
+// CheckedSubInt32 returns a - b.
+func CheckedSubInt32(a, b int32) int32 {
+	r := a - b
+	if (r < a) != (b > 0) {
+		overflowWarning(int64(a), "-", int64(b))
+	}
+	return r
+}
+
+// CheckedMulInt32 returns a * b.
+func CheckedMulInt32(a, b int32) int32 {
+	r := int64(a) * int64(b)
+	if r != int64(int32(r)) {
+		overflowWarning(int64(a), "*", int64(b))
+	}
+	return int32(r)
+}
+
+// CheckedAddInt64 returns a + b.
+func CheckedAddInt64(a, b int64) int64 {
+	r := a + b
+	if (r > a) != (b > 0) {
+		overflowWarning(a, "+", b)
+	}
+	return r
+}
+
+// CheckedSubInt64 returns a - b.
+func CheckedSubInt64(a, b int64) int64 {
+	r := a - b
+	if (r < a) != (b > 0) {
+		overflowWarning(a, "-", b)
+	}
+	return r
+}
+
+// CheckedMulInt64 returns a * b.
+func CheckedMulInt64(a, b int64) int64 {
+	r := a * b
+	if a != 0 && (r/a != b || a == -1 && b == math.MinInt64) {
+		overflowWarning(a, "*", b)
+	}
+	return r
+}
+
+func overflowWarning(a int64, operator string, b int64) {
+	fmt.Fprintf(overflowOutput, "signed integer overflow: %d %s %d\n",
+		a, operator, b)
+}
@@ -0,0 +1,54 @@
+package noarch
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestCheckedArithmetic(t *testing.T) {
+	var buf bytes.Buffer
+	output := overflowOutput
+	overflowOutput = &buf
+	defer func() {
+		overflowOutput = output
+	}()
+
+	tests := []struct {
+		name     string
+		got      int64
+		want     int64
+		overflow bool
+	}{
+		{"add", int64(CheckedAddInt32(2, 3)), 5, false},
+		{"add negative", int64(CheckedAddInt32(-2, -3)), -5, false},
+		{"add overflow", int64(CheckedAddInt32(math.MaxInt32, 1)), math.MinInt32, true},
+		{"sub overflow", int64(CheckedSubInt32(math.MinInt32, 1)), math.MaxInt32, true},
+		{"sub", int64(CheckedSubInt32(-1, math.MaxInt32)), math.MinInt32, false},
+		{"mul overflow", int64(CheckedMulInt32(65536, 65536)), 0, true},
+		{"mul", int64(CheckedMulInt32(-65536, 32768)), math.MinInt32, false},
+		{"add 64", CheckedAddInt64(math.MaxInt64, -1), math.MaxInt64 - 1, false},
+		{"add 64 overflow", CheckedAddInt64(math.MaxInt64, 1), math.MinInt64, true},
+		{"sub 64 overflow", CheckedSubInt64(0, math.MinInt64), math.MinInt64, true},
+		{"mul 64 overflow", CheckedMulInt64(-1, math.MinInt64), math.MinInt64, true},
+		{"mul 64", CheckedMulInt64(-1, math.MaxInt64), -math.MaxInt64, false},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, tt.got)
+		}
+	}
+
+	var warnings int
+	for _, tt := range tests {
+		if tt.overflow {
+			warnings++
+		}
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("signed integer overflow")); n != warnings {
+		t.Errorf("Expected %d warnings, got %d:\n%s", warnings, n, buf.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("signed integer overflow: 2147483647 + 1\n")) {
+		t.Errorf("Unexpected warnings:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,506 @@
+package noarch
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// formatSpec is a conversion specification of a printf() format string, like
+// "%-8.3ld". The conversions of C cannot be passed to the fmt package because
+// they are different from its verbs: "%ld", "%u" and "%c" are not verbs of the
+// fmt package and "%x" of a negative int prints a sign, for example.
+type formatSpec struct {
+	minus, plus, space, hash, zero bool
+
+	width int
+
+	// precision is -1 when it is not given.
+	precision int
+
+	// length is the length modifier, like "hh" or "l".
+	length string
+
+	verb byte
+}
+
+// formatArgs returns the result of printf() for a C format string. The
+// arguments of a va_list are passed as a nested []interface{}.
+func formatArgs(format *byte, args []interface{}) []byte {
+	return formatString(CStringToString(format), flattenArgs(args))
+}
+
+func flattenArgs(args []interface{}) (result []interface{}) {
+	for _, arg := range args {
+		if a, ok := arg.([]interface{}); ok {
+			result = append(result, flattenArgs(a)...)
+			continue
+		}
+		result = append(result, arg)
+	}
+	return
+}
+
+// formatString formats the arguments like printf(). A conversion that does not
+// have an argument, or that is not known, is printed unchanged.
+func formatString(format string, args []interface{}) []byte {
+	var out []byte
+	nextArg := func() (interface{}, bool) {
+		if len(args) == 0 {
+			return nil, false
+		}
+		arg := args[0]
+		args = args[1:]
+		return arg, true
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out = append(out, format[i])
+			continue
+		}
+		start := i
+		i++
+
+		spec := formatSpec{precision: -1}
+	flags:
+		for ; i < len(format); i++ {
+			switch format[i] {
+			case '-':
+				spec.minus = true
+			case '+':
+				spec.plus = true
+			case ' ':
+				spec.space = true
+			case '#':
+				spec.hash = true
+			case '0':
+				spec.zero = true
+			default:
+				break flags
+			}
+		}
+
+		if i < len(format) && format[i] == '*' {
+			i++
+			if arg, ok := nextArg(); ok {
+				spec.width = intArg(arg)
+			}
+			if spec.width < 0 {
+				spec.minus = true
+				spec.width = -spec.width
+			}
+		} else {
+			spec.width, i = parseDigits(format, i)
+		}
+
+		if i < len(format) && format[i] == '.' {
+			i++
+			if i < len(format) && format[i] == '*' {
+				i++
+				spec.precision = 0
+				if arg, ok := nextArg(); ok {
+					spec.precision = intArg(arg)
+				}
+				// A negative precision is taken as if it was not given.
+				if spec.precision < 0 {
+					spec.precision = -1
+				}
+			} else {
+				spec.precision, i = parseDigits(format, i)
+			}
+		}
+
+		for _, length := range []string{"hh", "ll", "h", "l", "L", "q", "j", "z", "t"} {
+			if strings.HasPrefix(format[i:], length) {
+				spec.length = length
+				i += len(length)
+				break
+			}
+		}
+
+		if i >= len(format) {
+			out = append(out, format[start:]...)
+			break
+		}
+		spec.verb = format[i]
+
+		if spec.verb == '%' {
+			out = append(out, '%')
+			continue
+		}
+		if strings.IndexByte("diouxXfFeEgGaAcspn", spec.verb) < 0 {
+			out = append(out, format[start:i+1]...)
+			continue
+		}
+
+		arg, ok := nextArg()
+		if !ok {
+			out = append(out, format[start:i+1]...)
+			continue
+		}
+
+		switch spec.verb {
+		case 'd', 'i', 'o', 'u', 'x', 'X':
+			out = append(out, spec.formatInteger(arg)...)
+		case 'f', 'F', 'e', 'E', 'g', 'G', 'a', 'A':
+			out = append(out, spec.formatFloat(arg)...)
+		case 'c':
+			out = append(out, spec.formatChar(arg)...)
+		case 's':
+			out = append(out, spec.formatString(arg)...)
+		case 'p':
+			out = append(out, spec.formatPointer(arg)...)
+		case 'n':
+			storeCount(arg, len(out))
+		}
+	}
+
+	return out
+}
+
+func parseDigits(s string, i int) (int, int) {
+	n := 0
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		n = n*10 + int(s[i]-'0')
+	}
+	return n, i
+}
+
+// pad pads the prefix (the sign or "0x") and the digits to the width. Zeros
+// are inserted between the prefix and the digits with the "0" flag, when
+// zeroAllowed is true.
+func (s formatSpec) pad(prefix, digits string, zeroAllowed bool) []byte {
+	n := s.width - len(prefix) - len(digits)
+	switch {
+	case n <= 0:
+		return []byte(prefix + digits)
+	case s.minus:
+		return []byte(prefix + digits + strings.Repeat(" ", n))
+	case s.zero && zeroAllowed:
+		return []byte(prefix + strings.Repeat("0", n) + digits)
+	default:
+		return []byte(strings.Repeat(" ", n) + prefix + digits)
+	}
+}
+
+// integerBits returns the bits of an integer argument and the size of its type
+// in bits. The signed and the unsigned conversions reinterpret the bits, like
+// C does when "%u" is used for an int.
+func integerBits(arg interface{}) (uint64, int) {
+	if ld, ok := arg.(LongDouble); ok {
+		return uint64(LongDoubleToInt64(ld)), 64
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int()), v.Type().Bits()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), v.Type().Bits()
+	case reflect.Float32, reflect.Float64:
+		return uint64(int64(v.Float())), 64
+	case reflect.Bool:
+		if v.Bool() {
+			return 1, 32
+		}
+		return 0, 32
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Slice, reflect.Func,
+		reflect.Map, reflect.Chan:
+		return uint64(v.Pointer()), 64
+	}
+	return 0, 32
+}
+
+// intArg returns the value of an int argument, like the width "*".
+func intArg(arg interface{}) int {
+	v, bits := integerBits(arg)
+	shift := uint(64 - bits)
+	return int(int64(v<<shift) >> shift)
+}
+
+func (s formatSpec) formatInteger(arg interface{}) []byte {
+	v, bits := integerBits(arg)
+	switch s.length {
+	case "hh":
+		bits = 8
+	case "h":
+		bits = 16
+	}
+	shift := uint(64 - bits)
+
+	var negative bool
+	if s.verb == 'd' || s.verb == 'i' {
+		signed := int64(v<<shift) >> shift
+		negative = signed < 0
+		v = uint64(signed)
+		if negative {
+			v = -v
+		}
+	} else {
+		v = v << shift >> shift
+	}
+
+	base := 10
+	switch s.verb {
+	case 'o':
+		base = 8
+	case 'x', 'X':
+		base = 16
+	}
+	digits := strconv.FormatUint(v, base)
+	if s.verb == 'X' {
+		digits = strings.ToUpper(digits)
+	}
+
+	// The precision is the minimum number of digits. Zero with a precision of
+	// 0 has no digits at all.
+	if s.precision == 0 && v == 0 {
+		digits = ""
+	}
+	if len(digits) < s.precision {
+		digits = strings.Repeat("0", s.precision-len(digits)) + digits
+	}
+
+	prefix := ""
+	switch {
+	case negative:
+		prefix = "-"
+	case s.verb != 'd' && s.verb != 'i':
+	case s.plus:
+		prefix = "+"
+	case s.space:
+		prefix = " "
+	}
+	if s.hash {
+		switch {
+		case s.verb == 'o' && (digits == "" || digits[0] != '0'):
+			digits = "0" + digits
+		case s.verb == 'x' && v != 0:
+			prefix = "0x"
+		case s.verb == 'X' && v != 0:
+			prefix = "0X"
+		}
+	}
+
+	return s.pad(prefix, digits, s.precision < 0)
+}
+
+// floatText formats the absolute value of a float64 or a LongDouble with one
+// of the formats of strconv.FormatFloat().
+func floatText(arg interface{}, verb byte, precision int) string {
+	if ld, ok := arg.(LongDouble); ok {
+		return new(big.Float).Abs(ld.float()).Text(verb, precision)
+	}
+	return strconv.FormatFloat(math.Abs(floatValue(arg)), verb, precision, 64)
+}
+
+func floatValue(arg interface{}) float64 {
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	}
+	return 0
+}
+
+func (s formatSpec) formatFloat(arg interface{}) []byte {
+	var negative, inf, nan bool
+	if ld, ok := arg.(LongDouble); ok {
+		f := ld.float()
+		negative = f.Signbit()
+		inf = f.IsInf()
+	} else {
+		x := floatValue(arg)
+		negative = math.Signbit(x)
+		inf = math.IsInf(x, 0)
+		nan = math.IsNaN(x)
+	}
+
+	prefix := ""
+	switch {
+	case negative:
+		prefix = "-"
+	case s.plus:
+		prefix = "+"
+	case s.space:
+		prefix = " "
+	}
+
+	upper := s.verb >= 'A' && s.verb <= 'Z'
+	verb := s.verb | 0x20
+	if inf || nan {
+		digits := "inf"
+		if nan {
+			digits = "nan"
+		}
+		if upper {
+			digits = strings.ToUpper(digits)
+		}
+		return s.pad(prefix, digits, false)
+	}
+
+	precision := s.precision
+	if precision < 0 && verb != 'a' {
+		precision = 6
+	}
+
+	var digits string
+	switch verb {
+	case 'f', 'e':
+		digits = floatText(arg, verb, precision)
+		if s.hash && precision == 0 {
+			digits = addDecimalPoint(digits)
+		}
+
+	case 'g':
+		// %g is %e or %f depending on the exponent, the precision is the
+		// number of significant digits. The trailing zeros are removed
+		// unless the "#" flag is used.
+		if precision == 0 {
+			precision = 1
+		}
+		digits = floatText(arg, 'e', precision-1)
+		exp, _ := strconv.Atoi(digits[strings.IndexByte(digits, 'e')+1:])
+		if exp >= -4 && exp < precision {
+			digits = floatText(arg, 'f', precision-1-exp)
+		}
+		if s.hash {
+			digits = addDecimalPoint(digits)
+		} else {
+			digits = removeTrailingZeros(digits)
+		}
+
+	case 'a':
+		// strconv prints at least two digits in the exponent, C prints as
+		// few as possible.
+		digits = floatText(arg, 'x', precision)
+		p := strings.IndexByte(digits, 'p')
+		exp := strings.TrimLeft(digits[p+2:], "0")
+		if exp == "" {
+			exp = "0"
+		}
+		digits = digits[2:p+2] + exp
+		if s.hash && precision == 0 {
+			digits = addDecimalPoint(digits)
+		}
+		prefix += "0x"
+	}
+
+	if upper {
+		prefix = strings.ToUpper(prefix)
+		digits = strings.ToUpper(digits)
+	}
+
+	return s.pad(prefix, digits, true)
+}
+
+// addDecimalPoint adds a decimal point to a number that does not have one,
+// like the "#" flag of C does.
+func addDecimalPoint(digits string) string {
+	if strings.IndexByte(digits, '.') >= 0 {
+		return digits
+	}
+	if i := strings.IndexAny(digits, "ep"); i >= 0 {
+		return digits[:i] + "." + digits[i:]
+	}
+	return digits + "."
+}
+
+// removeTrailingZeros removes the trailing zeros of the fraction, and the
+// decimal point if the fraction is empty.
+func removeTrailingZeros(digits string) string {
+	exp := ""
+	if i := strings.IndexByte(digits, 'e'); i >= 0 {
+		digits, exp = digits[:i], digits[i:]
+	}
+	if strings.IndexByte(digits, '.') >= 0 {
+		digits = strings.TrimRight(strings.TrimRight(digits, "0"), ".")
+	}
+	return digits + exp
+}
+
+func (s formatSpec) formatChar(arg interface{}) []byte {
+	v, _ := integerBits(arg)
+
+	// A wide character is encoded as UTF-8.
+	if s.length == "l" {
+		var buf [utf8.UTFMax]byte
+		return s.pad("", string(buf[:utf8.EncodeRune(buf[:], rune(v))]), false)
+	}
+
+	return s.pad("", string([]byte{byte(v)}), false)
+}
+
+func (s formatSpec) formatString(arg interface{}) []byte {
+	var str string
+	switch v := arg.(type) {
+	case *byte:
+		if v == nil {
+			str = "(null)"
+			break
+		}
+
+		// The string does not have to be terminated if it is not shorter
+		// than the precision, so the bytes after it must not be read.
+		var b []byte
+		for p := v; *p != 0 && (s.precision < 0 || len(b) < s.precision); {
+			b = append(b, *p)
+			p = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(p)) + 1))
+		}
+		str = string(b)
+	case []byte:
+		if i := bytes.IndexByte(v, 0); i >= 0 {
+			v = v[:i]
+		}
+		str = string(v)
+	case string:
+		str = v
+	default:
+		str = fmt.Sprint(v)
+	}
+
+	if s.precision >= 0 && len(str) > s.precision {
+		str = str[:s.precision]
+	}
+
+	return s.pad("", str, false)
+}
+
+func (s formatSpec) formatPointer(arg interface{}) []byte {
+	v, _ := integerBits(arg)
+	if v == 0 {
+		return s.pad("", "(nil)", false)
+	}
+
+	return s.pad("0x", strconv.FormatUint(v, 16), false)
+}
+
+// storeCount stores the number of bytes that have been written so far for
+// "%n".
+func storeCount(arg interface{}, n int) {
+	switch p := arg.(type) {
+	case *int32:
+		*p = int32(n)
+	case *int64:
+		*p = int64(n)
+	case *int16:
+		*p = int16(n)
+	case *int8:
+		*p = int8(n)
+	case *int:
+		*p = n
+	}
+}
@@ -0,0 +1,153 @@
+package noarch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+func TestFormatString(t *testing.T) {
+	x := new(int32)
+	var p *int32
+	var s *byte
+	var a = [4]byte{'a', 'b', 'c', 'd'}
+
+	tests := []struct {
+		format string
+		args   []interface{}
+		want   string
+	}{
+		{"100%%", nil, "100%"},
+		{"no argument %d", nil, "no argument %d"},
+		{"unknown %k", []interface{}{int32(1)}, "unknown %k"},
+		{"end %", nil, "end %"},
+
+		// Integers.
+		{"%d %i", []interface{}{int32(42), int32(-42)}, "42 -42"},
+		{"%5d|%-5d|%05d", []interface{}{int32(42), int32(42), int32(-42)}, "   42|42   |-0042"},
+		{"%+d % d %+d", []interface{}{int32(42), int32(42), int32(-42)}, "+42  42 -42"},
+		{"%.3d %.0d %5.0d", []interface{}{int32(7), int32(0), int32(0)}, "007       "},
+		{"%08.3d", []interface{}{int32(7)}, "     007"},
+		{"%ld %lld", []interface{}{int32(-1), int64(math.MinInt64)}, "-1 -9223372036854775808"},
+		{"%u", []interface{}{int32(-1)}, "4294967295"},
+		{"%lu", []interface{}{uint64(math.MaxUint64)}, "18446744073709551615"},
+		{"%hhd %hhu %hd %hu", []interface{}{int32(255), int32(-1), int32(65535), int32(-1)}, "-1 255 -1 65535"},
+		{"%x %X %o", []interface{}{int32(255), int32(255), int32(8)}, "ff FF 10"},
+		{"%x", []interface{}{int32(-1)}, "ffffffff"},
+		{"%#x %#X %#o %#x %#o", []interface{}{int32(255), int32(255), int32(8), int32(0), int32(0)}, "0xff 0XFF 010 0 0"},
+		{"%#010x", []interface{}{int32(255)}, "0x000000ff"},
+		{"%d", []interface{}{byte('A')}, "65"},
+		{"%zu %jd %td", []interface{}{uint32(3), int64(4), int32(5)}, "3 4 5"},
+		{"%*d|%-*d|%*d", []interface{}{int32(4), int32(1), int32(3), int32(2), int32(-3), int32(3)}, "   1|2  |3  "},
+
+		// Floating-point numbers.
+		{"%f %F", []interface{}{3.1416, 1.5}, "3.141600 1.500000"},
+		{"%.2f %.0f %#.0f", []interface{}{3.1416, 2.5, 3.0}, "3.14 2 3."},
+		{"%8.3f|%-8.3f|%08.3f", []interface{}{-3.1416, 3.1416, -3.1416}, "  -3.142|3.142   |-003.142"},
+		{"%+.1f % .1f", []interface{}{1.0, 1.0}, "+1.0  1.0"},
+		{"%e %E %.0e", []interface{}{31416.0, 0.00031416, 5.0}, "3.141600e+04 3.141600E-04 5e+00"},
+		{"%g %g %g %g", []interface{}{100000.0, 1000000.0, 0.0001, 0.00001}, "100000 1e+06 0.0001 1e-05"},
+		{"%g %G %.3g %#g", []interface{}{3.1416, 1e-10, 3.1416, 1.5}, "3.1416 1E-10 3.14 1.50000"},
+		{"%.0g %g", []interface{}{123.0, 0.0}, "1e+02 0"},
+		{"%a %A %.1a", []interface{}{1.0, 0.5, 1.5}, "0x1p+0 0X1P-1 0x1.8p+0"},
+		{"%f %f %F %5.1f", []interface{}{math.Inf(1), math.Inf(-1), math.NaN(), math.Inf(1)}, "inf -inf NAN   inf"},
+		{"%f", []interface{}{float32(0.5)}, "0.500000"},
+		{"%Lf %.3Le %Lg", []interface{}{Float64ToLongDouble(2.5), Float64ToLongDouble(1234.5), Float64ToLongDouble(0.5)}, "2.500000 1.234e+03 0.5"},
+
+		// Characters and strings.
+		{"%c%c%c", []interface{}{int32('a'), byte('b'), int32(65)}, "abA"},
+		{"%3c|%-3c", []interface{}{int32('x'), int32('y')}, "  x|y  "},
+		{"%lc", []interface{}{int32(0x263a)}, "☺"},
+		{"%s", []interface{}{&[]byte("hello\x00world")[0]}, "hello"},
+		{"%s", []interface{}{s}, "(null)"},
+		{"%.3s|%8s|%-8s|", []interface{}{&[]byte("hello\x00")[0], "hello", []byte("hi\x00x")}, "hel|   hello|hi      |"},
+		{"%.2s", []interface{}{&a[0]}, "ab"},
+		{"%s", []interface{}{int32(3)}, "3"},
+
+		// Pointers.
+		{"%p", []interface{}{p}, "(nil)"},
+		{"%p", []interface{}{uintptr(0xbeef)}, "0xbeef"},
+		{"%p", []interface{}{unsafe.Pointer(x)}, fmt.Sprintf("%p", x)},
+
+		// A va_list is passed as a slice of the arguments.
+		{"%d %s", []interface{}{[]interface{}{int32(1), &[]byte("a\x00")[0]}}, "1 a"},
+	}
+
+	for _, tt := range tests {
+		got := string(formatArgs(&append([]byte(tt.format), 0)[0], tt.args))
+		if got != tt.want {
+			t.Errorf("%q %v: got %q, want %q", tt.format, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestFormatStringCount(t *testing.T) {
+	var n int32
+	got := string(formatString("abc%n%d", []interface{}{&n, int32(12)}))
+	if got != "abc12" || n != 3 {
+		t.Errorf("got %q and %d, want \"abc12\" and 3", got, n)
+	}
+}
+
+func TestSnprintf(t *testing.T) {
+	buffer := []byte("xxxxxxxx")
+	n := Snprintf(&buffer[0], 4, &[]byte("%d\x00")[0], int32(123456))
+	if n != 6 {
+		t.Errorf("Snprintf() returned %d, want 6", n)
+	}
+	if string(buffer) != "123\x00xxxx" {
+		t.Errorf("got buffer %q, want \"123\\x00xxxx\"", buffer)
+	}
+
+	n = Snprintf(&buffer[0], 0, &[]byte("%d\x00")[0], int32(7))
+	if n != 1 || buffer[0] != '1' {
+		t.Errorf("Snprintf() with a size of 0 returned %d and wrote to the buffer", n)
+	}
+}
+
+func TestFprintf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The standard error is replaced by a file, like the stream of a C
+	// program is redirected.
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStderr := Stderr
+	Stderr = NewFile(stderr)
+	defer func() { Stderr = oldStderr }()
+
+	if n := Fprintf(Stderr, &[]byte("error %d\n\x00")[0], int32(42)); n != 9 {
+		t.Errorf("Fprintf() to stderr returned %d, want 9", n)
+	}
+	stderr.Close()
+
+	path := filepath.Join(dir, "out.txt")
+	f := Fopen(&[]byte(path + "\x00")[0], &[]byte("w\x00")[0])
+	if f == nil {
+		t.Fatal("Fopen() failed")
+	}
+	if n := Fprintf(f, &[]byte("%s=%.1f\x00")[0], &[]byte("pi\x00")[0], 3.14); n != 6 {
+		t.Errorf("Fprintf() to a file returned %d, want 6", n)
+	}
+	Fclose(f)
+
+	for name, want := range map[string]string{"stderr": "error 42\n", "out.txt": "pi=3.1"} {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("got %q in %s, want %q", got, name, want)
+		}
+	}
+}
@@ -0,0 +1,491 @@
+package noarch
+
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// scanSpec is a conversion specification of a scanf() format string, like
+// "%*5ld" or "%[a-z]". The fmt package cannot scan the conversions of C: it
+// does not know "%ld", "%i" or "%[", and its "%s" and "%c" are different.
+type scanSpec struct {
+	// suppress is true for "%*d", the value is read but not stored.
+	suppress bool
+
+	// width is the maximum number of characters that are read, 0 if it is
+	// not given.
+	width int
+
+	verb byte
+
+	// set is the scanset of "%[", negated is true for "%[^".
+	set     string
+	negated bool
+}
+
+// scanner reads the input of scanf() one byte at a time. The byte that ends a
+// conversion is unread, so that it is read again by the rest of the format.
+type scanner struct {
+	r io.ByteScanner
+
+	// count is the number of bytes that are read, for "%n".
+	count int
+}
+
+func (s *scanner) readByte() (byte, bool) {
+	c, err := s.r.ReadByte()
+	if err != nil {
+		return 0, false
+	}
+	s.count++
+
+	return c, true
+}
+
+func (s *scanner) unreadByte() {
+	if s.r.UnreadByte() == nil {
+		s.count--
+	}
+}
+
+// peekByte returns the next byte without reading it.
+func (s *scanner) peekByte() (byte, bool) {
+	c, ok := s.readByte()
+	if ok {
+		s.unreadByte()
+	}
+
+	return c, ok
+}
+
+func (s *scanner) skipSpace() {
+	for {
+		c, ok := s.readByte()
+		if !ok {
+			return
+		}
+		if !isSpaceByte(c) {
+			s.unreadByte()
+			return
+		}
+	}
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+// scanFormat reads the input of scanf() from r according to the C format and
+// stores the values into the locations that args point to. It returns the
+// number of the values that are stored, or EOF if the input ends before the
+// first conversion.
+//
+// A string of "%s", "%c" or "%[" is stored into a *byte. Nothing checks the
+// size of that buffer in C or here, the width of the conversion limits the
+// number of the characters, like "%9s" for a char[10].
+func scanFormat(r io.ByteScanner, format string, args []interface{}) int32 {
+	s := &scanner{r: r}
+	args = flattenArgs(args)
+	var assigned int32
+	converted := false
+
+	// fail is the result of an input failure: EOF before the first
+	// conversion, otherwise the number of the stored values.
+	fail := func() int32 {
+		if !converted {
+			return EOF
+		}
+		return assigned
+	}
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if isSpaceByte(c) {
+			s.skipSpace()
+			continue
+		}
+		if c != '%' || (i+1 < len(format) && format[i+1] == '%') {
+			if c == '%' {
+				i++
+				s.skipSpace()
+			}
+			in, ok := s.readByte()
+			if !ok {
+				return fail()
+			}
+			if in != c {
+				s.unreadByte()
+				return assigned
+			}
+			continue
+		}
+
+		spec, end := parseScanSpec(format, i+1)
+		if spec.verb == 0 {
+			// An incomplete conversion, like "%l" at the end.
+			return assigned
+		}
+		i = end
+
+		if spec.verb != 'c' && spec.verb != '[' && spec.verb != 'n' {
+			s.skipSpace()
+		}
+
+		var arg interface{}
+		if !spec.suppress {
+			if len(args) == 0 {
+				return assigned
+			}
+			arg = args[0]
+			args = args[1:]
+		}
+
+		if spec.verb == 'n' {
+			if arg != nil {
+				storeInt(arg, uint64(s.count))
+			}
+			continue
+		}
+
+		if _, ok := s.peekByte(); !ok {
+			return fail()
+		}
+
+		var ok bool
+		switch spec.verb {
+		case 'd', 'i', 'u', 'o', 'x', 'X', 'p':
+			ok = scanInt(s, spec, arg)
+		case 'f', 'F', 'e', 'E', 'g', 'G', 'a', 'A':
+			ok = scanFloat(s, spec, arg)
+		case 's', 'c', '[':
+			ok = scanString(s, spec, arg)
+		}
+		if !ok {
+			return assigned
+		}
+		converted = true
+		if !spec.suppress {
+			assigned++
+		}
+	}
+
+	return assigned
+}
+
+// parseScanSpec parses the conversion specification of format that starts
+// after the "%" at i. It returns the index of the last byte of the
+// specification. The verb is 0 if the specification is not complete.
+func parseScanSpec(format string, i int) (spec scanSpec, end int) {
+	if i < len(format) && format[i] == '*' {
+		spec.suppress = true
+		i++
+	}
+	for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+		spec.width = spec.width*10 + int(format[i]-'0')
+	}
+
+	// The length modifiers do not matter, the type of the pointer decides
+	// how the value is stored.
+	for i < len(format) && strings.IndexByte("hljztLq", format[i]) != -1 {
+		i++
+	}
+	if i >= len(format) {
+		return scanSpec{}, len(format) - 1
+	}
+
+	spec.verb = format[i]
+	if spec.verb != '[' {
+		return spec, i
+	}
+
+	// The "]" right after "[" or "[^" is a member of the set.
+	i++
+	if i < len(format) && format[i] == '^' {
+		spec.negated = true
+		i++
+	}
+	start := i
+	if i < len(format) && format[i] == ']' {
+		i++
+	}
+	for ; i < len(format) && format[i] != ']'; i++ {
+	}
+	if i >= len(format) {
+		return scanSpec{}, len(format) - 1
+	}
+	spec.set = format[start:i]
+
+	return spec, i
+}
+
+// inScanSet returns true if c is in the scanset, like "a-z0-9_".
+func inScanSet(c byte, set string) bool {
+	for i := 0; i < len(set); i++ {
+		if i+2 < len(set) && set[i+1] == '-' {
+			if c >= set[i] && c <= set[i+2] {
+				return true
+			}
+			i += 2
+			continue
+		}
+		if c == set[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readWhile reads the bytes that match, but not more than width if it is not
+// 0. The byte that does not match is unread.
+func (s *scanner) readWhile(width int, match func(c byte, read []byte) bool) []byte {
+	var read []byte
+	for width == 0 || len(read) < width {
+		c, ok := s.readByte()
+		if !ok {
+			break
+		}
+		if !match(c, read) {
+			s.unreadByte()
+			break
+		}
+		read = append(read, c)
+	}
+
+	return read
+}
+
+func scanInt(s *scanner, spec scanSpec, arg interface{}) bool {
+	base := 10
+	switch spec.verb {
+	case 'i':
+		base = 0
+	case 'o':
+		base = 8
+	case 'x', 'X', 'p':
+		base = 16
+	}
+
+	read := s.readWhile(spec.width, func(c byte, read []byte) bool {
+		digits := read
+		if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+			digits = digits[1:]
+		}
+		switch {
+		case len(read) == 0 && (c == '-' || c == '+'):
+			return true
+		case (c == 'x' || c == 'X') && len(digits) == 1 && digits[0] == '0' &&
+			(base == 0 || base == 16):
+			return true
+		}
+		b := base
+		if b == 0 {
+			b = 10
+			if len(digits) > 0 && digits[0] == '0' {
+				b = 8
+				if len(digits) > 1 && (digits[1] == 'x' || digits[1] == 'X') {
+					b = 16
+				}
+			}
+		}
+		return digitValue(c) < b
+	})
+
+	text := string(read)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimLeft(text, "+-")
+	if base == 0 {
+		base = 10
+		if strings.HasPrefix(text, "0") && len(text) > 1 {
+			base = 8
+		}
+	}
+	if base == 16 || base == 8 {
+		if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X") {
+			base = 16
+			text = text[2:]
+			if text == "" {
+				// Only the "0" of "0x" is a number.
+				text = "0"
+			}
+		}
+	}
+	v, err := strconv.ParseUint(text, base, 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); !ok || numErr.Err != strconv.ErrRange {
+			return false
+		}
+	}
+	if negative {
+		v = -v
+	}
+
+	if arg != nil {
+		return storeInt(arg, v)
+	}
+	return true
+}
+
+func digitValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	}
+
+	return 36
+}
+
+func scanFloat(s *scanner, spec scanSpec, arg interface{}) bool {
+	read := s.readWhile(spec.width, func(c byte, read []byte) bool {
+		var last byte
+		if len(read) > 0 {
+			last = read[len(read)-1]
+		}
+		text := string(read)
+		switch {
+		case c == '-' || c == '+':
+			return len(read) == 0 || last == 'e' || last == 'E' ||
+				last == 'p' || last == 'P'
+		case c == '.':
+			return !strings.ContainsAny(text, ".eEpP")
+		case c == 'x' || c == 'X':
+			return strings.TrimLeft(text, "+-") == "0"
+		case c == 'e' || c == 'E':
+			if strings.ContainsAny(text, "xX") {
+				return true
+			}
+			return strings.ContainsAny(text, "0123456789") && !strings.ContainsAny(text, "eE")
+		case c == 'p' || c == 'P':
+			return strings.ContainsAny(text, "xX") && !strings.ContainsAny(text, "pP")
+		case c >= '0' && c <= '9':
+			return true
+		case (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'):
+			return strings.ContainsAny(text, "xX") && !strings.ContainsAny(text, "pP")
+		}
+		return false
+	})
+
+	v, err := strconv.ParseFloat(string(read), 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); !ok || numErr.Err != strconv.ErrRange {
+			return false
+		}
+	}
+
+	if arg != nil {
+		return storeFloat(arg, v)
+	}
+	return true
+}
+
+func scanString(s *scanner, spec scanSpec, arg interface{}) bool {
+	var read []byte
+	switch spec.verb {
+	case 'c':
+		width := spec.width
+		if width == 0 {
+			width = 1
+		}
+		read = s.readWhile(width, func(byte, []byte) bool { return true })
+		if len(read) < width {
+			return false
+		}
+	case 's':
+		read = s.readWhile(spec.width, func(c byte, _ []byte) bool {
+			return !isSpaceByte(c)
+		})
+	case '[':
+		read = s.readWhile(spec.width, func(c byte, _ []byte) bool {
+			return inScanSet(c, spec.set) != spec.negated
+		})
+	}
+	if len(read) == 0 {
+		return false
+	}
+	if arg == nil {
+		return true
+	}
+
+	buf, ok := arg.(*byte)
+	if !ok {
+		return false
+	}
+
+	// The characters of "%c" are not terminated.
+	if spec.verb != 'c' {
+		read = append(read, 0)
+	}
+	copy(toByteSlice(buf, int32(len(read))), read)
+
+	return true
+}
+
+// storeInt stores the integer through the pointer arg. The value is truncated
+// to the size of the type like in C.
+func storeInt(arg interface{}, v uint64) bool {
+	ptr := reflect.ValueOf(arg)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return false
+	}
+	switch e := ptr.Elem(); e.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.SetInt(int64(v))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.SetUint(v)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// storeFloat stores the floating-point number through the pointer arg.
+func storeFloat(arg interface{}, v float64) bool {
+	switch p := arg.(type) {
+	case *float64:
+		*p = v
+	case *float32:
+		*p = float32(v)
+	case *LongDouble:
+		*p = Float64ToLongDouble(v)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// fileByteScanner reads the input of fscanf() from a file. The byte that is
+// unread at the end of fscanf() is given back to the file, like with ungetc().
+type fileByteScanner struct {
+	f    *File
+	last byte
+}
+
+func (r *fileByteScanner) ReadByte() (byte, error) {
+	c, err := r.f.readByte()
+	r.last = c
+
+	return c, err
+}
+
+func (r *fileByteScanner) UnreadByte() error {
+	Ungetc(int32(r.last), r.f)
+	return nil
+}
+
+// Sscanf handles sscanf().
+//
+// Reads data from str and stores them according to the parameter format into
+// the locations given by the additional arguments, as if scanf was used, but
+// reading from str instead of the standard input (stdin).
+func Sscanf(str *byte, format *byte, args ...interface{}) int32 {
+	return scanFormat(strings.NewReader(CStringToString(str)),
+		CStringToString(format), args)
+}
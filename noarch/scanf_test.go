@@ -0,0 +1,106 @@
+package noarch
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSscanf(t *testing.T) {
+	var a, b int32
+	name := make([]byte, 8)
+	n := Sscanf(&[]byte("12 -34 gopher\x00")[0], &[]byte("%d %d %s\x00")[0], &a, &b, &name[0])
+	if n != 3 || a != 12 || b != -34 || CStringToString(&name[0]) != "gopher" {
+		t.Errorf("Unexpected result: %d, %d, %d, %q", n, a, b, CStringToString(&name[0]))
+	}
+}
+
+func TestScanFormat(t *testing.T) {
+	tests := []struct {
+		input  string
+		format string
+		// args are the pointers to new values, like new(int32).
+		args   []interface{}
+		want   int32
+		values string
+	}{
+		// Integers.
+		{"42", "%d", []interface{}{new(int32)}, 1, "42"},
+		{"  -7,+8", "%d,%d", []interface{}{new(int32), new(int32)}, 2, "-7 8"},
+		{"0x1f 017 9", "%i %i %i", []interface{}{new(int32), new(int32), new(int32)}, 3, "31 15 9"},
+		{"ff 0XFF 17", "%x %X %o", []interface{}{new(int32), new(uint64), new(int32)}, 3, "255 255 15"},
+		{"-1", "%u", []interface{}{new(uint32)}, 1, "4294967295"},
+		{"300", "%hhd", []interface{}{new(int8)}, 1, "44"},
+		{"123456", "%3d%d", []interface{}{new(int32), new(int64)}, 2, "123 456"},
+		{"1 2 3", "%d %*d %d", []interface{}{new(int32), new(int32)}, 2, "1 3"},
+
+		// Floating-point numbers.
+		{"1.5 -2e3", "%f %lf", []interface{}{new(float32), new(float64)}, 2, "1.5 -2000"},
+		{"0x1p-2", "%a", []interface{}{new(float64)}, 1, "0.25"},
+
+		// Strings.
+		{"  hello world", "%s", []interface{}{new([16]byte)}, 1, "hello"},
+		{"hello", "%3s", []interface{}{new([16]byte)}, 1, "hel"},
+		{"abc123", "%[a-z]%[0-9]", []interface{}{new([16]byte), new([16]byte)}, 2, "abc 123"},
+		{"key=value", "%[^=]=%s", []interface{}{new([16]byte), new([16]byte)}, 2, "key value"},
+		{" x", "%2c", []interface{}{new([16]byte)}, 1, " x"},
+		{"100%", "%d%%", []interface{}{new(int32)}, 1, "100"},
+		{"abc", "%*s%n", []interface{}{new(int32)}, 0, "3"},
+
+		// Failures.
+		{"", "%d", []interface{}{new(int32)}, EOF, "0"},
+		{"   ", "%d", []interface{}{new(int32)}, EOF, "0"},
+		{"x", "%d", []interface{}{new(int32)}, 0, "0"},
+		{"1 x", "%d %d", []interface{}{new(int32), new(int32)}, 1, "1 0"},
+		{"1", "%d %d", []interface{}{new(int32), new(int32)}, 1, "1 0"},
+		{"1;2", "%d,%d", []interface{}{new(int32), new(int32)}, 1, "1 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+"|"+tt.format, func(t *testing.T) {
+			args := make([]interface{}, len(tt.args))
+			for i, arg := range tt.args {
+				args[i] = arg
+				if buf, ok := arg.(*[16]byte); ok {
+					args[i] = &buf[0]
+				}
+			}
+
+			got := Sscanf(&[]byte(tt.input + "\x00")[0], &[]byte(tt.format + "\x00")[0], args...)
+			if got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+
+			var values string
+			for i, arg := range tt.args {
+				if i > 0 {
+					values += " "
+				}
+				switch v := arg.(type) {
+				case *[16]byte:
+					if tt.format == "%2c" {
+						values += string(v[:2])
+					} else {
+						values += CStringToString(&v[0])
+					}
+				case *int8:
+					values += fmt.Sprint(*v)
+				case *int32:
+					values += fmt.Sprint(*v)
+				case *int64:
+					values += fmt.Sprint(*v)
+				case *uint32:
+					values += fmt.Sprint(*v)
+				case *uint64:
+					values += fmt.Sprint(*v)
+				case *float32:
+					values += fmt.Sprint(*v)
+				case *float64:
+					values += fmt.Sprint(*v)
+				}
+			}
+			if values != tt.values {
+				t.Errorf("Expected values %q, got %q", tt.values, values)
+			}
+		})
+	}
+}
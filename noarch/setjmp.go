@@ -0,0 +1,58 @@
+package noarch
+
+// JmpBuf is the representation of "jmp_buf". Only its address is used, it
+// identifies the setjmp() that a longjmp() returns to.
+type JmpBuf struct {
+	// The buffer must not have a zero size, otherwise two of them could have
+	// the same address.
+	_ int32
+}
+
+// jump is the value of the panic that unwinds the stack in Longjmp().
+type jump struct {
+	env *JmpBuf
+	val int32
+}
+
+// Setjmp runs f, which is the code that follows setjmp() in C, with the value
+// that setjmp() returns. That is 0 the first time. Each time Longjmp() is
+// called with env while f is running the stack is unwound back to Setjmp() and
+// f is run again with the value of the Longjmp().
+//
+// A Longjmp() with another buffer is not recovered, so that it can reach the
+// Setjmp() of that buffer further up the stack. This is how nested setjmp()
+// scopes are handled.
+func Setjmp(env *JmpBuf, f func(val int32)) {
+	val := int32(0)
+	for {
+		var jumped bool
+		val, jumped = runSetjmp(env, val, f)
+		if !jumped {
+			return
+		}
+	}
+}
+
+func runSetjmp(env *JmpBuf, val int32, f func(int32)) (next int32, jumped bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			j, ok := r.(jump)
+			if !ok || j.env != env {
+				panic(r)
+			}
+			next, jumped = j.val, true
+		}
+	}()
+
+	f(val)
+	return
+}
+
+// Longjmp returns to the Setjmp() of env that is still running, which then
+// returns val. Like in C a val of 0 is returned as 1.
+func Longjmp(env *JmpBuf, val int32) {
+	if val == 0 {
+		val = 1
+	}
+	panic(jump{env: env, val: val})
+}
@@ -0,0 +1,61 @@
+package noarch
+
+import (
+	"testing"
+)
+
+func TestSetjmp(t *testing.T) {
+	var env JmpBuf
+	var vals []int32
+	Setjmp(&env, func(val int32) {
+		vals = append(vals, val)
+		if val == 0 {
+			Longjmp(&env, 7)
+		}
+	})
+	if len(vals) != 2 || vals[0] != 0 || vals[1] != 7 {
+		t.Errorf("Unexpected values of setjmp: %v", vals)
+	}
+
+	// longjmp(env, 0) returns 1.
+	vals = nil
+	Setjmp(&env, func(val int32) {
+		vals = append(vals, val)
+		if val == 0 {
+			Longjmp(&env, 0)
+		}
+	})
+	if len(vals) != 2 || vals[1] != 1 {
+		t.Errorf("Unexpected values of setjmp: %v", vals)
+	}
+}
+
+func TestSetjmpNested(t *testing.T) {
+	var outer, inner JmpBuf
+	var got []int32
+	Setjmp(&outer, func(val int32) {
+		got = append(got, val)
+		if val != 0 {
+			return
+		}
+		Setjmp(&inner, func(val int32) {
+			got = append(got, 10+val)
+			if val == 0 {
+				Longjmp(&inner, 1)
+			}
+			// The inner scope must not recover the jump to the outer buffer.
+			Longjmp(&outer, 2)
+		})
+		t.Error("The jump to the outer buffer returned to the inner scope")
+	})
+
+	expected := []int32{0, 10, 11, 2}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, got)
+		}
+	}
+}
@@ -1,11 +1,11 @@
 package noarch
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
-	"reflect"
 	"strings"
 	"unsafe"
 )
@@ -27,6 +27,11 @@ type File struct {
 	// calls in Go.
 	OsFile *os.File
 
+	// The input is read ahead from OsFile into a buffer. The bytes that are
+	// given back with ungetc() are read first, the last one first.
+	in    *bufio.Reader
+	unget []byte
+
 	// unsigned char *_p;
 	// int _r;
 	// int _w;
@@ -50,6 +55,42 @@ type File struct {
 	_flags int32
 }
 
+// readByte reads the next byte of the input of the stream. The end-of-file or
+// the error indicator is set when no byte can be read.
+func (f *File) readByte() (byte, error) {
+	if n := len(f.unget); n > 0 {
+		c := f.unget[n-1]
+		f.unget = f.unget[:n-1]
+		return c, nil
+	}
+	if f.in == nil {
+		f.in = bufio.NewReader(f.OsFile)
+	}
+	c, err := f.in.ReadByte()
+	if err == io.EOF {
+		f._flags |= io_EOF_SEEN
+	} else if err != nil {
+		f._flags |= io_ERR_SEEN
+	}
+
+	return c, err
+}
+
+// dropInput discards the input that is read ahead and the bytes that are given
+// back, before the stream is written, moved or closed. The file is moved back
+// to the position of the first byte that was read ahead. A stream that cannot
+// seek, like a pipe, loses the input that was read ahead.
+func (f *File) dropInput() {
+	f.unget = nil
+	if f.in == nil {
+		return
+	}
+	if n := f.in.Buffered(); n > 0 {
+		f.OsFile.Seek(-int64(n), io.SeekCurrent)
+	}
+	f.in = nil
+}
+
 // Fopen handles fopen().
 //
 // Opens the file whose name is specified in the parameter filePath and
@@ -141,6 +182,7 @@ func setFopenErrno(err error) {
 // Even if the call fails, the stream passed as parameter will no longer be
 // associated with the file nor its buffers.
 func Fclose(f *File) int32 {
+	f.dropInput()
 	err := f.OsFile.Close()
 	if err != nil {
 		if err == os.ErrInvalid {
@@ -212,6 +254,7 @@ func Rename(oldName, newName *byte) int32 {
 func Fputs(str *byte, stream *File) int32 {
 	goStr := CStringToString(str)
 
+	stream.dropInput()
 	n, err := stream.OsFile.WriteString(goStr)
 	if err != nil {
 		panic(err)
@@ -254,28 +297,28 @@ func Tmpfile() *File {
 // Notice that fgets is quite different from gets: not only fgets accepts a
 // stream argument, but also allows to specify the maximum size of str and
 // includes in the string any ending newline character.
+//
+// If the end-of-file is reached before any character is read, the contents of
+// str are not changed and a null pointer is returned, like for a read error.
 func Fgets(str *byte, num int32, stream *File) *byte {
-	buf := make([]byte, num)
-	n, err := stream.OsFile.Read(buf[:num-1])
-	var newlinepos int
-	for ; newlinepos < n-1; newlinepos++ {
-		if buf[newlinepos] == '\n' {
+	if num < 1 {
+		return nil
+	}
+	buf := make([]byte, 0, num)
+	for int32(len(buf)) < num-1 {
+		c, err := stream.readByte()
+		if err == io.EOF && len(buf) > 0 {
 			break
 		}
-	}
-	buf[newlinepos+1] = 0
-	if newlinepos < n-1 {
-		stream.OsFile.Seek(int64(newlinepos-n+1), 1)
-	}
-	if err != nil {
-		if n == 0 && err == io.EOF {
-			stream._flags |= io_EOF_SEEN
-		} else {
-			stream._flags |= io_ERR_SEEN
+		if err != nil {
+			return nil
+		}
+		buf = append(buf, c)
+		if c == '\n' {
+			break
 		}
-		return nil
 	}
-	copy(toByteSlice(str, num), buf[:newlinepos+2])
+	copy(toByteSlice(str, num), append(buf, 0))
 	return str
 }
 
@@ -425,6 +468,7 @@ func Tmpnam(str *byte) *byte {
 // program terminates, all the buffers associated with it are automatically
 // flushed.
 func Fflush(stream *File) int32 {
+	stream.dropInput()
 	err := stream.OsFile.Sync()
 	if err != nil {
 		return 1
@@ -443,19 +487,8 @@ func Fflush(stream *File) int32 {
 // After the format parameter, the function expects at least as many additional
 // arguments as specified by format.
 func Fprintf(f *File, format *byte, args ...interface{}) int32 {
-	realArgs := []interface{}{}
-
-	// Convert any C strings into Go strings.
-	typeOfByteSlice := reflect.TypeOf((*byte)(nil))
-	for _, arg := range args {
-		if reflect.TypeOf(arg) == typeOfByteSlice {
-			realArgs = append(realArgs, CStringToString(arg.(*byte)))
-		} else {
-			realArgs = append(realArgs, arg)
-		}
-	}
-
-	n, err := fmt.Fprintf(f.OsFile, CStringToString(format), realArgs...)
+	f.dropInput()
+	n, err := f.OsFile.Write(formatArgs(format, args))
 	if err != nil {
 		return -1
 	}
@@ -472,62 +505,13 @@ func Fprintf(f *File, format *byte, args ...interface{}) int32 {
 // type specified by their corresponding format specifier within the format
 // string.
 func Fscanf(f *File, format *byte, args ...interface{}) int32 {
-	realArgs := prepareArgsForScanf(args)
-
-	// format is ignored
-	// See https://github.com/elliotchance/c2go/issues/607
-	_ = format
-
-	n, err := fmt.Fscan(f.OsFile, realArgs...)
-	if err != nil {
-		f._flags |= io_EOF_SEEN
-		return EOF
-	}
+	r := &fileByteScanner{f: f}
 
-	finalizeArgsForScanf(realArgs, args)
-
-	return int32(n)
-}
-
-func finalizeArgsForScanf(realArgs []interface{}, args []interface{}) {
-	typeOfStringRef := reflect.TypeOf(new(string))
-	for i, arg := range realArgs {
-		if reflect.TypeOf(arg) == typeOfStringRef {
-			s := *arg.(*string)
-			byteSlice := toByteSlice(args[i].(*byte), int32(len(s)+1))
-			copy(byteSlice, []byte(s))
-			byteSlice[len(s)] = 0
-		}
-	}
-}
-
-func prepareArgsForScanf(args []interface{}) []interface{} {
-	realArgs := []interface{}{}
-
-	typeOfBytePointer := reflect.TypeOf((*byte)(nil))
-	for _, arg := range args {
-		if reflect.TypeOf(arg) == typeOfBytePointer {
-			realArgs = append(realArgs, new(string))
-		} else {
-			realArgs = append(realArgs, arg)
-		}
-	}
-
-	return realArgs
+	return scanFormat(r, CStringToString(format), args)
 }
 
 const EOF = -int32(1)
 
-func getc(f *os.File) int32 {
-	buffer := make([]byte, 1)
-	_, err := f.Read(buffer)
-	if err != nil {
-		return EOF
-	}
-
-	return int32(buffer[0])
-}
-
 // Fgetc handles fgetc().
 //
 // Returns the character currently pointed by the internal file position
@@ -542,12 +526,43 @@ func getc(f *os.File) int32 {
 //
 // fgetc and getc are equivalent, except that getc may be implemented as a macro
 // in some libraries.
-func Fgetc(stream *File) (ret int32) {
-	ret = getc(stream.OsFile)
-	if ret == EOF {
-		stream._flags |= io_EOF_SEEN
+func Fgetc(stream *File) int32 {
+	c, err := stream.readByte()
+	if err != nil {
+		return EOF
+	}
+
+	return int32(c)
+}
+
+// Ungetc handles ungetc().
+//
+// A character is virtually put back into an input stream, decreasing its
+// internal file position as if a previous getc operation was undone.
+//
+// This character may or may not be the one read from the stream in the
+// preceding input operation. In any case, the next character retrieved from
+// stream is the character passed to this function, independently of the
+// original one.
+//
+// Notice though, that this only affects further input operations on that
+// stream, and not the content of the physical file associated with it, which is
+// not modified by any calls to this function.
+//
+// If successful, the function clears the end-of-file indicator of stream. A
+// call to fseek, fsetpos or rewind on stream will discard any characters
+// previously put back into it with this function.
+//
+// If the argument passed for the character parameter is EOF, the operation
+// fails and the input stream remains unchanged.
+func Ungetc(character int32, stream *File) int32 {
+	if character == EOF {
+		return EOF
 	}
-	return
+	stream.unget = append(stream.unget, byte(character))
+	stream._flags &= ^io_EOF_SEEN
+
+	return int32(byte(character))
 }
 
 // Fputc handles fputc().
@@ -557,6 +572,7 @@ func Fgetc(stream *File) (ret int32) {
 // The character is written at the position indicated by the internal position
 // indicator of the stream, which is then automatically advanced by one.
 func Fputc(c int32, f *File) int32 {
+	f.dropInput()
 	n, err := f.OsFile.Write([]byte{byte(c)})
 	if err != nil {
 		return 0
@@ -571,7 +587,7 @@ func Fputc(c int32, f *File) int32 {
 //
 // It is equivalent to calling getc with stdin as argument.
 func Getchar() int32 {
-	return getc(Stdin.OsFile)
+	return Fgetc(Stdin)
 }
 
 // Fseek handles fseek().
@@ -595,6 +611,10 @@ func Getchar() int32 {
 // On streams open for update (read+write), a call to fseek allows to switch
 // between reading and writing.
 func Fseek(f *File, offset int32, origin int32) int32 {
+	if origin == int32(io.SeekCurrent) {
+		offset -= int32(len(f.unget))
+	}
+	f.dropInput()
 	n, err := f.OsFile.Seek(int64(offset), int(origin))
 	if err != nil {
 		f._flags |= io_EOF_SEEN
@@ -616,8 +636,16 @@ func Fseek(f *File, offset int32, origin int32) int32 {
 // used to restore the position to the same position later using fseek (if there
 // are characters put back using ungetc still pending of being read, the
 // behavior is undefined).
-func Ftell(f *File) int32 {
-	return int32(Fseek(f, 0, 1))
+func Ftell(f *File) int64 {
+	n, err := f.OsFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return int64(EOF)
+	}
+	if f.in != nil {
+		n -= int64(f.in.Buffered())
+	}
+
+	return n - int64(len(f.unget))
 }
 
 // Fread handles fread().
@@ -634,7 +662,14 @@ func Fread(ptr unsafe.Pointer, size1, size2 int32, f *File) int32 {
 	// number of bytes from the file.
 	newBuffer := make([]byte, size1*size2)
 	ptrSlice := toByteSlice((*byte)(ptr), size1*size2)
-	n, err := f.OsFile.Read(newBuffer)
+	n := 0
+	for ; n < len(newBuffer); n++ {
+		c, err := f.readByte()
+		if err != nil {
+			break
+		}
+		newBuffer[n] = c
+	}
 
 	// Despite any error we need to make sure the bytes read are copied to the
 	// destination buffer.
@@ -642,13 +677,7 @@ func Fread(ptr unsafe.Pointer, size1, size2 int32, f *File) int32 {
 		ptrSlice[i] = b
 	}
 
-	// Now we can handle the success or failure.
-	if err != nil {
-		if err == io.EOF {
-			f._flags |= io_EOF_SEEN
-		} else {
-			f._flags |= io_ERR_SEEN
-		}
+	if n == 0 && len(newBuffer) > 0 {
 		return EOF
 	}
 
@@ -667,6 +696,7 @@ func Fread(ptr unsafe.Pointer, size1, size2 int32, f *File) int32 {
 // array of (size*count) elements of type unsigned char, and writes them
 // sequentially to stream as if fputc was called for each byte.
 func Fwrite(str *byte, size1, size2 int32, stream *File) int32 {
+	stream.dropInput()
 	n, err := stream.OsFile.Write(toByteSlice(str, size1*size2))
 	if err != nil {
 		return -1
@@ -687,7 +717,7 @@ func Fwrite(str *byte, size1, size2 int32, stream *File) int32 {
 // The ftell function can be used to retrieve the current position in the stream
 //as an integer value.
 func Fgetpos(f *File, pos *int32) int32 {
-	absolutePos := Fseek(f, 0, 1)
+	absolutePos := int32(Ftell(f))
 	if pos != nil {
 		*pos = absolutePos
 	}
@@ -723,21 +753,7 @@ func Fsetpos(stream *File, pos *int32) int32 {
 // additional arguments following format are formatted and inserted in the
 // resulting string replacing their respective specifiers.
 func Printf(format *byte, args ...interface{}) int32 {
-	realArgs := []interface{}{}
-
-	// Convert any C strings into Go strings.
-	typeOfByteSlice := reflect.TypeOf((*byte)(nil))
-	for _, arg := range args {
-		if reflect.TypeOf(arg) == typeOfByteSlice {
-			realArgs = append(realArgs, CStringToString(arg.(*byte)))
-		} else {
-			realArgs = append(realArgs, arg)
-		}
-	}
-
-	n, _ := fmt.Printf(CStringToString(format), realArgs...)
-
-	return int32(n)
+	return Fprintf(Stdout, format, args...)
 }
 
 // Puts handles puts().
@@ -767,14 +783,9 @@ func Puts(str *byte) int32 {
 // type specified by their corresponding format specifier within the format
 // string.
 func Scanf(format *byte, args ...interface{}) int32 {
-	realArgs := prepareArgsForScanf(args)
-
-	// We cannot use fmt.Scanf() here because that would use the real stdin
-	// which does not work under test. See docs for noarch.Stdin.
-	n, _ := fmt.Fscanf(Stdin.OsFile, CStringToString(format), realArgs...)
-	finalizeArgsForScanf(realArgs, args)
-
-	return int32(n)
+	// We cannot use os.Stdin here because that would use the real stdin which
+	// does not work under test. See docs for noarch.Stdin.
+	return Fscanf(Stdin, format, args...)
 }
 
 // Putchar handles putchar().
@@ -793,21 +804,10 @@ func Putchar(character int32) {
 // additional arguments following format are formatted and inserted in the
 // resulting string replacing their respective specifiers.
 func Sprintf(buffer, format *byte, args ...interface{}) int32 {
-	realArgs := []interface{}{}
+	result := formatArgs(format, args)
+	copy(toByteSlice(buffer, int32(len(result)+1)), append(result, 0))
 
-	realArgs = append(realArgs, convert(args)...)
-
-	result := fmt.Sprintf(CStringToString(format), realArgs...)
-	var pBuf *byte
-	for i := range []byte(result) {
-		pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(i)))
-		*pBuf = result[i]
-	}
-	pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(len(result))))
-	*pBuf = '\x00'
-
-	n := len(result)
-	return int32(n)
+	return int32(len(result))
 }
 
 // Vsprintf handles vsprintf().
@@ -817,21 +817,10 @@ func Sprintf(buffer, format *byte, args ...interface{}) int32 {
 // additional arguments following format are formatted and inserted in the
 // resulting string replacing their respective specifiers.
 func Vsprintf(buffer, format *byte, args VaList) int32 {
-	realArgs := []interface{}{}
-
-	realArgs = append(realArgs, convert(args.Args)...)
+	result := formatArgs(format, args.Args)
+	copy(toByteSlice(buffer, int32(len(result)+1)), append(result, 0))
 
-	result := fmt.Sprintf(CStringToString(format), realArgs...)
-	var pBuf *byte
-	for i := range []byte(result) {
-		pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(i)))
-		*pBuf = result[i]
-	}
-	pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(len(result))))
-	*pBuf = '\x00'
-
-	n := len(result)
-	return int32(n)
+	return int32(len(result))
 }
 
 // Snprintf handles snprintf().
@@ -844,22 +833,6 @@ func Snprintf(buffer *byte, n int32, format *byte, args ...interface{}) int32 {
 	return internalVsnprintf(buffer, n, format, args)
 }
 
-// convert - convert va_list
-func convert(arg interface{}) (result []interface{}) {
-	typeOfByteSlice := reflect.TypeOf((*byte)(nil))
-	if reflect.TypeOf(arg) == typeOfByteSlice {
-		return []interface{}{CStringToString(arg.(*byte))}
-	}
-	if reflect.TypeOf(arg).Kind() == reflect.Slice {
-		arg := arg.([]interface{})
-		for j := 0; j < len(arg); j++ {
-			result = append(result, convert(arg[j])...)
-		}
-		return result
-	}
-	return []interface{}{arg}
-}
-
 // Vsnprintf handles vsnprintf().
 //
 // Writes the C string pointed by format to the standard output (stdout). If
@@ -870,22 +843,18 @@ func Vsnprintf(buffer *byte, n int32, format *byte, args VaList) int32 {
 	return internalVsnprintf(buffer, n, format, args.Args)
 }
 
+// internalVsnprintf writes at most n-1 bytes and the NULL byte to the buffer.
+// It returns the length of the whole result, like snprintf() does when the
+// buffer is too small.
 func internalVsnprintf(buffer *byte, n int32, format *byte, args ...interface{}) int32 {
-	realArgs := []interface{}{}
-
-	realArgs = append(realArgs, convert(args)...)
-
-	result := fmt.Sprintf(CStringToString(format), realArgs...)
-	if len(result) > int(n) {
-		result = result[:n]
-	}
-	var pBuf *byte
-	for i, b := range []byte(result) {
-		pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(i)))
-		*pBuf = b
+	result := formatArgs(format, args)
+	if n > 0 {
+		m := len(result)
+		if m > int(n)-1 {
+			m = int(n) - 1
+		}
+		copy(toByteSlice(buffer, int32(m+1)), append(result[:m:m], 0))
 	}
-	pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(len(result))))
-	*pBuf = '\x00'
 
 	return int32(len(result))
 }
@@ -0,0 +1,112 @@
+package noarch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFgets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "lines.txt")
+	if err := ioutil.WriteFile(path, []byte("first\nsecond line\nlast"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := Fopen(&[]byte(path + "\x00")[0], &[]byte("r\x00")[0])
+	if f == nil {
+		t.Fatal("Fopen() failed")
+	}
+
+	buffer := make([]byte, 8)
+	for _, want := range []string{"first\n", "second ", "line\n", "last"} {
+		if Fgets(&buffer[0], int32(len(buffer)), f) == nil {
+			t.Fatalf("Fgets() returned NULL, want %q", want)
+		}
+		if got := CStringToString(&buffer[0]); got != want {
+			t.Errorf("Fgets() read %q, want %q", got, want)
+		}
+		// The last line has no newline, so the end of the file is seen
+		// when it is read.
+		if eof := Feof(f) != 0; eof != (want == "last") {
+			t.Errorf("Feof() = %v after %q", eof, want)
+		}
+	}
+
+	if Fgets(&buffer[0], int32(len(buffer)), f) != nil {
+		t.Errorf("Fgets() at the end of the file did not return NULL")
+	}
+	if got := CStringToString(&buffer[0]); got != "last" {
+		t.Errorf("Fgets() at the end of the file changed the buffer to %q", got)
+	}
+	if Feof(f) == 0 || Ferror(f) != 0 {
+		t.Errorf("Feof() = %d and Ferror() = %d at the end of the file", Feof(f), Ferror(f))
+	}
+
+	if Fclose(f) != 0 {
+		t.Errorf("Fclose() failed")
+	}
+}
+
+func TestUngetc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "digits.txt")
+	if err := ioutil.WriteFile(path, []byte("12"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := Fopen(&[]byte(path + "\x00")[0], &[]byte("r\x00")[0])
+	if f == nil {
+		t.Fatal("Fopen() failed")
+	}
+	defer Fclose(f)
+
+	if c := Fgetc(f); c != '1' {
+		t.Errorf("Fgetc() = %q, want '1'", c)
+	}
+	if c := Ungetc('0', f); c != '0' {
+		t.Errorf("Ungetc() = %q, want '0'", c)
+	}
+	if pos := Ftell(f); pos != 0 {
+		t.Errorf("Ftell() after Ungetc() = %d, want 0", pos)
+	}
+
+	// The characters that are given back are read first, even after the
+	// end of the file, which they clear.
+	for _, want := range []int32{'0', '2', EOF} {
+		if c := Fgetc(f); c != want {
+			t.Errorf("Fgetc() = %q, want %q", c, want)
+		}
+	}
+	if Feof(f) == 0 {
+		t.Fatalf("Feof() is not set at the end of the file")
+	}
+	Ungetc('x', f)
+	if Feof(f) != 0 {
+		t.Errorf("Ungetc() did not clear the end-of-file indicator")
+	}
+	if c := Fgetc(f); c != 'x' {
+		t.Errorf("Fgetc() = %q, want 'x'", c)
+	}
+	if c := Ungetc(EOF, f); c != EOF {
+		t.Errorf("Ungetc(EOF) = %d, want EOF", c)
+	}
+
+	// A seek drops the characters that are given back.
+	Ungetc('y', f)
+	Fseek(f, 0, 0)
+	if c := Fgetc(f); c != '1' {
+		t.Errorf("Fgetc() after Fseek() = %q, want '1'", c)
+	}
+}
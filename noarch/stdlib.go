@@ -1,6 +1,7 @@
 package noarch
 
 import (
+	"fmt"
 	"math"
 	"os"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/elliotchance/c2go/util"
 	"math/rand"
+	"reflect"
 	"sync"
 	"unsafe"
 )
@@ -122,8 +124,8 @@ func Atoi(str *byte) int32 {
 // integral number, or if no such sequence exists because either str is empty or
 // it contains only whitespace characters, no conversion is performed and zero
 // is returned.
-func Atol(str *byte) int32 {
-	return int32(Atoll(str))
+func Atol(str *byte) int64 {
+	return Atoll(str)
 }
 
 // Atoll parses the C-string str interpreting its content as an integral number,
@@ -184,9 +186,46 @@ func Div(numer, denom int32) DivT {
 	}
 }
 
-// Exit uses os.Exit to stop program execution.
+var (
+	atexitSync     sync.Mutex
+	atexitHandlers []func()
+
+	// osExit is replaced by the tests.
+	osExit = os.Exit
+)
+
+// Atexit registers the function f to be called by Exit(). The functions are
+// called in the reverse order of their registration. The C main() returns with
+// Exit() when the program uses atexit(), so that they are called then as well.
+func Atexit(f func()) int32 {
+	atexitSync.Lock()
+	defer atexitSync.Unlock()
+	atexitHandlers = append(atexitHandlers, f)
+
+	return 0
+}
+
+// Exit calls the functions that are registered with Atexit() and then uses
+// os.Exit to stop program execution.
+//
+// Each function is removed before it is called. If a function calls Exit()
+// itself, the remaining functions are called by that Exit(), and the program
+// stops with its exit code instead.
 func Exit(exitCode int32) {
-	os.Exit(int(exitCode))
+	for {
+		atexitSync.Lock()
+		if len(atexitHandlers) == 0 {
+			atexitSync.Unlock()
+			break
+		}
+		f := atexitHandlers[len(atexitHandlers)-1]
+		atexitHandlers = atexitHandlers[:len(atexitHandlers)-1]
+		atexitSync.Unlock()
+
+		f()
+	}
+
+	osExit(int(exitCode))
 }
 
 // Getenv retrieves a C-string containing the value of the environment variable
@@ -328,8 +367,8 @@ func Strtold(str *byte, endptr **byte) float64 {
 //
 // For locales other than the "C" locale, additional subject sequence forms may
 // be accepted.
-func Strtol(str *byte, endptr **byte, radix int32) int32 {
-	return int32(Strtoll(str, endptr, radix))
+func Strtol(str *byte, endptr **byte, radix int32) int64 {
+	return Strtoll(str, endptr, radix)
 }
 
 // Strtoll works the same way as Strtol but returns a long long.
@@ -379,6 +418,64 @@ func Malloc(numBytes int32) unsafe.Pointer {
 	return unsafe.Pointer(&memBlock[0])
 }
 
+// AllocSlice registers a slice that is allocated for calloc(), like the
+// memory of Malloc(), so that Realloc() can resize it. It returns the pointer
+// to the first element, see SlicePointer().
+func AllocSlice(slice interface{}) unsafe.Pointer {
+	v := reflect.ValueOf(slice)
+	if v.Len() > 0 {
+		memSync.Lock()
+		defer memSync.Unlock()
+		memMgmt[uint64(v.Pointer())] = slice
+	}
+	return SlicePointer(slice)
+}
+
+// SlicePointer returns the pointer to the first element of a slice. It is nil
+// for an empty slice, that has no element to point to, like the memory of
+// calloc() or alloca() for a size of zero.
+func SlicePointer(slice interface{}) unsafe.Pointer {
+	v := reflect.ValueOf(slice)
+	if v.Len() == 0 {
+		return nil
+	}
+	return unsafe.Pointer(v.Pointer())
+}
+
+// Realloc changes the size of a memory block of Malloc() or AllocSlice(). The
+// new block is a slice of the same type, so the elements are copied up to the
+// smaller of the old and the new length, and the old block is freed. A nil
+// pointer is allocated like with Malloc() and a size of zero frees the block
+// and returns nil.
+func Realloc(ptr unsafe.Pointer, numBytes int32) unsafe.Pointer {
+	if ptr == nil {
+		return Malloc(numBytes)
+	}
+	if numBytes == 0 {
+		Free(ptr)
+		return nil
+	}
+
+	addr := uint64(uintptr(ptr))
+	memSync.Lock()
+	defer memSync.Unlock()
+	old, ok := memMgmt[addr]
+	if !ok {
+		panic(fmt.Sprintf("realloc(): %p is not a block of malloc() or calloc()", ptr))
+	}
+
+	// The length is rounded up to whole elements.
+	v := reflect.ValueOf(old)
+	size := int(v.Type().Elem().Size())
+	length := (int(numBytes) + size - 1) / size
+	block := reflect.MakeSlice(v.Type(), length, length)
+	reflect.Copy(block, v)
+
+	delete(memMgmt, addr)
+	memMgmt[uint64(block.Pointer())] = block.Interface()
+	return unsafe.Pointer(block.Pointer())
+}
+
 // Free removes the reference to this memory address,
 // so that the Go GC can free it.
 func Free(anything unsafe.Pointer) {
@@ -0,0 +1,73 @@
+package noarch
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestExitCallsAtexitHandlers(t *testing.T) {
+	var calls []string
+	var codes []int
+	osExit = func(code int) {
+		codes = append(codes, code)
+	}
+	defer func() {
+		osExit = os.Exit
+	}()
+
+	Atexit(func() { calls = append(calls, "first") })
+	Atexit(func() {
+		calls = append(calls, "second")
+		// The remaining handler is called by the nested Exit().
+		Exit(2)
+	})
+	Atexit(func() { calls = append(calls, "third") })
+
+	Exit(1)
+
+	if want := []string{"third", "second", "first"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, calls)
+	}
+	if want := []int{2, 1}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("Expected exit codes %v, got %v", want, codes)
+	}
+}
+
+func TestRealloc(t *testing.T) {
+	// realloc(NULL, n) is malloc(n).
+	p := Realloc(nil, 4)
+	copy((*[4]byte)(p)[:], "abc\x00")
+
+	// The contents are kept when the block grows and when it shrinks.
+	p = Realloc(p, 8)
+	if got := CStringToString((*byte)(p)); got != "abc" {
+		t.Errorf("Expected \"abc\" after growing, got %q", got)
+	}
+	(*[8]byte)(p)[2] = 0
+	p = Realloc(p, 2)
+	if got := string((*[2]byte)(p)[:]); got != "ab" {
+		t.Errorf("Expected \"ab\" after shrinking, got %q", got)
+	}
+
+	// A block of calloc() keeps the type of its elements.
+	ints := (*[2]int32)(AllocSlice(make([]int32, 2)))
+	ints[0], ints[1] = 7, 9
+	q := (*[3]int32)(Realloc(unsafe.Pointer(ints), 12))
+	if q[0] != 7 || q[1] != 9 || q[2] != 0 {
+		t.Errorf("Expected [7 9 0], got %v", *q)
+	}
+
+	if Realloc(unsafe.Pointer(q), 0) != nil {
+		t.Errorf("Expected nil from realloc() to 0 bytes")
+	}
+
+	// There is no element to point to for calloc() of 0 elements.
+	if AllocSlice(make([]int32, 0)) != nil {
+		t.Errorf("Expected nil from calloc() of 0 elements")
+	}
+	if SlicePointer(make([]byte, 0)) != nil {
+		t.Errorf("Expected nil from alloca() of 0 bytes")
+	}
+}
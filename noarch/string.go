@@ -27,22 +27,9 @@ func Strlen(a *byte) int32 {
 // long enough to contain the same C string as source (including the terminating
 // null character), and should not overlap in memory with source.
 func Strcpy(dest, src *byte) *byte {
-	var (
-		pSrc  *byte
-		pDest *byte
-		i     int
-	)
-	for ; ; i++ {
-		pSrc = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(src)) + uintptr(i)))
-		pDest = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(dest)) + uintptr(i)))
-		*pDest = *pSrc
-
-		// We only need to copy until the first NULL byte. Make sure we also
-		// include that NULL byte on the end.
-		if *pSrc == '\x00' {
-			break
-		}
-	}
+	// The null byte is copied too.
+	n := Strlen(src) + 1
+	copy(toByteSlice(dest, n), toByteSlice(src, n))
 
 	return dest
 }
@@ -59,29 +46,31 @@ func Strcpy(dest, src *byte) *byte {
 // destination and source shall not overlap (see memmove for a safer alternative
 // when overlapping).
 func Strncpy(dest, src *byte, len int32) *byte {
-	// Copy up to the len or first NULL bytes - whichever comes first.
-	var (
-		pSrc  = src
-		pDest = dest
-		i     int32
-	)
-	for i < len && *pSrc != 0 {
-		*pDest = *pSrc
-		i++
-		pSrc = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(src)) + uintptr(i)))
-		pDest = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(dest)) + uintptr(i)))
+	if len <= 0 {
+		return dest
 	}
 
+	// Copy up to the len or first NULL bytes - whichever comes first. The
+	// source is not read past the len, it does not need a NULL byte then.
+	d := toByteSlice(dest, len)
+	n := copy(d, toByteSlice(src, strnlen(src, len)))
+
 	// The rest of the dest will be padded with zeros to the len.
-	for i < len {
-		*pDest = 0
-		i++
-		pDest = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(dest)) + uintptr(i)))
+	for i := n; i < int(len); i++ {
+		d[i] = 0
 	}
 
 	return dest
 }
 
+// strnlen returns the length of a C string, but not more than max.
+func strnlen(s *byte, max int32) (n int32) {
+	for n < max && *(*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(s)) + uintptr(n))) != 0 {
+		n++
+	}
+	return
+}
+
 // Strcasestr - function is similar to Strstr(),
 // but ignores the case of both strings.
 func Strcasestr(str1, str2 *byte) *byte {
@@ -100,8 +89,11 @@ func Strcasestr(str1, str2 *byte) *byte {
 // character of source, and a null-character is included at the end
 // of the new string formed by the concatenation of both in destination.
 func Strcat(dest, src *byte) *byte {
-	newDest := (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(dest)) + uintptr(Strlen(dest))))
-	Strcpy(newDest, src)
+	// The null byte of dest is overwritten by the first byte of src.
+	d := Strlen(dest)
+	n := Strlen(src) + 1
+	copy(toByteSlice(dest, d+n)[d:], toByteSlice(src, n))
+
 	return dest
 }
 
@@ -24,3 +24,42 @@ func TestStringCopy(t *testing.T) {
 		})
 	}
 }
+
+func TestStrncpyTruncation(t *testing.T) {
+	// The source is longer than n, so no null byte is written and the rest of
+	// the destination is kept. The destinations are appended to a new slice,
+	// because the compiler may keep a []byte of a constant in read-only memory.
+	dest := append(make([]byte, 0, 16), "xxxxxxxx\x00"...)
+	Strncpy(&dest[0], &[]byte("hello\x00")[0], 3)
+	if got := string(dest); got != "helxxxxx\x00" {
+		t.Errorf("Expected %q, got %q", "helxxxxx\x00", got)
+	}
+
+	// The source is not read past n, it does not need a null byte.
+	dest = append(make([]byte, 0, 16), "xxxx"...)
+	Strncpy(&dest[0], &[]byte("ab")[0], 2)
+	if got := string(dest); got != "abxx" {
+		t.Errorf("Expected %q, got %q", "abxx", got)
+	}
+
+	// The destination is padded with null bytes up to n.
+	dest = append(make([]byte, 0, 16), "xxxxxxxx"...)
+	Strncpy(&dest[0], &[]byte("ab\x00cd")[0], 6)
+	if got := string(dest); got != "ab\x00\x00\x00\x00xx" {
+		t.Errorf("Expected %q, got %q", "ab\x00\x00\x00\x00xx", got)
+	}
+}
+
+func TestStrcat(t *testing.T) {
+	dest := make([]byte, 16)
+	for i := range dest {
+		dest[i] = 'x'
+	}
+	Strcpy(&dest[0], &[]byte("foo\x00")[0])
+	if got := Strcat(&dest[0], &[]byte(" bar\x00")[0]); got != &dest[0] {
+		t.Errorf("Expected the destination to be returned")
+	}
+	if got := string(dest); got != "foo bar\x00xxxxxxxx" {
+		t.Errorf("Expected %q, got %q", "foo bar\x00xxxxxxxx", got)
+	}
+}
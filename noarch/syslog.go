@@ -3,7 +3,6 @@
 package noarch
 
 import (
-	"fmt"
 	"log/syslog"
 )
 
@@ -40,17 +39,13 @@ func Setlogmask(mask int32) int32 {
 
 // void    syslog(int, const char *, ...);
 func Syslog(priority int32, format *byte, args ...interface{}) {
-	realArgs := []interface{}{}
-	realArgs = append(realArgs, convert(args)...)
-	msg := fmt.Sprintf(CStringToString(format), realArgs...)
+	msg := string(formatArgs(format, args))
 	internalSyslog(priority, msg)
 }
 
 // void    vsyslog(int, const char *, struct __va_list_tag *);
 func Vsyslog(priority int32, format *byte, args VaList) {
-	realArgs := []interface{}{}
-	realArgs = append(realArgs, convert(args.Args)...)
-	msg := fmt.Sprintf(CStringToString(format), realArgs...)
+	msg := string(formatArgs(format, args.Args))
 	internalSyslog(priority, msg)
 }
 
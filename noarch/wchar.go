@@ -0,0 +1,14 @@
+package noarch
+
+import (
+	"unsafe"
+)
+
+// Wcslen returns the length of a wide string, that is the number of wide
+// characters before the terminating null wide character.
+func Wcslen(s *int32) (n int32) {
+	for *(*int32)(unsafe.Pointer(uintptr(unsafe.Pointer(s)) + uintptr(n)*4)) != 0 {
+		n++
+	}
+	return
+}
@@ -0,0 +1,20 @@
+package noarch
+
+import (
+	"testing"
+)
+
+func TestWcslen(t *testing.T) {
+	for _, tt := range []struct {
+		s    []int32
+		want int32
+	}{
+		{[]int32{0}, 0},
+		{[]int32{'a', 'b', 'c', 0}, 3},
+		{[]int32{'h', 'é', 'l', 'l', 'o', 0, 'x', 0}, 5},
+	} {
+		if got := Wcslen(&tt.s[0]); got != tt.want {
+			t.Errorf("%q: expected %d, got %d", tt.s, tt.want, got)
+		}
+	}
+}
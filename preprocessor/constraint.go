@@ -0,0 +1,177 @@
+package preprocessor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// constraintExpr is a Go build constraint, like "linux && !386". It is either
+// a tag, or an operator ("!", "&&" or "||") with its operands. The constraints
+// are parsed here instead of with go/build/constraint, that needs Go 1.16.
+type constraintExpr struct {
+	op   string
+	tag  string
+	x, y *constraintExpr
+}
+
+// parseConstraint parses the expression of a "//go:build" line. The tags are
+// made of letters, digits, underscores and dots.
+func parseConstraint(s string) (*constraintExpr, error) {
+	p := &constraintParser{s: s}
+	p.next()
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, fmt.Errorf("unexpected %q", p.tok)
+	}
+
+	return expr, nil
+}
+
+type constraintParser struct {
+	s   string
+	tok string
+}
+
+func (p *constraintParser) next() {
+	p.s = strings.TrimLeft(p.s, " \t")
+	switch {
+	case p.s == "":
+		p.tok = ""
+	case strings.HasPrefix(p.s, "&&"), strings.HasPrefix(p.s, "||"):
+		p.tok, p.s = p.s[:2], p.s[2:]
+	case isTagChar(p.s[0]):
+		end := 0
+		for end < len(p.s) && isTagChar(p.s[end]) {
+			end++
+		}
+		p.tok, p.s = p.s[:end], p.s[end:]
+	default:
+		p.tok, p.s = p.s[:1], p.s[1:]
+	}
+}
+
+func (p *constraintParser) or() (*constraintExpr, error) {
+	x, err := p.and()
+	for err == nil && p.tok == "||" {
+		p.next()
+		var y *constraintExpr
+		y, err = p.and()
+		x = &constraintExpr{op: "||", x: x, y: y}
+	}
+
+	return x, err
+}
+
+func (p *constraintParser) and() (*constraintExpr, error) {
+	x, err := p.not()
+	for err == nil && p.tok == "&&" {
+		p.next()
+		var y *constraintExpr
+		y, err = p.not()
+		x = &constraintExpr{op: "&&", x: x, y: y}
+	}
+
+	return x, err
+}
+
+func (p *constraintParser) not() (*constraintExpr, error) {
+	switch {
+	case p.tok == "!":
+		p.next()
+		x, err := p.not()
+		return &constraintExpr{op: "!", x: x}, err
+
+	case p.tok == "(":
+		p.next()
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.next()
+		return x, nil
+
+	case p.tok != "" && isTagChar(p.tok[0]):
+		tag := p.tok
+		p.next()
+		return &constraintExpr{tag: tag}, nil
+	}
+
+	if p.tok == "" {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q", p.tok)
+}
+
+func isTagChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.'
+}
+
+// String returns the expression of the "//go:build" line. The operands of "!"
+// and the "||" inside of "&&" are put in parentheses.
+func (e *constraintExpr) String() string {
+	switch e.op {
+	case "!":
+		if e.x.op == "" {
+			return "!" + e.x.tag
+		}
+		return "!(" + e.x.String() + ")"
+	case "&&":
+		return e.x.andOperand() + " && " + e.y.andOperand()
+	case "||":
+		return e.x.String() + " || " + e.y.String()
+	}
+
+	return e.tag
+}
+
+func (e *constraintExpr) andOperand() string {
+	if e.op == "||" {
+		return "(" + e.String() + ")"
+	}
+
+	return e.String()
+}
+
+// plusBuild returns the options of the "// +build" line for the versions of Go
+// before 1.17. The line is an "||" of space separated options, and each option
+// is an "&&" of comma separated tags, so "(debug || test) && linux" becomes
+// "debug,linux test,linux".
+func (e *constraintExpr) plusBuild() string {
+	var options []string
+	for _, terms := range e.dnf(false) {
+		options = append(options, strings.Join(terms, ","))
+	}
+
+	return strings.Join(options, " ")
+}
+
+// dnf returns the expression, or its negation, as an "||" of "&&" of the tags
+// that may be negated.
+func (e *constraintExpr) dnf(negate bool) [][]string {
+	switch {
+	case e.op == "!":
+		return e.x.dnf(!negate)
+	case e.op == "||" && !negate, e.op == "&&" && negate:
+		return append(e.x.dnf(negate), e.y.dnf(negate)...)
+	case e.op == "&&", e.op == "||":
+		var options [][]string
+		for _, x := range e.x.dnf(negate) {
+			for _, y := range e.y.dnf(negate) {
+				options = append(options, append(append([]string{}, x...), y...))
+			}
+		}
+		return options
+	}
+
+	if negate {
+		return [][]string{{"!" + e.tag}}
+	}
+	return [][]string{{e.tag}}
+}
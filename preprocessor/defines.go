@@ -0,0 +1,209 @@
+package preprocessor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/util"
+)
+
+// UserDefines returns the macros that are defined with the -D flags of clang,
+// like "DEBUG" for "-DDEBUG" or "LEVEL=2" for "-D LEVEL=2".
+func UserDefines(clangFlags []string) (defines []string) {
+	for i := 0; i < len(clangFlags); i++ {
+		flag := clangFlags[i]
+		if !strings.HasPrefix(flag, "-D") {
+			continue
+		}
+
+		define := strings.TrimPrefix(flag, "-D")
+		if define == "" && i+1 < len(clangFlags) {
+			i++
+			define = clangFlags[i]
+		}
+		if define != "" {
+			defines = append(defines, define)
+		}
+	}
+
+	return
+}
+
+// GetDefines returns all of the macros that are defined for the C source,
+// including the ones that are predefined by clang, like "__linux__". The keys
+// are the names of the macros and the values are their definitions.
+func GetDefines(clangFlags []string) (map[string]string, error) {
+	var out, stderr bytes.Buffer
+	args := append([]string{"-dM", "-E", "-x", "c"}, clangFlags...)
+	args = append(args, os.DevNull)
+	cmd := exec.Command("clang", args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cannot get the defines: %v\nStdErr = %v", err, stderr.String())
+	}
+
+	return parseDefines(out.String()), nil
+}
+
+// parseDefines parses the output of "clang -dM -E", like:
+//
+//     #define __linux__ 1
+//     #define __VERSION__ "Clang 10.0.0 "
+//     #define MAX(a, b) ((a) > (b) ? (a) : (b))
+//
+// The parameters of a function-like macro are not part of its name.
+func parseDefines(s string) map[string]string {
+	defines := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#define ") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "#define ")
+		end := strings.IndexAny(line, " (")
+		if end == -1 {
+			defines[line] = ""
+			continue
+		}
+
+		name, value := line[:end], line[end:]
+		if value[0] == '(' {
+			value = value[strings.Index(value, ")")+1:]
+		}
+		defines[name] = strings.TrimSpace(value)
+	}
+
+	return defines
+}
+
+// GetMacros returns the object-like macros that are defined by the C files and
+// the headers of the user, in the order they are defined. The macros of the
+// system headers are left out.
+func GetMacros(inputFiles, clangFlags []string) ([]program.Macro, error) {
+	userFiles, err := GetIncludeListWithUserSource(inputFiles, clangFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	var out, stderr bytes.Buffer
+	for _, file := range inputFiles {
+		args := append([]string{"-E", "-dD"}, clangFlags...)
+		cmd := exec.Command("clang", append(args, file)...)
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("cannot get the macros of %s: %v\nStdErr = %v",
+				file, err, stderr.String())
+		}
+	}
+
+	return parseMacros(out.String(), userFiles), nil
+}
+
+// parseMacros parses the output of "clang -E -dD", that has the #define and
+// #undef directives in place, like:
+//
+//     # 1 "main.c"
+//     #define RED 0
+//     #define GREEN 1
+//
+// Only the macros of the files are returned, the line markers tell the file
+// of each directive. A function-like macro is not a value. A macro that is
+// undefined has no single value, so it is left out. When a header is included
+// more than once only the first definition of its macros is kept.
+func parseMacros(s string, files []string) (macros []program.Macro) {
+	isFile := map[string]bool{}
+	for _, file := range files {
+		isFile[filepath.Clean(file)] = true
+	}
+
+	lineMarker := util.GetRegex(`^# \d+ "(.*)"`)
+	file := ""
+	defined := map[string]bool{}
+	undefined := map[string]bool{}
+	for _, line := range strings.Split(s, "\n") {
+		if m := lineMarker.FindStringSubmatch(line); m != nil {
+			file = filepath.Clean(m[1])
+			continue
+		}
+		if !isFile[file] {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#undef ") {
+			undefined[strings.TrimSpace(strings.TrimPrefix(line, "#undef "))] = true
+			continue
+		}
+		if !strings.HasPrefix(line, "#define ") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "#define ")
+		name, value := line, ""
+		if end := strings.IndexAny(line, " ("); end != -1 {
+			if line[end] == '(' {
+				continue
+			}
+			name, value = line[:end], strings.TrimSpace(line[end:])
+		}
+		if defined[name] {
+			continue
+		}
+		defined[name] = true
+		macros = append(macros, program.Macro{File: file, Name: name, Value: value})
+	}
+
+	for i := 0; i < len(macros); i++ {
+		if undefined[macros[i].Name] {
+			macros = append(macros[:i], macros[i+1:]...)
+			i--
+		}
+	}
+
+	return
+}
+
+// BuildConstraint returns the expressions of the "//go:build" and "// +build"
+// lines from a mapping of macros to Go build constraints, like
+// "__linux__=linux". The constraints of the macros that are defined are
+// combined with "&&", so the mappings:
+//
+//     __linux__=linux  __x86_64__=amd64  _WIN32=windows
+//
+// result in "linux && amd64" and "linux,amd64" for clang on 64-bit Linux.
+// Empty strings are returned if none of the macros are defined.
+func BuildConstraint(tags []string, defines map[string]string) (goBuild, plusBuild string, err error) {
+	var expr *constraintExpr
+	for _, tag := range tags {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", fmt.Errorf("the build tag %q must be like macro=constraint", tag)
+		}
+
+		x, err := parseConstraint(parts[1])
+		if err != nil {
+			return "", "", fmt.Errorf("the build tag %q has an invalid constraint: %v", tag, err)
+		}
+
+		if _, ok := defines[parts[0]]; !ok {
+			continue
+		}
+		if expr == nil {
+			expr = x
+		} else {
+			expr = &constraintExpr{op: "&&", x: expr, y: x}
+		}
+	}
+
+	if expr == nil {
+		return "", "", nil
+	}
+	return expr.String(), expr.plusBuild(), nil
+}
@@ -0,0 +1,107 @@
+package preprocessor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestUserDefines(t *testing.T) {
+	got := UserDefines([]string{"-DDEBUG", "-I", "include", "-D", "LEVEL=2",
+		"-UNDEBUG", "-DNAME=\"a b\"", "-D"})
+	want := []string{"DEBUG", "LEVEL=2", "NAME=\"a b\""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestParseDefines(t *testing.T) {
+	got := parseDefines(`#define _LP64 1
+#define __VERSION__ "Clang 10.0.0 "
+#define __linux__ 1
+#define EMPTY
+#define MAX(a, b) ((a) > (b) ? (a) : (b))
+#define NOARGS() 0
+`)
+	want := map[string]string{
+		"_LP64":       "1",
+		"__VERSION__": `"Clang 10.0.0 "`,
+		"__linux__":   "1",
+		"EMPTY":       "",
+		"MAX":         "((a) > (b) ? (a) : (b))",
+		"NOARGS":      "0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestBuildConstraint(t *testing.T) {
+	defines := map[string]string{"__linux__": "1", "__x86_64__": "1", "DEBUG": ""}
+
+	for _, tt := range []struct {
+		tags      []string
+		goBuild   string
+		plusBuild string
+		err       bool
+	}{
+		{nil, "", "", false},
+		{[]string{"_WIN32=windows"}, "", "", false},
+		{[]string{"__linux__=linux", "_WIN32=windows", "__x86_64__=amd64"}, "linux && amd64", "linux,amd64", false},
+		{[]string{"DEBUG=debug || test", "__linux__=linux"}, "(debug || test) && linux", "debug,linux test,linux", false},
+		{[]string{"__linux__=!windows"}, "!windows", "!windows", false},
+		{[]string{"__linux__=!(windows || darwin)"}, "!(windows || darwin)", "!windows,!darwin", false},
+		{[]string{"__linux__=(linux&&go1.9)||!cgo"}, "linux && go1.9 || !cgo", "linux,go1.9 !cgo", false},
+		{[]string{"__linux__"}, "", "", true},
+		{[]string{"=linux"}, "", "", true},
+		{[]string{"_WIN32=windows &&"}, "", "", true},
+		{[]string{"_WIN32=(windows"}, "", "", true},
+		{[]string{"_WIN32=windows linux"}, "", "", true},
+		{[]string{"_WIN32=windows,linux"}, "", "", true},
+	} {
+		goBuild, plusBuild, err := BuildConstraint(tt.tags, defines)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: unexpected error: %v", tt.tags, err)
+			continue
+		}
+		if goBuild != tt.goBuild {
+			t.Errorf("%q: expected %q, got %q", tt.tags, tt.goBuild, goBuild)
+		}
+		if plusBuild != tt.plusBuild {
+			t.Errorf("%q: expected %q, got %q", tt.tags, tt.plusBuild, plusBuild)
+		}
+	}
+}
+
+func TestParseMacros(t *testing.T) {
+	got := parseMacros(`# 1 "/src/main.c"
+# 1 "<built-in>" 1
+#define __STDC__ 1
+# 1 "/src/main.c" 2
+# 1 "/usr/include/stdio.h" 1 3 4
+#define BUFSIZ 8192
+# 2 "/src/main.c" 2
+# 1 "/src/colors.h" 1
+#define RED 0
+#define GREEN 1
+#define MAX(a, b) ((a) > (b) ? (a) : (b))
+#define EMPTY
+# 3 "/src/main.c" 2
+#define SIZE (10U)
+#define TMP 2
+#undef TMP
+#define RED 5
+int main() { return RED; }
+`, []string{"/src/main.c", "/src/./colors.h"})
+
+	want := []program.Macro{
+		{File: "/src/colors.h", Name: "RED", Value: "0"},
+		{File: "/src/colors.h", Name: "GREEN", Value: "1"},
+		{File: "/src/colors.h", Name: "EMPTY", Value: ""},
+		{File: "/src/main.c", Name: "SIZE", Value: "(10U)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
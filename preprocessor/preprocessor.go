@@ -91,6 +91,11 @@ func Analyze(inputFiles, clangFlags []string, verbose bool) (pp []byte,
 		return
 	}
 
+	renames, err := staticFunctionRenames(inputFiles, clangFlags)
+	if err != nil {
+		return
+	}
+
 	// Generate list of user files
 	userSource := map[string]bool{}
 	var us []string
@@ -146,14 +151,17 @@ func Analyze(inputFiles, clangFlags []string, verbose bool) (pp []byte,
 		//                            ^
 		header := fmt.Sprintf("# %d \"%s\"", allItems[i].positionInSource, allItems[i].include)
 		lines = append(lines, header)
-		if len(allItems[i].lines) > 0 {
-			for ii, l := range allItems[i].lines {
-				if ii == 0 {
-					continue
-				}
-				lines = append(lines, *l)
+		var source []string
+		for ii, l := range allItems[i].lines {
+			if ii == 0 {
+				continue
 			}
+			source = append(source, *l)
 		}
+		if r := renames[allItems[i].include]; len(r) > 0 && len(source) > 0 {
+			source = strings.Split(renameIdentifiers(strings.Join(source, "\n"), r), "\n")
+		}
+		lines = append(lines, source...)
 	}
 	pp = ([]byte)(strings.Join(lines, "\n"))
 
@@ -238,19 +246,12 @@ func getPreprocessSources(inputFiles, clangFlags []string, verbose bool) (out by
 		return
 	}
 
-	// Add open source defines
-	if runtime.GOOS == "darwin" {
-		clangFlags = append(clangFlags, "-D_XOPEN_SOURCE")
-	} else {
-		clangFlags = append(clangFlags, "-D_GNU_SOURCE")
-	}
-
 	// preprocessor clang
 	var stderr bytes.Buffer
 
 	var args []string
 	args = append(args, "-E", "-C")
-	args = append(args, clangFlags...)
+	args = append(args, sourceFlags(clangFlags)...)
 	args = append(args, unionFileName) // All inputFiles
 
 	var outFile bytes.Buffer
@@ -274,6 +275,15 @@ func getPreprocessSources(inputFiles, clangFlags []string, verbose bool) (out by
 	return
 }
 
+// sourceFlags returns the flags of clang with the open source defines.
+func sourceFlags(clangFlags []string) []string {
+	flags := append([]string{}, clangFlags...)
+	if runtime.GOOS == "darwin" {
+		return append(flags, "-D_XOPEN_SOURCE")
+	}
+	return append(flags, "-D_GNU_SOURCE")
+}
+
 func generateIncludeList(userList, allList []string) (
 	includes []program.IncludeHeader) {
 
@@ -0,0 +1,37 @@
+package preprocessor
+
+import (
+	"strings"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+// SourceLines returns the lines of the preprocessed source by the file and the
+// line number in the file, like the positions in the clang AST. The line
+// markers, like:
+//
+//     # 26 "/usr/include/stdio.h" 3 4
+//
+// give the file and the number of the line that follows them.
+func SourceLines(pp []byte) map[string]map[int]string {
+	lines := map[string]map[int]string{}
+	file := ""
+	number := 0
+	for _, line := range strings.Split(string(pp), "\n") {
+		if util.GetRegex(`^# \d+ ".*"`).MatchString(line) {
+			item, err := parseIncludePreprocessorLine(line)
+			if err == nil {
+				file = item.include
+				number = item.positionInSource
+				continue
+			}
+		}
+		if lines[file] == nil {
+			lines[file] = map[int]string{}
+		}
+		lines[file][number] = line
+		number++
+	}
+
+	return lines
+}
@@ -0,0 +1,24 @@
+package preprocessor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSourceLines(t *testing.T) {
+	pp := `# 1 "x.c"
+int a;
+# 1 "./x.h" 1
+int b;
+int c;
+# 3 "x.c" 2
+int d;`
+
+	want := map[string]map[int]string{
+		"x.c":   {1: "int a;", 3: "int d;"},
+		"./x.h": {1: "int b;", 2: "int c;"},
+	}
+	if got := SourceLines([]byte(pp)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,219 @@
+package preprocessor
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elliotchance/c2go/ast"
+)
+
+// staticFunctionRenames returns the new names of the static functions that
+// collide with a function of another input file, by the absolute path of the
+// file and the name of the function:
+//
+//     a.c: static void helper(void)   =>   helper_a
+//     b.c: static void helper(void)   =>   helper_b
+//
+// All of the input files are included into one file that clang parses as a
+// single translation unit, where the two functions would be defined twice.
+// Each input file is parsed on its own to find its static functions, and the
+// colliding ones are renamed in the preprocessed source of their file. Nothing
+// is renamed for a single input file.
+func staticFunctionRenames(inputFiles, clangFlags []string) (
+	map[string]map[string]string, error) {
+	if len(inputFiles) < 2 {
+		return nil, nil
+	}
+
+	var files []string
+	statics := map[string]map[string]bool{}
+	functions := map[string]map[string]bool{}
+	for _, inputFile := range inputFiles {
+		file, err := filepath.Abs(inputFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var out, stderr bytes.Buffer
+		args := append([]string{"-Xclang", "-ast-dump", "-fsyntax-only",
+			"-fno-color-diagnostics"}, sourceFlags(clangFlags)...)
+		cmd := exec.Command("clang", append(args, file)...)
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %v\nStdErr = %v", file, err, stderr.String())
+		}
+
+		files = append(files, file)
+		statics[file], functions[file] = fileFunctions(strings.Split(out.String(), "\n"), file)
+	}
+
+	return renameStaticFunctions(files, statics, functions), nil
+}
+
+// fileFunctions returns the names of the functions that are declared by a C
+// file itself, not by the headers that it includes, from the output of
+// "clang -ast-dump" of the file. A function is static when one of its
+// declarations is static.
+func fileFunctions(dump []string, file string) (statics, functions map[string]bool) {
+	statics = map[string]bool{}
+	functions = map[string]bool{}
+
+	// The file of a node is the last one that is given by the positions of
+	// the nodes before it, like for the nodes that are transpiled.
+	var nodes []ast.Node
+	var topLevel []bool
+	for _, line := range dump {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		line = strings.Replace(line, "<<<NULL>>>", "NullStmt", 1)
+		trimmed := strings.TrimLeft(line, "|\\- `")
+		nodes = append(nodes, ast.Parse(trimmed))
+		topLevel = append(topLevel, len(line)-len(trimmed) == 2)
+	}
+	ast.FixPositions(nodes)
+
+	for i, node := range nodes {
+		f, ok := node.(*ast.FunctionDecl)
+		if !ok || !topLevel[i] || filepath.Clean(f.Pos.File) != filepath.Clean(file) {
+			continue
+		}
+		functions[f.Name] = true
+		if f.IsStatic {
+			statics[f.Name] = true
+		}
+	}
+
+	return
+}
+
+// renameStaticFunctions returns the new names of the static functions of the
+// files that are declared by another file as well, see
+// staticFunctionRenames(). The new name ends with the name of the file, or a
+// number if that is taken already.
+func renameStaticFunctions(files []string,
+	statics, functions map[string]map[string]bool) map[string]map[string]string {
+	taken := map[string]bool{}
+	for _, file := range files {
+		for name := range functions[file] {
+			taken[name] = true
+		}
+	}
+
+	renames := map[string]map[string]string{}
+	for _, file := range files {
+		var names []string
+		for name := range statics[file] {
+			for _, other := range files {
+				if other != file && functions[other][name] {
+					names = append(names, name)
+					break
+				}
+			}
+		}
+		sort.Strings(names)
+
+		base := filepath.Base(file)
+		suffix := identifierOf(strings.TrimSuffix(base, filepath.Ext(base)))
+		for _, name := range names {
+			newName := name + "_" + suffix
+			for i := 2; taken[newName]; i++ {
+				newName = fmt.Sprintf("%s_%s_%d", name, suffix, i)
+			}
+			taken[newName] = true
+			if renames[file] == nil {
+				renames[file] = map[string]string{}
+			}
+			renames[file][name] = newName
+		}
+	}
+
+	return renames
+}
+
+// identifierOf replaces the characters of s that cannot be in a C identifier
+// with underscores.
+func identifierOf(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// renameIdentifiers replaces the identifiers of the preprocessed C source that
+// have a new name. The strings, the characters and the comments are kept as
+// they are, and so are the line breaks.
+func renameIdentifiers(source string, renames map[string]string) string {
+	var out bytes.Buffer
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case strings.HasPrefix(source[i:], "//"):
+			end := strings.IndexByte(source[i:], '\n')
+			if end == -1 {
+				end = len(source) - i
+			}
+			out.WriteString(source[i : i+end])
+			i += end
+
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end == -1 {
+				end = len(source) - i
+			} else {
+				end += 4
+			}
+			out.WriteString(source[i : i+end])
+			i += end
+
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(source) && source[end] != c && source[end] != '\n' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(source) && source[end] == c {
+				end++
+			}
+			if end > len(source) {
+				end = len(source)
+			}
+			out.WriteString(source[i:end])
+			i = end
+
+		case isIdentifierStart(c) || c >= '0' && c <= '9':
+			// A number, like 0x1f or 1e5, is skipped as a whole.
+			end := i + 1
+			for end < len(source) && (isIdentifierStart(source[end]) ||
+				source[end] >= '0' && source[end] <= '9') {
+				end++
+			}
+			word := source[i:end]
+			if newName, ok := renames[word]; ok && isIdentifierStart(c) {
+				word = newName
+			}
+			out.WriteString(word)
+			i = end
+
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	return out.String()
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,93 @@
+package preprocessor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileFunctions(t *testing.T) {
+	// The dump of /tmp/a.c:
+	//
+	//     #include "a.h"
+	//     static int twice(int n);
+	//     int twice(int n) { return n * 2; }
+	//     int run(void) { return twice(shared()); }
+	dump := `TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 </tmp/a.h:1:1, col:16> col:5 used shared 'int (void)'
+|-FunctionDecl 0x20 </tmp/a.c:2:1, col:23> col:12 used twice 'int (int)' static
+| |-ParmVarDecl 0x21 <col:18, col:22> col:22 n 'int'
+|-FunctionDecl 0x30 prev 0x20 <line:3:1, col:34> col:5 used twice 'int (int)'
+| |-ParmVarDecl 0x31 <col:11, col:15> col:15 used n 'int'
+| |-CompoundStmt 0x32 <col:18, col:34>
+|   |-ReturnStmt 0x33 <col:20, col:31>
+|     |-BinaryOperator 0x34 <col:27, col:31> 'int' '*'
+|       |-ImplicitCastExpr 0x35 <col:27> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x36 <col:27> 'int' lvalue ParmVar 0x31 'n' 'int'
+|       |-IntegerLiteral 0x37 <col:31> 'int' 2
+|-FunctionDecl 0x40 <line:4:1, col:42> col:5 run 'int (void)'
+  |-CompoundStmt 0x41 <col:17, col:42>`
+
+	statics, functions := fileFunctions(strings.Split(dump, "\n"), "/tmp/a.c")
+
+	if want := map[string]bool{"twice": true}; !reflect.DeepEqual(statics, want) {
+		t.Errorf("Expected the statics %v, got %v", want, statics)
+	}
+	// shared() is declared by the header.
+	if want := map[string]bool{"twice": true, "run": true}; !reflect.DeepEqual(functions, want) {
+		t.Errorf("Expected the functions %v, got %v", want, functions)
+	}
+}
+
+func TestRenameStaticFunctions(t *testing.T) {
+	files := []string{"/tmp/main.c", "/tmp/lib/helper-1.c", "/src/main.c"}
+	statics := map[string]map[string]bool{
+		"/tmp/main.c":         {"helper": true, "only": true},
+		"/tmp/lib/helper-1.c": {"helper": true},
+		"/src/main.c":         {"helper": true, "run": true},
+	}
+	functions := map[string]map[string]bool{
+		"/tmp/main.c":         {"helper": true, "only": true, "main": true},
+		"/tmp/lib/helper-1.c": {"helper": true, "helper_main": true},
+		"/src/main.c":         {"helper": true, "run": true},
+	}
+
+	// The static run() collides with nothing, and the second main.c cannot
+	// take the name of the first one.
+	want := map[string]map[string]string{
+		"/tmp/main.c":         {"helper": "helper_main_2"},
+		"/tmp/lib/helper-1.c": {"helper": "helper_helper_1"},
+		"/src/main.c":         {"helper": "helper_main_3"},
+	}
+	if got := renameStaticFunctions(files, statics, functions); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// A static function that collides with an external one of another file
+	// is renamed, the external one is not.
+	statics = map[string]map[string]bool{"/tmp/main.c": {"helper": true}}
+	functions = map[string]map[string]bool{
+		"/tmp/main.c":         {"helper": true},
+		"/tmp/lib/helper-1.c": {"helper": true},
+	}
+	want = map[string]map[string]string{"/tmp/main.c": {"helper": "helper_main"}}
+	if got := renameStaticFunctions(files[:2], statics, functions); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRenameIdentifiers(t *testing.T) {
+	source := `static int helper(int n) { return n + 0x1f; }
+/* helper() is
+   static */ int run(void) { return helper(1) + helper_count; } // helper
+const char *s = "helper \" helper"; char c = 'h';`
+	want := `static int helper_a(int n) { return n + 0x1f; }
+/* helper() is
+   static */ int run(void) { return helper_a(1) + helper_count; } // helper
+const char *s = "helper \" helper"; char c = 'h';`
+
+	got := renameIdentifiers(source, map[string]string{"helper": "helper_a", "x1f": "no"})
+	if got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
@@ -0,0 +1,185 @@
+package program
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TypeLayout is the size and the alignment of a C type in bytes.
+type TypeLayout struct {
+	Size  int
+	Align int
+}
+
+// ABI contains the layouts of the fundamental C types of a target platform.
+// It is used to evaluate sizeof at transpile time, so the results match the
+// platform that the C code was written for. The integer types that have a
+// different width on each platform, like long, are also resolved to the Go
+// type of the same width.
+type ABI struct {
+	// Name is the name of the ABI that is used by the -abi flag, like "lp64".
+	Name string
+
+	// Pointer is the layout of all pointer types.
+	Pointer TypeLayout
+
+	// Types are the layouts of the fundamental types. The keys are the names
+	// of the types without "signed" or "unsigned", like "long long".
+	Types map[string]TypeLayout
+
+	// Integers are the Go types of the integer types that depend on the
+	// platform, see GoType().
+	Integers map[string]string
+
+	// CharSigned is true if a plain char is signed, like on x86. A char is
+	// always a byte in Go, so that the strings are []byte, but a signed char
+	// is converted to int8 first when it is promoted to a wider type. That is
+	// the difference between 255 and -1 for a char of 0xFF.
+	CharSigned bool
+}
+
+// LP64 is the ABI of 64-bit Linux and macOS. It is the default ABI.
+var LP64 = &ABI{
+	Name:    "lp64",
+	Pointer: TypeLayout{8, 8},
+	Types: map[string]TypeLayout{
+		"void":        {1, 1},
+		"bool":        {1, 1},
+		"char":        {1, 1},
+		"short":       {2, 2},
+		"int":         {4, 4},
+		"long":        {8, 8},
+		"long long":   {8, 8},
+		"__int128":    {16, 16},
+		"float":       {4, 4},
+		"double":      {8, 8},
+		"long double": {16, 16},
+	},
+	Integers: map[string]string{
+		"long":          "int64",
+		"unsigned long": "uint64",
+		"size_t":        "uint64",
+		"ssize_t":       "int64",
+		"ptrdiff_t":     "int64",
+		"wchar_t":       "int32",
+	},
+	CharSigned: true,
+}
+
+// LLP64 is the ABI of 64-bit Windows, where long is only 32 bits.
+var LLP64 = &ABI{
+	Name:    "llp64",
+	Pointer: TypeLayout{8, 8},
+	Types: map[string]TypeLayout{
+		"void":        {1, 1},
+		"bool":        {1, 1},
+		"char":        {1, 1},
+		"short":       {2, 2},
+		"int":         {4, 4},
+		"long":        {4, 4},
+		"long long":   {8, 8},
+		"__int128":    {16, 16},
+		"float":       {4, 4},
+		"double":      {8, 8},
+		"long double": {8, 8},
+	},
+	Integers: map[string]string{
+		"long":          "int32",
+		"unsigned long": "uint32",
+		"size_t":        "uint64",
+		"ssize_t":       "int64",
+		"ptrdiff_t":     "int64",
+		"wchar_t":       "uint16",
+	},
+	CharSigned: true,
+}
+
+// ILP32 is the ABI of 32-bit x86 Linux. The 64-bit types are only aligned to
+// 4 bytes in a struct.
+var ILP32 = &ABI{
+	Name:    "ilp32",
+	Pointer: TypeLayout{4, 4},
+	Types: map[string]TypeLayout{
+		"void":        {1, 1},
+		"bool":        {1, 1},
+		"char":        {1, 1},
+		"short":       {2, 2},
+		"int":         {4, 4},
+		"long":        {4, 4},
+		"long long":   {8, 4},
+		"float":       {4, 4},
+		"double":      {8, 4},
+		"long double": {12, 4},
+	},
+	Integers: map[string]string{
+		"long":          "int32",
+		"unsigned long": "uint32",
+		"size_t":        "uint32",
+		"ssize_t":       "int32",
+		"ptrdiff_t":     "int32",
+		"wchar_t":       "int32",
+	},
+	CharSigned: true,
+}
+
+var abis = map[string]*ABI{
+	LP64.Name:  LP64,
+	LLP64.Name: LLP64,
+	ILP32.Name: ILP32,
+}
+
+// ABINames returns the names of all of the ABIs that can be used with
+// GetABI().
+func ABINames() []string {
+	names := []string{}
+	for name := range abis {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// GetABI returns the ABI with a name, like "lp64".
+func GetABI(name string) (*ABI, error) {
+	if abi, ok := abis[strings.ToLower(name)]; ok {
+		return abi, nil
+	}
+
+	return nil, fmt.Errorf("unknown ABI %q, the ABIs are: %s", name,
+		strings.Join(ABINames(), ", "))
+}
+
+// Layout returns the layout of a fundamental type, like "long" or
+// "long long int". The "signed" and "unsigned" keywords must have been removed
+// from the type. ok is false if the type is not a fundamental type.
+func (abi *ABI) Layout(cType string) (TypeLayout, bool) {
+	switch cType {
+	case "signed", "unsigned":
+		cType = "int"
+	case "_Bool":
+		cType = "bool"
+	case "short int", "long int", "long long int":
+		cType = strings.TrimSuffix(cType, " int")
+	}
+
+	layout, ok := abi.Types[cType]
+	return layout, ok
+}
+
+// GoType returns the Go type of an integer type that has a different width on
+// each platform, like "int64" for "long int" on LP64. The variants of the
+// name, like "long unsigned int", are the same type. ok is false if the width
+// of the type is the same on all platforms, then it is not in the ABI.
+func (abi *ABI) GoType(cType string) (goType string, ok bool) {
+	switch cType {
+	case "long int", "signed long", "signed long int", "long signed int":
+		cType = "long"
+	case "long unsigned int", "unsigned long int":
+		cType = "unsigned long"
+	}
+
+	goType, ok = abi.Integers[cType]
+	return
+}
@@ -0,0 +1,94 @@
+package program
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/format"
+	"go/token"
+)
+
+// RemoveDuplicateDecls removes the declarations of the output file that are
+// the same as an earlier one. The headers that are shared by the C files can
+// be transpiled more than once, like a struct that is included by two of the
+// files. A declaration is the same when it has the same name and the same Go
+// source, apart from its comment.
+//
+// It returns an error for a name that is declared twice with a different
+// source, since Go does not accept both and only one of them is right.
+func (p *Program) RemoveDuplicateDecls() error {
+	seen := map[string]goast.Decl{}
+	decls := p.File.Decls[:0]
+	for _, decl := range p.File.Decls {
+		key := declKey(decl)
+		if key == "" {
+			decls = append(decls, decl)
+			continue
+		}
+		first, ok := seen[key]
+		if !ok {
+			seen[key] = decl
+			decls = append(decls, decl)
+			continue
+		}
+		if p.declSource(first) != p.declSource(decl) {
+			return fmt.Errorf("%s is declared twice with different definitions", key)
+		}
+	}
+	p.File.Decls = decls
+
+	return nil
+}
+
+// declKey returns the kind and the name of a declaration, like "type point",
+// or "" for a declaration that can be repeated, like init() or a group of
+// declarations.
+func declKey(decl goast.Decl) string {
+	switch d := decl.(type) {
+	case *goast.FuncDecl:
+		if d.Name.Name == "init" || d.Name.Name == "_" {
+			return ""
+		}
+		if d.Recv != nil && len(d.Recv.List) == 1 {
+			var buf bytes.Buffer
+			if format.Node(&buf, token.NewFileSet(), d.Recv.List[0].Type) != nil {
+				return ""
+			}
+			return fmt.Sprintf("func (%s) %s", buf.String(), d.Name.Name)
+		}
+		return "func " + d.Name.Name
+
+	case *goast.GenDecl:
+		if len(d.Specs) != 1 {
+			return ""
+		}
+		switch s := d.Specs[0].(type) {
+		case *goast.TypeSpec:
+			return "type " + s.Name.Name
+		case *goast.ValueSpec:
+			if len(s.Names) == 1 && s.Names[0].Name != "_" {
+				return fmt.Sprintf("%s %s", d.Tok, s.Names[0].Name)
+			}
+		}
+	}
+
+	return ""
+}
+
+// declSource returns the Go source of a declaration without its comment.
+func (p *Program) declSource(decl goast.Decl) string {
+	switch d := decl.(type) {
+	case *goast.FuncDecl:
+		c := *d
+		c.Doc = nil
+		decl = &c
+	case *goast.GenDecl:
+		c := *d
+		c.Doc = nil
+		decl = &c
+	}
+
+	var buf bytes.Buffer
+	p.formatNode(&buf, decl)
+	return buf.String()
+}
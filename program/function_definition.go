@@ -14,15 +14,62 @@ type FunctionDefinition struct {
 	// The C return type, like "int".
 	ReturnType string
 
-	// The C argument types, like ["bool", "int"]. There is currently no way
-	// to represent a varargs.
+	// The C argument types, like ["bool", "int"]. The variadic part of the
+	// prototype ("...") is not included here, see Variadic.
 	ArgumentTypes []string
 
+	// Restrict has an element for each of ArgumentTypes. It is true when the
+	// argument is a restrict-qualified pointer, like "int *restrict". Go has no
+	// equivalent so the qualifier is only kept for the information.
+	Restrict []bool
+
+	// StringParameters is nil or has an element for each of ArgumentTypes. It
+	// is true when the "const char *" argument is passed as a Go string, see
+	// Program.StringParameters.
+	StringParameters []bool
+
+	// TranslationUnit is the C file of a static function, that is only
+	// visible in that file. It is empty for the other functions. A static
+	// function that collides with a function of another input file is renamed
+	// by the preprocessor before clang parses the files, like helper_main for
+	// helper() of main.c, so that Name is unique in the program.
+	TranslationUnit string
+
+	// Variadic is true when the prototype ends with "...". Any arguments
+	// after ArgumentTypes are passed through to the Go variadic parameter
+	// without being cast.
+	Variadic bool
+
+	// NoReturn is true for the functions that never return, like the ones
+	// declared with _Noreturn. The Go function has no result even if the C
+	// function has a return type.
+	NoReturn bool
+
+	// Format is the archetype of the format attribute of the function, like
+	// "printf" for __attribute__((format(printf, 1, 2))). It is empty when the
+	// function has no format attribute. FormatIndex is the position (from 1)
+	// of the format string in the arguments and FormatFirstArgument is the
+	// position of the first argument that is formatted, or 0 when they are
+	// passed as a va_list.
+	Format              string
+	FormatIndex         int
+	FormatFirstArgument int
+
+	// ReturnsErrno is true for the functions that are transpiled with an
+	// extra error result, see Program.ErrnoFunctions.
+	ReturnsErrno bool
+
 	// If this is not empty then this function name should be used instead
 	// of the Name. Many low level functions have an exact match with a Go
 	// function. For example, "sin()".
 	Substitution string
 
+	// SubstitutionImports are the packages that are needed by a Substitution
+	// that was registered with Program.RegisterSubstitution(). When it is
+	// empty the package is taken from the Substitution itself, like
+	// "github.com/elliotchance/c2go/noarch.Strlen".
+	SubstitutionImports []string
+
 	// Can be overridden with the substitution to rearrange the return variables
 	// and parameters. When either of these are nil the behavior is to keep the
 	// single return value and parameters the same.
@@ -56,6 +103,18 @@ type FunctionDefinition struct {
 //
 //     size_t fread(void*, size_t, size_t, FILE*) -> $0 = noarch.Fread(&1, $2, $3, $4)
 //
+// inlineFunctionDefinitions are the functions that the system headers define
+// (not only declare) inline. Their C definitions appear in the AST, but they
+// cannot be transpiled so they must be replaced by a Go implementation. These
+// definitions are always loaded because the function may come from a header
+// that is only included indirectly.
+var inlineFunctionDefinitions = []string{
+	// darwin/math.h
+	"int __inline_signbitf(float) -> noarch.Signbitf",
+	"int __inline_signbitd(double) -> noarch.Signbitd",
+	"int __inline_signbitl(long double) -> noarch.Signbitl",
+}
+
 var builtInFunctionDefinitions = map[string][]string{
 	"assert.h": []string{
 		// darwin/assert.h
@@ -105,9 +164,6 @@ var builtInFunctionDefinitions = map[string][]string{
 		"Double2 __sincos_stret(double) -> darwin.SincosStret",
 		"Float2 __sincosf_stret(float) -> darwin.SincosfStret",
 		"float __builtin_huge_valf() -> darwin.Inff",
-		"int __inline_signbitf(float) -> noarch.Signbitf",
-		"int __inline_signbitd(double) -> noarch.Signbitd",
-		"int __inline_signbitl(long double) -> noarch.Signbitl",
 		"double __builtin_nanf(const char*) -> darwin.NaN",
 
 		// math.h
@@ -141,6 +197,7 @@ var builtInFunctionDefinitions = map[string][]string{
 		// stdio.h
 		"int printf(const char*) -> noarch.Printf",
 		"int scanf(const char*) -> noarch.Scanf",
+		"int sscanf(const char*, const char*) -> noarch.Sscanf",
 		"int putchar(int) -> noarch.Putchar",
 		"int puts(const char *) -> noarch.Puts",
 		"FILE* fopen(const char *, const char *) -> noarch.Fopen",
@@ -160,9 +217,11 @@ var builtInFunctionDefinitions = map[string][]string{
 		"int fgetc(FILE*) -> noarch.Fgetc",
 		"int fputc(int, FILE*) -> noarch.Fputc",
 		"int getc(FILE*) -> noarch.Fgetc",
+		"int ungetc(int, FILE*) -> noarch.Ungetc",
 		"int getchar() -> noarch.Getchar",
 		"int putc(int, FILE*) -> noarch.Fputc",
-		"int fseek(FILE*, long int, int) -> noarch.Fseek",
+		// should be: "int fseek(FILE*, long int, int) -> noarch.Fseek"
+		"int fseek(FILE*, int, int) -> noarch.Fseek",
 		"long ftell(FILE*) -> noarch.Ftell",
 		"int fread(void*, int, int, FILE*) -> noarch.Fread",
 		"int fwrite(char*, int, int, FILE*) -> noarch.Fwrite",
@@ -233,22 +292,36 @@ var builtInFunctionDefinitions = map[string][]string{
 		"void* __builtin___memmove_chk(void *, void *, int, int) -> darwin.Memcpy",
 		"void* __inline_memmove_chk(void *, void *, int) -> noarch.Memcpy",
 	},
+	"setjmp.h": []string{
+		// setjmp() has no substitute, it is transpiled together with the code
+		// that follows it.
+		"void longjmp(struct __jmp_buf_tag *, int) -> noarch.Longjmp",
+		"void _longjmp(struct __jmp_buf_tag *, int) -> noarch.Longjmp",
+		"void siglongjmp(struct __jmp_buf_tag *, int) -> noarch.Longjmp",
+	},
 	"stdlib.h": []string{
 		// stdlib.h
 		"int abs(int) -> noarch.Abs",
 		"double atof(const char *) -> noarch.Atof",
 		"int atoi(const char*) -> noarch.Atoi",
-		"long int atol(const char*) -> noarch.Atol",
+		"int atexit(void (*)(void)) -> noarch.Atexit",
+		// The width of long depends on the ABI, but noarch.Labs(), Ldiv()
+		// and Strtoul() use 32 bits.
+		"long atol(const char*) -> noarch.Atol",
 		"long long int atoll(const char*) -> noarch.Atoll",
 		"div_t div(int, int) -> noarch.Div",
 		"void exit(int) -> noarch.Exit",
 		"void free(void*) -> noarch.Free",
 		"char* getenv(const char *) -> noarch.Getenv",
-		"long int labs(long int) -> noarch.Labs",
-		"ldiv_t ldiv(long int, long int) -> noarch.Ldiv",
+		"int labs(int) -> noarch.Labs",
+		"ldiv_t ldiv(int, int) -> noarch.Ldiv",
 		"long long int llabs(long long int) -> noarch.Llabs",
 		"lldiv_t lldiv(long long int, long long int) -> noarch.Lldiv",
+		// The size is a size_t, but noarch.Malloc() takes an int32.
+		"void* malloc(int) -> noarch.Malloc",
 		"int rand() -> noarch.Rand",
+		// The size is a size_t, but noarch.Realloc() takes an int32.
+		"void* realloc(void*, int) -> noarch.Realloc",
 		// The real definition is srand(unsigned int) however the type would be
 		// different. It's easier to change the definition than create a proxy
 		// function in stdlib.go.
@@ -258,7 +331,7 @@ var builtInFunctionDefinitions = map[string][]string{
 		"long strtol(const char *, char **, int) -> noarch.Strtol",
 		"long double strtold(const char *, char **) -> noarch.Strtold",
 		"long long strtoll(const char *, char **, int) -> noarch.Strtoll",
-		"long unsigned int strtoul(const char *, char **, int) -> noarch.Strtoul",
+		"unsigned int strtoul(const char *, char **, int) -> noarch.Strtoul",
 		"long long unsigned int strtoull(const char *, char **, int) -> noarch.Strtoull",
 		"void free(void*) -> noarch.Free",
 	},
@@ -278,6 +351,11 @@ var builtInFunctionDefinitions = map[string][]string{
 		"time_t mktime(struct tm *) -> noarch.Mktime",
 		"char * asctime(struct tm *) -> noarch.Asctime",
 	},
+	"wchar.h": []string{
+		// real return type is "size_t", but it is changed to "int"
+		// in according to noarch.Strlen
+		"int wcslen(const wchar_t *) -> noarch.Wcslen",
+	},
 	"endian.h": []string{
 		// I'm not sure which header file these comes from?
 		"uint32 __builtin_bswap32(uint32) -> darwin.BSwap32",
@@ -305,13 +383,44 @@ func (p *Program) GetFunctionDefinition(functionName string) *FunctionDefinition
 }
 
 // AddFunctionDefinition registers a function definition. If the definition
-// already exists it will be replaced.
+// already exists it will be replaced. A substitution that was registered for
+// the function with RegisterSubstitution() replaces the one of the definition.
 func (p *Program) AddFunctionDefinition(f FunctionDefinition) {
 	p.loadFunctionDefinitions()
 
+	if s, ok := p.substitutions[f.Name]; ok {
+		f.Substitution = s.goExpr
+		f.SubstitutionImports = s.imports
+		f.ReturnParameters = nil
+		f.Parameters = nil
+	}
+
 	p.functionDefinitions[f.Name] = f
 }
 
+type substitution struct {
+	goExpr  string
+	imports []string
+}
+
+// RegisterSubstitution replaces the C function with the name by a Go function.
+// The calls of the function are transpiled as calls of goExpr, with the same
+// arguments, and the C definition of the function is not transpiled. The
+// imports are the packages that goExpr needs, for example:
+//
+//     p.RegisterSubstitution("strlen", "mystring.Len",
+//         []string{"github.com/me/mystring"})
+//
+// The substitutions must be registered before the program is transpiled. They
+// replace the built-in definitions as well, like the one of "strlen" above.
+func (p *Program) RegisterSubstitution(name, goExpr string, imports []string) {
+	p.substitutions[name] = substitution{goExpr, imports}
+
+	if f, ok := p.functionDefinitions[name]; ok {
+		p.AddFunctionDefinition(f)
+	}
+}
+
 // dollarArgumentsToIntSlice converts a list of dollar arguments, like "$1, &2"
 // into a slice of integers; [1, -2].
 //
@@ -351,51 +460,61 @@ func (p *Program) loadFunctionDefinitions() {
 		}
 
 		for _, f := range v {
-			match := util.GetRegex(`^(.+) ([^ ]+)\(([, a-z*A-Z_0-9]*)\)( -> .+)?$`).
-				FindStringSubmatch(f)
-
-			// Unpack argument types.
-			argumentTypes := strings.Split(match[3], ",")
-			for i := range argumentTypes {
-				argumentTypes[i] = strings.TrimSpace(argumentTypes[i])
-			}
-			if len(argumentTypes) == 1 && argumentTypes[0] == "" {
-				argumentTypes = []string{}
-			}
-
-			// Defaults for transformations.
-			var returnParameters, parameters []int
-
-			// Substitution rules.
-			substitution := match[4]
-			if substitution != "" {
-				substitution = strings.TrimLeft(substitution, " ->")
-
-				// The substitution might also rearrange the parameters (return and
-				// parameter transformation).
-				subMatch := util.GetRegex(`^(.*?) = (.*)\((.*)\)$`).
-					FindStringSubmatch(substitution)
-				if len(subMatch) > 0 {
-					returnParameters = dollarArgumentsToIntSlice(subMatch[1])
-					parameters = dollarArgumentsToIntSlice(subMatch[3])
-					substitution = subMatch[2]
-				}
-			}
-
-			if strings.HasPrefix(substitution, "darwin.") ||
-				strings.HasPrefix(substitution, "linux.") ||
-				strings.HasPrefix(substitution, "noarch.") {
-				substitution = "github.com/elliotchance/c2go/" + substitution
-			}
-
-			p.AddFunctionDefinition(FunctionDefinition{
-				Name:             match[2],
-				ReturnType:       match[1],
-				ArgumentTypes:    argumentTypes,
-				Substitution:     substitution,
-				ReturnParameters: returnParameters,
-				Parameters:       parameters,
-			})
+			p.addBuiltInFunctionDefinition(f)
 		}
 	}
+
+	for _, f := range inlineFunctionDefinitions {
+		p.addBuiltInFunctionDefinition(f)
+	}
+}
+
+// addBuiltInFunctionDefinition registers a function definition that is in the
+// syntax of builtInFunctionDefinitions.
+func (p *Program) addBuiltInFunctionDefinition(f string) {
+	match := util.GetRegex(`^(.+) ([^ (]+)\(([, a-z*A-Z_0-9()]*)\)( -> .+)?$`).
+		FindStringSubmatch(f)
+
+	// Unpack argument types.
+	argumentTypes := strings.Split(match[3], ",")
+	for i := range argumentTypes {
+		argumentTypes[i] = strings.TrimSpace(argumentTypes[i])
+	}
+	if len(argumentTypes) == 1 && argumentTypes[0] == "" {
+		argumentTypes = []string{}
+	}
+
+	// Defaults for transformations.
+	var returnParameters, parameters []int
+
+	// Substitution rules.
+	substitution := match[4]
+	if substitution != "" {
+		substitution = strings.TrimLeft(substitution, " ->")
+
+		// The substitution might also rearrange the parameters (return and
+		// parameter transformation).
+		subMatch := util.GetRegex(`^(.*?) = (.*)\((.*)\)$`).
+			FindStringSubmatch(substitution)
+		if len(subMatch) > 0 {
+			returnParameters = dollarArgumentsToIntSlice(subMatch[1])
+			parameters = dollarArgumentsToIntSlice(subMatch[3])
+			substitution = subMatch[2]
+		}
+	}
+
+	if strings.HasPrefix(substitution, "darwin.") ||
+		strings.HasPrefix(substitution, "linux.") ||
+		strings.HasPrefix(substitution, "noarch.") {
+		substitution = "github.com/elliotchance/c2go/" + substitution
+	}
+
+	p.AddFunctionDefinition(FunctionDefinition{
+		Name:             match[2],
+		ReturnType:       match[1],
+		ArgumentTypes:    argumentTypes,
+		Substitution:     substitution,
+		ReturnParameters: returnParameters,
+		Parameters:       parameters,
+	})
 }
@@ -1,13 +1,57 @@
 package program
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 )
 
-// Imports returns all of the Go imports for this program.
+// Imports returns all of the quoted Go imports for this program in the order
+// of ImportGroups(), so that the output does not depend on the order in which
+// the imports were added.
 func (p *Program) Imports() []string {
-	return p.imports
+	imports := []string{}
+	for _, group := range p.ImportGroups() {
+		imports = append(imports, group...)
+	}
+
+	return imports
+}
+
+// ImportGroups returns the quoted Go imports in two sorted groups, like
+// goimports does: the packages of the standard library and then all of the
+// other packages. A group without imports is left out.
+func (p *Program) ImportGroups() [][]string {
+	var std, other []string
+	for _, quotedImportPath := range p.imports {
+		if isStandardImport(quotedImportPath) {
+			std = append(std, quotedImportPath)
+		} else {
+			other = append(other, quotedImportPath)
+		}
+	}
+
+	groups := [][]string{}
+	for _, group := range [][]string{std, other} {
+		if len(group) > 0 {
+			sort.Strings(group)
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// isStandardImport returns true if the quoted import path is a package of the
+// standard library. Only the other packages have a domain, with a dot, as the
+// first element of the path.
+func isStandardImport(quotedImportPath string) bool {
+	importPath, err := strconv.Unquote(quotedImportPath)
+	if err != nil {
+		importPath = quotedImportPath
+	}
+
+	return !strings.Contains(strings.Split(importPath, "/")[0], ".")
 }
 
 // AddImport will append an absolute import if it is unique to the list of
@@ -10,6 +10,7 @@ import (
 
 	goast "go/ast"
 
+	"regexp"
 	"strings"
 
 	"github.com/elliotchance/c2go/ast"
@@ -40,12 +41,24 @@ type Program struct {
 	// name appears.
 	typesAlreadyDefined []string
 
+	// localTypes are the types that are shadowed by the typedefs of the
+	// current function, see DefineLocalType().
+	localTypes map[string]shadowedType
+
 	// Contains the current function name during the transpilation.
 	Function *ast.FunctionDecl
 
+	// UsesAtexit is true if the C code calls atexit(). The main() function
+	// then returns with noarch.Exit(), that calls the registered functions.
+	UsesAtexit bool
+
 	functionDefinitions                      map[string]FunctionDefinition
 	builtInFunctionDefinitionsHaveBeenLoaded bool
 
+	// The substitutions that were registered with RegisterSubstitution(). The
+	// key is the name of the C function.
+	substitutions map[string]substitution
+
 	// These are used to setup the runtime before the application begins. An
 	// example would be to setup globals with stdin file pointers on certain
 	// platforms.
@@ -73,6 +86,15 @@ type Program struct {
 	// in output Go code
 	messagePosition int
 
+	// transpiledFunctions are the Go functions of the C functions, see
+	// AddTranspiledFunction().
+	transpiledFunctions []*goast.FuncDecl
+
+	// functionMessages collects the messages of the function that is being
+	// transpiled. It is nil when the messages are not collected, see
+	// BeginFunctionMessages().
+	functionMessages []string
+
 	// A map of all the global variables (variables that exist outside of a
 	// function) and their types.
 	GlobalVariables map[string]string
@@ -82,6 +104,146 @@ type Program struct {
 	// Go-test rather than a standalone Go file.
 	OutputAsTest bool
 
+	// LongDoubleType is how the C "long double" is represented. When it is
+	// empty the type is float64. It can be set to LongDoubleBigFloat to keep
+	// the extended precision of long double, at the cost of generating method
+	// calls for the arithmetic. Any other value is rejected by the transpiler.
+	LongDoubleType string
+
+	// UnionMemory is how the members of a union share their memory. When it
+	// is empty or UnionMemoryArray the memory is an array of bytes in the
+	// struct of the union. It can be set to UnionMemoryPointer to keep the
+	// memory behind an unsafe.Pointer instead. The unions with pointer
+	// members always keep their memory behind a pointer, so that the garbage
+	// collector sees the pointers.
+	UnionMemory string
+
+	// Defines are the preprocessor macros that were defined for the C source,
+	// like "DEBUG" or "LEVEL=2". They are listed in the comment at the top of
+	// the Go file, because the Go code only contains the branches of #ifdef
+	// that were active.
+	Defines []string
+
+	// BuildConstraint is the expression of a "//go:build" line that is put
+	// at the top of the Go file, like "linux && amd64". There is no build
+	// constraint when it is empty.
+	BuildConstraint string
+
+	// PlusBuild is the same build constraint for the "// +build" line, like
+	// "linux,amd64", that is needed by the versions of Go before 1.17.
+	PlusBuild string
+
+	// ABI is the target platform of the C code. It is used for the sizes of
+	// the types, like sizeof(long), and for the Go types of the integers
+	// whose width depends on it, like long. NewProgram() sets it to LP64.
+	ABI *ABI
+
+	// VolatileAtomic turns the reads and writes of volatile integers into
+	// calls of sync/atomic, like atomic.LoadInt32(). When it is false the
+	// volatile qualifier is removed and they are plain variables.
+	VolatileAtomic bool
+
+	// TailCalls turns the functions that only call themselves directly in a
+	// return statement into loops, so that a deep recursion does not grow the
+	// stack of the goroutine. It is off by default.
+	TailCalls bool
+
+	// InlineFunctions replaces the calls of the functions that are declared
+	// inline and only return an expression, like an accessor, by that
+	// expression with the arguments in place of the parameters. A function
+	// that is inlined at all of its calls is not transpiled. It is off by
+	// default.
+	InlineFunctions bool
+
+	// InlinedFunctions are the functions that are inlined at their calls
+	// when InlineFunctions is on. The key is the name of the C function.
+	InlinedFunctions map[string]*ast.FunctionDecl
+
+	// StringParameters transpiles the "const char *" parameters of the
+	// functions that are defined in the C code into Go strings, so that Go
+	// code can call them with a string. The string is copied into a C string
+	// at the start of the function. A parameter stays a *byte when the
+	// function may write to its characters, or when the function is used as
+	// a function pointer. Note that a NULL argument becomes an empty string.
+	// It is off by default.
+	StringParameters bool
+
+	// CheckedOverflow turns the signed integer arithmetic into calls of the
+	// noarch package, like noarch.CheckedAddInt32(), that print a warning at
+	// run time when the result overflows. The result still wraps around like
+	// in Go.
+	CheckedOverflow bool
+
+	// Pack is the maximum alignment in bytes of the fields of the structs
+	// that do not have a packing of their own, like "#pragma pack(2)" at the
+	// start of the C source. It is 0 for the natural alignment. See
+	// Struct.Pack.
+	Pack int
+
+	// DisableDeferredFree turns off replacing free() of memory that was
+	// allocated at the top of a function with a single defer statement. When
+	// it is true every free() is transpiled where it appears in the C code.
+	DisableDeferredFree bool
+
+	// DisableConstComments turns off the "// const parameters:" comment that
+	// lists the parameters of a function whose C type is const-qualified. The
+	// qualifier itself has no equivalent in Go so it is otherwise lost.
+	DisableConstComments bool
+
+	// ErrnoFunctions are the names of the C functions that report a failure
+	// through errno, like a wrapper of open(). These functions are transpiled
+	// with an extra error result that contains the errno at the time of
+	// return. The main() function is never changed.
+	ErrnoFunctions []string
+
+	// MethodFunctions are the names of the C functions that are transpiled
+	// into methods of the struct that their first parameter points to, like:
+	//
+	//     void list_push(List *l, int v)   =>   func (l *List) Push(v int32)
+	//
+	// The name of the struct is removed from the start of the method name.
+	// The calls are changed to calls of the method. A function that does not
+	// have a pointer to a struct as the first parameter stays a function.
+	MethodFunctions []string
+
+	// ExportFunctions are the regular expressions of the names of the C
+	// functions that are exported from the Go package, like "parse_.*". The
+	// expression must match the whole name. The first letter of the name is
+	// made upper case, like Parse_line() for parse_line(). The calls are
+	// renamed too. Only the functions that are defined by the C code can be
+	// exported.
+	ExportFunctions []string
+
+	// exportRegexps are the compiled ExportFunctions. They are compiled by the
+	// first call of IsExportFunction().
+	exportRegexps []*regexp.Regexp
+
+	// FunctionMessageSummary attaches all of the messages that were generated
+	// while transpiling the body of a function to the doc comment of the
+	// function, so that it is easier to find the functions that need work in
+	// a large translation. The messages are still written at the top of the
+	// output.
+	FunctionMessageSummary bool
+
+	// LineComments attaches a "// #line 12 \"file.c\"" comment with the
+	// location in the C code to each top-level declaration. The location of
+	// a declaration that is expanded from a macro of a header is the first
+	// one that is in the C files, if there is one.
+	LineComments bool
+
+	// BuiltinHints are the builtins that only give a hint to the compiler,
+	// like __builtin_expect(). A call of one of them is transpiled into what
+	// it does for the program. The key is the name of the builtin and the
+	// value is the message of the panic() that replaces the call, or empty
+	// when the value of the call is its first argument. When it is nil the
+	// builtins are DefaultBuiltinHints. See BuiltinHint().
+	BuiltinHints map[string]string
+
+	// GoKeywordSuffix is appended to the C identifiers that are reserved
+	// words in Go, like a parameter named "type". When it is empty the suffix
+	// is util.DefaultGoKeywordSuffix. See GoIdentifier().
+	GoKeywordSuffix string
+
 	// EnumConstantToEnum - a map with key="EnumConstant" and value="enum type"
 	// clang don`t show enum constant with enum type,
 	// so we have to use hack for repair the type
@@ -100,6 +262,11 @@ type Program struct {
 	// Comments
 	Comments []Comment
 
+	// SourceLines are the lines of the preprocessed C source by the file and
+	// the line number, see preprocessor.SourceLines(). They are needed for
+	// what is not in the clang AST, like the member of offsetof().
+	SourceLines map[string]map[int]string
+
 	// commentLine - a map with:
 	// key    - filename
 	// value  - last comment inserted in Go code
@@ -108,10 +275,50 @@ type Program struct {
 	// IncludeHeaders - list of C header
 	IncludeHeaders []IncludeHeader
 
+	// Macros are the object-like macros of the C files and the headers of
+	// the user, in the order they are defined. The ones with an integer value
+	// are transpiled into constants. It is empty unless they are requested
+	// with the -macro-consts flag, because the macros are not in the AST.
+	Macros []Macro
+
 	// NodeMap - a map containing all the program's nodes with:
 	// key    - the node address
 	// value  - the node
 	NodeMap map[ast.Address]ast.Node
+
+	// PreviousEnumConstant is the address of the enum constant before each
+	// enum constant of the same enum, by its address. It is 0 for the first
+	// constant of an enum. See SetNodes().
+	PreviousEnumConstant map[ast.Address]ast.Address
+}
+
+// LongDoubleBigFloat is the type for Program.LongDoubleType that is backed by
+// big.Float in the runtime package.
+const LongDoubleBigFloat = "github.com/elliotchance/c2go/noarch.LongDouble"
+
+// These are the values for Program.UnionMemory, that are also used by the
+// -union flag.
+const (
+	// UnionMemoryArray keeps the memory of a union in an array of bytes, so
+	// an assignment copies the union like in C.
+	UnionMemoryArray = "array"
+
+	// UnionMemoryPointer keeps the memory of a union behind a pointer that is
+	// allocated when a member is used for the first time. An assignment
+	// shares the memory between the unions.
+	UnionMemoryPointer = "pointer"
+)
+
+// DefaultBuiltinHints are the builtins of Program.BuiltinHints when it is nil:
+//
+//     if (__builtin_expect(n > 0, 1))    =>    if n > 0
+//     __builtin_unreachable();           =>    panic("unreachable")
+//     __builtin_trap();                  =>    panic("trap")
+var DefaultBuiltinHints = map[string]string{
+	"__builtin_expect":                  "",
+	"__builtin_expect_with_probability": "",
+	"__builtin_unreachable":             "unreachable",
+	"__builtin_trap":                    "trap",
 }
 
 // Comment - position of line comment '//...'
@@ -121,6 +328,13 @@ type Comment struct {
 	Comment string
 }
 
+// Macro is an object-like macro, like "#define SIZE 10".
+type Macro struct {
+	File  string
+	Name  string
+	Value string
+}
+
 // IncludeHeader - struct for C include header
 type IncludeHeader struct {
 	HeaderName   string
@@ -169,7 +383,9 @@ func NewProgram() (p *Program) {
 		commentLine:         map[string]int{},
 		IncludeHeaders:      []IncludeHeader{},
 		functionDefinitions: map[string]FunctionDefinition{},
+		substitutions:       map[string]substitution{},
 		NodeMap:             map[ast.Address]ast.Node{},
+		ABI:                 LP64,
 		builtInFunctionDefinitionsHaveBeenLoaded: false,
 	}
 }
@@ -190,6 +406,9 @@ func (p *Program) AddMessage(message string) bool {
 	}
 
 	p.messages = append(p.messages, message)
+	if p.functionMessages != nil {
+		p.functionMessages = append(p.functionMessages, message)
+	}
 
 	// Compactizarion warnings stack
 	if len(p.messages) > 1 {
@@ -211,6 +430,33 @@ func (p *Program) AddMessage(message string) bool {
 	return true
 }
 
+// Messages returns the warnings and the errors that were added while the AST
+// was transpiled, like "Warning (GCCAsmStmt): x.c:3 : cannot transpile asm,
+// will be ignored". They are the constructs of the C code that could not be
+// transpiled, or only partly, see AddMessage().
+func (p *Program) Messages() []string {
+	messages := make([]string, len(p.messages))
+	for i, message := range p.messages {
+		messages[i] = strings.TrimPrefix(message, "// ")
+	}
+	return messages
+}
+
+// BeginFunctionMessages starts to collect the messages of a function. All of
+// the messages that are added until EndFunctionMessages() is called are
+// returned by it.
+func (p *Program) BeginFunctionMessages() {
+	p.functionMessages = []string{}
+}
+
+// EndFunctionMessages stops collecting the messages of a function and returns
+// them.
+func (p *Program) EndFunctionMessages() (messages []string) {
+	messages = p.functionMessages
+	p.functionMessages = nil
+	return
+}
+
 // GetMessageComments - get messages "Warnings", "Error" like a comment
 // Location of comments only NEAR of error or warning and
 // don't show directly location
@@ -307,6 +553,71 @@ func (p *Program) DefineType(typeName string) {
 	p.typesAlreadyDefined = append(p.typesAlreadyDefined, typeName)
 }
 
+// shadowedType is a type of the file that has the name of a typedef of a
+// function.
+type shadowedType struct {
+	typedef   string
+	isTypedef bool
+	record    *Struct
+}
+
+// DefineLocalType records a typedef of the current function. Go allows a type
+// declaration in a function too, so it is not recorded by DefineType() and it
+// can have the name of a type of the file. The types of the file with the
+// same name are restored by EndLocalTypes() at the end of the function.
+func (p *Program) DefineLocalType(typeName string) {
+	if p.localTypes == nil {
+		p.localTypes = map[string]shadowedType{}
+	}
+	if _, ok := p.localTypes[typeName]; ok {
+		return
+	}
+	typedef, isTypedef := p.TypedefType[typeName]
+	p.localTypes[typeName] = shadowedType{
+		typedef:   typedef,
+		isTypedef: isTypedef,
+		record:    p.Structs["struct "+typeName],
+	}
+}
+
+// EndLocalTypes removes the typedefs of the function and restores the types
+// of the file that they shadowed, see DefineLocalType().
+func (p *Program) EndLocalTypes() {
+	for name, t := range p.localTypes {
+		if t.isTypedef {
+			p.TypedefType[name] = t.typedef
+		} else {
+			delete(p.TypedefType, name)
+		}
+		if t.record != nil {
+			p.Structs["struct "+name] = t.record
+		} else {
+			delete(p.Structs, "struct "+name)
+		}
+	}
+	p.localTypes = nil
+}
+
+// GoIdentifier returns the Go name of a C identifier. A name that is a Go
+// keyword gets the GoKeywordSuffix, so it must be used for the declaration
+// and for every reference of the identifier.
+func (p *Program) GoIdentifier(name string) string {
+	return util.MangleGoKeyword(name, p.GoKeywordSuffix)
+}
+
+// BuiltinHint returns the message of the panic() for a call of the builtin
+// hint, or an empty message when the value of the call is its first argument.
+// ok is false if the function is not one of the BuiltinHints.
+func (p *Program) BuiltinHint(name string) (message string, ok bool) {
+	hints := p.BuiltinHints
+	if hints == nil {
+		hints = DefaultBuiltinHints
+	}
+	message, ok = hints[name]
+
+	return
+}
+
 // GetNextIdentifier generates a new globally unique identifier name. This can
 // be used for variables and functions in generated code.
 //
@@ -326,23 +637,67 @@ func (p *Program) GetNextIdentifier(prefix string) string {
 // String generates the whole output Go file as a string. This will include the
 // messages at the top of the file and all the rendered Go code.
 func (p *Program) String() string {
+	return p.source(p.File)
+}
+
+// buildConstraintLines returns the "//go:build" and "// +build" lines, and the
+// blank line after them, for the top of a Go file.
+func (p *Program) buildConstraintLines() string {
+	if p.BuildConstraint == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("//go:build %s\n// +build %s\n\n", p.BuildConstraint, p.PlusBuild)
+}
+
+// source generates the output Go file with the declarations of the file, see
+// String().
+func (p *Program) source(file *goast.File) string {
 	var buf bytes.Buffer
 
+	buf.WriteString(p.buildConstraintLines())
+
+	var defines string
+	if len(p.Defines) > 0 {
+		defines = "\n\tTranspiled with the preprocessor defines:\n\n"
+		for _, define := range p.Defines {
+			defines += "\t\t" + define + "\n"
+		}
+	}
+
 	buf.WriteString(fmt.Sprintf(`/*
 	Package %s - transpiled by c2go version: %s
-
+%s
 	If you have found any issues, please raise an issue at:
 	https://github.com/elliotchance/c2go/
 */
 
-`, p.File.Name.Name, Version))
+`, p.File.Name.Name, Version, defines))
 
 	// First write all the messages. The double newline afterwards is important
 	// so that the package statement has a newline above it so that the warnings
 	// are not part of the documentation for the package.
 	buf.WriteString(strings.Join(p.messages, "\n") + "\n\n")
 
-	if err := format.Node(&buf, p.FileSet, p.File); err != nil {
+	p.formatNode(&buf, file)
+
+	// Add comments at the end C file
+	for file, beginLine := range p.commentLine {
+		for i := range p.Comments {
+			if p.Comments[i].File == file {
+				if beginLine < p.Comments[i].Line {
+					buf.WriteString(fmt.Sprintln(p.Comments[i].Comment))
+				}
+			}
+		}
+	}
+
+	return simplifyInterfaces(buf.Bytes())
+}
+
+// formatNode writes the Go source of a file or a declaration.
+func (p *Program) formatNode(buf *bytes.Buffer, node interface{}) {
+	if err := format.Node(buf, p.FileSet, node); err != nil {
 		// Printing the entire AST will generate a lot of output. However, it is
 		// the only way to debug this type of error. Hopefully the error
 		// (printed immediately afterwards) will give a clue.
@@ -393,32 +748,67 @@ func (p *Program) String() string {
 		// Looking at the full output of the AST (thousands of lines) and
 		// looking at those line numbers should give you a good idea where the
 		// error is coming from; by looking at the parents of the bad lines.
-		_ = goast.Print(p.FileSet, p.File)
+		_ = goast.Print(p.FileSet, node)
 
 		panic(err)
 	}
+}
 
-	// Add comments at the end C file
-	for file, beginLine := range p.commentLine {
-		for i := range p.Comments {
-			if p.Comments[i].File == file {
-				if beginLine < p.Comments[i].Line {
-					buf.WriteString(fmt.Sprintln(p.Comments[i].Comment))
-				}
-			}
+// simplifyInterfaces simplifies the Go code. Example :
+// Before:
+// func compare(a interface {
+// }, b interface {
+// }) (c2goDefaultReturn int) {
+// After :
+// func compare(a interface {}, b interface {}) (c2goDefaultReturn int) {
+func simplifyInterfaces(src []byte) string {
+	reg := util.GetRegex("interface( )?{(\r*)\n(\t*)}")
+
+	return string(reg.ReplaceAll(src, []byte("interface {}")))
+}
+
+// IsErrnoFunction returns true if the function is one of ErrnoFunctions.
+func (p *Program) IsErrnoFunction(name string) bool {
+	if name == "main" {
+		return false
+	}
+	for _, f := range p.ErrnoFunctions {
+		if f == name {
+			return true
 		}
 	}
+	return false
+}
 
-	// simplify Go code. Example :
-	// Before:
-	// func compare(a interface {
-	// }, b interface {
-	// }) (c2goDefaultReturn int) {
-	// After :
-	// func compare(a interface {}, b interface {}) (c2goDefaultReturn int) {
-	reg := util.GetRegex("interface( )?{(\r*)\n(\t*)}")
+// IsMethodFunction returns true if the function is one of MethodFunctions.
+func (p *Program) IsMethodFunction(name string) bool {
+	if name == "main" {
+		return false
+	}
+	return util.InStrings(name, p.MethodFunctions)
+}
 
-	return string(reg.ReplaceAll(buf.Bytes(), []byte("interface {}")))
+// IsExportFunction returns true if the function matches one of
+// ExportFunctions. An invalid regular expression does not match any function.
+func (p *Program) IsExportFunction(name string) bool {
+	if name == "main" {
+		return false
+	}
+	if p.exportRegexps == nil {
+		p.exportRegexps = []*regexp.Regexp{}
+		for _, pattern := range p.ExportFunctions {
+			if _, err := regexp.Compile(pattern); err == nil {
+				p.exportRegexps = append(p.exportRegexps,
+					util.GetRegex(`^(?:`+pattern+`)$`))
+			}
+		}
+	}
+	for _, re := range p.exportRegexps {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
 }
 
 // IncludeHeaderIsExists - return true if C #include header is inside list
@@ -447,6 +837,18 @@ func (p *Program) SetNodes(nodes []ast.Node) {
 		if setNode {
 			p.NodeMap[addr] = n
 		}
+		if enum, ok := n.(*ast.EnumDecl); ok {
+			if p.PreviousEnumConstant == nil {
+				p.PreviousEnumConstant = map[ast.Address]ast.Address{}
+			}
+			var previous ast.Address
+			for _, c := range enum.Children() {
+				if constant, ok := c.(*ast.EnumConstantDecl); ok {
+					p.PreviousEnumConstant[constant.Addr] = previous
+					previous = constant.Addr
+				}
+			}
+		}
 		p.SetNodes(n.Children())
 	}
 }
@@ -0,0 +1,162 @@
+package program
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+// AddTranspiledFunction registers the Go function of a C function, so that it
+// can be written into a file of its own by SplitFiles().
+func (p *Program) AddTranspiledFunction(f *goast.FuncDecl) {
+	p.transpiledFunctions = append(p.transpiledFunctions, f)
+}
+
+// SplitFiles returns the output Go files when each transpiled C function is
+// written into a file of its own, so that the output of a large C file is
+// easier to compare. The keys are the names of the files. All of the other
+// declarations, like the types, the global variables and init(), are in the
+// shared file fileName together with the messages, like String().
+//
+// The file of a function is named after the shared file and the function,
+// like "app.main.go" for "app.go". Go only takes the build constraints of a
+// file name from the part before the first dot, so the name of a function,
+// like "linux", cannot add a constraint. A name that ends in "_test" gets an
+// extra underscore, otherwise it would be a test file.
+//
+// Each file only imports the packages that are used in it.
+func (p *Program) SplitFiles(fileName string) map[string]string {
+	isTranspiled := map[*goast.FuncDecl]bool{}
+	for _, f := range p.transpiledFunctions {
+		isTranspiled[f] = true
+	}
+
+	var imports *goast.GenDecl
+	var shared []goast.Decl
+	var functions []*goast.FuncDecl
+	for _, decl := range p.File.Decls {
+		if d, ok := decl.(*goast.GenDecl); ok && d.Tok == token.IMPORT {
+			imports = d
+			continue
+		}
+		if f, ok := decl.(*goast.FuncDecl); ok && isTranspiled[f] {
+			functions = append(functions, f)
+			continue
+		}
+		shared = append(shared, decl)
+	}
+
+	base := strings.TrimSuffix(fileName, ".go")
+	files := map[string]string{
+		fileName: p.source(p.newFile(imports, shared)),
+	}
+	for _, f := range functions {
+		name := f.Name.Name
+		if f.Recv != nil && len(f.Recv.List) > 0 {
+			name = receiverName(f.Recv.List[0].Type) + "." + name
+		}
+		if strings.HasSuffix(name, "_test") {
+			name += "_"
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(p.buildConstraintLines())
+		p.formatNode(&buf, p.newFile(imports, []goast.Decl{f}))
+
+		// The doc comments have no positions, so go/printer does not put a
+		// blank line between the package clause and the doc comment of a
+		// function without imports.
+		clause := "package " + p.File.Name.Name + "\n"
+		src := strings.Replace(buf.String(), clause+"//", clause+"\n//", 1)
+		files[base+"."+name+".go"] = simplifyInterfaces([]byte(src))
+	}
+
+	return files
+}
+
+// newFile returns a Go file of the package with the declarations. Only the
+// imports that are used by the declarations are kept.
+func (p *Program) newFile(imports *goast.GenDecl, decls []goast.Decl) *goast.File {
+	file := &goast.File{
+		Name:  p.File.Name,
+		Decls: decls,
+	}
+	if imports == nil {
+		return file
+	}
+
+	used := usedPackages(decls)
+	var specs []goast.Spec
+	for _, spec := range imports.Specs {
+		if used[importName(spec.(*goast.ImportSpec))] {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return file
+	}
+
+	// The import specs keep their positions, so the blank line between the
+	// groups of imports is kept as well.
+	importDecl := *imports
+	importDecl.Specs = specs
+	file.Decls = append([]goast.Decl{&importDecl}, decls...)
+
+	return file
+}
+
+// usedPackages returns the names of the packages that are used by the
+// declarations, like "noarch" for "noarch.Strlen". The transpiler also
+// creates identifiers that contain the package, like "*noarch.File", so the
+// names of the identifiers are searched as well.
+func usedPackages(decls []goast.Decl) map[string]bool {
+	used := map[string]bool{}
+	for _, decl := range decls {
+		goast.Inspect(decl, func(node goast.Node) bool {
+			switch n := node.(type) {
+			case *goast.SelectorExpr:
+				if x, ok := n.X.(*goast.Ident); ok {
+					used[x.Name] = true
+				}
+			case *goast.Ident:
+				for _, match := range util.GetRegex(`(\w+)\.`).FindAllStringSubmatch(n.Name, -1) {
+					used[match[1]] = true
+				}
+			}
+			return true
+		})
+	}
+
+	return used
+}
+
+// importName returns the name of the package of an import, like "atomic" for
+// "sync/atomic".
+func importName(spec *goast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	importPath, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		importPath = spec.Path.Value
+	}
+
+	return importPath[strings.LastIndex(importPath, "/")+1:]
+}
+
+// receiverName returns the name of the type of a method receiver, like "List"
+// for "*List".
+func receiverName(expr goast.Expr) string {
+	switch e := expr.(type) {
+	case *goast.StarExpr:
+		return receiverName(e.X)
+	case *goast.Ident:
+		return strings.TrimLeft(e.Name, "*")
+	}
+
+	return "method"
+}
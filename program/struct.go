@@ -2,6 +2,7 @@ package program
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/elliotchance/c2go/ast"
@@ -19,33 +20,67 @@ type Struct struct {
 
 	// Each of the fields and their C type. The field may be a string or an
 	// instance of Struct for nested structures.
+	//
+	// An anonymous struct or union member has no name, so its name is its
+	// type, like "union s::(anonymous at x.c:4:5)". Its members are in Fields
+	// too, because they are members of the enclosing struct in C.
 	Fields map[string]interface{}
 
-	// Each of the field names in the order they were defined.
+	// Each of the field names in the order they were defined. Only the
+	// fields that have storage of their own are listed, the nested records
+	// and the members of an anonymous struct or union are only in Fields.
 	FieldNames []string
+
+	// Bitfields are the widths of the bit-fields of a struct, like 3 for
+	// "unsigned flag : 3". They are packed into integer fields of the Go
+	// struct and are read and written with methods. The bit-fields of a union
+	// are plain fields.
+	Bitfields map[string]int
+
+	// Pack is the maximum alignment of the fields in bytes. It is set by
+	// "#pragma pack(n)", and it is 1 for __attribute__((packed)). It is 0 if
+	// the fields have their natural alignment. The Go struct has the same
+	// fields in the same order, but Go cannot pack them, so the packing only
+	// changes the results of sizeof and alignof.
+	Pack int
 }
 
 // NewStruct creates a new Struct definition from an ast.RecordDecl.
 func NewStruct(n *ast.RecordDecl) *Struct {
 	fields := make(map[string]interface{})
 	fieldNames := make([]string, 0, len(n.Children()))
+	bitfields := make(map[string]int)
+	pack := 0
 
 	for _, field := range n.Children() {
 		switch f := field.(type) {
 		case *ast.FieldDecl:
-			fields[f.Name] = f.Type
-			fieldNames = append(fieldNames, f.Name)
+			name := f.Name
+			if name == "" && f.Implicit {
+				name = f.Type
+			}
+			fields[name] = f.Type
+			fieldNames = append(fieldNames, name)
+			if width, ok := BitfieldWidth(f); ok && n.Kind != "union" {
+				bitfields[f.Name] = width
+			}
 
 		case *ast.IndirectFieldDecl:
 			fields[f.Name] = f.Type
-			fieldNames = append(fieldNames, f.Name)
 
 		case *ast.RecordDecl:
 			fields[f.Name] = NewStruct(f)
-			fieldNames = append(fieldNames, f.Name)
 
-		case *ast.MaxFieldAlignmentAttr,
-			*ast.AlignedAttr,
+		case *ast.MaxFieldAlignmentAttr:
+			// The size of the attribute is in bits.
+			if pack == 0 {
+				pack = f.Size / 8
+			}
+
+		case *ast.PackedAttr:
+			pack = 1
+
+		case *ast.AlignedAttr,
 			*ast.TransparentUnionAttr,
 			*ast.FullComment:
 			// FIXME: Should these really be ignored?
@@ -60,7 +95,32 @@ func NewStruct(n *ast.RecordDecl) *Struct {
 		IsUnion:    n.Kind == "union",
 		Fields:     fields,
 		FieldNames: fieldNames,
+		Bitfields:  bitfields,
+		Pack:       pack,
+	}
+}
+
+// IsAnonymousField returns true if a field is the unnamed field of an
+// anonymous struct or union member. Its name is its type, that has a space
+// unlike the name of a field.
+func (s *Struct) IsAnonymousField(name string) bool {
+	return strings.Contains(name, " ")
+}
+
+// BitfieldWidth returns the number of bits of a bit-field declaration, like 3
+// for "unsigned flag : 3". ok is false if the field is not a bit-field, or if
+// its width is not an integer literal.
+func BitfieldWidth(n *ast.FieldDecl) (width int, ok bool) {
+	for _, c := range n.Children() {
+		if e, isConstant := c.(*ast.ConstantExpr); isConstant && len(e.Children()) > 0 {
+			c = e.Children()[0]
+		}
+		if l, isLiteral := c.(*ast.IntegerLiteral); isLiteral {
+			width, err := strconv.Atoi(l.Value)
+			return width, err == nil
+		}
 	}
+	return 0, false
 }
 
 // IsUnion - return true if the cType is 'union' or
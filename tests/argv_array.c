@@ -0,0 +1,22 @@
+// This file contains tests for the system arguments (argv) when argv is
+// declared as an array.
+
+#include <stdio.h>
+#include "tests.h"
+
+int main(int argc, char *argv[])
+{
+    plan(3);
+
+    // See argv.c for the offset under "go test".
+    int offset = 0;
+    if (argc > 3) {
+        offset = 3;
+    }
+
+    is_streq(argv[1 + offset], "some");
+    is_streq(argv[2 + offset], "args");
+    is_null(argv[argc]);
+
+    done_testing();
+}
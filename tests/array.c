@@ -155,6 +155,41 @@ void test_partialarr_init()
     is_eq(b[2].c, 0);
 }
 
+struct designated {
+    int x;
+    int y;
+    int z;
+};
+
+void test_designated_init()
+{
+    int a[5] = {[2] = 7};
+    is_eq(a[0], 0);
+    is_eq(a[1], 0);
+    is_eq(a[2], 7);
+    is_eq(a[4], 0);
+
+    // Positional elements follow the last designated one.
+    int b[6] = {1, [3] = 4, 5};
+    is_eq(b[0], 1);
+    is_eq(b[2], 0);
+    is_eq(b[3], 4);
+    is_eq(b[4], 5);
+    is_eq(b[5], 0);
+
+    struct designated p = {.z = 3, .x = 1};
+    is_eq(p.x, 1);
+    is_eq(p.y, 0);
+    is_eq(p.z, 3);
+
+    struct designated q[3] = {[1] = {.y = 2}, {9}};
+    is_eq(q[0].y, 0);
+    is_eq(q[1].x, 0);
+    is_eq(q[1].y, 2);
+    is_eq(q[2].x, 9);
+    is_eq(q[2].z, 0);
+}
+
 extern int arrayEx[];
 int arrayEx[4] = { 1, 2, 3, 4 };
 
@@ -246,6 +281,18 @@ void test_pointer_minus_pointer()
 	structs *right_ptr2 = &arr2[20];
 
 	is_eq(right_ptr2 - left_ptr2, 20);
+
+	// long is smaller in Go than in C, the difference is still the number
+	// of elements.
+	long arr3[10] = {10, 11, 12, 13, 14, 15, 16, 17, 18, 19};
+	long *p = &arr3[2];
+	long *q = &arr3[7];
+	is_eq(q - p, 5);
+	is_eq(p - q, -5);
+	is_eq(*(p + 2), 14);
+	is_eq(*(3 + p), 15);
+	is_eq(*(q - 1), 16);
+	is_eq((p + 4) - q, -1);
 }
 
 typedef unsigned char pcre_uchar;
@@ -319,9 +366,142 @@ void test_arr_to_pointer() {
     is_eq(c, 7);
 }
 
+int sum_variable_length_array(int n, int a[n])
+{
+    int total = 0;
+    for (int i = 0; i < n; i++)
+        total += a[i];
+    return total;
+}
+
+void test_variable_length_array(int n)
+{
+    int buf[n * 2];
+    for (int i = 0; i < n * 2; i++)
+        buf[i] = i + 1;
+
+    is_eq(buf[0], 1);
+    is_eq(buf[n * 2 - 1], n * 2);
+    is_eq(sizeof(buf), n * 2 * sizeof(int));
+    is_eq(sum_variable_length_array(n, buf), 10);
+    is_eq(sum_variable_length_array(n * 2, buf), 36);
+}
+
+void fill_squares(int a[5], int n)
+{
+    for (int i = 0; i < n; i++)
+        a[i] = i * i;
+}
+
+int sum_rows(int m[][3], int rows)
+{
+    int total = 0;
+    for (int i = 0; i < rows; i++)
+        for (int j = 0; j < 3; j++)
+            total += m[i][j];
+    return total;
+}
+
+void test_array_parameters()
+{
+    int local[5];
+    fill_squares(local, 5);
+    is_eq(local[4], 16);
+
+    int *buffer = malloc(5 * sizeof(int));
+    fill_squares(buffer, 5);
+    is_eq(buffer[3], 9);
+    free(buffer);
+
+    int m[2][3] = {{1, 2, 3}, {4, 5, 6}};
+    is_eq(sum_rows(m, 2), 21);
+    is_eq(sum_rows(m + 1, 1), 15);
+    is_eq(sum_rows(&m[0], 1), 6);
+}
+
+int pointed_array[10] = {0, 1, 4, 9, 16, 25, 36, 49, 64, 81};
+
+int (*get_pointed_array(void))[10]
+{
+    return &pointed_array;
+}
+
+void test_array_pointers()
+{
+    int x = 1, y = 2;
+
+    // array of pointers
+    int *ap[10];
+    ap[0] = &x;
+    ap[9] = &y;
+    is_eq(*ap[0], 1);
+    is_eq(*ap[9], 2);
+    *ap[9] = 7;
+    is_eq(y, 7);
+
+    // pointer to array
+    int (*pa)[10] = &pointed_array;
+    is_eq((*pa)[3], 9);
+    (*pa)[3] = 10;
+    is_eq(pointed_array[3], 10);
+
+    // function returning a pointer to array
+    is_eq((*get_pointed_array())[9], 81);
+    int (*(*getter)(void))[10] = get_pointed_array;
+    is_eq((*getter())[3], 10);
+}
+
+// The const arrays are defined after the function that uses them.
+extern const int const_table[];
+extern const char *const const_messages[];
+
+void test_const_arrays()
+{
+    int i;
+    int sum = 0;
+    for (i = 0; i < 4; i++)
+        sum += const_table[i];
+    is_eq(sum, 30);
+    is_eq(const_table[3], 16);
+
+    is_streq(const_messages[0], "zero");
+    is_streq(const_messages[2], "two");
+    is_eq(const_messages[1][1], 'n');
+}
+
+const int const_table[] = {2, 4, 8, 16};
+const char *const const_messages[] = {"zero", "one", "two"};
+
+void test_char_arrays_from_strings()
+{
+    char s[] = "hello";
+    is_eq(sizeof(s), 6);
+    is_streq(s, "hello");
+    is_eq(s[5], 0);
+
+    // The string does not fit, so it is truncated without the null character.
+    char t[3] = "hello";
+    is_eq(sizeof(t), 3);
+    is_eq(t[0], 'h');
+    is_eq(t[2], 'l');
+
+    char u[8] = "hi";
+    is_eq(sizeof(u), 8);
+    is_streq(u, "hi");
+    is_eq(u[7], 0);
+
+    char v[] = {"hi"};
+    is_eq(sizeof(v), 3);
+    is_streq(v, "hi");
+
+    char w[2][4] = {"ab", "cd"};
+    is_streq(w[0], "ab");
+    is_streq(w[1], "cd");
+}
+
 int main()
 {
-    plan(162);
+    plan(220);
 
     START_TEST(intarr);
     START_TEST(doublearr);
@@ -336,6 +516,7 @@ int main()
     START_TEST(ptrarr);
     START_TEST(stringarr_init);
     START_TEST(partialarr_init);
+    START_TEST(designated_init);
 
 	is_eq(arrayEx[1],2.0);
 
@@ -792,5 +973,20 @@ int main()
     diag("array to pointer");
     test_arr_to_pointer();
 
+    diag("variable-length array");
+    test_variable_length_array(4);
+
+    diag("array parameters");
+    test_array_parameters();
+
+    diag("array of pointers and pointer to array");
+    test_array_pointers();
+
+    diag("const arrays");
+    test_const_arrays();
+
+    diag("char arrays from strings");
+    test_char_arrays_from_strings();
+
     done_testing();
 }
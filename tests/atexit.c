@@ -0,0 +1,28 @@
+#include <stdio.h>
+#include <stdlib.h>
+#include "tests.h"
+
+void first()
+{
+    printf("first handler\n");
+}
+
+void second()
+{
+    printf("second handler\n");
+
+    // The remaining handlers are still called by the nested exit().
+    exit(3);
+}
+
+int main()
+{
+    plan(0);
+
+    atexit(first);
+    atexit(second);
+
+    // There is no done_testing() because the handlers print after main()
+    // returns with an error code.
+    return 2;
+}
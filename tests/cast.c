@@ -174,15 +174,64 @@ void test_voidcast()
     is_true(y == NULL);
 }
 
+typedef size_t length;
+typedef char * text;
+
+size_t size_of_int(int x)
+{
+    return x + 1;
+}
+
+length length_of_int(int x)
+{
+    return x * 2;
+}
+
+text text_of(char *s)
+{
+    return s + 1;
+}
+
+void test_typedef_return()
+{
+    is_eq(size_of_int(41), 42);
+    is_eq(length_of_int(21), 42);
+    is_streq(text_of("xabc"), "abc");
+}
+
+// A plain char is signed on x86, so a char of 0xFF is -1.
+void test_signed_char()
+{
+    char c = 0xFF;
+    is_true(c < 0);
+    is_eq(c, -1);
+    int i = c;
+    is_eq(i, -1);
+}
+
+void test_unsigned_wraparound()
+{
+    unsigned char c = 300;
+    is_eq(c, 44);
+    c = c + 250;
+    is_eq(c, 38);
+    unsigned int u = 0;
+    u--;
+    is_eq(u, 4294967295);
+}
+
 int main()
 {
-    plan(52);
+    plan(61);
 
     START_TEST(cast);
     START_TEST(castbool);
     START_TEST(vertex);
     START_TEST(strCh);
     START_TEST(voidcast);
+    START_TEST(typedef_return);
+    START_TEST(signed_char);
+    START_TEST(unsigned_wraparound);
 
 	{
 	typedef unsigned int u32;
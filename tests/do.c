@@ -3,7 +3,7 @@
 
 int main()
 {
-    plan(7);
+    plan(12);
 	
 	int i = 0;
 
@@ -34,5 +34,31 @@ int main()
 	do s++; while(s < 10);
 	is_eq(s , 10);
 
+	diag("do that runs exactly once");
+	int runs = 0;
+	do {
+		runs++;
+	} while (0);
+	is_eq(runs, 1);
+
+	diag("do that loops three times");
+	runs = 0;
+	i = 3;
+	do {
+		runs++;
+	} while (--i > 0);
+	is_eq(runs, 3);
+	is_eq(i, 0);
+
+	diag("continue evaluates a condition with side effects");
+	runs = 0;
+	i = 0;
+	do {
+		if (i % 2 == 0) continue;
+		runs++;
+	} while (i++, i < 6);
+	is_eq(runs, 3);
+	is_eq(i, 6);
+
 	done_testing();
 }
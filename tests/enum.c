@@ -43,11 +43,18 @@ typedef enum e_strategy {RANDOM, IMMEDIATE = 5, SEARCH} strategy;
 
 enum { ESC_A = 1, ESC_d };
 
+enum gap { G_A, G_B = 10, G_C, G_D = -5, G_E, G_F = G_C + 100, G_G };
+
+int gap_value(enum gap g)
+{
+	return g;
+}
+
 // main function
 
 int main()
 {
-	plan(30);
+	plan(38);
 
 	// step 1
 	enum number n;
@@ -108,6 +115,17 @@ int main()
 
 	is_eq(ESC_d, 2);
 
+	diag("gaps in the values")
+	is_eq(G_A, 0);
+	is_eq(G_B, 10);
+	is_eq(G_C, 11);
+	is_eq(G_D, -5);
+	is_eq(G_E, -4);
+	is_eq(G_G, 112);
+	is_eq(gap_value(G_F), 111);
+	enum gap g = G_E;
+	is_eq(gap_value(g), -4);
+
 	diag("sizeof")
 	is_eq(sizeof(JUMP ),sizeof(int));
 	is_eq(sizeof(Jan  ),sizeof(int));
@@ -0,0 +1,34 @@
+// This file contains tests for the environment (envp) that is the third
+// parameter of main().
+
+#include <stdio.h>
+#include <string.h>
+#include "tests.h"
+
+int main(int argc, char **argv, char **envp)
+{
+    plan(4);
+
+    // See argv.c for the offset under "go test".
+    int offset = 0;
+    if (argc > 3) {
+        offset = 3;
+    }
+
+    is_streq(argv[1 + offset], "some");
+    is_null(argv[argc]);
+
+    // The environment is different for C and Go, but there is always a PATH.
+    int count = 0;
+    int found = 0;
+    for (int i = 0; envp[i] != NULL; i++) {
+        if (strncmp(envp[i], "PATH=", 5) == 0) {
+            found = 1;
+        }
+        count++;
+    }
+    is_true(count > 0);
+    is_true(found);
+
+    done_testing();
+}
@@ -3,7 +3,7 @@
 
 int main()
 {
-    plan(61);
+    plan(70);
 
     int i = 0;
 
@@ -174,5 +174,49 @@ int main()
     for (i = 3; i >= 1; i-=3)
         pass("%d", i);
 
+    diag("comma increment with continue");
+    {
+        int j, n = 0;
+        for (i = 0, j = 10; i < j; i++, j--) {
+            if (i == 2)
+                continue;
+            n++;
+        }
+        is_eq(i, 5);
+        is_eq(j, 5);
+        is_eq(n, 4);
+    }
+
+    diag("increment with continue");
+    {
+        int n = 0;
+        for (i = 0; i < 5; i++) {
+            if (i % 2)
+                continue;
+            n += i;
+        }
+        is_eq(i, 5);
+        is_eq(n, 6);
+    }
+
+    diag("comma in increment with continue in nested loops");
+    {
+        int j = 0, m, n = 0;
+        for (i = 0; i < 4; j = (i++, i * 2)) {
+            for (m = 0; m < 3; m++) {
+                if (m == 1)
+                    continue;
+                n++;
+            }
+            if (i % 2)
+                continue;
+            n += j;
+        }
+        is_eq(i, 4);
+        is_eq(j, 8);
+        is_eq(m, 3);
+        is_eq(n, 12);
+    }
+
 	done_testing();
 }
@@ -102,12 +102,129 @@ int call_a_func(operators call_this) {
     return output;
 }
 
+struct calculator {
+	operators op;
+	int base;
+};
+
+int calculate(struct calculator *c, int x) {
+	if (c->op == NULL) {
+		return c->base;
+	}
+	return c->op(c->base, x);
+}
+
+long double average(long double a, long double b)
+{
+	return (a + b) / 2;
+}
+
+int twice(int x) { return 2 * x; }
+int square(int x) { return x * x; }
+
+int apply_all(int *values, int size, int (*callback)(int))
+{
+	int sum = 0;
+	for (int i = 0; i < size; i++) {
+		sum += callback(values[i]);
+	}
+	return sum;
+}
+
+int (*pick_callback(int (*a)(int), int (*b)(int), int which))(int)
+{
+	if (which) {
+		return b;
+	}
+	return a;
+}
+
+int knr_max(a, b)
+int a, b;
+{
+	return a > b ? a : b;
+}
+
+double knr_scale(x, factor)
+double x;
+int factor;
+{
+	return x * factor;
+}
+
+int is_odd(unsigned int n);
+
+int is_even(unsigned int n)
+{
+	if (n == 0) {
+		return 1;
+	}
+	return is_odd(n - 1);
+}
+
+int is_odd(unsigned int n)
+{
+	if (n == 0) {
+		return 0;
+	}
+	return is_even(n - 1);
+}
+
+int keywords(int type, int func, int range, int map, int chan)
+{
+    return type + func + range + map + chan;
+}
+
+void copy_ints(int *restrict dst, const int src[restrict], int n)
+{
+	for (int i = 0; i < n; i++) {
+		dst[i] = src[i];
+	}
+}
+
+int next_id(void)
+{
+	static int counter = 0;
+	counter++;
+	return counter;
+}
+
+// The static variable has the same name as the one of next_id(), but they are
+// different variables.
+int next_even(void)
+{
+	static int counter = 0;
+	static int history[3];
+	history[counter / 2 % 3] = counter;
+	counter += 2;
+	return counter;
+}
+
+int sum_register(register int *values, register int n)
+{
+	register int total = 0;
+	auto int i;
+	for (i = 0; i < n; i++)
+		total += values[i];
+	return total;
+}
+
 long tolower (int a, int b) { return (long)(a+b);}
 long toupper (int a, int b) { return (long)(a+b);}
 
+const char *function_name() { return __func__; }
+const char *function_name2() { return __FUNCTION__; }
+
+int arg_counter;
+
+int read_counter(int x, int y)
+{
+	return arg_counter * 100 + x * 10 + y;
+}
+
 int main()
 {
-    plan(48);
+    plan(85);
 
     pass("%s", "Main function.");
 
@@ -217,12 +334,108 @@ int main()
 		is_eq(call_a_func(&mul),40);
 	}
 	
+	diag("long double return type");
+	{
+		long double d = average(1.5, 2.5L);
+		is_eq(d, 2);
+		d = d * 3 - average(d, 0);
+		is_eq(d, 5);
+		is_true(average(-1, -2) < 0);
+	}
+
+	diag("callbacks");
+	{
+		int values[3] = {1, 2, 3};
+		is_eq(apply_all(values, 3, twice), 12);
+		is_eq(apply_all(values, 3, square), 14);
+		is_eq(pick_callback(twice, square, 1)(5), 25);
+		int (*callback)(int) = pick_callback(twice, square, 0);
+		is_eq(callback(5), 10);
+		is_eq(apply_all(values, 3, pick_callback(twice, square, 1)), 14);
+
+		int (*op)(int, int) = add;
+		is_eq(op(2, 5), 7);
+		op = &mul;
+		is_eq(op(2, 5), 10);
+	}
+
+	diag("K&R function definition");
+	{
+		is_eq(knr_max(3, 7), 7);
+		is_eq(knr_max(-2, -5), -2);
+		is_eq(knr_scale(1.5, 4), 6);
+	}
+
+	diag("mutual recursion");
+	{
+		is_true(is_even(10));
+		is_false(is_odd(10));
+		is_true(is_odd(7));
+	}
+
+	diag("restrict parameters");
+	{
+		int src[3] = {4, 5, 6};
+		int dst[3] = {0, 0, 0};
+		copy_ints(dst, src, 3);
+		is_eq(dst[0], 4);
+		is_eq(dst[2], 6);
+	}
+
+	diag("parameters named like Go keywords");
+	is_eq(keywords(1, 2, 3, 4, 5), 15);
+
+	diag("static variables in functions");
+	{
+		is_eq(next_id(), 1);
+		is_eq(next_id(), 2);
+		is_eq(next_id(), 3);
+		is_eq(next_even(), 2);
+		is_eq(next_even(), 4);
+		is_eq(next_id(), 4);
+	}
+
+	diag("register and auto variables");
+	{
+		int values[] = {1, 2, 3, 4};
+		is_eq(sum_register(values, 4), 10);
+	}
+
 	diag("function name like in CSTD");
 	{
 		is_eq(tolower(34,52),86);
 		is_eq(toupper(34,52),86);
 	}
 
+	diag("predefined identifiers");
+	{
+		is_streq(function_name(), "function_name");
+		is_streq(function_name2(), "function_name2");
+		is_streq(__func__, "main");
+		is_streq(__FUNCTION__, "main");
+	}
+
+	diag("typedef of a function pointer");
+	{
+		operators fn = add;
+		is_eq(fn(2, 3), 5);
+		struct calculator c = {NULL, 7};
+		is_eq(calculate(&c, 1), 7);
+		c.op = fn;
+		is_eq(calculate(&c, 1), 8);
+		operators table[2] = {add, add};
+		is_eq(table[1](4, 5), 9);
+	}
+
+	diag("side effects of the arguments");
+	{
+		arg_counter = 1;
+		is_eq(read_counter(arg_counter++, arg_counter++), 312);
+		is_eq(arg_counter, 3);
+		int a = 1;
+		is_eq(read_counter(a, a = 5), 315);
+	}
+
     done_testing();
 }
 
@@ -57,13 +57,91 @@ void test_goto_stmt()
     is_eq(i, 15);
 }
 
+// A typical error-handling ladder. The gotos jump forward over declarations,
+// which is allowed in C but not in Go.
+int ladder(int failAt)
+{
+    int result = 0;
+    if (failAt == 1) {
+        goto cleanup;
+    }
+
+    int a = 10;
+    result += a;
+    if (failAt == 2) {
+        goto cleanup;
+    }
+
+    int b = 20;
+    result += b;
+    if (failAt == 3)
+        goto cleanup;
+
+    result += a + b;
+
+cleanup:
+    result = -result;
+    return result;
+}
+
+void test_goto_ladder()
+{
+    is_eq(ladder(1), 0);
+    is_eq(ladder(2), -10);
+    is_eq(ladder(3), -30);
+    is_eq(ladder(0), -60);
+}
+
+// The goto out of the nested loops becomes a labeled break.
+void test_goto_out_of_loops()
+{
+    int i, j, k;
+    for (i = 0; i < 10; i++)
+        for (j = 0; j < 10; j++)
+            for (k = 0; k < 10; k++)
+                if (i + j + k == 12)
+                    goto done;
+done:
+    is_eq(i, 0);
+    is_eq(j, 3);
+    is_eq(k, 9);
+}
+
+// A do-while loop that is built from a goto. The goto jumps backward over the
+// declaration of sq, which is the same variable on each pass.
+int manual_do_while(int n)
+{
+    int i = 0;
+    int *last = 0;
+again:
+    i++;
+    int sq = i * i;
+    if (last != 0 && last != &sq)
+        return -1;
+    last = &sq;
+    if (sq < n)
+        goto again;
+    return i;
+}
+
+void test_goto_backward()
+{
+    is_eq(manual_do_while(0), 1);
+    is_eq(manual_do_while(10), 4);
+    is_eq(manual_do_while(16), 4);
+    is_eq(manual_do_while(17), 5);
+}
+
 int main()
 {
-    plan(4);
+    plan(15);
 
     START_TEST(goto1)
     START_TEST(goto2)
     START_TEST(goto_stmt)
+    START_TEST(goto_ladder)
+    START_TEST(goto_out_of_loops)
+    START_TEST(goto_backward)
     
     done_testing();
 }
@@ -31,9 +31,19 @@ void compare_pointers() {
     is_false(t);
 }
 
+int sign_with_hints(int n) {
+    if (__builtin_expect(n > 0, 1))
+        return 1;
+    if (__builtin_expect(n < 0, 0))
+        return -1;
+    if (n == 0)
+        return 0;
+    __builtin_unreachable();
+}
+
 int main()
 {
-    plan(16);
+    plan(27);
 
     int x = 1;
 
@@ -86,5 +96,44 @@ int main()
 	diag("Pointer comparisons");
 	compare_pointers();
 
+	diag("Implicit conditions");
+	{
+		int *p = NULL;
+		int n = 3;
+		int count = 0;
+		double f = 0.5;
+
+		if (p) {
+			fail("a null pointer is false");
+		} else {
+			pass("a null pointer is false");
+		}
+		p = &n;
+		if (p) {
+			pass("a pointer is true");
+		}
+		if (!p) {
+			fail("a pointer is true");
+		}
+		if (f) {
+			pass("a nonzero double is true");
+		}
+		while (n--) {
+			count++;
+		}
+		is_eq(count, 3);
+		is_eq(n, -1);
+		if (!(count && p) || n) {
+			pass("a negated condition");
+		}
+		is_eq(p && n ? 1 : 2, 1);
+	}
+
+	diag("branch hints");
+	is_eq(sign_with_hints(5), 1);
+	is_eq(sign_with_hints(-3), -1);
+	is_eq(sign_with_hints(0), 0);
+	is_eq(__builtin_expect(7, 0), 7);
+
     done_testing();
 }
@@ -11,7 +11,7 @@ unsigned long long ullmax = 18446744073709551615ull;
 
 int main()
 {
-  plan(359);
+  plan(364);
 
   // Note: There are some tests that must be disabled because they return
   // different values under different compilers. See the comment surrounding the
@@ -400,6 +400,13 @@ int main()
   is_nan(pow(-INFINITY, NAN));
   is_nan(pow(NAN, NAN));
 
+  diag("signbit");
+  is_true(signbit(-1.5f));
+  is_true(signbit(-1.5));
+  is_true(signbit(-0.0));
+  is_false(signbit(1.5f));
+  is_false(signbit(0.0));
+
   diag("sin");
   is_eq(sin(0), 0);
   is_eq(sin(1), 0.84147098480789650488);
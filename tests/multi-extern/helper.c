@@ -0,0 +1,11 @@
+// The declarations of main.c are repeated here. Only one Go variable must be
+// created for each of them.
+extern int calls;
+extern int helper(int n);
+int total;
+int calls = 0;
+
+int helper(int n) {
+    calls++;
+    return n * n;
+}
@@ -0,0 +1,19 @@
+#include <stdio.h>
+
+// These are declared in helper.c as well.
+extern int calls;
+int total;
+int helper(int n);
+
+// These are defined after main.
+extern int scale;
+static int offset;
+
+int main() {
+    total = helper(2) + helper(3);
+    printf("%d %d %d\n", total, calls, total * scale + offset);
+    return 0;
+}
+
+int scale = 10;
+static int offset = 1;
@@ -0,0 +1,10 @@
+// main.c has a static scale() of its own.
+static int scale(int n);
+
+int helper(int n) {
+    return scale(n) + 1;
+}
+
+static int scale(int n) {
+    return n * 100;
+}
@@ -0,0 +1,13 @@
+#include <stdio.h>
+
+// helper.c has a static scale() of its own.
+static int scale(int n) {
+    return n * 10;
+}
+
+int helper(int n);
+
+int main() {
+    printf("%d %d\n", scale(2), helper(2));
+    return 0;
+}
@@ -0,0 +1,34 @@
+// This file contains tests for the functions that never return.
+
+#include <stdio.h>
+#include <stdlib.h>
+#include "tests.h"
+
+_Noreturn void die(int code)
+{
+    exit(code);
+}
+
+__attribute__((noreturn)) void fail(void)
+{
+    die(1);
+}
+
+int check(int value)
+{
+    if (value < 0) {
+        fail();
+    }
+    return value * 2;
+}
+
+int main()
+{
+    plan(2);
+
+    is_eq(check(3), 6);
+    is_eq(check(0), 0);
+
+    // done_testing() is not needed because die() does not return.
+    die(0);
+}
@@ -54,7 +54,7 @@ void unusedInt(int n) {
 
 int main()
 {
-	plan(142);
+	plan(199);
 
     int i = 10;
     signed char j = 1;
@@ -167,6 +167,17 @@ int main()
 	int s1 = ({ 2; });
 	is_eq(s1, 2);
 	is_eq(({ int foo = s1 * 3; foo + 1; }), 7);
+	{
+#define SUM_TO(n) ({ int i = 0, sum = 0; while (i <= (n)) { sum += i; i++; } sum; })
+		int a = SUM_TO(4);
+		int b = SUM_TO(a) - SUM_TO(3);
+		is_eq(a, 10);
+		is_eq(b, 49);
+		int c = 0;
+		while (({ c++; c < 5; })) {
+		}
+		is_eq(c, 5);
+	}
 
 	diag("Not allowable var name for Go")
 	int type = 42;
@@ -526,6 +537,127 @@ int main()
 		++*p;
 		is_streq(s, "orld");
 	}
+	diag("Compound assignment with side effects on the left");
+	{
+		int a[] = {1, 2, 3};
+		int i = 0;
+		a[i++] += 10;
+		is_eq(a[0], 11);
+		is_eq(i, 1);
+		is_eq(a[i++] *= 3, 6);
+		is_eq(a[1], 6);
+		is_eq(i, 2);
+
+		char *s = "abcd";
+		char *ss[] = {s, s};
+		int k = 0;
+		ss[k++] += 2;
+		is_streq(ss[0], "cd");
+		is_streq(ss[1], "abcd");
+		is_eq(k, 1);
+
+		int *p = a;
+		*p++ -= 1;
+		is_eq(a[0], 10);
+		is_eq(*p, 6);
+	}
+
+	diag("Operator comma as the value of an assignment");
+	{
+		int a = 1, b = 5, x;
+		x = (a++, b);
+		is_eq(x, 5);
+		is_eq(a, 2);
+		x = (a++, a * 10);
+		is_eq(x, 30);
+		int y = (b++, a + b);
+		is_eq(y, 9);
+		is_eq((a, y), 9);
+	}
+
+	diag("Division and remainder of negative and unsigned operands");
+	{
+		int n = -7, d = 3;
+		unsigned u = 3;
+		is_eq(-7 / 3, -2);
+		is_eq(-7 % 3, -1);
+		is_eq(7 % -3, 1);
+		is_eq(n / d, -2);
+		is_eq(n % d, -1);
+		is_eq(n % -d, -1);
+		// n is converted to unsigned int.
+		is_eq(n % u, 0);
+		is_eq(n / u, 1431655763);
+		is_eq(4294967295u % u, 0);
+		is_eq(4294967295u / 2u, 2147483647);
+	}
+
+	diag("Assignment as the value of an expression");
+	{
+		int n, a = 0, b, c = 7, calls = 0;
+		if ((n = c - 2) > 0) {
+			pass("%s", "value of assignment in condition");
+		}
+		is_eq(n, 5);
+		if (a && (n = 1)) {
+			fail("%s", "right side of && is evaluated");
+		}
+		is_eq(n, 5);
+		is_eq(a = b = c, 7);
+		is_eq(a, 7);
+		is_eq(b, 7);
+		while ((n = n - 1) > 2) {
+			calls++;
+		}
+		is_eq(calls, 2);
+		is_eq(n, 2);
+	}
+
+	diag("Increment and decrement as the value of an expression");
+	{
+		int a = 5, x, arr[4] = {0, 0, 0, 0}, i = 0;
+		x = a++;
+		is_eq(x, 5);
+		is_eq(a, 6);
+		x = ++a;
+		is_eq(x, 7);
+		is_eq(a, 7);
+		arr[i++] = 3;
+		is_eq(i, 1);
+		is_eq(arr[0], 3);
+		arr[i++] = arr[0]--;
+		is_eq(i, 2);
+		is_eq(arr[1], 3);
+		is_eq(arr[0], 2);
+		x = arr[--i];
+		is_eq(x, 3);
+		is_eq(i, 1);
+	}
+
+	diag("Integer promotions of the narrower operands");
+	{
+		char a = 100, b = 100;
+		unsigned char u = 200;
+		short s = -2;
+		_Bool x = 1;
+		is_eq((char)a + (char)b, 200);
+		is_eq(u * u, 40000);
+		is_eq(x & u, 0);
+		is_eq(x | u, 201);
+		is_eq(x + x, 2);
+
+		char c = -4;
+		c /= 2;
+		is_eq(c, -2);
+		c %= s + 5;
+		is_eq(c, -2);
+		c >>= 1;
+		is_eq(c, -1);
+		u /= s;
+		is_eq(u, 156);
+		s >>= 1;
+		is_eq(s, -1);
+	}
 
 	done_testing();
 }
@@ -0,0 +1,54 @@
+// This file contains tests for setjmp.h.
+
+#include <stdio.h>
+#include <setjmp.h>
+#include "tests.h"
+
+jmp_buf env;
+jmp_buf inner;
+
+void jump(jmp_buf buf, int value)
+{
+    longjmp(buf, value);
+}
+
+int round_trip(int value)
+{
+    int result = setjmp(env);
+    if (result != 0) {
+        return result;
+    }
+    jump(env, value);
+    return -1;
+}
+
+int nested()
+{
+    // The variable is changed after setjmp() so it must be volatile.
+    volatile int count = 0;
+    if (setjmp(env) != 0) {
+        return count;
+    }
+    count++;
+    if (setjmp(inner) == 0) {
+        count++;
+        longjmp(inner, 1);
+    }
+    count++;
+    longjmp(env, 1);
+    return -1;
+}
+
+int main()
+{
+    plan(3);
+
+    diag("setjmp() returns the value of longjmp()");
+    is_eq(round_trip(42), 42);
+    is_eq(round_trip(0), 1);
+
+    diag("nested setjmp() scopes");
+    is_eq(nested(), 3);
+
+    done_testing();
+}
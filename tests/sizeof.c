@@ -1,5 +1,6 @@
 // This file contains tests for the sizeof() function and operator.
 
+#include <stddef.h>
 #include <stdio.h>
 #include "tests.h"
 
@@ -21,6 +22,9 @@
 #define VARIABLE(v, p) \
     printf("%s = (%d) %d bytes\n", #v, p, sizeof(v));
 
+#define TYPE_NAME(x) _Generic((x), int: "int", double: "double", \
+    char *: "char *", default: "other")
+
 struct MyStruct
 {
     double a;
@@ -52,6 +56,51 @@ struct MyStruct4
     char d[30];
 };
 
+struct Padded
+{
+    char a;
+    int b;
+    char c;
+};
+
+struct TwoChars
+{
+    char a;
+    char b;
+};
+
+struct Flags
+{
+    unsigned a : 3;
+    unsigned b : 5;
+    unsigned c : 30;
+    char d;
+};
+
+#pragma pack(push, 1)
+struct Packed
+{
+    char a;
+    int b;
+};
+#pragma pack(pop)
+
+#pragma pack(push, 2)
+struct PackedTo2
+{
+    char a;
+    long b;
+    char c;
+};
+#pragma pack(pop)
+
+struct AttributePacked
+{
+    char a;
+    int b;
+    char c;
+} __attribute__((packed));
+
 union MyUnion
 {
     double a;
@@ -64,7 +113,7 @@ int b;
 
 int main()
 {
-    plan(42);
+    plan(65);
 
     diag("Integer types");
     check_sizes(char, 1);
@@ -110,6 +159,25 @@ int main()
     diag("Structures");
     is_eq(sizeof(struct MyStruct), 16);
 
+    is_eq(sizeof(struct Padded), 12);
+    is_eq(sizeof(struct TwoChars), 2);
+    is_eq(sizeof(struct Flags), 12);
+
+    is_eq(sizeof(struct Packed), 5);
+    is_eq(sizeof(struct PackedTo2), 12);
+    is_eq(sizeof(struct AttributePacked), 6);
+
+    struct Packed packed[2];
+    packed[0].a = 'a';
+    packed[0].b = 1234;
+    is_eq(sizeof(packed), 10);
+    is_eq(packed[0].b, 1234);
+
+    struct Padded padded[3];
+    padded[0].a = 0;
+    is_eq(sizeof(padded), 36);
+    is_eq(sizeof padded[1], 12);
+
     diag("Unions");
     is_eq(sizeof(union MyUnion), 8);
 
@@ -133,5 +201,28 @@ int main()
     is_eq(sizeof(f), 48);
     is_streq(f[1], "b");
 
+    int g[5];
+    g[0] = 1;
+    is_eq(sizeof g, 20);
+    is_eq(sizeof(g) / sizeof(g[0]), 5);
+
+    diag("offsetof");
+    is_eq(offsetof(struct Padded, c), 8);
+    is_eq(offsetof(struct Packed, b), 1);
+    // d is after the second unsigned int of the bit-fields.
+    is_eq(offsetof(struct Flags, d), 8);
+
+    diag("Expressions");
+    is_eq(sizeof(b + 1L), 8);
+    is_eq(sizeof(a * 2), 4);
+    is_eq(sizeof 'x', 4);
+
+    diag("_Generic");
+    is_streq(TYPE_NAME(b), "int");
+    is_streq(TYPE_NAME(b * 1.5), "double");
+    is_streq(TYPE_NAME('x'), "int");
+    is_streq(TYPE_NAME("abc"), "char *");
+    is_streq(TYPE_NAME(a), "other");
+
     done_testing();
 }
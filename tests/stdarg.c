@@ -115,9 +115,48 @@ void test_va_list4(func_va *f, char *format, ...) {
 	va_end(args);
 }
 
+int sum(int count, ...)
+{
+	va_list args;
+	va_start(args, count);
+	int total = 0;
+	for (int i = 0; i < count; i++) {
+		total += va_arg(args, int);
+	}
+	va_end(args);
+	return total;
+}
+
+void test_va_sum()
+{
+	is_eq(sum(0), 0);
+	is_eq(sum(1, 7), 7);
+	is_eq(sum(3, 1, 2, 3), 6);
+	is_eq(sum(5, 10, -20, 30, -40, 50), 30);
+}
+
+int format_message(char *buf, const char *fmt, ...)
+    __attribute__((format(printf, 2, 3)));
+
+int format_message(char *buf, const char *fmt, ...)
+{
+	va_list args;
+	va_start(args, fmt);
+	int n = vsprintf(buf, fmt, args);
+	va_end(args);
+	return n;
+}
+
+void test_va_format()
+{
+	char buf[32];
+	is_eq(format_message(buf, "%d-%s", 42, "x"), 4);
+	is_streq(buf, "42-x");
+}
+
 int main()
 {
-    plan(16);
+    plan(22);
 
     START_TEST(va_list)
 
@@ -125,6 +164,9 @@ int main()
     test_va_list3(simple2, "dcff", 3, 'a', 1.999, 42.5);
     test_va_list4(simple2, "dcff", 3, 'a', 1.999, 42.5);
 
+    START_TEST(va_sum)
+    START_TEST(va_format)
+
     done_testing();
 }
 
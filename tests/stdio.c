@@ -35,11 +35,8 @@ void test_puts()
 
 void test_printf()
 {
-    // TODO: printf() has a different syntax to Go
-    // https://github.com/elliotchance/c2go/issues/94
-
     printf("# Characters: %c %c \n", 'a', 65);
-    //printf("Decimals: %d %ld\n", 1977, 650000L);
+    printf("# Decimals: %d %ld \n", 1977, 650000L);
     printf("# Preceding with blanks: %10d \n", 1977);
     printf("# Preceding with zeros: %010d \n", 1977);
     printf("# Some different radices: %d %x %o %#x %#o \n", 100, 100, 100, 100, 100);
@@ -258,6 +255,21 @@ void test_fscanf()
     is_eq(remove("/tmp/myfile2.txt"),0)
 }
 
+void test_sscanf()
+{
+    int a, b;
+    char name[4];
+    char rest[16];
+
+    is_eq(sscanf("12 -34 gopher=go", "%d %i %[^=]%s", &a, &b, name, rest), 4);
+    is_eq(a, 12);
+    is_eq(b, -34);
+    is_streq(name, "gop");
+    is_streq(rest, "her=go");
+    is_eq(sscanf("", "%d", &a), EOF);
+    is_eq(sscanf("x", "%d", &a), 0);
+}
+
 void test_fgetc()
 {
     FILE *pFile;
@@ -375,6 +387,20 @@ void test_getc()
     is_eq(n, 2);
 }
 
+void test_ungetc()
+{
+    FILE *pFile;
+    pFile = fopen("tests/stdio.c", "r");
+    is_not_null(pFile);
+
+    is_eq(fgetc(pFile), '/');
+    is_eq(ungetc('#', pFile), '#');
+    is_eq(fgetc(pFile), '#');
+    is_eq(fgetc(pFile), '/');
+
+    fclose(pFile);
+}
+
 void test_putc()
 {
     FILE *pFile;
@@ -558,6 +584,49 @@ void test_snprintf()
 	is_eq(n,13)
 }
 
+void test_format_conversions()
+{
+	char buffer[50];
+	char *null = NULL;
+	int n;
+
+	sprintf(buffer, "%d|%5d|%-5d|%05d|%+d", -42, 42, 42, -42, 42);
+	is_streq(buffer, "-42|   42|42   |-0042|+42");
+
+	sprintf(buffer, "%ld %lu %u %hhd", 650000L, 4000000000UL, -1, 255);
+	is_streq(buffer, "650000 4000000000 4294967295 -1");
+
+	sprintf(buffer, "%x %X %o %#x %#o %.3d", 255, 255, 8, 255, 8, 7);
+	is_streq(buffer, "ff FF 10 0xff 010 007");
+
+	sprintf(buffer, "%.2f %8.3f %e %g %g", 3.1416, -3.1416, 31416.0, 0.0001, 1e6);
+	is_streq(buffer, "3.14   -3.142 3.141600e+04 0.0001 1e+06");
+
+	sprintf(buffer, "%c%c%3c", 'a', 66, 'c');
+	is_streq(buffer, "aB  c");
+
+	sprintf(buffer, "[%s|%.3s|%6s|%-6s]", "abc", "abcdef", "ab", "ab");
+	is_streq(buffer, "[abc|abc|    ab|ab    ]");
+
+	sprintf(buffer, "%s", null);
+	is_streq(buffer, "(null)");
+
+	sprintf(buffer, "%*d|%-*d|%.*f", 4, 1, 3, 2, 1, 2.25);
+	is_streq(buffer, "   1|2  |2.2");
+
+	sprintf(buffer, "100%% %d", 1);
+	is_streq(buffer, "100% 1");
+
+	sprintf(buffer, "abc%n", &n);
+	is_eq(n, 3);
+
+	strcpy(buffer, "xxxxxxxx");
+	n = snprintf(buffer, 4, "%d", 123456);
+	is_eq(n, 6);
+	is_streq(buffer, "123");
+	is_eq(buffer[5], 'x');
+}
+
 int PrintFError(const char * format, ... )
 {
 	char buffer[256];
@@ -614,7 +683,7 @@ void test_eof()
 
 int main()
 {
-    plan(90);
+    plan(115);
 
     START_TEST(putchar)
     START_TEST(puts)
@@ -629,12 +698,14 @@ int main()
     START_TEST(printf)
     START_TEST(fprintf)
     START_TEST(fscanf)
+    START_TEST(sscanf)
     START_TEST(fgetc)
     START_TEST(fgets)
     START_TEST(fgets2)
     START_TEST(fputc)
     START_TEST(fputs)
     START_TEST(getc)
+    START_TEST(ungetc)
     START_TEST(putc)
     START_TEST(fseek)
     START_TEST(ftell)
@@ -646,6 +717,7 @@ int main()
     START_TEST(feof)
     START_TEST(sprintf)
     START_TEST(snprintf)
+    START_TEST(format_conversions)
     START_TEST(vsprintf)
     START_TEST(vsnprintf)
 	START_TEST(eof)
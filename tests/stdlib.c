@@ -1,6 +1,8 @@
+#include <alloca.h>
 #include <assert.h>
 #include <stdio.h>
 #include <stdlib.h>
+#include <string.h>
 #include "tests.h"
 
 #define test_strto0(actual, func, end) \
@@ -126,6 +128,16 @@ void test_malloc3()
 
     is_eq(*d, 123);
     is_eq(d[4], 456);
+
+    // An array of structs is zeroed too.
+    struct point { int x; double y; char *name; } *points;
+    points = calloc(3, sizeof(struct point));
+    is_not_null(points) or_return();
+    is_eq(points[2].x, 0);
+    is_eq(points[2].y, 0);
+    is_null(points[2].name);
+    points[1].x = 5;
+    is_eq(points[1].x, 5);
 }
 
 void test_malloc4()
@@ -171,6 +183,96 @@ void test_malloc5()
     free(block);
 }
 
+// The free() calls of "values" are replaced by a single defer, which must also
+// be used by the early return.
+int sum_malloc(int n)
+{
+    int *values = malloc(sizeof(int) * 4);
+    for (int i = 0; i < 4; i++) {
+        values[i] = i * n;
+    }
+    if (n < 0) {
+        free(values);
+        return -1;
+    }
+
+    int total = values[0] + values[1] + values[2] + values[3];
+    free(values);
+    return total;
+}
+
+void test_malloc6()
+{
+    diag("malloc6");
+
+    is_eq(sum_malloc(-1), -1);
+    is_eq(sum_malloc(0), 0);
+    is_eq(sum_malloc(2), 12);
+}
+
+struct point {
+    int x;
+    int y;
+};
+
+typedef int *int_ptr;
+
+int *new_int(int value)
+{
+    int *p = malloc(sizeof(int));
+    *p = value;
+    return p;
+}
+
+// The result of malloc() is not assigned, so it is converted like any other
+// void pointer.
+int *new_ints(int n)
+{
+    return malloc(sizeof(int) * n);
+}
+
+struct point *new_point(int x, int y)
+{
+    struct point *p = (struct point *)malloc(sizeof(struct point));
+    p->x = x;
+    p->y = y;
+    return p;
+}
+
+int *set_int(int *p, int value)
+{
+    *p = value;
+    return p;
+}
+
+void test_malloc7()
+{
+    diag("malloc7");
+
+    int *i = new_int(42);
+    is_eq(*i, 42);
+    free(i);
+
+    int *ints = new_ints(3);
+    ints[2] = 7;
+    is_eq(ints[2], 7);
+    free(ints);
+
+    struct point *p = new_point(3, 4);
+    is_eq(p->x, 3);
+    is_eq(p->y, 4);
+    free(p);
+
+    int_ptr t = malloc(sizeof(int));
+    *t = 5;
+    is_eq(*t, 5);
+    free(t);
+
+    int *u = set_int((int *)malloc(sizeof(int)), 9);
+    is_eq(*u, 9);
+    free(u);
+}
+
 // calloc() works exactly the same as malloc() however the memory is zeroed out.
 // In Go all allocated memory is zeroed out so they actually are the same thing.
 void test_calloc()
@@ -195,6 +297,31 @@ void test_calloc()
     is_eq(d[4], 456);
 }
 
+// Each call of alloca() in the loop gets a new buffer.
+int alloca_sum(int n)
+{
+    int total = 0;
+    for (int i = 1; i <= n; i++) {
+        int *buf = (int *)alloca(i * sizeof(int));
+        for (int j = 0; j < i; j++)
+            buf[j] = j + 1;
+        total += buf[i - 1];
+    }
+    return total;
+}
+
+void test_alloca()
+{
+    // The temporary buffer is copied before it is freed.
+    char out[6];
+    char *tmp = alloca(6);
+    strcpy(tmp, "hello");
+    strcpy(out, tmp);
+    is_streq(out, "hello");
+
+    is_eq(alloca_sum(4), 10);
+}
+
 void test_free()
 {
 	int * buffer1, * buffer2, * buffer3;
@@ -214,6 +341,34 @@ void test_free()
 	is_eq(i,3);
 }
 
+void test_realloc()
+{
+    // realloc(NULL, n) is like malloc(n).
+    int *a = realloc(NULL, 3 * sizeof(int));
+    is_not_null(a);
+    for (int i = 0; i < 3; i++) {
+        a[i] = (i + 1) * 10;
+    }
+
+    // The contents are kept when the memory grows.
+    a = realloc(a, 6 * sizeof(int));
+    is_not_null(a);
+    is_eq(a[0], 10);
+    is_eq(a[1], 20);
+    is_eq(a[2], 30);
+    a[5] = 60;
+    is_eq(a[5], 60);
+
+    char *s = calloc(4, sizeof(char));
+    strcpy(s, "abc");
+    s = realloc(s, 8);
+    strcat(s, "def");
+    is_streq(s, "abcdef");
+
+    free(a);
+    free(s);
+}
+
 int values[] = { 40, 10, 100, 90, 20, 25 };
 int compare (const void * a, const void * b)
 {
@@ -233,7 +388,7 @@ void q_sort(){
 
 int main()
 {
-    plan(754);
+    plan(778);
 
     char *endptr;
 
@@ -331,12 +486,18 @@ int main()
     diag("calloc")
     test_calloc();
 
+    diag("alloca")
+    test_alloca();
+
     // exit() is handled in tests/exit.c
 
     // free() is handled with the malloc and calloc tests.
 	diag("free");
 	test_free();
 
+    diag("realloc")
+    test_realloc();
+
     diag("getenv")
     is_not_null(getenv("PATH"));
     is_not_null(getenv("HOME"));
@@ -396,6 +557,8 @@ int main()
     test_malloc3();
     test_malloc4();
     test_malloc5();
+    test_malloc6();
+    test_malloc7();
 
     diag("rand")
     int i, nextRand, lastRand = rand();
@@ -1,4 +1,5 @@
 #include <string.h>
+#include <wchar.h>
 #include "tests.h"
 
 typedef struct mem {
@@ -20,7 +21,7 @@ void setarr(int arr[], int val) {
 
 int main()
 {
-    plan(80);
+    plan(109);
 
     diag("TODO: __builtin_object_size")
     // https://github.com/elliotchance/c2go/issues/359
@@ -89,6 +90,12 @@ int main()
         is_eq(dest2[25], 'c');
     }
 
+    {
+        char dest[4] = "xyz";
+        strncpy(dest, "hello", sizeof(dest));
+        is_eq(dest[3], 'l');
+    }
+
     {
         diag("strlen")
         is_eq(strlen(""), 0);
@@ -106,6 +113,10 @@ int main()
 		strcat (str,"are ");
 		strcat (str,"concatenated.");
 		is_streq(str,"these strings are concatenated.");
+
+		char greeting[20] = "hello";
+		strcat(greeting, ", world");
+		is_streq(greeting, "hello, world");
 	}
 	{
 		diag("strcmp");
@@ -272,6 +283,34 @@ int main()
         setptr(dest9.a, 2);
         is_eq(dest9.a[0], 2);
     }
+    {
+        diag("memset & memcpy of arrays");
+        int a[4] = {1, 2, 3, 4};
+        int b[4];
+        short c[3];
+        char s[6] = "hello";
+        int n = 2;
+        memcpy(b, a, sizeof(a));
+        is_eq(b[3], 4);
+        memset(a, 0, sizeof(a));
+        is_eq(a[0], 0);
+        is_eq(a[3], 0);
+        is_eq(b[0], 1);
+        memmove(&b[1], b, 3 * sizeof(int));
+        is_eq(b[0], 1);
+        is_eq(b[1], 1);
+        is_eq(b[2], 2);
+        is_eq(b[3], 3);
+        memcpy(a, b, n * sizeof(int));
+        is_eq(a[1], 1);
+        is_eq(a[2], 0);
+        memset(b, -1, sizeof(b));
+        is_eq(b[2], -1);
+        memset(c, 1, sizeof(c));
+        is_eq(c[2], 257);
+        memset(&s[1], n + 'a', 2);
+        is_streq(s, "hcclo");
+    }
     {
         diag("memcmp");
         {
@@ -325,6 +364,33 @@ int main()
             is_null(res);
         }
     }
+    {
+        diag("escape sequences in string literals");
+        {
+            char s[] = "ab\x00-cd\0e";
+            is_eq(sizeof(s), 9);
+            is_eq(s[2], 0);
+            is_eq(s[3], '-');
+            is_eq(s[7], 'e');
+            is_streq(s + 3, "-cd");
+        }
+        {
+            char s[] = "\101\102\0\103\x41\?";
+            is_eq(sizeof(s), 7);
+            is_streq(s, "AB");
+            is_eq(s[3], 'C');
+            is_eq(s[5], '?');
+        }
+    }
+    {
+        diag("wide strings");
+        const wchar_t *s = L"h\u00e9llo";
+        is_eq(wcslen(s), 5);
+        is_eq(s[1], 0xe9);
+        is_eq(s[5], 0);
+        is_eq(wcslen(L""), 0);
+        is_eq(wcslen(L"\x4E16\x754C"), 2);
+    }
 
     done_testing();
 }
@@ -405,9 +405,217 @@ void test_mark()
         is_eq(study->start_bits[i], 0);
 }
 
+struct point {
+    int x;
+    int y;
+};
+
+struct point make_point(int x, int y)
+{
+    struct point p;
+    p.x = x;
+    p.y = y;
+    return p;
+}
+
+struct point literal_point(int v)
+{
+    return (struct point){v, v * 2};
+}
+
+typedef struct {
+    int a;
+    double b;
+} anonymous_pair;
+
+anonymous_pair make_anonymous(int v)
+{
+    anonymous_pair r;
+    r.a = v;
+    r.b = v / 2.0;
+    return r;
+}
+
+void struct_return()
+{
+    diag("struct_return");
+
+    is_eq(make_point(3, 4).y, 4);
+    is_eq(literal_point(5).y, 10);
+
+    struct point p = make_point(1, 2);
+    is_eq(p.x + p.y, 3);
+
+    is_eq(make_anonymous(7).a, 7);
+    is_eq(make_anonymous(7).b, 3.5);
+}
+
+struct flags {
+    unsigned low : 2;
+    unsigned high : 6;
+    int plain;
+    int small : 3;
+    unsigned wide : 7;
+};
+
+typedef struct flags flags_t;
+
+void struct_bitfields()
+{
+    diag("struct_bitfields");
+
+    struct flags f = {1, 2, 3};
+    is_eq(f.low, 1);
+    is_eq(f.high, 2);
+    is_eq(f.plain, 3);
+
+    f.low = 3;
+    f.high = 63;
+    is_eq(f.low, 3);
+    is_eq(f.high, 63);
+
+    // The bits of a value that does not fit are dropped.
+    f.low = 5;
+    is_eq(f.low, 1);
+    is_eq(f.high, 63);
+
+    f.high += 1;
+    is_eq(f.high, 0);
+    is_eq(f.low, 1);
+
+    f.small = -3;
+    is_eq(f.small, -3);
+    f.small = 5;
+    is_eq(f.small, -3);
+
+    // wide straddles the first and the second byte of its storage.
+    f.wide = 100;
+    is_eq(f.wide, 100);
+    is_eq(f.small, -3);
+
+    flags_t *p = &f;
+    p->low++;
+    is_eq(p->low, 2);
+    is_eq(f.low, 2);
+}
+
+struct termio_like {
+    int flags;
+    struct {
+        int x;
+        int y;
+    };
+    union {
+        int i;
+        float f;
+    };
+    union {
+        char c;
+        short h;
+    };
+};
+
+void struct_anonymous_members()
+{
+    diag("struct_anonymous_members");
+
+    struct termio_like t = {1, {2, 3}};
+    is_eq(t.flags, 1);
+    is_eq(t.x, 2);
+    is_eq(t.y, 3);
+
+    t.x = 4;
+    t.i = 5;
+    t.h = 6;
+    is_eq(t.x + t.y, 7);
+    is_eq(t.i, 5);
+    is_eq(t.h, 6);
+
+    struct termio_like *p = &t;
+    p->y = 8;
+    is_eq(t.y, 8);
+
+    // Both anonymous unions have storage of their own.
+    is_eq(sizeof(struct termio_like), 20);
+}
+
+struct cl_point { int x; int y; };
+
+int cl_sum(struct cl_point p) { return p.x + p.y; }
+
+int cl_y(struct cl_point *p) { return p->y; }
+
+void compound_literals()
+{
+    diag("compound_literals");
+
+    is_eq(cl_sum((struct cl_point){1, 2}), 3);
+    is_eq(cl_y(&(struct cl_point){3, 4}), 4);
+
+    int *v = (int[]){5, 6, 7};
+    is_eq(v[2], 7);
+
+    int *w = &(int){8};
+    *w += 1;
+    is_eq(*w, 9);
+}
+
+struct ni_inner { int a; int b; };
+struct ni_outer { struct ni_inner in; int c; };
+struct ni_box { struct ni_inner items[2]; char name[8]; };
+
+void nested_initializers()
+{
+    diag("nested_initializers");
+
+    struct ni_outer o = {{1, 2}, 3};
+    is_eq(o.in.b, 2);
+    is_eq(o.c, 3);
+
+    struct ni_box b = {{{1, 2}, {3, 4}}, "box"};
+    is_eq(b.items[1].a, 3);
+    is_streq(b.name, "box");
+
+    struct ni_outer arr[2] = {{{1, 2}, 3}, {{4, 5}, 6}};
+    is_eq(arr[1].in.a, 4);
+    is_eq(arr[1].c, 6);
+}
+
+struct ll_node { int value; struct ll_node *next; };
+
+int ll_length(struct ll_node *n)
+{
+    return n ? 1 + ll_length(n->next) : 0;
+}
+
+struct mr_a;
+struct mr_b { struct mr_a *a; int n; };
+struct mr_a { struct mr_b *b; int n; };
+
+void self_referential_structs()
+{
+    diag("self_referential_structs");
+
+    struct ll_node third = {3, NULL};
+    struct ll_node second = {2, &third};
+    struct ll_node first = {1, &second};
+    is_eq(ll_length(&first), 3);
+    is_eq(first.next->next->value, 3);
+    is_null(third.next);
+
+    struct mr_a a;
+    struct mr_b b;
+    a.b = &b;
+    a.n = 1;
+    b.a = &a;
+    b.n = 2;
+    is_eq(a.b->a->n, 1);
+    is_eq(b.a->b->n, 2);
+}
+
 int main()
 {
-    plan(104);
+    plan(147);
 
     struct programming variable;
     char *s = "Programming in Software Development.";
@@ -670,5 +878,13 @@ int main()
 
 	test_mark();
 
+	struct_return();
+
+	struct_bitfields();
+	struct_anonymous_members();
+	compound_literals();
+	nested_initializers();
+	self_referential_structs();
+
     done_testing();
 }
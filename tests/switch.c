@@ -468,9 +468,92 @@ void switch_without_input()
 	is_eq(pos,1);
 }
 
+int two_cases_fall_into_third(int x)
+{
+    int r = 0;
+    switch (x)
+    {
+    case 1:
+        r += 1;
+    case 2:
+        r += 10;
+    case 3:
+        r += 100;
+        break;
+    default:
+        r = 9;
+    case 4:
+    case 5:
+        r += 1000;
+    }
+    return r;
+}
+
+void fallthrough_two_cases_into_third()
+{
+    is_eq(two_cases_fall_into_third(1), 111);
+    is_eq(two_cases_fall_into_third(2), 110);
+    is_eq(two_cases_fall_into_third(3), 100);
+    is_eq(two_cases_fall_into_third(4), 1000);
+    is_eq(two_cases_fall_into_third(5), 1000);
+    is_eq(two_cases_fall_into_third(6), 1009);
+}
+
+enum flag_size { FLAG_SMALL = 2, FLAG_LARGE };
+
+int constant_case(int x)
+{
+    int a[FLAG_LARGE * 2 + 1];
+    a[0] = 0;
+
+    switch (x)
+    {
+    case 1 << 4:
+        return 1;
+    case FLAG_LARGE + 1:
+        return 2;
+    case sizeof(a) / sizeof(a[0]):
+        return 3;
+    }
+    return a[0];
+}
+
+void constant_expressions_in_cases()
+{
+    is_eq(constant_case(16), 1);
+    is_eq(constant_case(4), 2);
+    is_eq(constant_case(7), 3);
+    is_eq(constant_case(1), 0);
+}
+
+int char_case(char c)
+{
+    switch (c)
+    {
+    case 'a':
+        return 1;
+    case '\n':
+        return 2;
+    case -2:
+        return 3;
+    case '\xa0':
+        return 4;
+    }
+    return 0;
+}
+
+void character_constants_in_cases()
+{
+    is_eq(char_case('a'), 1);
+    is_eq(char_case('\n'), 2);
+    is_eq(char_case(-2), 3);
+    is_eq(char_case((char)0xa0), 4);
+    is_eq(char_case('b'), 0);
+}
+
 int main()
 {
-    plan(37);
+    plan(52);
 
     match_a_single_case();
     fallthrough_to_next_case();
@@ -496,6 +579,9 @@ int main()
 	empty_switch();
 	default_only_switch();
 	switch_without_input();
+	fallthrough_two_cases_into_third();
+	constant_expressions_in_cases();
+	character_constants_in_cases();
 
     done_testing();
 }
@@ -5,9 +5,17 @@ void f_empty(){
 	return;
 };
 
+int ternary_calls = 0;
+
+int ternary_next(int value)
+{
+	ternary_calls++;
+	return value;
+}
+
 int main()
 {
-    plan(9);
+    plan(23);
 
     int a = 'a' == 65 ? 10 : 100;
     float b = 10 == 10 ? 1.0 : 2.0;
@@ -41,5 +49,41 @@ int main()
 	{           ; 0 ? f_empty() : f_empty(); }
 	pass("Ok - ToVoid");
 
+	diag("only the taken branch is evaluated")
+	{
+		int x = 0, y = 0;
+		int r = 1 ? (x++, 10) : (y++, 20);
+		is_eq(r, 10);
+		is_eq(x, 1);
+		is_eq(y, 0);
+
+		r = x > 5 ? (x++, 1) : y == 0 ? (y += 3, 2) : (x--, 3);
+		is_eq(r, 2);
+		is_eq(x, 1);
+		is_eq(y, 3);
+
+		r = (x ? y : x++) + (y ? 100 : 200);
+		is_eq(r, 103);
+	}
+
+	diag("the GNU extension without the middle operand")
+	{
+		int r = ternary_next(7) ?: ternary_next(8);
+		is_eq(r, 7);
+		is_eq(ternary_calls, 1);
+
+		r = ternary_next(0) ?: ternary_next(8);
+		is_eq(r, 8);
+		is_eq(ternary_calls, 3);
+
+		char *s = NULL;
+		is_streq(s ?: "none", "none");
+		s = "some";
+		is_streq(s ?: "none", "some");
+
+		int x = 0;
+		is_eq(x++ ?: x + 10, 11);
+	}
+
     done_testing();
 }
@@ -39,12 +39,25 @@ void test_notptr()
     }
 }
 
+void test_typeof()
+{
+    int x = 5;
+    int *a = &x;
+    typeof(a) b = a;
+    typeof(*a) c = *b + 1;
+    *b = 7;
+    is_eq(x, 7);
+    is_eq(c, 6);
+    is_eq(sizeof(typeof(*b)), sizeof(int));
+}
+
 int main()
 {
-    plan(4);
+    plan(7);
 
     START_TEST(notint)
     START_TEST(notptr)
+    START_TEST(typeof)
 
     done_testing();
 }
@@ -249,9 +249,52 @@ void union_with_struct()
 	is_true( u.l > 0 );
 }
 
+union int_float {
+	int i;
+	float f;
+};
+
+void union_shared_bytes()
+{
+	diag("Members share their bytes")
+	union int_float u;
+	u.i = 0x3f800000;
+	is_eq(u.f, 1.0);
+	u.f = 2.0;
+	is_eq(u.i, 0x40000000);
+
+	union int_float copy = u;
+	copy.i = 0;
+	is_eq(copy.f, 0.0);
+	is_eq(u.f, 2.0);
+}
+
+union uint_float {
+	unsigned int bits;
+	float f;
+	unsigned char bytes[4];
+};
+
+void union_type_punning()
+{
+	diag("Members are reinterpreted in the byte order of the platform")
+	union uint_float u;
+	u.f = -1.5f;
+	is_eq(u.bits, 0xbfc00000);
+
+	// The first byte in memory is the low byte on a little-endian platform.
+	unsigned int one = 1;
+	int little = *(unsigned char *)&one == 1;
+	is_eq(u.bytes[0], little ? 0x00 : 0xbf);
+	is_eq(u.bytes[3], little ? 0xbf : 0x00);
+
+	u.bytes[little ? 3 : 0] = 0x3f;
+	is_eq(u.f, 1.5);
+}
+
 int main()
 {
-    plan(46);
+    plan(54);
 
     union programming variable;
 
@@ -267,6 +310,8 @@ int main()
 	union_array();
 	union_arr_in_str();
 	union_with_struct();
+	union_shared_bytes();
+	union_type_punning();
 
     done_testing();
 }
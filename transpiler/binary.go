@@ -57,6 +57,51 @@ func transpileBinaryOperatorComma(n *ast.BinaryOperator, p *program.Program) (
 	return nil, nil, nil
 }
 
+// transpileCommaLeft transpiles the left side of a comma operator. Only the
+// side effects of it are needed, so it is transpiled into statements.
+func transpileCommaLeft(n ast.Node, p *program.Program) (stmts []goast.Stmt, err error) {
+	stmts, err = transpileToStmts(n, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// A value that is not used, like the "a" of "a, b", is not a valid
+	// statement in Go.
+	for i, s := range stmts {
+		e, ok := s.(*goast.ExprStmt)
+		if !ok || hasSideEffects(e.X) {
+			continue
+		}
+		if b, ok := e.X.(*goast.BinaryExpr); ok && isAssignOperator(b.Op) {
+			continue
+		}
+		stmts[i] = &goast.AssignStmt{
+			Lhs: []goast.Expr{goast.NewIdent("_")},
+			Tok: token.ASSIGN,
+			Rhs: []goast.Expr{e.X},
+		}
+	}
+	return
+}
+
+// getCommaOperator returns the comma operator of the node, that can be in
+// parentheses. It returns nil if the node is not a comma operator.
+func getCommaOperator(n ast.Node) *ast.BinaryOperator {
+	for {
+		switch v := n.(type) {
+		case *ast.ParenExpr:
+			n = v.Children()[0]
+		case *ast.BinaryOperator:
+			if v.Operator == "," {
+				return v
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
 func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsStmt bool) (
 	expr goast.Expr, eType string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
 	defer func() {
@@ -68,7 +113,8 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 
 	operator := getTokenForOperator(n.Operator)
 
-	// Char overflow
+	// Char overflow, when char is unsigned a char of -1 is 255. A signed
+	// char is promoted to int with its sign, see program.ABI.CharSigned.
 	// BinaryOperator 0x2b74458 <line:506:7, col:18> 'int' '!='
 	// |-ImplicitCastExpr 0x2b74440 <col:7, col:10> 'int' <IntegralCast>
 	// | `-ImplicitCastExpr 0x2b74428 <col:7, col:10> 'char' <LValueToRValue>
@@ -76,7 +122,7 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 	// `-ParenExpr 0x2b74408 <col:15, col:18> 'int'
 	//   `-UnaryOperator 0x2b743e8 <col:16, col:17> 'int' prefix '-'
 	//     `-IntegerLiteral 0x2b743c8 <col:17> 'int' 1
-	if n.Operator == "!=" {
+	if n.Operator == "!=" && p.ABI != nil && !p.ABI.CharSigned {
 		var leftOk bool
 		if l0, ok := n.ChildNodes[0].(*ast.ImplicitCastExpr); ok && l0.Type == "int" {
 			if len(l0.ChildNodes) > 0 {
@@ -190,26 +236,58 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 	// | `-ImplicitCastExpr 0x21a7898 <col:6> 'int' <LValueToRValue>
 	// |   `-DeclRefExpr 0x21a7870 <col:6> 'int' lvalue Var 0x21a7748 'y' 'int'
 	if getTokenForOperator(n.Operator) == token.COMMA {
-		stmts, _, newPre, newPost, err := transpileToExpr(n.Children()[0], p, exprIsStmt)
+		preStmts, err = transpileCommaLeft(n.Children()[0], p)
 		if err != nil {
 			return nil, "unknown50", nil, nil, err
 		}
-		preStmts = append(preStmts, newPre...)
-		preStmts = append(preStmts, util.NewExprStmt(stmts))
-		preStmts = append(preStmts, newPost...)
 
-		var st string
-		stmts, st, newPre, newPost, err = transpileToExpr(n.Children()[1], p, exprIsStmt)
+		var newPre []goast.Stmt
+		expr, eType, newPre, postStmts, err = transpileToExpr(n.Children()[1], p, exprIsStmt)
 		if err != nil {
 			return nil, "unknown51", nil, nil, err
 		}
-		// Theoretically , we don't have any preStmts or postStmts
-		// from n.Children()[1]
-		if len(newPre) > 0 || len(newPost) > 0 {
-			p.AddMessage(p.GenerateWarningMessage(
-				fmt.Errorf("Not support length pre or post stmts: {%d,%d}", len(newPre), len(newPost)), n))
+		preStmts = append(preStmts, newPre...)
+		return
+	}
+
+	// The left side of a comma operator on the right side of an assignment is
+	// evaluated before the assignment:
+	//
+	//     x = (a++, b);    =>    a++
+	//                            x = b
+	//
+	// The comma operator of an assignment that is not a statement is
+	// transpiled by atomicOperation().
+	if exprIsStmt && getTokenForOperator(n.Operator) == token.ASSIGN {
+		if c := getCommaOperator(n.Children()[1]); c != nil {
+			preStmts, err = transpileCommaLeft(c.Children()[0], p)
+			if err != nil {
+				return nil, "", nil, nil, err
+			}
+
+			var newPre []goast.Stmt
+			expr, eType, newPre, postStmts, err = transpileBinaryOperator(&ast.BinaryOperator{
+				Type:       n.Type,
+				Type2:      n.Type2,
+				Operator:   n.Operator,
+				ChildNodes: []ast.Node{n.Children()[0], c.Children()[1]},
+			}, p, exprIsStmt)
+			preStmts = append(preStmts, newPre...)
+			return
+		}
+	}
+
+	// An assignment of a bit-field is a call of its setter.
+	if isAssignOperator(operator) {
+		var ok bool
+		expr, eType, preStmts, postStmts, ok, err = transpileBitfieldAssign(n, p)
+		if ok || err != nil {
+			return
+		}
+		expr, eType, preStmts, postStmts, ok, err = transpileAtomicAssign(n, p, exprIsStmt)
+		if ok || err != nil {
+			return
 		}
-		return stmts, st, preStmts, postStmts, nil
 	}
 
 	left, leftType, newPre, newPost, err := atomicOperation(n.Children()[0], p)
@@ -223,14 +301,20 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 	if err != nil {
 		return nil, "unknown53", nil, nil, err
 	}
-	var adjustPointerDiff int
+	// The difference of two pointers is the number of elements between them,
+	// so the difference of the addresses is divided by the size of an element.
+	// It is the size of the Go type, which can be smaller than the size of the
+	// C type, like an int32 for a long.
+	var pointerDiffSize goast.Expr
 	if types.IsPointer(p, leftType) && types.IsPointer(p, rightType) &&
 		(operator == token.SUB ||
 			operator == token.LSS || operator == token.GTR ||
 			operator == token.LEQ || operator == token.GEQ) {
 		baseSize, err := types.SizeOf(p, types.GetBaseType(leftType))
-		if operator == token.SUB && err == nil && baseSize > 1 {
-			adjustPointerDiff = baseSize
+		goType, _ := types.ResolveType(p, leftType)
+		if operator == token.SUB && (err != nil || baseSize > 1) &&
+			strings.HasPrefix(goType, "*") {
+			pointerDiffSize = pointerElementSize(p, left)
 		}
 		left, leftType, err = GetUintptrForPointer(p, left, leftType)
 		if err != nil {
@@ -254,6 +338,20 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		}
 	}
 
+	// The right side of && and || is only evaluated when it is needed, and so
+	// are its statements.
+	if (operator == token.LAND || operator == token.LOR) &&
+		(len(newPre) > 0 || len(newPost) > 0) {
+		right, err = types.CastExpr(p, right, rightType, "bool")
+		p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, right == nil))
+		if right == nil {
+			right = util.NewNil()
+		}
+		right = util.NewAnonymousFunction(newPre, newPost, right, "bool")
+		rightType = "bool"
+		newPre, newPost = nil, nil
+	}
+
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
 	returnType := types.ResolveTypeForBinaryOperator(p, n.Operator, leftType, rightType)
@@ -319,6 +417,21 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		}
 	}
 
+	// The usual arithmetic conversions of the operands are in the AST already,
+	// so the operands of an integer division or remainder have the type of
+	// the result. But a literal is transpiled as an int, whatever its C type
+	// is. The left side is cast to the type of the result (the right side is
+	// cast to the left side below) so that the division truncates like in C
+	// and an unsigned operation is not done on signed Go types:
+	//
+	//     3u % u   =>   uint32(3) % u
+	if (operator == token.QUO || operator == token.REM) &&
+		types.IsCInteger(p, n.Type) && leftType != n.Type {
+		left, err = types.CastExpr(p, left, leftType, n.Type)
+		leftType = n.Type
+		p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, left == nil))
+	}
+
 	if operator == token.NEQ || operator == token.EQL ||
 		operator == token.LSS || operator == token.GTR ||
 		operator == token.LEQ || operator == token.GEQ ||
@@ -401,19 +514,128 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		return nil, "", nil, nil, err
 	}
 
-	if adjustPointerDiff > 0 {
+	if pointerDiffSize != nil {
 		expr := util.NewBinaryExpr(left, operator, right, resolvedLeftType, exprIsStmt)
 		returnType = types.ResolveTypeForBinaryOperator(p, n.Operator, leftType, rightType)
-		return util.NewBinaryExpr(expr, token.QUO, util.NewIntLit(adjustPointerDiff), returnType, exprIsStmt),
+		return util.NewBinaryExpr(expr, token.QUO, pointerDiffSize, returnType, exprIsStmt),
 			returnType,
 			preStmts, postStmts, nil
 	}
 
+	if p.CheckedOverflow {
+		if e, ok := checkedOperation(left, operator, right, resolvedLeftType); ok {
+			p.AddImport("github.com/elliotchance/c2go/noarch")
+			return e, types.ResolveTypeForBinaryOperator(p, n.Operator, leftType, rightType),
+				preStmts, postStmts, nil
+		}
+	}
+
+	// The increments of a long double are transpiled as "+=" and "-=":
+	//
+	//     x++   =>   x = x.Add(noarch.Int32ToLongDouble(int32(1)))
+	if operator == token.ADD_ASSIGN || operator == token.SUB_ASSIGN {
+		if resolvedLeftType == types.BigFloatLongDouble {
+			var hoisted []goast.Stmt
+			left, hoisted = hoistSideEffects(p, left)
+			preStmts = append(preStmts, hoisted...)
+		}
+		if e, ok := longDoubleOperation(left, convertToWithoutAssign(operator),
+			right, resolvedLeftType); ok {
+			return util.NewBinaryExpr(left, token.ASSIGN, e, resolvedLeftType, exprIsStmt),
+				n.Type, preStmts, postStmts, nil
+		}
+	}
+
+	if e, ok := longDoubleOperation(left, operator, right, resolvedLeftType); ok {
+		return e, types.ResolveTypeForBinaryOperator(p, n.Operator, leftType, rightType),
+			preStmts, postStmts, nil
+	}
+
+	// The value of an assignment to a variable is read from the variable after
+	// the assignment, which is done before the expression.
+	if operator == token.ASSIGN && !exprIsStmt {
+		if name, ok := left.(*goast.Ident); ok {
+			preStmts = append(preStmts, util.NewExprStmt(
+				util.NewBinaryExpr(left, operator, right, resolvedLeftType, true)))
+			preStmts = append(preStmts, postStmts...)
+			return util.NewIdent(name.Name),
+				types.ResolveTypeForBinaryOperator(p, n.Operator, leftType, rightType),
+				preStmts, nil, nil
+		}
+	}
+
 	return util.NewBinaryExpr(left, operator, right, resolvedLeftType, exprIsStmt),
 		types.ResolveTypeForBinaryOperator(p, n.Operator, leftType, rightType),
 		preStmts, postStmts, nil
 }
 
+// checkedOperation returns the call of the noarch function that checks the
+// signed integer arithmetic for an overflow, like:
+//
+//     a + b    =>   noarch.CheckedAddInt32(a, b)
+//
+// It is only used with -checked-overflow. If the operation is not an addition,
+// subtraction or multiplication of an int32 or int64 then ok is false.
+func checkedOperation(left goast.Expr, operator token.Token, right goast.Expr,
+	goType string) (_ goast.Expr, ok bool) {
+	if goType != "int32" && goType != "int64" {
+		return nil, false
+	}
+
+	function := map[token.Token]string{
+		token.ADD: "CheckedAdd",
+		token.SUB: "CheckedSub",
+		token.MUL: "CheckedMul",
+	}
+	f, ok := function[operator]
+	if !ok {
+		return nil, false
+	}
+
+	return util.NewCallExpr("noarch."+f+strings.Title(goType), left, right), true
+}
+
+// longDoubleOperation returns the method call for an arithmetic or comparison
+// operator when both sides are a noarch.LongDouble, like:
+//
+//     a + b    =>   a.Add(b)
+//     a < b    =>   a.Cmp(b) < 0
+//
+// If the operation is not for a noarch.LongDouble then ok is false.
+func longDoubleOperation(left goast.Expr, operator token.Token, right goast.Expr,
+	goType string) (_ goast.Expr, ok bool) {
+	if goType != types.BigFloatLongDouble {
+		return nil, false
+	}
+
+	method := map[token.Token]string{
+		token.ADD: "Add",
+		token.SUB: "Sub",
+		token.MUL: "Mul",
+		token.QUO: "Quo",
+	}
+	if m, ok := method[operator]; ok {
+		return &goast.CallExpr{
+			Fun:  &goast.SelectorExpr{X: left, Sel: util.NewIdent(m)},
+			Args: []goast.Expr{right},
+		}, true
+	}
+
+	switch operator {
+	case token.EQL, token.NEQ, token.LSS, token.GTR, token.LEQ, token.GEQ:
+		return &goast.BinaryExpr{
+			X: &goast.CallExpr{
+				Fun:  &goast.SelectorExpr{X: left, Sel: util.NewIdent("Cmp")},
+				Args: []goast.Expr{right},
+			},
+			Op: operator,
+			Y:  util.NewIntLit(0),
+		}, true
+	}
+
+	return nil, false
+}
+
 func foundCallExpr(n ast.Node) *ast.CallExpr {
 	switch v := n.(type) {
 	case *ast.ImplicitCastExpr, *ast.CStyleCastExpr:
@@ -432,10 +654,11 @@ func foundCallExpr(n ast.Node) *ast.CallExpr {
 // Would return the node that represents the "sizeof(int)".
 //
 // If the node does not represent an allocation operation (such as calling
-// malloc, calloc, realloc, etc.) then nil is returned.
+// malloc or calloc) then nil is returned. The realloc() keeps the contents of
+// the memory, so it is called like the other functions, see noarch.Realloc().
 //
 // In the case of calloc() it will return a new BinaryExpr that multiplies both
-// arguments.
+// arguments, unless the size is a sizeof.
 func getAllocationSizeNode(p *program.Program, node ast.Node) ast.Node {
 	expr := foundCallExpr(node)
 
@@ -452,6 +675,11 @@ func getAllocationSizeNode(p *program.Program, node ast.Node) ast.Node {
 	}
 
 	if functionName == "calloc" {
+		// The calloc() of a sizeof is a slice of the type instead, see
+		// transpileCalloc().
+		if _, ok := callocElementType(expr.Children()[2]); ok {
+			return nil
+		}
 		return &ast.BinaryOperator{
 			Type:       "int",
 			Operator:   "*",
@@ -459,15 +687,6 @@ func getAllocationSizeNode(p *program.Program, node ast.Node) ast.Node {
 		}
 	}
 
-	// TODO: realloc() is not supported
-	// https://github.com/elliotchance/c2go/issues/118
-	//
-	// Realloc will be treated as calloc which will almost certainly cause
-	// bugs in your code.
-	if functionName == "realloc" {
-		return expr.Children()[2]
-	}
-
 	return nil
 }
 
@@ -491,6 +710,7 @@ func generateAlloc(p *program.Program, allocSize ast.Node, leftType string) (
 		return nil, preStmts, postStmts, err
 	}
 
+	p.AddImport("github.com/elliotchance/c2go/noarch")
 	right = util.NewCallExpr(
 		"noarch.Malloc",
 		allocSizeExpr,
@@ -505,3 +725,20 @@ func generateAlloc(p *program.Program, allocSize ast.Node, leftType string) (
 	}
 	return
 }
+
+// pointerElementSize returns the size in bytes of the element that a Go
+// pointer points to, as an int64:
+//
+//     int64(unsafe.Sizeof(*p))
+//
+// The operand of unsafe.Sizeof() is not evaluated, so the pointer can be any
+// expression.
+func pointerElementSize(p *program.Program, pointer goast.Expr) goast.Expr {
+	if _, ok := pointer.(*goast.Ident); !ok {
+		pointer = &goast.ParenExpr{X: pointer}
+	}
+
+	p.AddImport("unsafe")
+	return util.NewCallExpr("int64",
+		util.NewCallExpr("unsafe.Sizeof", &goast.StarExpr{X: pointer}))
+}
@@ -0,0 +1,980 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestCommaOperator(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+		want string
+	}{
+		// int f(int a) {
+		//     int x;
+		//     x = (a++, a * 10);
+		//     for (a = 0; a < 5; a++, x += 100)
+		//         if (a == 2)
+		//             continue;
+		//     return x;
+		// }
+		{"statement", `
+FunctionDecl 0x10 <x.c:1:1, line:9:1> line:1:5 f 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used a 'int'
+|-CompoundStmt 0x12 <col:14, line:9:1>
+  |-DeclStmt 0x13 <line:2:3, col:8>
+  | |-VarDecl 0x14 <col:3, col:7> col:7 used x 'int'
+  |-BinaryOperator 0x20 <line:3:3, col:15> 'int' '='
+  | |-DeclRefExpr 0x21 <col:3> 'int' lvalue Var 0x14 'x' 'int'
+  | |-ParenExpr 0x22 <col:7, col:15> 'int'
+  |   |-BinaryOperator 0x23 <col:8, col:14> 'int' ','
+  |     |-UnaryOperator 0x24 <col:8, col:9> 'int' postfix '++'
+  |     | |-DeclRefExpr 0x25 <col:8> 'int' lvalue ParmVar 0x11 'a' 'int'
+  |     |-BinaryOperator 0x26 <col:12, col:14> 'int' '*'
+  |       |-ImplicitCastExpr 0x27 <col:12> 'int' <LValueToRValue>
+  |       | |-DeclRefExpr 0x28 <col:12> 'int' lvalue ParmVar 0x11 'a' 'int'
+  |       |-IntegerLiteral 0x29 <col:14> 'int' 10
+  |-ForStmt 0x30 <line:4:3, line:7:3>
+  | |-BinaryOperator 0x31 <line:4:8, col:12> 'int' '='
+  | | |-DeclRefExpr 0x32 <col:8> 'int' lvalue ParmVar 0x11 'a' 'int'
+  | | |-IntegerLiteral 0x33 <col:12> 'int' 0
+  | |-NullStmt
+  | |-BinaryOperator 0x34 <col:15, col:19> 'int' '<'
+  | | |-ImplicitCastExpr 0x35 <col:15> 'int' <LValueToRValue>
+  | | | |-DeclRefExpr 0x36 <col:15> 'int' lvalue ParmVar 0x11 'a' 'int'
+  | | |-IntegerLiteral 0x37 <col:19> 'int' 5
+  | |-BinaryOperator 0x38 <col:22, col:30> 'int' ','
+  | | |-UnaryOperator 0x39 <col:22, col:23> 'int' postfix '++'
+  | | | |-DeclRefExpr 0x3a <col:22> 'int' lvalue ParmVar 0x11 'a' 'int'
+  | | |-CompoundAssignOperator 0x3b <col:26, col:31> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  | |   |-DeclRefExpr 0x3c <col:26> 'int' lvalue Var 0x14 'x' 'int'
+  | |   |-IntegerLiteral 0x3d <col:31> 'int' 100
+  | |-CompoundStmt 0x40 <col:34, line:7:3>
+  |   |-IfStmt 0x41 <line:5:5, line:6:7>
+  |     |-NullStmt
+  |     |-NullStmt
+  |     |-BinaryOperator 0x42 <line:5:9, col:14> 'int' '=='
+  |     | |-ImplicitCastExpr 0x43 <col:9> 'int' <LValueToRValue>
+  |     | | |-DeclRefExpr 0x44 <col:9> 'int' lvalue ParmVar 0x11 'a' 'int'
+  |     | |-IntegerLiteral 0x45 <col:14> 'int' 2
+  |     |-ContinueStmt 0x46 <line:6:7>
+  |     |-NullStmt
+  |-ReturnStmt 0x50 <line:8:3, col:10>
+    |-ImplicitCastExpr 0x51 <col:10> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x52 <col:10> 'int' lvalue Var 0x14 'x' 'int'
+`, `
+	var x int32
+	a += 1
+	x = a*int32(10)
+	for a = int32(0); a < int32(5); func() {
+		a += 1
+		x += int32(100)
+	}() {
+		if a == int32(2) {
+			continue
+		}
+	}
+	return x
+`},
+
+		// int g(int a) {
+		//     int y = (a++, a + 1);
+		//     return (a, y);
+		// }
+		{"expression", `
+FunctionDecl 0x10 <x.c:1:1, line:4:1> line:1:5 g 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used a 'int'
+|-CompoundStmt 0x12 <col:14, line:9:1>
+  |-DeclStmt 0x13 <line:2:3, col:8>
+  | |-VarDecl 0x14 <col:3, col:7> col:7 used y 'int' cinit
+  |   |-ParenExpr 0x22 <col:7, col:15> 'int'
+  |     |-BinaryOperator 0x23 <col:8, col:14> 'int' ','
+  |       |-UnaryOperator 0x24 <col:8, col:9> 'int' postfix '++'
+  |       | |-DeclRefExpr 0x25 <col:8> 'int' lvalue ParmVar 0x11 'a' 'int'
+  |       |-BinaryOperator 0x26 <col:12, col:14> 'int' '+'
+  |         |-ImplicitCastExpr 0x27 <col:12> 'int' <LValueToRValue>
+  |         | |-DeclRefExpr 0x28 <col:12> 'int' lvalue ParmVar 0x11 'a' 'int'
+  |         |-IntegerLiteral 0x29 <col:14> 'int' 1
+  |-ReturnStmt 0x50 <line:8:3, col:10>
+    |-ParenExpr 0x53 <col:7, col:15> 'int'
+      |-BinaryOperator 0x54 <col:8, col:14> 'int' ','
+        |-ImplicitCastExpr 0x55 <col:12> 'int' <LValueToRValue>
+        | |-DeclRefExpr 0x56 <col:12> 'int' lvalue ParmVar 0x11 'a' 'int'
+        |-ImplicitCastExpr 0x51 <col:10> 'int' <LValueToRValue>
+          |-DeclRefExpr 0x52 <col:10> 'int' lvalue Var 0x14 'y' 'int'
+`, `
+	var y int32 = func() int32 {
+		a += 1
+		var tempVar int32 = a + int32(1)
+		return tempVar
+	}()
+	_ = a
+	return (y)
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := program.NewProgram()
+			decls, err := transpileFunctionDecl(parseTree(tt.dump).(*ast.FunctionDecl), p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want[1:]) {
+				t.Errorf("Expected:\n%s\nin:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestPointerArithmetic(t *testing.T) {
+	// long f(long *p, long *q) {
+	//     return *(p + 2) + (p - q);
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:3:1> line:1:6 f 'long (long *, long *)'
+|-ParmVarDecl 0x11 <col:8, col:14> col:14 used p 'long *'
+|-ParmVarDecl 0x12 <col:17, col:23> col:23 used q 'long *'
+|-CompoundStmt 0x13 <col:26, line:3:1>
+  |-ReturnStmt 0x14 <line:2:5, col:30>
+    |-BinaryOperator 0x15 <col:12, col:30> 'long' '+'
+      |-ImplicitCastExpr 0x16 <col:12, col:19> 'long' <LValueToRValue>
+      | |-UnaryOperator 0x17 <col:12, col:19> 'long' lvalue prefix '*' cannot overflow
+      |   |-ParenExpr 0x18 <col:13, col:19> 'long *'
+      |     |-BinaryOperator 0x19 <col:14, col:18> 'long *' '+'
+      |       |-ImplicitCastExpr 0x1a <col:14> 'long *' <LValueToRValue>
+      |       | |-DeclRefExpr 0x1b <col:14> 'long *' lvalue ParmVar 0x11 'p' 'long *'
+      |       |-IntegerLiteral 0x1c <col:18> 'int' 2
+      |-ParenExpr 0x1d <col:23, col:30> 'long'
+        |-BinaryOperator 0x1e <col:24, col:29> 'long' '-'
+          |-ImplicitCastExpr 0x1f <col:24> 'long *' <LValueToRValue>
+          | |-DeclRefExpr 0x20 <col:24> 'long *' lvalue ParmVar 0x11 'p' 'long *'
+          |-ImplicitCastExpr 0x21 <col:29> 'long *' <LValueToRValue>
+            |-DeclRefExpr 0x22 <col:29> 'long *' lvalue ParmVar 0x12 'q' 'long *'
+`
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		// p + 2 advances by two elements.
+		"uintptr(unsafe.Pointer(p)) + (uintptr)(",
+		"*unsafe.Sizeof(*p)",
+
+		// p - q is the number of elements between them. The size of the
+		// elements is the size of the Go type, a C long is an int32.
+		"(int64(uintptr(unsafe.Pointer(p)))-int64(uintptr(unsafe.Pointer(q))))/int64(unsafe.Sizeof(*p))",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMalloc(t *testing.T) {
+	// struct s { int a; };
+	// typedef int *IntPtr;
+	// int *g(int n) { return malloc(n); }
+	// struct s *k(void) { return (struct s *)malloc(sizeof(struct s)); }
+	// void h(void) {
+	//     int *a = malloc(sizeof(int));
+	//     struct s *b = (struct s *)malloc(sizeof(struct s));
+	//     IntPtr c = malloc(sizeof(int));
+	//     a = malloc(8);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x5 </usr/include/stdlib.h:1:1, col:30> col:14 used malloc 'void *(unsigned long)'
+| |-ParmVarDecl 0x6 <col:21> col:29 'unsigned long'
+|-RecordDecl 0x7 <x.c:1:1, col:20> col:8 struct s definition
+| |-FieldDecl 0x8 <col:12, col:16> col:16 a 'int'
+|-TypedefDecl 0x9 <line:2:1, col:14> col:14 referenced IntPtr 'int *'
+| |-PointerType 0xa 'int *'
+|   |-BuiltinType 0xb 'int'
+|-FunctionDecl 0x10 <line:3:1, col:50> col:6 g 'int *(int)'
+| |-ParmVarDecl 0x11 <col:8, col:12> col:12 used n 'int'
+| |-CompoundStmt 0x12 <col:15, col:50>
+|   |-ReturnStmt 0x13 <col:17, col:47>
+|     |-ImplicitCastExpr 0x14 <col:24, col:47> 'int *' <BitCast>
+|       |-CallExpr 0x15 <col:24, col:47> 'void *'
+|         |-ImplicitCastExpr 0x16 <col:24> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+|         | |-DeclRefExpr 0x17 <col:24> 'void *(unsigned long)' Function 0x5 'malloc' 'void *(unsigned long)'
+|         |-ImplicitCastExpr 0x18 <col:31> 'unsigned long' <IntegralCast>
+|           |-ImplicitCastExpr 0x19 <col:31> 'int' <LValueToRValue>
+|             |-DeclRefExpr 0x1a <col:31> 'int' lvalue ParmVar 0x11 'n' 'int'
+|-FunctionDecl 0x60 <line:3:1, col:50> col:11 k 'struct s *(void)'
+| |-CompoundStmt 0x62 <col:15, col:50>
+|   |-ReturnStmt 0x63 <col:17, col:47>
+|     |-CStyleCastExpr 0x64 <col:24, col:47> 'struct s *' <BitCast>
+|       |-CallExpr 0x65 <col:24, col:47> 'void *'
+|         |-ImplicitCastExpr 0x66 <col:24> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+|         | |-DeclRefExpr 0x67 <col:24> 'void *(unsigned long)' Function 0x5 'malloc' 'void *(unsigned long)'
+|         |-UnaryExprOrTypeTraitExpr 0x68 <col:37, col:48> 'unsigned long' sizeof 'struct s':'struct s'
+|-FunctionDecl 0x20 <line:4:1, line:9:1> line:4:6 h 'void (void)'
+  |-CompoundStmt 0x21 <col:15, line:9:1>
+    |-DeclStmt 0x22 <line:5:3, col:31>
+    | |-VarDecl 0x23 <col:3, col:30> col:8 used a 'int *' cinit
+    |   |-ImplicitCastExpr 0x24 <col:12, col:30> 'int *' <BitCast>
+    |     |-CallExpr 0x25 <col:12, col:30> 'void *'
+    |       |-ImplicitCastExpr 0x26 <col:12> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+    |       | |-DeclRefExpr 0x27 <col:12> 'void *(unsigned long)' Function 0x5 'malloc' 'void *(unsigned long)'
+    |       |-UnaryExprOrTypeTraitExpr 0x28 <col:19, col:29> 'unsigned long' sizeof 'int'
+    |-DeclStmt 0x30 <line:6:3, col:50>
+    | |-VarDecl 0x31 <col:3, col:49> col:13 b 'struct s *' cinit
+    |   |-CStyleCastExpr 0x32 <col:17, col:49> 'struct s *' <BitCast>
+    |     |-CallExpr 0x33 <col:30, col:49> 'void *'
+    |       |-ImplicitCastExpr 0x34 <col:30> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+    |       | |-DeclRefExpr 0x35 <col:30> 'void *(unsigned long)' Function 0x5 'malloc' 'void *(unsigned long)'
+    |       |-UnaryExprOrTypeTraitExpr 0x36 <col:37, col:48> 'unsigned long' sizeof 'struct s':'struct s'
+    |-DeclStmt 0x40 <line:7:3, col:33>
+    | |-VarDecl 0x41 <col:3, col:32> col:10 c 'IntPtr':'int *' cinit
+    |   |-ImplicitCastExpr 0x42 <col:14, col:32> 'IntPtr':'int *' <BitCast>
+    |     |-CallExpr 0x43 <col:14, col:32> 'void *'
+    |       |-ImplicitCastExpr 0x44 <col:14> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+    |       | |-DeclRefExpr 0x45 <col:14> 'void *(unsigned long)' Function 0x5 'malloc' 'void *(unsigned long)'
+    |       |-UnaryExprOrTypeTraitExpr 0x46 <col:21, col:31> 'unsigned long' sizeof 'int'
+    |-BinaryOperator 0x50 <line:8:3, col:15> 'int *' '='
+      |-DeclRefExpr 0x51 <col:3> 'int *' lvalue Var 0x23 'a' 'int *'
+      |-ImplicitCastExpr 0x52 <col:7, col:15> 'int *' <BitCast>
+        |-CallExpr 0x53 <col:7, col:15> 'void *'
+          |-ImplicitCastExpr 0x54 <col:7> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+          | |-DeclRefExpr 0x55 <col:7> 'void *(unsigned long)' Function 0x5 'malloc' 'void *(unsigned long)'
+          |-IntegerLiteral 0x56 <col:14> 'int' 8
+`
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		`"github.com/elliotchance/c2go/noarch"`,
+		"return (*int32)(noarch.Malloc(int32(uint64(n))))",
+		"return (*s)(noarch.Malloc(int32(4)))",
+		"var a *int32 = (*int32)(noarch.Malloc(int32(4)))",
+		"var b *s = (*s)(noarch.Malloc(int32(4)))",
+		"var c IntPtr = (IntPtr)(noarch.Malloc(int32(4)))",
+		"a = (*int32)(noarch.Malloc(int32(8)))",
+	)
+}
+
+func TestCalloc(t *testing.T) {
+	// struct s { int a; };
+	// struct s *g(int n) { return calloc(n, sizeof(struct s)); }
+	// char *k(int n) { return calloc(4, n); }
+	// void h(int n) {
+	//     struct s *b = calloc(10, sizeof(struct s));
+	//     char *c = calloc(10, n);
+	//     b = calloc(3, sizeof(struct s));
+	//     b = calloc(0, sizeof(struct s));
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x5 </usr/include/stdlib.h:1:1, col:30> col:14 used calloc 'void *(unsigned long, unsigned long)'
+| |-ParmVarDecl 0x6 <col:21> col:29 'unsigned long'
+| |-ParmVarDecl 0x4 <col:21> col:29 'unsigned long'
+|-RecordDecl 0x7 <x.c:1:1, col:20> col:8 struct s definition
+| |-FieldDecl 0x8 <col:12, col:16> col:16 a 'int'
+|-FunctionDecl 0x10 <line:3:1, col:50> col:11 g 'struct s *(int)'
+| |-ParmVarDecl 0x11 <col:8, col:12> col:12 used n 'int'
+| |-CompoundStmt 0x12 <col:15, col:50>
+|   |-ReturnStmt 0x13 <col:17, col:47>
+|     |-ImplicitCastExpr 0x14 <col:24, col:47> 'struct s *' <BitCast>
+|       |-CallExpr 0x15 <col:24, col:47> 'void *'
+|         |-ImplicitCastExpr 0x16 <col:24> 'void *(*)(unsigned long, unsigned long)' <FunctionToPointerDecay>
+|         | |-DeclRefExpr 0x17 <col:24> 'void *(unsigned long, unsigned long)' Function 0x5 'calloc' 'void *(unsigned long, unsigned long)'
+|         |-ImplicitCastExpr 0x18 <col:31> 'unsigned long' <IntegralCast>
+|         | |-ImplicitCastExpr 0x19 <col:31> 'int' <LValueToRValue>
+|         |   |-DeclRefExpr 0x1a <col:31> 'int' lvalue ParmVar 0x11 'n' 'int'
+|         |-UnaryExprOrTypeTraitExpr 0x68 <col:37, col:48> 'unsigned long' sizeof 'struct s':'struct s'
+|-FunctionDecl 0x70 <line:10:1, col:40> col:7 k 'char *(int)'
+| |-ParmVarDecl 0x71 <col:9, col:13> col:13 used n 'int'
+| |-CompoundStmt 0x72 <col:16, col:40>
+|   |-ReturnStmt 0x73 <col:18, col:37>
+|     |-ImplicitCastExpr 0x74 <col:25, col:37> 'char *' <BitCast>
+|       |-CallExpr 0x75 <col:25, col:37> 'void *'
+|         |-ImplicitCastExpr 0x76 <col:25> 'void *(*)(unsigned long, unsigned long)' <FunctionToPointerDecay>
+|         | |-DeclRefExpr 0x77 <col:25> 'void *(unsigned long, unsigned long)' Function 0x5 'calloc' 'void *(unsigned long, unsigned long)'
+|         |-IntegerLiteral 0x78 <col:32> 'unsigned long' 4
+|         |-ImplicitCastExpr 0x79 <col:35> 'unsigned long' <IntegralCast>
+|           |-ImplicitCastExpr 0x7a <col:35> 'int' <LValueToRValue>
+|             |-DeclRefExpr 0x7b <col:35> 'int' lvalue ParmVar 0x71 'n' 'int'
+|-FunctionDecl 0x20 <line:4:1, line:9:1> line:4:6 h 'void (int)'
+  |-ParmVarDecl 0x21 <col:8, col:12> col:12 used n 'int'
+  |-CompoundStmt 0x22 <col:15, line:9:1>
+    |-DeclStmt 0x30 <line:6:3, col:50>
+    | |-VarDecl 0x31 <col:3, col:49> col:13 used b 'struct s *' cinit
+    |   |-ImplicitCastExpr 0x32 <col:17, col:49> 'struct s *' <BitCast>
+    |     |-CallExpr 0x33 <col:30, col:49> 'void *'
+    |       |-ImplicitCastExpr 0x34 <col:30> 'void *(*)(unsigned long, unsigned long)' <FunctionToPointerDecay>
+    |       | |-DeclRefExpr 0x35 <col:30> 'void *(unsigned long, unsigned long)' Function 0x5 'calloc' 'void *(unsigned long, unsigned long)'
+    |       |-IntegerLiteral 0x36 <col:14> 'unsigned long' 10
+    |       |-UnaryExprOrTypeTraitExpr 0x37 <col:37, col:48> 'unsigned long' sizeof 'struct s':'struct s'
+    |-DeclStmt 0x40 <line:7:3, col:33>
+    | |-VarDecl 0x41 <col:3, col:32> col:10 c 'char *' cinit
+    |   |-ImplicitCastExpr 0x42 <col:14, col:32> 'char *' <BitCast>
+    |     |-CallExpr 0x43 <col:14, col:32> 'void *'
+    |       |-ImplicitCastExpr 0x44 <col:14> 'void *(*)(unsigned long, unsigned long)' <FunctionToPointerDecay>
+    |       | |-DeclRefExpr 0x45 <col:14> 'void *(unsigned long, unsigned long)' Function 0x5 'calloc' 'void *(unsigned long, unsigned long)'
+    |       |-IntegerLiteral 0x46 <col:14> 'unsigned long' 10
+    |       |-ImplicitCastExpr 0x47 <col:31> 'unsigned long' <IntegralCast>
+    |         |-ImplicitCastExpr 0x48 <col:31> 'int' <LValueToRValue>
+    |           |-DeclRefExpr 0x49 <col:31> 'int' lvalue ParmVar 0x21 'n' 'int'
+    |-BinaryOperator 0x50 <line:8:3, col:15> 'struct s *' '='
+      |-DeclRefExpr 0x51 <col:3> 'struct s *' lvalue Var 0x31 'b' 'struct s *'
+      |-ImplicitCastExpr 0x52 <col:7, col:15> 'struct s *' <BitCast>
+        |-CallExpr 0x53 <col:7, col:15> 'void *'
+          |-ImplicitCastExpr 0x54 <col:7> 'void *(*)(unsigned long, unsigned long)' <FunctionToPointerDecay>
+          | |-DeclRefExpr 0x55 <col:7> 'void *(unsigned long, unsigned long)' Function 0x5 'calloc' 'void *(unsigned long, unsigned long)'
+          |-IntegerLiteral 0x56 <col:14> 'unsigned long' 3
+          |-UnaryExprOrTypeTraitExpr 0x57 <col:37, col:48> 'unsigned long' sizeof 'struct s':'struct s'
+    |-BinaryOperator 0x58 <line:9:3, col:15> 'struct s *' '='
+      |-DeclRefExpr 0x59 <col:3> 'struct s *' lvalue Var 0x31 'b' 'struct s *'
+      |-ImplicitCastExpr 0x5a <col:7, col:15> 'struct s *' <BitCast>
+        |-CallExpr 0x5b <col:7, col:15> 'void *'
+          |-ImplicitCastExpr 0x5c <col:7> 'void *(*)(unsigned long, unsigned long)' <FunctionToPointerDecay>
+          | |-DeclRefExpr 0x5d <col:7> 'void *(unsigned long, unsigned long)' Function 0x5 'calloc' 'void *(unsigned long, unsigned long)'
+          |-IntegerLiteral 0x5e <col:14> 'unsigned long' 0
+          |-UnaryExprOrTypeTraitExpr 0x5f <col:37, col:48> 'unsigned long' sizeof 'struct s':'struct s'
+`
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"return (*s)(noarch.AllocSlice(make([]s, uint64(n))))",
+		"var b *s = (*s)(noarch.AllocSlice(make([]s, 10)))",
+		"b = (*s)(noarch.AllocSlice(make([]s, 3)))",
+		// There is no element to take the address of, the pointer is nil.
+		"b = (*s)(noarch.AllocSlice(make([]s, 0)))",
+		// The size is not a sizeof, so the bytes are allocated.
+		"return (*byte)(noarch.Malloc(4 * int32(uint64(n))))",
+		"var c *byte = (*byte)(noarch.Malloc(10 * int32(uint64(n))))",
+	)
+}
+
+func TestRealloc(t *testing.T) {
+	// int *grow(int *a, int n) { return realloc(a, n * sizeof(int)); }
+	// void h(void) {
+	//     int *b = realloc(NULL, 8);
+	//     b = (int *)realloc(b, 16);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x5 </usr/include/stdlib.h:1:1, col:40> col:14 used realloc 'void *(void *, unsigned long)'
+| |-ParmVarDecl 0x6 <col:22> col:28 'void *'
+| |-ParmVarDecl 0x4 <col:31> col:39 'unsigned long'
+|-FunctionDecl 0x10 <x.c:1:1, col:65> col:6 grow 'int *(int *, int)'
+| |-ParmVarDecl 0x11 <col:11, col:16> col:16 used a 'int *'
+| |-ParmVarDecl 0x12 <col:19, col:23> col:23 used n 'int'
+| |-CompoundStmt 0x13 <col:26, col:65>
+|   |-ReturnStmt 0x14 <col:28, col:62>
+|     |-ImplicitCastExpr 0x15 <col:35, col:62> 'int *' <BitCast>
+|       |-CallExpr 0x16 <col:35, col:62> 'void *'
+|         |-ImplicitCastExpr 0x17 <col:35> 'void *(*)(void *, unsigned long)' <FunctionToPointerDecay>
+|         | |-DeclRefExpr 0x18 <col:35> 'void *(void *, unsigned long)' Function 0x5 'realloc' 'void *(void *, unsigned long)'
+|         |-ImplicitCastExpr 0x19 <col:43> 'void *' <BitCast>
+|         | |-ImplicitCastExpr 0x1a <col:43> 'int *' <LValueToRValue>
+|         |   |-DeclRefExpr 0x1b <col:43> 'int *' lvalue ParmVar 0x11 'a' 'int *'
+|         |-BinaryOperator 0x1c <col:46, col:61> 'unsigned long' '*'
+|           |-ImplicitCastExpr 0x1d <col:46> 'unsigned long' <IntegralCast>
+|           | |-ImplicitCastExpr 0x1e <col:46> 'int' <LValueToRValue>
+|           |   |-DeclRefExpr 0x1f <col:46> 'int' lvalue ParmVar 0x12 'n' 'int'
+|           |-UnaryExprOrTypeTraitExpr 0x20 <col:50, col:61> 'unsigned long' sizeof 'int'
+|-FunctionDecl 0x30 <line:2:1, line:5:1> line:2:6 h 'void (void)'
+  |-CompoundStmt 0x31 <col:15, line:5:1>
+    |-DeclStmt 0x32 <line:3:5, col:30>
+    | |-VarDecl 0x33 <col:5, col:29> col:10 used b 'int *' cinit
+    |   |-ImplicitCastExpr 0x34 <col:14, col:29> 'int *' <BitCast>
+    |     |-CallExpr 0x35 <col:14, col:29> 'void *'
+    |       |-ImplicitCastExpr 0x36 <col:14> 'void *(*)(void *, unsigned long)' <FunctionToPointerDecay>
+    |       | |-DeclRefExpr 0x37 <col:14> 'void *(void *, unsigned long)' Function 0x5 'realloc' 'void *(void *, unsigned long)'
+    |       |-ParenExpr 0x38 <col:22> 'void *'
+    |       | |-CStyleCastExpr 0x39 <col:22> 'void *' <NullToPointer>
+    |       |   |-IntegerLiteral 0x3a <col:22> 'int' 0
+    |       |-ImplicitCastExpr 0x3b <col:28> 'unsigned long' <IntegralCast>
+    |         |-IntegerLiteral 0x3c <col:28> 'int' 8
+    |-BinaryOperator 0x40 <line:4:5, col:29> 'int *' '='
+      |-DeclRefExpr 0x41 <col:5> 'int *' lvalue Var 0x33 'b' 'int *'
+      |-CStyleCastExpr 0x42 <col:9, col:29> 'int *' <BitCast>
+        |-CallExpr 0x43 <col:16, col:29> 'void *'
+          |-ImplicitCastExpr 0x44 <col:16> 'void *(*)(void *, unsigned long)' <FunctionToPointerDecay>
+          | |-DeclRefExpr 0x45 <col:16> 'void *(void *, unsigned long)' Function 0x5 'realloc' 'void *(void *, unsigned long)'
+          |-ImplicitCastExpr 0x46 <col:24> 'void *' <BitCast>
+          | |-ImplicitCastExpr 0x47 <col:24> 'int *' <LValueToRValue>
+          |   |-DeclRefExpr 0x48 <col:24> 'int *' lvalue Var 0x33 'b' 'int *'
+          |-ImplicitCastExpr 0x49 <col:27> 'unsigned long' <IntegralCast>
+            |-IntegerLiteral 0x4a <col:27> 'int' 16
+`
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+	output := transpileDump(t, p, dump)
+
+	// The memory is not allocated again like with malloc(), noarch.Realloc()
+	// keeps its contents.
+	expectContains(t, output,
+		"noarch.Realloc(unsafe.Pointer(a),",
+		"noarch.Realloc((nil),",
+		"noarch.Realloc(unsafe.Pointer(b),",
+	)
+	if strings.Contains(output, "noarch.Malloc") {
+		t.Errorf("Unexpected noarch.Malloc in:\n%s", output)
+	}
+}
+
+func TestAlloca(t *testing.T) {
+	// void f(char *out, int n) {
+	//     char *tmp = alloca(n);
+	//     *tmp = 'a';
+	//     *out = *tmp;
+	//     while (n--) {
+	//         char *b = alloca(4);
+	//         *out = *b;
+	//     }
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:9:1> line:1:6 f 'void (char *, int)'
+|-ParmVarDecl 0x11 <col:8, col:14> col:14 used out 'char *'
+|-ParmVarDecl 0x12 <col:19, col:23> col:23 used n 'int'
+|-CompoundStmt 0x13 <col:26, line:9:1>
+  |-DeclStmt 0x20 <line:2:5, col:27>
+  | |-VarDecl 0x21 <col:5, col:26> col:11 used tmp 'char *' cinit
+  |   |-ImplicitCastExpr 0x22 <line:2:17, col:26> 'char *' <BitCast>
+  |     |-CallExpr 0x23 <col:17, col:26> 'void *'
+  |       |-ImplicitCastExpr 0x24 <col:17> 'void *(*)(unsigned long)' <BuiltinFnToFnPtr>
+  |       | |-DeclRefExpr 0x25 <col:17> '<builtin fn type>' Function 0x2 '__builtin_alloca' 'void *(unsigned long)'
+  |       |-ImplicitCastExpr 0x26 <col:25> 'unsigned long' <IntegralCast>
+  |         |-ImplicitCastExpr 0x27 <col:25> 'int' <LValueToRValue>
+  |           |-DeclRefExpr 0x28 <col:25> 'int' lvalue ParmVar 0x12 'n' 'int'
+  |-BinaryOperator 0x30 <line:3:5, col:12> 'char' '='
+  | |-UnaryOperator 0x31 <col:5, col:6> 'char' lvalue prefix '*' cannot overflow
+  | | |-ImplicitCastExpr 0x32 <col:6> 'char *' <LValueToRValue>
+  | |   |-DeclRefExpr 0x33 <col:6> 'char *' lvalue Var 0x21 'tmp' 'char *'
+  | |-ImplicitCastExpr 0x34 <col:12> 'char' <IntegralCast>
+  |   |-CharacterLiteral 0x35 <col:12> 'int' 97
+  |-BinaryOperator 0x40 <line:4:5, col:13> 'char' '='
+  | |-UnaryOperator 0x41 <col:5, col:6> 'char' lvalue prefix '*' cannot overflow
+  | | |-ImplicitCastExpr 0x42 <col:6> 'char *' <LValueToRValue>
+  | |   |-DeclRefExpr 0x43 <col:6> 'char *' lvalue ParmVar 0x11 'out' 'char *'
+  | |-ImplicitCastExpr 0x44 <col:12, col:13> 'char' <LValueToRValue>
+  |   |-UnaryOperator 0x45 <col:12, col:13> 'char' lvalue prefix '*' cannot overflow
+  |     |-ImplicitCastExpr 0x46 <col:13> 'char *' <LValueToRValue>
+  |       |-DeclRefExpr 0x47 <col:13> 'char *' lvalue Var 0x21 'tmp' 'char *'
+  |-WhileStmt 0x50 <line:5:5, line:8:5>
+    |-UnaryOperator 0x51 <line:5:12, col:13> 'int' postfix '--'
+    | |-DeclRefExpr 0x52 <col:12> 'int' lvalue ParmVar 0x12 'n' 'int'
+    |-CompoundStmt 0x53 <col:17, line:8:5>
+      |-DeclStmt 0x60 <line:6:9, col:29>
+      | |-VarDecl 0x61 <col:9, col:28> col:15 used b 'char *' cinit
+      |   |-ImplicitCastExpr 0x62 <col:19, col:28> 'char *' <BitCast>
+      |     |-CallExpr 0x63 <col:19, col:28> 'void *'
+      |       |-ImplicitCastExpr 0x64 <col:19> 'void *(*)(unsigned long)' <BuiltinFnToFnPtr>
+      |       | |-DeclRefExpr 0x65 <col:19> '<builtin fn type>' Function 0x2 '__builtin_alloca' 'void *(unsigned long)'
+      |       |-ImplicitCastExpr 0x66 <col:27> 'unsigned long' <IntegralCast>
+      |         |-IntegerLiteral 0x67 <col:27> 'int' 4
+      |-BinaryOperator 0x70 <line:7:9, col:16> 'char' '='
+        |-UnaryOperator 0x71 <col:9, col:10> 'char' lvalue prefix '*' cannot overflow
+        | |-ImplicitCastExpr 0x72 <col:10> 'char *' <LValueToRValue>
+        |   |-DeclRefExpr 0x73 <col:10> 'char *' lvalue ParmVar 0x11 'out' 'char *'
+        |-ImplicitCastExpr 0x74 <col:15, col:16> 'char' <LValueToRValue>
+          |-UnaryOperator 0x75 <col:15, col:16> 'char' lvalue prefix '*' cannot overflow
+            |-ImplicitCastExpr 0x76 <col:16> 'char *' <LValueToRValue>
+              |-DeclRefExpr 0x77 <col:16> 'char *' lvalue Var 0x61 'b' 'char *'
+`
+	p := program.NewProgram()
+	f, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), f); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	expectContains(t, output,
+		"var c2goAlloca1 []byte = make([]byte, int32(uint64(n)))\n"+
+			"\tvar tmp *byte = (*byte)(noarch.SlicePointer(c2goAlloca1))",
+		// The slice is declared in the loop, so each iteration allocates
+		// a new one like in C.
+		"}() {\n"+
+			"\t\tvar c2goAlloca3 []byte = make([]byte, int32(uint64(int32(4))))\n"+
+			"\t\tvar b *byte = (*byte)(noarch.SlicePointer(c2goAlloca3))",
+	)
+}
+
+func TestBuiltinHints(t *testing.T) {
+	// int f(int n) {
+	//     long v = __builtin_expect(n, 0);
+	//     if (__builtin_expect(n > 0, 1))
+	//         return n;
+	//     if (n < -100)
+	//         __builtin_trap();
+	//     __builtin_unreachable();
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:8:1> line:1:5 f 'int (int)'
+  |-ParmVarDecl 0x11 <col:7, col:11> col:11 used n 'int'
+  |-CompoundStmt 0x12 <col:14, line:8:1>
+    |-DeclStmt 0x13 <line:2:5, col:37>
+    | |-VarDecl 0x14 <col:5, col:36> col:10 v 'long' cinit
+    |   |-CallExpr 0x15 <col:14, col:36> 'long'
+    |     |-ImplicitCastExpr 0x16 <col:14> 'long (*)(long, long)' <BuiltinFnToFnPtr>
+    |     | |-DeclRefExpr 0x17 <col:14> '<builtin fn type>' Function 0x18 '__builtin_expect' 'long (long, long)'
+    |     |-ImplicitCastExpr 0x19 <col:31> 'long' <IntegralCast>
+    |     | |-ImplicitCastExpr 0x1a <col:31> 'int' <LValueToRValue>
+    |     |   |-DeclRefExpr 0x1b <col:31> 'int' lvalue ParmVar 0x11 'n' 'int'
+    |     |-ImplicitCastExpr 0x1c <col:34> 'long' <IntegralCast>
+    |       |-IntegerLiteral 0x1d <col:34> 'int' 0
+    |-IfStmt 0x20 <line:3:5, line:4:16>
+    | |-CallExpr 0x21 <line:3:9, col:34> 'long'
+    | | |-ImplicitCastExpr 0x22 <col:9> 'long (*)(long, long)' <BuiltinFnToFnPtr>
+    | | | |-DeclRefExpr 0x23 <col:9> '<builtin fn type>' Function 0x18 '__builtin_expect' 'long (long, long)'
+    | | |-ImplicitCastExpr 0x24 <col:26, col:30> 'long' <IntegralCast>
+    | | | |-BinaryOperator 0x25 <col:26, col:30> 'int' '>'
+    | | |   |-ImplicitCastExpr 0x26 <col:26> 'int' <LValueToRValue>
+    | | |   | |-DeclRefExpr 0x27 <col:26> 'int' lvalue ParmVar 0x11 'n' 'int'
+    | | |   |-IntegerLiteral 0x28 <col:30> 'int' 0
+    | | |-ImplicitCastExpr 0x29 <col:33> 'long' <IntegralCast>
+    | |   |-IntegerLiteral 0x2a <col:33> 'int' 1
+    | |-ReturnStmt 0x2b <line:4:9, col:16>
+    |   |-ImplicitCastExpr 0x2c <col:16> 'int' <LValueToRValue>
+    |     |-DeclRefExpr 0x2d <col:16> 'int' lvalue ParmVar 0x11 'n' 'int'
+    |-IfStmt 0x30 <line:5:5, line:6:24>
+    | |-BinaryOperator 0x31 <line:5:9, col:14> 'int' '<'
+    | | |-ImplicitCastExpr 0x32 <col:9> 'int' <LValueToRValue>
+    | | | |-DeclRefExpr 0x33 <col:9> 'int' lvalue ParmVar 0x11 'n' 'int'
+    | | |-UnaryOperator 0x34 <col:13, col:14> 'int' prefix '-'
+    | |   |-IntegerLiteral 0x35 <col:14> 'int' 100
+    | |-CallExpr 0x36 <line:6:9, col:24> 'void'
+    |   |-ImplicitCastExpr 0x37 <col:9> 'void (*)(void)' <BuiltinFnToFnPtr>
+    |     |-DeclRefExpr 0x38 <col:9> '<builtin fn type>' Function 0x39 '__builtin_trap' 'void (void)'
+    |-CallExpr 0x40 <line:7:5, col:27> 'void'
+      |-ImplicitCastExpr 0x41 <col:5> 'void (*)(void)' <BuiltinFnToFnPtr>
+        |-DeclRefExpr 0x42 <col:5> '<builtin fn type>' Function 0x43 '__builtin_unreachable' 'void (void)'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	// The condition stays a comparison and the value is the one of n.
+	expectContains(t, output,
+		"var v int64 = int64(n)",
+		"if n > int32(0) {",
+		`panic("trap")`,
+		`panic("unreachable")`,
+	)
+	if strings.Contains(output, "BuiltinExpect") || strings.Contains(output, "__builtin") {
+		t.Errorf("Unexpected call of a builtin in:\n%s", output)
+	}
+}
+
+func TestCallArgumentsOrder(t *testing.T) {
+	// int f(int, int);
+	// void g(void) {
+	//     int a = 1;
+	//     f(a++, a++);
+	//     f(a, a = 5);
+	// }
+	// void h(int err, int a) {
+	//     if (err) goto done;
+	//     f(a++, a++);
+	// done:
+	//     return;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x2 <x.c:1:1, col:22> col:5 used f 'int (int, int)'
+| |-ParmVarDecl 0x3 <col:7> col:10 'int'
+| |-ParmVarDecl 0x4 <col:12> col:15 'int'
+|-FunctionDecl 0x10 <line:2:1, line:6:1> line:2:6 g 'void (void)'
+| |-CompoundStmt 0x11 <col:15, line:6:1>
+|   |-DeclStmt 0x12 <line:3:5, col:14>
+|   | |-VarDecl 0x13 <col:5, col:13> col:9 used a 'int' cinit
+|   |   |-IntegerLiteral 0x14 <col:13> 'int' 1
+|   |-CallExpr 0x20 <line:4:5, col:16> 'int'
+|   | |-ImplicitCastExpr 0x21 <col:5> 'int (*)(int, int)' <FunctionToPointerDecay>
+|   | | |-DeclRefExpr 0x22 <col:5> 'int (int, int)' Function 0x2 'f' 'int (int, int)'
+|   | |-UnaryOperator 0x23 <col:7, col:8> 'int' postfix '++'
+|   | | |-DeclRefExpr 0x24 <col:7> 'int' lvalue Var 0x13 'a' 'int'
+|   | |-UnaryOperator 0x25 <col:12, col:13> 'int' postfix '++'
+|   |   |-DeclRefExpr 0x26 <col:12> 'int' lvalue Var 0x13 'a' 'int'
+|   |-CallExpr 0x30 <line:5:5, col:16> 'int'
+|     |-ImplicitCastExpr 0x31 <col:5> 'int (*)(int, int)' <FunctionToPointerDecay>
+|     | |-DeclRefExpr 0x32 <col:5> 'int (int, int)' Function 0x2 'f' 'int (int, int)'
+|     |-ImplicitCastExpr 0x33 <col:7> 'int' <LValueToRValue>
+|     | |-DeclRefExpr 0x34 <col:7> 'int' lvalue Var 0x13 'a' 'int'
+|     |-BinaryOperator 0x35 <col:10, col:14> 'int' '='
+|       |-DeclRefExpr 0x36 <col:10> 'int' lvalue Var 0x13 'a' 'int'
+|       |-IntegerLiteral 0x37 <col:14> 'int' 5
+|-FunctionDecl 0x40 <line:7:1, line:12:1> line:7:6 h 'void (int, int)'
+  |-ParmVarDecl 0x41 <col:8, col:12> col:12 used err 'int'
+  |-ParmVarDecl 0x42 <col:17, col:21> col:21 used a 'int'
+  |-CompoundStmt 0x43 <col:24, line:12:1>
+    |-IfStmt 0x44 <line:8:5, col:23>
+    | |-NullStmt
+    | |-NullStmt
+    | |-ImplicitCastExpr 0x45 <col:9> 'int' <LValueToRValue>
+    | | |-DeclRefExpr 0x46 <col:9> 'int' lvalue ParmVar 0x41 'err' 'int'
+    | |-GotoStmt 0x47 <col:14, col:19> 'done' 0x50
+    | |-NullStmt
+    |-CallExpr 0x48 <line:9:5, col:16> 'int'
+    | |-ImplicitCastExpr 0x49 <col:5> 'int (*)(int, int)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x4a <col:5> 'int (int, int)' Function 0x2 'f' 'int (int, int)'
+    | |-UnaryOperator 0x4b <col:7, col:8> 'int' postfix '++'
+    | | |-DeclRefExpr 0x4c <col:7> 'int' lvalue ParmVar 0x42 'a' 'int'
+    | |-UnaryOperator 0x4d <col:12, col:13> 'int' postfix '++'
+    |   |-DeclRefExpr 0x4e <col:12> 'int' lvalue ParmVar 0x42 'a' 'int'
+    |-LabelStmt 0x50 <line:10:1, line:11:5> 'done'
+      |-ReturnStmt 0x51 <line:11:5>
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		// Both increments happen before the first call, and the first
+		// argument of the second call is read before the assignment.
+		`
+	var c2goArg0 int32 = a
+	a += 1
+	var c2goArg1 int32 = a
+	a += 1
+	f(c2goArg0, c2goArg1)
+	var c2goArg2 int32 = a
+	a = int32(5)
+	f(c2goArg2, a)
+`,
+		// The goto does not jump over the declarations of the arguments.
+		`
+	var c2goArg3 int32
+	var c2goArg4 int32
+	if err != 0 {
+		goto done
+	}
+	c2goArg3 = a
+	a += 1
+	c2goArg4 = a
+	a += 1
+	f(c2goArg3, c2goArg4)
+done:
+`,
+	)
+}
+
+func TestConfiguredBuiltinHints(t *testing.T) {
+	// void f(void) { __builtin_trap(); }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:34> col:6 f 'void (void)'
+  |-CompoundStmt 0x11 <col:15, col:34>
+    |-CallExpr 0x12 <col:17, col:32> 'void'
+      |-ImplicitCastExpr 0x13 <col:17> 'void (*)(void)' <BuiltinFnToFnPtr>
+        |-DeclRefExpr 0x14 <col:17> '<builtin fn type>' Function 0x15 '__builtin_trap' 'void (void)'
+`
+
+	p := program.NewProgram()
+	p.BuiltinHints = map[string]string{"__builtin_trap": "illegal instruction"}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output, `panic("illegal instruction")`)
+}
+
+func TestSignedCharComparison(t *testing.T) {
+	// int f(char c) {
+	//     return c < 0;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:3:1> line:1:5 f 'int (char)'
+|-ParmVarDecl 0x11 <col:7, col:12> col:12 used c 'char'
+|-CompoundStmt 0x12 <col:15, line:3:1>
+  |-ReturnStmt 0x13 <line:2:5, col:16>
+    |-BinaryOperator 0x14 <col:12, col:16> 'int' '<'
+      |-ImplicitCastExpr 0x15 <col:12> 'int' <IntegralCast>
+      | |-ImplicitCastExpr 0x16 <col:12> 'char' <LValueToRValue>
+      |   |-DeclRefExpr 0x17 <col:12> 'char' lvalue ParmVar 0x11 'c' 'char'
+      |-IntegerLiteral 0x18 <col:16> 'int' 0
+`
+
+	unsignedChar := *program.LP64
+	unsignedChar.CharSigned = false
+
+	tests := []struct {
+		abi  *program.ABI
+		want string
+	}{
+		// A char of 0xFF is -1 when char is signed.
+		{program.LP64, "int32(int8(c)) < int32(0)"},
+		{&unsignedChar, "int32(c) < int32(0)"},
+	}
+
+	for _, tt := range tests {
+		p := program.NewProgram()
+		p.ABI = tt.abi
+		decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("Expected %q in:\n%s", tt.want, buf.String())
+		}
+	}
+}
+
+func TestCheckedOverflow(t *testing.T) {
+	// int f(int a, int b) {
+	//     return a * b - 1;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:3:1> line:1:5 f 'int (int, int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used a 'int'
+|-ParmVarDecl 0x12 <col:14, col:18> col:18 used b 'int'
+|-CompoundStmt 0x13 <col:21, line:3:1>
+  |-ReturnStmt 0x14 <line:2:5, col:20>
+    |-BinaryOperator 0x15 <col:12, col:20> 'int' '-'
+      |-BinaryOperator 0x16 <col:12, col:16> 'int' '*'
+      | |-ImplicitCastExpr 0x17 <col:12> 'int' <LValueToRValue>
+      | | |-DeclRefExpr 0x18 <col:12> 'int' lvalue ParmVar 0x11 'a' 'int'
+      | |-ImplicitCastExpr 0x19 <col:16> 'int' <LValueToRValue>
+      |   |-DeclRefExpr 0x1a <col:16> 'int' lvalue ParmVar 0x12 'b' 'int'
+      |-IntegerLiteral 0x1b <col:20> 'int' 1
+`
+
+	tests := []struct {
+		checked bool
+		want    string
+	}{
+		{false, "return a*b - int32(1)"},
+		{true, "return noarch.CheckedSubInt32(noarch.CheckedMulInt32(a, b), int32(1))"},
+	}
+
+	for _, tt := range tests {
+		p := program.NewProgram()
+		p.CheckedOverflow = tt.checked
+		decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("Expected %q in:\n%s", tt.want, buf.String())
+		}
+	}
+}
+
+func TestUnsignedCharWraparound(t *testing.T) {
+	// unsigned char f(unsigned char a) {
+	//     unsigned char b = 300;
+	//     return a + b;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:4:1> line:1:15 f 'unsigned char (unsigned char)'
+|-ParmVarDecl 0x11 <col:17, col:31> col:31 used a 'unsigned char'
+|-CompoundStmt 0x12 <col:34, line:4:1>
+  |-DeclStmt 0x13 <line:2:5, col:27>
+  | |-VarDecl 0x14 <col:5, col:23> col:19 used b 'unsigned char' cinit
+  |   |-ImplicitCastExpr 0x15 <col:23> 'unsigned char' <IntegralCast>
+  |     |-IntegerLiteral 0x16 <col:23> 'int' 300
+  |-ReturnStmt 0x17 <line:3:5, col:16>
+    |-ImplicitCastExpr 0x18 <col:12, col:16> 'unsigned char' <IntegralCast>
+      |-BinaryOperator 0x19 <col:12, col:16> 'int' '+'
+        |-ImplicitCastExpr 0x1a <col:12> 'int' <IntegralCast>
+        | |-ImplicitCastExpr 0x1b <col:12> 'unsigned char' <LValueToRValue>
+        |   |-DeclRefExpr 0x1c <col:12> 'unsigned char' lvalue ParmVar 0x11 'a' 'unsigned char'
+        |-ImplicitCastExpr 0x1d <col:16> 'int' <IntegralCast>
+          |-ImplicitCastExpr 0x1e <col:16> 'unsigned char' <LValueToRValue>
+            |-DeclRefExpr 0x1f <col:16> 'unsigned char' lvalue Var 0x14 'b' 'unsigned char'
+`
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+
+	// The constant wraps at 256 and the sum is converted back to a uint8,
+	// which wraps at run time.
+	for _, want := range []string{
+		"var b uint8 = uint8(44)",
+		"return uint8(int32(a) + int32(b))",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDivisionSignedness(t *testing.T) {
+	// void f(int i, unsigned u) {
+	//   int a = -7 % 3;
+	//   unsigned b = -7 % 3u;
+	//   unsigned c = u % i;
+	//   unsigned d = i / u;
+	//   long e = i % 3L;
+	//   unsigned g = 4294967295u % u;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:8:1> line:1:6 f 'void (int, unsigned int)'
+  |-ParmVarDecl 0x11 <col:8, col:12> col:12 used i 'int'
+  |-ParmVarDecl 0x12 <col:15, col:24> col:24 used u 'unsigned int'
+  |-CompoundStmt 0x13 <col:27, line:8:1>
+    |-DeclStmt 0x20 <line:2:3, col:17>
+    | |-VarDecl 0x21 <col:3, col:16> col:7 a 'int' cinit
+    |   |-BinaryOperator 0x22 <col:11, col:16> 'int' '%'
+    |     |-UnaryOperator 0x23 <col:11, col:12> 'int' prefix '-'
+    |     | |-IntegerLiteral 0x24 <col:12> 'int' 7
+    |     |-IntegerLiteral 0x25 <col:16> 'int' 3
+    |-DeclStmt 0x30 <line:3:3, col:23>
+    | |-VarDecl 0x31 <col:3, col:21> col:12 b 'unsigned int' cinit
+    |   |-BinaryOperator 0x32 <col:16, col:21> 'unsigned int' '%'
+    |     |-ImplicitCastExpr 0x33 <col:16, col:17> 'unsigned int' <IntegralCast>
+    |     | |-UnaryOperator 0x34 <col:16, col:17> 'int' prefix '-'
+    |     |   |-IntegerLiteral 0x35 <col:17> 'int' 7
+    |     |-IntegerLiteral 0x36 <col:21> 'unsigned int' 3
+    |-DeclStmt 0x40 <line:4:3, col:21>
+    | |-VarDecl 0x41 <col:3, col:20> col:12 c 'unsigned int' cinit
+    |   |-BinaryOperator 0x42 <col:16, col:20> 'unsigned int' '%'
+    |     |-ImplicitCastExpr 0x43 <col:16> 'unsigned int' <LValueToRValue>
+    |     | |-DeclRefExpr 0x44 <col:16> 'unsigned int' lvalue ParmVar 0x12 'u' 'unsigned int'
+    |     |-ImplicitCastExpr 0x45 <col:20> 'unsigned int' <IntegralCast>
+    |       |-ImplicitCastExpr 0x46 <col:20> 'int' <LValueToRValue>
+    |         |-DeclRefExpr 0x47 <col:20> 'int' lvalue ParmVar 0x11 'i' 'int'
+    |-DeclStmt 0x50 <line:5:3, col:21>
+    | |-VarDecl 0x51 <col:3, col:20> col:12 d 'unsigned int' cinit
+    |   |-BinaryOperator 0x52 <col:16, col:20> 'unsigned int' '/'
+    |     |-ImplicitCastExpr 0x53 <col:16> 'unsigned int' <IntegralCast>
+    |     | |-ImplicitCastExpr 0x54 <col:16> 'int' <LValueToRValue>
+    |     |   |-DeclRefExpr 0x55 <col:16> 'int' lvalue ParmVar 0x11 'i' 'int'
+    |     |-ImplicitCastExpr 0x56 <col:20> 'unsigned int' <LValueToRValue>
+    |       |-DeclRefExpr 0x57 <col:20> 'unsigned int' lvalue ParmVar 0x12 'u' 'unsigned int'
+    |-DeclStmt 0x60 <line:6:3, col:19>
+    | |-VarDecl 0x61 <col:3, col:18> col:8 e 'long' cinit
+    |   |-BinaryOperator 0x62 <col:12, col:16> 'long' '%'
+    |     |-ImplicitCastExpr 0x63 <col:12> 'long' <IntegralCast>
+    |     | |-ImplicitCastExpr 0x64 <col:12> 'int' <LValueToRValue>
+    |     |   |-DeclRefExpr 0x65 <col:12> 'int' lvalue ParmVar 0x11 'i' 'int'
+    |     |-IntegerLiteral 0x66 <col:16> 'long' 3
+    |-DeclStmt 0x70 <line:7:3, col:32>
+      |-VarDecl 0x71 <col:3, col:31> col:12 g 'unsigned int' cinit
+        |-BinaryOperator 0x72 <col:16, col:31> 'unsigned int' '%'
+          |-IntegerLiteral 0x73 <col:16> 'unsigned int' 4294967295
+          |-ImplicitCastExpr 0x74 <col:31> 'unsigned int' <LValueToRValue>
+            |-DeclRefExpr 0x75 <col:31> 'unsigned int' lvalue ParmVar 0x12 'u' 'unsigned int'
+`
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		// A signed remainder has the sign of the dividend, like in C.
+		"var a int32 = -int32(7) % int32(3)",
+		// The negative operand is converted to unsigned.
+		"var b uint32 = uint32(4294967289) % uint32(3)",
+		"var c uint32 = u % uint32(i)",
+		"var d uint32 = uint32(i) / u",
+		"var e int64 = int64(i) % int64(3)",
+		// The unsigned literal must not make the operation signed.
+		"var g uint32 = uint32(4294967295) % u",
+	)
+}
+
+func TestAssignmentValue(t *testing.T) {
+	// int f(void);
+	// int g(int a) {
+	//     int n, b, c = 3;
+	//     if ((n = f()) > 0) n = 0;
+	//     if (a && (n = f())) n = 1;
+	//     return a = b = c;
+	// }
+	dump := `
+FunctionDecl 0x20 <x.c:2:1, line:7:1> line:2:5 g 'int (int)'
+|-ParmVarDecl 0x21 <col:7, col:11> col:11 used a 'int'
+|-CompoundStmt 0x22 <col:14, line:7:1>
+  |-DeclStmt 0x23 <line:3:5, col:20>
+  | |-VarDecl 0x24 <col:5, col:9> col:9 used n 'int'
+  | |-VarDecl 0x25 <col:5, col:12> col:12 used b 'int'
+  | |-VarDecl 0x26 <col:5, col:19> col:15 used c 'int' cinit
+  |   |-IntegerLiteral 0x27 <col:19> 'int' 3
+  |-IfStmt 0x30 <line:4:5, col:28>
+  | |-BinaryOperator 0x31 <col:9, col:22> 'int' '>'
+  | | |-ParenExpr 0x32 <col:9, col:18> 'int'
+  | | | |-BinaryOperator 0x33 <col:10, col:17> 'int' '='
+  | | |   |-DeclRefExpr 0x34 <col:10> 'int' lvalue Var 0x24 'n' 'int'
+  | | |   |-CallExpr 0x35 <col:14, col:17> 'int'
+  | | |     |-ImplicitCastExpr 0x36 <col:14> 'int (*)(void)' <FunctionToPointerDecay>
+  | | |       |-DeclRefExpr 0x37 <col:14> 'int (void)' Function 0x10 'f' 'int (void)'
+  | | |-IntegerLiteral 0x38 <col:22> 'int' 0
+  | |-BinaryOperator 0x39 <col:24, col:28> 'int' '='
+  |   |-DeclRefExpr 0x3a <col:24> 'int' lvalue Var 0x24 'n' 'int'
+  |   |-IntegerLiteral 0x3b <col:28> 'int' 0
+  |-IfStmt 0x40 <line:5:5, col:29>
+  | |-BinaryOperator 0x41 <col:9, col:23> 'int' '&&'
+  | | |-ImplicitCastExpr 0x42 <col:9> 'int' <LValueToRValue>
+  | | | |-DeclRefExpr 0x43 <col:9> 'int' lvalue ParmVar 0x21 'a' 'int'
+  | | |-ParenExpr 0x44 <col:14, col:23> 'int'
+  | |   |-BinaryOperator 0x45 <col:15, col:22> 'int' '='
+  | |     |-DeclRefExpr 0x46 <col:15> 'int' lvalue Var 0x24 'n' 'int'
+  | |     |-CallExpr 0x47 <col:19, col:22> 'int'
+  | |       |-ImplicitCastExpr 0x48 <col:19> 'int (*)(void)' <FunctionToPointerDecay>
+  | |         |-DeclRefExpr 0x49 <col:19> 'int (void)' Function 0x10 'f' 'int (void)'
+  | |-BinaryOperator 0x4a <col:25, col:29> 'int' '='
+  |   |-DeclRefExpr 0x4b <col:25> 'int' lvalue Var 0x24 'n' 'int'
+  |   |-IntegerLiteral 0x4c <col:29> 'int' 1
+  |-ReturnStmt 0x50 <line:6:5, col:20>
+    |-BinaryOperator 0x51 <col:12, col:20> 'int' '='
+      |-DeclRefExpr 0x52 <col:12> 'int' lvalue ParmVar 0x21 'a' 'int'
+      |-BinaryOperator 0x53 <col:16, col:20> 'int' '='
+        |-DeclRefExpr 0x54 <col:16> 'int' lvalue Var 0x25 'b' 'int'
+        |-ImplicitCastExpr 0x55 <col:20> 'int' <LValueToRValue>
+          |-DeclRefExpr 0x56 <col:20> 'int' lvalue Var 0x26 'c' 'int'
+`
+	p := program.NewProgram()
+	decls, err := transpileToNode(parseTree(dump), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	expectContains(t, output,
+		"n = f()\n\tif n > int32(0) {",
+		"if a != 0 && func() bool {\n\t\tn = f()\n\t\treturn n != 0\n\t}() {",
+		"b = c\n\ta = b\n\treturn a\n",
+	)
+	if strings.Contains(output, "tempVar") {
+		t.Errorf("Unexpected closure in:\n%s", output)
+	}
+}
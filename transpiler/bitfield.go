@@ -0,0 +1,328 @@
+// This file contains functions for transpiling the bit-fields of structs. The
+// bit-fields that follow each other are packed into an unsigned integer field
+// of the Go struct. Each bit-field has a getter and a setter method that
+// mask and shift its bits.
+
+package transpiler
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"text/template"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// bitfieldTemplate is the source of the methods of a bit-field. The getter of
+// a signed bit-field shifts the bits to the top of a signed integer first, so
+// that the sign is extended when they are shifted back. The setter returns the
+// new value of the bit-field, like an assignment in C.
+var bitfieldTemplate = template.Must(template.New("").Parse(`package main
+
+func (structVar {{ .Struct }}) {{ .Name }}() {{ .Type }} {
+{{- if .Signed }}
+	return {{ .Type }}(int{{ .Bits }}(structVar.{{ .Storage }}<<{{ .Left }}) >> {{ .Right }})
+{{- else }}
+	return {{ .Type }}(structVar.{{ .Storage }} >> {{ .Offset }} & {{ .Mask }})
+{{- end }}
+}
+
+func (structVar *{{ .Struct }}) {{ .Setter }}(value {{ .Type }}) {{ .Type }} {
+	structVar.{{ .Storage }} = structVar.{{ .Storage }}&^({{ .Mask }} << {{ .Offset }}) |
+		uint{{ .Bits }}(value)&{{ .Mask }}<<{{ .Offset }}
+	return structVar.{{ .Name }}()
+}
+`))
+
+// bitfields packs the bit-fields of a struct into storage fields. For example:
+//
+//     struct s {                    type s struct {
+//         unsigned a : 2;               c2goBitfield0 uint32
+//         unsigned b : 6;     =>        c int32
+//         int c;                    }
+//     };
+//
+//     s.b = s.a;              =>    s.setB(s.a())
+//
+// The getter has the name of the bit-field, the name of the setter is
+// returned by bitfieldSetter().
+type bitfields struct {
+	p          *program.Program
+	structName string
+
+	// count is the number of storage fields of the struct.
+	count int
+
+	// storage is the name of the storage field that the next bit-field is
+	// added to. It is empty when the next one needs a new storage field.
+	storage     string
+	storageBits int
+	usedBits    int
+}
+
+// add adds the next bit-field of the struct. It returns the storage field when
+// a new one is started for the bit-field, and the methods of the bit-field.
+func (b *bitfields) add(n *ast.FieldDecl, width int) (
+	field *goast.Field, methods []goast.Decl, err error) {
+	size, err := types.SizeOf(b.p, n.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	bits := size * 8
+	if width > bits {
+		return nil, nil, fmt.Errorf("bit-field %s is wider than its type %s", n.Name, n.Type)
+	}
+
+	// An unnamed bit-field with a width of 0 only ends the storage field.
+	if width == 0 {
+		b.end()
+		return
+	}
+
+	// A bit-field starts a new storage field when it does not fit in the
+	// current one, or when its type has another size.
+	if b.storage == "" || bits != b.storageBits || b.usedBits+width > bits {
+		b.storage = fmt.Sprintf("c2goBitfield%d", b.count)
+		b.count++
+		b.storageBits = bits
+		b.usedBits = 0
+		field = &goast.Field{
+			Names: []*goast.Ident{util.NewIdent(b.storage)},
+			Type:  util.NewTypeIdent(fmt.Sprintf("uint%d", bits)),
+		}
+	}
+	offset := b.usedBits
+	b.usedBits += width
+
+	// An unnamed bit-field is only padding.
+	if n.Name == "" {
+		return
+	}
+
+	methods, err = b.methods(n, offset, width)
+	return
+}
+
+// end ends the current storage field, so that the next bit-field is added to
+// a new one. It is called for each field that is not a bit-field.
+func (b *bitfields) end() {
+	b.storage = ""
+}
+
+func (b *bitfields) methods(n *ast.FieldDecl, offset, width int) (
+	_ []goast.Decl, err error) {
+	goType, err := types.ResolveType(b.p, n.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		Struct, Name, Setter, Type, Storage string
+		Signed                              bool
+		Bits, Offset, Left, Right           int
+		Mask                                string
+	}{
+		Struct:  b.structName,
+		Name:    b.p.GoIdentifier(n.Name),
+		Setter:  bitfieldSetter(n.Name),
+		Type:    goType,
+		Storage: b.storage,
+		Signed:  strings.HasPrefix(goType, "int"),
+		Bits:    b.storageBits,
+		Offset:  offset,
+		Left:    b.storageBits - offset - width,
+		Right:   b.storageBits - width,
+		Mask:    fmt.Sprintf("0x%x", uint64(1)<<uint(width)-1),
+	}
+
+	var source bytes.Buffer
+	if err = bitfieldTemplate.Execute(&source, data); err != nil {
+		return nil, fmt.Errorf("cannot execute template of bit-field %s : %v", n.Name, err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", source.String(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse source \"%s\" : %v", source.String(), err)
+	}
+
+	return f.Decls, nil
+}
+
+// bitfieldSetter returns the name of the setter method of a bit-field.
+func bitfieldSetter(name string) string {
+	return "set" + strings.ToUpper(name[:1]) + name[1:]
+}
+
+// transpileBitfieldAssign transpiles an assignment of a bit-field into a call
+// of its setter. A compound assignment, like "s.a += 2", sets the bit-field to
+// the result of the operation. ok is false if the left side of the assignment
+// is not a bit-field.
+//
+// The increment and decrement operators are transpiled into compound
+// assignments, so the value of "s.a++" is the new value of the bit-field.
+func transpileBitfieldAssign(n *ast.BinaryOperator, p *program.Program) (
+	_ goast.Expr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt,
+	ok bool, err error) {
+	m := getBitfieldMemberExpr(n.Children()[0])
+	if m == nil {
+		return
+	}
+
+	// The getter of the bit-field is the transpiled left side.
+	left, _, preStmts, postStmts, err := transpileToExpr(m, p, false)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	getter, ok := getBitfieldGetter(left)
+	if !ok {
+		return nil, "", nil, nil, false, nil
+	}
+
+	value := n.Children()[1]
+	if n.Operator != "=" {
+		value = &ast.BinaryOperator{
+			Type:       m.Type,
+			Operator:   strings.TrimSuffix(n.Operator, "="),
+			ChildNodes: []ast.Node{m, value},
+		}
+	}
+
+	right, rightType, newPre, newPost, err := transpileToExpr(value, p, false)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+
+	right, err = types.CastExpr(p, right, rightType, m.Type)
+	p.AddMessage(p.GenerateWarningMessage(err, n))
+
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   getter.Fun.(*goast.SelectorExpr).X,
+			Sel: util.NewIdent(bitfieldSetter(m.Name)),
+		},
+		Args: []goast.Expr{right},
+	}, m.Type, preStmts, postStmts, true, nil
+}
+
+// isAssignOperator returns true for "=" and the compound assignments, like
+// "+=".
+func isAssignOperator(operator token.Token) bool {
+	switch operator {
+	case token.ASSIGN, token.ADD_ASSIGN, token.SUB_ASSIGN, token.MUL_ASSIGN,
+		token.QUO_ASSIGN, token.REM_ASSIGN, token.AND_ASSIGN, token.OR_ASSIGN,
+		token.XOR_ASSIGN, token.SHL_ASSIGN, token.SHR_ASSIGN:
+		return true
+	}
+	return false
+}
+
+// getBitfieldMemberExpr returns the member expression of a bit-field, or nil if
+// the node is not a bit-field.
+func getBitfieldMemberExpr(n ast.Node) *ast.MemberExpr {
+	for {
+		switch v := n.(type) {
+		case *ast.ParenExpr:
+			n = v.Children()[0]
+		case *ast.MemberExpr:
+			if v.IsBitfield {
+				return v
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// getBitfieldGetter returns the call of the getter of a bit-field, if the
+// expression is one. See transpileMemberExpr().
+func getBitfieldGetter(e goast.Expr) (*goast.CallExpr, bool) {
+	call, ok := e.(*goast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return nil, false
+	}
+	_, ok = call.Fun.(*goast.SelectorExpr)
+	return call, ok
+}
+
+// transpileBitfieldInitList transpiles the initialization of a struct that has
+// bit-fields. The bit-fields cannot be set in a composite literal, so the
+// struct is set up in a closure:
+//
+//     struct s v = {1, 2};    =>    var v s = func() s {
+//                                       var c2goStruct s
+//                                       c2goStruct.setA(1)
+//                                       c2goStruct.c = 2
+//                                       return c2goStruct
+//                                   }()
+func transpileBitfieldInitList(e *ast.InitListExpr, s *program.Struct,
+	goType string, p *program.Program) (
+	_ goast.Expr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+	// The unnamed bit-fields are skipped by the initialization.
+	var names []string
+	for _, name := range s.FieldNames {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	v := util.NewIdent("c2goStruct")
+	stmts := []goast.Stmt{newVarDeclStmt(v.Name, goType)}
+	i := 0
+	for _, node := range e.Children() {
+		if _, ok := node.(*ast.ArrayFiller); ok {
+			continue
+		}
+		if i >= len(names) {
+			break
+		}
+		name := names[i]
+		i++
+
+		// A field that is left out by a designated initializer is zero.
+		if _, ok := node.(*ast.ImplicitValueInitExpr); ok {
+			continue
+		}
+
+		expr, exprType, newPre, newPost, err := transpileToExpr(node, p, true)
+		if err != nil {
+			return nil, "", nil, nil, err
+		}
+		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+		if t, ok := s.Fields[name].(string); ok {
+			if e, err := types.CastExpr(p, expr, exprType, t); err == nil {
+				expr = e
+			}
+		}
+
+		if s.Bitfields[name] > 0 {
+			stmts = append(stmts, util.NewExprStmt(&goast.CallExpr{
+				Fun: &goast.SelectorExpr{
+					X:   v,
+					Sel: util.NewIdent(bitfieldSetter(name)),
+				},
+				Args: []goast.Expr{expr},
+			}))
+			continue
+		}
+		stmts = append(stmts, &goast.AssignStmt{
+			Lhs: []goast.Expr{&goast.SelectorExpr{
+				X:   v,
+				Sel: util.NewIdent(getFieldName(p, s, name)),
+			}},
+			Tok: token.ASSIGN,
+			Rhs: []goast.Expr{expr},
+		})
+	}
+	stmts = append(stmts, &goast.ReturnStmt{Results: []goast.Expr{v}})
+
+	return util.NewFuncClosure(goType, stmts...), e.Type1, preStmts, postStmts, nil
+}
@@ -0,0 +1,188 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestBitfields(t *testing.T) {
+	// struct s {
+	//     unsigned a : 2;
+	//     unsigned b : 6;
+	//     int c;
+	//     int d : 3;
+	//     unsigned e : 7;
+	// };
+	//
+	// unsigned f(struct s *v) {
+	//     v->a = 3;
+	//     v->b += v->a;
+	//     return v->e;
+	// }
+	tu := parseTree(`
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x10 <x.c:1:1, line:7:1> line:1:8 struct s definition
+| |-FieldDecl 0x11 <line:2:5, col:18> col:14 referenced a 'unsigned int'
+| | |-IntegerLiteral 0x12 <col:18> 'int' 2
+| |-FieldDecl 0x13 <line:3:5, col:18> col:14 referenced b 'unsigned int'
+| | |-IntegerLiteral 0x14 <col:18> 'int' 6
+| |-FieldDecl 0x15 <line:4:5, col:9> col:9 c 'int'
+| |-FieldDecl 0x16 <line:5:5, col:13> col:9 d 'int'
+| | |-ConstantExpr 0x17 <col:13> 'int'
+| |   |-IntegerLiteral 0x18 <col:13> 'int' 3
+| |-FieldDecl 0x19 <line:6:5, col:18> col:14 referenced e 'unsigned int'
+|   |-IntegerLiteral 0x1a <col:18> 'int' 7
+|-FunctionDecl 0x20 <line:9:1, line:13:1> line:9:10 f 'unsigned int (struct s *)'
+  |-ParmVarDecl 0x21 <col:12, col:22> col:22 used v 'struct s *'
+  |-CompoundStmt 0x22 <col:25, line:13:1>
+    |-BinaryOperator 0x23 <line:10:5, col:12> 'unsigned int' '='
+    | |-MemberExpr 0x24 <col:5, col:8> 'unsigned int' lvalue bitfield ->a 0x11
+    | | |-ImplicitCastExpr 0x25 <col:5> 'struct s *' <LValueToRValue>
+    | |   |-DeclRefExpr 0x26 <col:5> 'struct s *' lvalue ParmVar 0x21 'v' 'struct s *'
+    | |-ImplicitCastExpr 0x27 <col:12> 'unsigned int' <IntegralCast>
+    |   |-IntegerLiteral 0x28 <col:12> 'int' 3
+    |-CompoundAssignOperator 0x30 <line:11:5, col:16> 'unsigned int' '+=' ComputeLHSTy='unsigned int' ComputeResultTy='unsigned int'
+    | |-MemberExpr 0x31 <col:5, col:8> 'unsigned int' lvalue bitfield ->b 0x13
+    | | |-ImplicitCastExpr 0x32 <col:5> 'struct s *' <LValueToRValue>
+    | |   |-DeclRefExpr 0x33 <col:5> 'struct s *' lvalue ParmVar 0x21 'v' 'struct s *'
+    | |-ImplicitCastExpr 0x34 <col:13, col:16> 'unsigned int' <LValueToRValue>
+    |   |-MemberExpr 0x35 <col:13, col:16> 'unsigned int' lvalue bitfield ->a 0x11
+    |     |-ImplicitCastExpr 0x36 <col:13> 'struct s *' <LValueToRValue>
+    |       |-DeclRefExpr 0x37 <col:13> 'struct s *' lvalue ParmVar 0x21 'v' 'struct s *'
+    |-ReturnStmt 0x40 <line:12:5, col:15>
+      |-ImplicitCastExpr 0x41 <col:12, col:15> 'unsigned int' <LValueToRValue>
+        |-MemberExpr 0x42 <col:12, col:15> 'unsigned int' lvalue bitfield ->e 0x19
+          |-ImplicitCastExpr 0x43 <col:12> 'struct s *' <LValueToRValue>
+            |-DeclRefExpr 0x44 <col:12> 'struct s *' lvalue ParmVar 0x21 'v' 'struct s *'
+`)
+
+	p := program.NewProgram()
+	decls, err := transpileTranslationUnitDecl(p, tu.(*ast.TranslationUnitDecl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	for _, d := range decls {
+		if err := format.Node(&buf, token.NewFileSet(), d); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteString("\n")
+	}
+	for _, e := range []string{
+		// a and b share the first storage field.
+		"c2goBitfield0 uint32",
+		"return uint32(structVar.c2goBitfield0 >> 0 & 0x3)",
+		"structVar.c2goBitfield0 = structVar.c2goBitfield0&^(0x3<<0) | uint32(value)&0x3<<0",
+		"return uint32(structVar.c2goBitfield0 >> 2 & 0x3f)",
+		"structVar.c2goBitfield0 = structVar.c2goBitfield0&^(0x3f<<2) | uint32(value)&0x3f<<2",
+
+		// c is not a bit-field, so d and e start a new storage field. e
+		// straddles the first and the second byte of it.
+		"c             int32",
+		"c2goBitfield1 uint32",
+		"return int32(int32(structVar.c2goBitfield1<<29) >> 29)",
+		"return uint32(structVar.c2goBitfield1 >> 3 & 0x7f)",
+		"func (structVar *s) setE(value uint32) uint32 {",
+
+		"(*v).setA(uint32(int32(3)))",
+		"(*v).setB((*v).b() + (*v).a())",
+		"return (*v).e()",
+	} {
+		if !strings.Contains(buf.String(), e) {
+			t.Errorf("Expected %q in:\n%s", e, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "c2goBitfield2") {
+		t.Errorf("Unexpected third storage field in:\n%s", buf.String())
+	}
+}
+
+func TestBitfieldsPacking(t *testing.T) {
+	p := program.NewProgram()
+	b := bitfields{p: p, structName: "s"}
+
+	for _, tc := range []struct {
+		name       string
+		cType      string
+		width      int
+		newStorage bool
+	}{
+		{"a", "unsigned int", 30, true},
+		{"b", "unsigned int", 2, false},
+		{"c", "unsigned int", 1, true},
+		{"", "unsigned int", 0, false},
+		{"d", "unsigned int", 1, true},
+		{"e", "unsigned char", 1, true},
+		{"", "unsigned char", 3, false},
+		{"f", "unsigned char", 4, false},
+	} {
+		field, _, err := b.add(&ast.FieldDecl{Name: tc.name, Type: tc.cType}, tc.width)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (field != nil) != tc.newStorage {
+			t.Errorf("%s : %d: expected new storage field %v", tc.name, tc.width, tc.newStorage)
+		}
+	}
+	if b.storage != "c2goBitfield3" || b.storageBits != 8 || b.usedBits != 8 {
+		t.Errorf("Unexpected storage %s of %d bits, %d bits are used",
+			b.storage, b.storageBits, b.usedBits)
+	}
+
+	if _, _, err := b.add(&ast.FieldDecl{Name: "g", Type: "unsigned char"}, 9); err == nil {
+		t.Errorf("Expected an error for a bit-field that is wider than its type")
+	}
+}
+
+func TestBitfieldInitListSideEffects(t *testing.T) {
+	// struct s { unsigned a : 2; int b; };
+	// int next(int);
+	// void f(int n) {
+	//     struct s v = { next(n++), n };
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x10 <x.c:1:1, col:35> col:8 struct s definition
+| |-FieldDecl 0x11 <col:12, col:25> col:21 a 'unsigned int'
+| | |-IntegerLiteral 0x12 <col:25> 'int' 2
+| |-FieldDecl 0x13 <col:28, col:32> col:32 b 'int'
+|-FunctionDecl 0x20 <line:2:1, col:14> col:5 used next 'int (int)'
+| |-ParmVarDecl 0x21 <col:10> col:13 'int'
+|-FunctionDecl 0x30 <line:3:1, line:5:1> line:3:6 f 'void (int)'
+  |-ParmVarDecl 0x31 <col:8, col:12> col:12 used n 'int'
+  |-CompoundStmt 0x32 <col:15, line:5:1>
+    |-DeclStmt 0x33 <line:4:5, col:38>
+      |-VarDecl 0x34 <col:5, col:37> col:14 v 'struct s':'struct s' cinit
+        |-InitListExpr 0x35 <col:18, col:37> 'struct s':'struct s'
+          |-ImplicitCastExpr 0x36 <col:20, col:28> 'unsigned int' <IntegralCast>
+          | |-CallExpr 0x37 <col:20, col:28> 'int'
+          |   |-ImplicitCastExpr 0x38 <col:20> 'int (*)(int)' <FunctionToPointerDecay>
+          |   | |-DeclRefExpr 0x39 <col:20> 'int (int)' Function 0x20 'next' 'int (int)'
+          |   |-UnaryOperator 0x3a <col:25, col:26> 'int' postfix '++'
+          |     |-DeclRefExpr 0x3b <col:25> 'int' lvalue ParmVar 0x31 'n' 'int'
+          |-ImplicitCastExpr 0x3c <col:31> 'int' <LValueToRValue>
+            |-DeclRefExpr 0x3d <col:31> 'int' lvalue ParmVar 0x31 'n' 'int'
+`
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	// The increment of the argument is kept before the initialization.
+	want := `
+	var c2goArg0 int32 = n
+	n += 1
+	var v s = func() s {
+		var c2goStruct s
+		c2goStruct.setA(uint32(next(c2goArg0)))
+		c2goStruct.b = n
+`
+	if !strings.Contains(output, want[1:]) {
+		t.Errorf("Expected:\n%s\nin:\n%s", want, output)
+	}
+}
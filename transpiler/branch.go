@@ -68,28 +68,14 @@ func transpileIfStmt(n *ast.IfStmt, p *program.Program) (
 		children = children[1:]
 	}
 
-	// The last parameter must be false because we are transpiling an
-	// expression - assignment operators need to be wrapped in closures.
-	conditional, conditionalType, newPre, newPost, err := transpileToExpr(children[0], p, false)
+	// The condition in Go must always be a bool.
+	boolCondition, newPre, newPost, err := transpileToBoolExpr(children[0], p)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	// null in C is false
-	if conditionalType == types.NullPointer {
-		conditional = util.NewIdent("false")
-		conditionalType = "bool"
-	}
 
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
-	// The condition in Go must always be a bool.
-	boolCondition, err := types.CastExpr(p, conditional, conditionalType, "bool")
-	p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, boolCondition == nil))
-
-	if boolCondition == nil {
-		boolCondition = util.NewNil()
-	}
-
 	body, newPre, newPost, err := transpileToBlockStmt(children[1], p)
 	if err != nil {
 		return nil, nil, nil, err
@@ -209,43 +195,39 @@ func transpileForStmt(n *ast.ForStmt, p *program.Program) (
 	// If we have 2 and more increments
 	// in operator for
 	// for( a = 0; a < 5; a ++, b++, c+=2)
-	switch c := children[3].(type) {
-	case *ast.BinaryOperator:
-		if c.Operator == "," {
-			// recursive action to code like that:
-			// a = 0;
-			// b = 0;
-			// for(a = 0 ; a < 5 ; ){
-			// 		body
-			// 		a++;
-			// 		b++;
-			//		c+=2;
-			// }
-			//
-			var compound *ast.CompoundStmt
-			if children[4] != nil {
-				// if body is exist
-				if _, ok := children[4].(*ast.CompoundStmt); !ok {
-					compound = new(ast.CompoundStmt)
-					compound.AddChild(children[4])
-				} else {
-					compound = children[4].(*ast.CompoundStmt)
-				}
-			} else {
-				// if body is not exist
-				compound = new(ast.CompoundStmt)
-			}
-			compound.ChildNodes = append(compound.Children(), c.Children()[0:len(c.Children())]...)
-			children[4] = compound
-			children[3] = nil
+	// the increments are called in a function literal, because the post
+	// statement of a Go loop is only one statement:
+	// for a = 0; a < 5; func() {
+	// 		a++
+	// 		b++
+	// 		c += 2
+	// }() {
+	// 		body
+	// }
+	//
+	// They cannot be moved to the end of the body, then a continue would
+	// skip them.
+	var post goast.Stmt
+	var transpilate bool
+	if c := getCommaOperator(children[3]); c != nil {
+		var stmts []goast.Stmt
+		stmts, err = transpileToStmts(c, p)
+		if err != nil {
+			return nil, nil, nil, err
 		}
+		post = util.NewExprStmt(&goast.CallExpr{
+			Fun: &goast.FuncLit{
+				Type: &goast.FuncType{},
+				Body: &goast.BlockStmt{List: stmts},
+			},
+		})
+		transpilate = true
 	}
 
-	var post goast.Stmt
-	var transpilate bool
 	if v, ok := children[3].(*ast.UnaryOperator); ok {
 		if vv, ok := v.Children()[0].(*ast.DeclRefExpr); ok {
-			if !types.IsPointer(p, vv.Type) && !types.IsFunction(vv.Type) {
+			if !types.IsPointer(p, vv.Type) && !types.IsFunction(vv.Type) &&
+				atomicTypeOf(p, vv) == "" {
 				switch v.Operator {
 				case "++":
 					// for case:
@@ -267,13 +249,32 @@ func transpileForStmt(n *ast.ForStmt, p *program.Program) (
 			}
 		}
 	}
+
+	// When the increment needs statements of its own, or when it is not a
+	// simple statement, it is moved to the end of the body, and each continue
+	// of the loop becomes a goto to it. A continue of an inner loop is not
+	// changed:
+	//
+	//     for i = 0; i < n; {
+	//         if i%2 != 0 {
+	//             goto FOR_POST_LABEL_0
+	//         }
+	//         k += j
+	//     FOR_POST_LABEL_0:
+	//         i += 1
+	//         j = i * 2
+	//     }
+	var bodyPost []goast.Stmt
 	if !transpilate {
 		post, newPre, newPost, err = transpileToStmt(children[3], p)
 		if err != nil {
 			return nil, nil, nil, err
 		}
 
-		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+		if len(newPre) > 0 || len(newPost) > 0 || !isSimpleStmt(post) {
+			bodyPost = combineStmts(post, newPre, newPost)
+			post = nil
+		}
 	}
 
 	// If we have 2 and more conditions
@@ -329,28 +330,26 @@ func transpileForStmt(n *ast.ForStmt, p *program.Program) (
 	// rendered in Go as "for {".
 	var condition goast.Expr
 	if children[2] != nil {
-		var conditionType string
 		var newPre, newPost []goast.Stmt
-
-		// The last parameter must be false because we are transpiling an
-		// expression - assignment operators need to be wrapped in closures.
-		condition, conditionType, newPre, newPost, err = atomicOperation(children[2], p)
+		condition, newPre, newPost, err = transpileToBoolExpr(children[2], p)
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		// null in C is false
-		if conditionType == types.NullPointer {
-			condition = util.NewIdent("false")
-			conditionType = "bool"
-		}
 
-		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
-
-		condition, err = types.CastExpr(p, condition, conditionType, "bool")
-		p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, condition == nil))
+		// The preStmts of the condition are evaluated before each iteration,
+		// like the statements of a statement expression:
+		//
+		//     while (({ c = next(); c != 0; })) { ... }
+		//
+		// so they are in a closure with the condition.
+		inClosure := len(newPre) > 0 && len(newPost) == 0
+		if !inClosure {
+			preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+		}
 
-		if condition == nil {
-			condition = util.NewNil()
+		if inClosure {
+			condition = util.NewFuncClosure("bool",
+				append(newPre, &goast.ReturnStmt{Results: []goast.Expr{condition}})...)
 		}
 	}
 
@@ -364,26 +363,31 @@ func transpileForStmt(n *ast.ForStmt, p *program.Program) (
 
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
+	forStmt := &goast.ForStmt{
+		Init: init,
+		Cond: condition,
+		Post: post,
+		Body: body,
+	}
+	if len(bodyPost) > 0 && hasContinueStmt(forStmt) {
+		label := p.GetNextIdentifier("FOR_POST_LABEL_")
+		adaptContinueStmt(forStmt, label)
+		bodyPost[0] = &goast.LabeledStmt{
+			Label: util.NewIdent(label),
+			Stmt:  bodyPost[0],
+		}
+	}
+	body.List = append(body.List, bodyPost...)
+
 	// avoid extra block around FOR
 	if len(preStmts) == 0 && len(postStmts) == 0 {
-		return &goast.ForStmt{
-			Init: init,
-			Cond: condition,
-			Post: post,
-			Body: body,
-		}, preStmts, postStmts, nil
+		return forStmt, preStmts, postStmts, nil
 	}
 
 	// for avoid dublication of init values for
 	// case with 2 for`s
 	var block goast.BlockStmt
-	var forStmt = goast.ForStmt{
-		Init: init,
-		Cond: condition,
-		Post: post,
-		Body: body,
-	}
-	block.List = combineStmts(&forStmt, preStmts, postStmts)
+	block.List = combineStmts(forStmt, preStmts, postStmts)
 	block.Lbrace = 1
 
 	return &block, nil, nil, nil
@@ -531,30 +535,78 @@ func transpileWhileStmt(n *ast.WhileStmt, p *program.Program) (
 //    |     |-CompoundStmt 0x3bb1d88 <col:13, line:17:3>
 //    |     | `-BreakStmt 0x3bb1d80 <line:16:4>
 //    |     `-<<<NULL>>>
+//
+// The condition is transpiled after the body, so any statements that are
+// needed to evaluate it stay at the end of each iteration. A continue in the
+// body must still evaluate the condition, so it becomes a goto to a label in
+// front of those statements:
+//
+//     for {
+//         if i < 3 {
+//             goto DO_WHILE_COND_LABEL_0
+//         }
+//         printf("i = %d\n", i)
+//     DO_WHILE_COND_LABEL_0:
+//         i++
+//         if !(i < 5) {
+//             break
+//         }
+//     }
 func transpileDoStmt(n *ast.DoStmt, p *program.Program) (
 	f goast.Stmt, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
-	var forOperator ast.ForStmt
-	forOperator.AddChild(nil)
-	forOperator.AddChild(nil)
-	forOperator.AddChild(nil)
-	forOperator.AddChild(nil)
-	var c *ast.CompoundStmt
-	if _, ok := n.Children()[0].(*ast.CompoundStmt); ok {
-		c = n.Children()[0].(*ast.CompoundStmt)
-	} else {
-		var newComp ast.CompoundStmt
-		newComp.AddChild(n.Children()[0])
-		c = &newComp
-	}
-	ifBreak := createIfWithNotConditionAndBreak(n.Children()[1])
-	c.AddChild(&ifBreak)
-	forOperator.AddChild(c)
-	f, preStmts, postStmts, err = transpileForStmt(&forOperator, p)
-
-	if hasContinueStmt(f) {
-		adaptContinueStmt(f, p)
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot transpile DoStmt: err = %v", err)
+		}
+	}()
+
+	body := &goast.BlockStmt{}
+	if n.Children()[0] != nil {
+		var newPre, newPost []goast.Stmt
+		body, newPre, newPost, err = transpileToBlockStmt(n.Children()[0], p)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if body == nil {
+			return nil, nil, nil, fmt.Errorf("Body of Do cannot be nil")
+		}
+		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 	}
-	return
+
+	ifBreak, err := createIfWithNotConditionAndBreak(n.Children()[1])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cond, newPre, newPost, err := transpileIfStmt(&ifBreak, p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	condStmts := combineStmts(cond, newPre, newPost)
+
+	forStmt := &goast.ForStmt{Body: body}
+	if hasContinueStmt(forStmt) {
+		label := p.GetNextIdentifier("DO_WHILE_COND_LABEL_")
+		adaptContinueStmt(forStmt, label)
+		condStmts[0] = &goast.LabeledStmt{
+			Label: util.NewIdent(label),
+			Stmt:  condStmts[0],
+		}
+	}
+	body.List = append(body.List, condStmts...)
+
+	return forStmt, preStmts, postStmts, nil
+}
+
+// isSimpleStmt returns true if the statement can be the post statement of a
+// Go for loop.
+func isSimpleStmt(s goast.Stmt) bool {
+	switch v := s.(type) {
+	case nil, *goast.ExprStmt, *goast.IncDecStmt, *goast.SendStmt:
+		return true
+	case *goast.AssignStmt:
+		return v.Tok != token.DEFINE
+	}
+	return false
 }
 
 type continueDetector struct {
@@ -602,11 +654,12 @@ func hasContinueStmt(e goast.Stmt) bool {
 	return c.hasContinue
 }
 
-func adaptContinueStmt(e goast.Stmt, p *program.Program) {
+// adaptContinueStmt replaces each continue of the loop e with a goto to the
+// label.
+func adaptContinueStmt(e goast.Stmt, label string) {
 	var (
-		level                int
-		forLevel             []int
-		beforeConditionLabel string
+		level    int
+		forLevel []int
 	)
 	funcTransformBreak := func(cursor *astutil.Cursor) bool {
 		level++
@@ -615,11 +668,8 @@ func adaptContinueStmt(e goast.Stmt, p *program.Program) {
 		case *goast.BranchStmt:
 			// only replace continue within the outer for loop
 			if n.Tok == token.CONTINUE && len(forLevel) > 0 {
-				if beforeConditionLabel == "" {
-					panic("Before condition label not set")
-				}
 				cursor.Replace(&goast.BranchStmt{
-					Label: util.NewIdent(beforeConditionLabel),
+					Label: util.NewIdent(label),
 					Tok:   token.GOTO,
 				})
 			}
@@ -631,7 +681,6 @@ func adaptContinueStmt(e goast.Stmt, p *program.Program) {
 			}
 			// we have found the outer for loop
 			forLevel = append(forLevel, level)
-			beforeConditionLabel = setConditionLabel(n, p)
 			return true
 		case *goast.RangeStmt:
 			// Do not look for continue within the children of this AST node type,
@@ -653,22 +702,6 @@ func adaptContinueStmt(e goast.Stmt, p *program.Program) {
 	astutil.Apply(e, funcTransformBreak, postFunc)
 }
 
-func setConditionLabel(f *goast.ForStmt, p *program.Program) (label string) {
-	if f.Body == nil || len(f.Body.List) == 0 {
-		return ""
-	}
-	lastStmt := f.Body.List[len(f.Body.List)-1]
-	if _, ok := lastStmt.(*goast.IfStmt); ok {
-		doWhileCondLabel := p.GetNextIdentifier("DO_WHILE_COND_LABEL_")
-		f.Body.List[len(f.Body.List)-1] = &goast.LabeledStmt{
-			Label: util.NewIdent(doWhileCondLabel),
-			Stmt:  lastStmt,
-		}
-		return doWhileCondLabel
-	}
-	return ""
-}
-
 // createIfWithNotConditionAndBreak - create operator IF like on next example
 // of C code:
 // if ( !(condition) ) {
@@ -683,7 +716,7 @@ func setConditionLabel(f *goast.ForStmt, p *program.Program) (label string) {
 //    |-CompoundStmt 0x3bb1d88 <col:13, line:17:3>
 //    | `-BreakStmt 0x3bb1d80 <line:16:4>
 //    `-<<<NULL>>>
-func createIfWithNotConditionAndBreak(condition ast.Node) (ifStmt ast.IfStmt) {
+func createIfWithNotConditionAndBreak(condition ast.Node) (ifStmt ast.IfStmt, err error) {
 	ifStmt.AddChild(nil)
 
 	var par ast.ParenExpr
@@ -713,9 +746,25 @@ func createIfWithNotConditionAndBreak(condition ast.Node) (ifStmt ast.IfStmt) {
 		par.Type = con.Type
 		unitary.Type = con.Type
 
+	case *ast.CallExpr:
+		par.Type = con.Type
+		unitary.Type = con.Type
+
+	case *ast.ConditionalOperator:
+		par.Type = con.Type
+		unitary.Type = con.Type
+
+	case *ast.BinaryConditionalOperator:
+		par.Type = con.Type
+		unitary.Type = con.Type
+
+	case *ast.CompoundAssignOperator:
+		par.Type = con.Type
+		unitary.Type = con.Type
+
 	default:
-		panic(
-			fmt.Errorf("Type %T is not implemented in createIfWithNotConditionAndBreak", condition))
+		err = fmt.Errorf("Type %T is not implemented in createIfWithNotConditionAndBreak", condition)
+		return
 	}
 	par.AddChild(condition)
 	unitary.Operator = "!"
@@ -735,3 +784,96 @@ func transpileContinueStmt(n *ast.ContinueStmt, p *program.Program) (*goast.Bran
 		Tok: token.CONTINUE,
 	}, nil
 }
+
+// transpileToBoolExpr transpiles the condition of an "if", "for", "while" or
+// "do" statement, or of the conditional operator. Go requires a bool, but any
+// nonzero scalar and any non-null pointer is true in C, so the other types
+// are compared with zero or nil, like:
+//
+//     if (ptr)       =>    if ptr != nil
+//     while (n--)    =>    for func() bool { ... }()
+//     if (!(a && b)) =>    if !(a != 0 && b != 0)
+//
+// The negations and the parentheses are kept as bool expressions, instead of
+// converting them to int and back again.
+func transpileToBoolExpr(n ast.Node, p *program.Program) (
+	_ goast.Expr, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+	switch v := n.(type) {
+	case *ast.ParenExpr:
+		return transpileToBoolExpr(v.Children()[0], p)
+
+	case *ast.BinaryOperator:
+		// The left side of a comma is evaluated before the condition:
+		//
+		//     while (n++, n < 10)
+		if v.Operator == "," {
+			preStmts, err = transpileToStmts(v.Children()[0], p)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			cond, newPre, newPost, err := transpileToBoolExpr(v.Children()[1], p)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+			return cond, preStmts, postStmts, nil
+		}
+
+	case *ast.UnaryOperator:
+		if v.Operator == "!" {
+			var cond goast.Expr
+			cond, preStmts, postStmts, err = transpileToBoolExpr(v.Children()[0], p)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return negateBoolExpr(cond), preStmts, postStmts, nil
+		}
+	}
+
+	expr, exprType, preStmts, postStmts, err := atomicOperation(n, p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// null in C is false
+	if exprType == types.NullPointer {
+		return util.NewIdent("false"), preStmts, postStmts, nil
+	}
+
+	cond, err := types.CastExpr(p, expr, exprType, "bool")
+	p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, cond == nil))
+	if cond == nil {
+		cond = util.NewNil()
+	}
+
+	// The post statements, like the decrement of "n--", are done before the
+	// body of the statement, so they are deferred in a closure.
+	if len(postStmts) > 0 {
+		cond = util.NewAnonymousFunction(preStmts, postStmts, cond, "bool")
+		preStmts, postStmts = nil, nil
+	}
+
+	return cond, preStmts, postStmts, nil
+}
+
+// negateBoolExpr returns the negation of a bool expression. The comparisons
+// with zero or nil are inverted, like "p == nil" for "!p".
+func negateBoolExpr(cond goast.Expr) goast.Expr {
+	switch c := cond.(type) {
+	case *goast.BinaryExpr:
+		switch c.Op {
+		case token.NEQ:
+			return &goast.BinaryExpr{X: c.X, Op: token.EQL, Y: c.Y}
+		case token.EQL:
+			return &goast.BinaryExpr{X: c.X, Op: token.NEQ, Y: c.Y}
+		}
+	case *goast.UnaryExpr:
+		if c.Op == token.NOT {
+			return c.X
+		}
+	case *goast.Ident, *goast.CallExpr, *goast.ParenExpr:
+		return &goast.UnaryExpr{Op: token.NOT, X: cond}
+	}
+
+	return &goast.UnaryExpr{Op: token.NOT, X: &goast.ParenExpr{X: cond}}
+}
@@ -0,0 +1,389 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestDoStmt(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+		want string
+	}{
+		// int f(int n) {
+		//     int i = 0;
+		//     do {
+		//         i++;
+		//         if (i < 3) continue;
+		//         int y = i; n += y;
+		//     } while (n++, n < 10);
+		//     return n;
+		// }
+		{"continue", `
+FunctionDecl 0x10 <x.c:1:1, line:9:1> line:1:5 f 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used n 'int'
+|-CompoundStmt 0x12 <col:14, line:9:1>
+  |-DeclStmt 0x13 <line:2:3, col:12>
+  | |-VarDecl 0x14 <col:3, col:11> col:7 used i 'int' cinit
+  |   |-IntegerLiteral 0x15 <col:11> 'int' 0
+  |-DoStmt 0x20 <line:3:3, line:7:23>
+  | |-CompoundStmt 0x21 <line:3:6, line:7:3>
+  | | |-UnaryOperator 0x22 <line:4:5, col:6> 'int' postfix '++'
+  | | | |-DeclRefExpr 0x23 <col:5> 'int' lvalue Var 0x14 'i' 'int'
+  | | |-IfStmt 0x24 <line:5:5, col:20>
+  | | | |-NullStmt
+  | | | |-NullStmt
+  | | | |-BinaryOperator 0x25 <col:9, col:13> 'int' '<'
+  | | | | |-ImplicitCastExpr 0x26 <col:9> 'int' <LValueToRValue>
+  | | | | | |-DeclRefExpr 0x27 <col:9> 'int' lvalue Var 0x14 'i' 'int'
+  | | | | |-IntegerLiteral 0x28 <col:13> 'int' 3
+  | | | |-ContinueStmt 0x29 <col:16>
+  | | | |-NullStmt
+  | | |-DeclStmt 0x2a <line:6:5, col:14>
+  | | | |-VarDecl 0x2b <col:5, col:13> col:9 used y 'int' cinit
+  | | |   |-ImplicitCastExpr 0x2c <col:13> 'int' <LValueToRValue>
+  | | |     |-DeclRefExpr 0x2d <col:13> 'int' lvalue Var 0x14 'i' 'int'
+  | | |-CompoundAssignOperator 0x2e <line:6:16, col:21> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  | |   |-DeclRefExpr 0x2f <col:16> 'int' lvalue ParmVar 0x11 'n' 'int'
+  | |   |-ImplicitCastExpr 0x30 <col:21> 'int' <LValueToRValue>
+  | |     |-DeclRefExpr 0x31 <col:21> 'int' lvalue Var 0x2b 'y' 'int'
+  | |-BinaryOperator 0x32 <line:7:12, col:21> 'int' ','
+  |   |-UnaryOperator 0x33 <col:12, col:13> 'int' postfix '++'
+  |   | |-DeclRefExpr 0x34 <col:12> 'int' lvalue ParmVar 0x11 'n' 'int'
+  |   |-BinaryOperator 0x36 <line:7:12, col:21> 'int' '<'
+  |     |-ImplicitCastExpr 0x37 <col:10> 'int' <LValueToRValue>
+  |     | |-DeclRefExpr 0x38 <col:12> 'int' lvalue ParmVar 0x11 'n' 'int'
+  |     |-IntegerLiteral 0x35 <col:17> 'int' 10
+  |-ReturnStmt 0x40 <line:8:3, col:10>
+    |-ImplicitCastExpr 0x41 <col:10> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x42 <col:10> 'int' lvalue ParmVar 0x11 'n' 'int'
+`, `
+	for {
+		var y int32
+		i += 1
+		if i < int32(3) {
+			goto DO_WHILE_COND_LABEL_0
+		}
+		y = i
+		n += y
+	DO_WHILE_COND_LABEL_0:
+		n += 1
+		if !(n < int32(10)) {
+			break
+		}
+	}
+	return n
+`},
+
+		// int g(int s) {
+		//     do s++; while (s < 10);
+		//     return s;
+		// }
+		{"without CompoundStmt", `
+FunctionDecl 0x10 <x.c:1:1, line:4:1> line:1:5 g 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used s 'int'
+|-CompoundStmt 0x12 <col:14, line:4:1>
+  |-DoStmt 0x20 <line:2:5, col:26>
+  | |-UnaryOperator 0x21 <col:8, col:9> 'int' postfix '++'
+  | | |-DeclRefExpr 0x22 <col:8> 'int' lvalue ParmVar 0x11 's' 'int'
+  | |-BinaryOperator 0x23 <col:20, col:24> 'int' '<'
+  |   |-ImplicitCastExpr 0x24 <col:20> 'int' <LValueToRValue>
+  |   | |-DeclRefExpr 0x25 <col:20> 'int' lvalue ParmVar 0x11 's' 'int'
+  |   |-IntegerLiteral 0x26 <col:24> 'int' 10
+  |-ReturnStmt 0x30 <line:3:5, col:12>
+    |-ImplicitCastExpr 0x31 <col:12> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x32 <col:12> 'int' lvalue ParmVar 0x11 's' 'int'
+`, `
+	for {
+		s += 1
+		if !(s < int32(10)) {
+			break
+		}
+	}
+	return s
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := program.NewProgram()
+			decls, err := transpileFunctionDecl(parseTree(tt.dump).(*ast.FunctionDecl), p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want[1:]) {
+				t.Errorf("Expected:\n%s\nin:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestForStmt(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+		want string
+	}{
+		// int f(int n) {
+		//     int i, s = 0;
+		//     for (i = 0; i < n; i++) {
+		//         if (i == 2) continue;
+		//         s += i;
+		//     }
+		//     return s;
+		// }
+		{"continue", `
+FunctionDecl 0x10 <x.c:1:1, line:8:1> line:1:5 f 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used n 'int'
+|-CompoundStmt 0x12 <col:14, line:8:1>
+  |-DeclStmt 0x13 <line:2:5, col:17>
+  | |-VarDecl 0x14 <col:5, col:9> col:9 used i 'int'
+  | |-VarDecl 0x15 <col:5, col:16> col:12 used s 'int' cinit
+  |   |-IntegerLiteral 0x16 <col:16> 'int' 0
+  |-ForStmt 0x20 <line:3:5, line:6:5>
+  | |-BinaryOperator 0x21 <line:3:10, col:14> 'int' '='
+  | | |-DeclRefExpr 0x22 <col:10> 'int' lvalue Var 0x14 'i' 'int'
+  | | |-IntegerLiteral 0x23 <col:14> 'int' 0
+  | |-NullStmt
+  | |-BinaryOperator 0x24 <col:17, col:21> 'int' '<'
+  | | |-ImplicitCastExpr 0x25 <col:17> 'int' <LValueToRValue>
+  | | | |-DeclRefExpr 0x26 <col:17> 'int' lvalue Var 0x14 'i' 'int'
+  | | |-ImplicitCastExpr 0x27 <col:21> 'int' <LValueToRValue>
+  | |   |-DeclRefExpr 0x28 <col:21> 'int' lvalue ParmVar 0x11 'n' 'int'
+  | |-UnaryOperator 0x29 <col:24, col:25> 'int' postfix '++'
+  | | |-DeclRefExpr 0x2a <col:24> 'int' lvalue Var 0x14 'i' 'int'
+  | |-CompoundStmt 0x2b <col:29, line:6:5>
+  |   |-IfStmt 0x2c <line:4:9, col:29>
+  |   | |-NullStmt
+  |   | |-NullStmt
+  |   | |-BinaryOperator 0x2d <col:13, col:18> 'int' '=='
+  |   | | |-ImplicitCastExpr 0x2e <col:13> 'int' <LValueToRValue>
+  |   | | | |-DeclRefExpr 0x2f <col:13> 'int' lvalue Var 0x14 'i' 'int'
+  |   | | |-IntegerLiteral 0x30 <col:18> 'int' 2
+  |   | |-ContinueStmt 0x31 <col:21>
+  |   | |-NullStmt
+  |   |-CompoundAssignOperator 0x32 <line:5:9, col:14> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  |     |-DeclRefExpr 0x33 <col:9> 'int' lvalue Var 0x15 's' 'int'
+  |     |-ImplicitCastExpr 0x34 <col:14> 'int' <LValueToRValue>
+  |       |-DeclRefExpr 0x35 <col:14> 'int' lvalue Var 0x14 'i' 'int'
+  |-ReturnStmt 0x40 <line:7:5, col:12>
+    |-ImplicitCastExpr 0x41 <col:12> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x42 <col:12> 'int' lvalue Var 0x15 's' 'int'
+`, `
+	for i = int32(0); i < n; i++ {
+		if i == int32(2) {
+			continue
+		}
+		s += i
+	}
+`},
+
+		// int g(int n) {
+		//     int i, j = 0, k = 0, m;
+		//     for (i = 0; i < n; j = (i++, i * 2)) {
+		//         for (m = 0; m < 3; m++) {
+		//             if (m == 1) continue;
+		//             k += 1;
+		//         }
+		//         if (i % 2) continue;
+		//         k += j;
+		//     }
+		//     return k;
+		// }
+		{"increment with statements", `
+FunctionDecl 0x10 <x.c:1:1, line:12:1> line:1:5 g 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used n 'int'
+|-CompoundStmt 0x12 <col:14, line:12:1>
+  |-DeclStmt 0x13 <line:2:3, col:26>
+  | |-VarDecl 0x14 <col:3, col:7> col:7 used i 'int'
+  | |-VarDecl 0x15 <col:3, col:14> col:10 used j 'int' cinit
+  | | |-IntegerLiteral 0x16 <col:14> 'int' 0
+  | |-VarDecl 0x17 <col:3, col:21> col:17 used k 'int' cinit
+  | | |-IntegerLiteral 0x18 <col:21> 'int' 0
+  | |-VarDecl 0x19 <col:3, col:24> col:24 used m 'int'
+  |-ForStmt 0x20 <line:3:3, line:10:3>
+  | |-BinaryOperator 0x21 <line:3:8, col:12> 'int' '='
+  | | |-DeclRefExpr 0x22 <col:8> 'int' lvalue Var 0x14 'i' 'int'
+  | | |-IntegerLiteral 0x23 <col:12> 'int' 0
+  | |-NullStmt
+  | |-BinaryOperator 0x24 <col:15, col:19> 'int' '<'
+  | | |-ImplicitCastExpr 0x25 <col:15> 'int' <LValueToRValue>
+  | | | |-DeclRefExpr 0x26 <col:15> 'int' lvalue Var 0x14 'i' 'int'
+  | | |-ImplicitCastExpr 0x27 <col:19> 'int' <LValueToRValue>
+  | |   |-DeclRefExpr 0x28 <col:19> 'int' lvalue ParmVar 0x11 'n' 'int'
+  | |-BinaryOperator 0x29 <col:22, col:38> 'int' '='
+  | | |-DeclRefExpr 0x2a <col:22> 'int' lvalue Var 0x15 'j' 'int'
+  | | |-ParenExpr 0x2b <col:26, col:38> 'int'
+  | |   |-BinaryOperator 0x2c <col:27, col:37> 'int' ','
+  | |     |-UnaryOperator 0x2d <col:27, col:28> 'int' postfix '++'
+  | |     | |-DeclRefExpr 0x2e <col:27> 'int' lvalue Var 0x14 'i' 'int'
+  | |     |-BinaryOperator 0x2f <col:33, col:37> 'int' '*'
+  | |       |-ImplicitCastExpr 0x30 <col:33> 'int' <LValueToRValue>
+  | |       | |-DeclRefExpr 0x31 <col:33> 'int' lvalue Var 0x14 'i' 'int'
+  | |       |-IntegerLiteral 0x32 <col:37> 'int' 2
+  | |-CompoundStmt 0x33 <col:41, line:10:3>
+  |   |-ForStmt 0x34 <line:4:5, line:7:5>
+  |   | |-BinaryOperator 0x35 <line:4:10, col:14> 'int' '='
+  |   | | |-DeclRefExpr 0x36 <col:10> 'int' lvalue Var 0x19 'm' 'int'
+  |   | | |-IntegerLiteral 0x37 <col:14> 'int' 0
+  |   | |-NullStmt
+  |   | |-BinaryOperator 0x38 <col:17, col:21> 'int' '<'
+  |   | | |-ImplicitCastExpr 0x39 <col:17> 'int' <LValueToRValue>
+  |   | | | |-DeclRefExpr 0x3a <col:17> 'int' lvalue Var 0x19 'm' 'int'
+  |   | | |-IntegerLiteral 0x3b <col:21> 'int' 3
+  |   | |-UnaryOperator 0x3c <col:24, col:25> 'int' postfix '++'
+  |   | | |-DeclRefExpr 0x3d <col:24> 'int' lvalue Var 0x19 'm' 'int'
+  |   | |-CompoundStmt 0x3e <col:29, line:7:5>
+  |   |   |-IfStmt 0x3f <line:5:7, col:23>
+  |   |   | |-NullStmt
+  |   |   | |-NullStmt
+  |   |   | |-BinaryOperator 0x40 <col:11, col:16> 'int' '=='
+  |   |   | | |-ImplicitCastExpr 0x41 <col:11> 'int' <LValueToRValue>
+  |   |   | | | |-DeclRefExpr 0x42 <col:11> 'int' lvalue Var 0x19 'm' 'int'
+  |   |   | | |-IntegerLiteral 0x43 <col:16> 'int' 1
+  |   |   | |-ContinueStmt 0x44 <col:19>
+  |   |   | |-NullStmt
+  |   |   |-CompoundAssignOperator 0x45 <line:6:7, col:12> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  |   |     |-DeclRefExpr 0x46 <col:7> 'int' lvalue Var 0x17 'k' 'int'
+  |   |     |-IntegerLiteral 0x47 <col:12> 'int' 1
+  |   |-IfStmt 0x48 <line:8:5, col:20>
+  |   | |-NullStmt
+  |   | |-NullStmt
+  |   | |-BinaryOperator 0x49 <col:9, col:13> 'int' '%'
+  |   | | |-ImplicitCastExpr 0x4a <col:9> 'int' <LValueToRValue>
+  |   | | | |-DeclRefExpr 0x4b <col:9> 'int' lvalue Var 0x14 'i' 'int'
+  |   | | |-IntegerLiteral 0x4c <col:13> 'int' 2
+  |   | |-ContinueStmt 0x4d <col:16>
+  |   | |-NullStmt
+  |   |-CompoundAssignOperator 0x4e <line:9:5, col:10> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  |     |-DeclRefExpr 0x4f <col:5> 'int' lvalue Var 0x17 'k' 'int'
+  |     |-ImplicitCastExpr 0x50 <col:10> 'int' <LValueToRValue>
+  |       |-DeclRefExpr 0x51 <col:10> 'int' lvalue Var 0x15 'j' 'int'
+  |-ReturnStmt 0x60 <line:11:3, col:10>
+    |-ImplicitCastExpr 0x61 <col:10> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x62 <col:10> 'int' lvalue Var 0x17 'k' 'int'
+`, `
+	for i = int32(0); i < n; {
+		for m = int32(0); m < int32(3); m++ {
+			if m == int32(1) {
+				continue
+			}
+			k += int32(1)
+		}
+		if i%int32(2) != 0 {
+			goto FOR_POST_LABEL_0
+		}
+		k += j
+	FOR_POST_LABEL_0:
+		i += 1
+		j = i*int32(2)
+	}
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := program.NewProgram()
+			decls, err := transpileFunctionDecl(parseTree(tt.dump).(*ast.FunctionDecl), p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want[1:]) {
+				t.Errorf("Expected:\n%s\nin:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestBoolConditions(t *testing.T) {
+	// int f(int *p, int n, double d) {
+	//     int c = 0;
+	//     if (p) c++;
+	//     while (n--) c++;
+	//     for (; d; ) d = 0;
+	//     do c++; while (p && n);
+	//     return !p || c ? c : 0;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:8:1> line:1:5 f 'int (int *, int, double)'
+|-ParmVarDecl 0x11 <col:7, col:12> col:12 used p 'int *'
+|-ParmVarDecl 0x12 <col:15, col:19> col:19 used n 'int'
+|-ParmVarDecl 0x13 <col:22, col:29> col:29 used d 'double'
+|-CompoundStmt 0x14 <col:32, line:8:1>
+  |-DeclStmt 0x15 <line:2:5, col:14>
+  | |-VarDecl 0x16 <col:5, col:13> col:9 used c 'int' cinit
+  |   |-IntegerLiteral 0x17 <col:13> 'int' 0
+  |-IfStmt 0x20 <line:3:5, col:15>
+  | |-ImplicitCastExpr 0x21 <col:9> 'int *' <LValueToRValue>
+  | | |-DeclRefExpr 0x22 <col:9> 'int *' lvalue ParmVar 0x11 'p' 'int *'
+  | |-UnaryOperator 0x23 <col:12, col:13> 'int' postfix '++'
+  |   |-DeclRefExpr 0x24 <col:12> 'int' lvalue Var 0x16 'c' 'int'
+  |-WhileStmt 0x30 <line:4:5, col:20>
+  | |-UnaryOperator 0x31 <col:12, col:13> 'int' postfix '--'
+  | | |-DeclRefExpr 0x32 <col:12> 'int' lvalue ParmVar 0x12 'n' 'int'
+  | |-UnaryOperator 0x33 <col:17, col:18> 'int' postfix '++'
+  |   |-DeclRefExpr 0x34 <col:17> 'int' lvalue Var 0x16 'c' 'int'
+  |-ForStmt 0x40 <line:5:5, col:23>
+  | |-NullStmt
+  | |-NullStmt
+  | |-ImplicitCastExpr 0x41 <col:12> 'double' <LValueToRValue>
+  | | |-DeclRefExpr 0x42 <col:12> 'double' lvalue ParmVar 0x13 'd' 'double'
+  | |-NullStmt
+  | |-BinaryOperator 0x43 <col:19, col:23> 'double' '='
+  |   |-DeclRefExpr 0x44 <col:19> 'double' lvalue ParmVar 0x13 'd' 'double'
+  |   |-ImplicitCastExpr 0x45 <col:23> 'double' <IntegralToFloating>
+  |     |-IntegerLiteral 0x46 <col:23> 'int' 0
+  |-DoStmt 0x50 <line:6:5, col:26>
+  | |-UnaryOperator 0x51 <col:8, col:9> 'int' postfix '++'
+  | | |-DeclRefExpr 0x52 <col:8> 'int' lvalue Var 0x16 'c' 'int'
+  | |-BinaryOperator 0x53 <col:18, col:23> 'int' '&&'
+  |   |-ImplicitCastExpr 0x54 <col:18> 'int *' <LValueToRValue>
+  |   | |-DeclRefExpr 0x55 <col:18> 'int *' lvalue ParmVar 0x11 'p' 'int *'
+  |   |-ImplicitCastExpr 0x56 <col:23> 'int' <LValueToRValue>
+  |     |-DeclRefExpr 0x57 <col:23> 'int' lvalue ParmVar 0x12 'n' 'int'
+  |-ReturnStmt 0x60 <line:7:5, col:27>
+    |-ConditionalOperator 0x61 <col:12, col:27> 'int'
+      |-BinaryOperator 0x62 <col:12, col:18> 'int' '||'
+      | |-UnaryOperator 0x63 <col:12, col:13> 'int' prefix '!'
+      | | |-ImplicitCastExpr 0x64 <col:13> 'int *' <LValueToRValue>
+      | |   |-DeclRefExpr 0x65 <col:13> 'int *' lvalue ParmVar 0x11 'p' 'int *'
+      | |-ImplicitCastExpr 0x66 <col:18> 'int' <LValueToRValue>
+      |   |-DeclRefExpr 0x67 <col:18> 'int' lvalue Var 0x16 'c' 'int'
+      |-ImplicitCastExpr 0x68 <col:22> 'int' <LValueToRValue>
+      | |-DeclRefExpr 0x69 <col:22> 'int' lvalue Var 0x16 'c' 'int'
+      |-IntegerLiteral 0x6a <col:27> 'int' 0
+`
+	p := program.NewProgram()
+	decls, err := transpileToNode(parseTree(dump), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	expectContains(t, output,
+		"if p != nil {",
+		"defer func() {\n\t\t\tn -= 1\n\t\t}()\n\t\treturn n != 0\n\t}() {",
+		"for d != 0 {",
+		"if !(p != nil && n != 0) {",
+		"if p == nil || c != 0 {",
+	)
+}
@@ -5,6 +5,7 @@ package transpiler
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/elliotchance/c2go/ast"
@@ -106,6 +107,308 @@ func getNameOfFunctionFromCallExpr(p *program.Program, n *ast.CallExpr) (string,
 	return getName(p, firstChild.Children()[0])
 }
 
+// transpileCalloc transpiles a call of calloc(). The memory is allocated as a
+// Go slice of the element type when the size is a sizeof of a type, so that it
+// is zeroed by make() and followed by the garbage collector. The slice is
+// registered by noarch.AllocSlice() for realloc(), that returns nil for a count
+// of zero:
+//
+//     calloc(n, sizeof(struct s))    =>    (*s)(noarch.AllocSlice(make([]s, n)))
+//
+// Any other size is allocated as a zeroed slice of bytes by noarch.Malloc(),
+// like malloc() is.
+//
+// ok is false if the call is not a call of calloc().
+func transpileCalloc(n *ast.CallExpr, p *program.Program) (
+	_ goast.Expr, resultType string, preStmts []goast.Stmt, postStmts []goast.Stmt,
+	ok bool, err error) {
+	if len(n.Children()) != 3 {
+		return
+	}
+	if name, err := getNameOfFunctionFromCallExpr(p, n); err != nil || name != "calloc" {
+		return nil, "", nil, nil, false, nil
+	}
+
+	count, countType, preStmts, postStmts, err := transpileToExpr(n.Children()[1], p, false)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+
+	if allocType, ok := callocElementType(n.Children()[2]); ok {
+		goType, err := types.ResolveType(p, allocType)
+		if err != nil {
+			return nil, "", nil, nil, true, err
+		}
+		sliceType := &goast.ArrayType{Elt: util.NewTypeIdent(goType)}
+		p.AddImport("github.com/elliotchance/c2go/noarch")
+		return &goast.CallExpr{
+			Fun:  &goast.ParenExpr{X: &goast.StarExpr{X: util.NewTypeIdent(goType)}},
+			Args: []goast.Expr{util.NewCallExpr("noarch.AllocSlice", util.NewCallExpr("make", sliceType, count))},
+		}, allocType + " *", preStmts, postStmts, true, nil
+	}
+
+	size, sizeType, newPre, newPost, err := transpileToExpr(n.Children()[2], p, false)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+
+	count, err = types.CastExpr(p, count, countType, "int")
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	size, err = types.CastExpr(p, size, sizeType, "int")
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	return util.NewCallExpr("noarch.Malloc",
+		util.NewBinaryExpr(count, token.MUL, size, "int32", false),
+	), "void *", preStmts, postStmts, true, nil
+}
+
+// callocElementType returns the type of the elements that calloc() allocates,
+// like "struct s" for "sizeof(struct s)". ok is false if the size is not a
+// sizeof, or if the type cannot be the element of a Go slice that is used as
+// a pointer, like an array.
+func callocElementType(size ast.Node) (_ string, ok bool) {
+	sizeof, ok := size.(*ast.UnaryExprOrTypeTraitExpr)
+	if !ok || sizeof.Function != "sizeof" {
+		return "", false
+	}
+
+	t := sizeof.Type2
+	if t == "" && len(sizeof.Children()) == 1 {
+		t, _ = sizeofOperandType(sizeof.Children()[0])
+	}
+	if t == "" || strings.ContainsAny(t, "[(") {
+		return "", false
+	}
+
+	return t, true
+}
+
+// transpileAlloca transpiles a call of alloca() into a slice of bytes that is
+// declared before the expression:
+//
+//     var c2goAlloca0 []byte = make([]byte, n)
+//     buf = (*byte)(noarch.SlicePointer(c2goAlloca0))
+//
+// C frees the memory when the function returns. The garbage collector keeps
+// the slice alive as long as it is used instead, so it is safe to use until
+// then as well.
+//
+// An alloca() inside a loop allocates more memory in each iteration that C
+// does not free before the function returns. The slice is declared in the
+// body of the loop, so each iteration gets a new slice as well, and the slices
+// of earlier iterations are freed when they are not used anymore.
+//
+// ok is false if the call is not a call of alloca().
+func transpileAlloca(n *ast.CallExpr, p *program.Program) (
+	_ goast.Expr, resultType string, preStmts []goast.Stmt, postStmts []goast.Stmt,
+	ok bool, err error) {
+	if len(n.Children()) != 2 {
+		return
+	}
+	if name, err := getNameOfFunctionFromCallExpr(p, n); err != nil ||
+		(name != "alloca" && name != "__builtin_alloca") {
+		return nil, "", nil, nil, false, nil
+	}
+
+	size, sizeType, preStmts, postStmts, err := transpileToExpr(n.Children()[1], p, false)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	size, err = types.CastExpr(p, size, sizeType, "int")
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+
+	buf := p.GetNextIdentifier("c2goAlloca")
+	preStmts = append(preStmts, util.NewVarDecl(buf, "[]byte",
+		util.NewCallExpr("make", util.NewTypeIdent("[]byte"), size)))
+
+	// The pointer is nil for alloca(0), that has no byte to point to.
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	return util.NewCallExpr("noarch.SlicePointer", util.NewIdent(buf)),
+		"void *", preStmts, postStmts, true, nil
+}
+
+// transpileBuiltinHint transpiles a call of one of the builtins of
+// program.Program.BuiltinHints into what it does for the program. The first
+// argument of __builtin_expect() is converted to a long by clang, the
+// expression is transpiled without the conversion so that a condition stays a
+// condition.
+//
+// ok is false if the call is not a call of a builtin hint.
+func transpileBuiltinHint(n *ast.CallExpr, p *program.Program) (
+	_ goast.Expr, resultType string, preStmts []goast.Stmt, postStmts []goast.Stmt,
+	ok bool, err error) {
+	name, err := getNameOfFunctionFromCallExpr(p, n)
+	if err != nil {
+		return nil, "", nil, nil, false, nil
+	}
+	message, ok := p.BuiltinHint(name)
+	if !ok {
+		return
+	}
+
+	if message != "" {
+		return util.NewCallExpr("panic", util.NewStringLit(strconv.Quote(message))),
+			n.Type, nil, nil, true, nil
+	}
+	if len(n.Children()) < 2 {
+		return nil, "", nil, nil, true, fmt.Errorf("%s() has no argument", name)
+	}
+	arg := n.Children()[1]
+	if cast, isCast := arg.(*ast.ImplicitCastExpr); isCast &&
+		cast.Kind == "IntegralCast" && len(cast.Children()) == 1 {
+		arg = cast.Children()[0]
+	}
+	expr, exprType, preStmts, postStmts, err := transpileToExpr(arg, p, false)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+
+	return expr, exprType, preStmts, postStmts, true, nil
+}
+
+// scanfFormatArguments are the positions of the format arguments of the
+// scanf() functions.
+var scanfFormatArguments = map[string]int{
+	"scanf":  0,
+	"sscanf": 1,
+	"fscanf": 1,
+}
+
+// limitScanfStrings adds a width to the "%s" and "%[" conversions of a scanf()
+// format that store into a char array, like "%9s" for a char[10]. The
+// conversion then reads only the characters that fit into the array, instead
+// of writing beyond the Go slice of the array. The format must be a string
+// literal. A conversion that has a width already is not changed.
+func limitScanfStrings(n *ast.CallExpr, name string) {
+	pos, ok := scanfFormatArguments[name]
+	if !ok || len(n.Children()) < pos+2 {
+		return
+	}
+	args := n.Children()[1:]
+	literal := findStringLiteral(args[pos])
+	if literal == nil {
+		return
+	}
+
+	format := literal.Value
+	var out bytes.Buffer
+	next := pos + 1
+	for i := 0; i < len(format); i++ {
+		out.WriteByte(format[i])
+		if format[i] != '%' {
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			out.WriteByte('%')
+			i++
+			continue
+		}
+
+		// The conversion is like "%*9ld".
+		j := i + 1
+		suppress := j < len(format) && format[j] == '*'
+		if suppress {
+			j++
+		}
+		width := j
+		for j < len(format) && format[j] >= '0' && format[j] <= '9' {
+			j++
+		}
+		hasWidth := j > width
+		for j < len(format) && strings.IndexByte("hljztLq", format[j]) != -1 {
+			j++
+		}
+		if j >= len(format) {
+			out.WriteString(format[i+1:])
+			break
+		}
+
+		verb := format[j]
+		if !suppress && !hasWidth && (verb == 's' || verb == '[') && next < len(args) {
+			if size := charArraySize(args[next]); size > 1 {
+				// The width is before the length modifier, like "%9ls".
+				out.WriteString(strconv.Itoa(size-1) + format[i+1:j])
+				i = j - 1
+			}
+		}
+		if !suppress {
+			next++
+		}
+	}
+
+	literal.Value = out.String()
+}
+
+// standardStream returns the noarch stream for an argument that is one of the
+// standard streams, like noarch.Stderr for stderr, or nil if it is not. The Go
+// variables of the C streams are only assigned when the program starts (and
+// they are named like __stderrp on macOS), so the output of a call like
+// "fprintf(stderr, ...)" is written to the noarch stream directly.
+func standardStream(p *program.Program, n ast.Node) goast.Expr {
+	switch v := n.(type) {
+	case *ast.ImplicitCastExpr, *ast.ParenExpr:
+		if len(v.Children()) == 1 {
+			return standardStream(p, v.Children()[0])
+		}
+	case *ast.DeclRefExpr:
+		name := v.Name
+		switch name {
+		case "__stdinp", "__stdoutp", "__stderrp":
+			name = name[2 : len(name)-1]
+		case "stdin", "stdout", "stderr":
+		default:
+			return nil
+		}
+		p.AddImport("github.com/elliotchance/c2go/noarch")
+		return util.NewTypeIdent("noarch." + util.Ucfirst(name))
+	}
+
+	return nil
+}
+
+// findStringLiteral returns the string literal of an argument, or nil if the
+// argument is not a string literal.
+func findStringLiteral(n ast.Node) *ast.StringLiteral {
+	switch v := n.(type) {
+	case *ast.StringLiteral:
+		return v
+	case *ast.ImplicitCastExpr, *ast.ParenExpr:
+		if len(v.Children()) == 1 {
+			return findStringLiteral(v.Children()[0])
+		}
+	}
+
+	return nil
+}
+
+// charArraySize returns the size of the char array that an argument decays
+// from, or 0 if the argument is not a char array.
+func charArraySize(n ast.Node) int {
+	decay, ok := n.(*ast.ImplicitCastExpr)
+	if !ok || decay.Kind != ast.ImplicitCastExprArrayToPointerDecay || len(decay.Children()) != 1 {
+		return 0
+	}
+	t, err := sizeofOperandType(decay.Children()[0])
+	if err != nil {
+		return 0
+	}
+	elementType, size := types.GetArrayTypeAndSize(types.CleanCType(t))
+	switch elementType {
+	case "char", "signed char", "unsigned char":
+		return size
+	}
+
+	return 0
+}
+
 // transpileCallExpr transpiles expressions that calls a function, for example:
 //
 //     foo("bar")
@@ -121,11 +424,16 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 		}
 	}()
 
+	if callee := getCalledExpression(n); callee != nil {
+		return transpileCallOfExpression(n, callee, p)
+	}
+
 	functionName, err := getNameOfFunctionFromCallExpr(p, n)
 	if err != nil {
 		return nil, "", nil, nil, err
 	}
 	functionName = util.ConvertFunctionNameFromCtoGo(functionName)
+	limitScanfStrings(n, functionName)
 
 	if functionName == "__builtin_va_start" ||
 		functionName == "__builtin_va_end" {
@@ -134,32 +442,6 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 		return nil, "", nil, nil, nil
 	}
 
-	// function "calloc" from stdlib.c
-	if functionName == "calloc" && len(n.Children()) == 3 {
-		var allocType string
-		size, _, preStmts, postStmts, err := transpileToExpr(n.Children()[1], p, false)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-		if v, ok := n.Children()[2].(*ast.UnaryExprOrTypeTraitExpr); ok {
-			allocType = v.Type2
-		} else {
-			return nil, "", nil, nil,
-				fmt.Errorf("Unsupport type '%T' in function calloc", n.Children()[2])
-		}
-		goType, err := types.ResolveType(p, allocType)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-		return &goast.CallExpr{
-			Fun: util.NewIdent("make"),
-			Args: []goast.Expr{
-				&goast.ArrayType{Elt: goast.NewIdent(goType)},
-				size,
-			},
-		}, allocType + " *", preStmts, postStmts, nil
-	}
-
 	// function "qsort" from stdlib.h
 	if functionName == "qsort" && len(n.Children()) == 5 {
 		defer func() {
@@ -275,6 +557,10 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 					p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("Cannot resolve function : %v", err), n))
 					return nil, "", nil, nil, err
 				}
+				if len(fields) > 0 && fields[len(fields)-1] == "..." {
+					fields = fields[:len(fields)-1]
+					functionDef.Variadic = true
+				}
 				functionDef.ReturnType = returns[0]
 				functionDef.ArgumentTypes = fields
 			}
@@ -296,57 +582,32 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 		}
 	}
 
-	if functionDef.Substitution != "" {
+	if len(functionDef.SubstitutionImports) > 0 {
+		for _, importName := range functionDef.SubstitutionImports {
+			p.AddImport(importName)
+		}
+		functionName = functionDef.Substitution
+	} else if functionDef.Substitution != "" {
 		parts := strings.Split(functionDef.Substitution, ".")
 		importName := strings.Join(parts[:len(parts)-1], ".")
 		p.AddImport(importName)
 
 		parts2 := strings.Split(functionDef.Substitution, "/")
 		functionName = parts2[len(parts2)-1]
+	} else {
+		functionName = p.GoIdentifier(functionName)
 	}
 
-	args := []goast.Expr{}
-	argTypes := []string{}
-	i := 0
-	for _, arg := range n.Children()[1:] {
-		e, eType, newPre, newPost, err := transpileToExpr(arg, p, false)
-		if err != nil {
-			return nil, "unknown2", nil, nil, err
-		}
-		argTypes = append(argTypes, eType)
-
-		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
-
-		_, arraySize := types.GetArrayTypeAndSize(eType)
-
-		// If we are using varargs with Printf we need to make sure that certain
-		// types are cast correctly.
-		if functionName == "fmt.Printf" {
-			// Make sure that any string parameters (const char*) are truncated
-			// to the NULL byte.
-			if arraySize != -1 {
-				p.AddImport("github.com/elliotchance/c2go/noarch")
-				e = util.NewCallExpr(
-					"noarch.CStringToString",
-					&goast.SliceExpr{X: e},
-				)
-			}
-
-			// Byte slices (char*) must also be truncated to the NULL byte.
-			//
-			// TODO: This would also apply to other formatting functions like
-			// fprintf, etc.
-			if i > len(functionDef.ArgumentTypes)-1 &&
-				(eType == "char *" || eType == "char*") {
-				p.AddImport("github.com/elliotchance/c2go/noarch")
-				e = util.NewCallExpr("noarch.CStringToString", e)
-			}
-		}
-
-		args = append(args, e)
-
-		i++
+	// An argument that is passed by reference must stay the variable.
+	inOrder := true
+	for _, a := range functionDef.Parameters {
+		inOrder = inOrder && a >= 0
 	}
+	args, argTypes, newPre, newPost, err := transpileCallArguments(p, n.Children()[1:], inOrder)
+	if err != nil {
+		return nil, "unknown2", nil, nil, err
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
 	// These are the arguments once any transformations have taken place.
 	realArgs := []goast.Expr{}
@@ -401,6 +662,11 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 			if i > len(functionDef.ArgumentTypes)-1 {
 				// This means the argument is one of the varargs so we don't
 				// know what type it needs to be cast to.
+			} else if len(functionDef.StringParameters) > i && functionDef.StringParameters[i] {
+				a, err = transpileStringArgument(p, n.Children()[i+1], a, argTypes[i])
+				if p.AddMessage(p.GenerateWarningMessage(err, n)) {
+					a = util.NewStringLit(`""`)
+				}
 			} else {
 				a, err = types.CastExpr(p, a, argTypes[i],
 					functionDef.ArgumentTypes[i])
@@ -449,8 +715,217 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 		return nil, "", preStmts, postStmts, nil
 	}
 
-	return util.NewCallExpr(functionName, realArgs...),
-		functionDef.ReturnType, preStmts, postStmts, nil
+	call := util.NewCallExpr(functionName, realArgs...)
+	if method, receiver, _ := getMethod(p, functionDef); method != "" && len(realArgs) > 0 {
+		call = newMethodCall(call, method, receiver)
+	}
+	// The noarch functions that return a long, like noarch.Ftell(), return an
+	// int64. The result is converted when long is 32 bits on the ABI.
+	if strings.HasPrefix(functionDef.Substitution, "github.com/elliotchance/c2go/noarch.") &&
+		functionDef.ReturnType == "long" {
+		if goType, _ := p.ABI.GoType("long"); goType != "int64" {
+			call = util.NewCallExpr(goType, call)
+		}
+	}
+	if functionDef.ReturnsErrno && functionDef.ReturnType != "void" {
+		call, err = newErrnoCall(p, call, functionDef.ReturnType)
+		if err != nil {
+			return nil, "", nil, nil, err
+		}
+	}
+
+	return call, functionDef.ReturnType, preStmts, postStmts, nil
+}
+
+// transpileCallArguments transpiles the arguments of a call. All of the side
+// effects of the arguments happen before the call, in the order of the
+// arguments, like in C:
+//
+//     f(a++, a++)    =>    var c2goArg0 int32 = a
+//                          a += 1
+//                          var c2goArg1 int32 = a
+//                          a += 1
+//                          f(c2goArg0, c2goArg1)
+//
+// The statements before an argument could change the value of the arguments
+// on its left, so these are stored into variables before the statements. An
+// argument with statements after its value, like the increment of "a++", is
+// stored into a variable as well, before the statements. Arguments without
+// such statements are evaluated by the call as usual. The variables have the
+// type of the argument, so that they can be moved out of the way of a goto.
+//
+// The statements are returned as they are when inOrder is false.
+func transpileCallArguments(p *program.Program, args []ast.Node, inOrder bool) (
+	exprs []goast.Expr, exprTypes []string, preStmts []goast.Stmt,
+	postStmts []goast.Stmt, err error) {
+	// store assigns an argument to a new variable that replaces it.
+	store := func(i int) {
+		switch v := exprs[i].(type) {
+		case *goast.BasicLit:
+			return
+		case *goast.Ident:
+			if v.Name == "nil" {
+				return
+			}
+		}
+		goType, err := types.ResolveType(p, exprTypes[i])
+		if err != nil {
+			p.AddMessage(p.GenerateWarningMessage(err, args[i]))
+			return
+		}
+		name := p.GetNextIdentifier("c2goArg")
+		preStmts = append(preStmts, util.NewVarDecl(name, goType, exprs[i]))
+		exprs[i] = util.NewIdent(name)
+	}
+
+	var pending []int
+	for i, arg := range args {
+		e, eType, newPre, newPost, err := transpileToExpr(arg, p, false)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if stream := standardStream(p, arg); stream != nil {
+			e = stream
+		}
+		exprs = append(exprs, e)
+		exprTypes = append(exprTypes, eType)
+
+		newPre, newPost = nilFilterStmts(newPre), nilFilterStmts(newPost)
+		if !inOrder || len(newPre)+len(newPost) == 0 {
+			preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+			pending = append(pending, i)
+			continue
+		}
+
+		for _, j := range pending {
+			store(j)
+		}
+		pending = nil
+		preStmts = append(preStmts, newPre...)
+		if len(newPost) == 0 {
+			pending = append(pending, i)
+			continue
+		}
+		store(i)
+		preStmts = append(preStmts, newPost...)
+	}
+
+	return
+}
+
+// getCalledExpression returns the expression that results in the called
+// function pointer, if the function is not called by its name. In Go there is
+// no need to dereference the function pointer, so it is removed. Example:
+//
+//     get_handler(1)(x)
+//     (*get_handler(1))(x)
+func getCalledExpression(n *ast.CallExpr) *ast.CallExpr {
+	var callee ast.Node = n.Children()[0]
+	for {
+		switch v := callee.(type) {
+		case *ast.CallExpr:
+			return v
+		case *ast.ParenExpr, *ast.ImplicitCastExpr:
+			callee = v.Children()[0]
+		case *ast.UnaryOperator:
+			if v.Operator != "*" {
+				return nil
+			}
+			callee = v.Children()[0]
+		default:
+			return nil
+		}
+	}
+}
+
+// transpileCallOfExpression transpiles the call of a function pointer that is
+// returned by another call. The arguments are cast to the types of the
+// function pointer.
+func transpileCallOfExpression(n, callee *ast.CallExpr, p *program.Program) (
+	_ *goast.CallExpr, resultType string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+	fun, funType, preStmts, postStmts, err := transpileToExpr(callee, p, false)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	fields, returns, err := types.ParseFunction(funType)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	args, argTypes, newPre, newPost, err := transpileCallArguments(p, n.Children()[1:], true)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+
+	for i, e := range args {
+		if i < len(fields) && fields[i] != "..." {
+			e, err = types.CastExpr(p, e, argTypes[i], fields[i])
+			if p.AddMessage(p.GenerateWarningMessage(err, n)) {
+				e = util.NewNil()
+			}
+		}
+		args[i] = e
+	}
+
+	return &goast.CallExpr{
+		Fun:  fun,
+		Args: args,
+	}, returns[0], preStmts, postStmts, nil
+}
+
+// transpileNoReturnResult replaces the result of a function that never
+// returns when it is used as a value. The Go function has no result, so the
+// call is wrapped into a function that panics when the call does return:
+//
+//     x = die(1)
+//
+// becomes:
+//
+//     x = func() int32 {
+//         die(1)
+//         panic("unreachable")
+//     }()
+func transpileNoReturnResult(n *ast.CallExpr, expr goast.Expr, cType string,
+	p *program.Program) goast.Expr {
+	call, ok := expr.(*goast.CallExpr)
+	if !ok || cType == "void" {
+		return expr
+	}
+	name, err := getNameOfFunctionFromCallExpr(p, n)
+	if err != nil {
+		return expr
+	}
+	f := p.GetFunctionDefinition(util.ConvertFunctionNameFromCtoGo(name))
+	if f == nil || !f.NoReturn {
+		return expr
+	}
+
+	p.AddMessage(p.GenerateWarningMessage(
+		fmt.Errorf("the result of noreturn function %s is used", name), n))
+
+	t, err := types.ResolveType(p, cType)
+	if p.AddMessage(p.GenerateWarningMessage(err, n)) {
+		return expr
+	}
+	return &goast.CallExpr{
+		Fun: &goast.FuncLit{
+			Type: &goast.FuncType{
+				Results: &goast.FieldList{List: []*goast.Field{
+					&goast.Field{Type: util.NewTypeIdent(t)},
+				}},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ExprStmt{X: call},
+					&goast.ExprStmt{
+						X: util.NewCallExpr("panic", util.NewStringLit(`"unreachable"`)),
+					},
+				},
+			},
+		},
+	}
 }
 
 func extractArray(expr goast.Expr) *goast.Ident {
@@ -47,6 +47,16 @@ func transpileImplicitCastExpr(n *ast.ImplicitCastExpr, p *program.Program, expr
 		return
 	}
 
+	expr = newAtomicLoad(p, n, expr)
+
+	// A jmp_buf is an array of one element in C, so it decays to the address
+	// of the buffer.
+	if n.Kind == ast.ImplicitCastExprArrayToPointerDecay && isJmpBuf(p, exprType) {
+		expr = util.NewUnaryExpr(token.AND, expr)
+		exprType = n.Type
+		return
+	}
+
 	if len(n.Type) != 0 && len(n.Type2) != 0 && n.Type != n.Type2 {
 		var tt string
 		tt, err = types.ResolveType(p, n.Type)
@@ -0,0 +1,50 @@
+package transpiler
+
+import (
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestPointerCasts(t *testing.T) {
+	// struct X { int a; int b; };
+	// int f(char *buf) {
+	//     struct X *x = (struct X *)(void *)buf;
+	//     return ((struct X *)buf)->a + x->b;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x2 <x.c:1:1, col:28> col:8 struct X definition
+| |-FieldDecl 0x3 <col:12, col:16> col:16 referenced a 'int'
+| |-FieldDecl 0x4 <col:19, col:23> col:23 referenced b 'int'
+|-FunctionDecl 0x10 <line:2:1, line:5:1> line:2:5 f 'int (char *)'
+  |-ParmVarDecl 0x11 <col:7, col:13> col:13 used buf 'char *'
+  |-CompoundStmt 0x12 <col:18, line:5:1>
+    |-DeclStmt 0x20 <line:3:5, col:42>
+    | |-VarDecl 0x21 <col:5, col:41> col:15 used x 'struct X *' cinit
+    |   |-CStyleCastExpr 0x22 <col:19, col:41> 'struct X *' <BitCast>
+    |     |-CStyleCastExpr 0x23 <col:31, col:41> 'void *' <BitCast>
+    |       |-ImplicitCastExpr 0x24 <col:38> 'char *' <LValueToRValue>
+    |         |-DeclRefExpr 0x25 <col:38> 'char *' lvalue ParmVar 0x11 'buf' 'char *'
+    |-ReturnStmt 0x30 <line:4:5, col:43>
+      |-BinaryOperator 0x31 <col:12, col:43> 'int' '+'
+        |-ImplicitCastExpr 0x32 <col:12, col:34> 'int' <LValueToRValue>
+        | |-MemberExpr 0x33 <col:12, col:34> 'int' lvalue ->a 0x3
+        |   |-ParenExpr 0x34 <col:12, col:31> 'struct X *'
+        |     |-CStyleCastExpr 0x35 <col:13, col:28> 'struct X *' <BitCast>
+        |       |-ImplicitCastExpr 0x36 <col:25> 'char *' <LValueToRValue>
+        |         |-DeclRefExpr 0x37 <col:25> 'char *' lvalue ParmVar 0x11 'buf' 'char *'
+        |-ImplicitCastExpr 0x38 <col:38, col:41> 'int' <LValueToRValue>
+          |-MemberExpr 0x39 <col:38, col:41> 'int' lvalue ->b 0x4
+            |-ImplicitCastExpr 0x3a <col:38> 'struct X *' <LValueToRValue>
+              |-DeclRefExpr 0x3b <col:38> 'struct X *' lvalue Var 0x21 'x' 'struct X *'
+`
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		`"unsafe"`,
+		"var x *X = (*X)(unsafe.Pointer(buf))",
+		"return (*((*X)(unsafe.Pointer(buf)))).a + (*x).b",
+	)
+}
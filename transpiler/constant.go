@@ -0,0 +1,170 @@
+// This file contains the evaluation of the integer expressions of C that are
+// constant, like the sizes of the arrays and the labels of the cases.
+
+package transpiler
+
+import (
+	"strconv"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+)
+
+// constantValue returns the value of an integer expression that is known
+// when the program is transpiled, like 8 for "2 * sizeof(int)" or 16 for
+// "1 << 4". The comparisons and the logical operators are 1 or 0, like in C.
+// The enum constants are looked up in p.NodeMap.
+func constantValue(p *program.Program, n ast.Node) (value int64, ok bool) {
+	switch v := removeImplicitCasts(n).(type) {
+	case *ast.IntegerLiteral:
+		value, err := strconv.ParseInt(v.Value, 10, 64)
+		return value, err == nil
+
+	case *ast.CharacterLiteral:
+		return characterValue(p, v), true
+
+	case *ast.ParenExpr:
+		return constantValue(p, v.Children()[0])
+
+	case *ast.ConstantExpr:
+		return constantValue(p, v.Children()[0])
+
+	case *ast.UnaryOperator:
+		value, ok := constantValue(p, v.Children()[0])
+		switch v.Operator {
+		case "-":
+			return -value, ok
+		case "+":
+			return value, ok
+		case "~":
+			return ^value, ok
+		case "!":
+			return boolValue(value == 0), ok
+		}
+		return 0, false
+
+	case *ast.DeclRefExpr:
+		if v.For != "EnumConstant" {
+			return 0, false
+		}
+		return enumConstantValue(p, ast.ParseAddress(v.Address2))
+
+	case *ast.ConditionalOperator:
+		c, ok := constantValue(p, v.Children()[0])
+		if !ok {
+			return 0, false
+		}
+		if c != 0 {
+			return constantValue(p, v.Children()[1])
+		}
+		return constantValue(p, v.Children()[2])
+
+	case *ast.UnaryExprOrTypeTraitExpr:
+		if v.Function != "sizeof" {
+			return 0, false
+		}
+		t := v.Type2
+		if len(v.Children()) > 0 {
+			var err error
+			if t, err = sizeofOperandType(v.Children()[0]); err != nil {
+				return 0, false
+			}
+		}
+		if _, _, isVariable := types.GetVariableArrayTypeAndSize(t); isVariable {
+			return 0, false
+		}
+		size, err := types.SizeOf(p, t)
+		return int64(size), err == nil
+
+	case *ast.OffsetOfExpr:
+		offset, err := offsetOf(p, v)
+		return int64(offset), err == nil
+
+	case *ast.BinaryOperator:
+		x, okX := constantValue(p, v.Children()[0])
+		y, okY := constantValue(p, v.Children()[1])
+		if !okX || !okY {
+			return 0, false
+		}
+		switch v.Operator {
+		case "*":
+			return x * y, true
+		case "+":
+			return x + y, true
+		case "-":
+			return x - y, true
+		case "/", "%":
+			if y == 0 {
+				return 0, false
+			}
+			if v.Operator == "/" {
+				return x / y, true
+			}
+			return x % y, true
+		case "<<", ">>":
+			if y < 0 || y > 63 {
+				return 0, false
+			}
+			if v.Operator == "<<" {
+				return x << uint(y), true
+			}
+			return x >> uint(y), true
+		case "&":
+			return x & y, true
+		case "|":
+			return x | y, true
+		case "^":
+			return x ^ y, true
+		case "==":
+			return boolValue(x == y), true
+		case "!=":
+			return boolValue(x != y), true
+		case "<":
+			return boolValue(x < y), true
+		case "<=":
+			return boolValue(x <= y), true
+		case ">":
+			return boolValue(x > y), true
+		case ">=":
+			return boolValue(x >= y), true
+		case "&&":
+			return boolValue(x != 0 && y != 0), true
+		case "||":
+			return boolValue(x != 0 || y != 0), true
+		}
+	}
+
+	return 0, false
+}
+
+// boolValue returns the value of a condition in C, that is 1 or 0.
+func boolValue(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// enumConstantValue returns the value of the enum constant at the address. A
+// constant without an initializer is one more than the constant before it,
+// the first one is 0.
+func enumConstantValue(p *program.Program, addr ast.Address) (int64, bool) {
+	constant, ok := p.NodeMap[addr].(*ast.EnumConstantDecl)
+	if !ok {
+		return 0, false
+	}
+	if len(constant.Children()) > 0 {
+		return constantValue(p, constant.Children()[0])
+	}
+
+	previous, ok := p.PreviousEnumConstant[addr]
+	if !ok {
+		return 0, false
+	}
+	if previous == 0 {
+		return 0, true
+	}
+	value, ok := enumConstantValue(p, previous)
+	return value + 1, ok
+}
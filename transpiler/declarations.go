@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	goast "go/ast"
+	"go/parser"
 	"go/token"
 	"strings"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/elliotchance/c2go/program"
 	"github.com/elliotchance/c2go/types"
 	"github.com/elliotchance/c2go/util"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 func newFunctionField(p *program.Program, name, cType string) (_ *goast.Field, err error) {
@@ -28,7 +30,7 @@ func newFunctionField(p *program.Program, name, cType string) (_ *goast.Field, e
 
 	field := &goast.Field{
 		Names: []*goast.Ident{
-			util.NewIdent(name),
+			util.NewIdent(p.GoIdentifier(name)),
 		},
 	}
 	var arg, ret []string
@@ -83,12 +85,7 @@ func transpileFieldDecl(p *program.Program, n *ast.FieldDecl) (field *goast.Fiel
 	fieldType, err := types.ResolveType(p, n.Type)
 	p.AddMessage(p.GenerateWarningMessage(err, n))
 
-	// TODO: The name of a variable or field cannot be a reserved word
-	// https://github.com/elliotchance/c2go/issues/83
-	// Search for this issue in other areas of the codebase.
-	if util.IsGoKeyword(name) {
-		name += "_"
-	}
+	name = p.GoIdentifier(name)
 
 	arrayType, arraySize := types.GetArrayTypeAndSize(n.Type)
 	if arraySize != -1 {
@@ -104,6 +101,46 @@ func transpileFieldDecl(p *program.Program, n *ast.FieldDecl) (field *goast.Fiel
 	}, nil
 }
 
+// transpileAnonymousFieldDecl transpiles the unnamed field of an anonymous
+// struct or union member:
+//
+//     struct s {
+//         int a;
+//         struct { int x, y; };
+//     };
+//
+// In C its members are members of the enclosing struct, like s.x. A Go struct
+// embeds the type of the member, so that its fields and the methods of an
+// union are promoted in the same way. The fields of an union are methods, so in
+// an union the field is named after its type and it is accessed with the
+// method of that name.
+func transpileAnonymousFieldDecl(p *program.Program, n *ast.FieldDecl, inUnion bool) (
+	*goast.Field, error) {
+	fieldType, err := types.ResolveType(p, n.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	field := &goast.Field{Type: util.NewTypeIdent(fieldType)}
+	if inUnion {
+		field.Names = []*goast.Ident{util.NewIdent(fieldType)}
+	}
+
+	return field, nil
+}
+
+// getFieldName returns the Go name of a field of a struct. The field of an
+// anonymous member is named after its type, see transpileAnonymousFieldDecl.
+func getFieldName(p *program.Program, s *program.Struct, name string) string {
+	if s.IsAnonymousField(name) {
+		if fieldType, err := types.ResolveType(p, name); err == nil {
+			return fieldType
+		}
+	}
+
+	return p.GoIdentifier(name)
+}
+
 func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.Decl, err error) {
 	name := n.Name
 
@@ -125,12 +162,35 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 	}
 
 	var fields []*goast.Field
+	var methods []goast.Decl
+	packer := bitfields{p: p, structName: name}
 
 	for pos := range n.Children() {
 		switch field := n.Children()[pos].(type) {
 		case *ast.FieldDecl:
 			field.Type = types.GenerateCorrectType(field.Type)
 			field.Type2 = types.GenerateCorrectType(field.Type2)
+			if width, ok := program.BitfieldWidth(field); ok && n.Kind != "union" {
+				f, m, err := packer.add(field, width)
+				if err != nil {
+					p.AddMessage(p.GenerateWarningMessage(err, field))
+				}
+				if f != nil {
+					fields = append(fields, f)
+				}
+				methods = append(methods, m...)
+				continue
+			}
+			packer.end()
+			if field.Name == "" && field.Implicit {
+				f, err := transpileAnonymousFieldDecl(p, field, n.Kind == "union")
+				if err != nil {
+					p.AddMessage(p.GenerateWarningMessage(err, field))
+				} else {
+					fields = append(fields, f)
+				}
+				continue
+			}
 			f, err := transpileFieldDecl(p, field)
 			if err != nil {
 				p.AddMessage(p.GenerateWarningMessage(err, field))
@@ -192,6 +252,14 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 				}
 			}
 
+		case *ast.MaxFieldAlignmentAttr, *ast.PackedAttr:
+			// The packing only changes the layout of the C struct, see
+			// program.Struct.Pack.
+
+		case *ast.IndirectFieldDecl:
+			// A member of an anonymous struct or union, that is accessed
+			// through the field of the anonymous member.
+
 		case *ast.FullComment:
 			// We haven't Go ast struct for easy inject a comments.
 			// All comments are added like CommentsGroup.
@@ -212,8 +280,11 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 	}
 	if s.IsUnion {
 		// Union size
-		var size int
+		var size, align int
 		size, err = types.SizeOf(p, "union "+name)
+		if err == nil {
+			align, err = types.AlignOf(p, "union "+name)
+		}
 
 		// In normal case no error is returned,
 		if err != nil {
@@ -225,8 +296,17 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 			// Add imports needed
 			p.AddImports("unsafe")
 
+			// The pointers of the members must be in memory that the garbage
+			// collector scans, which is only allocated behind a pointer.
+			memory := p.UnionMemory
+			if memory != program.UnionMemoryPointer && structHasPointers(p, s, map[string]bool{}) {
+				p.AddMessage(p.GenerateWarningMessage(fmt.Errorf(
+					"union %s has pointer members, its memory is allocated behind a pointer", name), n))
+				memory = program.UnionMemoryPointer
+			}
+
 			// Declaration for implementing union type
-			d, err2 := transpileUnion(name, size, fields)
+			d, err2 := transpileUnion(p, name, memory, size, align, fields)
 			if err2 != nil {
 				return nil, err2
 			}
@@ -248,6 +328,7 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 			},
 		},
 	})
+	decls = append(decls, methods...)
 
 	return
 }
@@ -310,13 +391,17 @@ func transpileTypedefDecl(p *program.Program, n *ast.TypedefDecl) (decls []goast
 		return
 	}
 
-	if p.IsTypeAlreadyDefined(name) {
+	// A typedef in a function is a type declaration in the Go function, that
+	// can shadow a type of the file.
+	if p.Function != nil {
+		p.DefineLocalType(name)
+	} else if p.IsTypeAlreadyDefined(name) {
 		err = nil
 		return
+	} else {
+		p.DefineType(name)
 	}
 
-	p.DefineType(name)
-
 	resolvedType, err := types.ResolveType(p, n.Type)
 	if err != nil {
 		p.AddMessage(p.GenerateWarningMessage(err, n))
@@ -348,10 +433,9 @@ func transpileTypedefDecl(p *program.Program, n *ast.TypedefDecl) (decls []goast
 	}
 
 	if name == "div_t" || name == "ldiv_t" || name == "lldiv_t" {
+		// The fields of noarch.LdivT are int32, whatever the width of long is.
 		intType := "int"
-		if name == "ldiv_t" {
-			intType = "long int"
-		} else if name == "lldiv_t" {
+		if name == "lldiv_t" {
 			intType = "long long int"
 		}
 
@@ -368,6 +452,7 @@ func transpileTypedefDecl(p *program.Program, n *ast.TypedefDecl) (decls []goast
 				"quot": intType,
 				"rem":  intType,
 			},
+			FieldNames: []string{"quot", "rem"},
 		}
 	}
 
@@ -375,17 +460,20 @@ func transpileTypedefDecl(p *program.Program, n *ast.TypedefDecl) (decls []goast
 	if resolvedType == "" {
 		resolvedType = "interface{}"
 	}
+	spec := &goast.TypeSpec{
+		Name: util.NewIdent(name),
+		Type: util.NewTypeIdent(resolvedType),
+	}
 	decls = append(decls, &goast.GenDecl{
-		Tok: token.TYPE,
-		Specs: []goast.Spec{
-			&goast.TypeSpec{
-				Name: util.NewIdent(name),
-				Type: util.NewTypeIdent(resolvedType),
-			},
-		},
+		Tok:   token.TYPE,
+		Specs: []goast.Spec{spec},
 	})
 
 	if v, ok := p.Structs["struct "+resolvedType]; ok {
+		// The methods of the bit-fields are kept by an alias.
+		if len(v.Bitfields) > 0 {
+			spec.Assign = 1
+		}
 		// Registration "typedef struct" with non-empty name of struct
 		p.Structs["struct "+name] = v
 	} else if v, ok := p.EnumConstantToEnum["enum "+resolvedType]; ok {
@@ -400,18 +488,21 @@ func transpileTypedefDecl(p *program.Program, n *ast.TypedefDecl) (decls []goast
 }
 
 func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
-	decls []goast.Decl, theType string, err error) {
+	decls []goast.Decl, theType string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("Cannot transpileVarDecl : err = %v", err)
 		}
 	}()
+	n.Type = types.GenerateCorrectType(n.Type)
+	n.Type2 = types.GenerateCorrectType(n.Type2)
+
 	// There may be some startup code for this global variable.
 	if p.Function == nil {
 		name := n.Name
 		switch name {
 		// Below are for macOS.
-		case "__stdinp", "__stdoutp":
+		case "__stdinp", "__stdoutp", "__stderrp":
 			theType = "*noarch.File"
 			p.AddImport("github.com/elliotchance/c2go/noarch")
 			p.AppendStartupExpr(
@@ -430,7 +521,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 					Type:  util.NewTypeIdent(theType),
 					Doc:   p.GetMessageComments(),
 				}},
-			}}, "", nil
+			}}, "", nil, nil, nil
 
 		// Below are for linux.
 		case "stdout", "stdin", "stderr":
@@ -452,14 +543,15 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 					Type:  util.NewTypeIdent(theType),
 				}},
 				Doc: p.GetMessageComments(),
-			}}, "", nil
+			}}, "", nil, nil, nil
 
 		default:
 			// No init needed.
 		}
 	}
 
-	// Ignore extern as there is no analogy for Go right now.
+	// An extern declaration does not define the variable, it is defined by
+	// another declaration. See removeVariableRedeclarations().
 	if n.IsExtern && len(n.ChildNodes) == 0 {
 		return
 	}
@@ -473,6 +565,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 		// Result:
 		// ... - convert to - c2goArgs ...interface{}
 		// var args = c2goArgs
+		p.AddImport("github.com/elliotchance/c2go/noarch")
 		return []goast.Decl{&goast.GenDecl{
 			Tok: token.VAR,
 			Specs: []goast.Spec{
@@ -481,7 +574,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 					Values: []goast.Expr{util.NewVaListTag()},
 				},
 			},
-		}}, "", nil
+		}}, "", nil, nil, nil
 	}
 
 	/*
@@ -507,11 +600,11 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 						return
 					}
 					functionType := GenerateFuncType(fields, returns)
-					nameVar1 := n.Name
+					nameVar1 := p.GoIdentifier(n.Name)
 
 					if vv, ok := v.Children()[0].(*ast.ImplicitCastExpr); ok {
 						if decl, ok := vv.Children()[0].(*ast.DeclRefExpr); ok {
-							nameVar2 := decl.Name
+							nameVar2 := p.GoIdentifier(decl.Name)
 
 							return []goast.Decl{&goast.GenDecl{
 								Tok: token.VAR,
@@ -528,7 +621,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 									}},
 									Doc: p.GetMessageComments(),
 								},
-								}}}, "", nil
+								}}}, "", nil, nil, nil
 						}
 					}
 				}
@@ -545,13 +638,27 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 			return
 		}
 		functionType := GenerateFuncType(fields, returns)
-		nameVar1 := n.Name
+		nameVar1 := p.GoIdentifier(n.Name)
+
+		// A function that initializes the function pointer is a Go function
+		// value, like "var fp func(int32) int32 = add".
+		var values []goast.Expr
+		if len(n.Children()) > 0 {
+			value, _, newPre, newPost, err := transpileToExpr(n.Children()[0], p, false)
+			if err != nil {
+				return nil, "", nil, nil, err
+			}
+			preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+			values = []goast.Expr{value}
+		}
+
 		decls = append(decls, &goast.GenDecl{
 			Tok: token.VAR,
 			Specs: []goast.Spec{&goast.ValueSpec{
-				Names: []*goast.Ident{{Name: nameVar1}},
-				Type:  functionType,
-				Doc:   p.GetMessageComments(),
+				Names:  []*goast.Ident{{Name: nameVar1}},
+				Type:   functionType,
+				Values: values,
+				Doc:    p.GetMessageComments(),
 			},
 			}})
 		err = nil
@@ -570,8 +677,6 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 	p.GlobalVariables[n.Name] = theType
 
 	name := n.Name
-	preStmts := []goast.Stmt{}
-	postStmts := []goast.Stmt{}
 
 	// TODO: Some platform structs are ignored.
 	// https://github.com/elliotchance/c2go/issues/85
@@ -617,18 +722,34 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 		} else {
 			if iniList, ok := n.Children()[0].(*ast.InitListExpr); ok {
 				var list goast.Expr
-				list, _, err = transpileInitListExpr(iniList, p)
+				list, _, newPre, newPost, err = transpileInitListExpr(iniList, p)
 				if err != nil {
 					p.AddMessage(p.GenerateErrorMessage(err, n))
 					err = nil // Error is ignored
 				} else {
 					defaultValue = []goast.Expr{list}
+					preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 				}
 			}
 		}
 	}
 
-	if len(preStmts) != 0 || len(postStmts) != 0 {
+	// A variable-length array is allocated with the size at the declaration.
+	if elementType, size, ok := types.GetVariableArrayTypeAndSize(n.Type); ok &&
+		defaultValue == nil && len(n.Children()) == 0 {
+		var alloc goast.Expr
+		alloc, err = newVariableArray(p, elementType, size)
+		if err != nil {
+			p.AddMessage(p.GenerateErrorMessage(err, n))
+			err = nil // Error is ignored
+		} else {
+			defaultValue = []goast.Expr{alloc}
+		}
+	}
+
+	// The statements are only returned for a local variable, they are placed
+	// before and after its declaration by transpileDeclStmt().
+	if p.Function == nil && (len(preStmts) != 0 || len(postStmts) != 0) {
 		p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Not acceptable length of Stmt : pre(%d), post(%d)", len(preStmts), len(postStmts)), n))
 	}
 
@@ -643,11 +764,102 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 		Tok: token.VAR,
 		Specs: []goast.Spec{
 			&goast.ValueSpec{
-				Names:  []*goast.Ident{util.NewIdent(n.Name)},
+				Names:  []*goast.Ident{util.NewIdent(p.GoIdentifier(n.Name))},
 				Type:   typeResult,
 				Values: defaultValue,
 				Doc:    p.GetMessageComments(),
 			},
 		},
-	}}, "", nil
+	}}, "", preStmts, postStmts, nil
+}
+
+// newVariableArray returns the allocation of a variable-length array, like:
+//
+//     int buf[n * 2];
+//
+//     var buf []int32 = make([]int32, int(n*2))
+//
+// The size is only available as the C source in the type of the array, so it
+// is parsed as a Go expression. Only the names of the variables, integers and
+// the arithmetic operators are allowed in the size.
+func newVariableArray(p *program.Program, elementType, size string) (
+	_ goast.Expr, err error) {
+	if _, arraySize := types.GetArrayTypeAndSize(elementType); arraySize != -1 {
+		return nil, fmt.Errorf("multidimensional variable-length arrays are not supported: `%s [%s]`", elementType, size)
+	}
+
+	goType, err := types.ResolveType(p, elementType)
+	if err != nil {
+		return nil, err
+	}
+
+	sizeExpr, err := parser.ParseExpr(size)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the size of the variable-length array `%s`: %v", size, err)
+	}
+
+	goast.Inspect(sizeExpr, func(node goast.Node) bool {
+		switch n := node.(type) {
+		case *goast.Ident:
+			n.Name = p.GoIdentifier(n.Name)
+		case *goast.BasicLit:
+			if n.Kind != token.INT {
+				err = fmt.Errorf("the size of the variable-length array `%s` is not an integer", size)
+			}
+		case *goast.BinaryExpr, *goast.UnaryExpr, *goast.ParenExpr, nil:
+		default:
+			err = fmt.Errorf("cannot transpile the size of the variable-length array `%s`", size)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The enum constants have the type of their enum in Go, but they are int
+	// in C, like the size "n + LARGE".
+	sizeExpr = astutil.Apply(sizeExpr, nil, func(c *astutil.Cursor) bool {
+		if ident, ok := c.Node().(*goast.Ident); ok {
+			if _, isEnum := p.EnumConstantToEnum[ident.Name]; isEnum {
+				c.Replace(util.NewCallExpr("int32", ident))
+			}
+		}
+		return true
+	}).(goast.Expr)
+
+	return util.NewCallExpr("make",
+		&goast.ArrayType{Elt: util.NewTypeIdent(goType)},
+		util.NewCallExpr("int", sizeExpr),
+	), nil
+}
+
+// transpileStaticAssertDecl checks a static assertion:
+//
+//     _Static_assert(sizeof(int) == 4, "int must be 32 bits");
+//
+// Go does not have static assertions, so nothing is transpiled. The condition
+// is evaluated with the sizes of the target ABI instead, and a false condition
+// is an error. A condition that cannot be evaluated is only a warning.
+func transpileStaticAssertDecl(p *program.Program, n *ast.StaticAssertDecl) (err error) {
+	children := n.Children()
+	if len(children) == 0 {
+		return fmt.Errorf("StaticAssertDecl has no condition")
+	}
+
+	value, ok := constantValue(p, children[0])
+	if !ok {
+		p.AddMessage(p.GenerateWarningMessage(
+			errors.New("cannot evaluate the condition of the static assertion, it will be ignored"), n))
+		return nil
+	}
+	if value != 0 {
+		return nil
+	}
+
+	if len(children) > 1 {
+		if message, ok := children[1].(*ast.StringLiteral); ok {
+			return fmt.Errorf("static assertion failed: %q", message.Value)
+		}
+	}
+	return fmt.Errorf("static assertion failed")
 }
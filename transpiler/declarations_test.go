@@ -0,0 +1,776 @@
+package transpiler
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+)
+
+func TestAnonymousMembers(t *testing.T) {
+	// struct s {
+	//     int a;
+	//     struct { int x, y; };
+	//     union { int u; float f; };
+	//     union { char c; short h; };
+	// };
+	// int main() {
+	//     struct s v;
+	//     v.x = 1;
+	//     v.h = 2;
+	//     return v.x + v.a;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x10 <x.c:1:1, line:6:1> line:1:8 struct s definition
+| |-FieldDecl 0x11 <line:2:5, col:9> col:9 referenced a 'int'
+| |-RecordDecl 0x12 <line:3:5, col:29> col:5 struct definition
+| | |-FieldDecl 0x13 <col:14, col:18> col:18 referenced x 'int'
+| | |-FieldDecl 0x14 <col:21, col:25> col:25 y 'int'
+| |-FieldDecl 0x15 <col:5> col:5 implicit referenced 'struct s::(anonymous at x.c:3:5)'
+| |-IndirectFieldDecl 0x16 <col:18> col:18 implicit x 'int'
+| | |-Field 0x15 '' 'struct s::(anonymous at x.c:3:5)'
+| | |-Field 0x13 'x' 'int'
+| |-IndirectFieldDecl 0x17 <col:25> col:25 implicit y 'int'
+| | |-Field 0x15 '' 'struct s::(anonymous at x.c:3:5)'
+| | |-Field 0x14 'y' 'int'
+| |-RecordDecl 0x20 <line:4:5, col:31> col:5 union definition
+| | |-FieldDecl 0x21 <col:13, col:17> col:17 u 'int'
+| | |-FieldDecl 0x22 <col:20, col:26> col:26 f 'float'
+| |-FieldDecl 0x23 <col:5> col:5 implicit 'union s::(anonymous at x.c:4:5)'
+| |-IndirectFieldDecl 0x24 <col:17> col:17 implicit u 'int'
+| | |-Field 0x23 '' 'union s::(anonymous at x.c:4:5)'
+| | |-Field 0x21 'u' 'int'
+| |-IndirectFieldDecl 0x25 <col:26> col:26 implicit f 'float'
+| | |-Field 0x23 '' 'union s::(anonymous at x.c:4:5)'
+| | |-Field 0x22 'f' 'float'
+| |-RecordDecl 0x30 <line:5:5, col:32> col:5 union definition
+| | |-FieldDecl 0x31 <col:13, col:18> col:18 c 'char'
+| | |-FieldDecl 0x32 <col:21, col:27> col:27 referenced h 'short'
+| |-FieldDecl 0x33 <col:5> col:5 implicit referenced 'union s::(anonymous at x.c:5:5)'
+| |-IndirectFieldDecl 0x34 <col:18> col:18 implicit c 'char'
+| | |-Field 0x33 '' 'union s::(anonymous at x.c:5:5)'
+| | |-Field 0x31 'c' 'char'
+| |-IndirectFieldDecl 0x35 <col:27> col:27 implicit h 'short'
+|   |-Field 0x33 '' 'union s::(anonymous at x.c:5:5)'
+|   |-Field 0x32 'h' 'short'
+|-FunctionDecl 0x40 <line:7:1, line:12:1> line:7:5 main 'int ()'
+  |-CompoundStmt 0x41 <col:12, line:12:1>
+    |-DeclStmt 0x42 <line:8:5, col:15>
+    | |-VarDecl 0x43 <col:5, col:14> col:14 used v 'struct s':'struct s'
+    |-BinaryOperator 0x44 <line:9:5, col:11> 'int' '='
+    | |-MemberExpr 0x45 <col:5, col:7> 'int' lvalue .x 0x13
+    | | |-MemberExpr 0x46 <col:5> 'struct s::(anonymous at x.c:3:5)' lvalue . 0x15
+    | |   |-DeclRefExpr 0x47 <col:5> 'struct s':'struct s' lvalue Var 0x43 'v' 'struct s':'struct s'
+    | |-IntegerLiteral 0x48 <col:11> 'int' 1
+    |-BinaryOperator 0x50 <line:10:5, col:11> 'short' '='
+    | |-MemberExpr 0x51 <col:5, col:7> 'short' lvalue .h 0x32
+    | | |-MemberExpr 0x52 <col:5> 'union s::(anonymous at x.c:5:5)' lvalue . 0x33
+    | |   |-DeclRefExpr 0x53 <col:5> 'struct s':'struct s' lvalue Var 0x43 'v' 'struct s':'struct s'
+    | |-ImplicitCastExpr 0x54 <col:11> 'short' <IntegralCast>
+    |   |-IntegerLiteral 0x55 <col:11> 'int' 2
+    |-ReturnStmt 0x60 <line:11:5, col:16>
+      |-BinaryOperator 0x61 <col:12, col:16> 'int' '+'
+        |-ImplicitCastExpr 0x62 <col:12, col:14> 'int' <LValueToRValue>
+        | |-MemberExpr 0x63 <col:12, col:14> 'int' lvalue .x 0x13
+        |   |-MemberExpr 0x64 <col:12> 'struct s::(anonymous at x.c:3:5)' lvalue . 0x15
+        |     |-DeclRefExpr 0x65 <col:12> 'struct s':'struct s' lvalue Var 0x43 'v' 'struct s':'struct s'
+        |-ImplicitCastExpr 0x66 <col:16> 'int' <LValueToRValue>
+          |-MemberExpr 0x67 <col:16> 'int' lvalue .a 0x11
+            |-DeclRefExpr 0x68 <col:16> 'struct s':'struct s' lvalue Var 0x43 'v' 'struct s':'struct s'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"type s struct {\n\ta int32\n\tsDDBSatSxPcD3D5E\n\tsDDBSatSxPcD4D5E\n\tsDDBSatSxPcD5D5E\n}",
+		"v.x = int32(1)",
+		"(*v.h()) = int16(int32(2))",
+		"os.Exit(int(v.x + v.a))",
+	)
+	if strings.Contains(output, "Warning") {
+		t.Errorf("Unexpected warnings in:\n%s", output)
+	}
+
+	// Both anonymous unions have storage.
+	size, err := types.SizeOf(p, "struct s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 20 {
+		t.Errorf("Expected sizeof(struct s) = 20, got %d", size)
+	}
+}
+
+func TestAnonymousMemberInitList(t *testing.T) {
+	// struct s {
+	//     int a;
+	//     struct { int x, y; };
+	// };
+	// int main() {
+	//     struct s v = {1, {2, 3}};
+	//     struct s w = {.y = 3};
+	//     return v.y + w.y;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x10 <x.c:1:1, line:4:1> line:1:8 struct s definition
+| |-FieldDecl 0x11 <line:2:5, col:9> col:9 referenced a 'int'
+| |-RecordDecl 0x12 <line:3:5, col:29> col:5 struct definition
+| | |-FieldDecl 0x13 <col:14, col:18> col:18 referenced x 'int'
+| | |-FieldDecl 0x14 <col:21, col:25> col:25 referenced y 'int'
+| |-FieldDecl 0x15 <col:5> col:5 implicit referenced 'struct s::(anonymous at x.c:3:5)'
+| |-IndirectFieldDecl 0x16 <col:18> col:18 implicit x 'int'
+| | |-Field 0x15 '' 'struct s::(anonymous at x.c:3:5)'
+| | |-Field 0x13 'x' 'int'
+| |-IndirectFieldDecl 0x17 <col:25> col:25 implicit y 'int'
+|   |-Field 0x15 '' 'struct s::(anonymous at x.c:3:5)'
+|   |-Field 0x14 'y' 'int'
+|-FunctionDecl 0x40 <line:5:1, line:8:1> line:5:5 main 'int ()'
+  |-CompoundStmt 0x41 <col:12, line:8:1>
+    |-DeclStmt 0x42 <line:6:5, col:30>
+    | |-VarDecl 0x43 <col:5, col:29> col:14 used v 'struct s':'struct s' cinit
+    |   |-InitListExpr 0x44 <col:18, col:29> 'struct s':'struct s'
+    |     |-IntegerLiteral 0x45 <col:19> 'int' 1
+    |     |-InitListExpr 0x46 <col:22, col:27> 'struct s::(anonymous at x.c:3:5)':'struct s::(anonymous at x.c:3:5)'
+    |       |-IntegerLiteral 0x47 <col:23> 'int' 2
+    |       |-IntegerLiteral 0x48 <col:26> 'int' 3
+    |-DeclStmt 0x50 <line:7:5, col:30>
+    | |-VarDecl 0x51 <col:5, col:29> col:14 used w 'struct s':'struct s' cinit
+    |   |-InitListExpr 0x52 <col:18, col:29> 'struct s':'struct s'
+    |     |-ImplicitValueInitExpr 0x53 <<invalid sloc>> 'int'
+    |     |-InitListExpr 0x54 <col:22, col:27> 'struct s::(anonymous at x.c:3:5)':'struct s::(anonymous at x.c:3:5)'
+    |       |-ImplicitValueInitExpr 0x55 <<invalid sloc>> 'int'
+    |       |-IntegerLiteral 0x56 <col:26> 'int' 3
+    |-ReturnStmt 0x60 <line:8:5, col:16>
+      |-BinaryOperator 0x61 <col:12, col:16> 'int' '+'
+        |-ImplicitCastExpr 0x62 <col:12, col:14> 'int' <LValueToRValue>
+        | |-MemberExpr 0x63 <col:12, col:14> 'int' lvalue .y 0x14
+        |   |-MemberExpr 0x64 <col:12> 'struct s::(anonymous at x.c:3:5)' lvalue . 0x15
+        |     |-DeclRefExpr 0x65 <col:12> 'struct s':'struct s' lvalue Var 0x43 'v' 'struct s':'struct s'
+        |-ImplicitCastExpr 0x66 <col:16> 'int' <LValueToRValue>
+          |-MemberExpr 0x67 <col:16> 'int' lvalue .y 0x14
+            |-MemberExpr 0x68 <col:12> 'struct s::(anonymous at x.c:3:5)' lvalue . 0x15
+              |-DeclRefExpr 0x69 <col:16> 'struct s':'struct s' lvalue Var 0x51 'w' 'struct s':'struct s'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"var v s = s{int32(1), sDDBSatSxPcD3D5E{int32(2), int32(3)}}",
+		"var w s = s{sDDBSatSxPcD3D5E: sDDBSatSxPcD3D5E{y: int32(3)}}",
+		"os.Exit(int(v.y + w.y))",
+	)
+}
+
+func TestStaticAssertDecl(t *testing.T) {
+	// _Static_assert(sizeof(int) == 4, "int must be 32 bits");
+	// int main() {
+	//     _Static_assert(sizeof(long) == 4, "long must be 32 bits");
+	//     return 0;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-StaticAssertDecl 0x10 <x.c:1:1, col:55> col:1
+| |-ImplicitCastExpr 0x11 <col:16, col:31> '_Bool' <IntegralToBoolean>
+| | |-BinaryOperator 0x12 <col:16, col:31> 'int' '=='
+| |   |-UnaryExprOrTypeTraitExpr 0x13 <col:16, col:26> 'unsigned long' sizeof 'int'
+| |   |-ImplicitCastExpr 0x14 <col:31> 'unsigned long' <IntegralCast>
+| |     |-IntegerLiteral 0x15 <col:31> 'int' 4
+| |-StringLiteral 0x16 <col:34> 'char [20]' lvalue "int must be 32 bits"
+|-FunctionDecl 0x20 <line:2:1, line:5:1> line:2:5 main 'int ()'
+  |-CompoundStmt 0x21 <col:12, line:5:1>
+    |-DeclStmt 0x22 <line:3:5, col:62>
+    | |-StaticAssertDecl 0x30 <col:5, col:61> col:5
+    |   |-ImplicitCastExpr 0x31 <col:20, col:36> '_Bool' <IntegralToBoolean>
+    |   | |-BinaryOperator 0x32 <col:20, col:36> 'int' '=='
+    |   |   |-UnaryExprOrTypeTraitExpr 0x33 <col:20, col:31> 'unsigned long' sizeof 'long'
+    |   |   |-ImplicitCastExpr 0x34 <col:36> 'unsigned long' <IntegralCast>
+    |   |     |-IntegerLiteral 0x35 <col:36> 'int' 4
+    |   |-StringLiteral 0x36 <col:39> 'char [21]' lvalue "long must be 32 bits"
+    |-ReturnStmt 0x40 <line:4:5, col:12>
+      |-IntegerLiteral 0x41 <col:12> 'int' 0
+`
+
+	for _, test := range []struct {
+		abi    *program.ABI
+		failed bool
+	}{
+		{program.LP64, true},
+		{program.ILP32, false},
+	} {
+		t.Run(test.abi.Name, func(t *testing.T) {
+			p := program.NewProgram()
+			p.ABI = test.abi
+			output := transpileDump(t, p, dump)
+
+			if strings.Contains(output, "int must be 32 bits") {
+				t.Errorf("Unexpected failure of the passing assertion in:\n%s", output)
+			}
+			failure := `static assertion failed: "long must be 32 bits"`
+			if strings.Contains(output, failure) != test.failed {
+				t.Errorf("Expected failure %v of %q in:\n%s", test.failed, failure, output)
+			}
+			if strings.Contains(output, "_Static_assert") || strings.Contains(output, "Warning") {
+				t.Errorf("Unexpected static assertion in:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestLocalTypedef(t *testing.T) {
+	// typedef int T;
+	// void f() {
+	//     typedef double T;
+	//     T x = 1.5;
+	// }
+	// int main() {
+	//     typedef short S;
+	//     S y = 2;
+	//     T z = y;
+	//     return z;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-TypedefDecl 0x10 <x.c:1:1, col:13> col:13 referenced T 'int'
+| |-BuiltinType 0x11 'int'
+|-FunctionDecl 0x20 <line:2:1, line:5:1> line:2:6 f 'void ()'
+| |-CompoundStmt 0x21 <col:10, line:5:1>
+|   |-DeclStmt 0x22 <line:3:5, col:21>
+|   | |-TypedefDecl 0x23 <col:5, col:20> col:20 referenced T 'double'
+|   |   |-BuiltinType 0x24 'double'
+|   |-DeclStmt 0x25 <line:4:5, col:14>
+|     |-VarDecl 0x26 <col:5, col:11> col:7 x 'T':'double' cinit
+|       |-FloatingLiteral 0x27 <col:11> 'double' 1.500000e+00
+|-FunctionDecl 0x30 <line:6:1, line:11:1> line:6:5 main 'int ()'
+  |-CompoundStmt 0x31 <col:12, line:11:1>
+    |-DeclStmt 0x32 <line:7:5, col:20>
+    | |-TypedefDecl 0x33 <col:5, col:19> col:19 referenced S 'short'
+    |   |-BuiltinType 0x34 'short'
+    |-DeclStmt 0x35 <line:8:5, col:14>
+    | |-VarDecl 0x36 <col:5, col:11> col:7 used y 'S':'short' cinit
+    |   |-ImplicitCastExpr 0x37 <col:11> 'S':'short' <IntegralCast>
+    |     |-IntegerLiteral 0x38 <col:11> 'int' 2
+    |-DeclStmt 0x39 <line:9:5, col:14>
+    | |-VarDecl 0x3a <col:5, col:11> col:7 used z 'T':'int' cinit
+    |   |-ImplicitCastExpr 0x3b <col:11> 'T':'int' <IntegralCast>
+    |     |-ImplicitCastExpr 0x3c <col:11> 'S':'short' <LValueToRValue>
+    |       |-DeclRefExpr 0x3d <col:11> 'S':'short' lvalue Var 0x36 'y' 'S':'short'
+    |-ReturnStmt 0x3e <line:10:5, col:12>
+      |-ImplicitCastExpr 0x3f <col:12> 'T':'int' <LValueToRValue>
+        |-DeclRefExpr 0x40 <col:12> 'T':'int' lvalue Var 0x3a 'z' 'T':'int'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"type T int32\n",
+		"func f() {\n\ttype T float64\n\tvar x T = T(1.5)\n}",
+		"\ttype S int16\n\tvar y S = S(int32(2))\n",
+	)
+
+	// The typedef of f() only shadows the one of the file in f().
+	if p.TypedefType["T"] != "int" {
+		t.Errorf("Expected T to be int after f(), got %q", p.TypedefType["T"])
+	}
+	if _, ok := p.TypedefType["S"]; ok {
+		t.Errorf("Expected S to be removed after main()")
+	}
+}
+
+func TestConstGlobalArrays(t *testing.T) {
+	// extern const int table[];
+	// extern const char *const msgs[];
+	// int f(int i) { return table[i] + msgs[i][0]; }
+	// const int table[] = {1, 2, 3};
+	// const char *const msgs[] = {"hi", "bye"};
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x5 <x.c:1:1, col:25> col:18 used table 'const int []' extern
+|-VarDecl 0x6 <line:2:1, col:32> col:26 used msgs 'const char *const []' extern
+|-FunctionDecl 0x30 <line:3:1, col:48> col:5 f 'int (int)'
+| |-ParmVarDecl 0x31 <col:7, col:11> col:11 used i 'int'
+| |-CompoundStmt 0x32 <col:14, col:48>
+|   |-ReturnStmt 0x33 <col:16, col:45>
+|     |-BinaryOperator 0x34 <col:23, col:45> 'int' '+'
+|       |-ImplicitCastExpr 0x35 <col:23, col:30> 'int' <LValueToRValue>
+|       | |-ArraySubscriptExpr 0x36 <col:23, col:30> 'const int' lvalue
+|       |   |-ImplicitCastExpr 0x37 <col:23> 'const int *' <ArrayToPointerDecay>
+|       |   | |-DeclRefExpr 0x38 <col:23> 'const int []' lvalue Var 0x5 'table' 'const int []'
+|       |   |-ImplicitCastExpr 0x39 <col:29> 'int' <LValueToRValue>
+|       |     |-DeclRefExpr 0x3a <col:29> 'int' lvalue ParmVar 0x31 'i' 'int'
+|       |-ImplicitCastExpr 0x3b <col:34, col:45> 'int' <IntegralCast>
+|         |-ImplicitCastExpr 0x3c <col:34, col:45> 'char' <LValueToRValue>
+|           |-ArraySubscriptExpr 0x3d <col:34, col:45> 'const char' lvalue
+|             |-ImplicitCastExpr 0x3e <col:34, col:40> 'const char *' <LValueToRValue>
+|             | |-ArraySubscriptExpr 0x3f <col:34, col:40> 'const char *const' lvalue
+|             |   |-ImplicitCastExpr 0x40 <col:34> 'const char *const *' <ArrayToPointerDecay>
+|             |   | |-DeclRefExpr 0x41 <col:34> 'const char *const []' lvalue Var 0x6 'msgs' 'const char *const []'
+|             |   |-ImplicitCastExpr 0x42 <col:39> 'int' <LValueToRValue>
+|             |     |-DeclRefExpr 0x43 <col:39> 'int' lvalue ParmVar 0x31 'i' 'int'
+|             |-IntegerLiteral 0x44 <col:44> 'int' 0
+|-VarDecl 0x10 prev 0x5 <line:4:1, col:29> col:11 used table 'const int [3]' cinit
+| |-InitListExpr 0x11 <col:21, col:29> 'const int [3]'
+|   |-IntegerLiteral 0x12 <col:22> 'int' 1
+|   |-IntegerLiteral 0x13 <col:25> 'int' 2
+|   |-IntegerLiteral 0x14 <col:28> 'int' 3
+|-VarDecl 0x20 prev 0x6 <line:5:1, col:40> col:19 used msgs 'const char *const [2]' cinit
+  |-InitListExpr 0x21 <col:28, col:40> 'const char *const [2]'
+    |-ImplicitCastExpr 0x22 <col:29> 'const char *' <ArrayToPointerDecay>
+    | |-StringLiteral 0x23 <col:29> 'char [3]' lvalue "hi"
+    |-ImplicitCastExpr 0x24 <col:35> 'const char *' <ArrayToPointerDecay>
+      |-StringLiteral 0x25 <col:35> 'char [4]' lvalue "bye"
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		`var table []int32 = []int32{int32(1), int32(2), int32(3)}`,
+		`var msgs []*byte = []*byte{(&[]byte("hi\x00")[0]), (&[]byte("bye\x00")[0])}`,
+		// The arrays are used before their definitions with the types of
+		// the extern declarations, that have no length.
+		"tempVar := &table[0]",
+		"tempVar := &msgs[0]",
+	)
+	if strings.Contains(output, "Cannot") {
+		t.Errorf("Unexpected error in:\n%s", output)
+	}
+}
+
+func TestFunctionPointerVariables(t *testing.T) {
+	// int add(int a, int b) { return a + b; }
+	// int (*gp)(int, int) = add;
+	// int f(void) {
+	//   int (*fp)(int, int) = add;
+	//   fp = &add;
+	//   return fp(1, 2);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x2 <x.c:1:1, col:40> col:5 used add 'int (int, int)'
+| |-ParmVarDecl 0x3 <col:9, col:13> col:13 used a 'int'
+| |-ParmVarDecl 0x4 <col:16, col:20> col:20 used b 'int'
+| |-CompoundStmt 0x5 <col:23, col:40>
+|   |-ReturnStmt 0x6 <col:25, col:36>
+|     |-BinaryOperator 0x7 <col:32, col:36> 'int' '+'
+|       |-ImplicitCastExpr 0x8 <col:32> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x9 <col:32> 'int' lvalue ParmVar 0x3 'a' 'int'
+|       |-ImplicitCastExpr 0xa <col:36> 'int' <LValueToRValue>
+|         |-DeclRefExpr 0xb <col:36> 'int' lvalue ParmVar 0x4 'b' 'int'
+|-VarDecl 0xc <line:2:1, col:23> col:7 gp 'int (*)(int, int)' cinit
+| |-ImplicitCastExpr 0xd <col:23> 'int (*)(int, int)' <FunctionToPointerDecay>
+|   |-DeclRefExpr 0xe <col:23> 'int (int, int)' Function 0x2 'add' 'int (int, int)'
+|-FunctionDecl 0x10 <line:3:1, line:7:1> line:3:5 f 'int (void)'
+  |-CompoundStmt 0x13 <col:13, line:7:1>
+    |-DeclStmt 0x20 <line:4:3, col:29>
+    | |-VarDecl 0x21 <col:3, col:28> col:9 used fp 'int (*)(int, int)' cinit
+    |   |-ImplicitCastExpr 0x22 <col:28> 'int (*)(int, int)' <FunctionToPointerDecay>
+    |     |-DeclRefExpr 0x23 <col:28> 'int (int, int)' Function 0x2 'add' 'int (int, int)'
+    |-BinaryOperator 0x30 <line:5:3, col:9> 'int (*)(int, int)' '='
+    | |-DeclRefExpr 0x31 <col:3> 'int (*)(int, int)' lvalue Var 0x21 'fp' 'int (*)(int, int)'
+    | |-UnaryOperator 0x32 <col:8, col:9> 'int (*)(int, int)' prefix '&' cannot overflow
+    |   |-DeclRefExpr 0x33 <col:9> 'int (int, int)' Function 0x2 'add' 'int (int, int)'
+    |-ReturnStmt 0x40 <line:6:3, col:17>
+      |-CallExpr 0x41 <col:10, col:17> 'int'
+        |-ImplicitCastExpr 0x42 <col:10> 'int (*)(int, int)' <LValueToRValue>
+        | |-DeclRefExpr 0x43 <col:10> 'int (*)(int, int)' lvalue Var 0x21 'fp' 'int (*)(int, int)'
+        |-IntegerLiteral 0x44 <col:13> 'int' 1
+        |-IntegerLiteral 0x45 <col:16> 'int' 2
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"var gp func(int32, int32) int32 = add",
+		"var fp func(int32, int32) int32 = add",
+		"fp = add",
+		"return fp(int32(1), int32(2))",
+	)
+}
+
+func TestFunctionPointerTypedef(t *testing.T) {
+	// typedef int (*handler_t)(void *);
+	// struct server { handler_t on_request; int port; };
+	// int ok(void *p) { return p != 0; }
+	// int run(void) {
+	//     handler_t h = ok;
+	//     struct server s;
+	//     s.on_request = h;
+	//     return h(0) + s.on_request(&s);
+	// }
+	// handler_t pick(int i) { return i ? ok : 0; }
+	// int run2(struct server *sp) {
+	//     handler_t table[2] = {ok, ok};
+	//     if (sp->on_request == 0)
+	//         return 0;
+	//     return sp->on_request(sp) + table[1](sp);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-TypedefDecl 0x2 <x.c:1:1, col:32> col:15 referenced handler_t 'int (*)(void *)'
+| |-PointerType 0x3 'int (*)(void *)'
+|   |-ParenType 0x4 'int (void *)' sugar
+|     |-FunctionProtoType 0x5 'int (void *)' cdecl
+|       |-BuiltinType 0x6 'int'
+|       |-PointerType 0x7 'void *'
+|         |-BuiltinType 0x8 'void'
+|-RecordDecl 0x10 <line:2:1, col:38> col:8 struct server definition
+| |-FieldDecl 0x11 <col:17, col:27> col:27 referenced on_request 'handler_t':'int (*)(void *)'
+| |-FieldDecl 0x12 <col:30, col:34> col:34 referenced port 'int'
+|-FunctionDecl 0x20 <line:3:1, col:44> col:5 used ok 'int (void *)'
+| |-ParmVarDecl 0x21 <col:8, col:14> col:14 used p 'void *'
+| |-CompoundStmt 0x22 <col:17, col:44>
+|   |-ReturnStmt 0x23 <col:19, col:36>
+|     |-BinaryOperator 0x24 <col:26, col:36> 'int' '!='
+|       |-ImplicitCastExpr 0x25 <col:26> 'void *' <LValueToRValue>
+|       | |-DeclRefExpr 0x26 <col:26> 'void *' lvalue ParmVar 0x21 'p' 'void *'
+|       |-ImplicitCastExpr 0x27 <col:31, col:36> 'void *' <NullToPointer>
+|         |-IntegerLiteral 0x28 <col:36> 'int' 0
+|-FunctionDecl 0x30 <line:4:1, line:9:1> line:4:5 run 'int (void)'
+  |-CompoundStmt 0x31 <col:15, line:9:1>
+    |-DeclStmt 0x32 <line:5:5, col:21>
+    | |-VarDecl 0x33 <col:5, col:20> col:15 used h 'handler_t':'int (*)(void *)' cinit
+    |   |-ImplicitCastExpr 0x34 <col:20> 'int (*)(void *)' <FunctionToPointerDecay>
+    |     |-DeclRefExpr 0x35 <col:20> 'int (void *)' Function 0x20 'ok' 'int (void *)'
+    |-DeclStmt 0x36 <line:6:5, col:21>
+    | |-VarDecl 0x37 <col:5, col:19> col:19 used s 'struct server':'struct server'
+    |-BinaryOperator 0x38 <line:7:5, col:20> 'handler_t':'int (*)(void *)' '='
+    | |-MemberExpr 0x39 <col:5, col:7> 'handler_t':'int (*)(void *)' lvalue .on_request 0x11
+    | | |-DeclRefExpr 0x3a <col:5> 'struct server':'struct server' lvalue Var 0x37 's' 'struct server':'struct server'
+    | |-ImplicitCastExpr 0x3b <col:20> 'handler_t':'int (*)(void *)' <LValueToRValue>
+    |   |-DeclRefExpr 0x3c <col:20> 'handler_t':'int (*)(void *)' lvalue Var 0x33 'h' 'handler_t':'int (*)(void *)'
+    |-ReturnStmt 0x40 <line:8:5, col:38>
+      |-BinaryOperator 0x41 <col:12, col:38> 'int' '+'
+        |-CallExpr 0x42 <col:12, col:15> 'int'
+        | |-ImplicitCastExpr 0x43 <col:12> 'handler_t':'int (*)(void *)' <LValueToRValue>
+        | | |-DeclRefExpr 0x44 <col:12> 'handler_t':'int (*)(void *)' lvalue Var 0x33 'h' 'handler_t':'int (*)(void *)'
+        | |-ImplicitCastExpr 0x45 <col:14> 'void *' <NullToPointer>
+        |   |-IntegerLiteral 0x46 <col:14> 'int' 0
+        |-CallExpr 0x47 <col:19, col:38> 'int'
+          |-ImplicitCastExpr 0x48 <col:19, col:21> 'handler_t':'int (*)(void *)' <LValueToRValue>
+          | |-MemberExpr 0x49 <col:19, col:21> 'handler_t':'int (*)(void *)' lvalue .on_request 0x11
+          |   |-DeclRefExpr 0x4a <col:19> 'struct server':'struct server' lvalue Var 0x37 's' 'struct server':'struct server'
+          |-ImplicitCastExpr 0x4b <col:32, col:33> 'void *' <BitCast>
+            |-UnaryOperator 0x4c <col:32, col:33> 'struct server *' prefix '&' cannot overflow
+              |-DeclRefExpr 0x4d <col:33> 'struct server':'struct server' lvalue Var 0x37 's' 'struct server':'struct server'
+|-FunctionDecl 0x60 <line:10:1, col:50> col:11 pick 'handler_t (int)'
+| |-ParmVarDecl 0x61 <col:16, col:20> col:20 used i 'int'
+| |-CompoundStmt 0x62 <col:23, col:50>
+|   |-ReturnStmt 0x63 <col:25, col:40>
+|     |-ConditionalOperator 0x64 <col:32, col:40> 'int (*)(void *)'
+|       |-ImplicitCastExpr 0x65 <col:32> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x66 <col:32> 'int' lvalue ParmVar 0x61 'i' 'int'
+|       |-ImplicitCastExpr 0x67 <col:36> 'int (*)(void *)' <FunctionToPointerDecay>
+|       | |-DeclRefExpr 0x68 <col:36> 'int (void *)' Function 0x20 'ok' 'int (void *)'
+|       |-ImplicitCastExpr 0x69 <col:40> 'int (*)(void *)' <NullToPointer>
+|         |-IntegerLiteral 0x6a <col:40> 'int' 0
+|-FunctionDecl 0x70 <line:11:1, line:16:1> line:11:5 run2 'int (struct server *)'
+  |-ParmVarDecl 0x71 <col:10, col:25> col:25 used sp 'struct server *'
+  |-CompoundStmt 0x72 <col:29, line:16:1>
+    |-DeclStmt 0x73 <line:12:5, col:30>
+    | |-VarDecl 0x74 <col:5, col:29> col:15 used table 'handler_t [2]' cinit
+    |   |-InitListExpr 0x75 <col:24, col:29> 'handler_t [2]'
+    |     |-ImplicitCastExpr 0x76 <col:25> 'int (*)(void *)' <FunctionToPointerDecay>
+    |     | |-DeclRefExpr 0x77 <col:25> 'int (void *)' Function 0x20 'ok' 'int (void *)'
+    |     |-ImplicitCastExpr 0x78 <col:28> 'int (*)(void *)' <FunctionToPointerDecay>
+    |       |-DeclRefExpr 0x79 <col:28> 'int (void *)' Function 0x20 'ok' 'int (void *)'
+    |-IfStmt 0x80 <line:13:5, line:14:16>
+    | |-BinaryOperator 0x81 <line:13:9, col:30> 'int' '=='
+    | | |-ImplicitCastExpr 0x82 <col:9, col:13> 'handler_t':'int (*)(void *)' <LValueToRValue>
+    | | | |-MemberExpr 0x83 <col:9, col:13> 'handler_t':'int (*)(void *)' lvalue ->on_request 0x11
+    | | |   |-ImplicitCastExpr 0x84 <col:9> 'struct server *' <LValueToRValue>
+    | | |     |-DeclRefExpr 0x85 <col:9> 'struct server *' lvalue ParmVar 0x71 'sp' 'struct server *'
+    | | |-ImplicitCastExpr 0x86 <col:30> 'handler_t':'int (*)(void *)' <NullToPointer>
+    | |   |-IntegerLiteral 0x87 <col:30> 'int' 0
+    | |-ReturnStmt 0x88 <line:14:9, col:16>
+    |   |-IntegerLiteral 0x89 <col:16> 'int' 0
+    |-ReturnStmt 0x90 <line:15:5, col:50>
+      |-BinaryOperator 0x91 <col:12, col:50> 'int' '+'
+        |-CallExpr 0x92 <col:12, col:28> 'int'
+        | |-ImplicitCastExpr 0x93 <col:12, col:26> 'handler_t':'int (*)(void *)' <LValueToRValue>
+        | | |-MemberExpr 0x94 <col:12, col:16> 'handler_t':'int (*)(void *)' lvalue ->on_request 0x11
+        | |   |-ImplicitCastExpr 0x95 <col:12> 'struct server *' <LValueToRValue>
+        | |     |-DeclRefExpr 0x96 <col:12> 'struct server *' lvalue ParmVar 0x71 'sp' 'struct server *'
+        | |-ImplicitCastExpr 0x97 <col:27> 'void *' <BitCast>
+        |   |-ImplicitCastExpr 0x98 <col:27> 'struct server *' <LValueToRValue>
+        |     |-DeclRefExpr 0x99 <col:27> 'struct server *' lvalue ParmVar 0x71 'sp' 'struct server *'
+        |-CallExpr 0x9a <col:32, col:50> 'int'
+          |-ImplicitCastExpr 0x9b <col:32, col:39> 'handler_t':'int (*)(void *)' <LValueToRValue>
+          | |-ArraySubscriptExpr 0x9c <col:32, col:39> 'handler_t':'int (*)(void *)' lvalue
+          |   |-ImplicitCastExpr 0x9d <col:32> 'handler_t *' <ArrayToPointerDecay>
+          |   | |-DeclRefExpr 0x9e <col:32> 'handler_t [2]' lvalue Var 0x74 'table' 'handler_t [2]'
+          |   |-IntegerLiteral 0x9f <col:38> 'int' 1
+          |-ImplicitCastExpr 0xa0 <col:41> 'void *' <BitCast>
+            |-ImplicitCastExpr 0xa1 <col:41> 'struct server *' <LValueToRValue>
+              |-DeclRefExpr 0xa2 <col:41> 'struct server *' lvalue ParmVar 0x71 'sp' 'struct server *'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	// A pointer to the typedef, like the decayed array, is a pointer to the
+	// func.
+	expectContains(t, output,
+		"type handler_t func(unsafe.Pointer) int32",
+		"on_request handler_t",
+		"var h handler_t = ok",
+		"s.on_request = h",
+		"return h(nil) + s.on_request(unsafe.Pointer(&s))",
+		"return nil",
+		"tempVar := &table[0]",
+		"(*((*handler_t)(",
+	)
+	if strings.Contains(output, "Error") || strings.Contains(output, "Warning") {
+		t.Errorf("Unexpected message in:\n%s", output)
+	}
+}
+
+func TestTypeOfDeclarations(t *testing.T) {
+	// void f(int *a) {
+	//   typeof(a) b = a;
+	//   typeof(*a) c = *b;
+	//   c = *b + 1;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:5:1> line:1:6 f 'void (int *)'
+  |-ParmVarDecl 0x11 <col:8, col:13> col:13 used a 'int *'
+  |-CompoundStmt 0x12 <col:16, line:5:1>
+    |-DeclStmt 0x20 <line:2:3, col:20>
+    | |-VarDecl 0x21 <col:3, col:19> col:13 used b 'typeof (a)':'int *' cinit
+    |   |-ImplicitCastExpr 0x22 <col:19> 'int *' <LValueToRValue>
+    |     |-DeclRefExpr 0x23 <col:19> 'int *' lvalue ParmVar 0x11 'a' 'int *'
+    |-DeclStmt 0x30 <line:3:3, col:22>
+    | |-VarDecl 0x31 <col:3, col:20> col:14 used c 'typeof (*a)':'int' cinit
+    |   |-ImplicitCastExpr 0x32 <col:18, col:19> 'int' <LValueToRValue>
+    |     |-UnaryOperator 0x33 <col:18, col:19> 'int' lvalue prefix '*' cannot overflow
+    |       |-ImplicitCastExpr 0x34 <col:19> 'typeof (a)':'int *' <LValueToRValue>
+    |         |-DeclRefExpr 0x35 <col:19> 'typeof (a)':'int *' lvalue Var 0x21 'b' 'typeof (a)':'int *'
+    |-BinaryOperator 0x40 <line:4:3, col:12> 'typeof (*a)':'int' '='
+      |-DeclRefExpr 0x41 <col:3> 'typeof (*a)':'int' lvalue Var 0x31 'c' 'typeof (*a)':'int'
+      |-BinaryOperator 0x42 <col:7, col:12> 'int' '+'
+        |-ImplicitCastExpr 0x43 <col:7, col:8> 'int' <LValueToRValue>
+        | |-UnaryOperator 0x44 <col:7, col:8> 'int' lvalue prefix '*' cannot overflow
+        |   |-ImplicitCastExpr 0x45 <col:8> 'typeof (a)':'int *' <LValueToRValue>
+        |     |-DeclRefExpr 0x46 <col:8> 'typeof (a)':'int *' lvalue Var 0x21 'b' 'typeof (a)':'int *'
+        |-IntegerLiteral 0x47 <col:12> 'int' 1
+`
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"var b *int32 = a",
+		"var c int32 = *b",
+		"c = *b+int32(1)",
+	)
+	if strings.Contains(output, "Error") || strings.Contains(output, "Warning") {
+		t.Errorf("Unexpected message in:\n%s", output)
+	}
+}
+
+func TestSharedStructOfTwoFiles(t *testing.T) {
+	// shared.h:
+	//     struct point { int x, y; };
+	//
+	// a.c:
+	//     struct point;
+	//     int area(struct point *p) { return p->x * p->y; }
+	//
+	// b.c:
+	//     #include "shared.h"
+	//     int sum(struct point *p) { return p->x + p->y; }
+	//
+	// The definition of the struct comes after its declaration in a.c and
+	// again with b.c, when the preprocessor does not merge the headers.
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x2 <a.c:1:1, col:8> col:8 struct point
+|-RecordDecl 0x3 <./shared.h:1:1, col:26> col:8 struct point definition
+| |-FieldDecl 0x4 <col:17, col:21> col:21 referenced x 'int'
+| |-FieldDecl 0x5 <col:17, col:24> col:24 referenced y 'int'
+|-FunctionDecl 0x10 <a.c:2:1, col:49> col:5 area 'int (struct point *)'
+| |-ParmVarDecl 0x11 <col:10, col:24> col:24 used p 'struct point *'
+| |-CompoundStmt 0x12 <col:27, col:49>
+|   |-ReturnStmt 0x13 <col:29, col:46>
+|     |-BinaryOperator 0x14 <col:36, col:46> 'int' '*'
+|       |-ImplicitCastExpr 0x15 <col:36, col:39> 'int' <LValueToRValue>
+|       | |-MemberExpr 0x16 <col:36, col:39> 'int' lvalue ->x 0x4
+|       |   |-ImplicitCastExpr 0x17 <col:36> 'struct point *' <LValueToRValue>
+|       |     |-DeclRefExpr 0x18 <col:36> 'struct point *' lvalue ParmVar 0x11 'p' 'struct point *'
+|       |-ImplicitCastExpr 0x19 <col:43, col:46> 'int' <LValueToRValue>
+|         |-MemberExpr 0x1a <col:43, col:46> 'int' lvalue ->y 0x5
+|           |-ImplicitCastExpr 0x1b <col:43> 'struct point *' <LValueToRValue>
+|             |-DeclRefExpr 0x1c <col:43> 'struct point *' lvalue ParmVar 0x11 'p' 'struct point *'
+|-RecordDecl 0x20 <./shared.h:1:1, col:26> col:8 struct point definition
+| |-FieldDecl 0x21 <col:17, col:21> col:21 referenced x 'int'
+| |-FieldDecl 0x22 <col:17, col:24> col:24 referenced y 'int'
+|-FunctionDecl 0x30 <b.c:2:1, col:48> col:5 sum 'int (struct point *)'
+  |-ParmVarDecl 0x31 <col:9, col:23> col:23 used p 'struct point *'
+  |-CompoundStmt 0x32 <col:26, col:48>
+    |-ReturnStmt 0x33 <col:28, col:45>
+      |-BinaryOperator 0x34 <col:35, col:45> 'int' '+'
+        |-ImplicitCastExpr 0x35 <col:35, col:38> 'int' <LValueToRValue>
+        | |-MemberExpr 0x36 <col:35, col:38> 'int' lvalue ->x 0x21
+        |   |-ImplicitCastExpr 0x37 <col:35> 'struct point *' <LValueToRValue>
+        |     |-DeclRefExpr 0x38 <col:35> 'struct point *' lvalue ParmVar 0x31 'p' 'struct point *'
+        |-ImplicitCastExpr 0x39 <col:42, col:45> 'int' <LValueToRValue>
+          |-MemberExpr 0x3a <col:42, col:45> 'int' lvalue ->y 0x22
+            |-ImplicitCastExpr 0x3b <col:42> 'struct point *' <LValueToRValue>
+              |-DeclRefExpr 0x3c <col:42> 'struct point *' lvalue ParmVar 0x31 'p' 'struct point *'
+`
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	want := `
+type point struct {
+	x int32
+	y int32
+}
+`
+	if !strings.Contains(output, want[1:]) {
+		t.Errorf("Expected:\n%s\nin:\n%s", want, output)
+	}
+	if n := strings.Count(output, "type point "); n != 1 {
+		t.Errorf("Expected one struct, got %d in:\n%s", n, output)
+	}
+	for _, want := range []string{"return (*p).x * (*p).y", "return (*p).x + (*p).y"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestSelfReferentialStructs(t *testing.T) {
+	// struct Node { int value; struct Node *next; };
+	// struct A;
+	// struct B { struct A *a; int n; };
+	// struct A { struct B *b; };
+	// struct Opaque;
+	// struct Opaque *get(void);
+	// typedef struct Tree Tree;
+	// struct Tree { Tree *left; int value; };
+	// int length(struct Node *n) {
+	//     return n ? 1 + length(n->next) : 0;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x2 <x.c:1:1, col:48> col:8 struct Node definition
+| |-FieldDecl 0x3 <col:22, col:26> col:26 referenced value 'int'
+| |-FieldDecl 0x4 <col:33, col:47> col:47 referenced next 'struct Node *'
+|-RecordDecl 0x10 <line:2:1, col:8> col:8 struct A
+|-RecordDecl 0x11 <line:3:1, col:32> col:8 struct B definition
+| |-FieldDecl 0x12 <col:12, col:22> col:22 a 'struct A *'
+| |-FieldDecl 0x13 <col:25, col:29> col:29 n 'int'
+|-RecordDecl 0x14 <line:4:1, col:26> col:8 struct A definition
+| |-FieldDecl 0x15 <col:12, col:22> col:22 b 'struct B *'
+|-RecordDecl 0x20 <line:5:1, col:8> col:8 struct Opaque
+|-FunctionDecl 0x21 <line:6:1, col:27> col:16 get 'struct Opaque *(void)'
+|-RecordDecl 0x34 <line:7:9, col:16> col:16 struct Tree
+|-TypedefDecl 0x30 <line:7:1, col:27> col:27 referenced Tree 'struct Tree':'struct Tree'
+| |-ElaboratedType 0x31 'struct Tree' sugar
+|   |-RecordType 0x32 'struct Tree'
+|     |-Record 0x33 'Tree'
+|-RecordDecl 0x33 prev 0x34 <line:8:1, col:30> col:8 struct Tree definition
+| |-FieldDecl 0x35 <col:14, col:20> col:20 left 'Tree *'
+| |-FieldDecl 0x36 <col:14, col:27> col:27 value 'int'
+|-FunctionDecl 0x40 <line:9:1, line:11:1> line:9:5 length 'int (struct Node *)'
+  |-ParmVarDecl 0x41 <col:12, col:26> col:26 used n 'struct Node *'
+  |-CompoundStmt 0x42 <col:29, line:11:1>
+    |-ReturnStmt 0x43 <line:10:5, col:46>
+      |-ConditionalOperator 0x44 <col:12, col:46> 'int'
+        |-ImplicitCastExpr 0x45 <col:12> 'struct Node *' <LValueToRValue>
+        | |-DeclRefExpr 0x46 <col:12> 'struct Node *' lvalue ParmVar 0x41 'n' 'struct Node *'
+        |-BinaryOperator 0x47 <col:16, col:36> 'int' '+'
+        | |-IntegerLiteral 0x48 <col:16> 'int' 1
+        | |-CallExpr 0x49 <col:20, col:36> 'int'
+        |   |-ImplicitCastExpr 0x4a <col:20> 'int (*)(struct Node *)' <FunctionToPointerDecay>
+        |   | |-DeclRefExpr 0x4b <col:20> 'int (struct Node *)' Function 0x40 'length' 'int (struct Node *)'
+        |   |-ImplicitCastExpr 0x4c <col:27, col:30> 'struct Node *' <LValueToRValue>
+        |     |-MemberExpr 0x4d <col:27, col:30> 'struct Node *' lvalue ->next 0x4
+        |       |-ImplicitCastExpr 0x4e <col:27> 'struct Node *' <LValueToRValue>
+        |         |-DeclRefExpr 0x4f <col:27> 'struct Node *' lvalue ParmVar 0x41 'n' 'struct Node *'
+        |-IntegerLiteral 0x50 <col:46> 'int' 0
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	// B points to A before A is defined.
+	want := `
+type Node struct {
+	value int32
+	next  *Node
+}
+type B struct {
+	a *A
+	n int32
+}
+type A struct {
+	b *B
+}
+type Opaque struct {
+}
+type Tree struct {
+	left  *Tree
+	value int32
+}
+`
+	if !strings.Contains(output, want[1:]) {
+		t.Errorf("Expected:\n%s\nin:\n%s", want, output)
+	}
+	expectContains(t, output,
+		"func length(n *Node) int32 {",
+		"length((*n).next)",
+	)
+	if strings.Contains(output, "Warning") {
+		t.Errorf("Unexpected warning in:\n%s", output)
+	}
+}
+
+func TestRemoveDuplicateDecls(t *testing.T) {
+	p := program.NewProgram()
+	p.FileSet = token.NewFileSet()
+	f, err := parser.ParseFile(p.FileSet, "x.go", `package main
+
+// first
+type point struct{ x int32 }
+
+// second
+type point struct{ x int32 }
+
+func init() {}
+func init() {}
+`, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.File = f
+
+	if err := p.RemoveDuplicateDecls(); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.File.Decls) != 3 {
+		t.Errorf("Expected 3 declarations, got %d", len(p.File.Decls))
+	}
+
+	// The same name with other fields is an error.
+	f, err = parser.ParseFile(p.FileSet, "x.go", `package main
+type point struct{ x int32 }
+type point struct{ y int32 }
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.File = f
+	if err := p.RemoveDuplicateDecls(); err == nil {
+		t.Errorf("Expected an error for the conflicting declarations")
+	}
+}
@@ -0,0 +1,310 @@
+// This file contains functions for replacing the explicit free() of memory
+// allocated at the top of a function with a single Go defer statement.
+
+package transpiler
+
+import (
+	"bytes"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+
+	goast "go/ast"
+	"go/printer"
+	"go/token"
+)
+
+// deferredFree is a variable that is allocated in the function body and can
+// be released with a defer statement instead of the explicit free() calls.
+type deferredFree struct {
+	// Name of the C variable, like "buffer".
+	name string
+
+	// The first free() call that was removed from the function body. It is
+	// used to generate the deferred call so that the argument is cast exactly
+	// like any other call of free().
+	call *ast.CallExpr
+}
+
+// findDeferredFrees searches the top level of the function body for variables
+// that are initialized with malloc() or calloc() and released with free().
+// Such variable can be released by one defer placed right after the
+// allocation, that defer also covers any early return in the middle of the
+// function. The free() calls are only removed by insertDeferredFrees once the
+// defer is in place.
+//
+// The variable is ignored if the pointer may escape the function: when it is
+// assigned again, returned, stored somewhere else or passed to a function
+// other than free(). It is also ignored if any of the free() calls is used as
+// an expression rather than a statement inside a block.
+//
+// Example:
+//
+//     int *a = malloc(sizeof(int) * 4);   a := ... malloc ...
+//     if (a[0] == 0) {                    defer noarch.Free(a)
+//         free(a);                        if a[0] == 0 {
+//         return 1;                           return 1
+//     }                             =>    }
+//     free(a);                            return 0
+//     return 0;
+func findDeferredFrees(p *program.Program, body *ast.CompoundStmt) (
+	frees map[ast.Address]deferredFree) {
+	frees = map[ast.Address]deferredFree{}
+
+	for _, c := range body.Children() {
+		decl, ok := c.(*ast.DeclStmt)
+		if !ok {
+			continue
+		}
+		for _, d := range decl.Children() {
+			v, ok := d.(*ast.VarDecl)
+			if !ok || len(v.Children()) == 0 {
+				continue
+			}
+			call := foundCallExpr(v.Children()[0])
+			if call == nil {
+				continue
+			}
+			name, _ := getNameOfFunctionFromCallExpr(p, call)
+			if name != "malloc" && name != "calloc" {
+				continue
+			}
+			frees[v.Addr] = deferredFree{name: v.Name}
+		}
+	}
+	if len(frees) == 0 {
+		return
+	}
+
+	// Only the free() calls that are statements can be removed, anything
+	// else disables the rewrite for that variable. The safe argument tells if
+	// the pointer cannot escape through the parent of the node, like when it
+	// is dereferenced or compared.
+	statements := map[*ast.CallExpr]bool{}
+	var walk func(n ast.Node, safe bool)
+	var addressOf func(n ast.Node)
+	walk = func(n ast.Node, safe bool) {
+		if n == nil {
+			return
+		}
+		if _, ok := n.(*ast.CompoundStmt); ok {
+			for _, c := range n.Children() {
+				if call, ok := c.(*ast.CallExpr); ok {
+					statements[call] = true
+				}
+			}
+		}
+		switch v := n.(type) {
+		case *ast.DeclRefExpr:
+			if !safe {
+				delete(frees, ast.ParseAddress(v.Address2))
+			}
+			return
+
+		case *ast.CallExpr:
+			if name, _ := getNameOfFunctionFromCallExpr(p, v); name == "free" &&
+				len(v.Children()) == 2 {
+				if ref := getFreedVariable(v.Children()[1]); ref != nil {
+					addr := ast.ParseAddress(ref.Address2)
+					if f, ok := frees[addr]; ok {
+						if !statements[v] {
+							delete(frees, addr)
+						} else if f.call == nil {
+							f.call = v
+							frees[addr] = f
+						}
+					}
+					return
+				}
+			}
+
+		case *ast.ImplicitCastExpr, *ast.ParenExpr:
+			for _, c := range n.Children() {
+				walk(c, safe)
+			}
+			return
+
+		case *ast.ArraySubscriptExpr:
+			walk(v.Children()[0], true)
+			walk(v.Children()[1], false)
+			return
+
+		case *ast.MemberExpr:
+			for _, c := range n.Children() {
+				walk(c, v.IsPointer)
+			}
+			return
+
+		case *ast.UnaryOperator:
+			if v.Operator == "&" {
+				addressOf(v.Children()[0])
+				return
+			}
+			for _, c := range n.Children() {
+				walk(c, v.Operator == "*" || v.Operator == "!")
+			}
+			return
+
+		case *ast.BinaryOperator:
+			if v.Operator == "=" {
+				if ref, ok := v.Children()[0].(*ast.DeclRefExpr); ok {
+					delete(frees, ast.ParseAddress(ref.Address2))
+				}
+			}
+			for _, c := range n.Children() {
+				walk(c, v.Operator == "==" || v.Operator == "!=")
+			}
+			return
+
+		case *ast.CompoundStmt, *ast.IfStmt, *ast.WhileStmt, *ast.DoStmt,
+			*ast.ForStmt:
+			// The conditions only test the pointer.
+			for _, c := range n.Children() {
+				walk(c, true)
+			}
+			return
+		}
+		for _, c := range n.Children() {
+			walk(c, false)
+		}
+	}
+
+	// The address of an element or a member points into the memory of the
+	// pointer, like &a[i] or &p->f, so the pointer escapes with it.
+	addressOf = func(n ast.Node) {
+		switch v := n.(type) {
+		case *ast.ParenExpr:
+			addressOf(v.Children()[0])
+			return
+
+		case *ast.ArraySubscriptExpr:
+			walk(v.Children()[0], false)
+			walk(v.Children()[1], false)
+			return
+
+		case *ast.MemberExpr:
+			if v.IsPointer {
+				walk(v.Children()[0], false)
+			} else {
+				addressOf(v.Children()[0])
+			}
+			return
+		}
+		walk(n, false)
+	}
+	walk(body, true)
+
+	for addr, f := range frees {
+		if f.call == nil {
+			delete(frees, addr)
+		}
+	}
+
+	return
+}
+
+// getFreedVariable returns the variable that is the argument of free(), or nil
+// if the argument is not a plain variable.
+func getFreedVariable(n ast.Node) *ast.DeclRefExpr {
+	switch v := n.(type) {
+	case *ast.DeclRefExpr:
+		return v
+	case *ast.ImplicitCastExpr, *ast.CStyleCastExpr, *ast.ParenExpr:
+		if len(v.Children()) == 1 {
+			return getFreedVariable(v.Children()[0])
+		}
+	}
+	return nil
+}
+
+// insertDeferredFrees places the defer statements immediately after the
+// declaration of each variable they release, and then removes the free() calls
+// of those variables from the body. A free() is kept when its defer cannot be
+// placed.
+func insertDeferredFrees(p *program.Program, body *goast.BlockStmt,
+	frees map[ast.Address]deferredFree) {
+	if len(frees) == 0 {
+		return
+	}
+
+	deferred := map[string]bool{}
+	var stmts []goast.Stmt
+	for _, s := range body.List {
+		stmts = append(stmts, s)
+
+		decl, ok := s.(*goast.DeclStmt)
+		if !ok {
+			continue
+		}
+		gen, ok := decl.Decl.(*goast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value, ok := spec.(*goast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for _, name := range value.Names {
+				for _, f := range frees {
+					if f.name != name.Name {
+						continue
+					}
+					expr, _, pre, post, err := transpileToExpr(f.call, p, true)
+					if err != nil || len(pre) > 0 || len(post) > 0 {
+						p.AddMessage(p.GenerateWarningMessage(err, f.call))
+						continue
+					}
+					call, ok := expr.(*goast.CallExpr)
+					if !ok {
+						continue
+					}
+					stmts = append(stmts, &goast.DeferStmt{Call: call})
+					deferred[goExprString(call)] = true
+				}
+			}
+		}
+	}
+	body.List = removeDeferredFrees(stmts, deferred)
+}
+
+// removeDeferredFrees removes the statements that only call one of the
+// deferred free() calls, in the list and in all of the nested blocks.
+func removeDeferredFrees(stmts []goast.Stmt, deferred map[string]bool) []goast.Stmt {
+	if len(deferred) == 0 {
+		return stmts
+	}
+
+	filter := func(list []goast.Stmt) []goast.Stmt {
+		var result []goast.Stmt
+		for _, s := range list {
+			if e, ok := s.(*goast.ExprStmt); ok && deferred[goExprString(e.X)] {
+				continue
+			}
+			result = append(result, s)
+		}
+		return result
+	}
+
+	block := &goast.BlockStmt{List: stmts}
+	goast.Inspect(block, func(n goast.Node) bool {
+		switch v := n.(type) {
+		case *goast.BlockStmt:
+			v.List = filter(v.List)
+		case *goast.CaseClause:
+			v.Body = filter(v.Body)
+		}
+		return true
+	})
+	return block.List
+}
+
+// goExprString returns the Go code of an expression, or an empty string if it
+// cannot be printed.
+func goExprString(expr goast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, token.NewFileSet(), expr); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,219 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestDeferredFree(t *testing.T) {
+	// int *g;
+	// void kept(void) { int *a = malloc(4); a[0] = 1; if (!a) return; free(a); }
+	// int *returned(int n) { int *b = malloc(4); if (n) { free(b); return 0; } return b; }
+	// void stored(void) { int *c = malloc(4); g = c; free(c); }
+	// void out(int **o) { int *d = malloc(4); *o = d; free(d); }
+	// void reassigned(void) { int *e = malloc(4); free(e); e = 0; }
+	// void element(int **o) { int *h = malloc(4); *o = &h[1]; free(h); }
+	// struct s { int f; };
+	// void member(int **o) { struct s *k = malloc(4); *o = &k->f; free(k); }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x2 </usr/include/stdlib.h:1:1, col:30> col:7 used malloc 'void *(unsigned long)'
+| |-ParmVarDecl 0x3 <col:14> col:27 'unsigned long'
+|-FunctionDecl 0x4 <line:2:1, col:20> col:6 used free 'void (void *)'
+| |-ParmVarDecl 0x5 <col:11> col:17 'void *'
+|-VarDecl 0x6 <x.c:1:1, col:6> col:6 used g 'int *'
+|-FunctionDecl 0x10 <line:2:1, col:70> col:6 kept 'void (void)'
+| |-CompoundStmt 0x11 <col:17, col:70>
+|   |-DeclStmt 0x12 <col:19, col:37>
+|   | |-VarDecl 0x13 <col:19, col:36> col:24 used a 'int *' cinit
+|   |   |-ImplicitCastExpr 0x14 <col:28, col:36> 'int *' <BitCast>
+|   |     |-CallExpr 0x15 <col:28, col:36> 'void *'
+|   |       |-ImplicitCastExpr 0x16 <col:28> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+|   |       | |-DeclRefExpr 0x17 <col:28> 'void *(unsigned long)' Function 0x2 'malloc' 'void *(unsigned long)'
+|   |       |-ImplicitCastExpr 0x18 <col:35> 'unsigned long' <IntegralCast>
+|   |         |-IntegerLiteral 0x19 <col:35> 'int' 4
+|   |-BinaryOperator 0x1a <col:39, col:46> 'int' '='
+|   | |-ArraySubscriptExpr 0x1b <col:39, col:42> 'int' lvalue
+|   | | |-ImplicitCastExpr 0x1c <col:39> 'int *' <LValueToRValue>
+|   | | | |-DeclRefExpr 0x1d <col:39> 'int *' lvalue Var 0x13 'a' 'int *'
+|   | | |-IntegerLiteral 0x1e <col:41> 'int' 0
+|   | |-IntegerLiteral 0x1f <col:46> 'int' 1
+|   |-IfStmt 0x20 <col:49, col:58>
+|   | |-UnaryOperator 0x21 <col:53, col:54> 'int' prefix '!' cannot overflow
+|   | | |-ImplicitCastExpr 0x22 <col:54> 'int *' <LValueToRValue>
+|   | |   |-DeclRefExpr 0x23 <col:54> 'int *' lvalue Var 0x13 'a' 'int *'
+|   | |-ReturnStmt 0x24 <col:57>
+|   |-CallExpr 0x25 <col:60, col:66> 'void'
+|     |-ImplicitCastExpr 0x26 <col:60> 'void (*)(void *)' <FunctionToPointerDecay>
+|     | |-DeclRefExpr 0x27 <col:60> 'void (void *)' Function 0x4 'free' 'void (void *)'
+|     |-ImplicitCastExpr 0x28 <col:65> 'void *' <BitCast>
+|       |-ImplicitCastExpr 0x29 <col:65> 'int *' <LValueToRValue>
+|         |-DeclRefExpr 0x2a <col:65> 'int *' lvalue Var 0x13 'a' 'int *'
+|-FunctionDecl 0x30 <line:3:1, col:80> col:6 returned 'int *(int)'
+| |-ParmVarDecl 0x31 <col:15, col:19> col:19 used n 'int'
+| |-CompoundStmt 0x32 <col:22, col:80>
+|   |-DeclStmt 0x33 <col:24, col:42>
+|   | |-VarDecl 0x34 <col:24, col:41> col:29 used b 'int *' cinit
+|   |   |-ImplicitCastExpr 0x35 <col:33, col:41> 'int *' <BitCast>
+|   |     |-CallExpr 0x36 <col:33, col:41> 'void *'
+|   |       |-ImplicitCastExpr 0x37 <col:33> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+|   |       | |-DeclRefExpr 0x38 <col:33> 'void *(unsigned long)' Function 0x2 'malloc' 'void *(unsigned long)'
+|   |       |-ImplicitCastExpr 0x39 <col:40> 'unsigned long' <IntegralCast>
+|   |         |-IntegerLiteral 0x3a <col:40> 'int' 4
+|   |-IfStmt 0x3b <col:44, col:70>
+|   | |-ImplicitCastExpr 0x3c <col:48> 'int' <LValueToRValue>
+|   | | |-DeclRefExpr 0x3d <col:48> 'int' lvalue ParmVar 0x31 'n' 'int'
+|   | |-CompoundStmt 0x3e <col:51, col:70>
+|   |   |-CallExpr 0x3f <col:53, col:59> 'void'
+|   |   | |-ImplicitCastExpr 0x40 <col:53> 'void (*)(void *)' <FunctionToPointerDecay>
+|   |   | | |-DeclRefExpr 0x41 <col:53> 'void (void *)' Function 0x4 'free' 'void (void *)'
+|   |   | |-ImplicitCastExpr 0x42 <col:58> 'void *' <BitCast>
+|   |   |   |-ImplicitCastExpr 0x43 <col:58> 'int *' <LValueToRValue>
+|   |   |     |-DeclRefExpr 0x44 <col:58> 'int *' lvalue Var 0x34 'b' 'int *'
+|   |   |-ReturnStmt 0x45 <col:62, col:69>
+|   |     |-ImplicitCastExpr 0x46 <col:69> 'int *' <NullToPointer>
+|   |       |-IntegerLiteral 0x47 <col:69> 'int' 0
+|   |-ReturnStmt 0x48 <col:72, col:79>
+|     |-ImplicitCastExpr 0x49 <col:79> 'int *' <LValueToRValue>
+|       |-DeclRefExpr 0x4a <col:79> 'int *' lvalue Var 0x34 'b' 'int *'
+|-FunctionDecl 0x50 <line:4:1, col:60> col:6 stored 'void (void)'
+| |-CompoundStmt 0x51 <col:19, col:60>
+|   |-DeclStmt 0x52 <col:21, col:39>
+|   | |-VarDecl 0x53 <col:21, col:38> col:26 used c 'int *' cinit
+|   |   |-ImplicitCastExpr 0x54 <col:30, col:38> 'int *' <BitCast>
+|   |     |-CallExpr 0x55 <col:30, col:38> 'void *'
+|   |       |-ImplicitCastExpr 0x56 <col:30> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+|   |       | |-DeclRefExpr 0x57 <col:30> 'void *(unsigned long)' Function 0x2 'malloc' 'void *(unsigned long)'
+|   |       |-ImplicitCastExpr 0x58 <col:37> 'unsigned long' <IntegralCast>
+|   |         |-IntegerLiteral 0x59 <col:37> 'int' 4
+|   |-BinaryOperator 0x5a <col:41, col:45> 'int *' '='
+|   | |-DeclRefExpr 0x5b <col:41> 'int *' lvalue Var 0x6 'g' 'int *'
+|   | |-ImplicitCastExpr 0x5c <col:45> 'int *' <LValueToRValue>
+|   |   |-DeclRefExpr 0x5d <col:45> 'int *' lvalue Var 0x53 'c' 'int *'
+|   |-CallExpr 0x5e <col:48, col:54> 'void'
+|     |-ImplicitCastExpr 0x5f <col:48> 'void (*)(void *)' <FunctionToPointerDecay>
+|     | |-DeclRefExpr 0x60 <col:48> 'void (void *)' Function 0x4 'free' 'void (void *)'
+|     |-ImplicitCastExpr 0x61 <col:53> 'void *' <BitCast>
+|       |-ImplicitCastExpr 0x62 <col:53> 'int *' <LValueToRValue>
+|         |-DeclRefExpr 0x63 <col:53> 'int *' lvalue Var 0x53 'c' 'int *'
+|-FunctionDecl 0x70 <line:5:1, col:60> col:6 out 'void (int **)'
+| |-ParmVarDecl 0x71 <col:10, col:16> col:16 used o 'int **'
+| |-CompoundStmt 0x72 <col:19, col:60>
+|   |-DeclStmt 0x73 <col:21, col:39>
+|   | |-VarDecl 0x74 <col:21, col:38> col:26 used d 'int *' cinit
+|   |   |-ImplicitCastExpr 0x75 <col:30, col:38> 'int *' <BitCast>
+|   |     |-CallExpr 0x76 <col:30, col:38> 'void *'
+|   |       |-ImplicitCastExpr 0x77 <col:30> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+|   |       | |-DeclRefExpr 0x78 <col:30> 'void *(unsigned long)' Function 0x2 'malloc' 'void *(unsigned long)'
+|   |       |-ImplicitCastExpr 0x79 <col:37> 'unsigned long' <IntegralCast>
+|   |         |-IntegerLiteral 0x7a <col:37> 'int' 4
+|   |-BinaryOperator 0x7b <col:41, col:46> 'int *' '='
+|   | |-UnaryOperator 0x7c <col:41, col:42> 'int *' lvalue prefix '*' cannot overflow
+|   | | |-ImplicitCastExpr 0x7d <col:42> 'int **' <LValueToRValue>
+|   | |   |-DeclRefExpr 0x7e <col:42> 'int **' lvalue ParmVar 0x71 'o' 'int **'
+|   | |-ImplicitCastExpr 0x7f <col:46> 'int *' <LValueToRValue>
+|   |   |-DeclRefExpr 0x80 <col:46> 'int *' lvalue Var 0x74 'd' 'int *'
+|   |-CallExpr 0x81 <col:49, col:55> 'void'
+|     |-ImplicitCastExpr 0x82 <col:49> 'void (*)(void *)' <FunctionToPointerDecay>
+|     | |-DeclRefExpr 0x83 <col:49> 'void (void *)' Function 0x4 'free' 'void (void *)'
+|     |-ImplicitCastExpr 0x84 <col:54> 'void *' <BitCast>
+|       |-ImplicitCastExpr 0x85 <col:54> 'int *' <LValueToRValue>
+|         |-DeclRefExpr 0x86 <col:54> 'int *' lvalue Var 0x74 'd' 'int *'
+|-FunctionDecl 0x90 <line:6:1, col:60> col:6 reassigned 'void (void)'
+| |-CompoundStmt 0x91 <col:23, col:60>
+|   |-DeclStmt 0x92 <col:25, col:43>
+|   | |-VarDecl 0x93 <col:25, col:42> col:30 used e 'int *' cinit
+|   |   |-ImplicitCastExpr 0x94 <col:34, col:42> 'int *' <BitCast>
+|   |     |-CallExpr 0x95 <col:34, col:42> 'void *'
+|   |       |-ImplicitCastExpr 0x96 <col:34> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+|   |       | |-DeclRefExpr 0x97 <col:34> 'void *(unsigned long)' Function 0x2 'malloc' 'void *(unsigned long)'
+|   |       |-ImplicitCastExpr 0x98 <col:41> 'unsigned long' <IntegralCast>
+|   |         |-IntegerLiteral 0x99 <col:41> 'int' 4
+|   |-CallExpr 0x9a <col:45, col:51> 'void'
+|   | |-ImplicitCastExpr 0x9b <col:45> 'void (*)(void *)' <FunctionToPointerDecay>
+|   | | |-DeclRefExpr 0x9c <col:45> 'void (void *)' Function 0x4 'free' 'void (void *)'
+|   | |-ImplicitCastExpr 0x9d <col:50> 'void *' <BitCast>
+|   |   |-ImplicitCastExpr 0x9e <col:50> 'int *' <LValueToRValue>
+|   |     |-DeclRefExpr 0x9f <col:50> 'int *' lvalue Var 0x93 'e' 'int *'
+|   |-BinaryOperator 0xa0 <col:54, col:58> 'int *' '='
+|     |-DeclRefExpr 0xa1 <col:54> 'int *' lvalue Var 0x93 'e' 'int *'
+|     |-ImplicitCastExpr 0xa2 <col:58> 'int *' <NullToPointer>
+|       |-IntegerLiteral 0xa3 <col:58> 'int' 0
+|-FunctionDecl 0xb0 <line:7:1, col:68> col:6 element 'void (int **)'
+| |-ParmVarDecl 0xb1 <col:14, col:20> col:20 used o 'int **'
+| |-CompoundStmt 0xb2 <col:23, col:68>
+|   |-DeclStmt 0xb3 <col:25, col:43>
+|   | |-VarDecl 0xb4 <col:25, col:42> col:30 used h 'int *' cinit
+|   |   |-ImplicitCastExpr 0xb5 <col:34, col:42> 'int *' <BitCast>
+|   |     |-CallExpr 0xb6 <col:34, col:42> 'void *'
+|   |       |-ImplicitCastExpr 0xb7 <col:34> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+|   |       | |-DeclRefExpr 0xb8 <col:34> 'void *(unsigned long)' Function 0x2 'malloc' 'void *(unsigned long)'
+|   |       |-ImplicitCastExpr 0xb9 <col:41> 'unsigned long' <IntegralCast>
+|   |         |-IntegerLiteral 0xba <col:41> 'int' 4
+|   |-BinaryOperator 0xbb <col:45, col:54> 'int *' '='
+|   | |-UnaryOperator 0xbc <col:45, col:46> 'int *' lvalue prefix '*' cannot overflow
+|   | | |-ImplicitCastExpr 0xbd <col:46> 'int **' <LValueToRValue>
+|   | |   |-DeclRefExpr 0xbe <col:46> 'int **' lvalue ParmVar 0xb1 'o' 'int **'
+|   | |-UnaryOperator 0xbf <col:50, col:54> 'int *' prefix '&' cannot overflow
+|   |   |-ArraySubscriptExpr 0xc0 <col:51, col:54> 'int' lvalue
+|   |     |-ImplicitCastExpr 0xc1 <col:51> 'int *' <LValueToRValue>
+|   |     | |-DeclRefExpr 0xc2 <col:51> 'int *' lvalue Var 0xb4 'h' 'int *'
+|   |     |-IntegerLiteral 0xc3 <col:53> 'int' 1
+|   |-CallExpr 0xc4 <col:57, col:63> 'void'
+|     |-ImplicitCastExpr 0xc5 <col:57> 'void (*)(void *)' <FunctionToPointerDecay>
+|     | |-DeclRefExpr 0xc6 <col:57> 'void (void *)' Function 0x4 'free' 'void (void *)'
+|     |-ImplicitCastExpr 0xc7 <col:62> 'void *' <BitCast>
+|       |-ImplicitCastExpr 0xc8 <col:62> 'int *' <LValueToRValue>
+|         |-DeclRefExpr 0xc9 <col:62> 'int *' lvalue Var 0xb4 'h' 'int *'
+|-RecordDecl 0xd0 <line:8:1, col:19> col:8 struct s definition
+| |-FieldDecl 0xd1 <col:12, col:16> col:16 f 'int'
+|-FunctionDecl 0xe0 <line:9:1, col:76> col:6 member 'void (int **)'
+  |-ParmVarDecl 0xe1 <col:13, col:19> col:19 used o 'int **'
+  |-CompoundStmt 0xe2 <col:22, col:76>
+    |-DeclStmt 0xe3 <col:24, col:50>
+    | |-VarDecl 0xe4 <col:24, col:49> col:34 used k 'struct s *' cinit
+    |   |-ImplicitCastExpr 0xe5 <col:38, col:49> 'struct s *' <BitCast>
+    |     |-CallExpr 0xe6 <col:38, col:49> 'void *'
+    |       |-ImplicitCastExpr 0xe7 <col:38> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+    |       | |-DeclRefExpr 0xe8 <col:38> 'void *(unsigned long)' Function 0x2 'malloc' 'void *(unsigned long)'
+    |       |-ImplicitCastExpr 0xe9 <col:45> 'unsigned long' <IntegralCast>
+    |         |-IntegerLiteral 0xea <col:45> 'int' 4
+    |-BinaryOperator 0xeb <col:52, col:62> 'int *' '='
+    | |-UnaryOperator 0xec <col:52, col:53> 'int *' lvalue prefix '*' cannot overflow
+    | | |-ImplicitCastExpr 0xed <col:53> 'int **' <LValueToRValue>
+    | |   |-DeclRefExpr 0xee <col:53> 'int **' lvalue ParmVar 0xe1 'o' 'int **'
+    | |-UnaryOperator 0xef <col:57, col:62> 'int *' prefix '&' cannot overflow
+    |   |-MemberExpr 0xf0 <col:58, col:61> 'int' lvalue ->f 0xd1
+    |     |-ImplicitCastExpr 0xf1 <col:58> 'struct s *' <LValueToRValue>
+    |       |-DeclRefExpr 0xf2 <col:58> 'struct s *' lvalue Var 0xe4 'k' 'struct s *'
+    |-CallExpr 0xf3 <col:65, col:71> 'void'
+      |-ImplicitCastExpr 0xf4 <col:65> 'void (*)(void *)' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0xf5 <col:65> 'void (void *)' Function 0x4 'free' 'void (void *)'
+      |-ImplicitCastExpr 0xf6 <col:70> 'void *' <BitCast>
+        |-ImplicitCastExpr 0xf7 <col:70> 'struct s *' <LValueToRValue>
+          |-DeclRefExpr 0xf8 <col:70> 'struct s *' lvalue Var 0xe4 'k' 'struct s *'
+`
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+	output := transpileDump(t, p, dump)
+
+	// Only the memory of kept() does not escape the function.
+	if n := strings.Count(output, "defer "); n != 1 {
+		t.Errorf("Expected one defer, got %d in:\n%s", n, output)
+	}
+	expectContains(t, output,
+		"defer noarch.Free(unsafe.Pointer(a))\n",
+		"\t\tnoarch.Free(unsafe.Pointer(b))\n",
+		"\tnoarch.Free(unsafe.Pointer(c))\n",
+		"\tnoarch.Free(unsafe.Pointer(d))\n",
+		"\tnoarch.Free(unsafe.Pointer(e))\n",
+		"\tnoarch.Free(unsafe.Pointer(h))\n",
+		"\tnoarch.Free(unsafe.Pointer(k))\n",
+	)
+	if strings.Contains(output, "\tnoarch.Free(unsafe.Pointer(a))") {
+		t.Errorf("Unexpected free() of a in:\n%s", output)
+	}
+}
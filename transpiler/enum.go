@@ -232,15 +232,31 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 		p.DefineType(n.Name)
 	}
 
-	baseType := util.NewTypeIdent(n.Name)
-
 	decl := &goast.GenDecl{
 		Tok: token.CONST,
 	}
 
-	// counter for replace iota
-	var counter int
-	var i int
+	// All of the constants have the type of the enum and an explicit value,
+	// so that the gaps between the values are kept:
+	//
+	//     enum Color { RED, GREEN = 5, BLUE };
+	//
+	//     type Color int32
+	//     const (
+	//         RED   Color = 0
+	//         GREEN Color = 5
+	//         BLUE  Color = 6
+	//     )
+	//
+	// A constant without a value is one more than the previous constant. If
+	// the value of a constant cannot be evaluated, like "WHITE = BLUE + 10",
+	// it is converted to the enum type and the next constants are counted
+	// from its name, like "BLACK Color = WHITE + 1".
+	var (
+		base    string
+		counter int64
+		i       int
+	)
 	for _, c := range n.Children() {
 		if _, ok := c.(*ast.EnumConstantDecl); !ok {
 			// add for avoid comments elements
@@ -250,46 +266,31 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
 		e.Names[0].Obj = goast.NewObj(goast.Con, e.Names[0].Name)
-
-		if i > 0 {
-			e.Type = nil
-			e.Values = nil
-		}
-
-		if i == 0 {
-			e.Type = baseType
-			if t, ok := e.Type.(*goast.Ident); ok {
-				t.Obj = &goast.Object{
-					Name: n.Name,
-					Kind: goast.Typ,
-					Decl: &goast.TypeSpec{
-						Name: &goast.Ident{
-							Name: n.Name,
-						},
-						Type: &goast.Ident{
-							Name: "int", // enum in C is "INT" by default
-						},
-					},
+		e.Type = util.NewTypeIdent(n.Name)
+
+		value := e.Values[0]
+		if id, ok := value.(*goast.Ident); !ok || id.Name != "iota" {
+			if isConst, v := util.EvaluateConstExpr(value); isConst {
+				base, counter = "", v
+			} else {
+				e.Values[0] = &goast.CallExpr{
+					Fun:  util.NewTypeIdent(n.Name),
+					Args: []goast.Expr{value},
 				}
+				base, counter = e.Names[0].Name, 0
 			}
 		}
 
-		if len(c.(*ast.EnumConstantDecl).ChildNodes) > 0 {
-			if integr, ok := c.(*ast.EnumConstantDecl).ChildNodes[0].(*ast.IntegerLiteral); ok {
-				is, err := strconv.ParseInt(integr.Value, 10, 64)
-				if err != nil {
-					p.AddMessage(p.GenerateWarningMessage(err, n))
-				}
-				counter = int(is)
-			}
+		lit := &goast.BasicLit{
+			Kind:  token.INT,
+			Value: strconv.FormatInt(counter, 10),
 		}
-
-		// Insert value of constants
-		e.Values = []goast.Expr{
-			&goast.BasicLit{
-				Kind:  token.INT,
-				Value: strconv.Itoa(counter),
-			},
+		switch {
+		case base == "":
+			e.Values[0] = lit
+		case base != e.Names[0].Name:
+			e.Values[0] = util.NewBinaryExpr(util.NewIdent(base), token.ADD, lit,
+				n.Name, false)
 		}
 
 		// Position inside (....), it is
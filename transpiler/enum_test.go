@@ -0,0 +1,71 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+)
+
+func TestEnumDecl(t *testing.T) {
+	// enum Color { RED, GREEN = 5, BLUE, WHITE = -1, BLACK, PINK = BLUE + 10, GREY };
+	dump := `
+EnumDecl 0x100 <x.c:1:1, col:70> col:6 Color
+|-EnumConstantDecl 0x101 <col:14> col:14 RED 'int'
+|-EnumConstantDecl 0x102 <col:19, col:27> col:19 GREEN 'int'
+| |-ConstantExpr 0x103 <col:27> 'int'
+|   |-IntegerLiteral 0x104 <col:27> 'int' 5
+|-EnumConstantDecl 0x105 <col:30> col:30 referenced BLUE 'int'
+|-EnumConstantDecl 0x106 <col:36, col:45> col:36 WHITE 'int'
+| |-ConstantExpr 0x107 <col:44, col:45> 'int'
+|   |-UnaryOperator 0x108 <col:44, col:45> 'int' prefix '-'
+|     |-IntegerLiteral 0x109 <col:45> 'int' 1
+|-EnumConstantDecl 0x10a <col:48> col:48 BLACK 'int'
+|-EnumConstantDecl 0x10b <col:55, col:63> col:55 PINK 'int'
+| |-ConstantExpr 0x10c <col:61, col:63> 'int'
+|   |-BinaryOperator 0x10d <col:61, col:63> 'int' '+'
+|     |-DeclRefExpr 0x10e <col:61> 'int' EnumConstant 0x105 'BLUE' 'int'
+|     |-IntegerLiteral 0x10f <col:63> 'int' 10
+|-EnumConstantDecl 0x110 <col:66> col:66 GREY 'int'
+`
+
+	p := program.NewProgram()
+	decls, err := transpileEnumDecl(p, parseTree(dump).(*ast.EnumDecl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	for _, decl := range decls {
+		if err := format.Node(&buf, token.NewFileSet(), decl); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteString("\n")
+	}
+	want := `type Color int32
+const (
+	RED   Color = 0
+	GREEN Color = 5
+	BLUE  Color = 6
+	WHITE Color = -1
+	BLACK Color = 0
+	PINK  Color = Color(int32((BLUE)) + int32((Color((int32(10))))))
+	GREY  Color = PINK + 1
+)
+`
+	if buf.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	// The enum can be used as a type, like for a parameter.
+	if goType, err := types.ResolveType(p, "enum Color"); err != nil || goType != "Color" {
+		t.Errorf("ResolveType(enum Color) = %q, %v", goType, err)
+	}
+	if p.EnumConstantToEnum["GREY"] != "enum Color" {
+		t.Errorf("GREY is not registered as a constant of enum Color")
+	}
+}
@@ -0,0 +1,92 @@
+// This file contains functions for transpiling the C functions that report a
+// failure through errno into Go functions with an extra error result. See
+// Program.ErrnoFunctions.
+
+package transpiler
+
+import (
+	goast "go/ast"
+	"go/token"
+
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// newErrnoResult returns the expression of the error result, it is nil if
+// errno is zero.
+func newErrnoResult(p *program.Program) goast.Expr {
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	return util.NewCallExpr("noarch.GetErrnoError")
+}
+
+// newErrnoFuncType returns the type of a function that has the extra error
+// result. The body is changed to reset errno at the start, so that the failure
+// of an earlier call is not returned, and to always end with a return
+// statement.
+//
+// Example of a function that returns an int:
+//
+//     func my_open(path *byte) (c2goDefaultReturn int32, c2goErrno error) {
+//         *noarch.Errno() = 0
+//         ...
+//         return c2goDefaultReturn, noarch.GetErrnoError()
+//     }
+func newErrnoFuncType(p *program.Program, fieldList *goast.FieldList,
+	body *goast.BlockStmt, returnType string) *goast.FuncType {
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	reset := &goast.AssignStmt{
+		Lhs: []goast.Expr{&goast.StarExpr{X: util.NewCallExpr("noarch.Errno")}},
+		Tok: token.ASSIGN,
+		Rhs: []goast.Expr{util.NewIntLit(0)},
+	}
+	body.List = append([]goast.Stmt{reset}, body.List...)
+
+	var addReturnName bool
+	if _, ok := body.List[len(body.List)-1].(*goast.ReturnStmt); !ok {
+		ret := &goast.ReturnStmt{}
+		if returnType != "" {
+			ret.Results = append(ret.Results, util.NewIdent("c2goDefaultReturn"))
+			addReturnName = true
+		}
+		ret.Results = append(ret.Results, newErrnoResult(p))
+		body.List = append(body.List, ret)
+	}
+
+	results := []*goast.Field{}
+	if returnType != "" {
+		results = append(results, &goast.Field{Type: util.NewTypeIdent(returnType)})
+	}
+	results = append(results, &goast.Field{Type: util.NewTypeIdent("error")})
+	if addReturnName {
+		results[0].Names = []*goast.Ident{util.NewIdent("c2goDefaultReturn")}
+		results[1].Names = []*goast.Ident{util.NewIdent("c2goErrno")}
+	}
+
+	return &goast.FuncType{
+		Params:  fieldList,
+		Results: &goast.FieldList{List: results},
+	}
+}
+
+// newErrnoCall discards the error result of the call of a function that
+// returns errno, because the C code only expects the value:
+//
+//     func() int32 {
+//         c2goValue, _ := my_open(path)
+//         return c2goValue
+//     }()
+func newErrnoCall(p *program.Program, call *goast.CallExpr,
+	returnType string) (*goast.CallExpr, error) {
+	t, err := types.ResolveType(p, returnType)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewAnonymousFunction([]goast.Stmt{
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{util.NewIdent("c2goValue"), goast.NewIdent("_")},
+			Tok: token.DEFINE,
+			Rhs: []goast.Expr{call},
+		},
+	}, nil, util.NewIdent("c2goValue"), t), nil
+}
@@ -0,0 +1,90 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestErrnoFunction(t *testing.T) {
+	// int my_open(int exists) {
+	//     if (!exists) {
+	//         errno = 2;
+	//         return -1;
+	//     }
+	//     return 3;
+	// }
+	//
+	// int main() {
+	//     return my_open(1);
+	// }
+	myOpen := parseTree(`
+FunctionDecl 0x10 <x.c:1:1, line:7:1> line:1:5 used my_open 'int (int)'
+|-ParmVarDecl 0x11 <col:13, col:17> col:17 used exists 'int'
+|-CompoundStmt 0x12 <col:25, line:7:1>
+  |-IfStmt 0x13 <line:2:5, line:5:5>
+  | |-UnaryOperator 0x14 <line:2:9, col:10> 'int' prefix '!'
+  | | |-ImplicitCastExpr 0x15 <col:10> 'int' <LValueToRValue>
+  | |   |-DeclRefExpr 0x16 <col:10> 'int' lvalue ParmVar 0x11 'exists' 'int'
+  | |-CompoundStmt 0x17 <col:18, line:5:5>
+  |   |-BinaryOperator 0x18 <line:3:9, col:17> 'int' '='
+  |   | |-UnaryOperator 0x19 <col:9> 'int' lvalue prefix '*'
+  |   | | |-CallExpr 0x1a <col:9> 'int *'
+  |   | |   |-ImplicitCastExpr 0x1b <col:9> 'int *(*)(void)' <FunctionToPointerDecay>
+  |   | |     |-DeclRefExpr 0x1c <col:9> 'int *(void)' Function 0x1d '__errno_location' 'int *(void)'
+  |   | |-IntegerLiteral 0x1e <col:17> 'int' 2
+  |   |-ReturnStmt 0x1f <line:4:9, col:17>
+  |     |-UnaryOperator 0x20 <col:16, col:17> 'int' prefix '-'
+  |       |-IntegerLiteral 0x21 <col:17> 'int' 1
+  |-ReturnStmt 0x22 <line:6:5, col:12>
+    |-IntegerLiteral 0x23 <col:12> 'int' 3
+`)
+	main := parseTree(`
+FunctionDecl 0x30 <x.c:9:1, line:11:1> line:9:5 main 'int ()'
+|-CompoundStmt 0x31 <col:12, line:11:1>
+  |-ReturnStmt 0x32 <line:10:5, col:21>
+    |-CallExpr 0x33 <col:12, col:21> 'int'
+      |-ImplicitCastExpr 0x34 <col:12> 'int (*)(int)' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0x35 <col:12> 'int (int)' Function 0x10 'my_open' 'int (int)'
+      |-IntegerLiteral 0x36 <col:20> 'int' 1
+`)
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "errno.h"}}
+	p.ErrnoFunctions = []string{"my_open", "main"}
+
+	var buf bytes.Buffer
+	for _, n := range []ast.Node{myOpen, main} {
+		decls, err := transpileFunctionDecl(n.(*ast.FunctionDecl), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteString("\n")
+	}
+
+	expected := `func my_open(exists int32) (int32, error) {
+	*noarch.Errno() = 0
+	if exists == 0 {
+		*noarch.Errno() = int32(2)
+		return -int32(1), noarch.GetErrnoError()
+	}
+	return int32(3), noarch.GetErrnoError()
+}
+func main() {
+	os.Exit(int(func() int32 {
+		c2goValue, _ := my_open(int32(1))
+		return c2goValue
+	}()))
+}
+`
+	if actual := buf.String(); actual != expected {
+		t.Errorf("Unexpected output:\n%s\nExpected:\n%s", actual, expected)
+	}
+}
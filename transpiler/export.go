@@ -0,0 +1,95 @@
+// This file contains functions for renaming the C functions that are exported
+// from the Go package. See Program.ExportFunctions.
+
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/util"
+)
+
+// exportFunctions renames the functions of Program.ExportFunctions that are
+// defined by the C code to exported Go names, by making the first letter upper
+// case:
+//
+//     int parse_line(char *s)   =>   func Parse_line(s *byte) int32
+//
+// All the declarations of a function and all the references to it (calls and
+// function pointers) are renamed. A function of a library, that is only
+// declared, keeps its name.
+//
+// A function is not renamed if the new name is already used by another
+// declaration of the translation unit, like the functions "parse" and "Parse",
+// or if it cannot be exported, like "_parse". A warning is added instead.
+func exportFunctions(p *program.Program, n *ast.TranslationUnitDecl) {
+	if len(p.ExportFunctions) == 0 {
+		return
+	}
+
+	used := map[string]bool{}
+	defined := map[string]*ast.FunctionDecl{}
+	for _, c := range n.Children() {
+		switch v := c.(type) {
+		case *ast.FunctionDecl:
+			used[v.Name] = true
+			if getFunctionBody(v) != nil {
+				defined[v.Name] = v
+			}
+		case *ast.VarDecl:
+			used[v.Name] = true
+		case *ast.TypedefDecl:
+			used[v.Name] = true
+		case *ast.RecordDecl:
+			used[v.Name] = true
+		}
+	}
+
+	renames := map[string]string{}
+	for name, f := range defined {
+		if !p.IsExportFunction(name) {
+			continue
+		}
+		exported := util.Ucfirst(name)
+		if exported == name {
+			if !goast.IsExported(name) {
+				p.AddMessage(p.GenerateWarningMessage(
+					fmt.Errorf("cannot export function %s", name), f))
+			}
+			continue
+		}
+		if used[exported] {
+			p.AddMessage(p.GenerateWarningMessage(
+				fmt.Errorf("cannot export function %s: %s is already used", name, exported), f))
+			continue
+		}
+		renames[name] = exported
+	}
+	if len(renames) == 0 {
+		return
+	}
+
+	var rename func(node ast.Node)
+	rename = func(node ast.Node) {
+		if node == nil {
+			return
+		}
+		switch v := node.(type) {
+		case *ast.FunctionDecl:
+			if name, ok := renames[v.Name]; ok {
+				v.Name = name
+			}
+		case *ast.DeclRefExpr:
+			if name, ok := renames[v.Name]; ok && v.For == "Function" {
+				v.Name = name
+			}
+		}
+		for _, c := range node.Children() {
+			rename(c)
+		}
+	}
+	rename(n)
+}
@@ -0,0 +1,83 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestExportFunctions(t *testing.T) {
+	// int twice(int a);
+	// int twice(int a) { return a * 2; }
+	// int half(int a) { return a / 2; }
+	// int Half(int a) { return a >> 1; }
+	// int main(void) { int (*f)(int) = twice; return twice(half(Half(4))) + f(1); }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:18> col:5 used twice 'int (int)'
+| |-ParmVarDecl 0x11 <col:11, col:15> col:15 a 'int'
+|-FunctionDecl 0x20 prev 0x10 <line:2:1, col:34> col:5 used twice 'int (int)'
+| |-ParmVarDecl 0x21 <col:11, col:15> col:15 used a 'int'
+| |-CompoundStmt 0x22 <col:18, col:34>
+|   |-ReturnStmt 0x23 <col:20, col:31>
+|     |-BinaryOperator 0x24 <col:27, col:31> 'int' '*'
+|       |-ImplicitCastExpr 0x25 <col:27> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x26 <col:27> 'int' lvalue ParmVar 0x21 'a' 'int'
+|       |-IntegerLiteral 0x27 <col:31> 'int' 2
+|-FunctionDecl 0x30 <line:3:1, col:33> col:5 used half 'int (int)'
+| |-ParmVarDecl 0x31 <col:10, col:14> col:14 used a 'int'
+| |-CompoundStmt 0x32 <col:17, col:33>
+|   |-ReturnStmt 0x33 <col:19, col:30>
+|     |-BinaryOperator 0x34 <col:26, col:30> 'int' '/'
+|       |-ImplicitCastExpr 0x35 <col:26> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x36 <col:26> 'int' lvalue ParmVar 0x31 'a' 'int'
+|       |-IntegerLiteral 0x37 <col:30> 'int' 2
+|-FunctionDecl 0x40 <line:4:1, col:34> col:5 used Half 'int (int)'
+| |-ParmVarDecl 0x41 <col:10, col:14> col:14 used a 'int'
+| |-CompoundStmt 0x42 <col:17, col:34>
+|   |-ReturnStmt 0x43 <col:19, col:31>
+|     |-BinaryOperator 0x44 <col:26, col:31> 'int' '>>'
+|       |-ImplicitCastExpr 0x45 <col:26> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x46 <col:26> 'int' lvalue ParmVar 0x41 'a' 'int'
+|       |-IntegerLiteral 0x47 <col:31> 'int' 1
+|-FunctionDecl 0x50 <line:5:1, col:80> col:5 main 'int (void)'
+  |-CompoundStmt 0x51 <col:16, col:80>
+    |-DeclStmt 0x52 <col:18, col:40>
+    | |-VarDecl 0x53 <col:18, col:35> col:24 used f 'int (*)(int)' cinit
+    |   |-ImplicitCastExpr 0x54 <col:35> 'int (*)(int)' <FunctionToPointerDecay>
+    |     |-DeclRefExpr 0x55 <col:35> 'int (int)' Function 0x20 'twice' 'int (int)'
+    |-ReturnStmt 0x56 <col:42, col:77>
+      |-BinaryOperator 0x57 <col:49, col:77> 'int' '+'
+        |-CallExpr 0x58 <col:49, col:70> 'int'
+        | |-ImplicitCastExpr 0x59 <col:49> 'int (*)(int)' <FunctionToPointerDecay>
+        | | |-DeclRefExpr 0x5a <col:49> 'int (int)' Function 0x20 'twice' 'int (int)'
+        | |-CallExpr 0x5b <col:55, col:69> 'int'
+        |   |-ImplicitCastExpr 0x5c <col:55> 'int (*)(int)' <FunctionToPointerDecay>
+        |   | |-DeclRefExpr 0x5d <col:55> 'int (int)' Function 0x30 'half' 'int (int)'
+        |   |-CallExpr 0x5e <col:60, col:68> 'int'
+        |     |-ImplicitCastExpr 0x5f <col:60> 'int (*)(int)' <FunctionToPointerDecay>
+        |     | |-DeclRefExpr 0x60 <col:60> 'int (int)' Function 0x40 'Half' 'int (int)'
+        |     |-IntegerLiteral 0x61 <col:65> 'int' 4
+        |-CallExpr 0x62 <col:74, col:77> 'int'
+          |-ImplicitCastExpr 0x63 <col:74> 'int (*)(int)' <LValueToRValue>
+          | |-DeclRefExpr 0x64 <col:74> 'int (*)(int)' lvalue Var 0x53 'f' 'int (*)(int)'
+          |-IntegerLiteral 0x65 <col:76> 'int' 1
+`
+
+	p := program.NewProgram()
+	// The invalid expression does not match any function.
+	p.ExportFunctions = []string{"twice", "h.*", "("}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"func Twice(a int32) int32 {",
+		"Twice(half(Half(int32(4))))",
+		// Exporting half would collide with the function Half.
+		"func half(a int32) int32 {",
+		"cannot export function half: Half is already used",
+	)
+	if strings.Contains(output, "twice") {
+		t.Errorf("Unexpected twice in:\n%s", output)
+	}
+}
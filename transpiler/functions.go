@@ -14,6 +14,7 @@ import (
 	"github.com/elliotchance/c2go/util"
 
 	goast "go/ast"
+	"go/parser"
 	"go/token"
 )
 
@@ -54,20 +55,13 @@ func transpileFunctionDecl(n *ast.FunctionDecl, p *program.Program) (
 	defer func() {
 		// Reset the function name when we go out of scope.
 		p.Function = nil
+		p.EndLocalTypes()
 	}()
 
-	n.Name = util.ConvertFunctionNameFromCtoGo(n.Name)
-
-	// Always register the new function. Only from this point onwards will
-	// we be allowed to refer to the function.
-	if p.GetFunctionDefinition(n.Name) == nil {
-		p.AddFunctionDefinition(program.FunctionDefinition{
-			Name:          n.Name,
-			ReturnType:    getFunctionReturnType(n.Type),
-			ArgumentTypes: getFunctionArgumentTypes(n),
-			Substitution:  "",
-		})
-	}
+	// The function is usually registered already by
+	// registerFunctionDefinitions(), but not when only this declaration is
+	// transpiled.
+	registerFunctionDefinition(n, p)
 
 	// If the function has a direct substitute in Go we do not want to
 	// output the C definition of it.
@@ -77,17 +71,36 @@ func transpileFunctionDecl(n *ast.FunctionDecl, p *program.Program) (
 		return
 	}
 
+	// An inlined function is transpiled at its calls instead.
+	if _, ok := p.InlinedFunctions[n.Name]; ok {
+		return
+	}
+
 	// Test if the function has a body. This is identified by a child node that
 	// is a CompoundStmt (since it is not valid to have a function body without
 	// curly brackets).
 	functionBody := getFunctionBody(n)
 	if functionBody != nil {
+		if p.FunctionMessageSummary {
+			p.BeginFunctionMessages()
+			defer p.EndFunctionMessages()
+		}
+
+		var frees map[ast.Address]deferredFree
+		if !p.DisableDeferredFree {
+			frees = findDeferredFrees(p, functionBody)
+		}
+
 		var pre, post []goast.Stmt
 		body, pre, post, err = transpileToBlockStmt(functionBody, p)
 		if err != nil || len(pre) > 0 || len(post) > 0 {
 			p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Not correct result in function %s body: err = %v", n.Name, err), n))
 			err = nil // Error is ignored
 		}
+
+		insertDeferredFrees(p, body, frees)
+		replaceGotosAfterLoops(body)
+		hoistDeclarationsForGoto(body)
 	}
 
 	// These functions cause us trouble for whatever reason. Some of them might
@@ -98,10 +111,7 @@ func transpileFunctionDecl(n *ast.FunctionDecl, p *program.Program) (
 	if n.Name == "__istype" ||
 		n.Name == "__isctype" ||
 		n.Name == "__wcwidth" ||
-		n.Name == "__sputc" ||
-		n.Name == "__inline_signbitf" ||
-		n.Name == "__inline_signbitd" ||
-		n.Name == "__inline_signbitl" {
+		n.Name == "__sputc" {
 		err = nil
 		return
 	}
@@ -124,146 +134,345 @@ func transpileFunctionDecl(n *ast.FunctionDecl, p *program.Program) (
 		t, err := types.ResolveType(p, f.ReturnType)
 		p.AddMessage(p.GenerateWarningMessage(err, n))
 
+		// A function that never returns does not need a result.
+		if f.NoReturn {
+			t = ""
+		}
+
 		if p.Function != nil && p.Function.Name == "main" {
 			// main() function does not have a return type.
 			t = ""
 
-			// This collects statements that will be placed at the top of
-			// (before any other code) in main().
-			prependStmtsInMain := []goast.Stmt{}
-
 			// In Go, the main() function does not take the system arguments.
 			// Instead they are accessed through the os package. We create new
-			// variables in the main() function (if needed), immediately after
-			// the __init() for these variables.
-			if len(fieldList.List) > 0 {
-				p.AddImport("os")
-
-				prependStmtsInMain = append(
-					prependStmtsInMain,
-					&goast.AssignStmt{
-						Lhs: []goast.Expr{fieldList.List[0].Names[0]},
-						Tok: token.DEFINE,
-						Rhs: []goast.Expr{util.NewCallExpr("int32", util.NewCallExpr("len", util.NewTypeIdent("os.Args")))},
-					},
-				)
+			// variables at the top of the main() function (if needed).
+			body.List = append(getMainArguments(p, n), body.List...)
+
+			// The main() function does not have arguments or a return value.
+			fieldList = &goast.FieldList{}
+
+			// The functions that are registered with atexit() are called
+			// when main() returns at the end of its body as well.
+			if p.UsesAtexit && !endsWithReturn(functionBody) {
+				p.AddImport("github.com/elliotchance/c2go/noarch")
+				body.List = append(body.List, util.NewExprStmt(
+					util.NewCallExpr("noarch.Exit", util.NewIntLit(0))))
 			}
+		}
 
-			if len(fieldList.List) > 1 {
-				argvMultiArrayName := &goast.Ident{}
-				argvArrayName := &goast.Ident{}
-				*argvArrayName = *fieldList.List[1].Names[0]
-				*argvMultiArrayName = *argvArrayName
-				argvArrayName.Name += "__array"
-				argvMultiArrayName.Name += "__multiarray"
-				prependStmtsInMain = append(
-					prependStmtsInMain,
-					&goast.AssignStmt{
-						Lhs: []goast.Expr{argvMultiArrayName},
-						Tok: token.DEFINE,
-						Rhs: []goast.Expr{&goast.CompositeLit{Type: util.NewTypeIdent("[][]byte")}},
-					},
-					&goast.AssignStmt{
-						Lhs: []goast.Expr{argvArrayName},
-						Tok: token.DEFINE,
-						Rhs: []goast.Expr{&goast.CompositeLit{Type: util.NewTypeIdent("[]*byte")}},
-					},
-					&goast.RangeStmt{
-						Key:   goast.NewIdent("_"),
-						Value: util.NewIdent("argvSingle"),
-						Tok:   token.DEFINE,
-						X:     util.NewTypeIdent("os.Args"),
-						Body: &goast.BlockStmt{
-							List: []goast.Stmt{
-								&goast.AssignStmt{
-									Lhs: []goast.Expr{argvMultiArrayName},
-									Tok: token.ASSIGN,
-									Rhs: []goast.Expr{util.NewCallExpr(
-										"append",
-										argvMultiArrayName,
-										util.NewCallExpr("append",
-											util.NewCallExpr("[]byte", util.NewIdent("argvSingle")),
-											util.NewIntLit(0)),
-									)},
-								},
-							},
-						},
-					},
-					&goast.RangeStmt{
-						Key:   goast.NewIdent("_"),
-						Value: util.NewIdent("argvSingle"),
-						Tok:   token.DEFINE,
-						X:     argvMultiArrayName,
-						Body: &goast.BlockStmt{
-							List: []goast.Stmt{
-								&goast.AssignStmt{
-									Lhs: []goast.Expr{argvArrayName},
-									Tok: token.ASSIGN,
-									Rhs: []goast.Expr{util.NewCallExpr(
-										"append",
-										argvArrayName,
-										&goast.UnaryExpr{
-											Op: token.AND,
-											X: &goast.IndexExpr{
-												X:     util.NewIdent("argvSingle"),
-												Index: util.NewIntLit(0),
-											},
-										},
-									)},
-								},
-							},
-						},
-					},
-					&goast.AssignStmt{
-						Lhs: []goast.Expr{fieldList.List[1].Names[0]},
-						Tok: token.DEFINE,
-						Rhs: []goast.Expr{
-							&goast.StarExpr{
-								X: &goast.CallExpr{
-									Fun: &goast.ParenExpr{
-										X: util.NewTypeIdent("***byte"),
-									},
-									Args: []goast.Expr{
-										util.NewCallExpr("unsafe.Pointer", &goast.UnaryExpr{
-											Op: token.AND,
-											X:  argvArrayName,
-										}),
-									},
-								},
-							},
-						},
-					})
+		var results []string
+		if t != "" {
+			results = append(results, t)
+		}
+		if f.ReturnsErrno {
+			results = append(results, "error")
+		}
+		transpileSetjmp(p, n, body, results)
+
+		var funcType *goast.FuncType
+		if f.ReturnsErrno {
+			funcType = newErrnoFuncType(p, fieldList, body, t)
+		} else {
+			// Each function MUST have "ReturnStmt",
+			// except function without return type
+			var addReturnName bool
+			if len(body.List) > 0 {
+				last := body.List[len(body.List)-1]
+				if _, ok := last.(*goast.ReturnStmt); !ok && t != "" {
+					body.List = append(body.List, &goast.ReturnStmt{})
+					addReturnName = true
+				}
 			}
+			funcType = util.NewFuncType(fieldList, t, addReturnName)
+		}
 
-			// Prepend statements for main().
-			body.List = append(prependStmtsInMain, body.List...)
+		bridgeStringParameters(p, n, f, body)
 
-			// The main() function does not have arguments or a return value.
-			fieldList = &goast.FieldList{}
-		}
+		method, _, err := getMethod(p, f)
+		p.AddMessage(p.GenerateWarningMessage(err, n))
 
-		// Each function MUST have "ReturnStmt",
-		// except function without return type
-		var addReturnName bool
-		if len(body.List) > 0 {
-			last := body.List[len(body.List)-1]
-			if _, ok := last.(*goast.ReturnStmt); !ok && t != "" {
-				body.List = append(body.List, &goast.ReturnStmt{})
-				addReturnName = true
+		doc := getFunctionMessageSummary(p, f.Name)
+		if c := getConstComment(p, n); c != nil {
+			if doc == nil {
+				doc = c
+			} else {
+				doc.List = append(doc.List, c.List...)
 			}
 		}
 
-		decls = append(decls, &goast.FuncDecl{
-			Name: util.NewIdent(n.Name),
-			Type: util.NewFuncType(fieldList, t, addReturnName),
+		decl := &goast.FuncDecl{
+			Doc:  doc,
+			Name: util.NewIdent(p.GoIdentifier(n.Name)),
+			Type: funcType,
 			Body: body,
-		})
+		}
+		if method != "" {
+			newMethodFuncDecl(decl, method)
+		} else if p.TailCalls && !f.ReturnsErrno && n.Name != "main" {
+			eliminateTailCalls(decl)
+		}
+		decls = append(decls, decl)
 	}
 
 	err = nil
 	return
 }
 
+// getFunctionMessageSummary returns the comment with all of the messages that
+// were generated while the function was transpiled, or nil if there are none
+// or Program.FunctionMessageSummary is off. In verbose mode the messages are
+// also printed after the name of the function.
+func getFunctionMessageSummary(p *program.Program, name string) *goast.CommentGroup {
+	if !p.FunctionMessageSummary {
+		return nil
+	}
+	messages := p.EndFunctionMessages()
+	if len(messages) == 0 {
+		return nil
+	}
+
+	doc := &goast.CommentGroup{}
+	doc.List = append(doc.List, &goast.Comment{
+		Text: fmt.Sprintf("// Summary of %d messages in %s():", len(messages), name),
+	})
+	for _, m := range messages {
+		doc.List = append(doc.List, &goast.Comment{Text: m})
+		if p.Verbose {
+			fmt.Println(m)
+		}
+	}
+
+	return doc
+}
+
+// registerFunctionDefinitions registers all of the functions of the
+// translation unit before any of them is transpiled. Otherwise a function
+// could not be called before it is declared, like in mutual recursion without
+// a prototype.
+func registerFunctionDefinitions(p *program.Program, n *ast.TranslationUnitDecl) {
+	for _, c := range n.Children() {
+		if f, ok := c.(*ast.FunctionDecl); ok {
+			registerFunctionDefinition(f, p)
+		}
+	}
+}
+
+// registerFunctionDefinition registers the function, unless it is known
+// already by an earlier declaration or a built-in definition. It is safe to
+// call it more than once for the same declaration. Only from this point
+// onwards will we be allowed to refer to the function.
+func registerFunctionDefinition(n *ast.FunctionDecl, p *program.Program) {
+	n.Name = util.ConvertFunctionNameFromCtoGo(n.Name)
+	decayArrayParameters(n)
+	noReturn := isNoReturnFunction(n)
+
+	// The prototype of a K&R definition replaces the empty one of an earlier
+	// declaration, like "int f();".
+	if fixKAndRFunction(n) {
+		if f := p.GetFunctionDefinition(n.Name); f != nil &&
+			len(f.ArgumentTypes) == 0 {
+			f.ArgumentTypes = getFunctionArgumentTypes(n)
+			p.AddFunctionDefinition(*f)
+		}
+	}
+
+	// The format attribute can be on a later declaration than the first one.
+	format := getFunctionFormat(n)
+	if f := p.GetFunctionDefinition(n.Name); f != nil {
+		if format != nil && f.Format == "" {
+			setFunctionFormat(f, format)
+			p.AddFunctionDefinition(*f)
+		}
+		return
+	}
+
+	f := program.FunctionDefinition{
+		Name:          n.Name,
+		ReturnType:    getFunctionReturnType(n.Type),
+		ArgumentTypes: getFunctionArgumentTypes(n),
+		Restrict:      getFunctionArgumentRestrict(n),
+		Variadic:      types.IsVariadic(n.Type),
+		NoReturn:      noReturn,
+		ReturnsErrno:  p.IsErrnoFunction(n.Name),
+		Substitution:  "",
+	}
+	if n.IsStatic {
+		f.TranslationUnit = n.Pos.File
+	}
+	if format != nil {
+		setFunctionFormat(&f, format)
+	}
+	p.AddFunctionDefinition(f)
+}
+
+// getFunctionFormat returns the format attribute of the function, or nil if
+// it has none. For example:
+//
+//     void mylog(const char *fmt, ...) __attribute__((format(printf, 1, 2)));
+func getFunctionFormat(n *ast.FunctionDecl) *ast.FormatAttr {
+	for _, c := range n.Children() {
+		if a, ok := c.(*ast.FormatAttr); ok {
+			return a
+		}
+	}
+	return nil
+}
+
+func setFunctionFormat(f *program.FunctionDefinition, a *ast.FormatAttr) {
+	f.Format = a.FunctionName
+	f.FormatIndex = a.Unknown1
+	f.FormatFirstArgument = a.Unknown2
+}
+
+// isNoReturnFunction returns true if the function is declared with _Noreturn
+// or __attribute__((noreturn)). The attribute is removed from the type of the
+// function, so that the type can be parsed like any other function.
+func isNoReturnFunction(n *ast.FunctionDecl) bool {
+	const attribute = " __attribute__((noreturn))"
+	if strings.HasSuffix(n.Type, attribute) {
+		n.Type = strings.TrimSuffix(n.Type, attribute)
+		return true
+	}
+	for _, c := range n.Children() {
+		if _, ok := c.(*ast.C11NoReturnAttr); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fixKAndRFunction sets the prototype of a K&R function definition, like:
+//
+//     int f(a, b)
+//     int a;
+//     {
+//         return a + b;
+//     }
+//
+// The type of such definition is "int ()" in the AST, because it is not a
+// prototype. The types of the parameters are only in the ParmVarDecl nodes
+// that are made from the declarations after the parameter list. A parameter
+// without a declaration is an int.
+//
+// It returns true if the type of the function was changed.
+func fixKAndRFunction(n *ast.FunctionDecl) bool {
+	if !strings.HasSuffix(n.Type, "()") {
+		return false
+	}
+	var params []string
+	for _, c := range n.Children() {
+		if v, ok := c.(*ast.ParmVarDecl); ok {
+			if strings.TrimSpace(v.Type) == "" {
+				v.Type = "int"
+			}
+			params = append(params, v.Type)
+		}
+	}
+	if len(params) == 0 {
+		return false
+	}
+	n.Type = strings.TrimSuffix(n.Type, "()") + "(" + strings.Join(params, ", ") + ")"
+	return true
+}
+
+// getMainArguments returns the statements that create the parameters of the C
+// main() function, because the main() function in Go has no parameters. All of
+// these forms are supported:
+//
+//     int main(int argc, char **argv)
+//     int main(int argc, char *argv[])
+//     int main(int argc, char **argv, char **envp)
+//
+// argv and envp are made from os.Args and os.Environ(). Like in C, each string
+// and the array itself are terminated with NULL.
+func getMainArguments(p *program.Program, n *ast.FunctionDecl) (
+	stmts []goast.Stmt) {
+	var params []*ast.ParmVarDecl
+	for _, c := range n.Children() {
+		if v, ok := c.(*ast.ParmVarDecl); ok {
+			params = append(params, v)
+		}
+	}
+	if len(params) > 3 {
+		p.AddMessage(p.GenerateWarningMessage(
+			fmt.Errorf("main() has %d parameters, expected at most 3", len(params)), n))
+		params = params[:3]
+	}
+
+	p.AddImport("os")
+	sources := []string{"os.Args", "os.Args", "os.Environ()"}
+	for i, param := range params {
+		if param.Name == "" {
+			continue
+		}
+		name := util.NewIdent(p.GoIdentifier(param.Name)).Name
+
+		// A parameter that is declared as an array is a pointer.
+		cType := param.Type
+		if strings.HasSuffix(cType, "[]") {
+			cType = strings.TrimSuffix(cType, "[]") + "*"
+		}
+
+		t, err := types.ResolveType(p, cType)
+		if p.AddMessage(p.GenerateWarningMessage(err, param)) {
+			continue
+		}
+
+		var src string
+		switch {
+		case i == 0 && types.IsGoIntegerType(t):
+			src = fmt.Sprintf("%s := %s(len(os.Args))", name, t)
+
+		case i > 0 && (t == "**byte" || t == "[]*byte"):
+			value := name + "__array"
+			if t == "**byte" {
+				value = "&" + value + "[0]"
+			}
+			src = fmt.Sprintf(`%[1]s__multiarray := [][]byte{}
+				%[1]s__array := []*byte{}
+				for _, argvSingle := range %[2]s {
+					%[1]s__multiarray = append(%[1]s__multiarray, append([]byte(argvSingle), 0))
+				}
+				for _, argvSingle := range %[1]s__multiarray {
+					%[1]s__array = append(%[1]s__array, &argvSingle[0])
+				}
+				%[1]s__array = append(%[1]s__array, nil)
+				%[1]s := %[3]s`, name, sources[i], value)
+
+		default:
+			p.AddMessage(p.GenerateWarningMessage(
+				fmt.Errorf("unsupported type '%s' of parameter %d of main()",
+					param.Type, i+1), param))
+			continue
+		}
+
+		// The parameters of a function can be unused. That is not allowed for
+		// the variables in Go.
+		if !param.IsUsed {
+			src += "\n_ = " + name
+		}
+
+		body, err := parseStmts(src)
+		if err != nil {
+			p.AddMessage(p.GenerateWarningMessage(err, param))
+			continue
+		}
+		stmts = append(stmts, body...)
+	}
+
+	return
+}
+
+// parseStmts returns the Go statements of the source code.
+func parseStmts(src string) ([]goast.Stmt, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "",
+		"package main\nfunc main() {\n"+src+"\n}", 0)
+	if err != nil {
+		return nil, err
+	}
+	return f.Decls[0].(*goast.FuncDecl).Body.List, nil
+}
+
 // getFieldList returns the parameters of a C function as a Go AST FieldList.
 func getFieldList(f *ast.FunctionDecl, p *program.Program) (_ *goast.FieldList, err error) {
 	defer func() {
@@ -271,9 +480,23 @@ func getFieldList(f *ast.FunctionDecl, p *program.Program) (_ *goast.FieldList,
 			err = fmt.Errorf("Error in function field list. err = %v", err)
 		}
 	}()
+	var stringParameters []bool
+	if def := p.GetFunctionDefinition(f.Name); def != nil {
+		stringParameters = def.StringParameters
+	}
 	r := []*goast.Field{}
+	i := -1
 	for _, n := range f.Children() {
 		if v, ok := n.(*ast.ParmVarDecl); ok {
+			i++
+			if len(stringParameters) > i && stringParameters[i] {
+				r = append(r, &goast.Field{
+					Names: []*goast.Ident{util.NewIdent(p.GoIdentifier(v.Name))},
+					Type:  util.NewTypeIdent("string"),
+				})
+				continue
+			}
+			v.Type = implicitInt(v.Type)
 			if types.IsFunction(v.Type) {
 				field, err := newFunctionField(p, v.Name, v.Type)
 				if err != nil {
@@ -291,14 +514,16 @@ func getFieldList(f *ast.FunctionDecl, p *program.Program) (_ *goast.FieldList,
 			p.AddMessage(p.GenerateWarningMessage(err, f))
 
 			r = append(r, &goast.Field{
-				Names: []*goast.Ident{util.NewIdent(v.Name)},
+				Names: []*goast.Ident{util.NewIdent(p.GoIdentifier(v.Name))},
 				Type:  util.NewTypeIdent(t),
 			})
 		}
 	}
 
 	// for function argument: ...
-	if strings.Contains(f.Type, "...") {
+	// The variadic arguments are always collected into c2goArgs, even when
+	// there are no fixed arguments before them.
+	if types.IsVariadic(f.Type) {
 		r = append(r, &goast.Field{
 			Names: []*goast.Ident{util.NewIdent("c2goArgs")},
 			Type: &goast.Ellipsis{
@@ -329,6 +554,11 @@ func transpileReturnStmt(n *ast.ReturnStmt, p *program.Program) (
 	// There may not be a return value. Then we don't have to both ourselves
 	// with all the rest of the logic below.
 	if len(n.Children()) == 0 {
+		if f := p.GetFunctionDefinition(p.Function.Name); f != nil && f.ReturnsErrno {
+			return &goast.ReturnStmt{
+				Results: []goast.Expr{newErrnoResult(p)},
+			}, nil, nil, nil
+		}
 		return &goast.ReturnStmt{}, nil, nil, nil
 	}
 
@@ -344,16 +574,42 @@ func transpileReturnStmt(n *ast.ReturnStmt, p *program.Program) (
 
 	f := p.GetFunctionDefinition(p.Function.Name)
 
+	// Returning from a function that never returns is undefined behavior in
+	// C, and there is no result in Go.
+	if f.NoReturn {
+		p.AddMessage(p.GenerateWarningMessage(
+			fmt.Errorf("return in noreturn function %s", f.Name), n))
+		return &goast.ReturnStmt{}, preStmts, postStmts, nil
+	}
+
 	t, err := types.CastExpr(p, e, eType, f.ReturnType)
 	if p.AddMessage(p.GenerateWarningMessage(err, n)) {
 		t = util.NewNil()
 	}
 
+	// The post statements, like the increment of "return a[i++]", cannot be
+	// after the return, so they are deferred in a closure.
+	if len(postStmts) > 0 {
+		var returnType string
+		returnType, err = types.ResolveType(p, f.ReturnType)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		t = util.NewAnonymousFunction(nil, postStmts, t, returnType)
+		postStmts = nil
+	}
+
 	results := []goast.Expr{t}
 
 	// main() function is not allowed to return a result. Use os.Exit if
-	// non-zero.
+	// non-zero, or noarch.Exit if the functions that are registered with
+	// atexit() must be called.
 	if p.Function != nil && p.Function.Name == "main" {
+		if p.UsesAtexit {
+			p.AddImport("github.com/elliotchance/c2go/noarch")
+			return util.NewExprStmt(util.NewCallExpr("noarch.Exit", t)),
+				preStmts, postStmts, nil
+		}
 		litExpr, isLiteral := getReturnLiteral(e)
 		if !isLiteral || (isLiteral && litExpr.Value != "0") {
 			p.AddImport("os")
@@ -363,11 +619,45 @@ func transpileReturnStmt(n *ast.ReturnStmt, p *program.Program) (
 		results = []goast.Expr{}
 	}
 
+	if f.ReturnsErrno {
+		results = append(results, newErrnoResult(p))
+	}
+
 	return &goast.ReturnStmt{
 		Results: results,
 	}, preStmts, postStmts, nil
 }
 
+// endsWithReturn returns true if the last statement of the function body is a
+// return statement.
+func endsWithReturn(body *ast.CompoundStmt) bool {
+	children := body.Children()
+	if len(children) == 0 {
+		return false
+	}
+	_, ok := children[len(children)-1].(*ast.ReturnStmt)
+
+	return ok
+}
+
+// callsFunction returns true if the function is referenced anywhere in the
+// node, like in a call of the function.
+func callsFunction(node ast.Node, name string) bool {
+	if node == nil {
+		return false
+	}
+	if ref, ok := node.(*ast.DeclRefExpr); ok && ref.For == "Function" && ref.Name == name {
+		return true
+	}
+	for _, c := range node.Children() {
+		if callsFunction(c, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func getReturnLiteral(e goast.Expr) (litExpr *goast.BasicLit, ok bool) {
 	if litExpr, ok = e.(*goast.BasicLit); ok {
 		return
@@ -397,13 +687,117 @@ func getFunctionReturnType(f string) string {
 	//
 	// The arguments will handle themselves, we only care about the return type
 	// ('int' in this case)
+	//
+	// A function that returns a function pointer has the return type inside
+	// the prototype:
+	//
+	//     int (*(int))(int)
+	//
+	// and so does a function that returns a pointer to an array:
+	//
+	//     int (*(int))[10]
+	//
+	// The name of an anonymous struct is generated, otherwise it would be
+	// split as the arguments:
+	//
+	//     struct (anonymous struct at x.c:1:1) (int)
+	f = types.GenerateCorrectType(f)
+	if _, r, err := types.ParseFunction(f); err == nil && len(r) == 1 &&
+		strings.Contains(r[0], "(") {
+		return r[0]
+	}
+
 	returnType := strings.TrimSpace(strings.Split(f, "(")[0])
 
-	if returnType == "" {
-		panic(fmt.Sprintf("unable to extract the return type from: %s", f))
+	return implicitInt(returnType)
+}
+
+// implicitInt returns "int" for a missing C type. Before C99 the type of a
+// function or a parameter could be left out and would be an int, like:
+//
+//     main() { }
+//     f(x) { return x; }
+func implicitInt(cType string) string {
+	if strings.TrimSpace(cType) == "" {
+		return "int"
+	}
+	return cType
+}
+
+// getConstComment returns the comment that lists the parameters of a function
+// that have a const qualifier in C, like "const char *" or "int *const":
+//
+//     // const parameters: s, p
+//
+// go/printer does not print the comments inside of a parameter list, so the
+// comment is a part of the doc of the function. It returns nil if there are no
+// const-qualified parameters or the comments are disabled.
+func getConstComment(p *program.Program, f *ast.FunctionDecl) *goast.CommentGroup {
+	if p.DisableConstComments {
+		return nil
+	}
+	var names []string
+	for _, c := range f.Children() {
+		if v, ok := c.(*ast.ParmVarDecl); ok &&
+			util.GetRegex(`\bconst\b`).MatchString(v.Type) {
+			names = append(names, p.GoIdentifier(v.Name))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return &goast.CommentGroup{
+		List: []*goast.Comment{
+			&goast.Comment{Text: "// const parameters: " + strings.Join(names, ", ")},
+		},
+	}
+}
+
+// getFunctionArgumentRestrict returns true for each of the arguments with a
+// restrict qualifier, see FunctionDefinition.Restrict.
+func getFunctionArgumentRestrict(f *ast.FunctionDecl) []bool {
+	rx := util.GetRegex(`\b(restrict|__restrict|__restrict__)\b`)
+	r := []bool{}
+	for _, t := range getFunctionArgumentTypes(f) {
+		r = append(r, rx.MatchString(t))
 	}
+	return r
+}
 
-	return returnType
+// decayArrayParameters changes the parameters that are declared as arrays,
+// like "int a[10]" or "int m[][3]", to the pointers that they are in C. The
+// references to the parameters in the body are changed as well. This way any
+// array or pointer with the type of the elements can be passed to the function,
+// like a local array or a buffer from malloc().
+func decayArrayParameters(n *ast.FunctionDecl) {
+	decayed := map[ast.Address]string{}
+	for _, c := range n.Children() {
+		if v, ok := c.(*ast.ParmVarDecl); ok {
+			if t := types.DecayArrayType(v.Type); t != v.Type {
+				v.Type, v.Type2 = t, ""
+				decayed[v.Addr] = t
+			}
+		}
+	}
+	if len(decayed) == 0 {
+		return
+	}
+
+	var decay func(ast.Node)
+	decay = func(node ast.Node) {
+		if node == nil {
+			return
+		}
+		if ref, ok := node.(*ast.DeclRefExpr); ok && ref.For == "ParmVar" {
+			if t, ok := decayed[ast.ParseAddress(ref.Address2)]; ok {
+				ref.Type, ref.Type1 = t, ""
+			}
+		}
+		for _, c := range node.Children() {
+			decay(c)
+		}
+	}
+	decay(n)
 }
 
 // getFunctionArgumentTypes returns the C types of the arguments in a function.
@@ -411,7 +805,7 @@ func getFunctionArgumentTypes(f *ast.FunctionDecl) []string {
 	r := []string{}
 	for _, n := range f.Children() {
 		if v, ok := n.(*ast.ParmVarDecl); ok {
-			r = append(r, v.Type)
+			r = append(r, implicitInt(v.Type))
 		}
 	}
 
@@ -0,0 +1,856 @@
+package transpiler
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestConstComments(t *testing.T) {
+	// void f(const char *s, char *const p, const int n, int m) {}
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x2 <x.c:1:1, col:59> col:6 f 'void (const char *, char *const, const int, int)'
+  |-ParmVarDecl 0x3 <col:8, col:20> col:20 s 'const char *'
+  |-ParmVarDecl 0x4 <col:23, col:35> col:35 p 'char *const'
+  |-ParmVarDecl 0x5 <col:38, col:48> col:48 n 'const int'
+  |-ParmVarDecl 0x6 <col:51, col:55> col:55 m 'int'
+  |-CompoundStmt 0x7 <col:58, col:59>
+`
+
+	for _, disable := range []bool{false, true} {
+		p := program.NewProgram()
+		p.DisableConstComments = disable
+		output := transpileDump(t, p, dump)
+
+		want := "// const parameters: s, p, n\nfunc f("
+		if disable {
+			if strings.Contains(output, "const parameters") {
+				t.Errorf("Unexpected const comment in:\n%s", output)
+			}
+		} else if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestFixKAndRFunction(t *testing.T) {
+	// int f(a, b, c)
+	// double a;
+	// char *b;
+	// {
+	//     ...
+	// }
+	//
+	// The parameter "c" has no declaration so its type is missing.
+	f := &ast.FunctionDecl{
+		Name: "f",
+		Type: "int ()",
+		ChildNodes: []ast.Node{
+			&ast.ParmVarDecl{Name: "a", Type: "double"},
+			&ast.ParmVarDecl{Name: "b", Type: "char *"},
+			&ast.ParmVarDecl{Name: "c"},
+			&ast.CompoundStmt{},
+		},
+	}
+	if !fixKAndRFunction(f) {
+		t.Fatal("Expected the K&R function to be fixed")
+	}
+	if f.Type != "int (double, char *, int)" {
+		t.Errorf("Unexpected type: %s", f.Type)
+	}
+
+	p := program.NewProgram()
+	fields, err := getFieldList(f, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"float64", "*byte", "int32"} {
+		if actual := fields.List[i].Type.(*goast.Ident).Name; actual != expected {
+			t.Errorf("%s: expected %s, got %s",
+				fields.List[i].Names[0].Name, expected, actual)
+		}
+	}
+
+	// A function without parameters is not changed.
+	g := &ast.FunctionDecl{Name: "g", Type: "int ()"}
+	if fixKAndRFunction(g) || g.Type != "int ()" {
+		t.Errorf("Unexpected change of function without parameters: %s", g.Type)
+	}
+}
+
+func TestInlineFunctionIsSubstituted(t *testing.T) {
+	// The definition of __inline_signbitf() from darwin/math.h must not be
+	// transpiled, even if math.h was not included directly.
+	f := &ast.FunctionDecl{
+		Name: "__inline_signbitf",
+		Type: "int (float)",
+		ChildNodes: []ast.Node{
+			&ast.ParmVarDecl{Name: "__x", Type: "float"},
+			&ast.CompoundStmt{},
+		},
+	}
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(f, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decls) != 0 {
+		t.Errorf("Expected no declarations, got %d", len(decls))
+	}
+
+	def := p.GetFunctionDefinition("__inline_signbitf")
+	if def == nil ||
+		def.Substitution != "github.com/elliotchance/c2go/noarch.Signbitf" {
+		t.Errorf("Unexpected definition: %#v", def)
+	}
+}
+
+func TestGetMainArguments(t *testing.T) {
+	for _, tc := range []struct {
+		params   []*ast.ParmVarDecl
+		expected []string
+	}{
+		{
+			params: []*ast.ParmVarDecl{
+				{Name: "argc", Type: "int", IsUsed: true},
+				{Name: "argv", Type: "char **", IsUsed: true},
+			},
+			expected: []string{
+				"argc := int32(len(os.Args))",
+				"argv := &argv__array[0]",
+			},
+		},
+		{
+			params: []*ast.ParmVarDecl{
+				{Name: "argc", Type: "int", IsUsed: true},
+				{Name: "argv", Type: "char *[]", IsUsed: true},
+			},
+			expected: []string{
+				"argv__array = append(argv__array, nil)",
+				"argv := &argv__array[0]",
+			},
+		},
+		{
+			params: []*ast.ParmVarDecl{
+				{Name: "argc", Type: "int"},
+				{Name: "argv", Type: "char **"},
+				{Name: "envp", Type: "char **", IsUsed: true},
+			},
+			expected: []string{
+				"_ = argc",
+				"_ = argv",
+				"range os.Environ()",
+				"envp := &envp__array[0]",
+			},
+		},
+	} {
+		n := &ast.FunctionDecl{Name: "main", Type: "int ()"}
+		for _, param := range tc.params {
+			n.AddChild(param)
+		}
+
+		p := program.NewProgram()
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), getMainArguments(p, n)); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range tc.expected {
+			if !strings.Contains(buf.String(), e) {
+				t.Errorf("Expected %q in:\n%s", e, buf.String())
+			}
+		}
+	}
+}
+
+func TestRegisterFunctionDefinitions(t *testing.T) {
+	// static int is_even(unsigned int n) { return n == 0 ? 1 : is_odd(n - 1); }
+	// int is_odd(unsigned int n) { return n == 0 ? 0 : is_even(n - 1); }
+	//
+	// is_odd() is called before it is declared.
+	isEven := &ast.FunctionDecl{Name: "is_even", Type: "int (unsigned int)",
+		IsStatic: true, Pos: ast.Position{File: "x.c"},
+		ChildNodes: []ast.Node{
+			&ast.ParmVarDecl{Name: "n", Type: "unsigned int"},
+			&ast.CompoundStmt{},
+		}}
+	isOddPrototype := &ast.FunctionDecl{Name: "is_odd", Type: "int (unsigned int)",
+		ChildNodes: []ast.Node{
+			&ast.ParmVarDecl{Name: "n", Type: "unsigned int"},
+		}}
+	isOdd := &ast.FunctionDecl{Name: "is_odd", Type: "int (unsigned int)",
+		ChildNodes: []ast.Node{
+			&ast.ParmVarDecl{Name: "n", Type: "unsigned int"},
+			&ast.CompoundStmt{},
+		}}
+	tu := &ast.TranslationUnitDecl{ChildNodes: []ast.Node{
+		isEven, isOddPrototype, isOdd,
+	}}
+
+	p := program.NewProgram()
+	registerFunctionDefinitions(p, tu)
+	registerFunctionDefinitions(p, tu)
+
+	for _, name := range []string{"is_even", "is_odd"} {
+		f := p.GetFunctionDefinition(name)
+		if f == nil {
+			t.Errorf("%s is not registered", name)
+			continue
+		}
+		if f.ReturnType != "int" || len(f.ArgumentTypes) != 1 ||
+			f.ArgumentTypes[0] != "unsigned int" {
+			t.Errorf("Unexpected definition: %#v", f)
+		}
+	}
+	// Only the static function belongs to its file.
+	if f := p.GetFunctionDefinition("is_even"); f != nil && f.TranslationUnit != "x.c" {
+		t.Errorf("Expected the translation unit x.c, got %q", f.TranslationUnit)
+	}
+	if f := p.GetFunctionDefinition("is_odd"); f != nil && f.TranslationUnit != "" {
+		t.Errorf("Expected no translation unit, got %q", f.TranslationUnit)
+	}
+}
+
+func TestNoReturnFunction(t *testing.T) {
+	// _Noreturn int die(int code) {
+	//     exit(code);
+	// }
+	//
+	// int main() {
+	//     die(2);
+	//     int x = die(3);
+	// }
+	die := parseTree(`
+FunctionDecl 0x10 <x.c:1:1, line:3:1> line:1:15 used die 'int (int)'
+|-ParmVarDecl 0x11 <col:19, col:23> col:23 used code 'int'
+|-CompoundStmt 0x12 <col:29, line:3:1>
+| |-CallExpr 0x13 <line:2:5, col:14> 'void'
+|   |-ImplicitCastExpr 0x14 <col:5> 'void (*)(int) __attribute__((noreturn))' <FunctionToPointerDecay>
+|   | |-DeclRefExpr 0x15 <col:5> 'void (int) __attribute__((noreturn))' Function 0x16 'exit' 'void (int) __attribute__((noreturn))'
+|   |-ImplicitCastExpr 0x17 <col:10> 'int' <LValueToRValue>
+|     |-DeclRefExpr 0x18 <col:10> 'int' lvalue ParmVar 0x11 'code' 'int'
+|-C11NoReturnAttr 0x19 <col:1>
+`)
+	main := parseTree(`
+FunctionDecl 0x30 <x.c:5:1, line:8:1> line:5:5 main 'int ()'
+|-CompoundStmt 0x31 <col:12, line:8:1>
+  |-CallExpr 0x32 <line:6:5, col:10> 'int'
+  | |-ImplicitCastExpr 0x33 <col:5> 'int (*)(int)' <FunctionToPointerDecay>
+  | | |-DeclRefExpr 0x34 <col:5> 'int (int)' Function 0x10 'die' 'int (int)'
+  | |-IntegerLiteral 0x35 <col:9> 'int' 2
+  |-DeclStmt 0x36 <line:7:5, col:19>
+    |-VarDecl 0x37 <col:5, col:18> col:9 x 'int' cinit
+      |-CallExpr 0x38 <col:13, col:18> 'int'
+        |-ImplicitCastExpr 0x39 <col:13> 'int (*)(int)' <FunctionToPointerDecay>
+        | |-DeclRefExpr 0x3a <col:13> 'int (int)' Function 0x10 'die' 'int (int)'
+        |-IntegerLiteral 0x3b <col:17> 'int' 3
+`)
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "stdlib.h"}}
+
+	var buf bytes.Buffer
+	for _, n := range []ast.Node{die, main} {
+		decls, err := transpileFunctionDecl(n.(*ast.FunctionDecl), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteString("\n")
+	}
+
+	for _, e := range []string{
+		"func die(code int32) {",
+		"\tdie(int32(2))\n",
+		"panic(\"unreachable\")",
+	} {
+		if !strings.Contains(buf.String(), e) {
+			t.Errorf("Expected %q in:\n%s", e, buf.String())
+		}
+	}
+
+	// The warning is attached to the declaration of x.
+	if !strings.Contains(buf.String(),
+		"the result of noreturn function die is used") {
+		t.Errorf("Expected a warning in:\n%s", buf.String())
+	}
+}
+
+func TestGetFieldListRestrict(t *testing.T) {
+	// void f(int *restrict p, double a[restrict 4], int n);
+	//
+	// The array parameter is a pointer in the AST.
+	f := &ast.FunctionDecl{
+		Name: "f",
+		Type: "void (int *restrict, double *restrict, int)",
+		ChildNodes: []ast.Node{
+			&ast.ParmVarDecl{Name: "p", Type: "int *restrict"},
+			&ast.ParmVarDecl{Name: "a", Type: "double *restrict"},
+			&ast.ParmVarDecl{Name: "n", Type: "int"},
+		},
+	}
+
+	p := program.NewProgram()
+	fields, err := getFieldList(f, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"*int32", "*float64", "int32"} {
+		if actual := fields.List[i].Type.(*goast.Ident).Name; actual != expected {
+			t.Errorf("%s: expected %s, got %s",
+				fields.List[i].Names[0].Name, expected, actual)
+		}
+	}
+
+	restrict := getFunctionArgumentRestrict(f)
+	for i, expected := range []bool{true, true, false} {
+		if restrict[i] != expected {
+			t.Errorf("%d: expected restrict %v, got %v", i, expected, restrict[i])
+		}
+	}
+}
+
+func TestGoKeywordParameters(t *testing.T) {
+	// int f(int type, int func, int range, int map, int chan) {
+	//     return type + func + range + map + chan;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:3:1> line:1:5 f 'int (int, int, int, int, int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used type 'int'
+|-ParmVarDecl 0x12 <col:17, col:21> col:21 used func 'int'
+|-ParmVarDecl 0x13 <col:27, col:31> col:31 used range 'int'
+|-ParmVarDecl 0x14 <col:38, col:42> col:42 used map 'int'
+|-ParmVarDecl 0x15 <col:47, col:51> col:51 used chan 'int'
+|-CompoundStmt 0x16 <col:57, line:3:1>
+  |-ReturnStmt 0x17 <line:2:5, col:41>
+    |-BinaryOperator 0x18 <col:12, col:41> 'int' '+'
+      |-BinaryOperator 0x19 <col:12, col:34> 'int' '+'
+      | |-BinaryOperator 0x1a <col:12, col:26> 'int' '+'
+      | | |-BinaryOperator 0x1b <col:12, col:19> 'int' '+'
+      | | | |-ImplicitCastExpr 0x1c <col:12> 'int' <LValueToRValue>
+      | | | | |-DeclRefExpr 0x1d <col:12> 'int' lvalue ParmVar 0x11 'type' 'int'
+      | | | |-ImplicitCastExpr 0x1e <col:19> 'int' <LValueToRValue>
+      | | |   |-DeclRefExpr 0x1f <col:19> 'int' lvalue ParmVar 0x12 'func' 'int'
+      | | |-ImplicitCastExpr 0x20 <col:26> 'int' <LValueToRValue>
+      | |   |-DeclRefExpr 0x21 <col:26> 'int' lvalue ParmVar 0x13 'range' 'int'
+      | |-ImplicitCastExpr 0x22 <col:34> 'int' <LValueToRValue>
+      |   |-DeclRefExpr 0x23 <col:34> 'int' lvalue ParmVar 0x14 'map' 'int'
+      |-ImplicitCastExpr 0x24 <col:41> 'int' <LValueToRValue>
+        |-DeclRefExpr 0x25 <col:41> 'int' lvalue ParmVar 0x15 'chan' 'int'
+`
+	for suffix, expected := range map[string]string{
+		"": "func f(type_ int32, func_ int32, range_ int32, map_ int32, chan_ int32) int32 {\n" +
+			"\treturn type_ + func_ + range_ + map_ + chan_\n}",
+		"C": "func f(typeC int32, funcC int32, rangeC int32, mapC int32, chanC int32) int32 {\n" +
+			"\treturn typeC + funcC + rangeC + mapC + chanC\n}",
+	} {
+		p := program.NewProgram()
+		p.GoKeywordSuffix = suffix
+
+		decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		for _, d := range decls {
+			if err := format.Node(&buf, token.NewFileSet(), d); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("suffix %q: expected %q in:\n%s", suffix, expected, buf.String())
+		}
+	}
+}
+
+func TestFunctionReturnsAnonymousStruct(t *testing.T) {
+	// struct {
+	//     int a;
+	//     int b;
+	// } get_pair(void);
+	//
+	// int main() {
+	//     return get_pair().b;
+	// }
+	tu := parseTree(`
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x10 <x.c:1:1, line:4:1> line:1:1 struct definition
+| |-FieldDecl 0x11 <line:2:5, col:9> col:9 a 'int'
+| |-FieldDecl 0x12 <line:3:5, col:9> col:9 referenced b 'int'
+|-FunctionDecl 0x20 <line:1:1, line:4:16> line:4:3 used get_pair 'struct (anonymous struct at x.c:1:1) (void)'
+|-FunctionDecl 0x30 <line:6:1, line:8:1> line:6:5 main 'int ()'
+  |-CompoundStmt 0x31 <col:12, line:8:1>
+    |-ReturnStmt 0x32 <line:7:5, col:23>
+      |-MemberExpr 0x33 <col:12, col:23> 'int' .b 0x12
+        |-CallExpr 0x34 <col:12, col:21> 'struct (anonymous struct at x.c:1:1)':'struct (anonymous struct at x.c:1:1)'
+          |-ImplicitCastExpr 0x35 <col:12> 'struct (anonymous struct at x.c:1:1) (*)(void)' <FunctionToPointerDecay>
+            |-DeclRefExpr 0x36 <col:12> 'struct (anonymous struct at x.c:1:1) (void)' Function 0x20 'get_pair' 'struct (anonymous struct at x.c:1:1) (void)'
+`)
+
+	p := program.NewProgram()
+	decls, err := transpileTranslationUnitDecl(p, tu.(*ast.TranslationUnitDecl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := p.GetFunctionDefinition("get_pair")
+	if f == nil || f.ReturnType != "struct BSstructSatSxPcD1D1E" {
+		t.Fatalf("Unexpected definition of get_pair: %#v", f)
+	}
+
+	var buf bytes.Buffer
+	for _, d := range decls {
+		if err := format.Node(&buf, token.NewFileSet(), d); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteString("\n")
+	}
+	for _, e := range []string{
+		"type BSstructSatSxPcD1D1E struct {",
+		"get_pair().b",
+	} {
+		if !strings.Contains(buf.String(), e) {
+			t.Errorf("Expected %q in:\n%s", e, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "Warning") {
+		t.Errorf("Unexpected warning in:\n%s", buf.String())
+	}
+}
+
+func TestFunctionMessageSummary(t *testing.T) {
+	// unsigned long f() {
+	//     __asm__("nop");
+	//     return offsetof(struct s, b);
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:4:1> line:1:15 f 'unsigned long ()'
+|-CompoundStmt 0x11 <col:19, line:4:1>
+  |-GCCAsmStmt 0x12 <line:2:5, col:18>
+  |-ReturnStmt 0x13 <line:3:5, col:32>
+    |-OffsetOfExpr 0x14 <col:12, col:32> 'unsigned long'
+`
+	for _, summary := range []bool{false, true} {
+		p := program.NewProgram()
+		p.FunctionMessageSummary = summary
+
+		decls, err := transpileToNode(parseTree(dump), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc []string
+		for _, c := range decls[0].(*goast.FuncDecl).Doc.List {
+			doc = append(doc, c.Text)
+		}
+		text := strings.Join(doc, "\n")
+
+		if strings.Contains(text, "Summary of") != summary {
+			t.Errorf("summary %v: unexpected doc:\n%s", summary, text)
+		}
+		if !summary {
+			continue
+		}
+		for _, e := range []string{
+			"messages in f():",
+			"cannot transpile asm, will be ignored",
+			"cannot find the source of offsetof",
+		} {
+			if !strings.Contains(text, e) {
+				t.Errorf("Expected %q in:\n%s", e, text)
+			}
+		}
+		if strings.Count(text, "cannot transpile asm") != 1 {
+			t.Errorf("The warning must only be in the summary:\n%s", text)
+		}
+	}
+}
+
+func TestReturnTypedef(t *testing.T) {
+	// typedef size_t length;
+	//
+	// length twice(int x) {
+	//     return x * 2;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:3:1, line:5:1> line:3:8 twice 'length (int)'
+|-ParmVarDecl 0x11 <col:14, col:18> col:18 used x 'int'
+|-CompoundStmt 0x12 <col:21, line:5:1>
+  |-ReturnStmt 0x13 <line:4:5, col:16>
+    |-BinaryOperator 0x14 <col:12, col:16> 'int' '*'
+      |-ImplicitCastExpr 0x15 <col:12> 'int' <LValueToRValue>
+      | |-DeclRefExpr 0x16 <col:12> 'int' lvalue ParmVar 0x11 'x' 'int'
+      |-IntegerLiteral 0x17 <col:16> 'int' 2
+`
+	for _, tt := range []struct {
+		returnType string
+		want       string
+	}{
+		{"size_t", "return uint64(x * int32(2))"},
+		{"length", "return length(uint64(x * int32(2)))"},
+	} {
+		p := program.NewProgram()
+		p.TypedefType["size_t"] = "unsigned long"
+		p.TypedefType["length"] = "size_t"
+
+		n := parseTree(strings.Replace(dump, "length (int)", tt.returnType+" (int)", 1))
+		decls, err := transpileFunctionDecl(n.(*ast.FunctionDecl), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("Expected %q in:\n%s", tt.want, buf.String())
+		}
+	}
+}
+
+func TestReturnPointerToArray(t *testing.T) {
+	// int a[10];
+	//
+	// int (*get(int i))[10] {
+	//     return &a;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:3:1, line:5:1> line:3:7 get 'int (*(int))[10]'
+|-ParmVarDecl 0x11 <col:11, col:15> col:15 i 'int'
+|-CompoundStmt 0x12 <col:23, line:5:1>
+  |-ReturnStmt 0x13 <line:4:5, col:13>
+    |-UnaryOperator 0x14 <col:12, col:13> 'int (*)[10]' prefix '&' cannot overflow
+      |-DeclRefExpr 0x15 <col:13> 'int [10]' lvalue Var 0x01 'a' 'int [10]'
+`
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+	want := "func get(i int32) *[]int32 {\n\treturn &a\n}"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected %q in:\n%s", want, buf.String())
+	}
+}
+
+func TestImplicitInt(t *testing.T) {
+	// f(x) { return x; }
+	// main() { }
+	tests := []struct {
+		dump string
+		want string
+	}{
+		{`
+FunctionDecl 0x10 <x.c:1:1, col:18> col:1 f '(x)'
+|-ParmVarDecl 0x11 <col:3> col:3 used x ''
+|-CompoundStmt 0x12 <col:6, col:18>
+  |-ReturnStmt 0x13 <col:8, col:15>
+    |-ImplicitCastExpr 0x14 <col:15> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x15 <col:15> 'int' lvalue ParmVar 0x11 'x' 'int'
+`, "func f(x int32) int32 {\n\treturn x\n}"},
+		{`
+FunctionDecl 0x20 <x.c:2:1, col:10> col:1 g '()'
+|-CompoundStmt 0x21 <col:5, col:10>
+`, "func g() int32 {\n}"},
+	}
+
+	for _, tt := range tests {
+		p := program.NewProgram()
+		decls, err := transpileFunctionDecl(parseTree(tt.dump).(*ast.FunctionDecl), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("Expected %q in:\n%s", tt.want, buf.String())
+		}
+	}
+}
+
+func TestFunctionFormatAttribute(t *testing.T) {
+	// void mylog(const char *fmt, ...) __attribute__((format(printf, 1, 2)));
+	// void mylog(const char *fmt, ...) { }
+	// int myscan(int level, const char *fmt, ...);
+	// int myscan(int level, const char *fmt, ...) __attribute__((format(scanf, 2, 3)));
+	// void plain(const char *s, ...) { }
+	tu := parseTree(`
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:70> col:6 mylog 'void (const char *, ...)'
+| |-ParmVarDecl 0x11 <col:12, col:24> col:24 fmt 'const char *'
+| |-FormatAttr 0x12 <col:43, col:68> printf 1 2
+|-FunctionDecl 0x13 prev 0x10 <line:2:1, col:36> col:6 mylog 'void (const char *, ...)'
+| |-ParmVarDecl 0x14 <col:12, col:24> col:24 fmt 'const char *'
+| |-CompoundStmt 0x15 <col:34, col:36>
+| |-FormatAttr 0x16 <line:1:43, col:68> Inherited printf 1 2
+|-FunctionDecl 0x20 <line:3:1, col:44> col:5 myscan 'int (int, const char *, ...)'
+| |-ParmVarDecl 0x21 <col:12, col:16> col:16 level 'int'
+| |-ParmVarDecl 0x22 <col:23, col:35> col:35 fmt 'const char *'
+|-FunctionDecl 0x23 prev 0x20 <line:4:1, col:82> col:5 myscan 'int (int, const char *, ...)'
+| |-ParmVarDecl 0x24 <col:12, col:16> col:16 level 'int'
+| |-ParmVarDecl 0x25 <col:23, col:35> col:35 fmt 'const char *'
+| |-FormatAttr 0x26 <col:59, col:79> scanf 2 3
+|-FunctionDecl 0x30 <line:5:1, col:34> col:6 plain 'void (const char *, ...)'
+  |-ParmVarDecl 0x31 <col:12, col:24> col:24 s 'const char *'
+  |-CompoundStmt 0x32 <col:32, col:34>
+`)
+
+	p := program.NewProgram()
+	registerFunctionDefinitions(p, tu.(*ast.TranslationUnitDecl))
+
+	for _, tt := range []struct {
+		name                 string
+		format               string
+		index, firstArgument int
+	}{
+		{"mylog", "printf", 1, 2},
+		{"myscan", "scanf", 2, 3},
+		{"plain", "", 0, 0},
+	} {
+		f := p.GetFunctionDefinition(tt.name)
+		if f == nil {
+			t.Errorf("%s is not registered", tt.name)
+			continue
+		}
+		if f.Format != tt.format || f.FormatIndex != tt.index ||
+			f.FormatFirstArgument != tt.firstArgument {
+			t.Errorf("%s: expected format %s %d %d, got %s %d %d", tt.name,
+				tt.format, tt.index, tt.firstArgument,
+				f.Format, f.FormatIndex, f.FormatFirstArgument)
+		}
+	}
+}
+
+func TestRegisterSubstitution(t *testing.T) {
+	// int square(int x) { return x * x; }
+	// int g(int a) { return square(a) + 1; }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:35> col:5 used square 'int (int)'
+| |-ParmVarDecl 0x11 <col:12, col:16> col:16 used x 'int'
+| |-CompoundStmt 0x12 <col:19, col:35>
+|   |-ReturnStmt 0x13 <col:21, col:32>
+|     |-BinaryOperator 0x14 <col:28, col:32> 'int' '*'
+|       |-ImplicitCastExpr 0x15 <col:28> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x16 <col:28> 'int' lvalue ParmVar 0x11 'x' 'int'
+|       |-ImplicitCastExpr 0x17 <col:32> 'int' <LValueToRValue>
+|         |-DeclRefExpr 0x18 <col:32> 'int' lvalue ParmVar 0x11 'x' 'int'
+|-FunctionDecl 0x20 <line:2:1, col:38> col:5 g 'int (int)'
+  |-ParmVarDecl 0x21 <col:7, col:11> col:11 used a 'int'
+  |-CompoundStmt 0x22 <col:14, col:38>
+    |-ReturnStmt 0x23 <col:16, col:35>
+      |-BinaryOperator 0x24 <col:23, col:35> 'int' '+'
+        |-CallExpr 0x25 <col:23, col:31> 'int'
+        | |-ImplicitCastExpr 0x26 <col:23> 'int (*)(int)' <FunctionToPointerDecay>
+        | | |-DeclRefExpr 0x27 <col:23> 'int (int)' Function 0x10 'square' 'int (int)'
+        | |-ImplicitCastExpr 0x28 <col:30> 'int' <LValueToRValue>
+        |   |-DeclRefExpr 0x29 <col:30> 'int' lvalue ParmVar 0x21 'a' 'int'
+        |-IntegerLiteral 0x2a <col:35> 'int' 1
+`
+
+	p := program.NewProgram()
+	p.RegisterSubstitution("square", "mymath.Square",
+		[]string{"github.com/me/mymath"})
+	output := transpileDump(t, p, dump)
+
+	if strings.Contains(output, "func square(") {
+		t.Errorf("The C definition of square() was transpiled:\n%s", output)
+	}
+	expectContains(t, output,
+		`"github.com/me/mymath"`,
+		"return mymath.Square(a) + int32(1)",
+	)
+
+	f := p.GetFunctionDefinition("square")
+	if f == nil || f.Substitution != "mymath.Square" || f.ReturnType != "int" {
+		t.Errorf("Unexpected definition: %#v", f)
+	}
+
+	// A substitution also replaces a definition that exists already.
+	p = program.NewProgram()
+	p.AddFunctionDefinition(program.FunctionDefinition{
+		Name:             "div",
+		ReturnType:       "div_t",
+		ArgumentTypes:    []string{"int", "int"},
+		Substitution:     "github.com/elliotchance/c2go/noarch.Div",
+		ReturnParameters: []int{1},
+	})
+	p.RegisterSubstitution("div", "mymath.Div", []string{"github.com/me/mymath"})
+	f = p.GetFunctionDefinition("div")
+	if f == nil || f.Substitution != "mymath.Div" || f.ReturnParameters != nil ||
+		len(f.ArgumentTypes) != 2 {
+		t.Errorf("Unexpected definition: %#v", f)
+	}
+}
+
+func TestArrayParameters(t *testing.T) {
+	// void f(int a[10]) {}
+	// void g(int m[][3]) {}
+	// int main() {
+	//   int x[10]; f(x);
+	//   int *b = malloc(40); f(b);
+	//   int y[2][3]; g(y);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x5 </usr/include/stdlib.h:1:1, col:30> col:14 used malloc 'void *(unsigned long)'
+| |-ParmVarDecl 0x6 <col:21> col:29 'unsigned long'
+|-FunctionDecl 0x10 <x.c:1:1, col:20> col:6 used f 'void (int *)'
+| |-ParmVarDecl 0x11 <col:8, col:16> col:12 a 'int *':'int *'
+| |-CompoundStmt 0x12 <col:19, col:20>
+|-FunctionDecl 0x20 <line:2:1, col:21> col:6 used g 'void (int (*)[3])'
+| |-ParmVarDecl 0x21 <col:8, col:17> col:12 m 'int (*)[3]':'int (*)[3]'
+| |-CompoundStmt 0x22 <col:20, col:21>
+|-FunctionDecl 0x30 <line:3:1, line:7:1> line:3:5 main 'int (void)'
+  |-CompoundStmt 0x31 <col:12, line:7:1>
+    |-DeclStmt 0x32 <line:4:3, col:12>
+    | |-VarDecl 0x33 <col:3, col:11> col:7 used x 'int [10]'
+    |-CallExpr 0x34 <col:14, col:17> 'void'
+    | |-ImplicitCastExpr 0x35 <col:14> 'void (*)(int *)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x36 <col:14> 'void (int *)' Function 0x10 'f' 'void (int *)'
+    | |-ImplicitCastExpr 0x37 <col:16> 'int *' <ArrayToPointerDecay>
+    |   |-DeclRefExpr 0x38 <col:16> 'int [10]' lvalue Var 0x33 'x' 'int [10]'
+    |-DeclStmt 0x40 <line:5:3, col:23>
+    | |-VarDecl 0x41 <col:3, col:22> col:8 used b 'int *' cinit
+    |   |-ImplicitCastExpr 0x42 <col:12, col:22> 'int *' <BitCast>
+    |     |-CallExpr 0x43 <col:12, col:22> 'void *'
+    |       |-ImplicitCastExpr 0x44 <col:12> 'void *(*)(unsigned long)' <FunctionToPointerDecay>
+    |       | |-DeclRefExpr 0x45 <col:12> 'void *(unsigned long)' Function 0x5 'malloc' 'void *(unsigned long)'
+    |       |-ImplicitCastExpr 0x46 <col:19> 'unsigned long' <IntegralCast>
+    |         |-IntegerLiteral 0x47 <col:19> 'int' 40
+    |-CallExpr 0x48 <col:25, col:28> 'void'
+    | |-ImplicitCastExpr 0x49 <col:25> 'void (*)(int *)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x4a <col:25> 'void (int *)' Function 0x10 'f' 'void (int *)'
+    | |-ImplicitCastExpr 0x4b <col:27> 'int *' <LValueToRValue>
+    |   |-DeclRefExpr 0x4c <col:27> 'int *' lvalue Var 0x41 'b' 'int *'
+    |-DeclStmt 0x50 <line:6:3, col:14>
+    | |-VarDecl 0x51 <col:3, col:13> col:7 used y 'int [2][3]'
+    |-CallExpr 0x52 <col:16, col:19> 'void'
+      |-ImplicitCastExpr 0x53 <col:16> 'void (*)(int (*)[3])' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0x54 <col:16> 'void (int (*)[3])' Function 0x20 'g' 'void (int (*)[3])'
+      |-ImplicitCastExpr 0x55 <col:18> 'int (*)[3]' <ArrayToPointerDecay>
+        |-DeclRefExpr 0x56 <col:18> 'int [2][3]' lvalue Var 0x51 'y' 'int [2][3]'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"func f(a *int32) {",
+		"func g(m *[]int32) {",
+		"f(&x[0])",
+		"f(b)",
+		"g(&y[0])",
+	)
+	if strings.Contains(output, "Warning") {
+		t.Errorf("Unexpected warning in:\n%s", output)
+	}
+}
+
+func TestAtexit(t *testing.T) {
+	// void bye(void) {}
+	// int main(void) {
+	//     atexit(bye);
+	//     if (1) return 3;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x5 </usr/include/stdlib.h:1:1, col:40> col:12 used atexit 'int (void (*)(void))'
+| |-ParmVarDecl 0x6 <col:19, col:38> col:26 __func 'void (*)(void)'
+|-FunctionDecl 0x10 <x.c:1:1, col:17> col:6 used bye 'void (void)'
+| |-CompoundStmt 0x11 <col:16, col:17>
+|-FunctionDecl 0x20 <line:2:1, line:5:1> line:2:5 main 'int (void)'
+  |-CompoundStmt 0x21 <col:16, line:5:1>
+    |-CallExpr 0x22 <line:3:5, col:15> 'int'
+    | |-ImplicitCastExpr 0x23 <col:5> 'int (*)(void (*)(void))' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x24 <col:5> 'int (void (*)(void))' Function 0x5 'atexit' 'int (void (*)(void))'
+    | |-ImplicitCastExpr 0x25 <col:12> 'void (*)(void)' <FunctionToPointerDecay>
+    |   |-DeclRefExpr 0x26 <col:12> 'void (void)' Function 0x10 'bye' 'void (void)'
+    |-IfStmt 0x30 <line:4:5, col:21>
+      |-IntegerLiteral 0x31 <col:9> 'int' 1
+      |-ReturnStmt 0x32 <col:12, col:19>
+        |-IntegerLiteral 0x33 <col:19> 'int' 3
+`
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"noarch.Atexit(bye)",
+		// The handlers are called when main() returns with a status.
+		"noarch.Exit(int32(3))",
+		// And when main() returns at the end of its body.
+		"noarch.Exit(0)\n}",
+	)
+	if strings.Contains(output, "os.Exit") {
+		t.Errorf("Unexpected os.Exit in:\n%s", output)
+	}
+}
+
+func TestLongResultOfNoarch(t *testing.T) {
+	// #include <stdlib.h>
+	// void f(void) {
+	//     long x = atol("12");
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x5 </usr/include/stdlib.h:1:1, col:34> col:17 used atol 'long (const char *)'
+| |-ParmVarDecl 0x6 <col:22, col:34> col:34 'const char *'
+|-FunctionDecl 0x10 <x.c:2:1, line:4:1> line:2:6 f 'void (void)'
+  |-CompoundStmt 0x11 <col:15, line:4:1>
+    |-DeclStmt 0x12 <line:3:5, col:25>
+      |-VarDecl 0x13 <col:5, col:24> col:10 x 'long' cinit
+        |-CallExpr 0x14 <col:14, col:24> 'long'
+          |-ImplicitCastExpr 0x15 <col:14> 'long (*)(const char *)' <FunctionToPointerDecay>
+          | |-DeclRefExpr 0x16 <col:14> 'long (const char *)' Function 0x5 'atol' 'long (const char *)'
+          |-ImplicitCastExpr 0x17 <col:19> 'const char *' <NoOp>
+            |-ImplicitCastExpr 0x18 <col:19> 'char *' <ArrayToPointerDecay>
+              |-StringLiteral 0x19 <col:19> 'char [3]' lvalue "12"
+`
+
+	for _, test := range []struct {
+		abi  *program.ABI
+		want string
+	}{
+		{program.LP64, "var x int64 = noarch.Atol("},
+		{program.ILP32, "var x int32 = int32(noarch.Atol("},
+	} {
+		t.Run(test.abi.Name, func(t *testing.T) {
+			p := program.NewProgram()
+			p.ABI = test.abi
+			p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+			output := transpileDump(t, p, dump)
+
+			expectContains(t, output, test.want)
+		})
+	}
+}
+
+func TestReturnWithoutFunctionDefinition(t *testing.T) {
+	// A bare return in a function that has no definition, like one that is
+	// transpiled on its own, is an empty return.
+	p := program.NewProgram()
+	p.Function = &ast.FunctionDecl{Name: "undefined", Type: "void (void)"}
+
+	stmt, _, _, err := transpileReturnStmt(&ast.ReturnStmt{}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmt.(*goast.ReturnStmt).Results) != 0 {
+		t.Errorf("Expected a return without results, got %#v", stmt)
+	}
+}
@@ -0,0 +1,76 @@
+// This file contains functions for transpiling the C11 _Generic selection.
+
+package transpiler
+
+import (
+	"fmt"
+
+	goast "go/ast"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+)
+
+// transpileGenericSelectionExpr transpiles a _Generic selection into the
+// expression of the association that is selected by the type of the
+// controlling expression. The type is known when transpiling, so the other
+// associations are not transpiled at all:
+//
+//     _Generic(x, int: "int", float: "float", default: "other")   =>   "int"
+//
+// The controlling expression is not evaluated in C, so it is not transpiled
+// either.
+func transpileGenericSelectionExpr(n *ast.GenericSelectionExpr, p *program.Program, exprIsStmt bool) (
+	expr goast.Expr, exprType string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+	selected, err := selectGenericAssociation(n)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	return transpileToExpr(selected, p, exprIsStmt)
+}
+
+// selectGenericAssociation returns the expression of the association that
+// clang selected. When the dump has no selection, it is the association that
+// has the type of the controlling expression, or else the default association.
+func selectGenericAssociation(n *ast.GenericSelectionExpr) (ast.Node, error) {
+	if len(n.Children()) == 0 {
+		return nil, fmt.Errorf("_Generic selection without a controlling expression")
+	}
+	controllingType, err := sizeofOperandType(n.Children()[0])
+	if err != nil {
+		return nil, err
+	}
+	controllingType = types.CleanCType(controllingType)
+
+	var matched, selected, byDefault *ast.GenericAssociation
+	for _, c := range n.Children()[1:] {
+		a, ok := c.(*ast.GenericAssociation)
+		if !ok || len(a.Children()) == 0 {
+			continue
+		}
+		switch {
+		case a.IsDefault:
+			byDefault = a
+		case matched == nil && (types.CleanCType(a.Type) == controllingType ||
+			types.CleanCType(a.Type2) == controllingType):
+			matched = a
+		}
+		if a.IsSelected {
+			selected = a
+		}
+	}
+
+	// Clang resolves the typedefs and the qualifiers of the types, which the
+	// comparison of the names cannot do, so its selection comes first.
+	for _, a := range []*ast.GenericAssociation{selected, matched, byDefault} {
+		if a != nil {
+			children := a.Children()
+			return children[len(children)-1], nil
+		}
+	}
+
+	return nil, fmt.Errorf("_Generic selection has no association for the type '%s'",
+		controllingType)
+}
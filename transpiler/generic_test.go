@@ -0,0 +1,113 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestGenericSelection(t *testing.T) {
+	// void f(int x, double d) {
+	//   int a = _Generic(x, int: 1, float: 2, default: 3);
+	//   int b = _Generic(d, int: 10, float: 20, default: 30);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:4:1> line:1:6 f 'void (int, double)'
+  |-ParmVarDecl 0x11 <col:8, col:12> col:12 used x 'int'
+  |-ParmVarDecl 0x12 <col:15, col:22> col:22 used d 'double'
+  |-CompoundStmt 0x13 <col:25, line:4:1>
+    |-DeclStmt 0x20 <line:2:3, col:52>
+    | |-VarDecl 0x21 <col:3, col:51> col:7 a 'int' cinit
+    |   |-GenericSelectionExpr 0x22 <col:11, col:51> 'int'
+    |     |-ImplicitCastExpr 0x23 <col:20> 'int' <LValueToRValue>
+    |     | |-DeclRefExpr 0x24 <col:20> 'int' lvalue ParmVar 0x11 'x' 'int'
+    |     |-BuiltinType 0x25 'int'
+    |     |-case 'int' selected
+    |     | |-BuiltinType 0x25 'int'
+    |     | |-IntegerLiteral 0x26 <col:28> 'int' 1
+    |     |-case 'float'
+    |     | |-BuiltinType 0x27 'float'
+    |     | |-IntegerLiteral 0x28 <col:38> 'int' 2
+    |     |-default
+    |       |-IntegerLiteral 0x29 <col:50> 'int' 3
+    |-DeclStmt 0x30 <line:3:3, col:55>
+      |-VarDecl 0x31 <col:3, col:54> col:7 b 'int' cinit
+        |-GenericSelectionExpr 0x32 <col:11, col:54> 'int'
+          |-ImplicitCastExpr 0x33 <col:20> 'double' <LValueToRValue>
+          | |-DeclRefExpr 0x34 <col:20> 'double' lvalue ParmVar 0x12 'd' 'double'
+          |-BuiltinType 0x35 'double'
+          |-case 'int'
+          | |-BuiltinType 0x25 'int'
+          | |-IntegerLiteral 0x36 <col:28> 'int' 10
+          |-case 'float'
+          | |-BuiltinType 0x27 'float'
+          | |-IntegerLiteral 0x37 <col:39> 'int' 20
+          |-default selected
+            |-IntegerLiteral 0x38 <col:52> 'int' 30
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"var a int32 = int32(1)",
+		// There is no association for double.
+		"var b int32 = int32(30)",
+	)
+	for _, unwanted := range []string{"int32(2)", "int32(3)", "int32(10)", "int32(20)"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("Unexpected %q in:\n%s", unwanted, output)
+		}
+	}
+}
+
+func TestGenericSelectionWithoutMatch(t *testing.T) {
+	// Without a default association, an expression that has none of the
+	// types cannot be selected.
+	n := parseTree(`
+GenericSelectionExpr 0x22 <x.c:1:11, col:41> 'int'
+|-ImplicitCastExpr 0x23 <col:20> 'double' <LValueToRValue>
+| |-DeclRefExpr 0x24 <col:20> 'double' lvalue ParmVar 0x11 'd' 'double'
+|-BuiltinType 0x25 'double'
+|-case 'int'
+  |-BuiltinType 0x26 'int'
+  |-IntegerLiteral 0x27 <col:28> 'int' 1
+`).(*ast.GenericSelectionExpr)
+
+	if _, err := selectGenericAssociation(n); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestGenericSelectionSelectedByClang(t *testing.T) {
+	// const int c = 1;
+	// _Generic(c, const int: 1, int: 2)
+	//
+	// The controlling expression is converted to an int, so the association of
+	// int is selected although the name of the other one matches too.
+	n := parseTree(`
+GenericSelectionExpr 0x22 <x.c:2:1, col:34> 'int'
+|-ImplicitCastExpr 0x23 <col:10> 'int' <LValueToRValue>
+| |-DeclRefExpr 0x24 <col:10> 'const int' lvalue Var 0x11 'c' 'const int'
+|-QualType 0x25 'const int' const
+| |-BuiltinType 0x26 'int'
+|-case 'const int'
+| |-QualType 0x25 'const int' const
+| | |-BuiltinType 0x26 'int'
+| |-IntegerLiteral 0x27 <col:24> 'int' 1
+|-case 'int' selected
+  |-BuiltinType 0x26 'int'
+  |-IntegerLiteral 0x28 <col:32> 'int' 2
+`).(*ast.GenericSelectionExpr)
+
+	selected, err := selectGenericAssociation(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := selected.(*ast.IntegerLiteral); !ok || v.Value != "2" {
+		t.Errorf("Expected the association of int, got %#v", selected)
+	}
+}
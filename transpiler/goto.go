@@ -35,3 +35,317 @@ func transpileGotoStmt(n *ast.GotoStmt, p *program.Program) (*goast.BranchStmt,
 		Tok:   token.GOTO,
 	}, nil
 }
+
+// replaceGotosAfterLoops replaces a goto that jumps out of loops to the label
+// right after them by a break of the outermost loop:
+//
+//     for (i = 0; i < n; i++)             done:
+//         for (j = 0; j < n; j++)         for i = 0; i < n; i++ {
+//             if (a[i][j] == x)               for j = 0; j < n; j++ {
+//                 goto done;                      if a[i][j] == x {
+//     done:                                           break done
+//     ...                                         }
+//                                             }
+//                                         }
+//                                         ...
+//
+// The label is only moved to the loop if all of the gotos to it are in the
+// loop.
+func replaceGotosAfterLoops(body *goast.BlockStmt) {
+	if body == nil {
+		return
+	}
+	goast.Inspect(body, func(node goast.Node) bool {
+		switch v := node.(type) {
+		case *goast.BlockStmt:
+			v.List = replaceGotosAfterLoop(body, v.List)
+		case *goast.CaseClause:
+			v.Body = replaceGotosAfterLoop(body, v.Body)
+		}
+		return true
+	})
+}
+
+func replaceGotosAfterLoop(body *goast.BlockStmt, stmts []goast.Stmt) []goast.Stmt {
+	for i := 0; i+1 < len(stmts); i++ {
+		switch stmts[i].(type) {
+		case *goast.ForStmt, *goast.RangeStmt:
+		default:
+			continue
+		}
+		label, ok := stmts[i+1].(*goast.LabeledStmt)
+		if !ok {
+			continue
+		}
+		if _, ok := label.Stmt.(*goast.EmptyStmt); !ok {
+			continue
+		}
+		name := label.Label.Name
+		inLoop := countGotos(stmts[i], name)
+		if inLoop == 0 || inLoop != countGotos(body, name) {
+			continue
+		}
+
+		goast.Inspect(stmts[i], func(node goast.Node) bool {
+			if b, ok := node.(*goast.BranchStmt); ok && b.Tok == token.GOTO &&
+				b.Label != nil && b.Label.Name == name {
+				b.Tok = token.BREAK
+			}
+			return true
+		})
+		stmts[i] = &goast.LabeledStmt{
+			Label: label.Label,
+			Stmt:  stmts[i],
+		}
+		stmts = append(stmts[:i+1], stmts[i+2:]...)
+	}
+
+	return stmts
+}
+
+// countGotos returns the number of the gotos to the label in the node.
+func countGotos(node goast.Node, label string) (count int) {
+	goast.Inspect(node, func(node goast.Node) bool {
+		if b, ok := node.(*goast.BranchStmt); ok && b.Tok == token.GOTO &&
+			b.Label != nil && b.Label.Name == label {
+			count++
+		}
+		return true
+	})
+	return
+}
+
+// hoistDeclarationsForGoto moves the variable declarations that a goto jumps
+// over to the top of their block. In C it is fine to jump forward over a
+// declaration:
+//
+//     if (err) goto cleanup;
+//     int n = 5;
+//     cleanup:
+//     ...
+//
+// But it is a compile error in Go. The declaration is moved to the top of the
+// block and the initialization is left in the original place:
+//
+//     var n int32
+//     if err {
+//         goto cleanup
+//     }
+//     n = 5
+//     cleanup:
+//     ...
+//
+// The declarations between a label and a goto that jumps backward to it, like
+// in a loop that is built from a goto, are moved above the label as well:
+//
+//     again:                        var sq int32
+//     i++;                          again:
+//     int sq = i * i;       =>      i++
+//     if (sq < n) goto again;       sq = i * i
+//                                   if sq < n {
+//                                       goto again
+//                                   }
+//
+// Go accepts the backward jump itself, but the variable is then declared
+// again by each jump. In C it keeps its storage, which matters when its
+// address is taken, and the moved declaration keeps the same variable.
+//
+// A variable that is declared with ":=" has no type to move, its statements
+// are put into a block instead, see scopeEnd().
+func hoistDeclarationsForGoto(body *goast.BlockStmt) {
+	if body == nil {
+		return
+	}
+	goast.Inspect(body, func(node goast.Node) bool {
+		switch v := node.(type) {
+		case *goast.BlockStmt:
+			v.List = hoistDeclarations(v.List)
+		case *goast.CaseClause:
+			v.Body = hoistDeclarations(v.Body)
+		}
+		return true
+	})
+}
+
+func hoistDeclarations(stmts []goast.Stmt) []goast.Stmt {
+	// Find the range of statements between the first goto and the label it
+	// jumps to, and between the label and the last goto that jumps back to
+	// it. Only the declarations in those ranges are moved.
+	hoist := map[int]bool{}
+	scope := map[int]bool{}
+	hoistRange := func(from, to int) {
+		for k := from + 1; k < to; k++ {
+			if isHoistableDecl(stmts[k]) {
+				hoist[k] = true
+			} else if len(definedNames(stmts[k])) > 0 {
+				scope[k] = true
+			}
+		}
+	}
+	for j, s := range stmts {
+		label, ok := s.(*goast.LabeledStmt)
+		if !ok {
+			continue
+		}
+		for i := 0; i < j; i++ {
+			if hasGoto(stmts[i], label.Label.Name) {
+				hoistRange(i, j)
+				break
+			}
+		}
+		for i := len(stmts) - 1; i > j; i-- {
+			if hasGoto(stmts[i], label.Label.Name) {
+				hoistRange(j, i+1)
+				break
+			}
+		}
+	}
+	if len(hoist)+len(scope) == 0 {
+		return stmts
+	}
+
+	var decls, rest []goast.Stmt
+	split := func(s goast.Stmt) goast.Stmt {
+		spec := s.(*goast.DeclStmt).Decl.(*goast.GenDecl).Specs[0].(*goast.ValueSpec)
+		decls = append(decls, &goast.DeclStmt{
+			Decl: &goast.GenDecl{
+				Tok: token.VAR,
+				Specs: []goast.Spec{&goast.ValueSpec{
+					Names: spec.Names,
+					Type:  spec.Type,
+				}},
+			},
+		})
+		if len(spec.Values) == 0 {
+			return nil
+		}
+		var lhs []goast.Expr
+		for _, name := range spec.Names {
+			lhs = append(lhs, util.NewIdent(name.Name))
+		}
+		return &goast.AssignStmt{
+			Lhs: lhs,
+			Tok: token.ASSIGN,
+			Rhs: spec.Values,
+		}
+	}
+	for k := 0; k < len(stmts); k++ {
+		switch {
+		case hoist[k]:
+			if s := split(stmts[k]); s != nil {
+				rest = append(rest, s)
+			}
+		case scope[k]:
+			end, ok := scopeEnd(stmts, k)
+			if !ok {
+				rest = append(rest, stmts[k])
+				continue
+			}
+			block := &goast.BlockStmt{}
+			for m := k; m <= end; m++ {
+				s := stmts[m]
+				if isHoistableDecl(s) {
+					s = split(s)
+				}
+				if s != nil {
+					block.List = append(block.List, s)
+				}
+			}
+			rest = append(rest, block)
+			k = end
+		default:
+			rest = append(rest, stmts[k])
+		}
+	}
+
+	return append(decls, rest...)
+}
+
+// scopeEnd returns the last of the statements that use the variables that are
+// defined with ":=" by the statement at start, like the temporary variables of
+// the transpiler:
+//
+//     c2goTempVar0 := next()
+//     a[c2goTempVar0] = a[c2goTempVar0] + 1
+//
+// The type of such a variable is not known, so its declaration cannot be moved
+// to the top of the block. The statements are put into a block of their own
+// instead, that a goto can jump over. ok is false if a variable is used after
+// a label, that would be in the block then.
+func scopeEnd(stmts []goast.Stmt, start int) (end int, ok bool) {
+	names := map[string]bool{}
+	end = start
+	for k := start; k < len(stmts) && k <= end; k++ {
+		if _, isLabel := stmts[k].(*goast.LabeledStmt); isLabel {
+			return 0, false
+		}
+		for _, name := range definedNames(stmts[k]) {
+			names[name] = true
+		}
+		for m := k + 1; m < len(stmts); m++ {
+			for name := range names {
+				if usesIdentInStmt(name, stmts[m]) && m > end {
+					end = m
+				}
+			}
+		}
+	}
+
+	return end, true
+}
+
+// definedNames returns the names of the variables that are declared by an
+// assignment with ":=". The blank identifier is not a variable.
+func definedNames(s goast.Stmt) (names []string) {
+	assign, ok := s.(*goast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE {
+		return nil
+	}
+	for _, lhs := range assign.Lhs {
+		if ident, ok := lhs.(*goast.Ident); ok && ident.Name != "_" {
+			names = append(names, ident.Name)
+		}
+	}
+	return
+}
+
+// usesIdentInStmt returns true if the statement refers to the name.
+func usesIdentInStmt(name string, s goast.Stmt) (uses bool) {
+	goast.Inspect(s, func(node goast.Node) bool {
+		if ident, ok := node.(*goast.Ident); ok && ident.Name == name {
+			uses = true
+		}
+		return !uses
+	})
+	return
+}
+
+// isHoistableDecl returns true for a single "var" declaration with an explicit
+// type. Without the type the declaration cannot be separated from its value.
+func isHoistableDecl(s goast.Stmt) bool {
+	decl, ok := s.(*goast.DeclStmt)
+	if !ok {
+		return false
+	}
+	gen, ok := decl.Decl.(*goast.GenDecl)
+	if !ok || gen.Tok != token.VAR || len(gen.Specs) != 1 {
+		return false
+	}
+	spec, ok := gen.Specs[0].(*goast.ValueSpec)
+	if !ok || spec.Type == nil {
+		return false
+	}
+	return len(spec.Values) == 0 || len(spec.Values) == len(spec.Names)
+}
+
+// hasGoto returns true if the statement contains a goto to the label.
+func hasGoto(s goast.Stmt, label string) (found bool) {
+	goast.Inspect(s, func(node goast.Node) bool {
+		if b, ok := node.(*goast.BranchStmt); ok && b.Tok == token.GOTO &&
+			b.Label != nil && b.Label.Name == label {
+			found = true
+		}
+		return !found
+	})
+	return
+}
@@ -0,0 +1,234 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestGotoAfterLoops(t *testing.T) {
+	// int f(int n) {
+	//     int i, j;
+	//     for (i = 0; i < n; i++)
+	//         for (j = 0; j < n; j++)
+	//             if (i * j == 6) goto done;
+	// done:
+	//     return i;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:8:1> line:1:5 f 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used n 'int'
+|-CompoundStmt 0x12 <col:14, line:8:1>
+  |-DeclStmt 0x13 <line:2:5, col:13>
+  | |-VarDecl 0x14 <col:5, col:9> col:9 used i 'int'
+  | |-VarDecl 0x15 <col:5, col:12> col:12 used j 'int'
+  |-ForStmt 0x20 <line:3:5, line:5:38>
+  | |-BinaryOperator 0x21 <line:3:10, col:14> 'int' '='
+  | | |-DeclRefExpr 0x22 <col:10> 'int' lvalue Var 0x14 'i' 'int'
+  | | |-IntegerLiteral 0x23 <col:14> 'int' 0
+  | |-NullStmt
+  | |-BinaryOperator 0x24 <col:17, col:21> 'int' '<'
+  | | |-ImplicitCastExpr 0x25 <col:17> 'int' <LValueToRValue>
+  | | | |-DeclRefExpr 0x26 <col:17> 'int' lvalue Var 0x14 'i' 'int'
+  | | |-ImplicitCastExpr 0x27 <col:21> 'int' <LValueToRValue>
+  | |   |-DeclRefExpr 0x28 <col:21> 'int' lvalue ParmVar 0x11 'n' 'int'
+  | |-UnaryOperator 0x29 <col:24, col:25> 'int' postfix '++'
+  | | |-DeclRefExpr 0x2a <col:24> 'int' lvalue Var 0x14 'i' 'int'
+  | |-ForStmt 0x30 <line:4:9, line:5:38>
+  |   |-BinaryOperator 0x31 <line:4:14, col:18> 'int' '='
+  |   | |-DeclRefExpr 0x32 <col:14> 'int' lvalue Var 0x15 'j' 'int'
+  |   | |-IntegerLiteral 0x33 <col:18> 'int' 0
+  |   |-NullStmt
+  |   |-BinaryOperator 0x34 <col:21, col:25> 'int' '<'
+  |   | |-ImplicitCastExpr 0x35 <col:21> 'int' <LValueToRValue>
+  |   | | |-DeclRefExpr 0x36 <col:21> 'int' lvalue Var 0x15 'j' 'int'
+  |   | |-ImplicitCastExpr 0x37 <col:25> 'int' <LValueToRValue>
+  |   |   |-DeclRefExpr 0x38 <col:25> 'int' lvalue ParmVar 0x11 'n' 'int'
+  |   |-UnaryOperator 0x39 <col:28, col:29> 'int' postfix '++'
+  |   | |-DeclRefExpr 0x3a <col:28> 'int' lvalue Var 0x15 'j' 'int'
+  |   |-IfStmt 0x40 <line:5:13, col:38>
+  |     |-NullStmt
+  |     |-NullStmt
+  |     |-BinaryOperator 0x41 <col:17, col:26> 'int' '=='
+  |     | |-BinaryOperator 0x42 <col:17, col:21> 'int' '*'
+  |     | | |-ImplicitCastExpr 0x43 <col:17> 'int' <LValueToRValue>
+  |     | | | |-DeclRefExpr 0x44 <col:17> 'int' lvalue Var 0x14 'i' 'int'
+  |     | | |-ImplicitCastExpr 0x45 <col:21> 'int' <LValueToRValue>
+  |     | |   |-DeclRefExpr 0x46 <col:21> 'int' lvalue Var 0x15 'j' 'int'
+  |     | |-IntegerLiteral 0x47 <col:26> 'int' 6
+  |     |-GotoStmt 0x48 <col:29, col:34> 'done' 0x50
+  |     |-NullStmt
+  |-LabelStmt 0x50 <line:6:1, line:7:12> 'done'
+    |-ReturnStmt 0x51 <line:7:5, col:12>
+      |-ImplicitCastExpr 0x52 <col:12> 'int' <LValueToRValue>
+        |-DeclRefExpr 0x53 <col:12> 'int' lvalue Var 0x14 'i' 'int'
+`
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `
+done:
+	for i = int32(0); i < n; i++ {
+		for j = int32(0); j < n; j++ {
+			if i*j == int32(6) {
+				break done
+			}
+		}
+	}
+	return i
+`
+	if !strings.Contains(buf.String(), want[1:]) {
+		t.Errorf("Expected:\n%s\nin:\n%s", want, buf.String())
+	}
+}
+
+func TestGotoBackward(t *testing.T) {
+	// A do-while loop that is built from a goto:
+	//
+	// int f(int n) {
+	//     int i = 0;
+	// again:
+	//     i++;
+	//     int sq = i * i;
+	//     if (sq < n) goto again;
+	//     return i;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:8:1> line:1:5 f 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used n 'int'
+|-CompoundStmt 0x12 <col:14, line:8:1>
+  |-DeclStmt 0x13 <line:2:5, col:14>
+  | |-VarDecl 0x14 <col:5, col:13> col:9 used i 'int' cinit
+  |   |-IntegerLiteral 0x15 <col:13> 'int' 0
+  |-LabelStmt 0x20 <line:3:1, line:4:8> 'again'
+  | |-UnaryOperator 0x21 <line:4:5, col:6> 'int' postfix '++'
+  |   |-DeclRefExpr 0x22 <col:5> 'int' lvalue Var 0x14 'i' 'int'
+  |-DeclStmt 0x30 <line:5:5, col:19>
+  | |-VarDecl 0x31 <col:5, col:18> col:9 used sq 'int' cinit
+  |   |-BinaryOperator 0x32 <col:14, col:18> 'int' '*'
+  |     |-ImplicitCastExpr 0x33 <col:14> 'int' <LValueToRValue>
+  |     | |-DeclRefExpr 0x34 <col:14> 'int' lvalue Var 0x14 'i' 'int'
+  |     |-ImplicitCastExpr 0x35 <col:18> 'int' <LValueToRValue>
+  |       |-DeclRefExpr 0x36 <col:18> 'int' lvalue Var 0x14 'i' 'int'
+  |-IfStmt 0x40 <line:6:5, col:26>
+  | |-NullStmt
+  | |-NullStmt
+  | |-BinaryOperator 0x41 <col:9, col:14> 'int' '<'
+  | | |-ImplicitCastExpr 0x42 <col:9> 'int' <LValueToRValue>
+  | | | |-DeclRefExpr 0x43 <col:9> 'int' lvalue Var 0x31 'sq' 'int'
+  | | |-ImplicitCastExpr 0x44 <col:14> 'int' <LValueToRValue>
+  | |   |-DeclRefExpr 0x45 <col:14> 'int' lvalue ParmVar 0x11 'n' 'int'
+  | |-GotoStmt 0x46 <col:17, col:22> 'again' 0x20
+  | |-NullStmt
+  |-ReturnStmt 0x50 <line:7:5, col:12>
+    |-ImplicitCastExpr 0x51 <col:12> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x52 <col:12> 'int' lvalue Var 0x14 'i' 'int'
+`
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+
+	// The declaration of sq is moved above the label, so that the jump back
+	// keeps the same variable.
+	want := `
+	var sq int32
+	var i int32 = int32(0)
+again:
+	;
+	i += 1
+	sq = i * i
+	if sq < n {
+		goto again
+	}
+	return i
+`
+	if !strings.Contains(buf.String(), want[1:]) {
+		t.Errorf("Expected:\n%s\nin:\n%s", want, buf.String())
+	}
+}
+
+func TestGotoOverTemporaryVariables(t *testing.T) {
+	// int next(void);
+	// void f(int err, char *a, int n) {
+	//     if (err) goto done;
+	//     a[next()] /= 2;
+	//     char *b = alloca(n);
+	// done:
+	//     return;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x2 <x.c:1:1, col:15> col:5 used next 'int (void)'
+|-FunctionDecl 0x10 <line:2:1, line:8:1> line:2:6 f 'void (int, char *, int)'
+  |-ParmVarDecl 0x11 <col:8, col:12> col:12 used err 'int'
+  |-ParmVarDecl 0x12 <col:17, col:23> col:23 used a 'char *'
+  |-ParmVarDecl 0x13 <col:26, col:30> col:30 used n 'int'
+  |-CompoundStmt 0x14 <col:33, line:8:1>
+    |-IfStmt 0x20 <line:3:5, col:23>
+    | |-NullStmt
+    | |-NullStmt
+    | |-ImplicitCastExpr 0x21 <col:9> 'int' <LValueToRValue>
+    | | |-DeclRefExpr 0x22 <col:9> 'int' lvalue ParmVar 0x11 'err' 'int'
+    | |-GotoStmt 0x23 <col:14, col:19> 'done' 0x50
+    | |-NullStmt
+    |-CompoundAssignOperator 0x30 <line:4:5, col:19> 'char' '/=' ComputeLHSTy='int' ComputeResultTy='int'
+    | |-ArraySubscriptExpr 0x31 <col:5, col:13> 'char' lvalue
+    | | |-ImplicitCastExpr 0x32 <col:5> 'char *' <LValueToRValue>
+    | | | |-DeclRefExpr 0x33 <col:5> 'char *' lvalue ParmVar 0x12 'a' 'char *'
+    | | |-CallExpr 0x34 <col:7, col:12> 'int'
+    | |   |-ImplicitCastExpr 0x35 <col:7> 'int (*)(void)' <FunctionToPointerDecay>
+    | |     |-DeclRefExpr 0x36 <col:7> 'int (void)' Function 0x2 'next' 'int (void)'
+    | |-IntegerLiteral 0x37 <col:18> 'int' 2
+    |-DeclStmt 0x40 <line:5:5, col:24>
+    | |-VarDecl 0x41 <col:5, col:23> col:11 b 'char *' cinit
+    |   |-ImplicitCastExpr 0x42 <col:15, col:23> 'char *' <BitCast>
+    |     |-CallExpr 0x43 <col:15, col:23> 'void *'
+    |       |-ImplicitCastExpr 0x44 <col:15> 'void *(*)(unsigned long)' <BuiltinFnToFnPtr>
+    |       | |-DeclRefExpr 0x45 <col:15> '<builtin fn type>' Function 0x46 '__builtin_alloca' 'void *(unsigned long)'
+    |       |-ImplicitCastExpr 0x47 <col:22> 'unsigned long' <IntegralCast>
+    |         |-ImplicitCastExpr 0x48 <col:22> 'int' <LValueToRValue>
+    |           |-DeclRefExpr 0x49 <col:22> 'int' lvalue ParmVar 0x13 'n' 'int'
+    |-LabelStmt 0x50 <line:6:1, line:7:5> 'done'
+      |-ReturnStmt 0x51 <line:7:5>
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	// The typed variables are declared at the top of the function, and the
+	// temporary variable of the index is in a block of its own.
+	expectContains(t, output, `
+	var c2goAlloca2 []byte
+	var b *byte
+	if err != 0 {
+		goto done
+	}
+	{
+		c2goTempVar0 := ((*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(a)) + (uintptr)(next())*unsafe.Sizeof(*a))))
+		*c2goTempVar0 = byte(int32(int8(*c2goTempVar0)) / int32(2))
+	}
+	c2goAlloca2 = make([]byte, int32(uint64(n)))
+	b = (*byte)(noarch.SlicePointer(c2goAlloca2))
+done:
+`)
+}
@@ -0,0 +1,50 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+// parseTree builds the nodes from the output of "clang -ast-dump" for a test.
+// Each level of the tree is indented by two characters, the last child can be
+// written with "|-" instead of "`-".
+func parseTree(dump string) ast.Node {
+	var parents []ast.Node
+	var root ast.Node
+	for _, line := range strings.Split(strings.TrimSpace(dump), "\n") {
+		trimmed := strings.TrimLeft(line, "|\\- `")
+		depth := (len(line) - len(trimmed)) / 2
+		node := ast.Parse(trimmed)
+		parents = append(parents[:depth], node)
+		if depth == 0 {
+			root = node
+			continue
+		}
+		parents[depth-1].AddChild(node)
+	}
+	return root
+}
+
+// transpileDump transpiles the translation unit of a "clang -ast-dump" output
+// of the file x.c and returns the Go code.
+func transpileDump(t *testing.T, p *program.Program, dump string) string {
+	t.Helper()
+	if err := TranspileAST("x.c", "main", p, parseTree(dump).(*ast.TranslationUnitDecl)); err != nil {
+		t.Fatal(err)
+	}
+	return p.String()
+}
+
+// expectContains reports each of the wanted pieces of Go code that are not in
+// the output.
+func expectContains(t *testing.T, output string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+}
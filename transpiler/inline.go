@@ -0,0 +1,325 @@
+// This file contains the inlining of small functions at their calls. See
+// Program.InlineFunctions.
+
+package transpiler
+
+import (
+	goast "go/ast"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+)
+
+// findInlineFunctions registers the inline functions that are transpiled at
+// their calls instead of as a Go function, like:
+//
+//     static inline int get_x(const struct P *p) { return p->x; }
+//
+//     return get_x(a) + 1;   =>   return (*a).x + 1
+//
+// A function is only inlined when it is marked inline, its body is a single
+// return statement with a value and each parameter is read exactly once and
+// unconditionally, so that each argument is evaluated once as in a call. A
+// function with other statements, like the declaration of a static local
+// variable, is not inlined. The function must not call itself or another
+// inlined function, every use of it must be a call and the value of each call
+// must be used, since the expression alone would not be a valid Go statement.
+// The other names that the expression uses, like a global variable, must not
+// be declared by a function that calls it, since the local variable would be
+// used instead. Exported functions are never inlined.
+func findInlineFunctions(p *program.Program, n *ast.TranslationUnitDecl) {
+	if !p.InlineFunctions {
+		return
+	}
+
+	candidates := map[string]*ast.FunctionDecl{}
+	for _, c := range n.Children() {
+		f, ok := c.(*ast.FunctionDecl)
+		if !ok || !f.IsInline || f.Name == "main" || p.IsExportFunction(f.Name) {
+			continue
+		}
+		if getInlineExpr(f) != nil && readsParametersOnce(f) {
+			candidates[f.Name] = f
+		}
+	}
+
+	for name, f := range candidates {
+		for other := range candidates {
+			if callsFunction(getInlineExpr(f), other) {
+				delete(candidates, name)
+				break
+			}
+		}
+	}
+
+	// A reference to a function is called when it is the first child of a
+	// call, after the decay of the function to a pointer.
+	var walk func(node, parent, grandparent ast.Node)
+	walk = func(node, parent, grandparent ast.Node) {
+		if node == nil {
+			return
+		}
+		if ref, ok := node.(*ast.DeclRefExpr); ok && ref.For == "Function" {
+			if _, ok := candidates[ref.Name]; ok {
+				call, isCall := grandparent.(*ast.CallExpr)
+				if !isCall || getCalleeName(call) != ref.Name || call.Children()[0] != parent {
+					delete(candidates, ref.Name)
+				}
+			}
+		}
+		if call, ok := node.(*ast.CallExpr); ok && !isValueUsed(parent) {
+			if name := getCalleeName(call); name != "" {
+				delete(candidates, name)
+			}
+		}
+		for _, c := range node.Children() {
+			walk(c, node, parent)
+		}
+	}
+	for _, c := range n.Children() {
+		walk(c, n, nil)
+	}
+
+	for _, c := range n.Children() {
+		if f, ok := c.(*ast.FunctionDecl); ok {
+			removeShadowedInlineFunctions(f, candidates)
+		}
+	}
+
+	if len(candidates) > 0 {
+		p.InlinedFunctions = candidates
+	}
+}
+
+// getInlineExpr returns the expression of the only statement of a function,
+// which must be a return statement, or nil.
+func getInlineExpr(f *ast.FunctionDecl) ast.Node {
+	body := getFunctionBody(f)
+	if body == nil || len(body.Children()) != 1 {
+		return nil
+	}
+	ret, ok := body.Children()[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Children()) != 1 {
+		return nil
+	}
+	return ret.Children()[0]
+}
+
+// removeShadowedInlineFunctions removes the candidates that are called by the
+// function while one of the names that their expression uses, other than
+// their parameters, is declared by the function:
+//
+//     int g;
+//     static inline int add_g(int x) { return x + g; }
+//     int f(int g) { return add_g(1); }    // 1 + g would use the parameter
+func removeShadowedInlineFunctions(f *ast.FunctionDecl,
+	candidates map[string]*ast.FunctionDecl) {
+	locals := map[string]bool{}
+	var called []string
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		switch v := node.(type) {
+		case nil:
+			return
+		case *ast.ParmVarDecl:
+			locals[v.Name] = true
+		case *ast.VarDecl:
+			locals[v.Name] = true
+		case *ast.CallExpr:
+			if name := getCalleeName(v); name != "" {
+				called = append(called, name)
+			}
+		}
+		for _, c := range node.Children() {
+			walk(c)
+		}
+	}
+	walk(f)
+
+	for _, name := range called {
+		candidate, ok := candidates[name]
+		if !ok {
+			continue
+		}
+		for _, used := range usedNames(getInlineExpr(candidate)) {
+			if locals[used] {
+				delete(candidates, name)
+				break
+			}
+		}
+	}
+}
+
+// usedNames returns the names that an expression refers to, other than the
+// parameters of its function, like the global variables, the enum constants
+// and the functions.
+func usedNames(node ast.Node) (names []string) {
+	if node == nil {
+		return nil
+	}
+	if ref, ok := node.(*ast.DeclRefExpr); ok && ref.For != "ParmVar" {
+		names = append(names, ref.Name)
+	}
+	for _, c := range node.Children() {
+		names = append(names, usedNames(c)...)
+	}
+	return
+}
+
+// readsParametersOnce reports whether each parameter of a function is read
+// exactly once in its return expression and is not used in any other way,
+// like taking its address or assigning to it. The reads must not be in the
+// operands of "?:", "&&" and "||" that are only evaluated for some values,
+// since the argument of a call is always evaluated.
+func readsParametersOnce(f *ast.FunctionDecl) bool {
+	reads := map[ast.Address]int{}
+	for _, c := range f.Children() {
+		if param, ok := c.(*ast.ParmVarDecl); ok {
+			reads[param.Addr] = 0
+		}
+	}
+
+	ok := true
+	var walk func(node ast.Node, read, conditional bool)
+	walk = func(node ast.Node, read, conditional bool) {
+		if node == nil {
+			return
+		}
+		if ref, isRef := node.(*ast.DeclRefExpr); isRef && ref.For == "ParmVar" {
+			addr := ast.ParseAddress(ref.Address2)
+			if _, isParam := reads[addr]; !isParam || !read || conditional {
+				ok = false
+			}
+			reads[addr]++
+		}
+		cast, isCast := node.(*ast.ImplicitCastExpr)
+		for i, c := range node.Children() {
+			walk(c, isCast && cast.Kind == "LValueToRValue",
+				conditional || i > 0 && isConditionalOperator(node))
+		}
+	}
+	walk(getInlineExpr(f), false, false)
+
+	for _, n := range reads {
+		if n != 1 {
+			return false
+		}
+	}
+	return ok
+}
+
+// isConditionalOperator reports whether only the first operand of an
+// operator is always evaluated, like the condition of "?:".
+func isConditionalOperator(node ast.Node) bool {
+	switch v := node.(type) {
+	case *ast.ConditionalOperator, *ast.BinaryConditionalOperator:
+		return true
+	case *ast.BinaryOperator:
+		return v.Operator == "&&" || v.Operator == "||"
+	}
+	return false
+}
+
+// isValueUsed reports whether the value of a call is used by its parent. The
+// calls in the statements and in the conditions of the statements are not
+// inlined, nor the operands of the comma operator. A call in parentheses is
+// not inlined either, since it may be a statement on its own.
+func isValueUsed(parent ast.Node) bool {
+	switch v := parent.(type) {
+	case *ast.CompoundStmt, *ast.LabelStmt, *ast.CaseStmt, *ast.DefaultStmt,
+		*ast.IfStmt, *ast.ForStmt, *ast.WhileStmt, *ast.DoStmt,
+		*ast.SwitchStmt, *ast.ParenExpr:
+		return false
+	case *ast.CStyleCastExpr:
+		return v.Type != "void"
+	case *ast.BinaryOperator:
+		return v.Operator != ","
+	}
+	return true
+}
+
+// getCalleeName returns the name of the function that is called directly, or
+// "" for a call through a function pointer.
+func getCalleeName(call *ast.CallExpr) string {
+	if len(call.Children()) == 0 {
+		return ""
+	}
+	cast, ok := call.Children()[0].(*ast.ImplicitCastExpr)
+	if !ok || len(cast.Children()) != 1 {
+		return ""
+	}
+	if ref, ok := cast.Children()[0].(*ast.DeclRefExpr); ok && ref.For == "Function" {
+		return ref.Name
+	}
+	return ""
+}
+
+// transpileInlineCall transpiles a call of a function of
+// Program.InlinedFunctions into the return expression of the function. The
+// reads of the parameters are replaced by the arguments of the call while the
+// expression is transpiled. It returns false for the other calls.
+func transpileInlineCall(n *ast.CallExpr, p *program.Program) (
+	expr goast.Expr, exprType string, preStmts []goast.Stmt,
+	postStmts []goast.Stmt, ok bool, err error) {
+	f, ok := p.InlinedFunctions[getCalleeName(n)]
+	if !ok {
+		return
+	}
+
+	args := n.Children()[1:]
+	params := map[ast.Address]ast.Node{}
+	i := 0
+	for _, c := range f.Children() {
+		if param, isParam := c.(*ast.ParmVarDecl); isParam {
+			if i >= len(args) {
+				return nil, "", nil, nil, false, nil
+			}
+			params[param.Addr] = args[i]
+			i++
+		}
+	}
+
+	// Each read of a parameter is the only child of its cast.
+	var restore []func()
+	var replace func(node ast.Node)
+	replace = func(node ast.Node) {
+		for j, c := range node.Children() {
+			if cast, isCast := c.(*ast.ImplicitCastExpr); isCast && len(cast.Children()) == 1 {
+				if ref, isRef := cast.Children()[0].(*ast.DeclRefExpr); isRef && ref.For == "ParmVar" {
+					if arg, isParam := params[ast.ParseAddress(ref.Address2)]; isParam {
+						children, index, old := node.Children(), j, c
+						children[index] = arg
+						restore = append(restore, func() { children[index] = old })
+						continue
+					}
+				}
+			}
+			if c != nil {
+				replace(c)
+			}
+		}
+	}
+	ret := getFunctionBody(f).Children()[0]
+	replace(ret)
+	defer func() {
+		for _, r := range restore {
+			r()
+		}
+	}()
+
+	expr, exprType, preStmts, postStmts, err = transpileToExpr(ret.Children()[0], p, false)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+
+	expr, err = types.CastExpr(p, expr, exprType, n.Type)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	if _, isBinary := expr.(*goast.BinaryExpr); isBinary {
+		expr = &goast.ParenExpr{X: expr}
+	}
+
+	return expr, n.Type, preStmts, postStmts, true, nil
+}
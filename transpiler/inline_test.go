@@ -0,0 +1,208 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestInlineFunctions(t *testing.T) {
+	// struct P { int x; };
+	// static inline int get_x(const struct P *p) { return p->x; }
+	// static inline int counter(void) {
+	//     static int n;
+	//     return ++n;
+	// }
+	// int f(struct P *a) { return get_x(a) + counter(); }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x2 <x.c:1:1, col:20> col:8 struct P definition
+| |-FieldDecl 0x3 <col:12, col:16> col:16 referenced x 'int'
+|-FunctionDecl 0x10 <line:2:1, col:60> col:19 used get_x 'int (const struct P *)' static inline
+| |-ParmVarDecl 0x11 <col:25, col:41> col:41 used p 'const struct P *'
+| |-CompoundStmt 0x12 <col:44, col:60>
+|   |-ReturnStmt 0x13 <col:46, col:56>
+|     |-ImplicitCastExpr 0x14 <col:53, col:56> 'int' <LValueToRValue>
+|       |-MemberExpr 0x15 <col:53, col:56> 'const int' lvalue ->x 0x3
+|         |-ImplicitCastExpr 0x16 <col:53> 'const struct P *' <LValueToRValue>
+|           |-DeclRefExpr 0x17 <col:53> 'const struct P *' lvalue ParmVar 0x11 'p' 'const struct P *'
+|-FunctionDecl 0x20 <line:3:1, line:6:1> line:3:19 used counter 'int (void)' static inline
+| |-CompoundStmt 0x21 <col:33, line:6:1>
+|   |-DeclStmt 0x22 <line:4:5, col:17>
+|   | |-VarDecl 0x23 <col:5, col:16> col:16 used n 'int' static
+|   |-ReturnStmt 0x24 <line:5:5, col:14>
+|     |-UnaryOperator 0x25 <col:12, col:14> 'int' prefix '++'
+|       |-DeclRefExpr 0x26 <col:14> 'int' lvalue Var 0x23 'n' 'int'
+|-FunctionDecl 0x30 <line:7:1, col:52> col:5 f 'int (struct P *)'
+  |-ParmVarDecl 0x31 <col:7, col:17> col:17 used a 'struct P *'
+  |-CompoundStmt 0x32 <col:20, col:52>
+    |-ReturnStmt 0x33 <col:22, col:49>
+      |-BinaryOperator 0x34 <col:29, col:49> 'int' '+'
+        |-CallExpr 0x35 <col:29, col:36> 'int'
+        | |-ImplicitCastExpr 0x36 <col:29> 'int (*)(const struct P *)' <FunctionToPointerDecay>
+        | | |-DeclRefExpr 0x37 <col:29> 'int (const struct P *)' Function 0x10 'get_x' 'int (const struct P *)'
+        | |-ImplicitCastExpr 0x38 <col:35> 'const struct P *' <NoOp>
+        |   |-ImplicitCastExpr 0x39 <col:35> 'struct P *' <LValueToRValue>
+        |     |-DeclRefExpr 0x3a <col:35> 'struct P *' lvalue ParmVar 0x31 'a' 'struct P *'
+        |-CallExpr 0x3b <col:40, col:48> 'int'
+          |-ImplicitCastExpr 0x3c <col:40> 'int (*)(void)' <FunctionToPointerDecay>
+            |-DeclRefExpr 0x3d <col:40> 'int (void)' Function 0x20 'counter' 'int (void)'
+`
+
+	for _, inline := range []bool{false, true} {
+		p := program.NewProgram()
+		p.InlineFunctions = inline
+		output := transpileDump(t, p, dump)
+
+		if inline == strings.Contains(output, "func get_x(") {
+			t.Errorf("InlineFunctions = %v: unexpected FuncDecl of get_x in:\n%s", inline, output)
+		}
+		want := "return get_x(a) + counter()"
+		if inline {
+			want = "return (*a).x + counter()"
+		}
+		if !strings.Contains(output, want) {
+			t.Errorf("InlineFunctions = %v: expected %q in:\n%s", inline, want, output)
+		}
+
+		// The static local variable stays with its function.
+		if !strings.Contains(output, "func counter() int32 {") {
+			t.Errorf("InlineFunctions = %v: expected the FuncDecl of counter in:\n%s", inline, output)
+		}
+	}
+}
+
+func TestInlineFunctionsNotInlined(t *testing.T) {
+	// static inline int twice(int n) { return n + n; }
+	// static inline int get(int n) { return n; }
+	// int (*fp)(int) = get;
+	// void f(int a) { twice(a); get(a); }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:50> col:19 used twice 'int (int)' static inline
+| |-ParmVarDecl 0x11 <col:25, col:29> col:29 used n 'int'
+| |-CompoundStmt 0x12 <col:32, col:50>
+|   |-ReturnStmt 0x13 <col:34, col:45>
+|     |-BinaryOperator 0x14 <col:41, col:45> 'int' '+'
+|       |-ImplicitCastExpr 0x15 <col:41> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x16 <col:41> 'int' lvalue ParmVar 0x11 'n' 'int'
+|       |-ImplicitCastExpr 0x17 <col:45> 'int' <LValueToRValue>
+|         |-DeclRefExpr 0x18 <col:45> 'int' lvalue ParmVar 0x11 'n' 'int'
+|-FunctionDecl 0x20 <line:2:1, col:42> col:19 used get 'int (int)' static inline
+| |-ParmVarDecl 0x21 <col:23, col:27> col:27 used n 'int'
+| |-CompoundStmt 0x22 <col:30, col:42>
+|   |-ReturnStmt 0x23 <col:32, col:39>
+|     |-ImplicitCastExpr 0x24 <col:39> 'int' <LValueToRValue>
+|       |-DeclRefExpr 0x25 <col:39> 'int' lvalue ParmVar 0x21 'n' 'int'
+|-VarDecl 0x30 <line:3:1, col:18> col:7 fp 'int (*)(int)' cinit
+| |-ImplicitCastExpr 0x31 <col:18> 'int (*)(int)' <FunctionToPointerDecay>
+|   |-DeclRefExpr 0x32 <col:18> 'int (int)' Function 0x20 'get' 'int (int)'
+|-FunctionDecl 0x40 <line:4:1, col:35> col:6 f 'void (int)'
+  |-ParmVarDecl 0x41 <col:8, col:12> col:12 used a 'int'
+  |-CompoundStmt 0x42 <col:15, col:35>
+    |-CallExpr 0x43 <col:17, col:24> 'int'
+    | |-ImplicitCastExpr 0x44 <col:17> 'int (*)(int)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x45 <col:17> 'int (int)' Function 0x10 'twice' 'int (int)'
+    | |-ImplicitCastExpr 0x46 <col:23> 'int' <LValueToRValue>
+    |   |-DeclRefExpr 0x47 <col:23> 'int' lvalue ParmVar 0x41 'a' 'int'
+    |-CallExpr 0x48 <col:27, col:32> 'int'
+      |-ImplicitCastExpr 0x49 <col:27> 'int (*)(int)' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0x4a <col:27> 'int (int)' Function 0x20 'get' 'int (int)'
+      |-ImplicitCastExpr 0x4b <col:31> 'int' <LValueToRValue>
+        |-DeclRefExpr 0x4c <col:31> 'int' lvalue ParmVar 0x41 'a' 'int'
+`
+
+	p := program.NewProgram()
+	p.InlineFunctions = true
+	output := transpileDump(t, p, dump)
+
+	// twice() reads its parameter twice and get() is used as a function
+	// pointer.
+	expectContains(t, output,
+		"func twice(n int32) int32 {",
+		"func get(n int32) int32 {",
+		"twice(a)",
+		"get(a)",
+	)
+	if len(p.InlinedFunctions) != 0 {
+		t.Errorf("Unexpected inlined functions: %v", p.InlinedFunctions)
+	}
+}
+
+func TestInlineFunctionsShadowedOrConditional(t *testing.T) {
+	// int g;
+	// static inline int add_g(int x) { return x + g; }
+	// static inline int both(int a, int b) { return a && b; }
+	// static inline int sign(int c) { return c ? 1 : 0; }
+	// int f(int g) { return add_g(1) + both(g, 2) + sign(g); }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x2 <x.c:1:1, col:5> col:5 used g 'int'
+|-FunctionDecl 0x10 <line:2:1, col:48> col:19 used add_g 'int (int)' static inline
+| |-ParmVarDecl 0x11 <col:25, col:29> col:29 used x 'int'
+| |-CompoundStmt 0x12 <col:32, col:48>
+|   |-ReturnStmt 0x13 <col:34, col:45>
+|     |-BinaryOperator 0x14 <col:41, col:45> 'int' '+'
+|       |-ImplicitCastExpr 0x15 <col:41> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x16 <col:41> 'int' lvalue ParmVar 0x11 'x' 'int'
+|       |-ImplicitCastExpr 0x17 <col:45> 'int' <LValueToRValue>
+|         |-DeclRefExpr 0x18 <col:45> 'int' lvalue Var 0x2 'g' 'int'
+|-FunctionDecl 0x20 <line:3:1, col:55> col:19 used both 'int (int, int)' static inline
+| |-ParmVarDecl 0x21 <col:24, col:28> col:28 used a 'int'
+| |-ParmVarDecl 0x22 <col:31, col:35> col:35 used b 'int'
+| |-CompoundStmt 0x23 <col:38, col:55>
+|   |-ReturnStmt 0x24 <col:40, col:52>
+|     |-BinaryOperator 0x25 <col:47, col:52> 'int' '&&'
+|       |-ImplicitCastExpr 0x26 <col:47> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x27 <col:47> 'int' lvalue ParmVar 0x21 'a' 'int'
+|       |-ImplicitCastExpr 0x28 <col:52> 'int' <LValueToRValue>
+|         |-DeclRefExpr 0x29 <col:52> 'int' lvalue ParmVar 0x22 'b' 'int'
+|-FunctionDecl 0x30 <line:4:1, col:50> col:19 used sign 'int (int)' static inline
+| |-ParmVarDecl 0x31 <col:24, col:28> col:28 used c 'int'
+| |-CompoundStmt 0x32 <col:31, col:50>
+|   |-ReturnStmt 0x33 <col:33, col:47>
+|     |-ConditionalOperator 0x34 <col:40, col:47> 'int'
+|       |-ImplicitCastExpr 0x35 <col:40> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x36 <col:40> 'int' lvalue ParmVar 0x31 'c' 'int'
+|       |-IntegerLiteral 0x37 <col:44> 'int' 1
+|       |-IntegerLiteral 0x38 <col:47> 'int' 0
+|-FunctionDecl 0x40 <line:5:1, col:57> col:5 f 'int (int)'
+  |-ParmVarDecl 0x41 <col:7, col:11> col:11 used g 'int'
+  |-CompoundStmt 0x42 <col:14, col:57>
+    |-ReturnStmt 0x43 <col:16, col:54>
+      |-BinaryOperator 0x44 <col:23, col:54> 'int' '+'
+        |-BinaryOperator 0x45 <col:23, col:44> 'int' '+'
+        | |-CallExpr 0x46 <col:23, col:30> 'int'
+        | | |-ImplicitCastExpr 0x47 <col:23> 'int (*)(int)' <FunctionToPointerDecay>
+        | | | |-DeclRefExpr 0x48 <col:23> 'int (int)' Function 0x10 'add_g' 'int (int)'
+        | | |-IntegerLiteral 0x49 <col:29> 'int' 1
+        | |-CallExpr 0x4a <col:34, col:44> 'int'
+        |   |-ImplicitCastExpr 0x4b <col:34> 'int (*)(int, int)' <FunctionToPointerDecay>
+        |   | |-DeclRefExpr 0x4c <col:34> 'int (int, int)' Function 0x20 'both' 'int (int, int)'
+        |   |-ImplicitCastExpr 0x4d <col:39> 'int' <LValueToRValue>
+        |   | |-DeclRefExpr 0x4e <col:39> 'int' lvalue ParmVar 0x41 'g' 'int'
+        |   |-IntegerLiteral 0x4f <col:42> 'int' 2
+        |-CallExpr 0x50 <col:48, col:54> 'int'
+          |-ImplicitCastExpr 0x51 <col:48> 'int (*)(int)' <FunctionToPointerDecay>
+          | |-DeclRefExpr 0x52 <col:48> 'int (int)' Function 0x30 'sign' 'int (int)'
+          |-ImplicitCastExpr 0x53 <col:53> 'int' <LValueToRValue>
+            |-DeclRefExpr 0x54 <col:53> 'int' lvalue ParmVar 0x41 'g' 'int'
+`
+
+	p := program.NewProgram()
+	p.InlineFunctions = true
+	output := transpileDump(t, p, dump)
+
+	// The g of add_g() would be the parameter of f() and the b of both()
+	// would only be evaluated when a is true. The condition of sign() is
+	// always evaluated.
+	expectContains(t, output,
+		"func add_g(x int32) int32 {",
+		"func both(a int32, b int32) int32 {",
+		"add_g(int32(1)) + both(g, int32(2))",
+	)
+	if _, ok := p.InlinedFunctions["sign"]; !ok || len(p.InlinedFunctions) != 1 {
+		t.Errorf("Expected only sign() to be inlined, got: %v", p.InlinedFunctions)
+	}
+}
@@ -0,0 +1,90 @@
+// This file contains the comments with the locations in the C code, see
+// program.Program.LineComments.
+
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	"strings"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+// addLineComments attaches the location of a top-level node in the C code to
+// the doc comments of the declarations that are transpiled from it.
+func addLineComments(p *program.Program, n ast.Node, decls []goast.Decl) {
+	if !p.LineComments {
+		return
+	}
+
+	pos, ok := linePosition(n)
+	if !ok {
+		return
+	}
+	comment := &goast.Comment{
+		Text: fmt.Sprintf("// #line %d %q", pos.Line, pos.File),
+	}
+
+	for _, decl := range decls {
+		switch d := decl.(type) {
+		case *goast.FuncDecl:
+			d.Doc = appendComment(d.Doc, comment)
+		case *goast.GenDecl:
+			d.Doc = appendComment(d.Doc, comment)
+		}
+	}
+}
+
+// linePosition returns the location of a node for its line comment. The code
+// that is expanded from a macro starts in the header of the macro, like:
+//
+//	DEFINE_GETTER(width)
+//
+// so the first location of the node or its children that is not in a header
+// is used instead. The location in the header is the last resort.
+func linePosition(n ast.Node) (pos ast.Position, ok bool) {
+	var header ast.Position
+	var find func(node ast.Node) bool
+	find = func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		position := node.Position()
+		if position.Line > 0 && isSourceFile(position.File) {
+			pos = position
+			return true
+		}
+		if position.Line > 0 && header.Line == 0 &&
+			strings.HasSuffix(position.File, ".h") {
+			header = position
+		}
+		for _, child := range node.Children() {
+			if find(child) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if find(n) {
+		return pos, true
+	}
+	return header, header.Line > 0
+}
+
+// isSourceFile reports whether the file of a location is a C file rather than
+// a header or a place of clang, like "<built-in>" or "<scratch space>".
+func isSourceFile(file string) bool {
+	return file != "" && !strings.HasPrefix(file, "<") &&
+		!strings.HasSuffix(file, ".h")
+}
+
+func appendComment(doc *goast.CommentGroup, c *goast.Comment) *goast.CommentGroup {
+	if doc == nil {
+		doc = &goast.CommentGroup{}
+	}
+	doc.List = append(doc.List, c)
+	return doc
+}
@@ -0,0 +1,65 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestLineComments(t *testing.T) {
+	// int limit = 3;
+	//
+	// int twice(int a) {
+	//     return a * 2;
+	// }
+	//
+	// GETTER(limit) {
+	//     return limit;
+	// }
+	//
+	// where getter.h has:
+	//
+	//     #define GETTER(name) int get_##name(void)
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x10 <x.c:1:1, col:13> col:5 used limit 'int' cinit
+| |-IntegerLiteral 0x11 <col:13> 'int' 3
+|-FunctionDecl 0x20 <line:3:1, line:5:1> line:3:5 twice 'int (int)'
+| |-ParmVarDecl 0x21 <col:11, col:15> col:15 used a 'int'
+| |-CompoundStmt 0x22 <col:18, line:5:1>
+|   |-ReturnStmt 0x23 <line:4:5, col:16>
+|     |-BinaryOperator 0x24 <col:12, col:16> 'int' '*'
+|       |-ImplicitCastExpr 0x25 <col:12> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x26 <col:12> 'int' lvalue ParmVar 0x21 'a' 'int'
+|       |-IntegerLiteral 0x27 <col:16> 'int' 2
+|-FunctionDecl 0x30 <./getter.h:1:22, x.c:9:1> ./getter.h:1:26 get_limit 'int (void)'
+  |-CompoundStmt 0x31 <x.c:7:15, line:9:1>
+    |-ReturnStmt 0x32 <line:8:5, col:12>
+      |-ImplicitCastExpr 0x33 <col:12> 'int' <LValueToRValue>
+        |-DeclRefExpr 0x34 <col:12> 'int' lvalue Var 0x10 'limit' 'int'
+`
+	for _, lineComments := range []bool{false, true} {
+		tree := parseTree(dump)
+		ast.FixPositions([]ast.Node{tree})
+
+		p := program.NewProgram()
+		p.LineComments = lineComments
+		if err := TranspileAST("x.c", "main", p, tree); err != nil {
+			t.Fatal(err)
+		}
+		output := p.String()
+
+		for _, want := range []string{
+			"// #line 1 \"x.c\"\nvar limit int32",
+			"// #line 3 \"x.c\"\nfunc twice(",
+			"// #line 7 \"x.c\"\nfunc get_limit(",
+		} {
+			if strings.Contains(output, want) != lineComments {
+				t.Errorf("line comments %v: unexpected %q in:\n%s",
+					lineComments, want, output)
+			}
+		}
+	}
+}
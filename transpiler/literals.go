@@ -11,6 +11,9 @@ import (
 	goast "go/ast"
 
 	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/elliotchance/c2go/ast"
 	"github.com/elliotchance/c2go/program"
@@ -30,14 +33,71 @@ func transpileStringLiteral(n *ast.StringLiteral) goast.Expr {
 		return toBytePointer(util.NewCallExpr("[]byte",
 			util.NewStringLit(strconv.Quote(n.Value+"\x00"))))
 	}
+	// The size is the one of the array that is initialized, like in
+	// "char s[8] = "hi"", so the string is padded with zeros or truncated. The
+	// null character is dropped when it does not fit, as in C.
 	buf := bytes.NewBufferString(n.Value + "\x00")
 	if buf.Len() < s {
 		buf.Write(make([]byte, s-buf.Len()))
 	}
+	buf.Truncate(s)
 	return toBytePointer(util.NewCallExpr("[]byte",
 		util.NewStringLit(strconv.Quote(buf.String()))))
 }
 
+// transpileWideStringLiteral transpiles a wide or a Unicode string, like
+// L"abc", into a pointer to the first element of a slice of its characters:
+//
+//     L"abc"    =>    (&[]int32{'a', 'b', 'c', '\x00'}[0])
+//
+// The type of the characters is the type of the elements of the array, that
+// is wchar_t for L. The characters of a 16-bit type are encoded as UTF-16.
+func transpileWideStringLiteral(n *ast.StringLiteral, p *program.Program) (
+	goast.Expr, string, error) {
+	cType, size := types.GetArrayTypeAndSize(n.Type)
+	goType, err := types.ResolveType(p, cType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The bytes that are not UTF-8, like the octal escape of "\242", are the
+	// characters of the same value.
+	var chars []rune
+	for s := n.Value; len(s) > 0; {
+		r, width := utf8.DecodeRuneInString(s)
+		if r == utf8.RuneError && width == 1 {
+			r = rune(s[0])
+		}
+		chars = append(chars, r)
+		s = s[width:]
+	}
+	if goType == "uint16" || goType == "int16" {
+		var units []rune
+		for _, u := range utf16.Encode(chars) {
+			units = append(units, rune(u))
+		}
+		chars = units
+	}
+	chars = append(chars, 0)
+	for len(chars) < size {
+		chars = append(chars, 0)
+	}
+
+	elts := make([]goast.Expr, len(chars))
+	for i, c := range chars {
+		if utf8.ValidRune(c) {
+			elts[i] = &goast.BasicLit{Kind: token.CHAR, Value: fmt.Sprintf("%q", c)}
+		} else {
+			elts[i] = util.NewIntLit(int(c))
+		}
+	}
+
+	return toBytePointer(&goast.CompositeLit{
+		Type: &goast.ArrayType{Elt: util.NewTypeIdent(goType)},
+		Elts: elts,
+	}), "const " + strings.TrimPrefix(cType, "const ") + " *", nil
+}
+
 func toBytePointer(expr goast.Expr) goast.Expr {
 	return &goast.ParenExpr{
 		X: &goast.UnaryExpr{
@@ -61,53 +121,116 @@ func transpileIntegerLiteral(n *ast.IntegerLiteral) (ret goast.Expr) {
 	return
 }
 
+// characterValue returns the value of a character constant. A constant like
+// 'a' has the type int in C but the value of a char. When the char is signed
+// on the ABI the characters above 127 like '\xa0' are negative, clang may also
+// print them as an unsigned 32-bit number, like 4294967200.
+func characterValue(p *program.Program, n *ast.CharacterLiteral) int64 {
+	if n.Type != "int" {
+		return int64(n.Value)
+	}
+	if p.ABI.CharSigned {
+		return int64(int8(n.Value))
+	}
+	return int64(uint8(n.Value))
+}
+
 func transpileCharacterLiteral(n *ast.CharacterLiteral) *goast.BasicLit {
 	return &goast.BasicLit{
 		Kind:  token.CHAR,
-		Value: fmt.Sprintf("%q", n.Value),
+		Value: fmt.Sprintf("%q", rune(n.Value)),
 	}
 }
 
+// transpilePredefinedExpr transpiles __func__, __FUNCTION__ and
+// __PRETTY_FUNCTION__ into a string literal of the function that lexically
+// contains them. Clang puts that string into the only child of the
+// PredefinedExpr, so it is used when it exists. Otherwise the function that is
+// being transpiled is used.
 func transpilePredefinedExpr(n *ast.PredefinedExpr, p *program.Program) (goast.Expr, string, error) {
-	// A predefined expression is a literal that is not given a value until
-	// compile time.
-	//
-	// TODO: Predefined expressions are not evaluated
-	// https://github.com/elliotchance/c2go/issues/81
+	if len(n.Children()) > 0 {
+		if s, ok := n.Children()[0].(*ast.StringLiteral); ok {
+			return transpileStringLiteral(s), "const char*", nil
+		}
+	}
 
-	var e goast.Expr
+	var name string
 	switch n.Name {
-	case "__PRETTY_FUNCTION__":
-		e = util.NewCallExpr(
-			"[]byte",
-			util.NewStringLit(`"void print_number(int *)\x00"`),
-		)
+	case "__func__", "__FUNCTION__":
+		if p.Function != nil {
+			name = p.Function.Name
+		}
 
-	case "__func__":
-		e = util.NewCallExpr(
-			"[]byte",
-			util.NewStringLit(strconv.Quote(p.Function.Name+"\x00")),
-		)
+	case "__PRETTY_FUNCTION__":
+		// The type of the function is like "void (int *)", so the pretty
+		// name is "void print_number(int *)".
+		if p.Function != nil {
+			name = strings.Replace(p.Function.Type, "(", p.Function.Name+"(", 1)
+		}
 
 	default:
 		// There are many more.
-		panic(fmt.Sprintf("unknown PredefinedExpr: %s", n.Name))
+		return nil, "", fmt.Errorf("unknown PredefinedExpr: %s", n.Name)
 	}
-	e = &goast.ParenExpr{
-		X: &goast.UnaryExpr{
-			Op: token.AND,
-			X: &goast.IndexExpr{
-				X:     e,
-				Index: util.NewIntLit(0),
-			},
-		},
+
+	return toBytePointer(util.NewCallExpr(
+		"[]byte",
+		util.NewStringLit(strconv.Quote(name+"\x00")),
+	)), "const char*", nil
+}
+
+// transpileCompoundLiteralExpr transpiles a compound literal of C99 into the
+// Go composite literal of its type:
+//
+//     (struct P){1, 2}    =>    P{int32(1), int32(2)}
+//     (int[]){1, 2, 3}    =>    []int32{int32(1), int32(2), int32(3)}
+//
+// A scalar type, like a number or a pointer, has no composite literal in Go,
+// so it is the value converted to the type:
+//
+//     (double){1}         =>    float64(int32(1))
+//
+// The address of a compound literal is transpiled by
+// transpileUnaryOperatorAmpersant().
+func transpileCompoundLiteralExpr(n *ast.CompoundLiteralExpr, p *program.Program) (
+	_ goast.Expr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+	if !isScalarCompoundLiteral(p, n) {
+		return transpileToExpr(n.Children()[0], p, false)
 	}
-	return e, "const char*", nil
+
+	var values []ast.Node
+	if list, ok := n.Children()[0].(*ast.InitListExpr); ok {
+		values = list.Children()
+	} else {
+		values = n.Children()
+	}
+	if len(values) == 0 {
+		zero, ok := zeroValueOf(p, n.Type1)
+		if !ok {
+			return nil, "", nil, nil, fmt.Errorf("cannot find the zero value of '%s'", n.Type1)
+		}
+		return zero, n.Type1, nil, nil, nil
+	}
+
+	expr, exprType, preStmts, postStmts, err := transpileToExpr(values[0], p, false)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	expr, err = types.CastExpr(p, expr, exprType, n.Type1)
+	return expr, n.Type1, preStmts, postStmts, err
 }
 
-func transpileCompoundLiteralExpr(n *ast.CompoundLiteralExpr, p *program.Program) (goast.Expr, string, error) {
-	expr, t, _, _, err := transpileToExpr(n.Children()[0], p, false)
-	return expr, t, err
+// isScalarCompoundLiteral returns true if the type of the compound literal is
+// not a struct, a union or an array.
+func isScalarCompoundLiteral(p *program.Program, n *ast.CompoundLiteralExpr) bool {
+	cType := n.Type2
+	if cType == "" {
+		cType = n.Type1
+	}
+	if strings.Contains(cType, "[") && !types.IsPointerToArray(cType) {
+		return false
+	}
+	return types.IsPointer(p, cType) || p.GetStruct(cType) == nil
 }
 
 func transpileConstantExpr(n *ast.ConstantExpr, p *program.Program) (goast.Expr, string, error) {
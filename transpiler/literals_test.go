@@ -1,11 +1,15 @@
 package transpiler
 
 import (
+	"bytes"
+	"go/format"
 	"reflect"
+	"strings"
 	"testing"
 	"unicode/utf8"
 
 	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
 	goast "go/ast"
 	"go/token"
 )
@@ -55,3 +59,340 @@ func TestCharacterLiterals(t *testing.T) {
 		}
 	}
 }
+
+func TestCharacterValue(t *testing.T) {
+	unsignedChar := *program.LP64
+	unsignedChar.CharSigned = false
+
+	for _, tt := range []struct {
+		abi   *program.ABI
+		value int
+		want  int64
+	}{
+		{program.LP64, 'a', 97},
+		{program.LP64, 4294967200, -96},
+		{program.LP64, 0xa0, -96},
+		{&unsignedChar, 'a', 97},
+		{&unsignedChar, 0xa0, 160},
+		{&unsignedChar, 4294967200, 160},
+	} {
+		p := program.NewProgram()
+		p.ABI = tt.abi
+		n := &ast.CharacterLiteral{Type: "int", Value: tt.value}
+		if got := characterValue(p, n); got != tt.want {
+			t.Errorf("%s: characterValue(%d) = %d, want %d", tt.abi.Name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	for _, tt := range []struct {
+		in  string
+		out string
+	}{
+		{`'char [4]' lvalue "abc"`, `(&[]byte("abc\x00")[0])`},
+		// The bytes after an embedded null are kept.
+		{`'char [9]' lvalue "ab\x00-cd\0e"`, `(&[]byte("ab\x00-cd\x00e\x00")[0])`},
+		{`'char [6]' lvalue "\101\102\0\103\7"`, `(&[]byte("AB\x00C\a\x00")[0])`},
+		{`'char [5]' lvalue "\?\?=\e"`, `(&[]byte("??=\x1b\x00")[0])`},
+		{`'char [3]' lvalue "\377\200"`, `(&[]byte("\xff\x80\x00")[0])`},
+		// The string of "char s[8] = "hi"" is padded and the one of
+		// "char s[3] = "hello"" is truncated without the null character.
+		{`'char [8]' lvalue "hi"`, `(&[]byte("hi\x00\x00\x00\x00\x00\x00")[0])`},
+		{`'char [3]' lvalue "hello"`, `(&[]byte("hel")[0])`},
+	} {
+		n := ast.Parse("StringLiteral 0x1 <col:1> " + tt.in).(*ast.StringLiteral)
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), transpileStringLiteral(n)); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.out {
+			t.Errorf("%s: expected %s, got %s", tt.in, tt.out, buf.String())
+		}
+	}
+}
+
+func TestCompoundLiteral(t *testing.T) {
+	// struct P { int x; int y; };
+	// int f(struct P a) { return a.x; }
+	// int g(struct P *a) { return a->y; }
+	// int h(void) {
+	//     int *v = (int[]){1, 2, 3};
+	//     int *w = &(int){5};
+	//     return f((struct P){1, 2}) + g(&(struct P){3, 4}) + *w;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x10 <x.c:1:1, col:26> col:8 struct P definition
+| |-FieldDecl 0x11 <col:12, col:16> col:16 referenced x 'int'
+| |-FieldDecl 0x12 <col:19, col:23> col:23 referenced y 'int'
+|-FunctionDecl 0x20 <line:2:1, col:33> col:5 used f 'int (struct P)'
+| |-ParmVarDecl 0x21 <col:7, col:16> col:16 used a 'struct P':'struct P'
+| |-CompoundStmt 0x22 <col:19, col:33>
+|   |-ReturnStmt 0x23 <col:21, col:30>
+|     |-ImplicitCastExpr 0x24 <col:28, col:30> 'int' <LValueToRValue>
+|       |-MemberExpr 0x25 <col:28, col:30> 'int' lvalue .x 0x11
+|         |-DeclRefExpr 0x26 <col:28> 'struct P':'struct P' lvalue ParmVar 0x21 'a' 'struct P':'struct P'
+|-FunctionDecl 0x30 <line:3:1, col:35> col:5 used g 'int (struct P *)'
+| |-ParmVarDecl 0x31 <col:7, col:17> col:17 used a 'struct P *'
+| |-CompoundStmt 0x32 <col:20, col:35>
+|   |-ReturnStmt 0x33 <col:22, col:32>
+|     |-ImplicitCastExpr 0x34 <col:29, col:32> 'int' <LValueToRValue>
+|       |-MemberExpr 0x35 <col:29, col:32> 'int' lvalue ->y 0x12
+|         |-ImplicitCastExpr 0x36 <col:29> 'struct P *' <LValueToRValue>
+|           |-DeclRefExpr 0x37 <col:29> 'struct P *' lvalue ParmVar 0x31 'a' 'struct P *'
+|-FunctionDecl 0x40 <line:4:1, line:8:1> line:4:5 h 'int (void)'
+  |-CompoundStmt 0x41 <col:17, line:8:1>
+    |-DeclStmt 0x42 <line:5:5, col:30>
+    | |-VarDecl 0x43 <col:5, col:29> col:10 used v 'int *' cinit
+    |   |-ImplicitCastExpr 0x44 <col:14, col:29> 'int *' <ArrayToPointerDecay>
+    |     |-CompoundLiteralExpr 0x45 <col:14, col:29> 'int [3]' lvalue
+    |       |-InitListExpr 0x46 <col:21, col:29> 'int [3]'
+    |         |-IntegerLiteral 0x47 <col:22> 'int' 1
+    |         |-IntegerLiteral 0x48 <col:25> 'int' 2
+    |         |-IntegerLiteral 0x49 <col:28> 'int' 3
+    |-DeclStmt 0x80 <line:6:5, col:22>
+    | |-VarDecl 0x81 <col:5, col:21> col:10 used w 'int *' cinit
+    |   |-UnaryOperator 0x82 <col:14, col:21> 'int *' prefix '&' cannot overflow
+    |     |-CompoundLiteralExpr 0x83 <col:15, col:21> 'int' lvalue
+    |       |-InitListExpr 0x84 <col:20, col:21> 'int'
+    |         |-IntegerLiteral 0x85 <col:21> 'int' 5
+    |-ReturnStmt 0x50 <line:7:5, col:60>
+      |-BinaryOperator 0x51 <col:12, col:60> 'int' '+'
+        |-BinaryOperator 0x52 <col:12, col:52> 'int' '+'
+        | |-CallExpr 0x53 <col:12, col:30> 'int'
+        | | |-ImplicitCastExpr 0x54 <col:12> 'int (*)(struct P)' <FunctionToPointerDecay>
+        | | | |-DeclRefExpr 0x55 <col:12> 'int (struct P)' Function 0x20 'f' 'int (struct P)'
+        | | |-ImplicitCastExpr 0x56 <col:14, col:29> 'struct P':'struct P' <LValueToRValue>
+        | |   |-CompoundLiteralExpr 0x57 <col:14, col:29> 'struct P':'struct P' lvalue
+        | |     |-InitListExpr 0x58 <col:24, col:29> 'struct P':'struct P'
+        | |       |-IntegerLiteral 0x59 <col:25> 'int' 1
+        | |       |-IntegerLiteral 0x5a <col:28> 'int' 2
+        | |-CallExpr 0x60 <col:34, col:52> 'int'
+        |   |-ImplicitCastExpr 0x61 <col:34> 'int (*)(struct P *)' <FunctionToPointerDecay>
+        |   | |-DeclRefExpr 0x62 <col:34> 'int (struct P *)' Function 0x30 'g' 'int (struct P *)'
+        |   |-UnaryOperator 0x63 <col:36, col:51> 'struct P *' prefix '&' cannot overflow
+        |     |-CompoundLiteralExpr 0x64 <col:37, col:51> 'struct P':'struct P' lvalue
+        |       |-InitListExpr 0x65 <col:47, col:51> 'struct P':'struct P'
+        |         |-IntegerLiteral 0x66 <col:48> 'int' 3
+        |         |-IntegerLiteral 0x67 <col:51> 'int' 4
+        |-ImplicitCastExpr 0x70 <col:56, col:60> 'int' <LValueToRValue>
+          |-UnaryOperator 0x71 <col:56, col:60> 'int' lvalue prefix '*' cannot overflow
+            |-ImplicitCastExpr 0x72 <col:57> 'int *' <LValueToRValue>
+              |-DeclRefExpr 0x73 <col:57> 'int *' lvalue Var 0x81 'w' 'int *'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"var v *int32 = &[]int32{int32(1), int32(2), int32(3)}[0]",
+		"var w *int32 = &[]int32{int32(5)}[0]",
+		"return f(P{int32(1), int32(2)}) + g(&P{int32(3), int32(4)}) + *w",
+	)
+}
+
+func TestPredefinedExpr(t *testing.T) {
+	// void log_call(int *a) {
+	//     printf("%s", __func__);
+	//     printf("%s", __FUNCTION__);
+	//     printf("%s", __PRETTY_FUNCTION__);
+	// }
+	//
+	// The last PredefinedExpr has no StringLiteral, so the name is taken
+	// from the function that is being transpiled.
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:5:1> line:1:6 log_call 'void (int *)'
+  |-ParmVarDecl 0x11 <col:15, col:20> col:20 a 'int *'
+  |-CompoundStmt 0x12 <col:23, line:5:1>
+    |-CallExpr 0x20 <line:2:5, col:26> 'int'
+    | |-ImplicitCastExpr 0x21 <col:5> 'int (*)(const char *, ...)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x22 <col:5> 'int (const char *, ...)' Function 0x2 'printf' 'int (const char *, ...)'
+    | |-ImplicitCastExpr 0x23 <col:12> 'const char *' <ArrayToPointerDecay>
+    | | |-StringLiteral 0x24 <col:12> 'char [3]' lvalue "%s"
+    | |-ImplicitCastExpr 0x25 <col:18> 'const char *' <ArrayToPointerDecay>
+    |   |-PredefinedExpr 0x26 <col:18> 'const char [9]' lvalue __func__
+    |     |-StringLiteral 0x27 <col:18> 'const char [9]' lvalue "log_call"
+    |-CallExpr 0x30 <line:3:5, col:30> 'int'
+    | |-ImplicitCastExpr 0x31 <col:5> 'int (*)(const char *, ...)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x32 <col:5> 'int (const char *, ...)' Function 0x2 'printf' 'int (const char *, ...)'
+    | |-ImplicitCastExpr 0x33 <col:12> 'const char *' <ArrayToPointerDecay>
+    | | |-StringLiteral 0x34 <col:12> 'char [3]' lvalue "%s"
+    | |-ImplicitCastExpr 0x35 <col:18> 'const char *' <ArrayToPointerDecay>
+    |   |-PredefinedExpr 0x36 <col:18> 'const char [9]' lvalue __FUNCTION__
+    |     |-StringLiteral 0x37 <col:18> 'const char [9]' lvalue "log_call"
+    |-CallExpr 0x40 <line:4:5, col:37> 'int'
+      |-ImplicitCastExpr 0x41 <col:5> 'int (*)(const char *, ...)' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0x42 <col:5> 'int (const char *, ...)' Function 0x2 'printf' 'int (const char *, ...)'
+      |-ImplicitCastExpr 0x43 <col:12> 'const char *' <ArrayToPointerDecay>
+      | |-StringLiteral 0x44 <col:12> 'char [3]' lvalue "%s"
+      |-ImplicitCastExpr 0x45 <col:18> 'const char *' <ArrayToPointerDecay>
+        |-PredefinedExpr 0x46 <col:18> 'const char [24]' lvalue __PRETTY_FUNCTION__
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		`(&[]byte("log_call\x00")[0])`,
+		`(&[]byte("void log_call(int *)\x00")[0])`,
+	)
+	if n := strings.Count(output, `"log_call\x00"`); n != 2 {
+		t.Errorf("Expected 2 names of log_call, got %d in:\n%s", n, output)
+	}
+}
+
+func TestScanfStringWidth(t *testing.T) {
+	// void f(void) {
+	//   int a;
+	//   char buf[8];
+	//   char key[4];
+	//   sscanf("12 name=x", "%d %[^=]=%*s%2s", &a, buf, key);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:6:1> line:1:6 f 'void (void)'
+  |-CompoundStmt 0x12 <col:15, line:6:1>
+    |-DeclStmt 0x13 <line:2:3, col:8>
+    | |-VarDecl 0x14 <col:3, col:7> col:7 used a 'int'
+    |-DeclStmt 0x15 <line:3:3, col:14>
+    | |-VarDecl 0x16 <col:3, col:13> col:8 used buf 'char [8]'
+    |-DeclStmt 0x17 <line:4:3, col:14>
+    | |-VarDecl 0x18 <col:3, col:13> col:8 used key 'char [4]'
+    |-CallExpr 0x20 <line:5:3, col:53> 'int'
+      |-ImplicitCastExpr 0x21 <col:3> 'int (*)(const char *, const char *, ...)' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0x22 <col:3> 'int (const char *, const char *, ...)' Function 0x2 'sscanf' 'int (const char *, const char *, ...)'
+      |-ImplicitCastExpr 0x23 <col:10> 'const char *' <BitCast>
+      | |-ImplicitCastExpr 0x24 <col:10> 'char *' <ArrayToPointerDecay>
+      |   |-StringLiteral 0x25 <col:10> 'char [10]' lvalue "12 name=x"
+      |-ImplicitCastExpr 0x26 <col:23> 'const char *' <BitCast>
+      | |-ImplicitCastExpr 0x27 <col:23> 'char *' <ArrayToPointerDecay>
+      |   |-StringLiteral 0x28 <col:23> 'char [17]' lvalue "%d %[^=]=%*s%2s"
+      |-UnaryOperator 0x29 <col:42, col:43> 'int *' prefix '&' cannot overflow
+      | |-DeclRefExpr 0x2a <col:43> 'int' lvalue Var 0x14 'a' 'int'
+      |-ImplicitCastExpr 0x2b <col:46> 'char *' <ArrayToPointerDecay>
+      | |-DeclRefExpr 0x2c <col:46> 'char [8]' lvalue Var 0x16 'buf' 'char [8]'
+      |-ImplicitCastExpr 0x2d <col:51> 'char *' <ArrayToPointerDecay>
+        |-DeclRefExpr 0x2e <col:51> 'char [4]' lvalue Var 0x18 'key' 'char [4]'
+`
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "stdio.h"}}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		// Only the conversions without a width that store into a char array
+		// are limited.
+		`%d %7[^=]=%*s%2s`,
+		"noarch.Sscanf(",
+		"&a, &buf[0], &key[0])",
+	)
+}
+
+func TestFprintfStreams(t *testing.T) {
+	// void f(FILE *out, int n) {
+	//   fprintf(stderr, "n = %d\n", n);
+	//   fprintf(out, "%d\n", n);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x5 <x.c:1:1, col:21> col:21 used stderr 'FILE *' extern
+|-FunctionDecl 0x10 <line:2:1, line:5:1> line:2:6 f 'void (FILE *, int)'
+  |-ParmVarDecl 0x11 <col:8, col:14> col:14 used out 'FILE *'
+  |-ParmVarDecl 0x12 <col:19, col:23> col:23 used n 'int'
+  |-CompoundStmt 0x13 <col:26, line:5:1>
+    |-CallExpr 0x20 <line:3:3, col:32> 'int'
+    | |-ImplicitCastExpr 0x21 <col:3> 'int (*)(FILE *, const char *, ...)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x22 <col:3> 'int (FILE *, const char *, ...)' Function 0x2 'fprintf' 'int (FILE *, const char *, ...)'
+    | |-ImplicitCastExpr 0x23 <col:11> 'FILE *' <LValueToRValue>
+    | | |-DeclRefExpr 0x24 <col:11> 'FILE *' lvalue Var 0x5 'stderr' 'FILE *'
+    | |-ImplicitCastExpr 0x25 <col:19> 'const char *' <BitCast>
+    | | |-ImplicitCastExpr 0x26 <col:19> 'char *' <ArrayToPointerDecay>
+    | |   |-StringLiteral 0x27 <col:19> 'char [8]' lvalue "n = %d\n"
+    | |-ImplicitCastExpr 0x28 <col:31> 'int' <LValueToRValue>
+    |   |-DeclRefExpr 0x29 <col:31> 'int' lvalue ParmVar 0x12 'n' 'int'
+    |-CallExpr 0x30 <line:4:3, col:25> 'int'
+      |-ImplicitCastExpr 0x31 <col:3> 'int (*)(FILE *, const char *, ...)' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0x32 <col:3> 'int (FILE *, const char *, ...)' Function 0x2 'fprintf' 'int (FILE *, const char *, ...)'
+      |-ImplicitCastExpr 0x33 <col:11> 'FILE *' <LValueToRValue>
+      | |-DeclRefExpr 0x34 <col:11> 'FILE *' lvalue ParmVar 0x11 'out' 'FILE *'
+      |-ImplicitCastExpr 0x35 <col:16> 'const char *' <BitCast>
+      | |-ImplicitCastExpr 0x36 <col:16> 'char *' <ArrayToPointerDecay>
+      |   |-StringLiteral 0x37 <col:16> 'char [4]' lvalue "%d\n"
+      |-ImplicitCastExpr 0x38 <col:24> 'int' <LValueToRValue>
+        |-DeclRefExpr 0x39 <col:24> 'int' lvalue ParmVar 0x12 'n' 'int'
+`
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "stdio.h"}}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"noarch.Fprintf(noarch.Stderr, ",
+		// A stream that is opened by the program is its own noarch.File.
+		"noarch.Fprintf(out, ",
+	)
+}
+
+func TestWideStringLiterals(t *testing.T) {
+	p := program.NewProgram()
+	for _, tt := range []struct {
+		in  string
+		out string
+	}{
+		{`'wchar_t [4]' lvalue L"abc"`, `(&[]int32{'a', 'b', 'c', '\x00'}[0])`},
+		{`'wchar_t [5]' lvalue L"é\242\x4E16"`, `(&[]int32{'é', '¢', '世', '\x00', '\x00'}[0])`},
+		{`'unsigned short [4]' lvalue u"a\U0001F600"`, `(&[]uint16{'a', 55357, 56832, '\x00'}[0])`},
+		{`'unsigned int [2]' lvalue U"\U0001F600"`, `(&[]uint32{'😀', '\x00'}[0])`},
+	} {
+		n := ast.Parse("StringLiteral 0x1 <col:1> " + tt.in).(*ast.StringLiteral)
+		expr, _, err := transpileWideStringLiteral(n, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.out {
+			t.Errorf("%s: expected %s, got %s", tt.in, tt.out, buf.String())
+		}
+	}
+}
+
+func TestWcslen(t *testing.T) {
+	// #include <wchar.h>
+	//
+	// int f(void) {
+	//     return wcslen(L"héllo");
+	// }
+	dump := `
+FunctionDecl 0x20 <x.c:3:1, line:5:1> line:3:5 f 'int (void)'
+|-CompoundStmt 0x21 <col:13, line:5:1>
+  |-ReturnStmt 0x22 <line:4:5, col:32>
+    |-ImplicitCastExpr 0x23 <col:12, col:32> 'int' <IntegralCast>
+      |-CallExpr 0x24 <col:12, col:32> 'unsigned long'
+        |-ImplicitCastExpr 0x25 <col:12> 'unsigned long (*)(const wchar_t *)' <FunctionToPointerDecay>
+        | |-DeclRefExpr 0x26 <col:12> 'unsigned long (const wchar_t *)' Function 0x10 'wcslen' 'unsigned long (const wchar_t *)'
+        |-ImplicitCastExpr 0x27 <col:19> 'const wchar_t *' <NoOp>
+          |-ImplicitCastExpr 0x28 <col:19> 'wchar_t *' <ArrayToPointerDecay>
+            |-StringLiteral 0x29 <col:19> 'wchar_t [6]' lvalue L"héllo"
+`
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/wchar.h"}}
+	decls, err := transpileToNode(parseTree(dump), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	want := "return noarch.Wcslen((&[]int32{'h', 'é', 'l', 'l', 'o', '\\x00'}[0]))"
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected %q in:\n%s", want, output)
+	}
+}
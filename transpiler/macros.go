@@ -0,0 +1,152 @@
+// This file contains the transpiling of the integer macros of the C source
+// into constants. See Program.Macros.
+
+package transpiler
+
+import (
+	goast "go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/util"
+)
+
+// integerMacro is a macro with an integer value. The literal is the value as
+// it is written in C without the suffix, like "0x1f" for "0x1FUL". The value
+// is only set if it fits into an int64.
+type integerMacro struct {
+	name    string
+	literal string
+	value   int64
+	isInt64 bool
+}
+
+// transpileMacros transpiles the runs of integer macros that are defined one
+// after another into const blocks. A macro that is not an integer ends a run.
+// The values are the untyped constants of Go, because a macro has no type:
+//
+//     #define RED 0               const (
+//     #define GREEN 1                 RED = iota
+//     #define BLUE 2                  GREEN
+//                                     BLUE
+//                                 )
+//
+// See newConstDecls for the values that are not sequential.
+func transpileMacros(p *program.Program) (decls []goast.Decl) {
+	var run []integerMacro
+	file := ""
+	for _, macro := range p.Macros {
+		m, ok := parseIntegerMacro(macro)
+		if !ok || macro.File != file {
+			decls = append(decls, newConstDecls(p, run)...)
+			run = nil
+			file = macro.File
+		}
+		if ok {
+			run = append(run, m)
+		}
+	}
+
+	return append(decls, newConstDecls(p, run)...)
+}
+
+// parseIntegerMacro returns the integer value of a macro, like "10", "0x1FU",
+// "(-1)" or "-1L". ok is false if the value is not an integer literal.
+func parseIntegerMacro(macro program.Macro) (m integerMacro, ok bool) {
+	literal := strings.TrimSpace(macro.Value)
+	for strings.HasPrefix(literal, "(") && strings.HasSuffix(literal, ")") {
+		literal = strings.TrimSpace(literal[1 : len(literal)-1])
+	}
+
+	match := util.GetRegex(`^(-?)\s*((?:0[xX][0-9a-fA-F]+)|(?:[0-9]+))[uUlL]*$`).
+		FindStringSubmatch(literal)
+	if match == nil {
+		return integerMacro{}, false
+	}
+
+	m = integerMacro{name: macro.Name, literal: match[1] + match[2]}
+	value, err := strconv.ParseInt(m.literal, 0, 64)
+	if err == nil {
+		m.value, m.isInt64 = value, true
+	} else if _, err := strconv.ParseUint(match[2], 0, 64); err != nil || match[1] != "" {
+		// A value that does not fit into an int64, like 0xffffffffffffffff,
+		// is still an untyped constant. A larger one is not an integer of C.
+		return integerMacro{}, false
+	}
+
+	return m, true
+}
+
+// newConstDecls returns the const blocks of a run of integer macros. Each part
+// of the run with sequential values, that increase by 1, is a block with iota.
+// A value that is not the next one of the sequence starts a new block, so that
+// iota begins at 0 again. The macros between the sequences have their values:
+//
+//     #define A 1                 const (
+//     #define B 2                     A = iota + 1
+//     #define C 3                     B
+//     #define D 8                     C
+//     #define E 10                )
+//                                 const (
+//                                     D = 8
+//                                     E = 10
+//                                 )
+func newConstDecls(p *program.Program, run []integerMacro) (decls []goast.Decl) {
+	var values []goast.Spec
+	flush := func() {
+		if len(values) > 0 {
+			decls = append(decls, newConstDecl(values))
+			values = nil
+		}
+	}
+
+	for i := 0; i < len(run); {
+		end := i + 1
+		for end < len(run) && run[end].isInt64 && run[end-1].isInt64 &&
+			run[end].value == run[end-1].value+1 {
+			end++
+		}
+
+		if end-i == 1 {
+			values = append(values, &goast.ValueSpec{
+				Names:  []*goast.Ident{util.NewIdent(p.GoIdentifier(run[i].name))},
+				Values: []goast.Expr{&goast.BasicLit{Kind: token.INT, Value: run[i].literal}},
+			})
+			i = end
+			continue
+		}
+
+		flush()
+		var iota goast.Expr = util.NewIdent("iota")
+		if start := run[i].value; start > 0 {
+			iota = &goast.BinaryExpr{X: iota, Op: token.ADD, Y: util.NewIntLit(int(start))}
+		} else if start < 0 {
+			iota = &goast.BinaryExpr{X: iota, Op: token.SUB, Y: util.NewIntLit(int(-start))}
+		}
+		var specs []goast.Spec
+		for j := i; j < end; j++ {
+			spec := &goast.ValueSpec{
+				Names: []*goast.Ident{util.NewIdent(p.GoIdentifier(run[j].name))},
+			}
+			if j == i {
+				spec.Values = []goast.Expr{iota}
+			}
+			specs = append(specs, spec)
+		}
+		decls = append(decls, newConstDecl(specs))
+		i = end
+	}
+	flush()
+
+	return
+}
+
+func newConstDecl(specs []goast.Spec) *goast.GenDecl {
+	decl := &goast.GenDecl{Tok: token.CONST, Specs: specs}
+	if len(specs) > 1 {
+		decl.Lparen = 1
+	}
+	return decl
+}
@@ -0,0 +1,115 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestTranspileMacros(t *testing.T) {
+	tests := []struct {
+		name   string
+		macros []program.Macro
+		want   string
+	}{
+		{
+			name: "sequence",
+			macros: []program.Macro{
+				{Name: "A", Value: "0"},
+				{Name: "B", Value: "1"},
+				{Name: "C", Value: "2"},
+				{Name: "D", Value: "3"},
+				{Name: "E", Value: "4"},
+			},
+			want: `
+const (
+	A = iota
+	B
+	C
+	D
+	E
+)
+`,
+		},
+		{
+			name: "gap",
+			macros: []program.Macro{
+				{Name: "ONE", Value: "1"},
+				{Name: "TWO", Value: "2U"},
+				{Name: "THREE", Value: "(3)"},
+				{Name: "EIGHT", Value: "8"},
+				{Name: "TEN", Value: "0x0aUL"},
+				{Name: "ELEVEN", Value: "11"},
+				{Name: "TWELVE", Value: "12"},
+				{Name: "MINUS", Value: "(-2)"},
+			},
+			want: `
+const (
+	ONE = iota + 1
+	TWO
+	THREE
+)
+
+const EIGHT = 8
+
+const (
+	TEN = iota + 10
+	ELEVEN
+	TWELVE
+)
+
+const MINUS = -2
+`,
+		},
+		{
+			name: "not integers",
+			macros: []program.Macro{
+				{Name: "X", Value: "0"},
+				{Name: "NAME", Value: `"c2go"`},
+				{Name: "Y", Value: "1"},
+				{Name: "Z", Value: "2"},
+				{Name: "RATE", Value: "1.5"},
+				{Name: "F", File: "b.h", Value: "3"},
+				{Name: "type", File: "b.h", Value: "(7L)"},
+				{Name: "MASK", File: "b.h", Value: "0xFFFFFFFFFFFFFFFFULL"},
+			},
+			want: `
+const X = 0
+
+const (
+	Y = iota + 1
+	Z
+)
+
+const (
+	F     = 3
+	type_ = 7
+	MASK  = 0xFFFFFFFFFFFFFFFF
+)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := program.NewProgram()
+			p.Macros = tt.macros
+
+			var got []string
+			for _, decl := range transpileMacros(p) {
+				var buf bytes.Buffer
+				if err := format.Node(&buf, token.NewFileSet(), decl); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, buf.String())
+			}
+			if s := strings.Join(got, "\n\n") + "\n"; s != tt.want[1:] {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+// This file contains functions for transpiling the calls of memcpy(),
+// memmove() and memset() on arrays into Go, without the copy of the bytes by
+// noarch.
+
+package transpiler
+
+import (
+	goast "go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// memoryFunctions are the functions that are transpiled by
+// transpileMemoryCall(), with the substitution of their definition.
+var memoryFunctions = map[string]string{
+	"memcpy":  "github.com/elliotchance/c2go/noarch.Memcpy",
+	"memmove": "github.com/elliotchance/c2go/noarch.Memcpy",
+	"memset":  "github.com/elliotchance/c2go/noarch.Memset",
+}
+
+// transpileMemoryCall transpiles a call of memcpy(), memmove() or memset()
+// into Go when the memory is an array, that is a slice in Go, and the number
+// of bytes is a multiple of the size of its elements:
+//
+//     memcpy(b, a, n * sizeof(int))    =>    copy(b[:n], a)
+//     memset(a, 0, sizeof(a))          =>    for i := range a {
+//                                                a[i] = 0
+//                                            }
+//
+// The built-in copy() allows the memory to overlap, so it is used for
+// memmove() too. memset() is a loop that assigns the zero value of the
+// elements, or the bytes of the value for an array of integers.
+//
+// ok is false if the call is transpiled as usual, with noarch.Memcpy() or
+// noarch.Memset(). That is the case when the value of the call is used, when an
+// argument is a pointer instead of an array, when the arrays have different
+// elements, for example a struct that is copied into an array of bytes, or
+// when the elements contain arrays, because the slices of Go would be shared
+// instead of copied.
+func transpileMemoryCall(n *ast.CallExpr, p *program.Program, exprIsStmt bool) (
+	_ *goast.CallExpr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt,
+	ok bool, err error) {
+	if !exprIsStmt || len(n.Children()) != 4 {
+		return
+	}
+	name, err := getNameOfFunctionFromCallExpr(p, n)
+	if err != nil {
+		return nil, "", nil, nil, false, nil
+	}
+	f := p.GetFunctionDefinition(name)
+	if f == nil || memoryFunctions[name] == "" || f.Substitution != memoryFunctions[name] {
+		return
+	}
+
+	dst, ok := getMemoryArray(p, n.Children()[1])
+	if !ok {
+		return
+	}
+	elementSize, err := types.SizeOf(p, dst.elementType)
+	if err != nil || elementSize == 0 {
+		return nil, "", nil, nil, false, nil
+	}
+	count, ok := getElementCount(p, n.Children()[3], elementSize)
+	if !ok {
+		return
+	}
+
+	if name == "memset" {
+		return transpileMemset(n, p, dst, count, elementSize)
+	}
+
+	src, ok := getMemoryArray(p, n.Children()[2])
+	if !ok || types.CleanCType(src.elementType) != types.CleanCType(dst.elementType) {
+		return nil, "", nil, nil, false, nil
+	}
+	if _, isValue := zeroValueOf(p, dst.elementType); !isValue {
+		return nil, "", nil, nil, false, nil
+	}
+
+	dstExpr, preStmts, postStmts, err := transpileMemorySlice(p, dst, count)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	srcExpr, newPre, newPost, err := transpileMemorySlice(p, src, nil)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+
+	return util.NewCallExpr("copy", toSlice(dst, dstExpr), toSlice(src, srcExpr)), "int",
+		preStmts, postStmts, true, nil
+}
+
+// transpileMemset returns the loop that sets the elements of an array for
+// memset(). The loop is in the returned statements.
+func transpileMemset(n *ast.CallExpr, p *program.Program, dst memoryArray,
+	count *elementCount, elementSize int) (
+	_ *goast.CallExpr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt,
+	ok bool, err error) {
+	var value goast.Expr
+	c, isConstant := constantValue(p, n.Children()[2])
+	if isConstant && c == 0 {
+		value, ok = zeroValueOf(p, dst.elementType)
+	} else if isConstant {
+		value, ok = memsetInteger(p, dst.elementType, elementSize, c)
+	} else if goType, _ := types.ResolveType(p, dst.elementType); elementSize == 1 &&
+		(goType == "byte" || types.IsGoIntegerType(goType)) {
+		var valueType string
+		value, valueType, preStmts, postStmts, err = transpileToExpr(n.Children()[2], p, false)
+		if err != nil {
+			return nil, "", nil, nil, true, err
+		}
+		value, err = types.CastExpr(p, value, valueType, dst.elementType)
+		p.AddMessage(p.GenerateWarningMessage(err, n))
+		if hasSideEffects(value) {
+			var s goast.Stmt
+			value, s = newTempVar(p, value)
+			preStmts = append(preStmts, s)
+		}
+		ok = true
+	}
+	if !ok {
+		return nil, "", nil, nil, false, nil
+	}
+
+	slice, newPre, newPost, err := transpileMemorySlice(p, dst, count)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+	if _, isSlice := slice.(*goast.SliceExpr); isSlice || hasSideEffects(slice) {
+		var s goast.Stmt
+		slice, s = newTempVar(p, slice)
+		preStmts = append(preStmts, s)
+	}
+
+	index := "i"
+	if usesIdent(index, slice, value) {
+		index = p.GetNextIdentifier(index)
+	}
+	preStmts = append(preStmts, &goast.RangeStmt{
+		Key: util.NewIdent(index),
+		Tok: token.DEFINE,
+		X:   slice,
+		Body: &goast.BlockStmt{List: []goast.Stmt{&goast.AssignStmt{
+			Lhs: []goast.Expr{&goast.IndexExpr{X: slice, Index: util.NewIdent(index)}},
+			Tok: token.ASSIGN,
+			Rhs: []goast.Expr{value},
+		}}},
+	})
+
+	return nil, "", preStmts, postStmts, true, nil
+}
+
+// memsetInteger returns the value of the integers that memset() fills with
+// the byte c, like -1 for "memset(a, 255, sizeof(a))" of an array of int.
+func memsetInteger(p *program.Program, cType string, size int, c int64) (goast.Expr, bool) {
+	goType, err := types.ResolveType(p, cType)
+	if err != nil || !types.IsGoIntegerType(goType) && goType != "byte" || size > 8 {
+		return nil, false
+	}
+
+	var value uint64
+	for i := 0; i < size; i++ {
+		value = value<<8 | uint64(c&0xff)
+	}
+	if strings.HasPrefix(goType, "uint") || goType == "byte" {
+		return &goast.BasicLit{Kind: token.INT, Value: strconv.FormatUint(value, 10)}, true
+	}
+
+	// The bytes are the two's complement of a signed integer.
+	signed := int64(value)
+	if bits := uint(size * 8); bits < 64 && value >= 1<<(bits-1) {
+		signed -= 1 << bits
+	}
+	return &goast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(signed, 10)}, true
+}
+
+// memoryArray is an array that is an argument of memcpy(), memmove() or
+// memset(), like "a" for "&a[2]".
+type memoryArray struct {
+	// The expression of the array. It is a slice in Go, except for the field
+	// of a struct, that is an array.
+	array ast.Node
+
+	// The C type of the elements of the array and its length.
+	elementType string
+	length      int
+
+	// The index of the first element, or nil for the start of the array.
+	index ast.Node
+}
+
+// getMemoryArray returns the array of an argument of memcpy(), memmove() or
+// memset(). ok is false if the argument is not a variable or a field that is
+// an array with a fixed size, or the address of one of its elements.
+func getMemoryArray(p *program.Program, n ast.Node) (a memoryArray, ok bool) {
+	n = removeImplicitCasts(n, "BitCast", "NoOp")
+
+	if u, isUnary := n.(*ast.UnaryOperator); isUnary && u.Operator == "&" {
+		subscript, isSubscript := u.Children()[0].(*ast.ArraySubscriptExpr)
+		if !isSubscript {
+			return a, false
+		}
+		a, ok = getMemoryArray(p, subscript.Children()[0])
+		if !ok || a.index != nil {
+			return a, false
+		}
+		a.index = subscript.Children()[1]
+		return a, true
+	}
+
+	decay, isCast := n.(*ast.ImplicitCastExpr)
+	if !isCast || decay.Kind != "ArrayToPointerDecay" {
+		return a, false
+	}
+	switch v := decay.Children()[0].(type) {
+	case *ast.DeclRefExpr:
+	case *ast.MemberExpr:
+		if isUnionMemberExpr(p, v) {
+			return a, false
+		}
+	default:
+		return a, false
+	}
+	arrayType, err := sizeofOperandType(decay.Children()[0])
+	if err != nil {
+		return a, false
+	}
+	elementType, length := types.GetArrayTypeAndSize(types.CleanCType(arrayType))
+	if length <= 0 {
+		return a, false
+	}
+
+	return memoryArray{
+		array:       decay.Children()[0],
+		elementType: elementType,
+		length:      length,
+	}, true
+}
+
+// toSlice returns the slice of all the elements of a Go array, like "s.a[:]",
+// when expr is a field of a struct. Otherwise expr is already a slice.
+func toSlice(a memoryArray, expr goast.Expr) goast.Expr {
+	if _, isSlice := expr.(*goast.SliceExpr); isSlice {
+		return expr
+	}
+	if _, isField := a.array.(*ast.MemberExpr); isField {
+		return &goast.SliceExpr{X: expr}
+	}
+
+	return expr
+}
+
+// elementCount is the number of elements that memcpy(), memmove() or memset()
+// use, that is the number of the bytes divided by the size of an element. The
+// number is constant, or it is the expression n for a number of bytes like
+// "n * sizeof(int)".
+type elementCount struct {
+	constant int
+	n        ast.Node
+}
+
+// getElementCount returns the number of elements of elementSize bytes for a
+// number of bytes. ok is false if the number of bytes is not a constant
+// multiple of elementSize and not the multiplication of a variable and
+// elementSize.
+func getElementCount(p *program.Program, bytes ast.Node, elementSize int) (
+	count *elementCount, ok bool) {
+	if c, isConstant := constantValue(p, bytes); isConstant {
+		if c < 0 || c%int64(elementSize) != 0 {
+			return nil, false
+		}
+		return &elementCount{constant: int(c) / elementSize}, true
+	}
+
+	b, isBinary := removeImplicitCasts(bytes).(*ast.BinaryOperator)
+	if !isBinary || b.Operator != "*" {
+		return nil, false
+	}
+	for i, operand := range b.Children() {
+		if c, isConstant := constantValue(p, operand); isConstant &&
+			c == int64(elementSize) {
+			return &elementCount{n: removeImplicitCasts(b.Children()[1-i], "IntegralCast")}, true
+		}
+	}
+
+	return nil, false
+}
+
+// transpileMemorySlice returns the slice of a memory array with count
+// elements, like "a[2:5]" for "&a[2]" and 3 elements. The slice has the rest of
+// the array if count is nil.
+func transpileMemorySlice(p *program.Program, a memoryArray, count *elementCount) (
+	_ goast.Expr, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+	array, _, preStmts, postStmts, err := transpileToExpr(a.array, p, false)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	slice := &goast.SliceExpr{X: array}
+	low := 0
+	if a.index != nil {
+		if c, isConstant := constantValue(p, a.index); isConstant {
+			low = int(c)
+			if low != 0 {
+				slice.Low = util.NewIntLit(low)
+			}
+		} else {
+			var newPre, newPost []goast.Stmt
+			slice.Low, _, newPre, newPost, err = transpileToExpr(a.index, p, false)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+			if hasSideEffects(slice.Low) {
+				var s goast.Stmt
+				slice.Low, s = newTempVar(p, slice.Low)
+				preStmts = append(preStmts, s)
+			}
+			low = -1
+		}
+	}
+
+	switch {
+	case count == nil:
+	case count.n == nil && low != -1:
+		if high := low + count.constant; high != a.length {
+			slice.High = util.NewIntLit(high)
+		}
+	default:
+		var n goast.Expr = util.NewIntLit(count.constant)
+		if count.n != nil {
+			var newPre, newPost []goast.Stmt
+			n, _, newPre, newPost, err = transpileToExpr(count.n, p, false)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+		}
+		slice.High = n
+		if slice.Low != nil {
+			slice.High = &goast.BinaryExpr{X: slice.Low, Op: token.ADD, Y: n}
+		}
+	}
+
+	if slice.Low == nil && slice.High == nil {
+		return array, preStmts, postStmts, nil
+	}
+	return slice, preStmts, postStmts, nil
+}
+
+// zeroValueOf returns the zero value of a C type, like "0" for an integer or
+// "nil" for a pointer. ok is false if the Go value of the type would contain a
+// slice, that is for an array or a struct with an array, or if the type is a
+// union. The value of such a type cannot be cleared or copied with an
+// assignment.
+func zeroValueOf(p *program.Program, cType string) (_ goast.Expr, ok bool) {
+	cType = types.CleanCType(cType)
+	for {
+		t, isTypedef := p.TypedefType[cType]
+		if !isTypedef {
+			break
+		}
+		cType = types.CleanCType(t)
+	}
+
+	if strings.Contains(cType, "[") && !strings.Contains(cType, "(*)") {
+		return nil, false
+	}
+	if types.IsPurePointer(p, cType) || types.IsFunction(cType) {
+		return util.NewNil(), true
+	}
+
+	s := p.GetStruct(cType)
+	if s == nil {
+		s = p.GetStruct("struct " + cType)
+	}
+	if s != nil {
+		if s.IsUnion {
+			return nil, false
+		}
+		for _, name := range s.FieldNames {
+			fieldType, isString := s.Fields[name].(string)
+			if !isString {
+				return nil, false
+			}
+			if _, ok := zeroValueOf(p, fieldType); !ok {
+				return nil, false
+			}
+		}
+		goType, err := types.ResolveType(p, cType)
+		if err != nil {
+			return nil, false
+		}
+		return &goast.CompositeLit{Type: util.NewTypeIdent(goType)}, true
+	}
+
+	goType, err := types.ResolveType(p, cType)
+	if err != nil {
+		return nil, false
+	}
+	switch {
+	case goType == "bool":
+		return util.NewIdent("false"), true
+	case goType == "byte", types.IsGoIntegerType(goType),
+		strings.HasPrefix(goType, "float"), strings.HasPrefix(goType, "complex"):
+		return util.NewIntLit(0), true
+	}
+
+	return nil, false
+}
+
+// removeImplicitCasts returns the operand of the implicit casts of an
+// expression. Only the casts of the kinds are removed, or all of them without
+// kinds.
+func removeImplicitCasts(n ast.Node, kinds ...string) ast.Node {
+	for {
+		cast, ok := n.(*ast.ImplicitCastExpr)
+		if !ok || len(kinds) > 0 && !util.InStrings(cast.Kind, kinds) {
+			return n
+		}
+		n = cast.Children()[0]
+	}
+}
+
+// usesIdent returns true if one of the expressions refers to the name.
+func usesIdent(name string, exprs ...goast.Expr) (uses bool) {
+	for _, expr := range exprs {
+		goast.Inspect(expr, func(node goast.Node) bool {
+			if ident, ok := node.(*goast.Ident); ok && ident.Name == name {
+				uses = true
+			}
+			return !uses
+		})
+	}
+	return
+}
@@ -0,0 +1,236 @@
+package transpiler
+
+import (
+	goast "go/ast"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+)
+
+func TestMemoryFunctions(t *testing.T) {
+	// struct s { int x; };
+	// void f(int n) {
+	//   int a[4];
+	//   int b[4];
+	//   memset(a, 0, sizeof(a));
+	//   memcpy(b, a, sizeof(b));
+	//   memcpy(b, a, n * sizeof(int));
+	//   memmove(&b[1], b, 3 * sizeof(int));
+	//   memset(b, 255, sizeof(b));
+	//   struct s v;
+	//   char buf[8];
+	//   memcpy(buf, &v, sizeof(v));
+	//   memset(&buf[2], n, 3);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x5 <x.c:1:1, col:20> col:8 struct s definition
+| |-FieldDecl 0x6 <col:12, col:16> col:16 x 'int'
+|-FunctionDecl 0x10 <line:2:1, line:13:1> line:2:6 f 'void (int)'
+  |-ParmVarDecl 0x11 <col:8, col:12> col:12 used n 'int'
+  |-CompoundStmt 0x12 <col:15, line:9:1>
+    |-DeclStmt 0x13 <line:2:3, col:11>
+    | |-VarDecl 0x14 <col:3, col:10> col:7 used a 'int [4]'
+    |-DeclStmt 0x15 <line:3:3, col:11>
+    | |-VarDecl 0x16 <col:3, col:10> col:7 used b 'int [4]'
+    |-CallExpr 0x20 <line:4:3, col:25> 'void *'
+    | |-ImplicitCastExpr 0x21 <col:3> 'void *(*)(void *, int, unsigned long)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x22 <col:3> 'void *(void *, int, unsigned long)' Function 0x2 'memset' 'void *(void *, int, unsigned long)'
+    | |-ImplicitCastExpr 0x23 <col:10> 'void *' <BitCast>
+    | | |-ImplicitCastExpr 0x24 <col:10> 'int *' <ArrayToPointerDecay>
+    | |   |-DeclRefExpr 0x25 <col:10> 'int [4]' lvalue Var 0x14 'a' 'int [4]'
+    | |-IntegerLiteral 0x26 <col:13> 'int' 0
+    | |-UnaryExprOrTypeTraitExpr 0x27 <col:16, col:24> 'unsigned long' sizeof
+    |   |-ParenExpr 0x28 <col:22, col:24> 'int [4]' lvalue
+    |     |-DeclRefExpr 0x29 <col:23> 'int [4]' lvalue Var 0x14 'a' 'int [4]'
+    |-CallExpr 0x30 <line:5:3, col:25> 'void *'
+    | |-ImplicitCastExpr 0x31 <col:3> 'void *(*)(void *, const void *, unsigned long)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x32 <col:3> 'void *(void *, const void *, unsigned long)' Function 0x3 'memcpy' 'void *(void *, const void *, unsigned long)'
+    | |-ImplicitCastExpr 0x33 <col:10> 'void *' <BitCast>
+    | | |-ImplicitCastExpr 0x34 <col:10> 'int *' <ArrayToPointerDecay>
+    | |   |-DeclRefExpr 0x35 <col:10> 'int [4]' lvalue Var 0x16 'b' 'int [4]'
+    | |-ImplicitCastExpr 0x36 <col:13> 'const void *' <BitCast>
+    | | |-ImplicitCastExpr 0x37 <col:13> 'int *' <ArrayToPointerDecay>
+    | |   |-DeclRefExpr 0x38 <col:13> 'int [4]' lvalue Var 0x14 'a' 'int [4]'
+    | |-UnaryExprOrTypeTraitExpr 0x39 <col:16, col:24> 'unsigned long' sizeof
+    |   |-ParenExpr 0x3a <col:22, col:24> 'int [4]' lvalue
+    |     |-DeclRefExpr 0x3b <col:23> 'int [4]' lvalue Var 0x16 'b' 'int [4]'
+    |-CallExpr 0x40 <line:6:3, col:32> 'void *'
+    | |-ImplicitCastExpr 0x41 <col:3> 'void *(*)(void *, const void *, unsigned long)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x42 <col:3> 'void *(void *, const void *, unsigned long)' Function 0x3 'memcpy' 'void *(void *, const void *, unsigned long)'
+    | |-ImplicitCastExpr 0x43 <col:10> 'void *' <BitCast>
+    | | |-ImplicitCastExpr 0x44 <col:10> 'int *' <ArrayToPointerDecay>
+    | |   |-DeclRefExpr 0x45 <col:10> 'int [4]' lvalue Var 0x16 'b' 'int [4]'
+    | |-ImplicitCastExpr 0x46 <col:13> 'const void *' <BitCast>
+    | | |-ImplicitCastExpr 0x47 <col:13> 'int *' <ArrayToPointerDecay>
+    | |   |-DeclRefExpr 0x48 <col:13> 'int [4]' lvalue Var 0x14 'a' 'int [4]'
+    | |-BinaryOperator 0x49 <col:16, col:31> 'unsigned long' '*'
+    |   |-ImplicitCastExpr 0x4a <col:16> 'unsigned long' <IntegralCast>
+    |   | |-ImplicitCastExpr 0x4b <col:16> 'int' <LValueToRValue>
+    |   |   |-DeclRefExpr 0x4c <col:16> 'int' lvalue ParmVar 0x11 'n' 'int'
+    |   |-UnaryExprOrTypeTraitExpr 0x4d <col:20, col:31> 'unsigned long' sizeof 'int'
+    |-CallExpr 0x50 <line:7:3, col:36> 'void *'
+    | |-ImplicitCastExpr 0x51 <col:3> 'void *(*)(void *, const void *, unsigned long)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x52 <col:3> 'void *(void *, const void *, unsigned long)' Function 0x4 'memmove' 'void *(void *, const void *, unsigned long)'
+    | |-ImplicitCastExpr 0x53 <col:11> 'void *' <BitCast>
+    | | |-UnaryOperator 0x54 <col:11, col:15> 'int *' prefix '&' cannot overflow
+    | |   |-ArraySubscriptExpr 0x55 <col:12, col:15> 'int' lvalue
+    | |     |-ImplicitCastExpr 0x56 <col:12> 'int *' <ArrayToPointerDecay>
+    | |     | |-DeclRefExpr 0x57 <col:12> 'int [4]' lvalue Var 0x16 'b' 'int [4]'
+    | |     |-IntegerLiteral 0x58 <col:14> 'int' 1
+    | |-ImplicitCastExpr 0x59 <col:18> 'const void *' <BitCast>
+    | | |-ImplicitCastExpr 0x5a <col:18> 'int *' <ArrayToPointerDecay>
+    | |   |-DeclRefExpr 0x5b <col:18> 'int [4]' lvalue Var 0x16 'b' 'int [4]'
+    | |-BinaryOperator 0x5c <col:21, col:35> 'unsigned long' '*'
+    |   |-ImplicitCastExpr 0x5d <col:21> 'unsigned long' <IntegralCast>
+    |   | |-IntegerLiteral 0x5e <col:21> 'int' 3
+    |   |-UnaryExprOrTypeTraitExpr 0x5f <col:23, col:35> 'unsigned long' sizeof 'int'
+    |-CallExpr 0x60 <line:8:3, col:36> 'void *'
+    | |-ImplicitCastExpr 0x61 <col:3> 'void *(*)(void *, int, unsigned long)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x62 <col:3> 'void *(void *, int, unsigned long)' Function 0x2 'memset' 'void *(void *, int, unsigned long)'
+    | |-ImplicitCastExpr 0x63 <col:10> 'void *' <BitCast>
+    | | |-ImplicitCastExpr 0x64 <col:10> 'int *' <ArrayToPointerDecay>
+    | |   |-DeclRefExpr 0x65 <col:10> 'int [4]' lvalue Var 0x16 'b' 'int [4]'
+    | |-IntegerLiteral 0x66 <col:13> 'int' 255
+    | |-UnaryExprOrTypeTraitExpr 0x67 <col:16, col:24> 'unsigned long' sizeof
+    |   |-ParenExpr 0x68 <col:22, col:24> 'int [4]' lvalue
+    |     |-DeclRefExpr 0x69 <col:23> 'int [4]' lvalue Var 0x16 'b' 'int [4]'
+    |-DeclStmt 0x70 <line:9:3, col:13>
+    | |-VarDecl 0x71 <col:3, col:12> col:12 used v 'struct s':'struct s'
+    |-DeclStmt 0x72 <line:10:3, col:14>
+    | |-VarDecl 0x73 <col:3, col:13> col:8 used buf 'char [8]'
+    |-CallExpr 0x80 <line:11:3, col:31> 'void *'
+    | |-ImplicitCastExpr 0x81 <col:3> 'void *(*)(void *, const void *, unsigned long)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x82 <col:3> 'void *(void *, const void *, unsigned long)' Function 0x3 'memcpy' 'void *(void *, const void *, unsigned long)'
+    | |-ImplicitCastExpr 0x83 <col:10> 'void *' <BitCast>
+    | | |-ImplicitCastExpr 0x84 <col:10> 'char *' <ArrayToPointerDecay>
+    | |   |-DeclRefExpr 0x85 <col:10> 'char [8]' lvalue Var 0x73 'buf' 'char [8]'
+    | |-ImplicitCastExpr 0x86 <col:15> 'const void *' <BitCast>
+    | | |-UnaryOperator 0x87 <col:15, col:16> 'struct s *' prefix '&' cannot overflow
+    | |   |-DeclRefExpr 0x88 <col:16> 'struct s':'struct s' lvalue Var 0x71 'v' 'struct s':'struct s'
+    | |-UnaryExprOrTypeTraitExpr 0x89 <col:19, col:30> 'unsigned long' sizeof
+    |   |-ParenExpr 0x8a <col:25, col:30> 'struct s':'struct s' lvalue
+    |     |-DeclRefExpr 0x8b <col:26> 'struct s':'struct s' lvalue Var 0x71 'v' 'struct s':'struct s'
+    |-CallExpr 0x90 <line:12:3, col:20> 'void *'
+      |-ImplicitCastExpr 0x91 <col:3> 'void *(*)(void *, int, unsigned long)' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0x92 <col:3> 'void *(void *, int, unsigned long)' Function 0x2 'memset' 'void *(void *, int, unsigned long)'
+      |-ImplicitCastExpr 0x93 <col:10> 'void *' <BitCast>
+      | |-UnaryOperator 0x94 <col:10, col:14> 'char *' prefix '&' cannot overflow
+      |   |-ArraySubscriptExpr 0x95 <col:11, col:14> 'char' lvalue
+      |     |-ImplicitCastExpr 0x96 <col:11> 'char *' <ArrayToPointerDecay>
+      |     | |-DeclRefExpr 0x97 <col:11> 'char [8]' lvalue Var 0x73 'buf' 'char [8]'
+      |     |-IntegerLiteral 0x98 <col:15> 'int' 2
+      |-ImplicitCastExpr 0x99 <col:18> 'int' <LValueToRValue>
+      | |-DeclRefExpr 0x9a <col:18> 'int' lvalue ParmVar 0x11 'n' 'int'
+      |-IntegerLiteral 0x9b <col:21> 'unsigned long' 3
+`
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "string.h"}}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"for i := range a {\n\t\ta[i] = 0\n\t}",
+		"copy(b, a)",
+		"copy(b[:n], a)",
+		"copy(b[1:], b)",
+		"for i := range b {\n\t\tb[i] = -1\n\t}",
+		// The struct is reinterpreted as bytes.
+		"noarch.Memcpy(unsafe.Pointer(&buf[0]), unsafe.Pointer(&v), int32(4))",
+		"c2goTempVar0 := buf[2:5]",
+		"for i := range c2goTempVar0 {\n\t\tc2goTempVar0[i] = byte(n)\n\t}",
+	)
+}
+
+func TestMemoryFunctionsOfFields(t *testing.T) {
+	// struct m2 { int a[2]; };
+	// void g(void) {
+	//   struct m2 x, y;
+	//   memcpy(x.a, y.a, sizeof(int) * 2);
+	//   memset(&x.a[1], 0, 4);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x5 <x.c:1:1, col:22> col:8 struct m2 definition
+| |-FieldDecl 0x6 <col:13, col:20> col:17 referenced a 'int [2]'
+|-FunctionDecl 0x10 <line:2:1, line:6:1> line:2:6 g 'void (void)'
+  |-CompoundStmt 0x12 <col:15, line:6:1>
+    |-DeclStmt 0x13 <line:3:3, col:18>
+    | |-VarDecl 0x14 <col:3, col:13> col:13 used x 'struct m2':'struct m2'
+    | |-VarDecl 0x15 <col:3, col:16> col:16 used y 'struct m2':'struct m2'
+    |-CallExpr 0x30 <line:4:3, col:35> 'void *'
+    | |-ImplicitCastExpr 0x31 <col:3> 'void *(*)(void *, const void *, unsigned long)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x32 <col:3> 'void *(void *, const void *, unsigned long)' Function 0x3 'memcpy' 'void *(void *, const void *, unsigned long)'
+    | |-ImplicitCastExpr 0x33 <col:10> 'void *' <BitCast>
+    | | |-ImplicitCastExpr 0x34 <col:10> 'int *' <ArrayToPointerDecay>
+    | |   |-MemberExpr 0x35 <col:10, col:12> 'int [2]' lvalue .a 0x6
+    | |     |-DeclRefExpr 0x36 <col:10> 'struct m2':'struct m2' lvalue Var 0x14 'x' 'struct m2':'struct m2'
+    | |-ImplicitCastExpr 0x37 <col:15> 'const void *' <BitCast>
+    | | |-ImplicitCastExpr 0x38 <col:15> 'int *' <ArrayToPointerDecay>
+    | |   |-MemberExpr 0x39 <col:15, col:17> 'int [2]' lvalue .a 0x6
+    | |     |-DeclRefExpr 0x3a <col:15> 'struct m2':'struct m2' lvalue Var 0x15 'y' 'struct m2':'struct m2'
+    | |-BinaryOperator 0x3b <col:20, col:34> 'unsigned long' '*'
+    |   |-UnaryExprOrTypeTraitExpr 0x3c <col:20, col:30> 'unsigned long' sizeof 'int'
+    |   |-ImplicitCastExpr 0x3d <col:34> 'unsigned long' <IntegralCast>
+    |     |-IntegerLiteral 0x3e <col:34> 'int' 2
+    |-CallExpr 0x40 <line:5:3, col:30> 'void *'
+      |-ImplicitCastExpr 0x41 <col:3> 'void *(*)(void *, int, unsigned long)' <FunctionToPointerDecay>
+      | |-DeclRefExpr 0x42 <col:3> 'void *(void *, int, unsigned long)' Function 0x2 'memset' 'void *(void *, int, unsigned long)'
+      |-ImplicitCastExpr 0x43 <col:10> 'void *' <BitCast>
+      | |-UnaryOperator 0x44 <col:10, col:15> 'int *' prefix '&' cannot overflow
+      |   |-ArraySubscriptExpr 0x45 <col:11, col:15> 'int' lvalue
+      |     |-ImplicitCastExpr 0x46 <col:11> 'int *' <ArrayToPointerDecay>
+      |     | |-MemberExpr 0x47 <col:11, col:13> 'int [2]' lvalue .a 0x6
+      |     |   |-DeclRefExpr 0x48 <col:11> 'struct m2':'struct m2' lvalue Var 0x14 'x' 'struct m2':'struct m2'
+      |     |-IntegerLiteral 0x49 <col:15> 'int' 1
+      |-IntegerLiteral 0x4a <col:18> 'int' 0
+      |-IntegerLiteral 0x4b <col:21> 'unsigned long' 4
+`
+
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "string.h"}}
+	output := transpileDump(t, p, dump)
+
+	// The arrays of the fields are not slices.
+	expectContains(t, output,
+		"copy(x.a[:], y.a[:])",
+		"c2goTempVar0 := x.a[1:]",
+	)
+}
+
+func TestMemsetInteger(t *testing.T) {
+	p := program.NewProgram()
+
+	for _, tt := range []struct {
+		cType string
+		c     int64
+		want  string
+	}{
+		{"int", 255, "-1"},
+		{"int", -1, "-1"},
+		{"unsigned int", 255, "4294967295"},
+		{"short", 1, "257"},
+		{"unsigned char", 'a', "97"},
+		{"char", -2, "254"},
+		{"long long", 0x80, "-9187201950435737472"},
+		{"unsigned long long", 0x7f, "9187201950435737471"},
+	} {
+		size, err := types.SizeOf(p, tt.cType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, ok := memsetInteger(p, tt.cType, size, tt.c)
+		if !ok {
+			t.Errorf("%s: expected a value", tt.cType)
+			continue
+		}
+		if got := value.(*goast.BasicLit).Value; got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.cType, tt.want, got)
+		}
+	}
+
+	if _, ok := memsetInteger(p, "double", 8, 1); ok {
+		t.Errorf("Expected no value for double")
+	}
+}
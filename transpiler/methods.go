@@ -0,0 +1,120 @@
+// This file contains functions for transpiling the C functions that take a
+// pointer to a struct as the first argument into Go methods of the struct. See
+// Program.MethodFunctions.
+
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	gotypes "go/types"
+	"strings"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// getMethod returns the name of the Go method and the Go type of the receiver
+// for a function of Program.MethodFunctions, like "Push" and "*List" for:
+//
+//     void list_push(List *l, int v)
+//
+// The name is empty if the function is not a method. The first parameter must
+// be a pointer to a type that is defined by the program. A pointer to a type
+// of Go, like *int32, or of a package, like *noarch.File, cannot be the
+// receiver.
+func getMethod(p *program.Program, f *program.FunctionDefinition) (name, receiver string, err error) {
+	if !p.IsMethodFunction(f.Name) || f.Substitution != "" {
+		return "", "", nil
+	}
+
+	if len(f.ArgumentTypes) == 0 {
+		return "", "", fmt.Errorf("function %s cannot be a method: it has no parameters", f.Name)
+	}
+	receiver, err = types.ResolveType(p, f.ArgumentTypes[0])
+	if err != nil || !util.GetRegex(`^\*\w+$`).MatchString(receiver) ||
+		gotypes.Universe.Lookup(receiver[1:]) != nil {
+		return "", "", fmt.Errorf("function %s cannot be a method: the first parameter is not a pointer to a struct", f.Name)
+	}
+
+	return getMethodName(f.Name, receiver[1:]), receiver, nil
+}
+
+// getMethodName returns the exported name of a method. The type of the
+// receiver is removed from the start of the function name, and the words that
+// are separated by underscores are joined, like "PushBack" for
+// "list_push_back" of the type "List".
+func getMethodName(function, receiver string) string {
+	name := function
+	if prefix := receiver + "_"; len(name) > len(prefix) &&
+		strings.EqualFold(name[:len(prefix)], prefix) {
+		name = name[len(prefix):]
+	}
+
+	var words []string
+	for _, word := range strings.Split(name, "_") {
+		words = append(words, util.Ucfirst(word))
+	}
+
+	return strings.Join(words, "")
+}
+
+// newMethodFuncDecl moves the first parameter of a function to the receiver.
+func newMethodFuncDecl(decl *goast.FuncDecl, name string) {
+	params := decl.Type.Params.List
+	decl.Recv = &goast.FieldList{List: params[:1]}
+	decl.Type.Params = &goast.FieldList{List: params[1:]}
+	decl.Name = util.NewIdent(name)
+}
+
+// newMethodCall changes the call of a function into the call of the method on
+// the first argument:
+//
+//     list_push(&l, 1)   =>   (&l).Push(1)
+//
+// NULL has no type in Go, so it is converted to the type of the receiver.
+func newMethodCall(call *goast.CallExpr, name, receiver string) *goast.CallExpr {
+	x := call.Args[0]
+	switch v := x.(type) {
+	case *goast.Ident:
+		if v.Name == "nil" {
+			x = &goast.CallExpr{
+				Fun:  &goast.ParenExpr{X: util.NewTypeIdent(receiver)},
+				Args: []goast.Expr{x},
+			}
+		}
+	case *goast.SelectorExpr, *goast.IndexExpr, *goast.CallExpr, *goast.ParenExpr:
+	default:
+		x = &goast.ParenExpr{X: x}
+	}
+
+	return &goast.CallExpr{
+		Fun:  &goast.SelectorExpr{X: x, Sel: util.NewIdent(name)},
+		Args: call.Args[1:],
+	}
+}
+
+// newMethodExpr returns the method expression for a function that is used as a
+// value instead of being called, like "(*List).Push" for a function pointer to
+// list_push(). The method expression has the same type as the function. It
+// returns nil if the function is not a method.
+func newMethodExpr(p *program.Program, n *ast.DeclRefExpr) goast.Expr {
+	if n.For != "Function" {
+		return nil
+	}
+	f := p.GetFunctionDefinition(n.Name)
+	if f == nil {
+		return nil
+	}
+	name, receiver, _ := getMethod(p, f)
+	if name == "" {
+		return nil
+	}
+
+	return &goast.SelectorExpr{
+		X:   &goast.ParenExpr{X: util.NewTypeIdent(receiver)},
+		Sel: util.NewIdent(name),
+	}
+}
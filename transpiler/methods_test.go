@@ -0,0 +1,119 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestMethodFunction(t *testing.T) {
+	// typedef struct list { int size; } List;
+	// void list_push(List *l, int v) { l->size += v; }
+	// int list_count_in(int v, List *l) { return l->size; }
+	// int main(void) {
+	//   List l;
+	//   l.size = 0;
+	//   list_push(&l, 3);
+	//   void (*f)(List *, int); f = list_push;
+	//   f(&l, 4);
+	//   return list_count_in(3, &l);
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x10 <x.c:1:9, col:33> col:16 struct list definition
+| |-FieldDecl 0x11 <col:23, col:27> col:27 referenced size 'int'
+|-TypedefDecl 0x12 <col:1, col:35> col:35 referenced List 'struct list':'struct list'
+| |-ElaboratedType 0x13 'struct list' sugar
+|   |-RecordType 0x14 'struct list'
+|     |-Record 0x10 'list'
+|-FunctionDecl 0x20 <line:2:1, col:50> col:6 used list_push 'void (List *, int)'
+| |-ParmVarDecl 0x21 <col:16, col:22> col:22 used l 'List *'
+| |-ParmVarDecl 0x22 <col:25, col:29> col:29 used v 'int'
+| |-CompoundStmt 0x23 <col:32, col:50>
+|   |-CompoundAssignOperator 0x24 <col:34, col:45> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+|     |-MemberExpr 0x25 <col:34, col:37> 'int' lvalue ->size 0x11
+|     | |-ImplicitCastExpr 0x26 <col:34> 'List *' <LValueToRValue>
+|     |   |-DeclRefExpr 0x27 <col:34> 'List *' lvalue ParmVar 0x21 'l' 'List *'
+|     |-ImplicitCastExpr 0x28 <col:45> 'int' <LValueToRValue>
+|       |-DeclRefExpr 0x29 <col:45> 'int' lvalue ParmVar 0x22 'v' 'int'
+|-FunctionDecl 0x30 <line:3:1, col:50> col:5 used list_count_in 'int (int, List *)'
+| |-ParmVarDecl 0x31 <col:15, col:19> col:19 used v 'int'
+| |-ParmVarDecl 0x32 <col:22, col:28> col:28 used l 'List *'
+| |-CompoundStmt 0x33 <col:31, col:50>
+|   |-ReturnStmt 0x34 <col:33, col:43>
+|     |-ImplicitCastExpr 0x35 <col:40, col:43> 'int' <LValueToRValue>
+|       |-MemberExpr 0x36 <col:40, col:43> 'int' lvalue ->size 0x11
+|         |-ImplicitCastExpr 0x37 <col:40> 'List *' <LValueToRValue>
+|           |-DeclRefExpr 0x38 <col:40> 'List *' lvalue ParmVar 0x32 'l' 'List *'
+|-FunctionDecl 0x40 <line:4:1, line:10:1> line:4:5 main 'int (void)'
+  |-CompoundStmt 0x41 <col:16, line:10:1>
+    |-DeclStmt 0x42 <line:5:3, col:9>
+    | |-VarDecl 0x43 <col:3, col:8> col:8 used l 'List':'struct list'
+    |-BinaryOperator 0x44 <line:6:3, col:12> 'int' '='
+    | |-MemberExpr 0x45 <col:3, col:5> 'int' lvalue .size 0x11
+    | | |-DeclRefExpr 0x46 <col:3> 'List':'struct list' lvalue Var 0x43 'l' 'List':'struct list'
+    | |-IntegerLiteral 0x47 <col:12> 'int' 0
+    |-CallExpr 0x48 <line:7:3, col:19> 'void'
+    | |-ImplicitCastExpr 0x49 <col:3> 'void (*)(List *, int)' <FunctionToPointerDecay>
+    | | |-DeclRefExpr 0x4a <col:3> 'void (List *, int)' Function 0x20 'list_push' 'void (List *, int)'
+    | |-UnaryOperator 0x4b <col:13, col:14> 'List *' prefix '&' cannot overflow
+    | | |-DeclRefExpr 0x4c <col:14> 'List':'struct list' lvalue Var 0x43 'l' 'List':'struct list'
+    | |-IntegerLiteral 0x4d <col:18> 'int' 3
+    |-DeclStmt 0x50 <line:8:3, col:25>
+    | |-VarDecl 0x51 <col:3, col:24> col:10 used f 'void (*)(List *, int)'
+    |-BinaryOperator 0x5a <col:27, col:31> 'void (*)(List *, int)' '='
+    | |-DeclRefExpr 0x5b <col:27> 'void (*)(List *, int)' lvalue Var 0x51 'f' 'void (*)(List *, int)'
+    | |-ImplicitCastExpr 0x52 <col:31> 'void (*)(List *, int)' <FunctionToPointerDecay>
+    |   |-DeclRefExpr 0x53 <col:31> 'void (List *, int)' Function 0x20 'list_push' 'void (List *, int)'
+    |-CallExpr 0x54 <line:9:3, col:10> 'void'
+    | |-ImplicitCastExpr 0x55 <col:3> 'void (*)(List *, int)' <LValueToRValue>
+    | | |-DeclRefExpr 0x56 <col:3> 'void (*)(List *, int)' lvalue Var 0x51 'f' 'void (*)(List *, int)'
+    | |-UnaryOperator 0x57 <col:5, col:6> 'List *' prefix '&' cannot overflow
+    | | |-DeclRefExpr 0x58 <col:6> 'List':'struct list' lvalue Var 0x43 'l' 'List':'struct list'
+    | |-IntegerLiteral 0x59 <col:9> 'int' 4
+    |-ReturnStmt 0x60 <col:3, col:30>
+      |-CallExpr 0x61 <col:10, col:30> 'int'
+        |-ImplicitCastExpr 0x62 <col:10> 'int (*)(int, List *)' <FunctionToPointerDecay>
+        | |-DeclRefExpr 0x63 <col:10> 'int (int, List *)' Function 0x30 'list_count_in' 'int (int, List *)'
+        |-IntegerLiteral 0x64 <col:24> 'int' 3
+        |-UnaryOperator 0x65 <col:27, col:28> 'List *' prefix '&' cannot overflow
+          |-DeclRefExpr 0x66 <col:28> 'List':'struct list' lvalue Var 0x43 'l' 'List':'struct list'
+`
+
+	p := program.NewProgram()
+	p.MethodFunctions = []string{"list_push", "list_count_in"}
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"func (l *List) Push(v int32) {",
+		"(&l).Push(int32(3))",
+		"f = (*List).Push",
+		// The struct is not the first parameter.
+		"function list_count_in cannot be a method: the first parameter is not a pointer to a struct",
+		"func list_count_in(v int32, l *List) int32 {",
+		"list_count_in(int32(3), &l)",
+	)
+	if strings.Contains(output, "list_push") {
+		t.Errorf("Unexpected function list_push in:\n%s", output)
+	}
+}
+
+func TestGetMethodName(t *testing.T) {
+	for _, tt := range []struct {
+		function string
+		receiver string
+		want     string
+	}{
+		{"list_push", "List", "Push"},
+		{"List_push_back", "List", "PushBack"},
+		{"push", "List", "Push"},
+		{"listpush", "List", "Listpush"},
+		{"list_", "List", "List"},
+		{"vec_len", "List", "VecLen"},
+	} {
+		if got := getMethodName(tt.function, tt.receiver); got != tt.want {
+			t.Errorf("%s of %s: expected %q, got %q", tt.function, tt.receiver, tt.want, got)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"go/parser"
 	"go/printer"
 	"go/token"
+	gotypes "go/types"
 	"html/template"
 	"strings"
 
@@ -32,7 +33,9 @@ import (
 // use a closure to work the same way.
 //
 // It is also important to note that C only evaulates the "b" or "c" condition
-// based on the result of "a" (from the above example).
+// based on the result of "a" (from the above example). This includes side
+// effects, so the statements that are needed before or after "b" or "c" are
+// put into the "if" or the "else" and not returned with the expression.
 func transpileConditionalOperator(n *ast.ConditionalOperator, p *program.Program) (
 	_ *goast.CallExpr, theType string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
 	defer func() {
@@ -42,104 +45,184 @@ func transpileConditionalOperator(n *ast.ConditionalOperator, p *program.Program
 	}()
 
 	// a - condition
-	a, aType, newPre, newPost, err := transpileToExpr(n.Children()[0], p, false)
+	a, newPre, newPost, err := transpileToBoolExpr(n.Children()[0], p)
 	if err != nil {
 		return
 	}
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
-	// null in C is zero
-	if aType == types.NullPointer {
-		a = &goast.BasicLit{
-			Kind:  token.INT,
-			Value: "0",
+	// rightType - generate return type
+	var returnType string
+	if n.Type != "void" {
+		returnType, err = types.ResolveType(p, n.Type)
+		if err != nil {
+			return
 		}
-		aType = "int"
 	}
 
-	a, err = types.CastExpr(p, a, aType, "bool")
+	// b - body
+	bod, err := transpileConditionalBranch(n.Children()[1], n.Type, returnType, p)
 	if err != nil {
 		return
 	}
 
-	// b - body
-	b, bType, newPre, newPost, err := transpileToExpr(n.Children()[1], p, false)
+	// c - else body
+	els, err := transpileConditionalBranch(n.Children()[2], n.Type, returnType, p)
 	if err != nil {
 		return
 	}
-	// Theoretically, length is must be zero
-	if len(newPre) > 0 || len(newPost) > 0 {
-		p.AddMessage(p.GenerateWarningMessage(
-			fmt.Errorf("length of pre or post in body must be zero. {%d,%d}", len(newPre), len(newPost)), n))
+
+	return util.NewFuncClosure(
+		returnType,
+		&goast.IfStmt{
+			Cond: a,
+			Body: bod,
+			Else: els,
+		},
+	), n.Type, preStmts, postStmts, nil
+}
+
+// transpileConditionalBranch transpiles the "b" or the "c" of a conditional
+// operator into the body of the "if" or the "else". The side effects of the
+// branch are kept in the body, so they only happen when the branch is taken:
+//
+//     a ? (x++, x) : 0
+//
+//     if a {
+//         x += 1
+//         return (x)
+//     }
+//
+// A branch with post statements keeps its value in a variable until they have
+// run. cType is the C type of the conditional operator.
+func transpileConditionalBranch(branch ast.Node, cType, returnType string,
+	p *program.Program) (*goast.BlockStmt, error) {
+	expr, exprType, preStmts, postStmts, err := transpileToExpr(branch, p, false)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &goast.BlockStmt{Lbrace: 1, List: preStmts}
+	if exprType == types.ToVoid {
+		body.List = append(body.List, postStmts...)
+		return body, nil
 	}
-	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
-	if n.Type != "void" {
-		b, err = types.CastExpr(p, b, bType, n.Type)
+	if cType == "void" {
+		body.List = append(body.List, &goast.ExprStmt{X: expr})
+		body.List = append(body.List, postStmts...)
+		return body, nil
+	}
+
+	expr, err = types.CastExpr(p, expr, exprType, cType)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(postStmts) > 0 {
+		body.List = append(body.List, &goast.DeclStmt{Decl: &goast.GenDecl{
+			Tok: token.VAR,
+			Specs: []goast.Spec{&goast.ValueSpec{
+				Names:  []*goast.Ident{util.NewIdent("tempVar")},
+				Type:   util.NewTypeIdent(returnType),
+				Values: []goast.Expr{expr},
+			}},
+		}})
+		body.List = append(body.List, postStmts...)
+		expr = util.NewIdent("tempVar")
+	}
+	body.List = append(body.List, &goast.ReturnStmt{Results: []goast.Expr{expr}})
+
+	return body, nil
+}
+
+// transpileBinaryConditionalOperator transpiles the GNU extension of the
+// conditional operator without the middle operand:
+//
+//     a ?: b
+//
+// It is the same as "a ? a : b", except that "a" is only evaluated once. The
+// value of "a" is kept in a variable, that is the result when it is true:
+//
+//     func() int32 {
+//         tempVar := a
+//         if tempVar != 0 {
+//             return tempVar
+//         } else {
+//             return b
+//         }
+//     }()
+//
+// The children of the node after "a" are the OpaqueValueExpr references to
+// it, that are not transpiled, and "b" is the last child.
+func transpileBinaryConditionalOperator(n *ast.BinaryConditionalOperator, p *program.Program) (
+	_ *goast.CallExpr, theType string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+	defer func() {
 		if err != nil {
-			return
+			err = fmt.Errorf("Cannot transpile BinaryConditionalOperator : err = %v", err)
 		}
-		bType = n.Type
+	}()
+
+	children := n.Children()
+	if len(children) < 2 {
+		err = fmt.Errorf("expected at least 2 children, got %d", len(children))
+		return
 	}
 
-	// c - else body
-	c, cType, newPre, newPost, err := transpileToExpr(n.Children()[2], p, false)
+	// a - condition and value
+	a, aType, newPre, newPost, err := transpileToExpr(children[0], p, false)
 	if err != nil {
-		return nil, "", nil, nil, err
+		return
 	}
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
-	if n.Type != "void" {
-		c, err = types.CastExpr(p, c, cType, n.Type)
-		if err != nil {
-			return
+	// null in C is zero
+	if aType == types.NullPointer {
+		a = &goast.BasicLit{
+			Kind:  token.INT,
+			Value: "0",
 		}
-		cType = n.Type
+		aType = "int"
+	}
+
+	cond, err := types.CastExpr(p, util.NewIdent("tempVar"), aType, "bool")
+	if err != nil {
+		return
 	}
 
-	// rightType - generate return type
 	var returnType string
+	bod := &goast.BlockStmt{Lbrace: 1}
 	if n.Type != "void" {
 		returnType, err = types.ResolveType(p, n.Type)
 		if err != nil {
 			return
 		}
-	}
 
-	var bod, els goast.BlockStmt
-
-	bod.Lbrace = 1
-	if bType != types.ToVoid {
-		if n.Type != "void" {
-			bod.List = []goast.Stmt{
-				&goast.ReturnStmt{
-					Results: []goast.Expr{b},
-				},
-			}
-		} else {
-			bod.List = []goast.Stmt{&goast.ExprStmt{b}}
+		var value goast.Expr
+		value, err = types.CastExpr(p, util.NewIdent("tempVar"), aType, n.Type)
+		if err != nil {
+			return
 		}
+		bod.List = []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{value}}}
 	}
 
-	els.Lbrace = 1
-	if cType != types.ToVoid {
-		if n.Type != "void" {
-			els.List = []goast.Stmt{
-				&goast.ReturnStmt{
-					Results: []goast.Expr{c},
-				},
-			}
-		} else {
-			els.List = []goast.Stmt{&goast.ExprStmt{c}}
-		}
+	// b - else body
+	els, err := transpileConditionalBranch(children[len(children)-1], n.Type, returnType, p)
+	if err != nil {
+		return
 	}
 
 	return util.NewFuncClosure(
 		returnType,
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{util.NewIdent("tempVar")},
+			Tok: token.DEFINE,
+			Rhs: []goast.Expr{a},
+		},
 		&goast.IfStmt{
-			Cond: a,
-			Body: &bod,
-			Else: &els,
+			Cond: cond,
+			Body: bod,
+			Else: els,
 		},
 	), n.Type, preStmts, postStmts, nil
 }
@@ -301,6 +384,17 @@ func transpileCompoundAssignOperator(
 
 	operator := getTokenForOperator(n.Opcode)
 
+	// The compound assignment of a bit-field is a call of its setter, and of
+	// a volatile integer it is a store of sync/atomic.
+	if getBitfieldMemberExpr(n.Children()[0]) != nil ||
+		(exprIsStmt && atomicTypeOf(p, n.Children()[0]) != "") {
+		return transpileBinaryOperator(&ast.BinaryOperator{
+			Type:       n.Type,
+			Operator:   n.Opcode,
+			ChildNodes: n.ChildNodes,
+		}, p, exprIsStmt)
+	}
+
 	right, rightType, newPre, newPost, err := atomicOperation(n.Children()[1], p)
 	if err != nil {
 		return nil, "", nil, nil, err
@@ -315,9 +409,21 @@ func transpileCompoundAssignOperator(
 
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
+	// The left side is used twice by the pointer arithmetic, the long double
+	// operations and the closure of an assignment that is used as a value. The
+	// side effects in it, like the i++ of "a[i++] += 1", must only happen once.
+	isPointerArithmetic := types.IsPointer(p, n.Type) &&
+		(operator == token.ADD_ASSIGN || operator == token.SUB_ASSIGN)
+	isPromoted := isPromotedAssign(p, operator, leftType, n.ComputationLHSType)
+	if goType, _ := types.ResolveType(p, leftType); !exprIsStmt ||
+		isPointerArithmetic || isPromoted || goType == types.BigFloatLongDouble {
+		var hoisted []goast.Stmt
+		left, hoisted = hoistSideEffects(p, left)
+		preStmts = append(preStmts, hoisted...)
+	}
+
 	// Pointer arithmetic
-	if types.IsPointer(p, n.Type) &&
-		(operator == token.ADD_ASSIGN || operator == token.SUB_ASSIGN) {
+	if isPointerArithmetic {
 		operator = convertToWithoutAssign(operator)
 		v, vType, newPre, newPost, err := pointerArithmetic(p, left, leftType, right, rightType, operator)
 		if err != nil {
@@ -335,6 +441,37 @@ func transpileCompoundAssignOperator(
 		return v, vType, preStmts, postStmts, nil
 	}
 
+	// The operands of a compound assignment to a narrower integer are
+	// promoted like the ones of the binary operator, see isPromotedAssign():
+	//
+	//     c /= n   =>   c = byte(int32(int8(c)) / n)
+	if isPromoted {
+		computeType := n.ComputationResultType
+		v, err := types.CastExpr(p, left, leftType, n.ComputationLHSType)
+		if err != nil {
+			return nil, "", nil, nil, err
+		}
+		if operator == token.SHR_ASSIGN {
+			right, err = types.CastExpr(p, right, rightType, "unsigned long long")
+		} else {
+			right, err = types.CastExpr(p, right, rightType, computeType)
+		}
+		if err != nil {
+			return nil, "", nil, nil, err
+		}
+		v, err = types.CastExpr(p, &goast.BinaryExpr{
+			X:  v,
+			Op: convertToWithoutAssign(operator),
+			Y:  right,
+		}, computeType, leftType)
+		if err != nil {
+			return nil, "", nil, nil, err
+		}
+		resolvedLeftType, _ := types.ResolveType(p, leftType)
+		return util.NewBinaryExpr(left, token.ASSIGN, v, resolvedLeftType, exprIsStmt),
+			n.Type, preStmts, postStmts, nil
+	}
+
 	// The right hand argument of the shift left or shift right operators
 	// in Go must be unsigned integers. In C, shifting with a negative shift
 	// count is undefined behaviour (so we should be able to ignore that case).
@@ -372,10 +509,129 @@ func transpileCompoundAssignOperator(
 		p.AddMessage(p.GenerateWarningMessage(err, n))
 	}
 
+	// a += b  =>  a = a.Add(b)
+	switch operator {
+	case token.ADD_ASSIGN, token.SUB_ASSIGN, token.MUL_ASSIGN, token.QUO_ASSIGN:
+		if e, ok := longDoubleOperation(left, convertToWithoutAssign(operator),
+			right, resolvedLeftType); ok {
+			return util.NewBinaryExpr(left, token.ASSIGN, e, resolvedLeftType, exprIsStmt),
+				n.Type, preStmts, postStmts, nil
+		}
+	}
+
 	return util.NewBinaryExpr(left, operator, right, resolvedLeftType, exprIsStmt),
 		n.Type, preStmts, postStmts, nil
 }
 
+// isPromotedAssign reports whether a compound assignment to an integer that is
+// narrower than int, like a char or a short, must be computed in the promoted
+// type of C. The sums, the products and the bitwise operations wrap around to
+// the same value in the narrower Go type, but the division, the remainder and
+// the shift right depend on the width and the signedness of the operands. For
+// example "c /= 2" of a char that is -4 is -2 in C, while the Go byte is 252.
+func isPromotedAssign(p *program.Program, operator token.Token,
+	leftType, computeType string) bool {
+	switch operator {
+	case token.QUO_ASSIGN, token.REM_ASSIGN, token.SHR_ASSIGN:
+	default:
+		return false
+	}
+	goLeftType, err := types.ResolveType(p, leftType)
+	if err != nil {
+		return false
+	}
+	goComputeType, err := types.ResolveType(p, computeType)
+	if err != nil {
+		return false
+	}
+	return goLeftType != goComputeType &&
+		(goLeftType == "byte" || types.IsGoIntegerType(goLeftType)) &&
+		types.IsGoIntegerType(goComputeType)
+}
+
+// hoistSideEffects moves the parts of the left side of an assignment that have
+// side effects into variables, so that the left side can be evaluated more
+// than once. The returned statements declare the variables. For example:
+//
+//     a[i++] += 1.5
+//
+// is the same as:
+//
+//     c2goTempVar0 := i++
+//     a[c2goTempVar0] = a[c2goTempVar0] + 1.5
+//
+// Only the index of an array and the pointer that is dereferenced are moved,
+// a struct cannot be copied into a variable without changing what is
+// assigned.
+func hoistSideEffects(p *program.Program, left goast.Expr) (
+	goast.Expr, []goast.Stmt) {
+	switch v := left.(type) {
+	case *goast.ParenExpr:
+		x, stmts := hoistSideEffects(p, v.X)
+		return &goast.ParenExpr{X: x}, stmts
+
+	case *goast.SelectorExpr:
+		x, stmts := hoistSideEffects(p, v.X)
+		return &goast.SelectorExpr{X: x, Sel: v.Sel}, stmts
+
+	case *goast.IndexExpr:
+		x, stmts := hoistSideEffects(p, v.X)
+		index := v.Index
+		if hasSideEffects(index) {
+			var s goast.Stmt
+			index, s = newTempVar(p, index)
+			stmts = append(stmts, s)
+		}
+		return &goast.IndexExpr{X: x, Index: index}, stmts
+
+	case *goast.StarExpr:
+		if hasSideEffects(v.X) {
+			x, s := newTempVar(p, v.X)
+			return &goast.StarExpr{X: x}, []goast.Stmt{s}
+		}
+	}
+
+	return left, nil
+}
+
+// hasSideEffects returns true if the expression calls a function. The
+// conversions of types are not function calls.
+func hasSideEffects(expr goast.Expr) (has bool) {
+	goast.Inspect(expr, func(node goast.Node) bool {
+		switch v := node.(type) {
+		case *goast.FuncLit:
+			has = true
+		case *goast.CallExpr:
+			has = !isTypeConversion(v)
+		}
+		return !has
+	})
+	return
+}
+
+func isTypeConversion(call *goast.CallExpr) bool {
+	switch f := call.Fun.(type) {
+	case *goast.ParenExpr, *goast.ArrayType, *goast.StarExpr:
+		return true
+	case *goast.SelectorExpr:
+		x, ok := f.X.(*goast.Ident)
+		return ok && x.Name == "unsafe" && f.Sel.Name == "Pointer"
+	case *goast.Ident:
+		_, ok := gotypes.Universe.Lookup(f.Name).(*gotypes.TypeName)
+		return ok
+	}
+	return false
+}
+
+func newTempVar(p *program.Program, expr goast.Expr) (*goast.Ident, goast.Stmt) {
+	name := util.NewIdent(p.GetNextIdentifier("c2goTempVar"))
+	return name, &goast.AssignStmt{
+		Lhs: []goast.Expr{name},
+		Tok: token.DEFINE,
+		Rhs: []goast.Expr{expr},
+	}
+}
+
 // getTokenForOperator returns the Go operator token for the provided C
 // operator.
 func getTokenForOperator(operator string) token.Token {
@@ -474,6 +730,10 @@ func convertToWithoutAssign(operator token.Token) token.Token {
 		return token.MUL
 	case token.QUO_ASSIGN: // "/="
 		return token.QUO
+	case token.REM_ASSIGN: // "%="
+		return token.REM
+	case token.SHR_ASSIGN: // ">>="
+		return token.SHR
 	}
 	panic(fmt.Sprintf("not support operator: %v", operator))
 }
@@ -503,136 +763,9 @@ func atomicOperation(n ast.Node, p *program.Program) (
 
 	switch v := n.(type) {
 	case *ast.UnaryOperator:
-		switch v.Operator {
-		case "&", "*", "!", "-", "~":
-			return
-		}
-		// UnaryOperator 0x252d798 <col:17, col:18> 'double' prefix '-'
-		// `-FloatingLiteral 0x252d778 <col:18> 'double' 0.000000e+00
-		if _, ok := v.Children()[0].(*ast.IntegerLiteral); ok {
-			return
-		}
-		if _, ok := v.Children()[0].(*ast.FloatingLiteral); ok {
-			return
-		}
-
-		// ++, -- anonymous functions are handled here below
-		expr, exprType, preStmts, postStmts, err = transpileToExpr(n, p, true)
-
-		// UnaryOperator 0x3001768 <col:204, col:206> 'int' prefix '++'
-		// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
-		// OR
-		// UnaryOperator 0x3001768 <col:204, col:206> 'int' postfix '++'
-		// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
-		var varName string
-		var vv *ast.DeclRefExpr
-		if vv, err = getSoleChildDeclRefExpr(v); err == nil {
-			varName = vv.Name
-
-			var exprResolveType string
-			exprResolveType, err = types.ResolveType(p, v.Type)
-			if err != nil {
-				return
-			}
-
-			// operators: ++, --
-			if v.IsPrefix {
-				// Example:
-				// UnaryOperator 0x3001768 <col:204, col:206> 'int' prefix '++'
-				// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
-				expr = util.NewAnonymousFunction(append(preStmts, &goast.ExprStmt{expr}),
-					nil,
-					util.NewIdent(varName),
-					exprResolveType)
-				preStmts = nil
-				break
-			}
-			// Example:
-			// UnaryOperator 0x3001768 <col:204, col:206> 'int' postfix '++'
-			// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
-			expr = util.NewAnonymousFunction(preStmts,
-				[]goast.Stmt{&goast.ExprStmt{expr}},
-				util.NewIdent(varName),
-				exprResolveType)
-			preStmts = nil
-
-			break
-		}
-
-		// UnaryOperator 0x358d470 <col:28, col:40> 'int' postfix '++'
-		// `-MemberExpr 0x358d438 <col:28, col:36> 'int' lvalue .pos 0x358b538
-		//   `-ArraySubscriptExpr 0x358d410 <col:28, col:34> 'struct struct_I_A':'struct struct_I_A' lvalue
-		//     |-ImplicitCastExpr 0x358d3f8 <col:28> 'struct struct_I_A *' <ArrayToPointerDecay>
-		//     | `-DeclRefExpr 0x358d3b0 <col:28> 'struct struct_I_A [2]' lvalue Var 0x358b6e8 'siia' 'struct struct_I_A [2]'
-		//     `-IntegerLiteral 0x358d3d8 <col:33> 'int' 0
-		varName = "tempVar"
-
-		expr, exprType, preStmts, postStmts, err = transpileToExpr(v.Children()[0], p, false)
-		if err != nil {
-			return
-		}
-
-		body := append(preStmts, &goast.AssignStmt{
-			Lhs: []goast.Expr{util.NewIdent(varName)},
-			Tok: token.DEFINE,
-			Rhs: []goast.Expr{&goast.UnaryExpr{
-				Op: token.AND,
-				X:  expr,
-			}},
-		})
-
-		deferBody := postStmts
-		postStmts = nil
-		preStmts = nil
-
-		switch v.Operator {
-		case "++":
-			expr = &goast.BinaryExpr{
-				X:  &goast.StarExpr{X: util.NewIdent(varName)},
-				Op: token.ADD_ASSIGN,
-				Y:  &goast.BasicLit{Kind: token.INT, Value: "1"},
-			}
-		case "--":
-			expr = &goast.BinaryExpr{
-				X:  &goast.StarExpr{X: util.NewIdent(varName)},
-				Op: token.SUB_ASSIGN,
-				Y:  &goast.BasicLit{Kind: token.INT, Value: "1"},
-			}
-		}
-
-		body = append(body, preStmts...)
-		deferBody = append(deferBody, postStmts...)
-
-		var exprResolveType string
-		exprResolveType, err = types.ResolveType(p, v.Type)
-		if err != nil {
-			return
-		}
-
-		// operators: ++, --
-		if v.IsPrefix {
-			// Example:
-			// UnaryOperator 0x3001768 <col:204, col:206> 'int' prefix '++'
-			// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
-			expr = util.NewAnonymousFunction(append(body, &goast.ExprStmt{expr}), deferBody,
-				&goast.StarExpr{
-					X: util.NewIdent(varName),
-				},
-				exprResolveType)
-			preStmts = nil
-			postStmts = nil
-			break
-		}
-		// Example:
-		// UnaryOperator 0x3001768 <col:204, col:206> 'int' postfix '++'
-		// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
-		expr = util.NewAnonymousFunction(body, append(deferBody, &goast.ExprStmt{expr}),
-			&goast.StarExpr{
-				X: util.NewIdent(varName),
-			},
-			exprResolveType)
-		preStmts = nil
-		postStmts = nil
+		// The value of an increment or a decrement is transpiled by
+		// transpileIncrementValue().
+		return
 
 	case *ast.CompoundAssignOperator:
 		// CompoundAssignOperator 0x32911c0 <col:18, col:28> 'int' '-=' ComputeLHSTy='int' ComputeResultTy='int'
@@ -797,6 +930,7 @@ func atomicOperation(n ast.Node, p *program.Program) (
 		if exprType == types.NullPointer {
 			return
 		}
+		expr = newAtomicLoad(p, v, expr)
 		if !types.IsFunction(exprType) && !strings.ContainsAny(v.Type, "[]") {
 			expr, err = types.CastExpr(p, expr, exprType, v.Type)
 			if err != nil {
@@ -852,26 +986,29 @@ func atomicOperation(n ast.Node, p *program.Program) (
 			body := append(inBody, preStmts...)
 			preStmts = nil
 
-			body = append(body, &goast.AssignStmt{
-				Lhs: []goast.Expr{util.NewIdent(varName)},
-				Tok: token.DEFINE,
-				Rhs: []goast.Expr{&goast.UnaryExpr{
-					Op: token.AND,
-					X:  expr,
-				}},
-			})
-
 			var exprResolveType string
 			exprResolveType, err = types.ResolveType(p, v.Type)
 			if err != nil {
 				return
 			}
 
+			// The value of the right side is kept before the post statements
+			// of it are run. It is not always addressable, like "a + 1".
+			expr, err = types.CastExpr(p, expr, exprType, v.Type)
+			if err != nil {
+				return
+			}
+			body = append(body, &goast.DeclStmt{Decl: &goast.GenDecl{
+				Tok: token.VAR,
+				Specs: []goast.Spec{&goast.ValueSpec{
+					Names:  []*goast.Ident{util.NewIdent(varName)},
+					Type:   util.NewTypeIdent(exprResolveType),
+					Values: []goast.Expr{expr},
+				}},
+			}})
+
 			expr = util.NewAnonymousFunction(body, postStmts,
-				&goast.UnaryExpr{
-					Op: token.MUL,
-					X:  util.NewIdent(varName),
-				},
+				util.NewIdent(varName),
 				exprResolveType)
 			preStmts = nil
 			postStmts = nil
@@ -906,6 +1043,21 @@ func atomicOperation(n ast.Node, p *program.Program) (
 			e, _, newPre, newPost, _ := transpileToExpr(v, p, true)
 			if assign, ok := e.(*goast.BinaryExpr); !ok || assign.Op != token.ASSIGN {
 				panic("not a valid assignment")
+			} else if name, ok := assign.X.(*goast.Ident); ok {
+				// The assignment to a variable is done before the expression,
+				// which reads the variable back, like:
+				//
+				//     if ((n = f()) > 0)
+				//
+				// becomes:
+				//
+				//     n = f()
+				//     if n > 0
+				preStmts = append(newPre, &goast.ExprStmt{X: assign})
+				preStmts = append(preStmts, newPost...)
+				postStmts = nil
+				expr = util.NewIdent(name.Name)
+				return
 			} else {
 				body = append(body, &goast.AssignStmt{
 					Lhs: []goast.Expr{util.NewIdent(varName)},
@@ -0,0 +1,325 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestCompoundAssignSideEffects(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+		want []string
+	}{
+		// void f(int **pp, int i) { pp[i++] += 1; }
+		{"pointer", `
+FunctionDecl 0x10 <x.c:1:1, line:3:1> line:1:6 f 'void (int **, int)'
+|-ParmVarDecl 0x11 <col:8, col:14> col:14 used pp 'int **'
+|-ParmVarDecl 0x12 <col:18, col:22> col:22 used i 'int'
+|-CompoundStmt 0x13 <col:25, line:3:1>
+  |-CompoundAssignOperator 0x20 <line:2:3, col:14> 'int *' '+=' ComputeLHSTy='int *' ComputeResultTy='int *'
+    |-ArraySubscriptExpr 0x21 <col:3, col:9> 'int *' lvalue
+    | |-ImplicitCastExpr 0x22 <col:3> 'int **' <LValueToRValue>
+    | | |-DeclRefExpr 0x23 <col:3> 'int **' lvalue ParmVar 0x11 'pp' 'int **'
+    | |-UnaryOperator 0x24 <col:6, col:7> 'int' postfix '++'
+    |   |-DeclRefExpr 0x25 <col:6> 'int' lvalue ParmVar 0x12 'i' 'int'
+    |-IntegerLiteral 0x26 <col:14> 'int' 1
+`, []string{
+			"c2goTempVar0 := ((**int32)(unsafe.Pointer(",
+			"*c2goTempVar0 = ((*int32)(",
+			"tempVar := *c2goTempVar0",
+		}},
+
+		// int f(int *a, int i) { return a[i++] *= 3; }
+		{"index", `
+FunctionDecl 0x10 <x.c:1:1, line:3:1> line:1:5 f 'int (int *, int)'
+|-ParmVarDecl 0x11 <col:7, col:12> col:12 used a 'int *'
+|-ParmVarDecl 0x12 <col:15, col:19> col:19 used i 'int'
+|-CompoundStmt 0x13 <col:22, line:3:1>
+  |-ReturnStmt 0x14 <line:2:3, col:21>
+    |-CompoundAssignOperator 0x20 <col:10, col:21> 'int' '*=' ComputeLHSTy='int' ComputeResultTy='int'
+      |-ArraySubscriptExpr 0x21 <col:10, col:15> 'int' lvalue
+      | |-ImplicitCastExpr 0x22 <col:10> 'int *' <LValueToRValue>
+      | | |-DeclRefExpr 0x23 <col:10> 'int *' lvalue ParmVar 0x11 'a' 'int *'
+      | |-UnaryOperator 0x24 <col:12, col:13> 'int' postfix '++'
+      |   |-DeclRefExpr 0x25 <col:12> 'int' lvalue ParmVar 0x12 'i' 'int'
+      |-IntegerLiteral 0x26 <col:21> 'int' 3
+`, []string{
+			"c2goTempVar0 := ((*int32)(unsafe.Pointer(",
+			"*c2goTempVar0 *= int32(3)",
+			"return *c2goTempVar0",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := program.NewProgram()
+			decls, err := transpileFunctionDecl(parseTree(tt.dump).(*ast.FunctionDecl), p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+				t.Fatal(err)
+			}
+
+			// The index is only incremented once.
+			if n := strings.Count(buf.String(), "i += 1"); n != 1 {
+				t.Errorf("Expected the index to be evaluated once, got %d times in:\n%s",
+					n, buf.String())
+			}
+			for _, e := range tt.want {
+				if !strings.Contains(buf.String(), e) {
+					t.Errorf("Expected %q in:\n%s", e, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestHoistSideEffects(t *testing.T) {
+	p := program.NewProgram()
+	tests := []struct {
+		left  string
+		want  string
+		stmts []string
+	}{
+		{"a[i]", "a[i]", nil},
+		{"a[int32(i)]", "a[int32(i)]", nil},
+		{"*p", "*p", nil},
+		{"*(*int32)(unsafe.Pointer(p))", "*(*int32)(unsafe.Pointer(p))", nil},
+		{"a[next()]", "a[c2goTempVar0]", []string{"c2goTempVar0 := next()"}},
+		{"(*next()).x", "(*c2goTempVar1).x", []string{"c2goTempVar1 := next()"}},
+		{"a[next()][f()]", "a[c2goTempVar2][c2goTempVar3]",
+			[]string{"c2goTempVar2 := next()", "c2goTempVar3 := f()"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.left, func(t *testing.T) {
+			left, err := parser.ParseExpr(tt.left)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expr, stmts := hoistSideEffects(p, left)
+
+			var buf bytes.Buffer
+			format.Node(&buf, token.NewFileSet(), expr)
+			if buf.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, buf.String())
+			}
+			var got []string
+			for _, s := range stmts {
+				buf.Reset()
+				format.Node(&buf, token.NewFileSet(), s)
+				got = append(got, buf.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.stmts, "\n") {
+				t.Errorf("Expected statements %q, got %q", tt.stmts, got)
+			}
+		})
+	}
+}
+
+func TestConditionalOperator(t *testing.T) {
+	// int f(int x, int a, int b) {
+	//     return x ? (a++, a) : b ? (b--, b) : 7;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:4:1> line:1:5 f 'int (int, int, int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used x 'int'
+|-ParmVarDecl 0x12 <col:14, col:18> col:18 used a 'int'
+|-ParmVarDecl 0x13 <col:21, col:25> col:25 used b 'int'
+|-CompoundStmt 0x14 <col:28, line:4:1>
+  |-ReturnStmt 0x20 <line:2:3, col:30>
+    |-ConditionalOperator 0x21 <col:10, col:30> 'int'
+      |-ImplicitCastExpr 0x22 <col:10> 'int' <LValueToRValue>
+      | |-DeclRefExpr 0x23 <col:10> 'int' lvalue ParmVar 0x11 'x' 'int'
+      |-ParenExpr 0x24 <col:14, col:20> 'int'
+      | |-BinaryOperator 0x25 <col:15, col:19> 'int' ','
+      |   |-UnaryOperator 0x26 <col:15, col:16> 'int' postfix '++'
+      |   | |-DeclRefExpr 0x27 <col:15> 'int' lvalue ParmVar 0x12 'a' 'int'
+      |   |-ImplicitCastExpr 0x28 <col:19> 'int' <LValueToRValue>
+      |     |-DeclRefExpr 0x29 <col:19> 'int' lvalue ParmVar 0x12 'a' 'int'
+      |-ConditionalOperator 0x30 <col:24, col:30> 'int'
+        |-ImplicitCastExpr 0x31 <col:24> 'int' <LValueToRValue>
+        | |-DeclRefExpr 0x32 <col:24> 'int' lvalue ParmVar 0x13 'b' 'int'
+        |-ParenExpr 0x33 <col:14, col:20> 'int'
+        | |-BinaryOperator 0x34 <col:15, col:19> 'int' ','
+        |   |-UnaryOperator 0x35 <col:15, col:16> 'int' postfix '--'
+        |   | |-DeclRefExpr 0x36 <col:15> 'int' lvalue ParmVar 0x13 'b' 'int'
+        |   |-ImplicitCastExpr 0x37 <col:19> 'int' <LValueToRValue>
+        |     |-DeclRefExpr 0x38 <col:19> 'int' lvalue ParmVar 0x13 'b' 'int'
+        |-IntegerLiteral 0x39 <col:30> 'int' 7
+`
+	want := `
+	return func() int32 {
+		if x != 0 {
+			a += 1
+			return (a)
+		} else {
+			return func() int32 {
+				if b != 0 {
+					b -= 1
+					return (b)
+				} else {
+					return int32(7)
+				}
+			}()
+		}
+	}()
+`
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), want[1:]) {
+		t.Errorf("Expected:\n%s\nin:\n%s", want, buf.String())
+	}
+}
+
+func TestBinaryConditionalOperator(t *testing.T) {
+	// int f(void);
+	// int g(void);
+	// int h() {
+	//     return f() ?: g();
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:3:1, line:5:1> line:3:5 h 'int ()'
+|-CompoundStmt 0x11 <col:9, line:5:1>
+  |-ReturnStmt 0x20 <line:4:3, col:20>
+    |-BinaryConditionalOperator 0x21 <col:10, col:20> 'int'
+      |-CallExpr 0x22 <col:10, col:12> 'int'
+      | |-ImplicitCastExpr 0x23 <col:10> 'int (*)(void)' <FunctionToPointerDecay>
+      |   |-DeclRefExpr 0x24 <col:10> 'int (void)' Function 0x01 'f' 'int (void)'
+      |-OpaqueValueExpr 0x25 <col:10, col:12> 'int'
+      | |-CallExpr 0x22 <col:10, col:12> 'int'
+      |   |-ImplicitCastExpr 0x23 <col:10> 'int (*)(void)' <FunctionToPointerDecay>
+      |     |-DeclRefExpr 0x24 <col:10> 'int (void)' Function 0x01 'f' 'int (void)'
+      |-OpaqueValueExpr 0x25 <col:10, col:12> 'int'
+      | |-CallExpr 0x22 <col:10, col:12> 'int'
+      |   |-ImplicitCastExpr 0x23 <col:10> 'int (*)(void)' <FunctionToPointerDecay>
+      |     |-DeclRefExpr 0x24 <col:10> 'int (void)' Function 0x01 'f' 'int (void)'
+      |-CallExpr 0x26 <col:18, col:20> 'int'
+        |-ImplicitCastExpr 0x27 <col:18> 'int (*)(void)' <FunctionToPointerDecay>
+          |-DeclRefExpr 0x28 <col:18> 'int (void)' Function 0x02 'g' 'int (void)'
+`
+	want := `
+	return func() int32 {
+		tempVar := f()
+		if tempVar != 0 {
+			return tempVar
+		} else {
+			return g()
+		}
+	}()
+`
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), want[1:]) {
+		t.Errorf("Expected:\n%s\nin:\n%s", want, buf.String())
+	}
+}
+
+func TestIntegerPromotions(t *testing.T) {
+	// int f(char a, char b, _Bool x, short s, unsigned char u) {
+	//     int r = (char)a + (char)b;
+	//     r = x & u;
+	//     a /= s;
+	//     s >>= u;
+	//     a += s;
+	//     return r;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:8:1> line:1:5 f 'int (char, char, _Bool, short, unsigned char)'
+|-ParmVarDecl 0x11 <col:7, col:12> col:12 used a 'char'
+|-ParmVarDecl 0x12 <col:15, col:20> col:20 used b 'char'
+|-ParmVarDecl 0x13 <col:23, col:29> col:29 used x '_Bool'
+|-ParmVarDecl 0x14 <col:32, col:38> col:38 used s 'short'
+|-ParmVarDecl 0x15 <col:41, col:55> col:55 used u 'unsigned char'
+|-CompoundStmt 0x20 <col:58, line:8:1>
+  |-DeclStmt 0x21 <line:2:5, col:30>
+  | |-VarDecl 0x22 <col:5, col:29> col:9 used r 'int' cinit
+  |   |-BinaryOperator 0x23 <col:13, col:29> 'int' '+'
+  |     |-ImplicitCastExpr 0x24 <col:13, col:19> 'int' <IntegralCast>
+  |     | |-CStyleCastExpr 0x25 <col:13, col:19> 'char' <NoOp>
+  |     |   |-ImplicitCastExpr 0x26 <col:19> 'char' <LValueToRValue>
+  |     |     |-DeclRefExpr 0x27 <col:19> 'char' lvalue ParmVar 0x11 'a' 'char'
+  |     |-ImplicitCastExpr 0x28 <col:23, col:29> 'int' <IntegralCast>
+  |       |-CStyleCastExpr 0x29 <col:23, col:29> 'char' <NoOp>
+  |         |-ImplicitCastExpr 0x2a <col:29> 'char' <LValueToRValue>
+  |           |-DeclRefExpr 0x2b <col:29> 'char' lvalue ParmVar 0x12 'b' 'char'
+  |-BinaryOperator 0x30 <line:3:5, col:13> 'int' '='
+  | |-DeclRefExpr 0x31 <col:5> 'int' lvalue Var 0x22 'r' 'int'
+  | |-BinaryOperator 0x32 <col:9, col:13> 'int' '&'
+  |   |-ImplicitCastExpr 0x33 <col:9> 'int' <IntegralCast>
+  |   | |-ImplicitCastExpr 0x34 <col:9> '_Bool' <LValueToRValue>
+  |   |   |-DeclRefExpr 0x35 <col:9> '_Bool' lvalue ParmVar 0x13 'x' '_Bool'
+  |   |-ImplicitCastExpr 0x36 <col:13> 'int' <IntegralCast>
+  |     |-ImplicitCastExpr 0x37 <col:13> 'unsigned char' <LValueToRValue>
+  |       |-DeclRefExpr 0x38 <col:13> 'unsigned char' lvalue ParmVar 0x15 'u' 'unsigned char'
+  |-CompoundAssignOperator 0x40 <line:4:5, col:10> 'char' '/=' ComputeLHSTy='int' ComputeResultTy='int'
+  | |-DeclRefExpr 0x41 <col:5> 'char' lvalue ParmVar 0x11 'a' 'char'
+  | |-ImplicitCastExpr 0x42 <col:10> 'int' <IntegralCast>
+  |   |-ImplicitCastExpr 0x43 <col:10> 'short' <LValueToRValue>
+  |     |-DeclRefExpr 0x44 <col:10> 'short' lvalue ParmVar 0x14 's' 'short'
+  |-CompoundAssignOperator 0x50 <line:5:5, col:11> 'short' '>>=' ComputeLHSTy='int' ComputeResultTy='int'
+  | |-DeclRefExpr 0x51 <col:5> 'short' lvalue ParmVar 0x14 's' 'short'
+  | |-ImplicitCastExpr 0x52 <col:11> 'int' <IntegralCast>
+  |   |-ImplicitCastExpr 0x53 <col:11> 'unsigned char' <LValueToRValue>
+  |     |-DeclRefExpr 0x54 <col:11> 'unsigned char' lvalue ParmVar 0x15 'u' 'unsigned char'
+  |-CompoundAssignOperator 0x60 <line:6:5, col:10> 'char' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  | |-DeclRefExpr 0x61 <col:5> 'char' lvalue ParmVar 0x11 'a' 'char'
+  | |-ImplicitCastExpr 0x62 <col:10> 'int' <IntegralCast>
+  |   |-ImplicitCastExpr 0x63 <col:10> 'short' <LValueToRValue>
+  |     |-DeclRefExpr 0x64 <col:10> 'short' lvalue ParmVar 0x14 's' 'short'
+  |-ReturnStmt 0x70 <line:7:5, col:12>
+    |-ImplicitCastExpr 0x71 <col:12> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x72 <col:12> 'int' lvalue Var 0x22 'r' 'int'
+`
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		// The sum of two chars is an int, from the signed chars.
+		"var r int32 = int32(int8(a)) + int32(int8(b))",
+		"r = int32(x)&int32(u)",
+		// The division and the shift right are computed in the promoted
+		// type, like -4 / 2 of a char that is -2 and not 126.
+		"a = byte(int32(int8(a))/int32(s))",
+		"s = int16(int32(s)>>uint64(int32(u)))",
+		// The sum wraps around to the same value without the promotion.
+		"a += byte(int32(s))",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// This file contains functions for transpiling setjmp() and longjmp(). The
+// jump back to setjmp() is a panic that is recovered by noarch.Setjmp().
+
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/token"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// setjmpFunctions are the names of setjmp() in the C headers. For example,
+// glibc defines setjmp() as a macro of _setjmp().
+var setjmpFunctions = []string{"setjmp", "_setjmp", "sigsetjmp", "__sigsetjmp"}
+
+// isJmpBuf returns true if the C type is a jmp_buf.
+func isJmpBuf(p *program.Program, cType string) bool {
+	t, err := types.ResolveType(p, cType)
+	return err == nil && t == "noarch.JmpBuf"
+}
+
+// transpileSetjmp replaces each setjmp() in the body of a function with a
+// call of noarch.Setjmp(). The statement of the setjmp() and all of the
+// statements after it in the same block are moved into a function literal, so
+// that they can be run again when longjmp() returns to the setjmp(). The
+// results are the Go types of the results of the function, they are needed
+// when there is a return statement in the moved code.
+//
+// Example:
+//
+//     if (setjmp(env) == 0) {           noarch.Setjmp(&env, func(c2goSetjmp0 int32) {
+//         jump(env);                        if c2goSetjmp0 == 0 {
+//     } else {                                  jump(&env)
+//         printf("jumped\n");     =>        } else {
+//     }                                         noarch.Printf(...)
+//     done();                               }
+//                                           done()
+//                                       })
+//
+// The longjmp() to a buffer is only recovered by the setjmp() of the same
+// buffer, so that setjmp() scopes can be nested.
+func transpileSetjmp(p *program.Program, n *ast.FunctionDecl,
+	body *goast.BlockStmt, results []string) {
+	if body == nil {
+		return
+	}
+	body.List = transpileSetjmpStmts(p, n, body.List, results)
+}
+
+func transpileSetjmpStmts(p *program.Program, n *ast.FunctionDecl,
+	stmts []goast.Stmt, results []string) []goast.Stmt {
+	// The blocks inside of the statements are handled first.
+	for _, s := range stmts {
+		goast.Inspect(s, func(node goast.Node) bool {
+			switch v := node.(type) {
+			case *goast.FuncLit:
+				return false
+			case *goast.BlockStmt:
+				v.List = transpileSetjmpStmts(p, n, v.List, results)
+				return false
+			case *goast.CaseClause:
+				v.Body = transpileSetjmpStmts(p, n, v.Body, results)
+				return false
+			case *goast.CommClause:
+				v.Body = transpileSetjmpStmts(p, n, v.Body, results)
+				return false
+			}
+			return true
+		})
+	}
+
+	return wrapSetjmp(p, n, stmts, results)
+}
+
+// wrapSetjmp moves the statements from the first setjmp() of the block into
+// the function literal of noarch.Setjmp().
+func wrapSetjmp(p *program.Program, n *ast.FunctionDecl,
+	stmts []goast.Stmt, results []string) []goast.Stmt {
+	for i, s := range stmts {
+		call := findSetjmp(s)
+		if call == nil {
+			continue
+		}
+
+		rest := stmts[i:]
+		if hasBranchOut(rest) {
+			p.AddMessage(p.GenerateWarningMessage(fmt.Errorf(
+				"cannot transpile setjmp(), the code after it jumps out of the block"), n))
+			return stmts
+		}
+
+		name := p.GetNextIdentifier("c2goSetjmp")
+		rest[0] = astutil.Apply(rest[0], func(cursor *astutil.Cursor) bool {
+			if cursor.Node() == call {
+				cursor.Replace(util.NewIdent(name))
+				return false
+			}
+			return true
+		}, nil).(goast.Stmt)
+		rest = append(rest[:1:1], wrapSetjmp(p, n, rest[1:], results)...)
+
+		return append(stmts[:i:i], newSetjmpScope(p, name, call.Args[0],
+			rest, results)...)
+	}
+
+	return stmts
+}
+
+// findSetjmp returns the setjmp() of the statement, but not the ones in the
+// blocks inside of the statement.
+func findSetjmp(s goast.Stmt) (call *goast.CallExpr) {
+	goast.Inspect(s, func(node goast.Node) bool {
+		if call != nil {
+			return false
+		}
+		switch v := node.(type) {
+		case *goast.FuncLit, *goast.BlockStmt, *goast.CaseClause, *goast.CommClause:
+			return false
+		case *goast.CallExpr:
+			if f, ok := v.Fun.(*goast.Ident); ok && len(v.Args) > 0 &&
+				util.InStrings(f.Name, setjmpFunctions) {
+				call = v
+				return false
+			}
+		}
+		return true
+	})
+	return
+}
+
+// hasBranchOut returns true if a break, continue or goto of the statements
+// jumps to a statement that is not one of them. It would not compile after the
+// statements are moved into a function literal.
+func hasBranchOut(stmts []goast.Stmt) (out bool) {
+	block := &goast.BlockStmt{List: stmts}
+
+	labels := map[string]bool{}
+	goast.Inspect(block, func(node goast.Node) bool {
+		if l, ok := node.(*goast.LabeledStmt); ok {
+			labels[l.Label.Name] = true
+		}
+		_, isFunc := node.(*goast.FuncLit)
+		return !isFunc
+	})
+
+	var walk func(node goast.Node, inLoop, inBreakable bool)
+	walk = func(node goast.Node, inLoop, inBreakable bool) {
+		goast.Inspect(node, func(node goast.Node) bool {
+			switch v := node.(type) {
+			case *goast.FuncLit:
+				return false
+			case *goast.ForStmt:
+				walk(v.Body, true, true)
+				return false
+			case *goast.RangeStmt:
+				walk(v.Body, true, true)
+				return false
+			case *goast.SwitchStmt:
+				walk(v.Body, inLoop, true)
+				return false
+			case *goast.TypeSwitchStmt:
+				walk(v.Body, inLoop, true)
+				return false
+			case *goast.SelectStmt:
+				walk(v.Body, inLoop, true)
+				return false
+			case *goast.BranchStmt:
+				switch {
+				case v.Label != nil:
+					out = out || !labels[v.Label.Name]
+				case v.Tok == token.BREAK:
+					out = out || !inBreakable
+				case v.Tok == token.CONTINUE:
+					out = out || !inLoop
+				}
+			}
+			return true
+		})
+	}
+	walk(block, false, false)
+
+	return
+}
+
+// newSetjmpScope returns the statements that run the body from the setjmp()
+// that is replaced by the name. A return in the body only returns from the
+// function literal, so the results are kept in variables and returned after
+// noarch.Setjmp().
+func newSetjmpScope(p *program.Program, name string, env goast.Expr,
+	body []goast.Stmt, results []string) (stmts []goast.Stmt) {
+	returned := name + "Returned"
+	var resultNames []goast.Expr
+	for i := range results {
+		resultNames = append(resultNames, util.NewIdent(fmt.Sprintf("%sResult%d", name, i)))
+	}
+
+	hasReturn := false
+	block := astutil.Apply(&goast.BlockStmt{List: body}, func(cursor *astutil.Cursor) bool {
+		switch v := cursor.Node().(type) {
+		case *goast.FuncLit:
+			return false
+		case *goast.ReturnStmt:
+			hasReturn = true
+			var list []goast.Stmt
+			if len(v.Results) > 0 && len(v.Results) == len(resultNames) {
+				list = append(list, &goast.AssignStmt{
+					Lhs: resultNames,
+					Tok: token.ASSIGN,
+					Rhs: v.Results,
+				})
+			}
+			list = append(list, &goast.AssignStmt{
+				Lhs: []goast.Expr{util.NewIdent(returned)},
+				Tok: token.ASSIGN,
+				Rhs: []goast.Expr{util.NewIdent("true")},
+			}, &goast.ReturnStmt{})
+
+			// A return that is not in a list of statements, like the one of
+			// a label, is replaced by a block.
+			if cursor.Index() < 0 {
+				cursor.Replace(&goast.BlockStmt{List: list})
+				return false
+			}
+			for _, s := range list[:len(list)-1] {
+				cursor.InsertBefore(s)
+			}
+			cursor.Replace(list[len(list)-1])
+			return false
+		}
+		return true
+	}, nil).(*goast.BlockStmt)
+
+	if hasReturn {
+		stmts = append(stmts, newVarDeclStmt(returned, "bool"))
+		for i, t := range results {
+			stmts = append(stmts, newVarDeclStmt(resultNames[i].(*goast.Ident).Name, t))
+		}
+	}
+
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	stmts = append(stmts, util.NewExprStmt(util.NewCallExpr("noarch.Setjmp", env,
+		&goast.FuncLit{
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{List: []*goast.Field{{
+					Names: []*goast.Ident{util.NewIdent(name)},
+					Type:  util.NewTypeIdent("int32"),
+				}}},
+			},
+			Body: block,
+		})))
+
+	if hasReturn {
+		stmts = append(stmts, &goast.IfStmt{
+			Cond: util.NewIdent(returned),
+			Body: &goast.BlockStmt{List: []goast.Stmt{
+				&goast.ReturnStmt{Results: resultNames},
+			}},
+		})
+	}
+
+	return
+}
+
+func newVarDeclStmt(name, goType string) goast.Stmt {
+	return &goast.DeclStmt{Decl: &goast.GenDecl{
+		Tok: token.VAR,
+		Specs: []goast.Spec{&goast.ValueSpec{
+			Names: []*goast.Ident{util.NewIdent(name)},
+			Type:  util.NewTypeIdent(goType),
+		}},
+	}}
+}
@@ -0,0 +1,78 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestSetjmp(t *testing.T) {
+	// jmp_buf env;
+	//
+	// int try(int v) {
+	//     int r = setjmp(env);
+	//     if (r != 0) return r;
+	//     jump(env, v);
+	//     return -1;
+	// }
+	f := parseTree(`
+FunctionDecl 0x30 <x.c:9:1, line:14:1> line:9:5 used try 'int (int)'
+|-ParmVarDecl 0x31 <col:9, col:13> col:13 used v 'int'
+|-CompoundStmt 0x32 <col:16, line:14:1>
+  |-DeclStmt 0x33 <line:10:5, col:24>
+  | |-VarDecl 0x34 <col:5, col:23> col:9 used r 'int' cinit
+  |   |-CallExpr 0x35 <col:13, col:23> 'int'
+  |     |-ImplicitCastExpr 0x36 <col:13> 'int (*)(struct __jmp_buf_tag *)' <FunctionToPointerDecay>
+  |     | |-DeclRefExpr 0x37 <col:13> 'int (struct __jmp_buf_tag *)' Function 0x4 '_setjmp' 'int (struct __jmp_buf_tag *)'
+  |     |-ImplicitCastExpr 0x38 <col:20> 'struct __jmp_buf_tag *' <ArrayToPointerDecay>
+  |       |-DeclRefExpr 0x39 <col:20> 'jmp_buf':'struct __jmp_buf_tag [1]' lvalue Var 0x10 'env' 'jmp_buf':'struct __jmp_buf_tag [1]'
+  |-IfStmt 0x40 <line:11:5, col:24>
+  | |-BinaryOperator 0x41 <col:9, col:14> 'int' '!='
+  | | |-ImplicitCastExpr 0x42 <col:9> 'int' <LValueToRValue>
+  | | | |-DeclRefExpr 0x43 <col:9> 'int' lvalue Var 0x34 'r' 'int'
+  | | |-IntegerLiteral 0x44 <col:14> 'int' 0
+  | |-ReturnStmt 0x45 <col:17, col:24>
+  |   |-ImplicitCastExpr 0x46 <col:24> 'int' <LValueToRValue>
+  |     |-DeclRefExpr 0x47 <col:24> 'int' lvalue Var 0x34 'r' 'int'
+  |-CallExpr 0x48 <line:12:5, col:16> 'void'
+  | |-ImplicitCastExpr 0x49 <col:5> 'void (*)(struct __jmp_buf_tag *, int)' <FunctionToPointerDecay>
+  | | |-DeclRefExpr 0x4a <col:5> 'void (struct __jmp_buf_tag *, int)' Function 0x20 'jump' 'void (struct __jmp_buf_tag *, int)'
+  | |-ImplicitCastExpr 0x4b <col:10> 'struct __jmp_buf_tag *' <ArrayToPointerDecay>
+  | | |-DeclRefExpr 0x4c <col:10> 'jmp_buf':'struct __jmp_buf_tag [1]' lvalue Var 0x10 'env' 'jmp_buf':'struct __jmp_buf_tag [1]'
+  | |-ImplicitCastExpr 0x4d <col:15> 'int' <LValueToRValue>
+  |   |-DeclRefExpr 0x4e <col:15> 'int' lvalue ParmVar 0x31 'v' 'int'
+  |-ReturnStmt 0x4f <line:13:5, col:13>
+    |-UnaryOperator 0x50 <col:12, col:13> 'int' prefix '-'
+      |-IntegerLiteral 0x51 <col:13> 'int' 1
+`)
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(f.(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	for _, d := range decls {
+		if err := format.Node(&buf, token.NewFileSet(), d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, e := range []string{
+		"noarch.Setjmp(&env, func(c2goSetjmp0 int32) {",
+		"var r int32 = c2goSetjmp0",
+		"jump(&env, v)",
+		"c2goSetjmp0Result0 = r\n",
+		"c2goSetjmp0Returned = true",
+		"if c2goSetjmp0Returned {\n\t\treturn c2goSetjmp0Result0\n\t}",
+	} {
+		if !strings.Contains(buf.String(), e) {
+			t.Errorf("Expected %q in:\n%s", e, buf.String())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// This file contains functions for moving the static variables of functions to
+// the package.
+
+package transpiler
+
+import (
+	"fmt"
+
+	"github.com/elliotchance/c2go/ast"
+)
+
+// hoistStaticVariables moves the static variables that are declared in a
+// function to the translation unit. Go has no static local variables, so they
+// become package variables that keep their value between the calls. The name
+// of the function is put in front of the name of the variable, so that the
+// variables of different functions do not collide:
+//
+//     int next(void) {                  var next_counter int32 = int32(0)
+//         static int counter = 0;  =>   func next() int32 {
+//         return ++counter;                 ...
+//     }
+//
+// The initializer of a static variable is a constant expression in C, so it
+// can be used for the package variable as it is. The variables are placed
+// right before their function and all the references to them are renamed.
+func hoistStaticVariables(n *ast.TranslationUnitDecl) {
+	names := map[string]bool{}
+	for _, c := range n.Children() {
+		switch v := c.(type) {
+		case *ast.FunctionDecl:
+			names[v.Name] = true
+		case *ast.VarDecl:
+			names[v.Name] = true
+		}
+	}
+
+	renames := map[ast.Address]string{}
+	var children []ast.Node
+	for _, c := range n.Children() {
+		if f, ok := c.(*ast.FunctionDecl); ok {
+			for _, d := range removeStaticVariables(f) {
+				children = append(children, d)
+				v, ok := d.(*ast.VarDecl)
+				if !ok {
+					continue
+				}
+				name := f.Name + "_" + v.Name
+				for i := 2; names[name]; i++ {
+					name = fmt.Sprintf("%s_%s_%d", f.Name, v.Name, i)
+				}
+				names[name] = true
+				renames[v.Addr] = name
+				v.Name = name
+			}
+		}
+		children = append(children, c)
+	}
+	if len(renames) == 0 {
+		return
+	}
+	n.ChildNodes = children
+
+	var rename func(node ast.Node)
+	rename = func(node ast.Node) {
+		if node == nil {
+			return
+		}
+		if ref, ok := node.(*ast.DeclRefExpr); ok && ref.For == "Var" {
+			if name, ok := renames[ast.ParseAddress(ref.Address2)]; ok {
+				ref.Name = name
+			}
+		}
+		for _, c := range node.Children() {
+			rename(c)
+		}
+	}
+	rename(n)
+}
+
+// removeStaticVariables removes the declarations of the static variables from
+// the body of a function and returns them in the order of the source. A
+// declaration of static variables can also declare their struct, like:
+//
+//     static struct { int a; } s;
+//
+// All the declarations of the statement are used, so that the struct stays
+// with the variables. They all have the same storage class.
+func removeStaticVariables(node ast.Node) (decls []ast.Node) {
+	if node == nil {
+		return nil
+	}
+
+	if d, ok := node.(*ast.DeclStmt); ok {
+		for _, c := range d.Children() {
+			if v, ok := c.(*ast.VarDecl); ok && v.IsStatic {
+				decls = d.Children()
+				d.ChildNodes = nil
+				break
+			}
+		}
+		return decls
+	}
+
+	for _, c := range node.Children() {
+		decls = append(decls, removeStaticVariables(c)...)
+	}
+	return decls
+}
@@ -0,0 +1,68 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestHoistStaticVariables(t *testing.T) {
+	// int other_counter = 5;
+	// int next(void) { static int counter = 0; counter++; return counter; }
+	// int other(void) { static int counter = 10; int x = 1; return counter += x; }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x2 <x.c:1:1, col:18> col:5 other_counter 'int' cinit
+| |-IntegerLiteral 0x3 <col:18> 'int' 5
+|-FunctionDecl 0x10 <x.c:1:1, line:5:1> line:1:5 next 'int (void)'
+| |-CompoundStmt 0x11 <col:16, line:5:1>
+|   |-DeclStmt 0x12 <line:2:3, col:25>
+|   | |-VarDecl 0x13 <col:3, col:24> col:14 used counter 'int' static cinit
+|   |   |-IntegerLiteral 0x14 <col:24> 'int' 0
+|   |-UnaryOperator 0x15 <line:3:3, col:10> 'int' postfix '++'
+|   | |-DeclRefExpr 0x16 <col:3> 'int' lvalue Var 0x13 'counter' 'int'
+|   |-ReturnStmt 0x17 <line:4:3, col:10>
+|     |-ImplicitCastExpr 0x18 <col:10> 'int' <LValueToRValue>
+|       |-DeclRefExpr 0x19 <col:10> 'int' lvalue Var 0x13 'counter' 'int'
+|-FunctionDecl 0x20 <line:6:1, line:9:1> line:6:5 other 'int (void)'
+  |-CompoundStmt 0x21 <col:17, line:9:1>
+    |-DeclStmt 0x22 <line:7:3, col:35>
+    | |-VarDecl 0x23 <col:3, col:24> col:14 used counter 'int' static cinit
+    |   |-IntegerLiteral 0x24 <col:24> 'int' 10
+    |-DeclStmt 0x2c <col:27, col:36>
+    | |-VarDecl 0x25 <col:27, col:35> col:31 used x 'int' cinit
+    |   |-IntegerLiteral 0x26 <col:35> 'int' 1
+    |-ReturnStmt 0x27 <line:8:3, col:21>
+      |-CompoundAssignOperator 0x28 <col:10, col:21> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+        |-DeclRefExpr 0x29 <col:10> 'int' lvalue Var 0x23 'counter' 'int'
+        |-ImplicitCastExpr 0x2a <col:21> 'int' <LValueToRValue>
+          |-DeclRefExpr 0x2b <col:21> 'int' lvalue Var 0x25 'x' 'int'
+`
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	for _, want := range []string{`
+var other_counter int32 = int32(5)
+var next_counter int32 = int32(0)
+`, `
+func next() int32 {
+	next_counter += 1
+	return next_counter
+}
+
+var other_counter_2 int32 = int32(10)
+`, `
+func other() int32 {
+	var x int32 = int32(1)
+	return func() int32 {
+		other_counter_2 += x
+		return other_counter_2
+	}()
+}
+`} {
+		if !strings.Contains(output, want[1:]) {
+			t.Errorf("Expected:\n%s\nin:\n%s", want, output)
+		}
+	}
+}
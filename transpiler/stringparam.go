@@ -0,0 +1,289 @@
+// This file contains the parameters of type "const char *" that are
+// transpiled into Go strings. See Program.StringParameters.
+
+package transpiler
+
+import (
+	goast "go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// findStringParameters registers the "const char *" parameters of the
+// functions defined in the C code that are passed as Go strings:
+//
+//     int count(const char *s, char c)   =>   func count(s string, c byte) int32
+//
+// A parameter is only a string when it is used and the function never writes
+// to its characters, like through a cast to "char *", nor returns, stores or
+// tests the pointer for NULL. The parameter itself can still be changed, like
+// with "s++", since it is a copy. A function that is
+// variadic, recursive or used as a function pointer keeps all of its
+// parameters.
+func findStringParameters(p *program.Program, n *ast.TranslationUnitDecl) {
+	if !p.StringParameters {
+		return
+	}
+
+	// A reference to a function that is not called is a function pointer.
+	pointers := map[string]bool{}
+	var walk func(node, parent, grandparent ast.Node)
+	walk = func(node, parent, grandparent ast.Node) {
+		if node == nil {
+			return
+		}
+		if ref, ok := node.(*ast.DeclRefExpr); ok && ref.For == "Function" {
+			call, isCall := grandparent.(*ast.CallExpr)
+			if !isCall || getCalleeName(call) != ref.Name || call.Children()[0] != parent {
+				pointers[ref.Name] = true
+			}
+		}
+		for _, c := range node.Children() {
+			walk(c, node, parent)
+		}
+	}
+	walk(n, nil, nil)
+
+	for _, c := range n.Children() {
+		f, ok := c.(*ast.FunctionDecl)
+		if !ok || f.Name == "main" || types.IsVariadic(f.Type) || pointers[f.Name] {
+			continue
+		}
+		body := getFunctionBody(f)
+		if body == nil || callsFunction(body, f.Name) {
+			continue
+		}
+		if _, ok := p.InlinedFunctions[f.Name]; ok {
+			continue
+		}
+		def := p.GetFunctionDefinition(f.Name)
+		if def == nil || def.Substitution != "" {
+			continue
+		}
+
+		var params []bool
+		found := false
+		for _, c := range f.Children() {
+			if param, ok := c.(*ast.ParmVarDecl); ok {
+				isString := types.CleanCType(param.Type) == "char *" &&
+					isConstPointer(param.Type) && onlyReadsString(body, param.Addr)
+				params = append(params, isString)
+				found = found || isString
+			}
+		}
+		if found && len(params) == len(def.ArgumentTypes) {
+			def.StringParameters = params
+			p.AddFunctionDefinition(*def)
+		}
+	}
+}
+
+// onlyReadsString reports whether a parameter is used at least once and its
+// characters are only read. A cast of the parameter to a pointer that is not
+// const, like "(char *)s", disqualifies it. So does a use of the pointer
+// itself that a copy of the string would change, see escapesString().
+func onlyReadsString(body ast.Node, param ast.Address) bool {
+	uses := 0
+	ok := true
+	var ancestors []ast.Node
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		if node == nil || !ok {
+			return
+		}
+		if ref, isRef := node.(*ast.DeclRefExpr); isRef && ref.For == "ParmVar" &&
+			ast.ParseAddress(ref.Address2) == param {
+			parent := ancestors[len(ancestors)-1]
+			if dropsConst(parent) {
+				ok = false
+			}
+			if read, isRead := parent.(*ast.ImplicitCastExpr); isRead &&
+				read.Kind == "LValueToRValue" && len(ancestors) > 1 &&
+				dropsConst(ancestors[len(ancestors)-2]) {
+				ok = false
+			}
+			if escapesString(append(ancestors, node), param) {
+				ok = false
+			}
+			uses++
+		}
+		ancestors = append(ancestors, node)
+		for _, c := range node.Children() {
+			walk(c)
+		}
+		ancestors = ancestors[:len(ancestors)-1]
+	}
+	walk(body)
+
+	return ok && uses > 0
+}
+
+// escapesString reports whether the pointer of a string parameter is used for
+// more than the characters, from the last of the nodes up to the body. The
+// argument is a copy of the string, and a NULL argument is an empty string, so
+// the pointer must not be:
+//
+//     return s;                  returned,
+//     t = s; p->name = s + 1;    assigned or stored,
+//     if (!s || s == NULL)       or compared with NULL, like the
+//     while (s) and s ? s : ""   conditions do.
+//
+// A change of the parameter itself, like "s = s + 1", is allowed.
+func escapesString(nodes []ast.Node, param ast.Address) bool {
+	for i := len(nodes) - 2; i >= 0; i-- {
+		child := nodes[i+1]
+		switch v := nodes[i].(type) {
+		case *ast.ImplicitCastExpr:
+			if v.Kind == "PointerToBoolean" {
+				return true
+			}
+		case *ast.ParenExpr, *ast.CStyleCastExpr:
+		case *ast.ReturnStmt, *ast.VarDecl, *ast.InitListExpr:
+			return true
+		case *ast.IfStmt, *ast.WhileStmt, *ast.DoStmt, *ast.ForStmt,
+			*ast.BinaryConditionalOperator:
+			return true
+		case *ast.UnaryOperator:
+			return v.Operator == "!"
+		case *ast.ConditionalOperator:
+			if child == v.Children()[0] {
+				return true
+			}
+		case *ast.BinaryOperator:
+			switch v.Operator {
+			case "=":
+				if child == v.Children()[0] {
+					return false
+				}
+				ref, isRef := v.Children()[0].(*ast.DeclRefExpr)
+				return !isRef || ast.ParseAddress(ref.Address2) != param
+			case "&&", "||":
+				return true
+			case "==", "!=":
+				other := v.Children()[0]
+				if other == child {
+					other = v.Children()[1]
+				}
+				return isNullPointerConstant(other)
+			case "+", "-":
+				if !strings.Contains(v.Type, "*") {
+					return false
+				}
+			case ",":
+				if child == v.Children()[0] {
+					return false
+				}
+			default:
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// isNullPointerConstant reports whether an expression is NULL, like "0" or
+// "(void *)0".
+func isNullPointerConstant(n ast.Node) bool {
+	for {
+		switch v := n.(type) {
+		case *ast.ImplicitCastExpr, *ast.CStyleCastExpr, *ast.ParenExpr:
+			n = v.Children()[0]
+			continue
+		case *ast.IntegerLiteral:
+			return v.Value == "0"
+		}
+		return false
+	}
+}
+
+// dropsConst reports whether a node is a cast to a pointer that is not const.
+func dropsConst(n ast.Node) bool {
+	var cType string
+	switch v := n.(type) {
+	case *ast.ImplicitCastExpr:
+		cType = v.Type
+	case *ast.CStyleCastExpr:
+		cType = v.Type
+	default:
+		return false
+	}
+	return strings.Contains(cType, "*") && !isConstPointer(cType)
+}
+
+// isConstPointer reports whether a C type is a pointer to a const type, like
+// "const char *" or "char const *". CleanCType() removes the qualifiers, so the
+// type that is given must not be cleaned.
+func isConstPointer(cType string) bool {
+	i := strings.Index(cType, "*")
+	return i >= 0 && strings.Contains(cType[:i], "const")
+}
+
+// transpileStringArgument returns the Go string that is passed for a string
+// parameter. A string literal is a Go string literal and any other C string is
+// converted with noarch.CStringToString().
+func transpileStringArgument(p *program.Program, arg ast.Node,
+	expr goast.Expr, exprType string) (goast.Expr, error) {
+	if s, ok := removeImplicitCasts(arg).(*ast.StringLiteral); ok && s.Prefix == "" {
+		value := s.Value
+		if i := strings.IndexByte(value, 0); i >= 0 {
+			value = value[:i]
+		}
+		return util.NewStringLit(strconv.Quote(value)), nil
+	}
+
+	expr, err := types.CastExpr(p, expr, exprType, "const char *")
+	if err != nil {
+		return nil, err
+	}
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	return util.NewCallExpr("noarch.CStringToString", expr), nil
+}
+
+// bridgeStringParameters copies the string parameters of a function into C
+// strings with the same names at the start of its body, so that the body is
+// transpiled like with the *byte parameters:
+//
+//     func count(s string, c byte) int32 {
+//         {
+//             s := noarch.StringToCString(s)
+//             ...
+//         }
+//     }
+//
+// The body is moved into a block because a parameter cannot be declared again
+// in the outer block of the function.
+func bridgeStringParameters(p *program.Program, n *ast.FunctionDecl,
+	f *program.FunctionDefinition, body *goast.BlockStmt) {
+	var stmts []goast.Stmt
+	i := 0
+	for _, c := range n.Children() {
+		param, ok := c.(*ast.ParmVarDecl)
+		if !ok {
+			continue
+		}
+		if i < len(f.StringParameters) && f.StringParameters[i] {
+			name := p.GoIdentifier(param.Name)
+			stmts = append(stmts, &goast.AssignStmt{
+				Lhs: []goast.Expr{util.NewIdent(name)},
+				Tok: token.DEFINE,
+				Rhs: []goast.Expr{util.NewCallExpr("noarch.StringToCString",
+					util.NewIdent(name))},
+			})
+		}
+		i++
+	}
+	if len(stmts) == 0 {
+		return
+	}
+
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	body.List = []goast.Stmt{&goast.BlockStmt{List: append(stmts, body.List...)}}
+}
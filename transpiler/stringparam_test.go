@@ -0,0 +1,196 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestStringParameters(t *testing.T) {
+	// int first(const char *s) { return s[0]; }
+	// int upper(const char *s) { char *t = (char *)s; return t[0]; }
+	// int g(char *buf) { return first("hi") + first(buf) + upper("x"); }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:42> col:5 used first 'int (const char *)'
+| |-ParmVarDecl 0x11 <col:11, col:23> col:23 used s 'const char *'
+| |-CompoundStmt 0x12 <col:26, col:42>
+|   |-ReturnStmt 0x13 <col:28, col:39>
+|     |-ImplicitCastExpr 0x14 <col:35, col:38> 'int' <IntegralCast>
+|       |-ImplicitCastExpr 0x15 <col:35, col:38> 'char' <LValueToRValue>
+|         |-ArraySubscriptExpr 0x16 <col:35, col:38> 'const char' lvalue
+|           |-ImplicitCastExpr 0x17 <col:35> 'const char *' <LValueToRValue>
+|           | |-DeclRefExpr 0x18 <col:35> 'const char *' lvalue ParmVar 0x11 's' 'const char *'
+|           |-IntegerLiteral 0x19 <col:37> 'int' 0
+|-FunctionDecl 0x20 <line:2:1, col:62> col:5 used upper 'int (const char *)'
+| |-ParmVarDecl 0x21 <col:11, col:23> col:23 used s 'const char *'
+| |-CompoundStmt 0x22 <col:26, col:62>
+|   |-DeclStmt 0x23 <col:28, col:47>
+|   | |-VarDecl 0x24 <col:28, col:46> col:34 used t 'char *' cinit
+|   |   |-CStyleCastExpr 0x25 <col:38, col:46> 'char *' <NoOp>
+|   |     |-ImplicitCastExpr 0x26 <col:46> 'const char *' <LValueToRValue>
+|   |       |-DeclRefExpr 0x27 <col:46> 'const char *' lvalue ParmVar 0x21 's' 'const char *'
+|   |-ReturnStmt 0x28 <col:49, col:60>
+|     |-ImplicitCastExpr 0x29 <col:56, col:59> 'int' <IntegralCast>
+|       |-ImplicitCastExpr 0x2a <col:56, col:59> 'char' <LValueToRValue>
+|         |-ArraySubscriptExpr 0x2b <col:56, col:59> 'char' lvalue
+|           |-ImplicitCastExpr 0x2c <col:56> 'char *' <LValueToRValue>
+|           | |-DeclRefExpr 0x2d <col:56> 'char *' lvalue Var 0x24 't' 'char *'
+|           |-IntegerLiteral 0x2e <col:58> 'int' 0
+|-FunctionDecl 0x30 <line:3:1, col:68> col:5 g 'int (char *)'
+  |-ParmVarDecl 0x31 <col:7, col:13> col:13 used buf 'char *'
+  |-CompoundStmt 0x32 <col:18, col:68>
+    |-ReturnStmt 0x33 <col:20, col:65>
+      |-BinaryOperator 0x34 <col:27, col:65> 'int' '+'
+        |-BinaryOperator 0x35 <col:27, col:52> 'int' '+'
+        | |-CallExpr 0x36 <col:27, col:37> 'int'
+        | | |-ImplicitCastExpr 0x37 <col:27> 'int (*)(const char *)' <FunctionToPointerDecay>
+        | | | |-DeclRefExpr 0x38 <col:27> 'int (const char *)' Function 0x10 'first' 'int (const char *)'
+        | | |-ImplicitCastExpr 0x39 <col:33> 'const char *' <NoOp>
+        | |   |-ImplicitCastExpr 0x3a <col:33> 'char *' <ArrayToPointerDecay>
+        | |     |-StringLiteral 0x3b <col:33> 'char [3]' lvalue "hi"
+        | |-CallExpr 0x40 <col:41, col:50> 'int'
+        |   |-ImplicitCastExpr 0x41 <col:41> 'int (*)(const char *)' <FunctionToPointerDecay>
+        |   | |-DeclRefExpr 0x42 <col:41> 'int (const char *)' Function 0x10 'first' 'int (const char *)'
+        |   |-ImplicitCastExpr 0x43 <col:47> 'const char *' <NoOp>
+        |     |-ImplicitCastExpr 0x44 <col:47> 'char *' <LValueToRValue>
+        |       |-DeclRefExpr 0x45 <col:47> 'char *' lvalue ParmVar 0x31 'buf' 'char *'
+        |-CallExpr 0x50 <col:56, col:65> 'int'
+          |-ImplicitCastExpr 0x51 <col:56> 'int (*)(const char *)' <FunctionToPointerDecay>
+          | |-DeclRefExpr 0x52 <col:56> 'int (const char *)' Function 0x20 'upper' 'int (const char *)'
+          |-ImplicitCastExpr 0x53 <col:62> 'const char *' <NoOp>
+            |-ImplicitCastExpr 0x54 <col:62> 'char *' <ArrayToPointerDecay>
+              |-StringLiteral 0x55 <col:62> 'char [2]' lvalue "x"
+`
+
+	for _, stringParameters := range []bool{false, true} {
+		p := program.NewProgram()
+		p.StringParameters = stringParameters
+		output := transpileDump(t, p, dump)
+
+		wants := []string{
+			"func first(s *byte) int32 {",
+			// upper() writes to the characters through a cast.
+			"func upper(s *byte) int32 {",
+		}
+		if stringParameters {
+			wants = []string{
+				"func first(s string) int32 {",
+				"s := noarch.StringToCString(s)",
+				`first("hi")`,
+				"first(noarch.CStringToString(buf))",
+				"func upper(s *byte) int32 {",
+			}
+		}
+		for _, want := range wants {
+			if !strings.Contains(output, want) {
+				t.Errorf("StringParameters = %v: expected %q in:\n%s", stringParameters, want, output)
+			}
+		}
+	}
+}
+
+func TestStringParametersPointer(t *testing.T) {
+	// const char *ret(const char *s) { return s; }
+	// void store(const char *s) { const char *t = s; }
+	// void assign(const char *s) { const char *t; t = s + 1; }
+	// int isnull(const char *s) { return s == 0; }
+	// int negate(const char *s) { return !s; }
+	// int test(const char *s) { return s ? s[0] : 0; }
+	// int skip(const char *s) { s = s + 1; return s[0]; }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:44> col:13 ret 'const char *(const char *)'
+| |-ParmVarDecl 0x11 <col:17, col:29> col:29 used s 'const char *'
+| |-CompoundStmt 0x12 <col:32, col:44>
+|   |-ReturnStmt 0x13 <col:34, col:41>
+|     |-ImplicitCastExpr 0x14 <col:41> 'const char *' <LValueToRValue>
+|       |-DeclRefExpr 0x15 <col:41> 'const char *' lvalue ParmVar 0x11 's' 'const char *'
+|-FunctionDecl 0x20 <line:2:1, col:48> col:6 store 'void (const char *)'
+| |-ParmVarDecl 0x21 <col:12, col:24> col:24 used s 'const char *'
+| |-CompoundStmt 0x22 <col:27, col:48>
+|   |-DeclStmt 0x23 <col:29, col:46>
+|     |-VarDecl 0x24 <col:29, col:45> col:41 t 'const char *' cinit
+|       |-ImplicitCastExpr 0x25 <col:45> 'const char *' <LValueToRValue>
+|         |-DeclRefExpr 0x26 <col:45> 'const char *' lvalue ParmVar 0x21 's' 'const char *'
+|-FunctionDecl 0x30 <line:3:1, col:56> col:6 assign 'void (const char *)'
+| |-ParmVarDecl 0x31 <col:13, col:25> col:25 used s 'const char *'
+| |-CompoundStmt 0x32 <col:28, col:56>
+|   |-DeclStmt 0x33 <col:30, col:44>
+|   | |-VarDecl 0x34 <col:30, col:42> col:42 used t 'const char *'
+|   |-BinaryOperator 0x35 <col:46, col:54> 'const char *' '='
+|     |-DeclRefExpr 0x36 <col:46> 'const char *' lvalue Var 0x34 't' 'const char *'
+|     |-BinaryOperator 0x37 <col:50, col:54> 'const char *' '+'
+|       |-ImplicitCastExpr 0x38 <col:50> 'const char *' <LValueToRValue>
+|       | |-DeclRefExpr 0x39 <col:50> 'const char *' lvalue ParmVar 0x31 's' 'const char *'
+|       |-IntegerLiteral 0x3a <col:54> 'int' 1
+|-FunctionDecl 0x40 <line:4:1, col:43> col:5 isnull 'int (const char *)'
+| |-ParmVarDecl 0x41 <col:12, col:24> col:24 used s 'const char *'
+| |-CompoundStmt 0x42 <col:27, col:43>
+|   |-ReturnStmt 0x43 <col:29, col:40>
+|     |-BinaryOperator 0x44 <col:36, col:41> 'int' '=='
+|       |-ImplicitCastExpr 0x45 <col:36> 'const char *' <LValueToRValue>
+|       | |-DeclRefExpr 0x46 <col:36> 'const char *' lvalue ParmVar 0x41 's' 'const char *'
+|       |-ImplicitCastExpr 0x47 <col:41> 'const char *' <NullToPointer>
+|         |-IntegerLiteral 0x48 <col:41> 'int' 0
+|-FunctionDecl 0x50 <line:5:1, col:40> col:5 negate 'int (const char *)'
+| |-ParmVarDecl 0x51 <col:12, col:24> col:24 used s 'const char *'
+| |-CompoundStmt 0x52 <col:27, col:40>
+|   |-ReturnStmt 0x53 <col:29, col:37>
+|     |-UnaryOperator 0x54 <col:36, col:37> 'int' prefix '!'
+|       |-ImplicitCastExpr 0x55 <col:37> 'const char *' <LValueToRValue>
+|         |-DeclRefExpr 0x56 <col:37> 'const char *' lvalue ParmVar 0x51 's' 'const char *'
+|-FunctionDecl 0x60 <line:6:1, col:49> col:5 test 'int (const char *)'
+| |-ParmVarDecl 0x61 <col:10, col:22> col:22 used s 'const char *'
+| |-CompoundStmt 0x62 <col:25, col:49>
+|   |-ReturnStmt 0x63 <col:27, col:46>
+|     |-ConditionalOperator 0x64 <col:34, col:46> 'int'
+|       |-ImplicitCastExpr 0x65 <col:34> 'const char *' <LValueToRValue>
+|       | |-DeclRefExpr 0x66 <col:34> 'const char *' lvalue ParmVar 0x61 's' 'const char *'
+|       |-ImplicitCastExpr 0x67 <col:38, col:41> 'int' <IntegralCast>
+|       | |-ImplicitCastExpr 0x68 <col:38, col:41> 'char' <LValueToRValue>
+|       |   |-ArraySubscriptExpr 0x69 <col:38, col:41> 'const char' lvalue
+|       |     |-ImplicitCastExpr 0x6a <col:38> 'const char *' <LValueToRValue>
+|       |     | |-DeclRefExpr 0x6b <col:38> 'const char *' lvalue ParmVar 0x61 's' 'const char *'
+|       |     |-IntegerLiteral 0x6c <col:40> 'int' 0
+|       |-IntegerLiteral 0x6d <col:46> 'int' 0
+|-FunctionDecl 0x70 <line:7:1, col:52> col:5 skip 'int (const char *)'
+  |-ParmVarDecl 0x71 <col:10, col:22> col:22 used s 'const char *'
+  |-CompoundStmt 0x72 <col:25, col:52>
+    |-BinaryOperator 0x73 <col:27, col:35> 'const char *' '='
+    | |-DeclRefExpr 0x74 <col:27> 'const char *' lvalue ParmVar 0x71 's' 'const char *'
+    | |-BinaryOperator 0x75 <col:31, col:35> 'const char *' '+'
+    |   |-ImplicitCastExpr 0x76 <col:31> 'const char *' <LValueToRValue>
+    |   | |-DeclRefExpr 0x77 <col:31> 'const char *' lvalue ParmVar 0x71 's' 'const char *'
+    |   |-IntegerLiteral 0x78 <col:35> 'int' 1
+    |-ReturnStmt 0x79 <col:38, col:49>
+      |-ImplicitCastExpr 0x7a <col:45, col:48> 'int' <IntegralCast>
+        |-ImplicitCastExpr 0x7b <col:45, col:48> 'char' <LValueToRValue>
+          |-ArraySubscriptExpr 0x7c <col:45, col:48> 'const char' lvalue
+            |-ImplicitCastExpr 0x7d <col:45> 'const char *' <LValueToRValue>
+            | |-DeclRefExpr 0x7e <col:45> 'const char *' lvalue ParmVar 0x71 's' 'const char *'
+            |-IntegerLiteral 0x7f <col:47> 'int' 0
+`
+
+	p := program.NewProgram()
+	p.StringParameters = true
+	output := transpileDump(t, p, dump)
+
+	for _, want := range []string{
+		// A copy of the string must not be returned or stored.
+		"func ret(s *byte) *byte {",
+		"func store(s *byte) {",
+		"func assign(s *byte) {",
+		// A NULL argument would become an empty string.
+		"func isnull(s *byte) int32 {",
+		"func negate(s *byte) int32 {",
+		"func test(s *byte) int32 {",
+		// Only the copy itself is changed.
+		"func skip(s string) int32 {",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/elliotchance/c2go/ast"
 	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
 	"github.com/elliotchance/c2go/util"
 	"golang.org/x/tools/go/ast/astutil"
 )
@@ -33,11 +34,23 @@ func transpileSwitchStmt(n *ast.SwitchStmt, p *program.Program) (
 
 	// The condition is the expression to be evaluated against each of the
 	// cases.
-	condition, _, newPre, newPost, err := transpileToExpr(n.Children()[len(n.Children())-2], p, false)
+	condition, conditionType, newPre, newPost, err := transpileToExpr(n.Children()[len(n.Children())-2], p, false)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
+	// The post statements of the condition, like the increment of
+	// "switch (i++)", are done before the cases.
+	if len(newPost) > 0 {
+		var goType string
+		goType, err = types.ResolveType(p, conditionType)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		condition = util.NewAnonymousFunction(nil, newPost, condition, goType)
+		newPost = nil
+	}
+
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
 	// separation body of switch on cases
@@ -49,9 +62,9 @@ func transpileSwitchStmt(n *ast.SwitchStmt, p *program.Program) (
 	bodyLen := len(body.Children())
 	for i := 0; i < bodyLen; i++ {
 		cn := body.ChildNodes[i]
-		cs, ok1 := cn.(*ast.CaseStmt)
-		ds, ok2 := cn.(*ast.DefaultStmt)
-		ls, ok3 := cn.(*ast.LabelStmt)
+		_, ok1 := cn.(*ast.CaseStmt)
+		_, ok2 := cn.(*ast.DefaultStmt)
+		_, ok3 := cn.(*ast.LabelStmt)
 		if !ok1 && !ok2 && !ok3 || cn == nil || len(cn.Children()) == 0 {
 			// Do not consider a node which is not a case, label or default statement here
 			continue
@@ -67,22 +80,11 @@ func transpileSwitchStmt(n *ast.SwitchStmt, p *program.Program) (
 			body.ChildNodes[i+1] = lastCn
 			bodyLen++
 
-			if len(cn.Children()) == 1 {
-				// If cn child nodes would be empty without lastCn,
-				// replace lastCn by an empty CompoundStmt
-				cn.Children()[0] = &ast.CompoundStmt{}
-			} else {
-				// Remove lastCn from cn child nodes
-				if ok1 {
-					cs.ChildNodes = cs.ChildNodes[:len(cs.ChildNodes)-1]
-				}
-				if ok2 {
-					ds.ChildNodes = ds.ChildNodes[:len(ds.ChildNodes)-1]
-				}
-				if ok3 {
-					ls.ChildNodes = ls.ChildNodes[:len(ls.ChildNodes)-1]
-				}
-			}
+			// lastCn is the body of cn, it is replaced by an empty
+			// CompoundStmt. The value of a case must not be replaced, it is
+			// the child before the body. Older versions of clang also have
+			// a <<<NULL>>> child between them for the end of a case range.
+			cn.Children()[len(cn.Children())-1] = &ast.CompoundStmt{}
 		}
 	}
 
@@ -159,58 +161,27 @@ func transpileSwitchStmt(n *ast.SwitchStmt, p *program.Program) (
 
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
-	// For simplification switch case:
+	// A fallthrough is appended to each case that is followed by another one.
+	// It is removed again from the cases that end with a break or a return:
 	// from:
 	// case 3:
 	// 	{
 	// 		var c int
-	// 		return
+	// 		break
 	// 	}
 	// 	fallthrough
 	// to:
 	// case 3:
-	// 	var c int
-	// 	return
+	// 	{
+	// 		var c int
+	// 	}
 	//
 	for i := range cases {
-		cs, ok := cases[i].(*goast.CaseClause)
-		if !ok {
-			continue
-		}
-		body := cs.Body
-		if len(body) != 2 {
-			continue
-		}
-		var isFallThrough bool
-		if v, ok := body[1].(*goast.BranchStmt); ok {
-			isFallThrough = (v.Tok == token.FALLTHROUGH)
-		}
-		if !isFallThrough {
-			if len(body) > 1 {
-				cs.Body = body
-			}
-			continue
-		}
-		if v, ok := body[0].(*goast.BlockStmt); ok {
-			if len(v.List) > 0 {
-				if vv, ok := v.List[len(v.List)-1].(*goast.BranchStmt); ok {
-					if vv.Tok == token.BREAK {
-						if isFallThrough {
-							v.List = v.List[:len(v.List)-1]
-							cs.Body = body[:len(body)-1]
-							continue
-						}
-					}
-				}
-				if _, ok := v.List[len(v.List)-1].(*goast.ReturnStmt); ok {
-					cs.Body = body[:len(body)-1]
-					continue
-				}
-			} else {
-				cs.Body = []goast.Stmt{body[1]}
-			}
+		if cs, ok := cases[i].(*goast.CaseClause); ok {
+			removeFallthroughAfterBreak(cs)
 		}
 	}
+	cases = mergeStackedCases(cases)
 
 	// Convert the normalized cases back into statements so they can be children
 	// of goast.SwitchStmt.
@@ -236,6 +207,70 @@ func transpileSwitchStmt(n *ast.SwitchStmt, p *program.Program) (
 	}, preStmts, postStmts, nil
 }
 
+// removeFallthroughAfterBreak removes the fallthrough at the end of the case,
+// if the statement before it is a break or a return. The break is removed too.
+// The statement may also be the last one of a block, that is how the body of
+// the case is transpiled.
+func removeFallthroughAfterBreak(cs *goast.CaseClause) {
+	if len(cs.Body) < 2 || !isFallthrough(cs.Body[len(cs.Body)-1]) {
+		return
+	}
+	body := cs.Body[:len(cs.Body)-1]
+
+	stmts := &body
+	if b, ok := body[len(body)-1].(*goast.BlockStmt); ok {
+		if len(b.List) == 0 {
+			// Only an empty block falls through.
+			if len(body) == 1 {
+				cs.Body = cs.Body[1:]
+			}
+			return
+		}
+		stmts = &b.List
+	}
+
+	switch v := (*stmts)[len(*stmts)-1].(type) {
+	case *goast.BranchStmt:
+		if v.Tok != token.BREAK {
+			return
+		}
+		*stmts = (*stmts)[:len(*stmts)-1]
+	case *goast.ReturnStmt:
+	default:
+		return
+	}
+	cs.Body = body
+}
+
+// mergeStackedCases merges each case that only falls through into the next
+// case with that case. For example:
+//
+//     case 1:                 case 1:                 case 1, 2:
+//     case 2:          =>         fallthrough    =>       foo()
+//         foo();              case 2:
+//                                 foo()
+//
+// A case cannot be merged with a default.
+func mergeStackedCases(cases []goast.Stmt) (merged []goast.Stmt) {
+	for i, c := range cases {
+		cs, ok := c.(*goast.CaseClause)
+		if ok && cs.List != nil && len(cs.Body) == 1 && isFallthrough(cs.Body[0]) &&
+			i+1 < len(cases) {
+			if next, ok := cases[i+1].(*goast.CaseClause); ok && next.List != nil {
+				next.List = append(cs.List, next.List...)
+				continue
+			}
+		}
+		merged = append(merged, c)
+	}
+	return
+}
+
+func isFallthrough(stmt goast.Stmt) bool {
+	b, ok := stmt.(*goast.BranchStmt)
+	return ok && b.Tok == token.FALLTHROUGH
+}
+
 func normalizeSwitchCases(body *ast.CompoundStmt, p *program.Program) (
 	_ []goast.Stmt, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
 	// The body of a switch has a non uniform structure. For example:
@@ -393,13 +428,25 @@ func transpileCaseStmt(n *ast.CaseStmt, p *program.Program) (
 	preStmts := []goast.Stmt{}
 	postStmts := []goast.Stmt{}
 
-	c, _, newPre, newPost, err := transpileToExpr(n.Children()[0], p, false)
+	c, cType, newPre, newPost, err := transpileToExpr(n.Children()[0], p, false)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
+	// The folded value is converted like a constant of C, so that a negative
+	// label of an unsigned switch, like "case -1", wraps around.
+	if value, ok := foldCaseValue(p, n.Children()[0]); ok {
+		if goType, err := types.ResolveType(p, cType); err == nil &&
+			types.IsGoIntegerType(goType) {
+			c, err = types.CastExpr(p, util.NewIntLit(int(value)), "long long", cType)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
 	stmts, err := transpileStmts(n.Children()[1:], p)
 	if err != nil {
 		return nil, nil, nil, err
@@ -411,6 +458,26 @@ func transpileCaseStmt(n *ast.CaseStmt, p *program.Program) (
 	}, preStmts, postStmts, nil
 }
 
+// foldCaseValue returns the value of a case label that is an expression of
+// constants, like "1 << 4", "RED + 1" or "-1". The literals and the names of
+// the enum constants are kept as they are, except the negative character
+// constants like '\xff', since they are not a valid Go rune.
+func foldCaseValue(p *program.Program, n ast.Node) (int64, bool) {
+	if c, ok := n.(*ast.ConstantExpr); ok && len(c.Children()) > 0 {
+		n = c.Children()[0]
+	}
+	switch v := removeImplicitCasts(n).(type) {
+	case *ast.CharacterLiteral:
+		if value := characterValue(p, v); value < 0 {
+			return value, true
+		}
+		return 0, false
+	case *ast.IntegerLiteral, *ast.DeclRefExpr:
+		return 0, false
+	}
+	return constantValue(p, n)
+}
+
 func transpileDefaultStmt(n *ast.DefaultStmt, p *program.Program) (*goast.CaseClause, error) {
 	stmts, err := transpileStmts(n.Children()[0:], p)
 	if err != nil {
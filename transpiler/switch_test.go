@@ -0,0 +1,320 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestSwitchFallthrough(t *testing.T) {
+	// int f(int x) {
+	//     int r = 0;
+	//     switch (x) {
+	//     case 1:
+	//         r += 1;
+	//     case 2:
+	//         r += 10;
+	//     case 3:
+	//         r += 100;
+	//         break;
+	//     default:
+	//         r = 9;
+	//     case 4:
+	//     case 5:
+	//         r += 1000;
+	//     }
+	//     return r;
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:17:1> line:1:5 f 'int (int)'
+|-ParmVarDecl 0x11 <col:7, col:11> col:11 used x 'int'
+|-CompoundStmt 0x12 <col:14, line:17:1>
+  |-DeclStmt 0x13 <line:2:5, col:14>
+  | |-VarDecl 0x14 <col:5, col:13> col:9 used r 'int' cinit
+  |   |-IntegerLiteral 0x15 <col:13> 'int' 0
+  |-SwitchStmt 0x16 <line:3:5, line:15:5>
+  | |-ImplicitCastExpr 0x17 <line:3:13> 'int' <LValueToRValue>
+  | | |-DeclRefExpr 0x18 <col:13> 'int' lvalue ParmVar 0x11 'x' 'int'
+  | |-CompoundStmt 0x19 <col:16, line:15:5>
+  |   |-CaseStmt 0x20 <line:4:5, line:5:14>
+  |   | |-IntegerLiteral 0x21 <line:4:10> 'int' 1
+  |   | |-NullStmt
+  |   | |-CompoundAssignOperator 0x22 <line:5:9, col:14> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  |   |   |-DeclRefExpr 0x23 <col:9> 'int' lvalue Var 0x14 'r' 'int'
+  |   |   |-IntegerLiteral 0x24 <col:14> 'int' 1
+  |   |-CaseStmt 0x30 <line:6:5, line:7:14>
+  |   | |-IntegerLiteral 0x31 <line:6:10> 'int' 2
+  |   | |-NullStmt
+  |   | |-CompoundAssignOperator 0x32 <line:7:9, col:14> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  |   |   |-DeclRefExpr 0x33 <col:9> 'int' lvalue Var 0x14 'r' 'int'
+  |   |   |-IntegerLiteral 0x34 <col:14> 'int' 10
+  |   |-CaseStmt 0x40 <line:8:5, line:9:14>
+  |   | |-IntegerLiteral 0x41 <line:8:10> 'int' 3
+  |   | |-NullStmt
+  |   | |-CompoundAssignOperator 0x42 <line:9:9, col:14> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  |   |   |-DeclRefExpr 0x43 <col:9> 'int' lvalue Var 0x14 'r' 'int'
+  |   |   |-IntegerLiteral 0x44 <col:14> 'int' 100
+  |   |-BreakStmt 0x45 <line:10:9>
+  |   |-DefaultStmt 0x50 <line:11:5, line:12:13>
+  |   | |-BinaryOperator 0x51 <line:12:9, col:13> 'int' '='
+  |   |   |-DeclRefExpr 0x52 <col:9> 'int' lvalue Var 0x14 'r' 'int'
+  |   |   |-IntegerLiteral 0x53 <col:13> 'int' 9
+  |   |-CaseStmt 0x60 <line:13:5, line:14:14>
+  |     |-IntegerLiteral 0x61 <line:13:10> 'int' 4
+  |     |-NullStmt
+  |     |-CaseStmt 0x62 <line:14:5, col:14>
+  |       |-IntegerLiteral 0x63 <col:10> 'int' 5
+  |       |-NullStmt
+  |       |-CompoundAssignOperator 0x64 <line:14:9, col:14> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+  |         |-DeclRefExpr 0x65 <col:9> 'int' lvalue Var 0x14 'r' 'int'
+  |         |-IntegerLiteral 0x66 <col:14> 'int' 1000
+  |-ReturnStmt 0x70 <line:16:5, col:12>
+    |-ImplicitCastExpr 0x71 <col:12> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x72 <col:12> 'int' lvalue Var 0x14 'r' 'int'
+`
+
+	want := `
+	switch x {
+	case int32(1):
+		{
+			r += int32(1)
+		}
+		fallthrough
+	case int32(2):
+		{
+			r += int32(10)
+		}
+		fallthrough
+	case int32(3):
+		{
+			r += int32(100)
+		}
+	default:
+		{
+			r = int32(9)
+		}
+		fallthrough
+	case int32(4), int32(5):
+		{
+			r += int32(1000)
+		}
+	}
+`
+
+	// Older versions of clang have a <<<NULL>>> child in each case, for the
+	// end of a case range.
+	withoutNull := regexp.MustCompile(`(?m)^.*NullStmt\n`).ReplaceAllString(dump, "")
+	for _, d := range []string{dump, withoutNull} {
+		p := program.NewProgram()
+		decls, err := transpileToNode(parseTree(d), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), want[1:]) {
+			t.Errorf("Expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestFoldConstantExpressions(t *testing.T) {
+	// enum size { SMALL, LARGE = 4, HUGE };
+	//
+	// int f(int x) {
+	//     int a[2 + 3];
+	//     int b[x + LARGE];
+	//     switch (x) {
+	//     case 1 << 4:
+	//         return 1;
+	//     case LARGE + 1:
+	//         return 2;
+	//     case SMALL:
+	//         return 3;
+	//     case HUGE * 2:
+	//         return 4;
+	//     }
+	//     return 0;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-EnumDecl 0x2 <x.c:1:1, col:34> col:6 size
+| |-EnumConstantDecl 0x3 <col:13> col:13 referenced SMALL 'int'
+| |-EnumConstantDecl 0x4 <col:20, col:26> col:20 referenced LARGE 'int'
+| | |-ConstantExpr 0x5 <col:26> 'int'
+| |   |-IntegerLiteral 0x6 <col:26> 'int' 4
+| |-EnumConstantDecl 0x7 <col:29> col:29 referenced HUGE 'int'
+|-FunctionDecl 0x10 <line:3:1, line:15:1> line:3:5 f 'int (int)'
+  |-ParmVarDecl 0x11 <col:7, col:11> col:11 used x 'int'
+  |-CompoundStmt 0x12 <col:14, line:15:1>
+    |-DeclStmt 0x13 <line:4:5, col:17>
+    | |-VarDecl 0x14 <col:5, col:16> col:9 a 'int [5]'
+    |-DeclStmt 0x15 <line:5:5, col:21>
+    | |-VarDecl 0x16 <col:5, col:20> col:9 b 'int [x + LARGE]'
+    |-SwitchStmt 0x17 <line:6:5, line:13:5>
+    | |-ImplicitCastExpr 0x18 <line:6:13> 'int' <LValueToRValue>
+    | | |-DeclRefExpr 0x19 <col:13> 'int' lvalue ParmVar 0x11 'x' 'int'
+    | |-CompoundStmt 0x1a <col:16, line:13:5>
+    |   |-CaseStmt 0x20 <line:7:5, line:8:16>
+    |   | |-ConstantExpr 0x21 <line:7:10, col:15> 'int'
+    |   | | |-BinaryOperator 0x22 <col:10, col:15> 'int' '<<'
+    |   | |   |-IntegerLiteral 0x23 <col:10> 'int' 1
+    |   | |   |-IntegerLiteral 0x24 <col:15> 'int' 4
+    |   | |-ReturnStmt 0x25 <line:8:9, col:16>
+    |   |   |-IntegerLiteral 0x26 <col:16> 'int' 1
+    |   |-CaseStmt 0x30 <line:9:5, line:10:16>
+    |   | |-ConstantExpr 0x31 <line:9:10, col:18> 'int'
+    |   | | |-BinaryOperator 0x32 <col:10, col:18> 'int' '+'
+    |   | |   |-DeclRefExpr 0x33 <col:10> 'int' EnumConstant 0x4 'LARGE' 'int'
+    |   | |   |-IntegerLiteral 0x34 <col:18> 'int' 1
+    |   | |-ReturnStmt 0x35 <line:10:9, col:16>
+    |   |   |-IntegerLiteral 0x36 <col:16> 'int' 2
+    |   |-CaseStmt 0x40 <line:11:5, line:12:16>
+    |   | |-ConstantExpr 0x41 <line:11:10> 'int'
+    |   | | |-DeclRefExpr 0x42 <col:10> 'int' EnumConstant 0x3 'SMALL' 'int'
+    |   | |-ReturnStmt 0x43 <line:12:9, col:16>
+    |   |   |-IntegerLiteral 0x44 <col:16> 'int' 3
+    |   |-CaseStmt 0x45 <line:13:5, line:14:16>
+    |     |-ConstantExpr 0x46 <line:13:10, col:17> 'int'
+    |     | |-BinaryOperator 0x47 <col:10, col:17> 'int' '*'
+    |     |   |-DeclRefExpr 0x48 <col:10> 'int' EnumConstant 0x7 'HUGE' 'int'
+    |     |   |-IntegerLiteral 0x49 <col:17> 'int' 2
+    |     |-ReturnStmt 0x4a <line:14:9, col:16>
+    |       |-IntegerLiteral 0x4b <col:16> 'int' 4
+    |-ReturnStmt 0x50 <line:14:5, col:12>
+      |-IntegerLiteral 0x51 <col:12> 'int' 0
+`
+	tree := parseTree(dump)
+	p := program.NewProgram()
+	p.SetNodes([]ast.Node{tree})
+	if err := TranspileAST("x.c", "main", p, tree); err != nil {
+		t.Fatal(err)
+	}
+	output := p.String()
+
+	expectContains(t, output,
+		"var a []int32 = make([]int32, 5, 5)",
+		"var b []int32 = make([]int32, int(x+int32(LARGE)))",
+		"case int32(16):",
+		"case int32(5):",
+		"case SMALL:",
+		// HUGE is one more than LARGE.
+		"case int32(10):",
+	)
+}
+
+func TestSwitchCharacterLabels(t *testing.T) {
+	// int f(char c) {
+	//     switch (c) {
+	//     case 'a':
+	//         return 1;
+	//     case '\n':
+	//         return 2;
+	//     case -2:
+	//         return 3;
+	//     case '\xa0':
+	//         return 4;
+	//     }
+	//     return 0;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:13:1> line:1:5 f 'int (char)'
+  |-ParmVarDecl 0x11 <col:7, col:12> col:12 used c 'char'
+  |-CompoundStmt 0x12 <col:15, line:13:1>
+    |-SwitchStmt 0x13 <line:2:5, line:11:5>
+    | |-ImplicitCastExpr 0x14 <line:2:13> 'int' <IntegralCast>
+    | | |-ImplicitCastExpr 0x15 <col:13> 'char' <LValueToRValue>
+    | |   |-DeclRefExpr 0x16 <col:13> 'char' lvalue ParmVar 0x11 'c' 'char'
+    | |-CompoundStmt 0x17 <col:16, line:11:5>
+    |   |-CaseStmt 0x20 <line:3:5, line:4:16>
+    |   | |-ConstantExpr 0x21 <line:3:10> 'int'
+    |   | | |-CharacterLiteral 0x22 <col:10> 'int' 97
+    |   | |-ReturnStmt 0x23 <line:4:9, col:16>
+    |   |   |-IntegerLiteral 0x24 <col:16> 'int' 1
+    |   |-CaseStmt 0x30 <line:5:5, line:6:16>
+    |   | |-ConstantExpr 0x31 <line:5:10> 'int'
+    |   | | |-CharacterLiteral 0x32 <col:10> 'int' 10
+    |   | |-ReturnStmt 0x33 <line:6:9, col:16>
+    |   |   |-IntegerLiteral 0x34 <col:16> 'int' 2
+    |   |-CaseStmt 0x40 <line:7:5, line:8:16>
+    |   | |-ConstantExpr 0x41 <line:7:10, col:11> 'int'
+    |   | | |-UnaryOperator 0x42 <col:10, col:11> 'int' prefix '-' cannot overflow
+    |   | |   |-IntegerLiteral 0x43 <col:11> 'int' 2
+    |   | |-ReturnStmt 0x44 <line:8:9, col:16>
+    |   |   |-IntegerLiteral 0x45 <col:16> 'int' 3
+    |   |-CaseStmt 0x50 <line:9:5, line:10:16>
+    |     |-ConstantExpr 0x51 <line:9:10> 'int'
+    |     | |-CharacterLiteral 0x52 <col:10> 'int' 4294967200
+    |     |-ReturnStmt 0x53 <line:10:9, col:16>
+    |       |-IntegerLiteral 0x54 <col:16> 'int' 4
+    |-ReturnStmt 0x60 <line:12:5, col:12>
+      |-IntegerLiteral 0x61 <col:12> 'int' 0
+`
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"switch int32(int8(c)) {",
+		"case 'a':",
+		`case '\n':`,
+		"case int32(-2):",
+		// The char is signed, so '\xa0' is negative like the condition.
+		"case int32(-96):",
+	)
+}
+
+func TestSwitchUnsignedNegativeLabel(t *testing.T) {
+	// int f(unsigned u) {
+	//     switch (u) {
+	//     case -1:
+	//         return 1;
+	//     case 1 - 3:
+	//         return 2;
+	//     }
+	//     return 0;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:9:1> line:1:5 f 'int (unsigned int)'
+  |-ParmVarDecl 0x11 <col:7, col:16> col:16 used u 'unsigned int'
+  |-CompoundStmt 0x12 <col:19, line:9:1>
+    |-SwitchStmt 0x13 <line:2:5, line:7:5>
+    | |-ImplicitCastExpr 0x14 <line:2:13> 'unsigned int' <LValueToRValue>
+    | | |-DeclRefExpr 0x15 <col:13> 'unsigned int' lvalue ParmVar 0x11 'u' 'unsigned int'
+    | |-CompoundStmt 0x16 <col:16, line:7:5>
+    |   |-CaseStmt 0x20 <line:3:5, line:4:16>
+    |   | |-ConstantExpr 0x21 <line:3:10, col:11> 'unsigned int'
+    |   | | |-ImplicitCastExpr 0x22 <col:10, col:11> 'unsigned int' <IntegralCast>
+    |   | |   |-UnaryOperator 0x23 <col:10, col:11> 'int' prefix '-' cannot overflow
+    |   | |     |-IntegerLiteral 0x24 <col:11> 'int' 1
+    |   | |-ReturnStmt 0x25 <line:4:9, col:16>
+    |   |   |-IntegerLiteral 0x26 <col:16> 'int' 1
+    |   |-CaseStmt 0x30 <line:5:5, line:6:16>
+    |     |-ConstantExpr 0x31 <line:5:10, col:14> 'unsigned int'
+    |     | |-ImplicitCastExpr 0x32 <col:10, col:14> 'unsigned int' <IntegralCast>
+    |     |   |-BinaryOperator 0x33 <col:10, col:14> 'int' '-'
+    |     |     |-IntegerLiteral 0x34 <col:10> 'int' 1
+    |     |     |-IntegerLiteral 0x35 <col:14> 'int' 3
+    |     |-ReturnStmt 0x36 <line:6:9, col:16>
+    |       |-IntegerLiteral 0x37 <col:16> 'int' 2
+    |-ReturnStmt 0x40 <line:8:5, col:12>
+      |-IntegerLiteral 0x41 <col:12> 'int' 0
+`
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	// The labels wrap around like in C, uint32(-1) would not compile.
+	expectContains(t, output,
+		"case uint32(4294967295):",
+		"case uint32(4294967294):",
+	)
+}
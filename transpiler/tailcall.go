@@ -0,0 +1,230 @@
+// This file contains the rewriting of the self-recursive tail calls of a
+// function into a loop. See Program.TailCalls.
+
+package transpiler
+
+import (
+	goast "go/ast"
+	"go/token"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+// tailCallLabel is the label of the loop that replaces the tail calls.
+const tailCallLabel = "c2goTailCall"
+
+// eliminateTailCalls rewrites a function that calls itself only in tail
+// position, that is directly in a return statement, into a loop. Each tail
+// call assigns its arguments to the parameters and starts the next iteration,
+// so the recursion does not grow the stack:
+//
+//     int fact(int n, int acc) {          func fact(n int32, acc int32) int32 {
+//         if (n <= 1)                     c2goTailCall:
+//             return acc;                     for {
+//         return fact(n - 1, acc * n);            if n <= 1 {
+//     }                                               return acc
+//                                                 }
+//                                                 n, acc = n-1, acc*n
+//                                                 continue c2goTailCall
+//                                             }
+//                                         }
+//
+// All of the arguments are evaluated before any parameter is assigned, like in
+// a call. The function is not changed if it has any other recursive call, or
+// if a new iteration would not be the same as a new call: when the address of
+// a parameter is taken, when a closure uses a parameter, when a parameter is
+// shadowed or when the function has a defer statement. It returns true if the
+// function was rewritten.
+func eliminateTailCalls(decl *goast.FuncDecl) bool {
+	name := decl.Name.Name
+	params := getParameterNames(decl.Type)
+	if params == nil {
+		return false
+	}
+
+	canEliminate := true
+	isParam := func(expr goast.Expr) bool {
+		ident, ok := expr.(*goast.Ident)
+		return ok && util.InStrings(ident.Name, params)
+	}
+	tailCalls := map[*goast.ReturnStmt]*goast.CallExpr{}
+
+	goast.Inspect(decl.Body, func(node goast.Node) bool {
+		switch n := node.(type) {
+		case *goast.ReturnStmt:
+			if call := getSelfCall(n, name); call != nil && len(call.Args) == len(params) {
+				if usesIdent(name, call.Args...) {
+					canEliminate = false
+				}
+				tailCalls[n] = call
+				return false
+			}
+
+		case *goast.CallExpr:
+			if ident, ok := n.Fun.(*goast.Ident); ok && ident.Name == name {
+				canEliminate = false
+			}
+
+		case *goast.FuncLit:
+			if usesIdent(name, n) || usesAnyIdent(params, n) {
+				canEliminate = false
+			}
+			return false
+
+		case *goast.DeferStmt:
+			canEliminate = false
+
+		case *goast.UnaryExpr:
+			if n.Op == token.AND && isParam(n.X) {
+				canEliminate = false
+			}
+
+		case *goast.ValueSpec:
+			for _, ident := range n.Names {
+				if isParam(ident) {
+					canEliminate = false
+				}
+			}
+
+		case *goast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, lhs := range n.Lhs {
+					if isParam(lhs) {
+						canEliminate = false
+					}
+				}
+			}
+
+		case *goast.RangeStmt:
+			if n.Tok == token.DEFINE && (isParam(n.Key) || n.Value != nil && isParam(n.Value)) {
+				canEliminate = false
+			}
+		}
+
+		return canEliminate
+	})
+
+	if !canEliminate || len(tailCalls) == 0 {
+		return false
+	}
+
+	_, endsWithReturn := decl.Body.List[len(decl.Body.List)-1].(*goast.ReturnStmt)
+	replaceTailCalls(decl.Body, params, tailCalls)
+
+	// A function without a result can reach the end of its body, that must
+	// not start the next iteration.
+	stmts := decl.Body.List
+	if !endsWithReturn && (decl.Type.Results == nil || len(decl.Type.Results.List) == 0) {
+		stmts = append(stmts, &goast.ReturnStmt{})
+	}
+	decl.Body.List = []goast.Stmt{&goast.LabeledStmt{
+		Label: util.NewIdent(tailCallLabel),
+		Stmt:  &goast.ForStmt{Body: &goast.BlockStmt{List: stmts}},
+	}}
+
+	return true
+}
+
+// getParameterNames returns the names of the parameters of a function, or nil
+// if it has no parameters, if one of them has no name or if it is variadic.
+func getParameterNames(t *goast.FuncType) (names []string) {
+	if t.Params == nil {
+		return nil
+	}
+	for _, field := range t.Params.List {
+		if len(field.Names) == 0 {
+			return nil
+		}
+		if _, ok := field.Type.(*goast.Ellipsis); ok {
+			return nil
+		}
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
+		}
+	}
+
+	return
+}
+
+// getSelfCall returns the call of the function with the name when it is the
+// only result of the return, like "return f(n - 1)".
+func getSelfCall(n *goast.ReturnStmt, name string) *goast.CallExpr {
+	if len(n.Results) != 1 {
+		return nil
+	}
+	call, ok := n.Results[0].(*goast.CallExpr)
+	if !ok || call.Ellipsis.IsValid() {
+		return nil
+	}
+	if ident, ok := call.Fun.(*goast.Ident); !ok || ident.Name != name {
+		return nil
+	}
+
+	return call
+}
+
+// usesAnyIdent returns true if the expression uses any of the identifiers.
+func usesAnyIdent(names []string, expr goast.Expr) bool {
+	for _, name := range names {
+		if usesIdent(name, expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceTailCalls replaces each of the tail calls by the assignment of its
+// arguments to the parameters and the continue of the loop.
+func replaceTailCalls(body *goast.BlockStmt, params []string,
+	tailCalls map[*goast.ReturnStmt]*goast.CallExpr) {
+	replace := func(stmts []goast.Stmt) []goast.Stmt {
+		var result []goast.Stmt
+		for _, stmt := range stmts {
+			if r, ok := stmt.(*goast.ReturnStmt); ok && tailCalls[r] != nil {
+				result = append(result, newTailCallStmts(params, tailCalls[r])...)
+				continue
+			}
+			result = append(result, stmt)
+		}
+		return result
+	}
+
+	goast.Inspect(body, func(node goast.Node) bool {
+		switch n := node.(type) {
+		case *goast.BlockStmt:
+			n.List = replace(n.List)
+		case *goast.CaseClause:
+			n.Body = replace(n.Body)
+		case *goast.CommClause:
+			n.Body = replace(n.Body)
+		case *goast.LabeledStmt:
+			if r, ok := n.Stmt.(*goast.ReturnStmt); ok && tailCalls[r] != nil {
+				n.Stmt = &goast.BlockStmt{List: newTailCallStmts(params, tailCalls[r])}
+			}
+		}
+		return true
+	})
+}
+
+// newTailCallStmts returns the statements of a tail call. The parameters that
+// are passed unchanged, like acc in "return f(n - 1, acc)", are not assigned.
+func newTailCallStmts(params []string, call *goast.CallExpr) []goast.Stmt {
+	var lhs, rhs []goast.Expr
+	for i, arg := range call.Args {
+		if ident, ok := arg.(*goast.Ident); ok && ident.Name == params[i] {
+			continue
+		}
+		lhs = append(lhs, util.NewIdent(params[i]))
+		rhs = append(rhs, arg)
+	}
+
+	var stmts []goast.Stmt
+	if len(lhs) > 0 {
+		stmts = append(stmts, &goast.AssignStmt{Lhs: lhs, Tok: token.ASSIGN, Rhs: rhs})
+	}
+
+	return append(stmts, &goast.BranchStmt{
+		Tok:   token.CONTINUE,
+		Label: util.NewIdent(tailCallLabel),
+	})
+}
@@ -0,0 +1,255 @@
+package transpiler
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestTailCalls(t *testing.T) {
+	// int fact(int n, int acc) {
+	//     if (n <= 1)
+	//         return acc;
+	//     if (n == 5)
+	//         return fact(n - 1, acc * 5);
+	//     return fact(n - 1, acc * n);
+	// }
+	// int main() { return fact(5, 1) % 256; }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:7:1> line:1:5 used fact 'int (int, int)'
+| |-ParmVarDecl 0x11 <col:10, col:14> col:14 used n 'int'
+| |-ParmVarDecl 0x12 <col:17, col:21> col:21 used acc 'int'
+| |-CompoundStmt 0x13 <col:26, line:7:1>
+|   |-IfStmt 0x14 <line:2:5, line:3:16>
+|   | |-BinaryOperator 0x15 <line:2:9, col:14> 'int' '<='
+|   | | |-ImplicitCastExpr 0x16 <col:9> 'int' <LValueToRValue>
+|   | | | |-DeclRefExpr 0x17 <col:9> 'int' lvalue ParmVar 0x11 'n' 'int'
+|   | | |-IntegerLiteral 0x18 <col:14> 'int' 1
+|   | |-ReturnStmt 0x19 <line:3:9, col:16>
+|   |   |-ImplicitCastExpr 0x1a <col:16> 'int' <LValueToRValue>
+|   |     |-DeclRefExpr 0x1b <col:16> 'int' lvalue ParmVar 0x12 'acc' 'int'
+|   |-IfStmt 0x20 <line:4:5, line:5:36>
+|   | |-BinaryOperator 0x21 <line:4:9, col:14> 'int' '=='
+|   | | |-ImplicitCastExpr 0x22 <col:9> 'int' <LValueToRValue>
+|   | | | |-DeclRefExpr 0x23 <col:9> 'int' lvalue ParmVar 0x11 'n' 'int'
+|   | | |-IntegerLiteral 0x24 <col:14> 'int' 5
+|   | |-ReturnStmt 0x25 <line:5:9, col:36>
+|   |   |-CallExpr 0x26 <col:16, col:36> 'int'
+|   |     |-ImplicitCastExpr 0x27 <col:16> 'int (*)(int, int)' <FunctionToPointerDecay>
+|   |     | |-DeclRefExpr 0x28 <col:16> 'int (int, int)' Function 0x10 'fact' 'int (int, int)'
+|   |     |-BinaryOperator 0x29 <col:21, col:25> 'int' '-'
+|   |     | |-ImplicitCastExpr 0x2a <col:21> 'int' <LValueToRValue>
+|   |     | | |-DeclRefExpr 0x2b <col:21> 'int' lvalue ParmVar 0x11 'n' 'int'
+|   |     | |-IntegerLiteral 0x2c <col:25> 'int' 1
+|   |     |-BinaryOperator 0x2d <col:28, col:34> 'int' '*'
+|   |       |-ImplicitCastExpr 0x2e <col:28> 'int' <LValueToRValue>
+|   |       | |-DeclRefExpr 0x2f <col:28> 'int' lvalue ParmVar 0x12 'acc' 'int'
+|   |       |-IntegerLiteral 0x30 <col:34> 'int' 5
+|   |-ReturnStmt 0x40 <line:6:5, col:32>
+|     |-CallExpr 0x41 <col:12, col:32> 'int'
+|       |-ImplicitCastExpr 0x42 <col:12> 'int (*)(int, int)' <FunctionToPointerDecay>
+|       | |-DeclRefExpr 0x43 <col:12> 'int (int, int)' Function 0x10 'fact' 'int (int, int)'
+|       |-BinaryOperator 0x44 <col:17, col:21> 'int' '-'
+|       | |-ImplicitCastExpr 0x45 <col:17> 'int' <LValueToRValue>
+|       | | |-DeclRefExpr 0x46 <col:17> 'int' lvalue ParmVar 0x11 'n' 'int'
+|       | |-IntegerLiteral 0x47 <col:21> 'int' 1
+|       |-BinaryOperator 0x48 <col:24, col:30> 'int' '*'
+|         |-ImplicitCastExpr 0x49 <col:24> 'int' <LValueToRValue>
+|         | |-DeclRefExpr 0x4a <col:24> 'int' lvalue ParmVar 0x12 'acc' 'int'
+|         |-ImplicitCastExpr 0x4b <col:30> 'int' <LValueToRValue>
+|           |-DeclRefExpr 0x4c <col:30> 'int' lvalue ParmVar 0x11 'n' 'int'
+|-FunctionDecl 0x50 <line:8:1, line:10:1> line:8:5 main 'int ()'
+  |-CompoundStmt 0x51 <col:12, line:10:1>
+    |-ReturnStmt 0x52 <line:9:5, col:25>
+      |-BinaryOperator 0x53 <col:12, col:25> 'int' '%'
+        |-CallExpr 0x54 <col:12, col:21> 'int'
+        | |-ImplicitCastExpr 0x55 <col:12> 'int (*)(int, int)' <FunctionToPointerDecay>
+        | | |-DeclRefExpr 0x56 <col:12> 'int (int, int)' Function 0x10 'fact' 'int (int, int)'
+        | |-IntegerLiteral 0x57 <col:17> 'int' 5
+        | |-IntegerLiteral 0x58 <col:20> 'int' 1
+        |-IntegerLiteral 0x59 <col:25> 'int' 256
+`
+
+	for _, tailCalls := range []bool{false, true} {
+		p := program.NewProgram()
+		p.TailCalls = tailCalls
+		output := transpileDump(t, p, dump)
+
+		want := `func fact(n int32, acc int32) int32 {
+c2goTailCall:
+	for {
+		if n <= int32(1) {
+			return acc
+		}
+		if n == int32(5) {
+			n, acc = n-int32(1), acc*int32(5)
+			continue c2goTailCall
+		}
+		n, acc = n-int32(1), acc*n
+		continue c2goTailCall
+	}
+}`
+		if tailCalls != strings.Contains(output, want) {
+			t.Errorf("TailCalls = %v: expected the loop %v in:\n%s", tailCalls, tailCalls, output)
+		}
+		if tailCalls == strings.Contains(output, "return fact(") {
+			t.Errorf("TailCalls = %v: unexpected recursion in:\n%s", tailCalls, output)
+		}
+	}
+}
+
+func TestEliminateTailCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "statement",
+			src: `func f(n int32) {
+	if n > 0 {
+		g(n)
+		f(n - 1)
+		return
+	}
+}`,
+			want: `func f(n int32) {
+	if n > 0 {
+		g(n)
+		f(n - 1)
+		return
+	}
+}`,
+		},
+		{
+			name: "unchanged parameter",
+			src: `func f(n int32, m int32) int32 {
+	switch n {
+	case 0:
+		return m
+	}
+	return f(n-1, m)
+}`,
+			want: `func f(n int32, m int32) int32 {
+c2goTailCall:
+	for {
+		switch n {
+		case 0:
+			return m
+		}
+		n = n - 1
+		continue c2goTailCall
+	}
+}`,
+		},
+		{
+			name: "void without return",
+			src: `func f(n int32) {
+	if n > 0 {
+		g(n)
+		return f(n - 1)
+	}
+}`,
+			want: `func f(n int32) {
+c2goTailCall:
+	for {
+		if n > 0 {
+			g(n)
+			n = n - 1
+			continue c2goTailCall
+		}
+		return
+	}
+}`,
+		},
+		{
+			name: "not a tail call",
+			src: `func f(n int32) int32 {
+	if n == 0 {
+		return 1
+	}
+	return n * f(n-1)
+}`,
+		},
+		{
+			name: "tail call and other call",
+			src: `func f(n int32) int32 {
+	if n == 0 {
+		return f(n + 1)
+	}
+	return f(f(n - 1))
+}`,
+		},
+		{
+			name: "address of parameter",
+			src: `func f(n int32) int32 {
+	g(&n)
+	return f(n - 1)
+}`,
+		},
+		{
+			name: "closure",
+			src: `func f(n int32) int32 {
+	g(func() int32 {
+		return n
+	})
+	return f(n - 1)
+}`,
+		},
+		{
+			name: "shadowed parameter",
+			src: `func f(n int32) int32 {
+	{
+		var n int32 = 2
+		return f(n)
+	}
+}`,
+		},
+		{
+			name: "defer",
+			src: `func f(n int32) int32 {
+	defer g(n)
+	return f(n - 1)
+}`,
+		},
+		{
+			name: "variadic",
+			src: `func f(n int32, args ...interface{}) int32 {
+	return f(n-1, args...)
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+tt.src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decl := file.Decls[0].(*goast.FuncDecl)
+
+			want := tt.want
+			if want == "" {
+				want = tt.src
+			}
+			if got := eliminateTailCalls(decl); got != (tt.want != "" && tt.want != tt.src) {
+				t.Errorf("Expected %v, got %v", !got, got)
+			}
+
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), decl); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+			}
+		})
+	}
+}
@@ -14,6 +14,16 @@ import (
 func transpileTranslationUnitDecl(p *program.Program, n *ast.TranslationUnitDecl) (
 	decls []goast.Decl, err error) {
 
+	findInlineFunctions(p, n)
+	hoistStaticVariables(n)
+	removeVariableRedeclarations(n)
+	removeRecordRedeclarations(p, n)
+	declareRecords(p, n)
+	exportFunctions(p, n)
+	registerFunctionDefinitions(p, n)
+	findStringParameters(p, n)
+	p.UsesAtexit = callsFunction(n, "atexit")
+
 	for i := 0; i < len(n.Children()); i++ {
 		presentNode := n.Children()[i]
 		var runAfter func()
@@ -35,6 +45,19 @@ func transpileTranslationUnitDecl(p *program.Program, n *ast.TranslationUnitDecl
 							rec.Name = name[len("struct "):]
 						}
 					}
+				case *ast.FunctionDecl:
+					// The function returns the anonymous struct, like:
+					//
+					//     struct { int a; } f(void) { ... }
+					name := getFunctionReturnType(recNode.Type)
+					if rec.Name == "" && strings.Contains(recNode.Type, "(anonymous") {
+						if strings.HasPrefix(name, "union ") {
+							rec.Name = name[len("union "):]
+						}
+						if strings.HasPrefix(name, "struct ") {
+							rec.Name = name[len("struct "):]
+						}
+					}
 				case *ast.TypedefDecl:
 					if isSameTypedefNames(recNode) && !rec.Definition {
 						// this is just the declaration of a type, the implementation comes later
@@ -128,6 +151,170 @@ func transpileTranslationUnitDecl(p *program.Program, n *ast.TranslationUnitDecl
 	return
 }
 
+// removeVariableRedeclarations removes the declarations of a global variable
+// that do not define it, so that only one Go variable is created for it. The
+// source files that are transpiled together are one translation unit, so a
+// variable that is shared by the files is often declared more than once, like:
+//
+//     extern int total;   // file1.c
+//     int total;          // file2.c, a tentative definition
+//     int total = 10;     // file2.c
+//
+// The declaration with the initializer is kept, otherwise the first tentative
+// definition. An extern declaration does not define the variable, so nothing
+// is kept when all of the declarations are extern. The declarations of the
+// same variable are linked with "prev". Static variables of different files
+// are different variables, so they are only merged with their own
+// declarations.
+func removeVariableRedeclarations(n *ast.TranslationUnitDecl) {
+	first := map[ast.Address]ast.Address{}
+	byName := map[string]ast.Address{}
+	decls := map[ast.Address][]*ast.VarDecl{}
+	var order []ast.Address
+	for _, c := range n.Children() {
+		v, ok := c.(*ast.VarDecl)
+		if !ok || v.Name == "" {
+			continue
+		}
+		addr, linked := first[ast.ParseAddress(v.Prev)]
+		if !linked {
+			addr, linked = byName[v.Name]
+			linked = linked && !v.IsStatic
+		}
+		if !linked {
+			addr = v.Addr
+			order = append(order, addr)
+		}
+		first[v.Addr] = addr
+		if _, ok := byName[v.Name]; !ok && !v.IsStatic {
+			byName[v.Name] = addr
+		}
+		decls[addr] = append(decls[addr], v)
+	}
+
+	keep := map[*ast.VarDecl]bool{}
+	for _, addr := range order {
+		if v := variableDefinition(decls[addr]); v != nil {
+			keep[v] = true
+		}
+	}
+
+	children := n.ChildNodes[:0]
+	for _, c := range n.Children() {
+		if v, ok := c.(*ast.VarDecl); ok && v.Name != "" && !keep[v] {
+			continue
+		}
+		children = append(children, c)
+	}
+	n.ChildNodes = children
+}
+
+// removeRecordRedeclarations removes the declarations of a struct or a union
+// that do not define it when it is defined in the translation unit, like:
+//
+//     struct point;                        // file1.c
+//     struct point { int x, y; };          // shared.h
+//
+// Otherwise the first declaration would be transpiled into an empty struct and
+// the definition would be ignored. A declaration that is followed by a
+// typedef is kept, since the typedef looks up the definition itself.
+//
+// A header that is included by more than one of the files defines its structs
+// more than once. The later definitions are removed, and an error is reported
+// for a definition with other fields than the first one.
+func removeRecordRedeclarations(p *program.Program, n *ast.TranslationUnitDecl) {
+	definitions := map[string]*ast.RecordDecl{}
+	for _, c := range n.Children() {
+		if rec, ok := c.(*ast.RecordDecl); ok && rec.Name != "" && rec.Definition {
+			if _, ok := definitions[rec.Kind+" "+rec.Name]; !ok {
+				definitions[rec.Kind+" "+rec.Name] = rec
+			}
+		}
+	}
+
+	children := n.ChildNodes[:0]
+	for i, c := range n.Children() {
+		rec, ok := c.(*ast.RecordDecl)
+		if !ok || rec.Name == "" {
+			children = append(children, c)
+			continue
+		}
+		definition, isDefined := definitions[rec.Kind+" "+rec.Name]
+		if !isDefined || definition == rec {
+			children = append(children, c)
+			continue
+		}
+		if !rec.Definition {
+			if i+1 < len(n.Children()) {
+				if _, ok := n.Children()[i+1].(*ast.TypedefDecl); ok {
+					children = append(children, c)
+				}
+			}
+			continue
+		}
+		if recordFields(rec) != recordFields(definition) {
+			p.AddMessage(p.GenerateErrorMessage(fmt.Errorf(
+				"%s %s is defined again with different fields", rec.Kind, rec.Name), rec))
+		}
+	}
+	n.ChildNodes = children
+}
+
+// declareRecords registers the structs and unions that are defined in the
+// translation unit before any of them is transpiled. A field can then point to
+// a struct that is defined later, like with two structs that point to each
+// other:
+//
+//     struct A;
+//     struct B { struct A *a; };   =>   type B struct { a *A }
+//     struct A { struct B *b; };
+//
+// A struct that points to itself, like the node of a linked list, is already
+// defined when its fields are transpiled, see transpileRecordDecl().
+func declareRecords(p *program.Program, n *ast.TranslationUnitDecl) {
+	for _, c := range n.Children() {
+		rec, ok := c.(*ast.RecordDecl)
+		if !ok || rec.Name == "" || !rec.Definition {
+			continue
+		}
+		name := types.GenerateCorrectType(rec.Name)
+		if p.GetStruct(rec.Kind+" "+name) == nil {
+			p.DeclareType(rec, name)
+		}
+	}
+}
+
+// recordFields returns the names and the types of the fields of a struct or a
+// union, including the ones of the nested records, to compare definitions.
+func recordFields(n ast.Node) string {
+	var fields []string
+	for _, c := range n.Children() {
+		switch v := c.(type) {
+		case *ast.FieldDecl:
+			fields = append(fields, v.Name+" "+v.Type)
+		case *ast.RecordDecl:
+			fields = append(fields, v.Kind+" "+v.Name+" {"+recordFields(v)+"}")
+		}
+	}
+	return strings.Join(fields, "; ")
+}
+
+// variableDefinition returns the declaration that defines a variable: the one
+// with an initializer, even if it is extern, or the first one that is not
+// extern. It returns nil if all of the declarations are extern.
+func variableDefinition(decls []*ast.VarDecl) *ast.VarDecl {
+	var tentative *ast.VarDecl
+	for _, v := range decls {
+		if len(v.Children()) > 0 {
+			return v
+		}
+		if tentative == nil && !v.IsExtern {
+			tentative = v
+		}
+	}
+	return tentative
+}
+
 func isSameTypedefNames(v *ast.TypedefDecl) bool {
 	// for structs :
 	/*
@@ -3,6 +3,7 @@
 package transpiler
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	goast "go/ast"
@@ -18,6 +19,13 @@ import (
 
 // TranspileAST iterates through the Clang AST and builds a Go AST
 func TranspileAST(fileName, packageName string, p *program.Program, root ast.Node) error {
+	switch p.LongDoubleType {
+	case "", program.LongDoubleBigFloat:
+	default:
+		return fmt.Errorf("unknown long double type %q, it must be empty or %s",
+			p.LongDoubleType, program.LongDoubleBigFloat)
+	}
+
 	// Start by parsing an empty file.
 	p.FileSet = token.NewFileSet()
 	packageSignature := fmt.Sprintf("package %v", packageName)
@@ -34,6 +42,7 @@ func TranspileAST(fileName, packageName string, p *program.Program, root ast.Nod
 		p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Error of transpiling: err = %v", err), root))
 		err = nil // Error is ignored
 	}
+	p.File.Decls = append(p.File.Decls, transpileMacros(p)...)
 	p.File.Decls = append(p.File.Decls, decls...)
 
 	if p.OutputAsTest {
@@ -105,26 +114,48 @@ func TranspileAST(fileName, packageName string, p *program.Program, root ast.Nod
 		})
 	}
 
+	if err := p.RemoveDuplicateDecls(); err != nil {
+		return err
+	}
+
 	// Add the imports after everything else so we can ensure that they are all
 	// placed at the top.
-	for _, quotedImportPath := range p.Imports() {
-		importSpec := &goast.ImportSpec{
-			Path: &goast.BasicLit{
-				Kind:  token.IMPORT,
-				Value: quotedImportPath,
-			},
-		}
-		importDecl := &goast.GenDecl{
-			Tok: token.IMPORT,
+	if groups := p.ImportGroups(); len(groups) > 0 {
+		importDecl, err := transpileImports(p.FileSet, groups)
+		if err != nil {
+			return err
 		}
-
-		importDecl.Specs = append(importDecl.Specs, importSpec)
 		p.File.Decls = append([]goast.Decl{importDecl}, p.File.Decls...)
 	}
 
 	return err
 }
 
+// transpileImports returns a single import declaration for the groups of
+// quoted import paths, see Program.ImportGroups(). The declaration is parsed
+// from source, because only the positions of the imports can keep a blank
+// line between the groups.
+func transpileImports(fset *token.FileSet, groups [][]string) (goast.Decl, error) {
+	var src bytes.Buffer
+	src.WriteString("package imports\n\nimport (\n")
+	for i, group := range groups {
+		if i > 0 {
+			src.WriteString("\n")
+		}
+		for _, quotedImportPath := range group {
+			fmt.Fprintf(&src, "\t%s\n", quotedImportPath)
+		}
+	}
+	src.WriteString(")\n")
+
+	f, err := parser.ParseFile(fset, "", src.String(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the imports: %v", err)
+	}
+
+	return f.Decls[0], nil
+}
+
 func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 	expr goast.Expr,
 	exprType string,
@@ -146,8 +177,12 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 
 	switch n := node.(type) {
 	case *ast.StringLiteral:
-		expr = transpileStringLiteral(n)
-		exprType = "const char *"
+		if n.Prefix == "" || n.Prefix == "u8" {
+			expr = transpileStringLiteral(n)
+			exprType = "const char *"
+		} else {
+			expr, exprType, err = transpileWideStringLiteral(n, p)
+		}
 
 	case *ast.FloatingLiteral:
 		expr = transpileFloatingLiteral(n)
@@ -163,6 +198,9 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 	case *ast.ConditionalOperator:
 		expr, exprType, preStmts, postStmts, err = transpileConditionalOperator(n, p)
 
+	case *ast.BinaryConditionalOperator:
+		expr, exprType, preStmts, postStmts, err = transpileBinaryConditionalOperator(n, p)
+
 	case *ast.ArraySubscriptExpr:
 		expr, exprType, preStmts, postStmts, err = transpileArraySubscriptExpr(n, p, exprIsStmt)
 
@@ -180,6 +218,9 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 
 	case *ast.DeclRefExpr:
 		expr, exprType, err = transpileDeclRefExpr(n, p)
+		if method := newMethodExpr(p, n); method != nil {
+			expr = method
+		}
 
 	case *ast.IntegerLiteral:
 		expr, exprType, err = transpileIntegerLiteral(n), "int", nil
@@ -187,6 +228,9 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 	case *ast.ParenExpr:
 		expr, exprType, preStmts, postStmts, err = transpileParenExpr(n, p)
 
+	case *ast.GenericSelectionExpr:
+		return transpileGenericSelectionExpr(n, p, exprIsStmt)
+
 	case *ast.CStyleCastExpr:
 		expr, exprType, preStmts, postStmts, err = transpileCStyleCastExpr(n, p, exprIsStmt)
 
@@ -194,7 +238,31 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 		expr, exprType, err = transpileCharacterLiteral(n), "char", nil
 
 	case *ast.CallExpr:
+		var ok bool
+		expr, exprType, preStmts, postStmts, ok, err = transpileInlineCall(n, p)
+		if ok {
+			break
+		}
+		expr, exprType, preStmts, postStmts, ok, err = transpileMemoryCall(n, p, exprIsStmt)
+		if ok {
+			break
+		}
+		expr, exprType, preStmts, postStmts, ok, err = transpileCalloc(n, p)
+		if ok {
+			break
+		}
+		expr, exprType, preStmts, postStmts, ok, err = transpileAlloca(n, p)
+		if ok {
+			break
+		}
+		expr, exprType, preStmts, postStmts, ok, err = transpileBuiltinHint(n, p)
+		if ok {
+			break
+		}
 		expr, exprType, preStmts, postStmts, err = transpileCallExpr(n, p)
+		if err == nil && !exprIsStmt {
+			expr = transpileNoReturnResult(n, expr, exprType, p)
+		}
 
 	case *ast.CompoundAssignOperator:
 		return transpileCompoundAssignOperator(n, p, exprIsStmt)
@@ -202,15 +270,21 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 	case *ast.UnaryExprOrTypeTraitExpr:
 		return transpileUnaryExprOrTypeTraitExpr(n, p)
 
+	case *ast.OffsetOfExpr:
+		return transpileOffsetOfExpr(n, p)
+
 	case *ast.InitListExpr:
-		expr, exprType, err = transpileInitListExpr(n, p)
+		return transpileInitListExpr(n, p)
 
 	case *ast.CompoundLiteralExpr:
-		expr, exprType, err = transpileCompoundLiteralExpr(n, p)
+		return transpileCompoundLiteralExpr(n, p)
 
 	case *ast.StmtExpr:
 		return transpileStmtExpr(n, p)
 
+	case *ast.VAArgExpr:
+		expr, exprType, err = transpileVAArgExpr(n, p)
+
 	case *ast.ImplicitValueInitExpr:
 		cType := n.Type1
 
@@ -352,6 +426,12 @@ func transpileToStmt(node ast.Node, p *program.Program) (
 		stmt, preStmts, postStmts, err = transpileCompoundStmt(n, p)
 		return
 
+	case *ast.StmtExpr:
+		// The value of a statement expression that is a statement is not
+		// used, so it is just a block.
+		stmt, preStmts, postStmts, err = transpileCompoundStmt(n.Children()[0].(*ast.CompoundStmt), p)
+		return
+
 	case *ast.BinaryOperator:
 		if n.Operator == "," {
 			stmt, preStmts, err = transpileBinaryOperatorComma(n, p)
@@ -382,7 +462,7 @@ func transpileToStmt(node ast.Node, p *program.Program) (
 		}
 		stmt = stmts[len(stmts)-1]
 		if len(stmts) > 1 {
-			preStmts = stmts[0 : len(stmts)-2]
+			preStmts = stmts[0 : len(stmts)-1]
 		}
 		return
 	}
@@ -447,17 +527,20 @@ func transpileToNode(node ast.Node, p *program.Program) (decls []goast.Decl, err
 	case *ast.FunctionDecl:
 		decls, err = transpileFunctionDecl(n, p)
 		if len(decls) > 0 {
-			if _, ok := decls[0].(*goast.FuncDecl); ok {
-				decls[0].(*goast.FuncDecl).Doc = p.GetMessageComments()
-				decls[0].(*goast.FuncDecl).Doc.List =
-					append(decls[0].(*goast.FuncDecl).Doc.List,
-						p.GetComments(node.Position())...)
-				decls[0].(*goast.FuncDecl).Doc.List =
-					append([]*goast.Comment{&goast.Comment{
-						Text: fmt.Sprintf("// %s - transpiled function from %s",
-							decls[0].(*goast.FuncDecl).Name.Name,
-							node.Position().GetSimpleLocation()),
-					}}, decls[0].(*goast.FuncDecl).Doc.List...)
+			if f, ok := decls[0].(*goast.FuncDecl); ok {
+				p.AddTranspiledFunction(f)
+				summary := f.Doc
+				f.Doc = p.GetMessageComments()
+				if summary != nil {
+					// The messages of the function are only in the summary.
+					f.Doc.List = append(withoutComments(f.Doc.List, summary.List),
+						summary.List...)
+				}
+				f.Doc.List = append(f.Doc.List, p.GetComments(node.Position())...)
+				f.Doc.List = append([]*goast.Comment{&goast.Comment{
+					Text: fmt.Sprintf("// %s - transpiled function from %s",
+						f.Name.Name, node.Position().GetSimpleLocation()),
+				}}, f.Doc.List...)
 			}
 		}
 
@@ -468,11 +551,19 @@ func transpileToNode(node ast.Node, p *program.Program) (decls []goast.Decl, err
 		decls, err = transpileRecordDecl(p, n)
 
 	case *ast.VarDecl:
-		decls, _, err = transpileVarDecl(p, n)
+		var preStmts, postStmts []goast.Stmt
+		decls, _, preStmts, postStmts, err = transpileVarDecl(p, n)
+		if err == nil && (len(preStmts) != 0 || len(postStmts) != 0) {
+			err = fmt.Errorf("Not acceptable length of Stmt : pre(%d), post(%d)",
+				len(preStmts), len(postStmts))
+		}
 
 	case *ast.EnumDecl:
 		decls, err = transpileEnumDecl(p, n)
 
+	case *ast.StaticAssertDecl:
+		err = transpileStaticAssertDecl(p, n)
+
 	case *ast.EmptyDecl:
 		if len(n.Children()) == 0 {
 			// ignore if length is zero, for avoid
@@ -487,6 +578,26 @@ func transpileToNode(node ast.Node, p *program.Program) (decls []goast.Decl, err
 		panic(fmt.Sprintf("cannot transpile to node: %#v", node))
 	}
 
+	if _, ok := node.(*ast.TranslationUnitDecl); !ok {
+		addLineComments(p, node, decls)
+	}
+
+	return
+}
+
+// withoutComments returns the comments that do not have the same text as
+// any of the excluded ones.
+func withoutComments(comments, excluded []*goast.Comment) (
+	result []*goast.Comment) {
+	texts := map[string]bool{}
+	for _, c := range excluded {
+		texts[c.Text] = true
+	}
+	for _, c := range comments {
+		if !texts[c.Text] {
+			result = append(result, c)
+		}
+	}
 	return
 }
 
@@ -0,0 +1,348 @@
+package transpiler
+
+import (
+	goast "go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/preprocessor"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestTranspileASTImports(t *testing.T) {
+	// int f(int a) { return a; }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:26> col:5 f 'int (int)'
+  |-ParmVarDecl 0x11 <col:7, col:11> col:11 used a 'int'
+  |-CompoundStmt 0x12 <col:14, col:26>
+    |-ReturnStmt 0x13 <col:16, col:23>
+      |-ImplicitCastExpr 0x14 <col:23> 'int' <LValueToRValue>
+        |-DeclRefExpr 0x15 <col:23> 'int' lvalue ParmVar 0x11 'a' 'int'
+`
+	want := `
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"github.com/elliotchance/c2go/noarch"
+)
+`
+
+	var outputs []string
+	for _, imports := range [][]string{
+		{"unsafe", "github.com/elliotchance/c2go/noarch", "fmt", "os"},
+		{"os", "fmt", "os", "github.com/elliotchance/c2go/noarch", "unsafe"},
+		{"github.com/elliotchance/c2go/noarch", "unsafe", "os", "fmt"},
+	} {
+		p := program.NewProgram()
+		p.AddImports(imports...)
+		output := transpileDump(t, p, dump)
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected the imports:%s\nin:\n%s", want, output)
+		}
+		outputs = append(outputs, output)
+	}
+
+	for i := range outputs[1:] {
+		if outputs[i+1] != outputs[0] {
+			t.Errorf("The output %d is not the same as the first output:\n%s\n%s",
+				i+1, outputs[i+1], outputs[0])
+		}
+	}
+}
+
+func TestTranspileASTDefines(t *testing.T) {
+	// int f(int a) { return a; }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, col:26> col:5 f 'int (int)'
+  |-ParmVarDecl 0x11 <col:7, col:11> col:11 used a 'int'
+  |-CompoundStmt 0x12 <col:14, col:26>
+    |-ReturnStmt 0x13 <col:16, col:23>
+      |-ImplicitCastExpr 0x14 <col:23> 'int' <LValueToRValue>
+        |-DeclRefExpr 0x15 <col:23> 'int' lvalue ParmVar 0x11 'a' 'int'
+`
+
+	goBuild, plusBuild, err := preprocessor.BuildConstraint(
+		[]string{"__linux__=linux", "__x86_64__=amd64", "_WIN32=windows"},
+		map[string]string{"__linux__": "1", "__x86_64__": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := program.NewProgram()
+	p.Defines = preprocessor.UserDefines([]string{"-DDEBUG", "-D", "LEVEL=2"})
+	p.BuildConstraint, p.PlusBuild = goBuild, plusBuild
+	if err := TranspileAST("x.c", "main", p, parseTree(dump).(*ast.TranslationUnitDecl)); err != nil {
+		t.Fatal(err)
+	}
+
+	output := p.String()
+	if !strings.HasPrefix(output, "//go:build linux && amd64\n// +build linux,amd64\n\n/*") {
+		t.Errorf("Expected the build constraint before the header in:\n%s", output)
+	}
+	want := "\tTranspiled with the preprocessor defines:\n\n\t\tDEBUG\n\t\tLEVEL=2\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected the defines:\n%s\nin:\n%s", want, output)
+	}
+
+	// Without any defines the header does not mention them.
+	p = program.NewProgram()
+	if err := TranspileAST("x.c", "main", p, parseTree(dump).(*ast.TranslationUnitDecl)); err != nil {
+		t.Fatal(err)
+	}
+	output = p.String()
+	if strings.Contains(output, "go:build") || strings.Contains(output, "preprocessor defines") {
+		t.Errorf("Expected no build constraint or defines in:\n%s", output)
+	}
+}
+
+func TestTranspileASTMultipleFiles(t *testing.T) {
+	// main.c:
+	//   extern int calls;
+	//   int total;
+	//   int helper(int n);
+	//   int main() { total = helper(2); return calls; }
+	//
+	// helper.c:
+	//   extern int calls;
+	//   int total;
+	//   int calls = 0;
+	//   int helper(int n) { calls++; return n * n; }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x10 <main.c:1:1, col:12> col:12 calls 'int' extern
+|-VarDecl 0x11 <line:2:1, col:5> col:5 used total 'int'
+|-FunctionDecl 0x12 <line:3:1, col:18> col:5 used helper 'int (int)'
+| |-ParmVarDecl 0x13 <col:12, col:16> col:16 n 'int'
+|-FunctionDecl 0x20 <line:4:1, col:47> col:5 main 'int ()'
+| |-CompoundStmt 0x21 <col:12, col:47>
+|   |-BinaryOperator 0x22 <col:14, col:30> 'int' '='
+|   | |-DeclRefExpr 0x23 <col:14> 'int' lvalue Var 0x11 'total' 'int'
+|   | |-CallExpr 0x24 <col:22, col:30> 'int'
+|   |   |-ImplicitCastExpr 0x25 <col:22> 'int (*)(int)' <FunctionToPointerDecay>
+|   |   | |-DeclRefExpr 0x26 <col:22> 'int (int)' Function 0x12 'helper' 'int (int)'
+|   |   |-IntegerLiteral 0x27 <col:29> 'int' 2
+|   |-ReturnStmt 0x28 <col:33, col:40>
+|     |-ImplicitCastExpr 0x29 <col:40> 'int' <LValueToRValue>
+|       |-DeclRefExpr 0x2a <col:40> 'int' lvalue Var 0x10 'calls' 'int'
+|-VarDecl 0x30 prev 0x10 <helper.c:1:1, col:12> col:12 calls 'int' extern
+|-VarDecl 0x31 prev 0x11 <line:2:1, col:5> col:5 total 'int'
+|-VarDecl 0x32 prev 0x30 <line:3:1, col:13> col:5 used calls 'int' cinit
+| |-IntegerLiteral 0x33 <col:13> 'int' 0
+|-FunctionDecl 0x40 prev 0x12 <line:4:1, col:45> col:5 used helper 'int (int)'
+  |-ParmVarDecl 0x41 <col:12, col:16> col:16 used n 'int'
+  |-CompoundStmt 0x42 <col:19, col:45>
+    |-UnaryOperator 0x43 <col:21, col:26> 'int' postfix '++'
+    | |-DeclRefExpr 0x44 <col:21> 'int' lvalue Var 0x32 'calls' 'int'
+    |-ReturnStmt 0x45 <col:30, col:42>
+      |-BinaryOperator 0x46 <col:37, col:41> 'int' '*'
+        |-ImplicitCastExpr 0x47 <col:37> 'int' <LValueToRValue>
+        | |-DeclRefExpr 0x48 <col:37> 'int' lvalue ParmVar 0x41 'n' 'int'
+        |-ImplicitCastExpr 0x49 <col:41> 'int' <LValueToRValue>
+          |-DeclRefExpr 0x4a <col:41> 'int' lvalue ParmVar 0x41 'n' 'int'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	for want, count := range map[string]int{
+		"var total int32\n":            1,
+		"var calls int32 = int32(0)\n": 1,
+		"func helper(n int32) int32 {": 1,
+		"total = helper(int32(2))":     1,
+		"os.Exit(int(calls))":          1,
+	} {
+		if got := strings.Count(output, want); got != count {
+			t.Errorf("Expected %q %d times, got %d times in:\n%s", want, count, got, output)
+		}
+	}
+	if strings.Contains(output, "Warning") || strings.Contains(output, "Error") {
+		t.Errorf("Unexpected message in:\n%s", output)
+	}
+}
+
+func TestTranspileASTExternVariables(t *testing.T) {
+	// extern int g;
+	// static int s;
+	// int f(void) { return g + s; }
+	// int g = 5;
+	// static int s = 2;
+	// extern int e = 3;
+	// int e;
+	// extern int only;
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x10 <x.c:1:1, col:12> col:12 used g 'int' extern
+|-VarDecl 0x11 <line:2:1, col:12> col:12 used s 'int' static
+|-FunctionDecl 0x20 <line:3:1, col:29> col:5 f 'int (void)'
+| |-CompoundStmt 0x21 <col:13, col:29>
+|   |-ReturnStmt 0x22 <col:15, col:26>
+|     |-BinaryOperator 0x23 <col:22, col:26> 'int' '+'
+|       |-ImplicitCastExpr 0x24 <col:22> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x25 <col:22> 'int' lvalue Var 0x10 'g' 'int'
+|       |-ImplicitCastExpr 0x26 <col:26> 'int' <LValueToRValue>
+|         |-DeclRefExpr 0x27 <col:26> 'int' lvalue Var 0x11 's' 'int'
+|-VarDecl 0x30 prev 0x10 <line:4:1, col:9> col:5 g 'int' cinit
+| |-IntegerLiteral 0x31 <col:9> 'int' 5
+|-VarDecl 0x32 prev 0x11 <line:5:1, col:16> col:12 s 'int' static cinit
+| |-IntegerLiteral 0x33 <col:16> 'int' 2
+|-VarDecl 0x34 <line:6:1, col:16> col:12 e 'int' extern cinit
+| |-IntegerLiteral 0x35 <col:16> 'int' 3
+|-VarDecl 0x36 prev 0x34 <line:7:1, col:5> col:5 e 'int'
+|-VarDecl 0x37 <line:8:1, col:12> col:12 only 'int' extern
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	for want, count := range map[string]int{
+		"var g int32 = int32(5)\n": 1,
+		"var s int32 = int32(2)\n": 1,
+		"var e int32 = int32(3)\n": 1,
+		"var g int32\n":            0,
+		"var s int32\n":            0,
+		"var e int32\n":            0,
+		"var only ":                0,
+		"return g + s":             1,
+	} {
+		if got := strings.Count(output, want); got != count {
+			t.Errorf("Expected %q %d times, got %d times in:\n%s", want, count, got, output)
+		}
+	}
+}
+
+func TestTranspileASTSplitFunctions(t *testing.T) {
+	// int total;
+	// int helper(int n) { return n * n; }
+	// int main() { total = helper(2); return total; }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x10 <x.c:1:1, col:5> col:5 used total 'int'
+|-FunctionDecl 0x20 <line:2:1, col:35> col:5 used helper 'int (int)'
+| |-ParmVarDecl 0x21 <col:12, col:16> col:16 used n 'int'
+| |-CompoundStmt 0x22 <col:19, col:35>
+|   |-ReturnStmt 0x23 <col:21, col:32>
+|     |-BinaryOperator 0x24 <col:28, col:32> 'int' '*'
+|       |-ImplicitCastExpr 0x25 <col:28> 'int' <LValueToRValue>
+|       | |-DeclRefExpr 0x26 <col:28> 'int' lvalue ParmVar 0x21 'n' 'int'
+|       |-ImplicitCastExpr 0x27 <col:32> 'int' <LValueToRValue>
+|         |-DeclRefExpr 0x28 <col:32> 'int' lvalue ParmVar 0x21 'n' 'int'
+|-FunctionDecl 0x30 <line:3:1, col:47> col:5 main 'int ()'
+  |-CompoundStmt 0x31 <col:12, col:47>
+    |-BinaryOperator 0x32 <col:14, col:30> 'int' '='
+    | |-DeclRefExpr 0x33 <col:14> 'int' lvalue Var 0x10 'total' 'int'
+    | |-CallExpr 0x34 <col:22, col:30> 'int'
+    |   |-ImplicitCastExpr 0x35 <col:22> 'int (*)(int)' <FunctionToPointerDecay>
+    |   | |-DeclRefExpr 0x36 <col:22> 'int (int)' Function 0x20 'helper' 'int (int)'
+    |   |-IntegerLiteral 0x37 <col:29> 'int' 2
+    |-ReturnStmt 0x38 <col:33, col:40>
+      |-ImplicitCastExpr 0x39 <col:40> 'int' <LValueToRValue>
+        |-DeclRefExpr 0x3a <col:40> 'int' lvalue Var 0x10 'total' 'int'
+`
+
+	p := program.NewProgram()
+	if err := TranspileAST("app.go", "main", p, parseTree(dump).(*ast.TranslationUnitDecl)); err != nil {
+		t.Fatal(err)
+	}
+	files := p.SplitFiles("app.go")
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, " ") != "app.go app.helper.go app.main.go" {
+		t.Fatalf("Unexpected files: %v", names)
+	}
+
+	for name, want := range map[string]string{
+		"app.go":        "var total int32\n",
+		"app.helper.go": "func helper(n int32) int32 {",
+		"app.main.go":   "os.Exit(int(total))",
+	} {
+		if !strings.Contains(files[name], want) {
+			t.Errorf("Expected %q in %s:\n%s", want, name, files[name])
+		}
+	}
+	if strings.Contains(files["app.go"], "func ") || strings.Contains(files["app.go"], `"os"`) {
+		t.Errorf("Unexpected function or import in the shared file:\n%s", files["app.go"])
+	}
+
+	// All of the files compile together into one package.
+	fset := token.NewFileSet()
+	var parsed []*goast.File
+	for _, name := range names {
+		f, err := parser.ParseFile(fset, name, files[name], 0)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", name, err, files[name])
+		}
+		parsed = append(parsed, f)
+	}
+	conf := gotypes.Config{Importer: importer.Default()}
+	if _, err := conf.Check("main", fset, parsed, nil); err != nil {
+		t.Errorf("The files do not compile: %v", err)
+	}
+}
+
+func TestTranspileASTMessages(t *testing.T) {
+	// int f(int x) {
+	//     __asm__("nop");
+	//     return x + 1;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:4:1> line:1:5 f 'int (int)'
+  |-ParmVarDecl 0x11 <col:7, col:11> col:11 used x 'int'
+  |-CompoundStmt 0x12 <col:14, line:4:1>
+    |-GCCAsmStmt 0x13 <x.c:2:5, col:18>
+    |-ReturnStmt 0x14 <x.c:3:5, col:16>
+      |-BinaryOperator 0x15 <col:12, col:16> 'int' '+'
+        |-ImplicitCastExpr 0x16 <col:12> 'int' <LValueToRValue>
+        | |-DeclRefExpr 0x17 <col:12> 'int' lvalue ParmVar 0x11 'x' 'int'
+        |-IntegerLiteral 0x18 <col:16> 'int' 1
+`
+	p := program.NewProgram()
+	if err := TranspileAST("x.c", "main", p, parseTree(dump).(*ast.TranslationUnitDecl)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The asm is ignored, so the function is transpiled without it, and it is
+	// the only construct that is reported.
+	messages := p.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected one message, got %q", messages)
+	}
+	for _, want := range []string{"Warning (GCCAsmStmt)", "x.c:2", "cannot transpile asm"} {
+		if !strings.Contains(messages[0], want) {
+			t.Errorf("Expected %q in the message %q", want, messages[0])
+		}
+	}
+}
+
+func TestTranspileASTLongDoubleType(t *testing.T) {
+	root := parseTree(`TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>`)
+
+	p := program.NewProgram()
+	p.LongDoubleType = "float32"
+	err := TranspileAST("x.c", "main", p, root)
+	if err == nil || !strings.Contains(err.Error(), `unknown long double type "float32"`) {
+		t.Errorf("Expected an error for the unknown long double type, got %v", err)
+	}
+
+	for _, longDoubleType := range []string{"", program.LongDoubleBigFloat} {
+		p := program.NewProgram()
+		p.LongDoubleType = longDoubleType
+		if err := TranspileAST("x.c", "main", p, root); err != nil {
+			t.Errorf("Unexpected error for %q: %v", longDoubleType, err)
+		}
+	}
+}
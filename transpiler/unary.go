@@ -3,7 +3,9 @@
 package transpiler
 
 import (
+	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/elliotchance/c2go/ast"
@@ -28,6 +30,10 @@ func transpileUnaryOperatorInc(n *ast.UnaryOperator, p *program.Program, operato
 		return
 	}
 
+	if !exprIsStmt {
+		return transpileIncrementValue(n, p)
+	}
+
 	if types.IsPointer(p, n.Type) {
 		switch operator {
 		case token.INC:
@@ -72,20 +78,14 @@ func transpileUnaryOperatorInc(n *ast.UnaryOperator, p *program.Program, operato
 			Op: token.ASSIGN,
 			Y:  expr,
 		}
-		if !exprIsStmt {
-			var lType string
-			lType, err = types.ResolveType(p, leftType)
-			if err != nil {
-				return
-			}
-			expr = util.NewAnonymousFunction([]goast.Stmt{&goast.ExprStmt{
-				X: expr,
-			}}, nil, left, lType)
-		}
 		return
 	}
 
-	if v, ok := n.Children()[0].(*ast.DeclRefExpr); ok {
+	// The long double of program.LongDoubleBigFloat has no += operator, it is
+	// incremented by the binary operator below.
+	goType, _ := types.ResolveType(p, n.Type)
+	if v, ok := n.Children()[0].(*ast.DeclRefExpr); ok && atomicTypeOf(p, v) == "" &&
+		goType != types.BigFloatLongDouble {
 		switch n.Operator {
 		case "++":
 			return &goast.BinaryExpr{
@@ -128,6 +128,152 @@ func transpileUnaryOperatorInc(n *ast.UnaryOperator, p *program.Program, operato
 	}, p, exprIsStmt)
 }
 
+// transpileIncrementValue transpiles an increment or a decrement whose value
+// is used. The value of the prefix operators is the new value, and of the
+// postfix operators it is the old value. The increment of a variable is a pre
+// or a post statement, other lvalues are incremented through a pointer in a
+// closure.
+func transpileIncrementValue(n *ast.UnaryOperator, p *program.Program) (
+	expr goast.Expr, exprType string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+	expr, exprType, preStmts, postStmts, err = transpileToExpr(n, p, true)
+
+	// UnaryOperator 0x3001768 <col:204, col:206> 'int' prefix '++'
+	// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
+	// OR
+	// UnaryOperator 0x3001768 <col:204, col:206> 'int' postfix '++'
+	// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
+	var varName string
+	var vv *ast.DeclRefExpr
+	if vv, err = getSoleChildDeclRefExpr(n); err == nil {
+		varName = vv.Name
+
+		var exprResolveType string
+		exprResolveType, err = types.ResolveType(p, n.Type)
+		if err != nil {
+			return
+		}
+
+		// The increment of a variable is done before the expression for
+		// the prefix operators, and after it for the postfix operators.
+		// The value is read from the variable:
+		//
+		//     x = ++a    =>    a += 1
+		//                      x = a
+		//
+		//     x = a++    =>    x = a
+		//                      a += 1
+		if _, ok := expr.(*goast.BinaryExpr); ok && atomicTypeOf(p, vv) == "" {
+			if n.IsPrefix {
+				preStmts = append(preStmts, &goast.ExprStmt{X: expr})
+			} else {
+				postStmts = append([]goast.Stmt{&goast.ExprStmt{X: expr}}, postStmts...)
+			}
+			expr = util.NewIdent(varName)
+			exprType = n.Type
+			return
+		}
+
+		// operators: ++, --
+		if n.IsPrefix {
+			// Example:
+			// UnaryOperator 0x3001768 <col:204, col:206> 'int' prefix '++'
+			// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
+			expr = util.NewAnonymousFunction(append(preStmts, &goast.ExprStmt{X: expr}),
+				nil,
+				util.NewIdent(varName),
+				exprResolveType)
+			preStmts = nil
+			return
+		}
+		// Example:
+		// UnaryOperator 0x3001768 <col:204, col:206> 'int' postfix '++'
+		// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
+		expr = util.NewAnonymousFunction(preStmts,
+			[]goast.Stmt{&goast.ExprStmt{X: expr}},
+			util.NewIdent(varName),
+			exprResolveType)
+		preStmts = nil
+
+		return
+	}
+
+	// UnaryOperator 0x358d470 <col:28, col:40> 'int' postfix '++'
+	// `-MemberExpr 0x358d438 <col:28, col:36> 'int' lvalue .pos 0x358b538
+	//   `-ArraySubscriptExpr 0x358d410 <col:28, col:34> 'struct struct_I_A':'struct struct_I_A' lvalue
+	//     |-ImplicitCastExpr 0x358d3f8 <col:28> 'struct struct_I_A *' <ArrayToPointerDecay>
+	//     | `-DeclRefExpr 0x358d3b0 <col:28> 'struct struct_I_A [2]' lvalue Var 0x358b6e8 'siia' 'struct struct_I_A [2]'
+	//     `-IntegerLiteral 0x358d3d8 <col:33> 'int' 0
+	varName = "tempVar"
+
+	expr, exprType, preStmts, postStmts, err = transpileToExpr(n.Children()[0], p, false)
+	if err != nil {
+		return
+	}
+
+	body := append(preStmts, &goast.AssignStmt{
+		Lhs: []goast.Expr{util.NewIdent(varName)},
+		Tok: token.DEFINE,
+		Rhs: []goast.Expr{&goast.UnaryExpr{
+			Op: token.AND,
+			X:  expr,
+		}},
+	})
+
+	deferBody := postStmts
+	postStmts = nil
+	preStmts = nil
+
+	switch n.Operator {
+	case "++":
+		expr = &goast.BinaryExpr{
+			X:  &goast.StarExpr{X: util.NewIdent(varName)},
+			Op: token.ADD_ASSIGN,
+			Y:  &goast.BasicLit{Kind: token.INT, Value: "1"},
+		}
+	case "--":
+		expr = &goast.BinaryExpr{
+			X:  &goast.StarExpr{X: util.NewIdent(varName)},
+			Op: token.SUB_ASSIGN,
+			Y:  &goast.BasicLit{Kind: token.INT, Value: "1"},
+		}
+	}
+
+	body = append(body, preStmts...)
+	deferBody = append(deferBody, postStmts...)
+
+	var exprResolveType string
+	exprResolveType, err = types.ResolveType(p, n.Type)
+	if err != nil {
+		return
+	}
+
+	// operators: ++, --
+	if n.IsPrefix {
+		// Example:
+		// UnaryOperator 0x3001768 <col:204, col:206> 'int' prefix '++'
+		// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
+		expr = util.NewAnonymousFunction(append(body, &goast.ExprStmt{X: expr}), deferBody,
+			&goast.StarExpr{
+				X: util.NewIdent(varName),
+			},
+			exprResolveType)
+		preStmts = nil
+		postStmts = nil
+		return
+	}
+	// Example:
+	// UnaryOperator 0x3001768 <col:204, col:206> 'int' postfix '++'
+	// `-DeclRefExpr 0x3001740 <col:206> 'int' lvalue Var 0x303e888 'current_test' 'int'
+	expr = util.NewAnonymousFunction(body, append(deferBody, &goast.ExprStmt{X: expr}),
+		&goast.StarExpr{
+			X: util.NewIdent(varName),
+		},
+		exprResolveType)
+	preStmts = nil
+	postStmts = nil
+	return
+}
+
 func getSoleChildIncrementable(n ast.Node) (result ast.Node, err error) {
 	children := n.Children()
 	if len(children) != 1 {
@@ -254,6 +400,46 @@ func transpileUnaryOperatorAmpersant(n *ast.UnaryOperator, p *program.Program) (
 		return
 	}
 
+	// The address of a compound literal, like "&(struct P){1, 2}", is the
+	// address of the composite literal. A scalar has no composite literal, so
+	// it is the first element of a slice:
+	//
+	//     &(int){5}    =>    &[]int32{int32(5)}[0]
+	if c, ok := n.Children()[0].(*ast.CompoundLiteralExpr); ok {
+		if isScalarCompoundLiteral(p, c) {
+			var goType string
+			goType, err = types.ResolveType(p, c.Type1)
+			if err != nil {
+				return
+			}
+			expr = &goast.IndexExpr{
+				X: &goast.CompositeLit{
+					Type: &goast.ArrayType{Elt: util.NewTypeIdent(goType)},
+					Elts: []goast.Expr{expr},
+				},
+				Index: util.NewIntLit(0),
+			}
+		}
+		expr = &goast.UnaryExpr{
+			X:  expr,
+			Op: token.AND,
+		}
+		eType = n.Type
+		return
+	}
+
+	// The address of a whole array, like "&a" for "int a[10]", is a pointer
+	// to the array "int (*)[10]". The array is a slice in Go, so it is the
+	// address of the slice: *[]int32.
+	if types.IsPointerToArray(n.Type) {
+		expr = &goast.UnaryExpr{
+			X:  expr,
+			Op: token.AND,
+		}
+		eType = n.Type
+		return
+	}
+
 	if types.IsLastArray(eType) {
 		// In : eType = 'int [5]'
 		// Out: eType = 'int *'
@@ -545,7 +731,7 @@ func transpilePointerArith(n *ast.UnaryOperator, p *program.Program) (
 			return
 		}
 		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
-		isConst, indexInt := util.EvaluateConstExpr(e)
+		indexInt, isConst := constantValue(p, n.Children()[0])
 		if isConst && indexInt == 0 {
 			// nop
 		} else if isConst && indexInt < 0 {
@@ -563,9 +749,9 @@ func transpilePointerArith(n *ast.UnaryOperator, p *program.Program) (
 	case *ast.DeclRefExpr:
 		var ident goast.Expr
 		ident = util.NewIdent(v.Name)
-		isConst, indexInt := util.EvaluateConstExpr(e)
+		indexInt, isConst := constantValue(p, n.Children()[0])
 		if isConst && indexInt == 0 {
-			if strings.HasSuffix(v.Type, "]") {
+			if strings.HasSuffix(v.Type, "]") && !types.IsPointerToArray(v.Type) {
 				return &goast.IndexExpr{
 					X:     ident,
 					Index: util.NewIntLit(0),
@@ -590,7 +776,7 @@ func transpilePointerArith(n *ast.UnaryOperator, p *program.Program) (
 			return
 		}
 		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
-		isConst, indexInt := util.EvaluateConstExpr(e)
+		indexInt, isConst := constantValue(p, n.Children()[0])
 		if isConst && indexInt == 0 {
 			// nop
 		} else if isConst && indexInt < 0 {
@@ -619,7 +805,7 @@ func transpilePointerArith(n *ast.UnaryOperator, p *program.Program) (
 				},
 			}, eType, preStmts, postStmts, err
 		}
-		isConst, indexInt := util.EvaluateConstExpr(e)
+		indexInt, isConst := constantValue(p, n.Children()[0])
 		if isConst && indexInt == 0 {
 			// nop
 		} else if isConst && indexInt < 0 {
@@ -671,6 +857,14 @@ func transpileUnaryOperator(n *ast.UnaryOperator, p *program.Program, exprIsStmt
 
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
+	if operator == token.SUB {
+		if t, err := types.ResolveType(p, eType); err == nil && t == types.BigFloatLongDouble {
+			return &goast.CallExpr{
+				Fun: &goast.SelectorExpr{X: e, Sel: util.NewIdent("Neg")},
+			}, eType, preStmts, postStmts, nil
+		}
+	}
+
 	return &goast.UnaryExpr{
 		Op: operator,
 		X:  e,
@@ -678,90 +872,311 @@ func transpileUnaryOperator(n *ast.UnaryOperator, p *program.Program, exprIsStmt
 
 }
 
+// transpileUnaryExprOrTypeTraitExpr transpiles sizeof and alignof into an
+// integer literal. The operand of "sizeof expr" is not evaluated, only its
+// type is used. When the C type has no known layout, like a type that is
+// implemented by the noarch package, it is the size of the Go type:
+//
+//     sizeof(FILE)    =>    uint32(unsafe.Sizeof(*new(noarch.File)))
 func transpileUnaryExprOrTypeTraitExpr(n *ast.UnaryExprOrTypeTraitExpr, p *program.Program) (
-	*goast.BasicLit, string, []goast.Stmt, []goast.Stmt, error) {
+	goast.Expr, string, []goast.Stmt, []goast.Stmt, error) {
 	t := n.Type2
 
 	// It will have children if the sizeof() is referencing a variable.
 	// Fortunately clang already has the type in the AST for us.
 	if len(n.Children()) > 0 {
-		var realFirstChild interface{}
-		t = ""
-
-		switch c := n.Children()[0].(type) {
-		case *ast.ParenExpr:
-			realFirstChild = c.Children()[0]
-		case *ast.DeclRefExpr:
-			t = c.Type
-		default:
-			panic(fmt.Sprintf("cannot find first child from: %#v", n.Children()[0]))
+		var err error
+		t, err = sizeofOperandType(n.Children()[0])
+		if err != nil {
+			p.AddMessage(p.GenerateWarningMessage(err, n))
+			return util.NewIntLit(0), n.Type1, nil, nil, nil
 		}
+	}
 
-		if t == "" {
-			switch ty := realFirstChild.(type) {
-			case *ast.DeclRefExpr:
-				t = ty.Type2
+	layoutOf := types.SizeOf
+	unsafeFunc := "unsafe.Sizeof"
+	if strings.Contains(n.Function, "alignof") {
+		layoutOf = types.AlignOf
+		unsafeFunc = "unsafe.Alignof"
+	} else if elementType, _, ok := types.GetVariableArrayTypeAndSize(t); ok &&
+		len(n.Children()) > 0 {
+		return transpileSizeOfVariableArray(n, elementType, p)
+	}
 
-			case *ast.ArraySubscriptExpr:
-				t = ty.Type
+	size, err := layoutOf(p, t)
+	if err != nil && n.Type3 != "" {
+		// The desugared type, like 'unsigned long' for 'size_t'.
+		size, err = layoutOf(p, n.Type3)
+	}
+	if err == nil {
+		return util.NewIntLit(size), n.Type1, nil, nil, nil
+	}
 
-			case *ast.MemberExpr:
-				t = ty.Type
+	goType, goErr := types.ResolveType(p, t)
+	resultType, resultErr := types.ResolveType(p, n.Type1)
+	if goErr != nil || resultErr != nil {
+		p.AddMessage(p.GenerateWarningMessage(err, n))
+		return util.NewIntLit(0), n.Type1, nil, nil, nil
+	}
 
-			case *ast.UnaryOperator:
-				t = ty.Type
+	p.AddImport("unsafe")
+	return &goast.CallExpr{
+		Fun: util.NewTypeIdent(resultType),
+		Args: []goast.Expr{util.NewCallExpr(unsafeFunc, &goast.StarExpr{
+			X: util.NewCallExpr("new", util.NewTypeIdent(goType)),
+		})},
+	}, n.Type1, nil, nil, nil
+}
 
-			case *ast.ParenExpr:
-				t = ty.Type
+// transpileSizeOfVariableArray returns the size of a variable-length array,
+// that is only known at run time. It is the length of the slice times the size
+// of the elements:
+//
+//     uint32(len(buf)) * 4
+func transpileSizeOfVariableArray(n *ast.UnaryExprOrTypeTraitExpr,
+	elementType string, p *program.Program) (
+	goast.Expr, string, []goast.Stmt, []goast.Stmt, error) {
+	elementSize, err := types.SizeOf(p, elementType)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	resultType, err := types.ResolveType(p, n.Type1)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	array, _, preStmts, postStmts, err := transpileToExpr(n.Children()[0], p, false)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	if paren, ok := array.(*goast.ParenExpr); ok {
+		array = paren.X
+	}
 
-			case *ast.CallExpr:
-				t = ty.Type
+	return util.NewBinaryExpr(
+		util.NewCallExpr(resultType, util.NewCallExpr("len", array)),
+		token.MUL,
+		util.NewIntLit(elementSize),
+		resultType,
+		false,
+	), n.Type1, preStmts, postStmts, nil
+}
 
-			case *ast.CStyleCastExpr:
-				t = ty.Type
+// transpileOffsetOfExpr transpiles offsetof into an integer literal, like
+// sizeof. The clang AST only has the type of the result and the indexes of the
+// arrays, so the type and the members are read from the source:
+//
+//     offsetof(struct s, c)       =>    8
+//     offsetof(struct s, a[i])    =>    16    (i is an enum constant of 2)
+func transpileOffsetOfExpr(n *ast.OffsetOfExpr, p *program.Program) (
+	goast.Expr, string, []goast.Stmt, []goast.Stmt, error) {
+	offset, err := offsetOf(p, n)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
 
-			case *ast.ConditionalOperator:
-				t = ty.Type
+	return util.NewIntLit(offset), n.Type, nil, nil, nil
+}
 
-			case *ast.BinaryOperator:
-				t = ty.Type
+// offsetOf returns the value of offsetof. The operands are read from the line
+// of the expression in the preprocessed source, that has
+// "__builtin_offsetof(struct s, c)" instead of the macro. The indexes of the
+// arrays in the member are the children of the expression.
+func offsetOf(p *program.Program, n *ast.OffsetOfExpr) (int, error) {
+	pos := n.Position()
+	line, ok := p.SourceLines[pos.File][pos.Line]
+	if !ok || pos.Column < 1 || pos.Column > len(line) {
+		return 0, fmt.Errorf("cannot find the source of offsetof")
+	}
 
-			case *ast.IntegerLiteral:
-				t = ty.Type
+	source := line[pos.Column-1:]
+	start := strings.Index(source, "(")
+	depth := 0
+	for end := start; start >= 0 && end < len(source); end++ {
+		switch source[end] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth > 0 {
+			continue
+		}
 
-			case *ast.StringLiteral:
-				t = ty.Type
+		// A type cannot have a comma, so the member is after the first one.
+		operands := strings.SplitN(source[start+1:end], ",", 2)
+		if len(operands) != 2 {
+			break
+		}
+		member, err := offsetOfIndexes(p, n, operands[1])
+		if err != nil {
+			return 0, err
+		}
+		return types.OffsetOf(p, operands[0], member)
+	}
 
-			default:
-				panic(fmt.Sprintf("cannot do unary on: %#v", ty))
+	return 0, fmt.Errorf("cannot find the operands of offsetof in: %s", source)
+}
+
+// offsetOfIndexes replaces the indexes of the arrays in the member of
+// offsetof by the values of the children of the expression, like "a[2].b" for
+// "a[i].b". An index that is not a constant is not supported.
+func offsetOfIndexes(p *program.Program, n *ast.OffsetOfExpr, member string) (
+	string, error) {
+	var buf bytes.Buffer
+	index := 0
+	for i := 0; i < len(member); i++ {
+		buf.WriteByte(member[i])
+		if member[i] != '[' {
+			continue
+		}
+
+		depth := 1
+		for i++; i < len(member) && depth > 0; i++ {
+			switch member[i] {
+			case '[':
+				depth++
+			case ']':
+				depth--
 			}
 		}
+		i -= 2
+		if depth > 0 || index >= len(n.Children()) {
+			return "", fmt.Errorf("cannot find the index of offsetof in: %s", member)
+		}
+
+		value, ok := constantValue(p, n.Children()[index])
+		if !ok {
+			return "", fmt.Errorf("the index of offsetof is not a constant in: %s", member)
+		}
+		buf.WriteString(strconv.FormatInt(value, 10))
+		index++
 	}
 
-	sizeInBytes, err := types.SizeOf(p, t)
-	p.AddMessage(p.GenerateWarningMessage(err, n))
+	return buf.String(), nil
+}
 
-	return util.NewIntLit(sizeInBytes), n.Type1, nil, nil, nil
+// sizeofOperandType returns the C type of the expression that is the operand
+// of sizeof, like 'int [5]' for "sizeof(arr)".
+func sizeofOperandType(n ast.Node) (string, error) {
+	switch v := n.(type) {
+	case *ast.ArraySubscriptExpr:
+		return v.Type, nil
+	case *ast.BinaryConditionalOperator:
+		return v.Type, nil
+	case *ast.BinaryOperator:
+		return v.Type, nil
+	case *ast.CallExpr:
+		return v.Type, nil
+	case *ast.CharacterLiteral:
+		return v.Type, nil
+	case *ast.CompoundAssignOperator:
+		return v.Type, nil
+	case *ast.CompoundLiteralExpr:
+		return v.Type1, nil
+	case *ast.ConditionalOperator:
+		return v.Type, nil
+	case *ast.CStyleCastExpr:
+		return v.Type, nil
+	case *ast.DeclRefExpr:
+		return v.Type, nil
+	case *ast.FloatingLiteral:
+		return v.Type, nil
+	case *ast.GenericSelectionExpr:
+		return v.Type, nil
+	case *ast.ImplicitCastExpr:
+		return v.Type, nil
+	case *ast.IntegerLiteral:
+		return v.Type, nil
+	case *ast.MemberExpr:
+		return v.Type, nil
+	case *ast.ParenExpr:
+		return v.Type, nil
+	case *ast.StmtExpr:
+		return v.Type, nil
+	case *ast.StringLiteral:
+		return v.Type, nil
+	case *ast.UnaryExprOrTypeTraitExpr:
+		return v.Type1, nil
+	case *ast.UnaryOperator:
+		return v.Type, nil
+	}
+
+	return "", fmt.Errorf("cannot find the type of the operand of sizeof: %T", n)
 }
 
+// transpileStmtExpr transpiles a statement expression of GNU C, that is a
+// block with the value of its last statement:
+//
+//     x = ({ int i = 0; while (i < n) i++; i; });
+//
+// The statements are a block in the preStmts that assigns the value to a
+// temporary variable:
+//
+//     var c2goStmtExpr0 int32
+//     {
+//         var i int32 = int32(0)
+//         for i < n {
+//             i += 1
+//         }
+//         c2goStmtExpr0 = i
+//     }
+//     x = c2goStmtExpr0
+//
+// The block keeps the variables of the statements local, so that a macro
+// with a statement expression can be expanded several times in a function.
+//
+// A statement expression without a value is a closure that is called instead.
 func transpileStmtExpr(n *ast.StmtExpr, p *program.Program) (
-	*goast.CallExpr, string, []goast.Stmt, []goast.Stmt, error) {
+	goast.Expr, string, []goast.Stmt, []goast.Stmt, error) {
+	compound := n.Children()[0].(*ast.CompoundStmt)
+	children := compound.Children()
+
+	if n.Type == "void" || len(children) == 0 {
+		body, pre, post, err := transpileCompoundStmt(compound, p)
+		if err != nil {
+			return nil, "", pre, post, err
+		}
+		return util.NewFuncClosure("", body.List...), n.Type, pre, post, nil
+	}
+
 	returnType, err := types.ResolveType(p, n.Type)
 	if err != nil {
 		return nil, "", nil, nil, err
 	}
 
-	body, pre, post, err := transpileCompoundStmt(n.Children()[0].(*ast.CompoundStmt), p)
+	body, pre, post, err := transpileCompoundStmt(&ast.CompoundStmt{
+		Pos:        compound.Pos,
+		ChildNodes: children[:len(children)-1],
+	}, p)
 	if err != nil {
 		return nil, "", pre, post, err
 	}
 
-	// The body of the StmtExpr is always a CompoundStmt. However, the last
-	// statement needs to be transformed into an explicit return statement.
-	lastStmt := body.List[len(body.List)-1]
-	body.List[len(body.List)-1] = &goast.ReturnStmt{
-		Results: []goast.Expr{lastStmt.(*goast.ExprStmt).X},
+	value, valueType, newPre, newPost, err := transpileToExpr(children[len(children)-1], p, false)
+	if err != nil {
+		return nil, "", pre, post, err
+	}
+	value, err = types.CastExpr(p, value, valueType, n.Type)
+	if err != nil {
+		return nil, "", pre, post, err
 	}
 
-	return util.NewFuncClosure(returnType, body.List...), n.Type, pre, post, nil
+	name := util.NewIdent(p.GetNextIdentifier("c2goStmtExpr"))
+	body.List = append(body.List, newPre...)
+	body.List = append(body.List, &goast.AssignStmt{
+		Lhs: []goast.Expr{name},
+		Tok: token.ASSIGN,
+		Rhs: []goast.Expr{value},
+	})
+	body.List = append(body.List, newPost...)
+
+	pre = append(pre, &goast.DeclStmt{Decl: &goast.GenDecl{
+		Tok: token.VAR,
+		Specs: []goast.Spec{&goast.ValueSpec{
+			Names: []*goast.Ident{name},
+			Type:  util.NewTypeIdent(returnType),
+		}},
+	}}, body)
+
+	return name, n.Type, pre, post, nil
 }
@@ -0,0 +1,428 @@
+package transpiler
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestSizeOf(t *testing.T) {
+	// struct P { char c; int x; double d; };
+	p := program.NewProgram()
+	p.Structs["struct P"] = program.NewStruct(parseTree(`
+RecordDecl 0x10 <x.c:1:1, col:40> col:8 struct P definition
+|-FieldDecl 0x11 <col:12, col:17> col:17 c 'char'
+|-FieldDecl 0x12 <col:20, col:24> col:24 x 'int'
+|-FieldDecl 0x13 <col:27, col:34> col:34 d 'double'
+`).(*ast.RecordDecl))
+
+	// #pragma pack(1)
+	// struct Q { char c; int x; };
+	p.Structs["struct Q"] = program.NewStruct(parseTree(`
+RecordDecl 0x14 <x.c:3:1, col:27> col:8 struct Q definition
+|-MaxFieldAlignmentAttr 0x15 <<invalid sloc>> Implicit 8
+|-FieldDecl 0x16 <col:12, col:17> col:17 c 'char'
+|-FieldDecl 0x17 <col:20, col:24> col:24 x 'int'
+`).(*ast.RecordDecl))
+
+	// struct R { char c; int x; char d; } __attribute__((packed));
+	p.Structs["struct R"] = program.NewStruct(parseTree(`
+RecordDecl 0x18 <x.c:4:1, col:35> col:8 struct R definition
+|-PackedAttr 0x19 <col:52>
+|-FieldDecl 0x1a <col:12, col:17> col:17 c 'char'
+|-FieldDecl 0x1b <col:20, col:24> col:24 x 'int'
+|-FieldDecl 0x1c <col:27, col:32> col:32 d 'char'
+`).(*ast.RecordDecl))
+
+	for _, tc := range []struct {
+		name     string
+		dump     string
+		expected string
+	}{
+		{
+			"sizeof(int)",
+			`UnaryExprOrTypeTraitExpr 0x20 <col:1, col:11> 'unsigned long' sizeof 'int'`,
+			"4",
+		},
+		{
+			"sizeof(struct P)",
+			`UnaryExprOrTypeTraitExpr 0x21 <col:1, col:16> 'unsigned long' sizeof 'struct P':'struct P'`,
+			"16",
+		},
+		{
+			"sizeof(arr)",
+			`
+UnaryExprOrTypeTraitExpr 0x22 <col:1, col:11> 'unsigned long' sizeof
+|-ParenExpr 0x23 <col:7, col:11> 'int [5]' lvalue
+  |-DeclRefExpr 0x24 <col:8> 'int [5]' lvalue Var 0x25 'arr' 'int [5]'
+`,
+			"20",
+		},
+		{
+			"sizeof arr[0]",
+			`
+UnaryExprOrTypeTraitExpr 0x26 <col:1, col:13> 'unsigned long' sizeof
+|-ArraySubscriptExpr 0x27 <col:8, col:13> 'int' lvalue
+  |-ImplicitCastExpr 0x28 <col:8> 'int *' <ArrayToPointerDecay>
+  | |-DeclRefExpr 0x29 <col:8> 'int [5]' lvalue Var 0x25 'arr' 'int [5]'
+  |-IntegerLiteral 0x2a <col:12> 'int' 0
+`,
+			"4",
+		},
+		{
+			"sizeof(ptr)",
+			`
+UnaryExprOrTypeTraitExpr 0x2b <col:1, col:11> 'unsigned long' sizeof
+|-ParenExpr 0x2c <col:7, col:11> 'int *' lvalue
+  |-DeclRefExpr 0x2d <col:8> 'int *' lvalue ParmVar 0x2e 'ptr' 'int *'
+`,
+			"8",
+		},
+		{
+			// The size of a variable-length array is only known at run time.
+			"sizeof(buf)",
+			`
+UnaryExprOrTypeTraitExpr 0x31 <col:1, col:11> 'unsigned long' sizeof
+|-ParenExpr 0x32 <col:7, col:11> 'int [n * 2]' lvalue
+  |-DeclRefExpr 0x33 <col:8> 'int [n * 2]' lvalue Var 0x34 'buf' 'int [n * 2]'
+`,
+			"uint64(len(buf)) * 4",
+		},
+		{
+			"_Alignof(struct P)",
+			`UnaryExprOrTypeTraitExpr 0x2f <col:1, col:18> 'unsigned long' alignof 'struct P':'struct P'`,
+			"8",
+		},
+		{
+			// The int follows the char without padding.
+			"sizeof(struct Q)",
+			`UnaryExprOrTypeTraitExpr 0x35 <col:1, col:16> 'unsigned long' sizeof 'struct Q':'struct Q'`,
+			"5",
+		},
+		{
+			"sizeof(struct R)",
+			`UnaryExprOrTypeTraitExpr 0x36 <col:1, col:16> 'unsigned long' sizeof 'struct R':'struct R'`,
+			"6",
+		},
+		{
+			"_Alignof(struct R)",
+			`UnaryExprOrTypeTraitExpr 0x37 <col:1, col:18> 'unsigned long' alignof 'struct R':'struct R'`,
+			"1",
+		},
+		{
+			// FILE is opaque, it is implemented by the noarch package.
+			"sizeof(FILE)",
+			`UnaryExprOrTypeTraitExpr 0x30 <col:1, col:12> 'unsigned long' sizeof 'FILE'`,
+			"uint64(unsafe.Sizeof(*new(noarch.File)))",
+		},
+	} {
+		expr, _, _, _, err := transpileToExpr(parseTree(tc.dump), p, false)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, buf.String())
+		}
+	}
+}
+
+func TestOffsetOf(t *testing.T) {
+	// struct P { char c; int x; double d; };
+	// struct B { unsigned a : 3; unsigned b : 7; char c; };
+	// struct Q { int n; struct P p[3]; };
+	p := program.NewProgram()
+	p.Structs["struct P"] = program.NewStruct(parseTree(`
+RecordDecl 0x10 <x.c:1:1, col:40> col:8 struct P definition
+|-FieldDecl 0x11 <col:12, col:17> col:17 c 'char'
+|-FieldDecl 0x12 <col:20, col:24> col:24 x 'int'
+|-FieldDecl 0x13 <col:27, col:34> col:34 d 'double'
+`).(*ast.RecordDecl))
+	p.Structs["struct B"] = program.NewStruct(parseTree(`
+RecordDecl 0x14 <x.c:2:1, col:52> col:8 struct B definition
+|-FieldDecl 0x15 <col:12, col:25> col:21 a 'unsigned int'
+| |-ConstantExpr 0x16 <col:25> 'int'
+|   |-IntegerLiteral 0x17 <col:25> 'int' 3
+|-FieldDecl 0x18 <col:28, col:41> col:37 b 'unsigned int'
+| |-ConstantExpr 0x19 <col:41> 'int'
+|   |-IntegerLiteral 0x1a <col:41> 'int' 7
+|-FieldDecl 0x1b <col:44, col:49> col:49 c 'char'
+`).(*ast.RecordDecl))
+	p.Structs["struct Q"] = program.NewStruct(parseTree(`
+RecordDecl 0x1c <x.c:3:1, col:35> col:8 struct Q definition
+|-FieldDecl 0x1d <col:12, col:16> col:16 n 'int'
+|-FieldDecl 0x1e <col:19, col:32> col:28 p 'struct P [3]'
+`).(*ast.RecordDecl))
+	p.SourceLines = map[string]map[int]string{
+		"x.c": {
+			4: "    return __builtin_offsetof(struct P, d);",
+			5: "    return __builtin_offsetof(struct B, c);",
+			6: "    return __builtin_offsetof(struct Q, p[1 + 1].x);",
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		dump     string
+		expected string
+	}{
+		{
+			"offsetof(struct P, d)",
+			`OffsetOfExpr 0x20 <x.c:4:12, col:42> 'unsigned long'`,
+			"8",
+		},
+		{
+			// The char is in the byte after the bit-fields.
+			"offsetof(struct B, c)",
+			`OffsetOfExpr 0x21 <x.c:5:12, col:42> 'unsigned long'`,
+			"2",
+		},
+		{
+			// The index of the array is the child of the expression.
+			"offsetof(struct Q, p[1 + 1].x)",
+			`OffsetOfExpr 0x22 <x.c:6:12, col:51> 'unsigned long'
+|-BinaryOperator 0x23 <col:42, col:46> 'int' '+'
+  |-IntegerLiteral 0x24 <col:42> 'int' 1
+  |-IntegerLiteral 0x25 <col:46> 'int' 1`,
+			"44",
+		},
+	} {
+		expr, _, _, _, err := transpileToExpr(parseTree(tc.dump), p, false)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, buf.String())
+		}
+	}
+
+	// There is no value to transpile when the source is missing.
+	_, _, _, _, err := transpileToExpr(parseTree(
+		`OffsetOfExpr 0x26 <x.c:7:12, col:42> 'unsigned long'`), p, false)
+	if err == nil {
+		t.Errorf("Expected an error without the source of offsetof")
+	}
+}
+
+func TestStmtExpr(t *testing.T) {
+	// #define COUNT(n) ({ int i = 0; while (i < n) i++; i; })
+	// int f(int n) {
+	//     int x = COUNT(n);
+	//     int y = COUNT(n);
+	//     return x;
+	// }
+	count := func(addr int) string {
+		return strings.NewReplacer("0x1", fmt.Sprintf("0x%d", addr)).Replace(`
+    | |-StmtExpr 0x10 <col:13, col:55> 'int'
+    |   |-CompoundStmt 0x11 <col:14, col:54>
+    |     |-DeclStmt 0x12 <col:16, col:25>
+    |     | |-VarDecl 0x13 <col:16, col:24> col:20 used i 'int' cinit
+    |     |   |-IntegerLiteral 0x14 <col:24> 'int' 0
+    |     |-WhileStmt 0x15 <col:27, col:45>
+    |     | |-BinaryOperator 0x16 <col:34, col:38> 'int' '<'
+    |     | | |-ImplicitCastExpr 0x17 <col:34> 'int' <LValueToRValue>
+    |     | | | |-DeclRefExpr 0x18 <col:34> 'int' lvalue Var 0x13 'i' 'int'
+    |     | | |-ImplicitCastExpr 0x19 <col:38> 'int' <LValueToRValue>
+    |     | |   |-DeclRefExpr 0x1a <col:38> 'int' lvalue ParmVar 0x02 'n' 'int'
+    |     | |-UnaryOperator 0x1b <col:41, col:42> 'int' postfix '++'
+    |     |   |-DeclRefExpr 0x1c <col:41> 'int' lvalue Var 0x13 'i' 'int'
+    |     |-ImplicitCastExpr 0x1d <col:47> 'int' <LValueToRValue>
+    |       |-DeclRefExpr 0x1e <col:47> 'int' lvalue Var 0x13 'i' 'int'`)
+	}
+	dump := `
+FunctionDecl 0x01 <x.c:2:1, line:6:1> line:2:5 f 'int (int)'
+|-ParmVarDecl 0x02 <col:7, col:11> col:11 used n 'int'
+|-CompoundStmt 0x03 <col:14, line:6:1>
+  |-DeclStmt 0x04 <line:3:5, col:21>
+  | |-VarDecl 0x05 <col:5, col:20> col:9 used x 'int' cinit` + count(2) + `
+  |-DeclStmt 0x06 <line:4:5, col:21>
+  | |-VarDecl 0x07 <col:5, col:20> col:9 y 'int' cinit` + count(3) + `
+  |-ReturnStmt 0x08 <line:5:5, col:12>
+    |-ImplicitCastExpr 0x09 <col:12> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x0a <col:12> 'int' lvalue Var 0x05 'x' 'int'
+`
+
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each expansion has a block of its own, so the two variables named i do
+	// not collide.
+	want := `func f(n int32) int32 {
+	var c2goStmtExpr0 int32
+	{
+		var i int32 = int32(0)
+		for i < n {
+			i += 1
+		}
+		c2goStmtExpr0 = i
+	}
+	var x int32 = c2goStmtExpr0
+	var c2goStmtExpr1 int32
+	{
+		var i int32 = int32(0)
+		for i < n {
+			i += 1
+		}
+		c2goStmtExpr1 = i
+	}
+	var y int32 = c2goStmtExpr1
+	return x
+}`
+	if got := buf.String(); got != want {
+		t.Errorf("Unexpected output:\n%s\nExpected:\n%s", got, want)
+	}
+}
+
+func TestIncrementValue(t *testing.T) {
+	// void f(int);
+	// int g(int *arr, int a, int i) {
+	//     int x, b, y;
+	//     x = a++;
+	//     b = ++a;
+	//     arr[i++] = 5;
+	//     y = arr[i++];
+	//     f(i++);
+	//     return a--;
+	// }
+	dump := `
+FunctionDecl 0x20 <x.c:2:1, line:10:1> line:2:5 g 'int (int *, int, int)'
+|-ParmVarDecl 0x21 <col:7, col:12> col:12 used arr 'int *'
+|-ParmVarDecl 0x22 <col:17, col:21> col:21 used a 'int'
+|-ParmVarDecl 0x23 <col:24, col:28> col:28 used i 'int'
+|-CompoundStmt 0x24 <col:31, line:10:1>
+  |-DeclStmt 0x25 <line:3:5, col:15>
+  | |-VarDecl 0x26 <col:5, col:9> col:9 used x 'int'
+  | |-VarDecl 0x27 <col:5, col:12> col:12 used b 'int'
+  | |-VarDecl 0x28 <col:5, col:15> col:15 used y 'int'
+  |-BinaryOperator 0x30 <line:4:5, col:10> 'int' '='
+  | |-DeclRefExpr 0x31 <col:5> 'int' lvalue Var 0x26 'x' 'int'
+  | |-UnaryOperator 0x32 <col:9, col:10> 'int' postfix '++'
+  |   |-DeclRefExpr 0x33 <col:9> 'int' lvalue ParmVar 0x22 'a' 'int'
+  |-BinaryOperator 0x34 <line:5:5, col:11> 'int' '='
+  | |-DeclRefExpr 0x35 <col:5> 'int' lvalue Var 0x27 'b' 'int'
+  | |-UnaryOperator 0x36 <col:9, col:11> 'int' prefix '++'
+  |   |-DeclRefExpr 0x37 <col:11> 'int' lvalue ParmVar 0x22 'a' 'int'
+  |-BinaryOperator 0x40 <line:6:5, col:16> 'int' '='
+  | |-ArraySubscriptExpr 0x41 <col:5, col:12> 'int' lvalue
+  | | |-ImplicitCastExpr 0x42 <col:5> 'int *' <LValueToRValue>
+  | | | |-DeclRefExpr 0x43 <col:5> 'int *' lvalue ParmVar 0x21 'arr' 'int *'
+  | | |-UnaryOperator 0x44 <col:9, col:10> 'int' postfix '++'
+  | |   |-DeclRefExpr 0x45 <col:9> 'int' lvalue ParmVar 0x23 'i' 'int'
+  | |-IntegerLiteral 0x46 <col:16> 'int' 5
+  |-BinaryOperator 0x50 <line:7:5, col:16> 'int' '='
+  | |-DeclRefExpr 0x51 <col:5> 'int' lvalue Var 0x28 'y' 'int'
+  | |-ImplicitCastExpr 0x52 <col:9, col:16> 'int' <LValueToRValue>
+  |   |-ArraySubscriptExpr 0x53 <col:9, col:16> 'int' lvalue
+  |     |-ImplicitCastExpr 0x54 <col:9> 'int *' <LValueToRValue>
+  |     | |-DeclRefExpr 0x55 <col:9> 'int *' lvalue ParmVar 0x21 'arr' 'int *'
+  |     |-UnaryOperator 0x56 <col:13, col:14> 'int' postfix '++'
+  |       |-DeclRefExpr 0x57 <col:13> 'int' lvalue ParmVar 0x23 'i' 'int'
+  |-CallExpr 0x60 <line:8:5, col:10> 'void'
+  | |-ImplicitCastExpr 0x61 <col:5> 'void (*)(int)' <FunctionToPointerDecay>
+  | | |-DeclRefExpr 0x62 <col:5> 'void (int)' Function 0x10 'f' 'void (int)'
+  | |-UnaryOperator 0x63 <col:7, col:8> 'int' postfix '++'
+  |   |-DeclRefExpr 0x64 <col:7> 'int' lvalue ParmVar 0x23 'i' 'int'
+  |-ReturnStmt 0x70 <line:9:5, col:13>
+    |-UnaryOperator 0x71 <col:12, col:13> 'int' postfix '--'
+      |-DeclRefExpr 0x72 <col:12> 'int' lvalue ParmVar 0x22 'a' 'int'
+`
+	p := program.NewProgram()
+	decls, err := transpileToNode(parseTree(dump), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	expectContains(t, output,
+		"x = a\n\ta += 1\n",
+		"a += 1\n\tb = a\n",
+		"(uintptr)(i)*unsafe.Sizeof(*arr)))) = int32(5)\n\ti += 1\n",
+		"y = *((*int32)(unsafe.Pointer(uintptr(unsafe.Pointer(arr)) + (uintptr)(i)*unsafe.Sizeof(*arr))))\n\ti += 1\n",
+		// The argument is incremented before the call, like in C.
+		"var c2goArg0 int32 = i\n\ti += 1\n\tf(c2goArg0)\n",
+		"defer func() {\n\t\t\ta -= 1\n\t\t}()\n\t\treturn a\n",
+	)
+	// The index is only incremented once for each statement.
+	if n := strings.Count(output, "i += 1"); n != 3 {
+		t.Errorf("Expected 3 increments of i, got %d in:\n%s", n, output)
+	}
+}
+
+func TestIncrementBigFloatLongDouble(t *testing.T) {
+	// long double f(long double x, long double *a, int i) {
+	//     long double y;
+	//     x++;
+	//     a[i++]++;
+	//     --x;
+	//     y = ++x;
+	//     return x--;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-FunctionDecl 0x10 <x.c:1:1, line:8:1> line:1:13 f 'long double (long double, long double *, int)'
+  |-ParmVarDecl 0x11 <col:15, col:27> col:27 used x 'long double'
+  |-ParmVarDecl 0x15 <col:30, col:43> col:43 used a 'long double *'
+  |-ParmVarDecl 0x16 <col:46, col:50> col:50 used i 'int'
+  |-CompoundStmt 0x12 <col:53, line:8:1>
+    |-DeclStmt 0x13 <line:2:5, col:18>
+    | |-VarDecl 0x14 <col:5, col:17> col:17 used y 'long double'
+    |-UnaryOperator 0x20 <line:3:5, col:6> 'long double' postfix '++'
+    | |-DeclRefExpr 0x21 <col:5> 'long double' lvalue ParmVar 0x11 'x' 'long double'
+    |-UnaryOperator 0x30 <line:4:5, col:12> 'long double' postfix '++'
+    | |-ArraySubscriptExpr 0x31 <col:5, col:10> 'long double' lvalue
+    |   |-ImplicitCastExpr 0x32 <col:5> 'long double *' <LValueToRValue>
+    |   | |-DeclRefExpr 0x33 <col:5> 'long double *' lvalue ParmVar 0x15 'a' 'long double *'
+    |   |-UnaryOperator 0x34 <col:7, col:8> 'int' postfix '++'
+    |     |-DeclRefExpr 0x35 <col:7> 'int' lvalue ParmVar 0x16 'i' 'int'
+    |-UnaryOperator 0x22 <line:5:5, col:7> 'long double' prefix '--'
+    | |-DeclRefExpr 0x23 <col:7> 'long double' lvalue ParmVar 0x11 'x' 'long double'
+    |-BinaryOperator 0x24 <line:6:5, col:11> 'long double' '='
+    | |-DeclRefExpr 0x25 <col:5> 'long double' lvalue Var 0x14 'y' 'long double'
+    | |-UnaryOperator 0x26 <col:9, col:11> 'long double' prefix '++'
+    |   |-DeclRefExpr 0x27 <col:11> 'long double' lvalue ParmVar 0x11 'x' 'long double'
+    |-ReturnStmt 0x28 <line:7:5, col:13>
+      |-UnaryOperator 0x29 <col:12, col:13> 'long double' postfix '--'
+        |-DeclRefExpr 0x2a <col:12> 'long double' lvalue ParmVar 0x11 'x' 'long double'
+`
+	p := program.NewProgram()
+	p.LongDoubleType = program.LongDoubleBigFloat
+	output := transpileDump(t, p, dump)
+
+	// The noarch.LongDouble has no += operator. The element is only indexed
+	// once.
+	expectContains(t, output,
+		"var y noarch.LongDouble\n\tx = x.Add(noarch.Int32ToLongDouble(int32(1)))\n",
+		"*c2goTempVar0 = (*c2goTempVar0).Add(noarch.Int32ToLongDouble(int32(1)))\n\ti += 1\n\tx = x.Sub(noarch.Int32ToLongDouble(int32(1)))\n",
+		"x = x.Add(noarch.Int32ToLongDouble(int32(1)))\n\ty = x\n",
+		"x = x.Sub(noarch.Int32ToLongDouble(int32(1)))\n\t\t}()\n\t\treturn x\n",
+	)
+	if strings.Contains(output, "x += 1") || strings.Contains(output, "x -= 1") {
+		t.Errorf("Expected no increment operator in:\n%s", output)
+	}
+}
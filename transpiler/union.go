@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"strings"
 
 	goast "go/ast"
 	"go/format"
@@ -12,37 +13,79 @@ import (
 
 	"github.com/elliotchance/c2go/ast"
 	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
 )
 
-func transpileUnion(name string, size int, fields []*goast.Field) (
-	_ []goast.Decl, err error) {
+// The size of the memory of a union is the size of its largest member in Go,
+// or its size in C if that is larger. The Go size can be larger than the size
+// from the C ABI, like for a pointer under -abi ilp32. The largest of the
+// constants is found by the Go compiler: each size becomes an index of an array
+// literal and the length of the array is one more than the largest index. The
+// sizes are multiplied by the number of sizes and the position of the size is
+// added, so that two equal sizes are not the same index.
+const unionSizeTemplate = `
+const {{ .SizeName }} = (len([...]bool{
+{{- range $i, $t := .Sizes }}
+	unsafe.Sizeof(*(*{{ $t }})(nil))*{{ $.Count }} + {{ $i }}: false,
+{{- end }}
+	{{ .Size }}*{{ .Count }} + {{ len .Sizes }}: false,
+}) - 1) / {{ .Count }}
+{{- if .Pointers }}
 
-	type field struct {
-		Name      string
-		TypeField string
-	}
+const {{ .SizeName }}Words = ({{ .SizeName }} + int(unsafe.Sizeof(unsafe.Pointer(nil))) - 1) / int(unsafe.Sizeof(unsafe.Pointer(nil)))
+{{- end }}
+`
 
-	type union struct {
-		Name   string
-		Size   int
-		Fields []field
-	}
+// The memory of a union is the array of bytes in the struct, so a union is
+// copied by an assignment like in C. The members are read and written through
+// pointers into the array that are returned by the methods. The array of length
+// 0 only gives the struct the alignment of the union.
+//
+// The pointers reinterpret the same bytes, so a member that is read after
+// another member was written sees the bytes in the byte order of the platform,
+// like in C.
+const unionArrayTemplate = `package main
 
-	src := `package main
+import(
+	"unsafe"
+)
+` + unionSizeTemplate + `
+type {{ .Name }} struct{
+{{- if .AlignType }}
+	_ [0]{{ .AlignType }}
+{{- end }}
+	memory {{ .Buffer }}
+}
+
+func (unionVar * {{ .Name }}) copy() ( {{ .Name }}){
+	return *unionVar
+}
+
+{{ range .Fields }}
+func (unionVar * {{ $.Name }}) {{ .Name }}() (*{{ .TypeField }}){
+	return (*{{ .TypeField }})(unsafe.Pointer(&unionVar.memory))
+}
+{{ end }}
+`
+
+// The memory of a union is allocated when a member is used for the first time.
+// The struct only contains the pointer to the memory, so copy() must be used to
+// pass the union by value.
+const unionPointerTemplate = `package main
 
 import(
 	"unsafe"
 	"reflect"
 )
-
+` + unionSizeTemplate + `
 type {{ .Name }} struct{
 	memory unsafe.Pointer
 }
 
 func (unionVar * {{ .Name }}) copy() ( {{ .Name }}){
-	var buffer [{{ .Size }}]byte
+	var buffer {{ .Buffer }}
 	for i := range buffer{
-		buffer[i] = (*((*[{{ .Size }}]byte)(unionVar.memory)))[i]
+		buffer[i] = (*((*{{ .Buffer }})(unionVar.memory)))[i]
 	}
 	var newUnion {{ .Name }}
 	newUnion.memory = unsafe.Pointer(&buffer)
@@ -52,17 +95,60 @@ func (unionVar * {{ .Name }}) copy() ( {{ .Name }}){
 {{ range .Fields }}
 func (unionVar * {{ $.Name }}) {{ .Name }}() (*{{ .TypeField }}){
 	if unionVar.memory == nil{
-		var buffer [{{ $.Size }}]byte
+		var buffer {{ $.Buffer }}
 		unionVar.memory = unsafe.Pointer(&buffer)
 	}
 	return (*{{ .TypeField }})(unionVar.memory)
 }
 {{ end }}
 `
+
+// transpileUnion returns the declarations of a union with a size and an
+// alignment in bytes. The union is a struct with a method for each member that
+// returns a pointer to the member. The memory is program.UnionMemoryArray or
+// program.UnionMemoryPointer, it is how the memory of the members is kept.
+//
+// The garbage collector does not see the pointers that are stored in an array
+// of bytes. The memory of a union with pointer members, see hasPointers, is
+// allocated as an array of unsafe.Pointer instead.
+func transpileUnion(p *program.Program, name, memory string, size, align int,
+	fields []*goast.Field) (_ []goast.Decl, err error) {
+
+	type field struct {
+		Name      string
+		TypeField string
+	}
+
+	type union struct {
+		Name      string
+		Size      int
+		SizeName  string
+		Sizes     []string
+		Count     int
+		Pointers  bool
+		Buffer    string
+		AlignType string
+		Fields    []field
+	}
+
+	src := unionArrayTemplate
+	if memory == program.UnionMemoryPointer {
+		src = unionPointerTemplate
+	}
+
 	// Generate structure of union
 	var un union
 	un.Name = name
 	un.Size = size
+	un.SizeName = "c2goSizeof" + name
+	switch {
+	case align >= 8:
+		un.AlignType = "uint64"
+	case align >= 4:
+		un.AlignType = "uint32"
+	case align >= 2:
+		un.AlignType = "uint16"
+	}
 	for i := range fields {
 		var f field
 		f.Name = fields[i].Names[0].Name
@@ -74,8 +160,17 @@ func (unionVar * {{ $.Name }}) {{ .Name }}() (*{{ .TypeField }}){
 			return
 		}
 		f.TypeField = buf.String()
+		if hasPointers(p, f.TypeField, map[string]bool{}) {
+			un.Pointers = memory == program.UnionMemoryPointer
+		}
 
 		un.Fields = append(un.Fields, f)
+		un.Sizes = append(un.Sizes, f.TypeField)
+	}
+	un.Count = len(un.Sizes) + 1
+	un.Buffer = fmt.Sprintf("[%s]byte", un.SizeName)
+	if un.Pointers {
+		un.Buffer = fmt.Sprintf("[%sWords]unsafe.Pointer", un.SizeName)
 	}
 
 	tmpl := template.Must(template.New("").Parse(src))
@@ -97,6 +192,63 @@ func (unionVar * {{ $.Name }}) {{ .Name }}() (*{{ .TypeField }}){
 	return f.Decls[1:], nil
 }
 
+// hasPointers returns true if a Go type of a member of a union holds any
+// pointer, like "*int32", "[2]func()" or a struct with a pointer field. The
+// types that are not known are assumed to hold pointers. The seen types are
+// not checked again, so that the recursive types end.
+func hasPointers(p *program.Program, goType string, seen map[string]bool) bool {
+	// The elements of an array.
+	for strings.HasPrefix(goType, "[") && !strings.HasPrefix(goType, "[]") {
+		goType = goType[strings.Index(goType, "]")+1:]
+	}
+
+	switch goType {
+	case "bool", "byte", "int8", "int16", "int32", "int64", "uint8", "uint16",
+		"uint32", "uint64", "int", "uint", "uintptr", "float32", "float64",
+		"complex64", "complex128":
+		return false
+	}
+	if seen[goType] {
+		return false
+	}
+	seen[goType] = true
+
+	if t, ok := p.TypedefType[goType]; ok {
+		resolved, err := types.ResolveType(p, t)
+		if err != nil {
+			return true
+		}
+		if resolved != goType {
+			return hasPointers(p, resolved, seen)
+		}
+	}
+	for _, name := range []string{"struct " + goType, "union " + goType} {
+		if s := p.GetStruct(name); s != nil {
+			return structHasPointers(p, s, seen)
+		}
+	}
+	return true
+}
+
+// structHasPointers returns true if any of the fields of a struct or a union
+// holds a pointer in Go, see hasPointers.
+func structHasPointers(p *program.Program, s *program.Struct, seen map[string]bool) bool {
+	for _, f := range s.Fields {
+		switch v := f.(type) {
+		case string:
+			resolved, err := types.ResolveType(p, v)
+			if err != nil || hasPointers(p, resolved, seen) {
+				return true
+			}
+		case *program.Struct:
+			if structHasPointers(p, v, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func isUnionMemberExpr(p *program.Program, n *ast.MemberExpr) (IsUnion bool) {
 	if len(n.Children()) > 0 {
 		if v, ok := n.Children()[0].(*ast.MemberExpr); ok {
@@ -0,0 +1,164 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestUnion(t *testing.T) {
+	// union U { int i; float f; };
+	dump := `
+RecordDecl 0x10 <x.c:1:1, col:28> col:7 union U definition
+|-FieldDecl 0x11 <col:11, col:15> col:15 i 'int'
+|-FieldDecl 0x12 <col:18, col:24> col:24 f 'float'
+`
+
+	tests := []struct {
+		memory string
+		want   string
+	}{
+		{program.UnionMemoryArray, `
+const c2goSizeofU = (len([...]bool{unsafe.Sizeof(*(*int32)(nil))*3 + 0: false, unsafe.Sizeof(*(*float32)(nil))*3 + 1: false, 4*3 + 2: false}) - 1) / 3
+
+type U struct {
+	_      [0]uint32
+	memory [c2goSizeofU]byte
+}
+
+func (unionVar *U) copy() U {
+	return *unionVar
+}
+
+func (unionVar *U) i() *int32 {
+	return (*int32)(unsafe.Pointer(&unionVar.memory))
+}
+
+func (unionVar *U) f() *float32 {
+	return (*float32)(unsafe.Pointer(&unionVar.memory))
+}
+`},
+		{program.UnionMemoryPointer, `
+const c2goSizeofU = (len([...]bool{unsafe.Sizeof(*(*int32)(nil))*3 + 0: false, unsafe.Sizeof(*(*float32)(nil))*3 + 1: false, 4*3 + 2: false}) - 1) / 3
+
+type U struct{ memory unsafe.Pointer }
+
+func (unionVar *U) copy() U {
+	var buffer [c2goSizeofU]byte
+	for i := range buffer {
+		buffer[i] = (*((*[c2goSizeofU]byte)(unionVar.memory)))[i]
+	}
+	var newUnion U
+	newUnion.memory = unsafe.Pointer(&buffer)
+	return newUnion
+}
+
+func (unionVar *U) i() *int32 {
+	if unionVar.memory == nil {
+		var buffer [c2goSizeofU]byte
+		unionVar.memory = unsafe.Pointer(&buffer)
+	}
+	return (*int32)(unionVar.memory)
+}
+
+func (unionVar *U) f() *float32 {
+	if unionVar.memory == nil {
+		var buffer [c2goSizeofU]byte
+		unionVar.memory = unsafe.Pointer(&buffer)
+	}
+	return (*float32)(unionVar.memory)
+}
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.memory, func(t *testing.T) {
+			p := program.NewProgram()
+			p.UnionMemory = tt.memory
+			decls, err := transpileRecordDecl(p, parseTree(dump).(*ast.RecordDecl))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, decl := range decls {
+				var buf bytes.Buffer
+				if err := format.Node(&buf, token.NewFileSet(), decl); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, buf.String())
+			}
+			if s := strings.Join(got, "\n\n") + "\n"; s != tt.want[1:] {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, s)
+			}
+		})
+	}
+}
+
+func TestUnionPointerMembers(t *testing.T) {
+	// union V { int *p; long l; };
+	dump := `
+RecordDecl 0x10 <x.c:1:1, col:27> col:7 union V definition
+|-FieldDecl 0x11 <col:11, col:16> col:16 p 'int *'
+|-FieldDecl 0x12 <col:19, col:24> col:24 l 'long'
+`
+	p := program.NewProgram()
+	p.ABI = program.ILP32
+	decls, err := transpileRecordDecl(p, parseTree(dump).(*ast.RecordDecl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	for _, decl := range decls {
+		if err := format.Node(&buf, token.NewFileSet(), decl); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteString("\n")
+	}
+	output := buf.String()
+
+	// The pointer is 4 bytes in C but 8 bytes in Go, and the memory is
+	// allocated as pointers that are seen by the garbage collector.
+	expectContains(t, output,
+		"const c2goSizeofV = (len([...]bool{unsafe.Sizeof(*(**int32)(nil))*3 + 0: false, unsafe.Sizeof(*(*int32)(nil))*3 + 1: false, 4*3 + 2: false}) - 1) / 3",
+		"type V struct{ memory unsafe.Pointer }",
+		"const c2goSizeofVWords = (c2goSizeofV + int(unsafe.Sizeof(unsafe.Pointer(nil))) - 1) / int(unsafe.Sizeof(unsafe.Pointer(nil)))",
+		"var buffer [c2goSizeofVWords]unsafe.Pointer",
+	)
+	if messages := strings.Join(p.Messages(), "\n"); !strings.Contains(messages, "union V has pointer members") {
+		t.Errorf("Expected a warning about the pointer members, got:\n%s", messages)
+	}
+}
+
+func TestHasPointers(t *testing.T) {
+	p := program.NewProgram()
+	p.Structs["struct P"] = &program.Struct{Name: "P",
+		Fields: map[string]interface{}{"x": "int", "y": "double"}}
+	p.Structs["struct L"] = &program.Struct{Name: "L",
+		Fields: map[string]interface{}{"v": "int", "next": "struct L *"}}
+	p.TypedefType["Point"] = "struct P"
+
+	for goType, want := range map[string]bool{
+		"int32":             false,
+		"[4]float64":        false,
+		"P":                 false,
+		"Point":             false,
+		"[2]Point":          false,
+		"*int32":            true,
+		"[]byte":            true,
+		"func(int32) int32": true,
+		"L":                 true,
+		"[3]L":              true,
+		"noarch.LongDouble": true,
+	} {
+		if got := hasPointers(p, goType, map[string]bool{}); got != want {
+			t.Errorf("hasPointers(%q) = %v, want %v", goType, got, want)
+		}
+	}
+}
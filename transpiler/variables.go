@@ -2,6 +2,7 @@ package transpiler
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/elliotchance/c2go/ast"
@@ -48,7 +49,7 @@ func transpileDeclRefExpr(n *ast.DeclRefExpr, p *program.Program) (
 		// clang don`t show enum constant with enum type,
 		// so we have to use hack for repair the type
 		if v, ok := p.EnumConstantToEnum[n.Name]; ok {
-			expr, exprType, err = util.NewIdent(n.Name), v, nil
+			expr, exprType, err = util.NewIdent(p.GoIdentifier(n.Name)), v, nil
 			return
 		}
 	}
@@ -60,7 +61,7 @@ func transpileDeclRefExpr(n *ast.DeclRefExpr, p *program.Program) (
 		theType = "FILE *"
 	}
 
-	return util.NewIdent(n.Name), theType, nil
+	return util.NewIdent(p.GoIdentifier(n.Name)), theType, nil
 }
 
 func getDefaultValueForVar(p *program.Program, a *ast.VarDecl) (
@@ -99,39 +100,11 @@ func getDefaultValueForVar(p *program.Program, a *ast.VarDecl) (
 	}
 
 	if va, ok := a.Children()[0].(*ast.VAArgExpr); ok {
-		outType, err := types.ResolveType(p, va.Type)
+		expr, exprType, err := transpileVAArgExpr(va, p)
 		if err != nil {
 			return nil, "", nil, nil, err
 		}
-		if a, ok := va.Children()[0].(*ast.ImplicitCastExpr); ok {
-		} else {
-			return nil, "", nil, nil, fmt.Errorf("Expect ImplicitCastExpr for vaar, but we have %T", a)
-		}
-		src := fmt.Sprintf(`package main
-var temp = func() %s {
-	var ret %s
-	if v, ok := c2goVaList.Args[c2goVaList.Pos].(int32); ok{
-		// for 'rune' type
-		ret = %s(v)
-	} else {
-		ret = c2goVaList.Args[c2goVaList.Pos].(%s)
-	}
-	c2goVaList.Pos++
-	return ret
-}()`, outType,
-			outType,
-			outType,
-			outType)
-
-		// Create the AST by parsing src.
-		fset := token.NewFileSet() // positions are relative to fset
-		f, err := parser.ParseFile(fset, "", src, 0)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-
-		expr := f.Decls[0].(*goast.GenDecl).Specs[0].(*goast.ValueSpec).Values
-		return expr, va.Type, nil, nil, nil
+		return []goast.Expr{expr}, exprType, nil, nil, nil
 	}
 
 	defaultValue, defaultValueType, newPre, newPost, err := atomicOperation(a.Children()[0], p)
@@ -208,7 +181,8 @@ func GenerateFuncType(fields, returns []string) *goast.FuncType {
 	return &ft
 }
 
-func transpileInitListExpr(e *ast.InitListExpr, p *program.Program) (goast.Expr, string, error) {
+func transpileInitListExpr(e *ast.InitListExpr, p *program.Program) (
+	_ goast.Expr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
 	resp := []goast.Expr{}
 	var hasArrayFiller = false
 	e.Type1 = types.GenerateCorrectType(e.Type1)
@@ -234,21 +208,74 @@ func transpileInitListExpr(e *ast.InitListExpr, p *program.Program) (goast.Expr,
 			goStruct = p.GetStruct("struct " + goType)
 		}
 	}
+	if goStruct == nil && arraySize == -1 {
+		// The struct of a typedef is the type that it resolves to.
+		goStruct = p.GetStruct(e.Type2)
+		if goStruct == nil {
+			goStruct = p.GetStruct(e.Type1)
+		}
+	}
+	if goStruct != nil && len(goStruct.Bitfields) > 0 {
+		return transpileBitfieldInitList(e, goStruct, goType, p)
+	}
 	fieldIndex := 0
 
+	// A char array can be initialized by a string in braces, like
+	// "char s[] = {"hi"}". It is the same as without the braces.
+	if len(e.Children()) == 1 {
+		if literal, ok := e.Children()[0].(*ast.StringLiteral); ok &&
+			types.GenerateCorrectType(literal.Type) == e.Type1 {
+			expr, exprType, _, _, err := transpileToExpr(literal, p, false)
+			if err != nil {
+				return nil, "", nil, nil, err
+			}
+			expr, err = types.CastExpr(p, expr, exprType, e.Type1)
+			return expr, e.Type1, nil, nil, err
+		}
+	}
+
+	// A designated initializer, like {[2] = 7} or {.y = 1}, leaves out some
+	// of the elements. They are ImplicitValueInitExpr nodes and are left out
+	// of the composite literal too, so that they have the zero value. The
+	// elements after them need keys.
+	hasGap := false
+	var fieldNames []string
+	arrayIndex := 0
+
 	for _, node := range e.Children() {
 		// Skip ArrayFiller
 		if _, ok := node.(*ast.ArrayFiller); ok {
 			hasArrayFiller = true
 			continue
 		}
+		if v, ok := node.(*ast.ImplicitValueInitExpr); ok {
+			if v.IsArrayFiller {
+				hasArrayFiller = true
+				continue
+			}
+			hasGap = true
+			fieldIndex++
+			arrayIndex++
+			continue
+		}
 
 		var expr goast.Expr
 		var exprType string
-		var err error
-		expr, exprType, _, _, err = transpileToExpr(node, p, true)
+		var fieldName string
+		var newPre, newPost []goast.Stmt
+		expr, exprType, newPre, newPost, err = transpileToExpr(node, p, true)
 		if err != nil {
-			return nil, "", err
+			return nil, "", nil, nil, err
+		}
+		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+		// The strings of an array of char arrays, like
+		// "char s[2][4] = {"ab", "cd"}", are the slices of their bytes.
+		if _, ok := node.(*ast.StringLiteral); ok && goStruct == nil && arraySize != -1 {
+			if _, size := types.GetArrayTypeAndSize(arrayType); size != -1 {
+				if expr2, err := types.CastExpr(p, expr, exprType, arrayType); err == nil {
+					expr = expr2
+				}
+			}
 		}
 		if goStruct != nil {
 			if fieldIndex >= len(goStruct.FieldNames) {
@@ -261,15 +288,45 @@ func transpileInitListExpr(e *ast.InitListExpr, p *program.Program) (goast.Expr,
 				goto CONTINUE_INIT
 			}
 			if field, ok := goStruct.Fields[goStruct.FieldNames[fieldIndex]].(string); ok {
-				expr2, err := types.CastExpr(p, expr, exprType, field)
-				if err == nil {
+				if _, size := types.GetArrayTypeAndSize(field); size != -1 {
+					if literal, ok := node.(*ast.StringLiteral); ok {
+						expr = newByteArrayLiteral(literal.Value, size)
+					} else {
+						expr = toArrayLiteral(expr, size)
+					}
+				} else if expr2, err := types.CastExpr(p, expr, exprType, field); err == nil {
 					expr = expr2
 				}
 			}
+			fieldName = fn
 			fieldIndex++
 		}
 	CONTINUE_INIT:
+		if hasGap && arraySize != -1 {
+			expr = &goast.KeyValueExpr{
+				Key:   util.NewIntLit(arrayIndex),
+				Value: expr,
+			}
+		}
+		arrayIndex++
 		resp = append(resp, expr)
+		fieldNames = append(fieldNames, fieldName)
+	}
+
+	// The keyed and the positional fields of a struct cannot be mixed, so all
+	// of them get keys.
+	if hasGap && goStruct != nil && arraySize == -1 {
+		for i := range resp {
+			if fieldNames[i] == "" {
+				p.AddMessage(p.GenerateWarningMessage(
+					fmt.Errorf("cannot find the field of element %d of %s", i, e.Type1), e))
+				continue
+			}
+			resp[i] = &goast.KeyValueExpr{
+				Key:   util.NewIdent(getFieldName(p, goStruct, fieldNames[i])),
+				Value: resp[i],
+			}
+		}
 	}
 
 	var t goast.Expr
@@ -303,7 +360,7 @@ func transpileInitListExpr(e *ast.InitListExpr, p *program.Program) (goast.Expr,
 						},
 					},
 				},
-			}, cTypeString, nil
+			}, cTypeString, preStmts, postStmts, nil
 		}
 
 		t = &goast.ArrayType{
@@ -314,7 +371,7 @@ func transpileInitListExpr(e *ast.InitListExpr, p *program.Program) (goast.Expr,
 	} else {
 		goType, err := types.ResolveType(p, e.Type1)
 		if err != nil {
-			return nil, "", err
+			return nil, "", nil, nil, err
 		}
 
 		t = &goast.Ident{
@@ -327,13 +384,75 @@ func transpileInitListExpr(e *ast.InitListExpr, p *program.Program) (goast.Expr,
 	return &goast.CompositeLit{
 		Type: t,
 		Elts: resp,
-	}, cTypeString, nil
+	}, cTypeString, preStmts, postStmts, nil
+}
+
+// toArrayLiteral returns the composite literal of a Go array for the slice that
+// an InitListExpr of a C array is transpiled into, because the arrays of a
+// struct are Go arrays:
+//
+//     []int32{1, 2}          =>   [2]int32{1, 2}
+//     (&[4]int32{1, 2})[:]   =>   [4]int32{1, 2}
+func toArrayLiteral(expr goast.Expr, size int) goast.Expr {
+	if s, ok := expr.(*goast.SliceExpr); ok {
+		if paren, ok := s.X.(*goast.ParenExpr); ok {
+			if u, ok := paren.X.(*goast.UnaryExpr); ok && u.Op == token.AND {
+				return u.X
+			}
+		}
+	}
+	if c, ok := expr.(*goast.CompositeLit); ok {
+		if a, ok := c.Type.(*goast.ArrayType); ok && a.Len == nil {
+			a.Len = util.NewIntLit(size)
+		}
+	}
+
+	return expr
+}
+
+// newByteArrayLiteral returns the composite literal of a Go array for a string
+// literal that initializes a char array of a struct, like
+// "[8]byte{'a', 'b', 'c'}" for "abc". The rest of the array is zeroed.
+func newByteArrayLiteral(value string, size int) goast.Expr {
+	var elts []goast.Expr
+	for i := 0; i < len(value) && i < size; i++ {
+		elts = append(elts, &goast.BasicLit{
+			Kind:  token.CHAR,
+			Value: strconv.QuoteRuneToASCII(rune(value[i])),
+		})
+	}
+
+	return &goast.CompositeLit{
+		Type: &goast.ArrayType{
+			Len: util.NewIntLit(size),
+			Elt: util.NewTypeIdent("byte"),
+		},
+		Elts: elts,
+	}
 }
 
 func transpileDeclStmt(n *ast.DeclStmt, p *program.Program) (stmts []goast.Stmt, err error) {
 	if len(n.Children()) == 0 {
 		return
 	}
+	// The initializer of a local variable can have statements, like a
+	// statement expression, that are placed around its declaration. A
+	// declaration with a type, like "struct s { int a; } x;", is transpiled
+	// like the declarations of a translation unit instead.
+	if vars, ok := onlyVarDecls(n.Children()); ok {
+		for _, v := range vars {
+			decls, _, preStmts, postStmts, err := transpileVarDecl(p, v)
+			if err != nil {
+				p.AddMessage(p.GenerateErrorMessage(err, n))
+				continue
+			}
+			stmts = append(stmts, preStmts...)
+			stmts = append(stmts, convertDeclToStmt(decls)...)
+			stmts = append(stmts, postStmts...)
+		}
+		return
+	}
+
 	var tud ast.TranslationUnitDecl
 	tud.ChildNodes = n.Children()
 	var decls []goast.Decl
@@ -347,6 +466,19 @@ func transpileDeclStmt(n *ast.DeclStmt, p *program.Program) (stmts []goast.Stmt,
 	return
 }
 
+// onlyVarDecls returns the declarations as variables. ok is false if any of
+// them is not a variable.
+func onlyVarDecls(decls []ast.Node) (vars []*ast.VarDecl, ok bool) {
+	for _, d := range decls {
+		v, ok := d.(*ast.VarDecl)
+		if !ok {
+			return nil, false
+		}
+		vars = append(vars, v)
+	}
+	return vars, true
+}
+
 func transpileArraySubscriptExpr(n *ast.ArraySubscriptExpr, p *program.Program, exprIsStmt bool) (
 	_ goast.Expr, theType string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
 	defer func() {
@@ -375,14 +507,15 @@ func transpileArraySubscriptExpr(n *ast.ArraySubscriptExpr, p *program.Program,
 		expression = se.X
 	}
 
-	isConst, indexInt := util.EvaluateConstExpr(index)
+	indexInt, isConst := constantValue(p, children[1])
 	if isConst && indexInt < 0 {
 		indexInt = -indexInt
 		expression, leftType, newPre, newPost, err =
 			pointerArithmetic(p, expression, leftType, util.NewIntLit(int(indexInt)), "int", token.SUB)
+		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 		return &goast.StarExpr{
 			X: expression,
-		}, n.Type, newPre, newPost, err
+		}, n.Type, preStmts, postStmts, err
 	} else {
 		resolvedLeftType, err := types.ResolveType(p, leftType)
 		if err != nil {
@@ -392,10 +525,11 @@ func transpileArraySubscriptExpr(n *ast.ArraySubscriptExpr, p *program.Program,
 			if !isConst || indexInt != 0 {
 				expression, leftType, newPre, newPost, err =
 					pointerArithmetic(p, expression, leftType, index, indexType, token.ADD)
+				preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 			}
 			return &goast.StarExpr{
 				X: expression,
-			}, n.Type, newPre, newPost, err
+			}, n.Type, preStmts, postStmts, err
 		}
 	}
 
@@ -448,7 +582,7 @@ func transpileMemberExpr(n *ast.MemberExpr, p *program.Program) (
 		err = fmt.Errorf("cannot determine type for LHS '%v'"+
 			", will use 'void *' for all fields. Is lvalue = %v", lhsType, n.IsLvalue)
 		p.AddMessage(p.GenerateWarningMessage(err, n))
-	} else {
+	} else if rhs != "" {
 		if s, ok := structType.Fields[rhs].(string); ok {
 			rhsType = s
 		} else {
@@ -482,9 +616,19 @@ func transpileMemberExpr(n *ast.MemberExpr, p *program.Program) (
 		}
 	}
 
-	// anonymous struct member?
+	// The unnamed field of an anonymous struct or union member is embedded
+	// in a struct, so its members are promoted. In an union it is accessed
+	// with the method named after its type. See transpileAnonymousFieldDecl.
 	if rhs == "" {
-		rhs = "anon"
+		if !isUnionMemberExpr(p, n) {
+			return x, n.Type, preStmts, postStmts, nil
+		}
+		rhs, err = types.ResolveType(p, n.Type)
+		if err != nil {
+			return nil, "", nil, nil, err
+		}
+	} else {
+		rhs = p.GoIdentifier(rhs)
 	}
 
 	if isUnionMemberExpr(p, n) {
@@ -505,8 +649,68 @@ func transpileMemberExpr(n *ast.MemberExpr, p *program.Program) (
 
 	_ = rhsType
 
+	// A bit-field is read with its getter, see bitfields.
+	if structType != nil && structType.Bitfields[n.Name] > 0 {
+		return &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   x,
+				Sel: util.NewIdent(rhs),
+			},
+		}, n.Type, preStmts, postStmts, nil
+	}
+
 	return &goast.SelectorExpr{
 		X:   x,
 		Sel: util.NewIdent(rhs),
 	}, n.Type, preStmts, postStmts, nil
 }
+
+// transpileVAArgExpr transpiles va_arg(). The next variadic argument is taken
+// from c2goVaList which is created from c2goArgs, see "variadic function".
+// Example of AST:
+// `-VAArgExpr 0x2fd8b58 <col:11, col:29> 'int'
+//   `-ImplicitCastExpr 0x2fd8b40 <col:19> 'struct __va_list_tag *' <ArrayToPointerDecay>
+//     `-DeclRefExpr 0x2fd8b18 <col:19> 'va_list':'struct __va_list_tag [1]' lvalue Var 0x2fd8780 'args' 'va_list':'struct __va_list_tag [1]'
+func transpileVAArgExpr(n *ast.VAArgExpr, p *program.Program) (
+	_ goast.Expr, _ string, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot transpileVAArgExpr : err = %v", err)
+		}
+	}()
+	outType, err := types.ResolveType(p, n.Type)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(n.Children()) == 0 {
+		return nil, "", fmt.Errorf("VAArgExpr has no children")
+	}
+	if a, ok := n.Children()[0].(*ast.ImplicitCastExpr); !ok {
+		return nil, "", fmt.Errorf("Expect ImplicitCastExpr for vaar, but we have %T", a)
+	}
+	src := fmt.Sprintf(`package main
+var temp = func() %s {
+	var ret %s
+	if v, ok := c2goVaList.Args[c2goVaList.Pos].(int32); ok{
+		// for 'rune' type
+		ret = %s(v)
+	} else {
+		ret = c2goVaList.Args[c2goVaList.Pos].(%s)
+	}
+	c2goVaList.Pos++
+	return ret
+}()`, outType,
+		outType,
+		outType,
+		outType)
+
+	// Create the AST by parsing src.
+	fset := token.NewFileSet() // positions are relative to fset
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	expr := f.Decls[0].(*goast.GenDecl).Specs[0].(*goast.ValueSpec).Values[0]
+	return expr, n.Type, nil
+}
@@ -0,0 +1,445 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestDesignatedInitializers(t *testing.T) {
+	// struct P { int x; int y; int z; };
+	p := program.NewProgram()
+	p.Structs["struct P"] = program.NewStruct(parseTree(`
+RecordDecl 0x10 <x.c:1:1, col:33> col:8 struct P definition
+|-FieldDecl 0x11 <col:12, col:16> col:16 x 'int'
+|-FieldDecl 0x12 <col:19, col:23> col:23 y 'int'
+|-FieldDecl 0x13 <col:26, col:30> col:30 z 'int'
+`).(*ast.RecordDecl))
+
+	for _, tc := range []struct {
+		name     string
+		dump     string
+		expected string
+	}{
+		{
+			"int a[5] = {[2] = 7}",
+			`
+InitListExpr 0x24 <col:14, col:22> 'int [5]'
+|-array_filler: ImplicitValueInitExpr 0x25 <<invalid sloc>> 'int'
+|-ImplicitValueInitExpr 0x26 <<invalid sloc>> 'int'
+|-ImplicitValueInitExpr 0x27 <<invalid sloc>> 'int'
+|-IntegerLiteral 0x28 <col:21> 'int' 7
+`,
+			"(&[5]int32{2: int32(7)})[:]",
+		},
+		{
+			// The array filler of an older version of clang.
+			"int a[5] = {[2] = 7}",
+			`
+InitListExpr 0x24 <col:14, col:22> 'int [5]'
+|-array filler
+| |-ImplicitValueInitExpr 0x25 <<invalid sloc>> 'int'
+|-ImplicitValueInitExpr 0x26 <<invalid sloc>> 'int'
+|-ImplicitValueInitExpr 0x27 <<invalid sloc>> 'int'
+|-IntegerLiteral 0x28 <col:21> 'int' 7
+`,
+			"(&[5]int32{2: int32(7)})[:]",
+		},
+		{
+			"int a[6] = {1, [3] = 4, 5}",
+			`
+InitListExpr 0x32 <col:14, col:28> 'int [6]'
+|-array_filler: ImplicitValueInitExpr 0x33 <<invalid sloc>> 'int'
+|-IntegerLiteral 0x34 <col:15> 'int' 1
+|-ImplicitValueInitExpr 0x35 <<invalid sloc>> 'int'
+|-ImplicitValueInitExpr 0x36 <<invalid sloc>> 'int'
+|-IntegerLiteral 0x37 <col:24> 'int' 4
+|-IntegerLiteral 0x38 <col:27> 'int' 5
+`,
+			"(&[6]int32{int32(1), 3: int32(4), 4: int32(5)})[:]",
+		},
+		{
+			"struct P p = {.z = 3, .x = 1}",
+			`
+InitListExpr 0x42 <col:16, col:32> 'struct P':'struct P'
+|-IntegerLiteral 0x43 <col:30> 'int' 1
+|-ImplicitValueInitExpr 0x44 <<invalid sloc>> 'int'
+|-IntegerLiteral 0x45 <col:22> 'int' 3
+`,
+			"P{x: int32(1), z: int32(3)}",
+		},
+		{
+			"struct P p = {2}",
+			`
+InitListExpr 0x52 <col:16, col:19> 'struct P'
+|-IntegerLiteral 0x53 <col:17> 'int' 2
+|-ImplicitValueInitExpr 0x54 <<invalid sloc>> 'int'
+|-ImplicitValueInitExpr 0x55 <<invalid sloc>> 'int'
+`,
+			"P{x: int32(2)}",
+		},
+		{
+			"struct P p = {1, 2, 3}",
+			`
+InitListExpr 0x52 <col:16, col:19> 'struct P'
+|-IntegerLiteral 0x53 <col:17> 'int' 1
+|-IntegerLiteral 0x54 <col:17> 'int' 2
+|-IntegerLiteral 0x55 <col:17> 'int' 3
+`,
+			"P{int32(1), int32(2), int32(3)}",
+		},
+	} {
+		expr, _, _, _, err := transpileInitListExpr(parseTree(tc.dump).(*ast.InitListExpr), p)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, buf.String())
+		}
+	}
+}
+
+func TestVariableLengthArray(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+		want string
+	}{
+		// int sum(int n) { int buf[n * 2]; buf[1] = n; return sizeof(buf); }
+		{
+			"local",
+			`
+FunctionDecl 0x10 <x.c:1:1, line:6:1> line:1:5 sum 'unsigned long (int)'
+|-ParmVarDecl 0x11 <col:9, col:13> col:13 used n 'int'
+|-CompoundStmt 0x12 <col:16, line:6:1>
+  |-DeclStmt 0x13 <line:2:3, col:17>
+  | |-VarDecl 0x14 <col:3, col:16> col:7 used buf 'int [n * 2]'
+  |-BinaryOperator 0x20 <line:3:3, col:12> 'int' '='
+  | |-ArraySubscriptExpr 0x21 <col:3, col:8> 'int' lvalue
+  | | |-ImplicitCastExpr 0x22 <col:3> 'int *' <ArrayToPointerDecay>
+  | | | |-DeclRefExpr 0x23 <col:3> 'int [n * 2]' lvalue Var 0x14 'buf' 'int [n * 2]'
+  | | |-IntegerLiteral 0x24 <col:7> 'int' 1
+  | |-ImplicitCastExpr 0x25 <col:12> 'int' <LValueToRValue>
+  |   |-DeclRefExpr 0x26 <col:12> 'int' lvalue ParmVar 0x11 'n' 'int'
+  |-ReturnStmt 0x30 <line:4:3, col:20>
+    |-UnaryExprOrTypeTraitExpr 0x31 <col:10, col:20> 'unsigned long' sizeof
+      |-ParenExpr 0x32 <col:16, col:20> 'int [n * 2]' lvalue
+        |-DeclRefExpr 0x33 <col:17> 'int [n * 2]' lvalue Var 0x14 'buf' 'int [n * 2]'
+`,
+			`
+	var buf []int32 = make([]int32, int(n*2))
+`,
+		},
+		{
+			"sizeof",
+			`
+FunctionDecl 0x10 <x.c:1:1, line:6:1> line:1:5 sum 'unsigned long (int)'
+|-ParmVarDecl 0x11 <col:9, col:13> col:13 used n 'int'
+|-CompoundStmt 0x12 <col:16, line:6:1>
+  |-DeclStmt 0x13 <line:2:3, col:17>
+  | |-VarDecl 0x14 <col:3, col:16> col:7 used buf 'int [n * 2]'
+  |-ReturnStmt 0x30 <line:4:3, col:20>
+    |-UnaryExprOrTypeTraitExpr 0x31 <col:10, col:20> 'unsigned long' sizeof
+      |-ParenExpr 0x32 <col:16, col:20> 'int [n * 2]' lvalue
+        |-DeclRefExpr 0x33 <col:17> 'int [n * 2]' lvalue Var 0x14 'buf' 'int [n * 2]'
+`,
+			`
+	return uint64(len(buf)) * 4
+`,
+		},
+		// int f(int n, int a[n]) { return a[0]; }
+		{
+			"parameter",
+			`
+FunctionDecl 0x40 <x.c:7:1, col:40> col:5 f 'int (int, int [n])'
+|-ParmVarDecl 0x41 <col:7, col:11> col:11 used n 'int'
+|-ParmVarDecl 0x42 <col:14, col:21> col:18 used a 'int [n]'
+|-CompoundStmt 0x43 <col:24, col:40>
+  |-ReturnStmt 0x44 <col:26, col:36>
+    |-ImplicitCastExpr 0x45 <col:33, col:36> 'int' <LValueToRValue>
+      |-ArraySubscriptExpr 0x46 <col:33, col:36> 'int' lvalue
+        |-ImplicitCastExpr 0x47 <col:33> 'int *' <ArrayToPointerDecay>
+        | |-DeclRefExpr 0x48 <col:33> 'int [n]' lvalue ParmVar 0x42 'a' 'int [n]'
+        |-IntegerLiteral 0x49 <col:35> 'int' 0
+`,
+			`
+func f(n int32, a *int32) int32 {
+	return *a
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := program.NewProgram()
+			decls, err := transpileFunctionDecl(parseTree(tt.dump).(*ast.FunctionDecl), p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want[1:]) {
+				t.Errorf("Expected:\n%s\nin:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestRegisterVariables(t *testing.T) {
+	// int f(register int *p) { register int n = *p; auto int m = n; return m; }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:5:1> line:1:5 f 'int (int *)'
+|-ParmVarDecl 0x11 <col:7, col:21> col:21 used p 'int *' register
+|-CompoundStmt 0x12 <col:24, line:5:1>
+  |-DeclStmt 0x13 <line:2:3, col:22>
+  | |-VarDecl 0x14 <col:3, col:21> col:16 used n 'int' register cinit
+  |   |-ImplicitCastExpr 0x15 <col:20, col:21> 'int' <LValueToRValue>
+  |     |-UnaryOperator 0x16 <col:20, col:21> 'int' lvalue prefix '*' cannot overflow
+  |       |-ImplicitCastExpr 0x17 <col:21> 'int *' <LValueToRValue>
+  |         |-DeclRefExpr 0x18 <col:21> 'int *' lvalue ParmVar 0x11 'p' 'int *'
+  |-DeclStmt 0x20 <line:3:3, col:17>
+  | |-VarDecl 0x21 <col:3, col:16> col:12 used m 'int' cinit
+  |   |-ImplicitCastExpr 0x22 <col:16> 'int' <LValueToRValue>
+  |     |-DeclRefExpr 0x23 <col:16> 'int' lvalue Var 0x14 'n' 'int'
+  |-ReturnStmt 0x30 <line:4:3, col:10>
+    |-ImplicitCastExpr 0x31 <col:10> 'int' <LValueToRValue>
+      |-DeclRefExpr 0x32 <col:10> 'int' lvalue Var 0x21 'm' 'int'
+`
+	want := `
+func f(p *int32) int32 {
+	var n int32 = *p
+	var m int32 = n
+	return m
+}`
+
+	// The storage classes are ignored, even when they are part of the types.
+	for _, leak := range []bool{false, true} {
+		tree := parseTree(dump).(*ast.FunctionDecl)
+		if leak {
+			tree.Children()[0].(*ast.ParmVarDecl).Type = "register int *"
+			decls := tree.Children()[1].Children()
+			decls[0].Children()[0].(*ast.VarDecl).Type = "register int"
+			decls[1].Children()[0].(*ast.VarDecl).Type = "auto int"
+		}
+
+		p := program.NewProgram()
+		decls, err := transpileFunctionDecl(tree, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), want[1:]) {
+			t.Errorf("Expected:\n%s\nin:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestArrayOfPointersAndPointerToArray(t *testing.T) {
+	// int f(void) {
+	//     int x, a[10];
+	//     int *ap[10];
+	//     int (*pa)[10] = &a;
+	//     ap[0] = &x;
+	//     return *ap[0] + (*pa)[3];
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:7:1> line:1:5 f 'int (void)'
+|-CompoundStmt 0x11 <col:13, line:7:1>
+  |-DeclStmt 0x12 <line:2:5, col:17>
+  | |-VarDecl 0x13 <col:5, col:9> col:9 used x 'int'
+  | |-VarDecl 0x14 <col:5, col:16> col:12 used a 'int [10]'
+  |-DeclStmt 0x15 <line:3:5, col:16>
+  | |-VarDecl 0x16 <col:5, col:15> col:10 used ap 'int *[10]'
+  |-DeclStmt 0x17 <line:4:5, col:23>
+  | |-VarDecl 0x18 <col:5, col:22> col:11 used pa 'int (*)[10]' cinit
+  |   |-UnaryOperator 0x19 <col:21, col:22> 'int (*)[10]' prefix '&' cannot overflow
+  |     |-DeclRefExpr 0x1a <col:22> 'int [10]' lvalue Var 0x14 'a' 'int [10]'
+  |-BinaryOperator 0x20 <line:5:5, col:14> 'int *' '='
+  | |-ArraySubscriptExpr 0x21 <col:5, col:9> 'int *' lvalue
+  | | |-ImplicitCastExpr 0x22 <col:5> 'int **' <ArrayToPointerDecay>
+  | | | |-DeclRefExpr 0x23 <col:5> 'int *[10]' lvalue Var 0x16 'ap' 'int *[10]'
+  | | |-IntegerLiteral 0x24 <col:8> 'int' 0
+  | |-UnaryOperator 0x25 <col:13, col:14> 'int *' prefix '&' cannot overflow
+  |   |-DeclRefExpr 0x26 <col:14> 'int' lvalue Var 0x13 'x' 'int'
+  |-ReturnStmt 0x30 <line:6:5, col:29>
+    |-BinaryOperator 0x31 <col:12, col:29> 'int' '+'
+      |-ImplicitCastExpr 0x32 <col:12, col:17> 'int' <LValueToRValue>
+      | |-UnaryOperator 0x33 <col:12, col:17> 'int' lvalue prefix '*' cannot overflow
+      |   |-ImplicitCastExpr 0x34 <col:13, col:17> 'int *' <LValueToRValue>
+      |     |-ArraySubscriptExpr 0x35 <col:13, col:17> 'int *' lvalue
+      |       |-ImplicitCastExpr 0x36 <col:13> 'int **' <ArrayToPointerDecay>
+      |       | |-DeclRefExpr 0x37 <col:13> 'int *[10]' lvalue Var 0x16 'ap' 'int *[10]'
+      |       |-IntegerLiteral 0x38 <col:16> 'int' 0
+      |-ImplicitCastExpr 0x40 <col:21, col:29> 'int' <LValueToRValue>
+        |-ArraySubscriptExpr 0x41 <col:21, col:29> 'int' lvalue
+          |-ImplicitCastExpr 0x42 <col:21, col:25> 'int *' <ArrayToPointerDecay>
+          | |-ParenExpr 0x43 <col:21, col:25> 'int [10]' lvalue
+          |   |-UnaryOperator 0x44 <col:22, col:23> 'int [10]' lvalue prefix '*' cannot overflow
+          |     |-ImplicitCastExpr 0x45 <col:23> 'int (*)[10]' <LValueToRValue>
+          |       |-DeclRefExpr 0x46 <col:23> 'int (*)[10]' lvalue Var 0x18 'pa' 'int (*)[10]'
+          |-IntegerLiteral 0x47 <col:28> 'int' 3
+`
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"var ap []*int32 = make([]*int32, 10, 10)",
+		"var pa *[]int32 = &a\n",
+		"*&ap[0] = &x",
+		"return **&ap[0] +",
+		"tempVar := &(*pa)[0]",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestNestedStructInitializers(t *testing.T) {
+	// struct Inner { int a; int b; };
+	// struct Outer { struct Inner in; int c; };
+	// struct Box { struct Inner items[2]; char name[8]; };
+	// int f(void) {
+	//   struct Outer o = {{1, 2}, 3};
+	//   struct Box b = {{{1, 2}, {3, 4}}, "box"};
+	//   struct Outer arr[2] = {{{1, 2}, 3}, {{4, 5}, 6}};
+	//   return o.in.b + o.c;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-RecordDecl 0x2 <x.c:1:1, col:30> col:8 struct Inner definition
+| |-FieldDecl 0x3 <col:16, col:20> col:20 a 'int'
+| |-FieldDecl 0x4 <col:23, col:27> col:27 referenced b 'int'
+|-RecordDecl 0x5 <line:2:1, col:40> col:8 struct Outer definition
+| |-FieldDecl 0x6 <col:16, col:29> col:29 referenced in 'struct Inner':'struct Inner'
+| |-FieldDecl 0x7 <col:33, col:37> col:37 referenced c 'int'
+|-RecordDecl 0x8 <line:3:1, col:51> col:8 struct Box definition
+| |-FieldDecl 0x9 <col:14, col:34> col:27 items 'struct Inner [2]'
+| |-FieldDecl 0xa <col:37, col:48> col:42 name 'char [8]'
+|-FunctionDecl 0x10 <line:4:1, line:9:1> line:4:5 f 'int (void)'
+  |-CompoundStmt 0x11 <col:13, line:9:1>
+    |-DeclStmt 0x20 <line:5:3, col:31>
+    | |-VarDecl 0x21 <col:3, col:30> col:16 used o 'struct Outer':'struct Outer' cinit
+    |   |-InitListExpr 0x22 <col:20, col:30> 'struct Outer':'struct Outer'
+    |     |-InitListExpr 0x23 <col:21, col:26> 'struct Inner':'struct Inner'
+    |     | |-IntegerLiteral 0x24 <col:22> 'int' 1
+    |     | |-IntegerLiteral 0x25 <col:25> 'int' 2
+    |     |-IntegerLiteral 0x26 <col:29> 'int' 3
+    |-DeclStmt 0x30 <line:6:3, col:43>
+    | |-VarDecl 0x31 <col:3, col:42> col:14 b 'struct Box':'struct Box' cinit
+    |   |-InitListExpr 0x32 <col:18, col:42> 'struct Box':'struct Box'
+    |     |-InitListExpr 0x33 <col:19, col:34> 'struct Inner [2]'
+    |     | |-InitListExpr 0x34 <col:20, col:25> 'struct Inner':'struct Inner'
+    |     | | |-IntegerLiteral 0x35 <col:21> 'int' 1
+    |     | | |-IntegerLiteral 0x36 <col:24> 'int' 2
+    |     | |-InitListExpr 0x37 <col:28, col:33> 'struct Inner':'struct Inner'
+    |     |   |-IntegerLiteral 0x38 <col:29> 'int' 3
+    |     |   |-IntegerLiteral 0x39 <col:32> 'int' 4
+    |     |-StringLiteral 0x3a <col:37> 'char [8]' "box"
+    |-DeclStmt 0x40 <line:7:3, col:52>
+    | |-VarDecl 0x41 <col:3, col:51> col:16 arr 'struct Outer [2]' cinit
+    |   |-InitListExpr 0x42 <col:25, col:51> 'struct Outer [2]'
+    |     |-InitListExpr 0x43 <col:26, col:36> 'struct Outer':'struct Outer'
+    |     | |-InitListExpr 0x44 <col:27, col:32> 'struct Inner':'struct Inner'
+    |     | | |-IntegerLiteral 0x45 <col:28> 'int' 1
+    |     | | |-IntegerLiteral 0x46 <col:31> 'int' 2
+    |     | |-IntegerLiteral 0x47 <col:35> 'int' 3
+    |     |-InitListExpr 0x48 <col:39, col:49> 'struct Outer':'struct Outer'
+    |       |-InitListExpr 0x49 <col:40, col:45> 'struct Inner':'struct Inner'
+    |       | |-IntegerLiteral 0x4a <col:41> 'int' 4
+    |       | |-IntegerLiteral 0x4b <col:44> 'int' 5
+    |       |-IntegerLiteral 0x4c <col:48> 'int' 6
+    |-ReturnStmt 0x50 <line:8:3, col:22>
+      |-BinaryOperator 0x51 <col:10, col:22> 'int' '+'
+        |-ImplicitCastExpr 0x52 <col:10, col:15> 'int' <LValueToRValue>
+        | |-MemberExpr 0x53 <col:10, col:15> 'int' lvalue .b 0x4
+        |   |-MemberExpr 0x54 <col:10, col:12> 'struct Inner':'struct Inner' lvalue .in 0x6
+        |     |-DeclRefExpr 0x55 <col:10> 'struct Outer':'struct Outer' lvalue Var 0x21 'o' 'struct Outer':'struct Outer'
+        |-ImplicitCastExpr 0x56 <col:19, col:21> 'int' <LValueToRValue>
+          |-MemberExpr 0x57 <col:19, col:21> 'int' lvalue .c 0x7
+            |-DeclRefExpr 0x58 <col:19> 'struct Outer':'struct Outer' lvalue Var 0x21 'o' 'struct Outer':'struct Outer'
+`
+
+	p := program.NewProgram()
+	output := transpileDump(t, p, dump)
+
+	expectContains(t, output,
+		"var o Outer = Outer{Inner{int32(1), int32(2)}, int32(3)}",
+		// The arrays of a struct are Go arrays.
+		"Box{[2]Inner{Inner{int32(1), int32(2)}, Inner{int32(3), int32(4)}}, [8]byte{'b', 'o', 'x'}}",
+		"[]Outer{Outer{Inner{int32(1), int32(2)}, int32(3)}, Outer{Inner{int32(4), int32(5)}, int32(6)}}",
+		"return o.in.b + o.c",
+	)
+}
+
+func TestCharArrayFromString(t *testing.T) {
+	// void f(void) {
+	//     char s[] = "hello";
+	//     char t[3] = "hello";
+	//     char u[] = {"hi"};
+	//     char v[2][4] = {"ab", "cd"};
+	// }
+	dump := `
+FunctionDecl 0x10 <x.c:1:1, line:6:1> line:1:6 f 'void (void)'
+|-CompoundStmt 0x11 <col:14, line:6:1>
+  |-DeclStmt 0x12 <line:2:5, col:23>
+  | |-VarDecl 0x13 <col:5, col:16> col:10 s 'char [6]' cinit
+  |   |-StringLiteral 0x14 <col:16> 'char [6]' lvalue "hello"
+  |-DeclStmt 0x15 <line:3:5, col:24>
+  | |-VarDecl 0x16 <col:5, col:17> col:10 t 'char [3]' cinit
+  |   |-StringLiteral 0x17 <col:17> 'char [3]' lvalue "hello"
+  |-DeclStmt 0x18 <line:4:5, col:22>
+  | |-VarDecl 0x19 <col:5, col:21> col:10 u 'char [3]' cinit
+  |   |-InitListExpr 0x1a <col:16, col:21> 'char [3]'
+  |     |-StringLiteral 0x1b <col:17> 'char [3]' lvalue "hi"
+  |-DeclStmt 0x1c <line:5:5, col:32>
+    |-VarDecl 0x1d <col:5, col:31> col:10 v 'char [2][4]' cinit
+      |-InitListExpr 0x1e <col:20, col:31> 'char [2][4]'
+        |-StringLiteral 0x1f <col:21> 'char [4]' lvalue "ab"
+        |-StringLiteral 0x20 <col:27> 'char [4]' lvalue "cd"
+`
+	p := program.NewProgram()
+	decls, err := transpileFunctionDecl(parseTree(dump).(*ast.FunctionDecl), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), decls); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`var s []byte = []byte("hello\x00")`,
+		// The string does not fit, so it is truncated without the null
+		// character.
+		`var t []byte = []byte("hel")`,
+		`var u []byte = []byte("hi\x00")`,
+		`var v [][]byte = [][]byte{[]byte("ab\x00\x00"), []byte("cd\x00\x00")}`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// This file contains functions for transpiling the reads and writes of
+// volatile integers into calls of sync/atomic. See Program.VolatileAtomic.
+
+package transpiler
+
+import (
+	goast "go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// atomicType returns the suffix of the sync/atomic functions for a C type,
+// like "Int32" for "volatile int". It is empty when Program.VolatileAtomic is
+// off, when the type is not volatile or when sync/atomic has no functions for
+// its Go type.
+//
+// Only the qualifier of the type itself counts. A volatile pointer, like
+// "int *volatile", is not atomic, because it is a Go pointer. The data of a
+// pointer to volatile data, like "volatile int *", is read and written with
+// sync/atomic through the pointer:
+//
+//     volatile int *p;
+//     *p = 1;           =>    atomic.StoreInt32(p, int32(1))
+func atomicType(p *program.Program, cType string) string {
+	if !p.VolatileAtomic || !isVolatile(cType) {
+		return ""
+	}
+
+	goType, err := types.ResolveType(p, cType)
+	if err != nil {
+		return ""
+	}
+	switch goType {
+	case "int32", "int64", "uint32", "uint64":
+		return util.Ucfirst(goType)
+	}
+
+	return ""
+}
+
+// isVolatile returns true if a C type is qualified with volatile, and not
+// only the type that it points to.
+func isVolatile(cType string) bool {
+	if i := strings.LastIndex(cType, "*"); i != -1 {
+		cType = cType[i+1:]
+	}
+
+	return util.GetRegex(`\bvolatile\b`).MatchString(cType)
+}
+
+// atomicTypeOf returns the atomicType() of the C type of an expression.
+func atomicTypeOf(p *program.Program, n ast.Node) string {
+	cType, _ := sizeofOperandType(n)
+	return atomicType(p, cType)
+}
+
+// newAtomicCall returns the call of a sync/atomic function, like
+// "atomic.LoadInt32(&v)". The first argument is the address of x.
+func newAtomicCall(p *program.Program, function, atomicType string,
+	x goast.Expr, args ...goast.Expr) *goast.CallExpr {
+	p.AddImport("sync/atomic")
+
+	var addr goast.Expr = &goast.UnaryExpr{Op: token.AND, X: x}
+	if star, ok := x.(*goast.StarExpr); ok {
+		addr = star.X
+	}
+
+	return util.NewCallExpr("atomic."+function+atomicType, append([]goast.Expr{addr}, args...)...)
+}
+
+// newAtomicLoad returns the read of a volatile integer with sync/atomic, like
+// "atomic.LoadInt32(&v)", when n is the cast of its value. Otherwise expr, the
+// transpiled child of the cast, is returned.
+func newAtomicLoad(p *program.Program, n *ast.ImplicitCastExpr, expr goast.Expr) goast.Expr {
+	if n.Kind != "LValueToRValue" {
+		return expr
+	}
+	if t := atomicTypeOf(p, n.Children()[0]); t != "" {
+		return newAtomicCall(p, "Load", t, expr)
+	}
+
+	return expr
+}
+
+// transpileAtomicAssign transpiles the assignment of a volatile integer into a
+// store of sync/atomic. A compound assignment stores the result of the
+// operation with the loaded value:
+//
+//     v += 2    =>    atomic.StoreInt32(&v, atomic.LoadInt32(&v)+int32(2))
+//
+// Like in C the whole operation is not atomic, only each read and write is.
+// ok is false if the left side is not atomic, or if the value of the
+// assignment is used, then the assignment is transpiled as usual.
+func transpileAtomicAssign(n *ast.BinaryOperator, p *program.Program, exprIsStmt bool) (
+	_ goast.Expr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt,
+	ok bool, err error) {
+	t := atomicTypeOf(p, n.Children()[0])
+	if !exprIsStmt || t == "" {
+		return
+	}
+
+	left, _, preStmts, postStmts, err := transpileToExpr(n.Children()[0], p, false)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+
+	right, rightType, newPre, newPost, err := atomicOperation(n.Children()[1], p)
+	if err != nil {
+		return nil, "", nil, nil, true, err
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+
+	right, err = types.CastExpr(p, right, rightType, n.Type)
+	p.AddMessage(p.GenerateWarningMessage(err, n))
+
+	if n.Operator != "=" {
+		var hoisted []goast.Stmt
+		left, hoisted = hoistSideEffects(p, left)
+		preStmts = append(preStmts, hoisted...)
+
+		right = &goast.BinaryExpr{
+			X:  newAtomicCall(p, "Load", t, left),
+			Op: getTokenForOperator(strings.TrimSuffix(n.Operator, "=")),
+			Y:  right,
+		}
+	}
+
+	return newAtomicCall(p, "Store", t, left, right), n.Type,
+		preStmts, postStmts, true, nil
+}
@@ -0,0 +1,124 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestVolatileAtomic(t *testing.T) {
+	// volatile int flag;
+	// int f(volatile int *p, int *volatile q) {
+	//   flag = 1;
+	//   flag += 2;
+	//   flag++;
+	//   *p = flag;
+	//   *q = 3;
+	//   return *p + *q;
+	// }
+	dump := `
+TranslationUnitDecl 0x1 <<invalid sloc>> <invalid sloc>
+|-VarDecl 0x10 <x.c:1:1, col:14> col:14 used flag 'volatile int'
+|-FunctionDecl 0x20 <line:2:1, line:9:1> line:2:5 f 'int (volatile int *, int *volatile)'
+  |-ParmVarDecl 0x21 <col:7, col:21> col:21 used p 'volatile int *'
+  |-ParmVarDecl 0x22 <col:24, col:38> col:38 used q 'int *volatile'
+  |-CompoundStmt 0x23 <col:41, line:9:1>
+    |-BinaryOperator 0x30 <line:3:3, col:10> 'int' '='
+    | |-DeclRefExpr 0x31 <col:3> 'volatile int' lvalue Var 0x10 'flag' 'volatile int'
+    | |-IntegerLiteral 0x32 <col:10> 'int' 1
+    |-CompoundAssignOperator 0x33 <line:4:3, col:11> 'int' '+=' ComputeLHSTy='int' ComputeResultTy='int'
+    | |-DeclRefExpr 0x34 <col:3> 'volatile int' lvalue Var 0x10 'flag' 'volatile int'
+    | |-IntegerLiteral 0x35 <col:11> 'int' 2
+    |-UnaryOperator 0x36 <line:5:3, col:7> 'int' postfix '++'
+    | |-DeclRefExpr 0x37 <col:3> 'volatile int' lvalue Var 0x10 'flag' 'volatile int'
+    |-BinaryOperator 0x38 <line:6:3, col:8> 'volatile int' '='
+    | |-UnaryOperator 0x39 <col:3, col:4> 'volatile int' lvalue prefix '*' cannot overflow
+    | | |-ImplicitCastExpr 0x3a <col:4> 'volatile int *' <LValueToRValue>
+    | |   |-DeclRefExpr 0x3b <col:4> 'volatile int *' lvalue ParmVar 0x21 'p' 'volatile int *'
+    | |-ImplicitCastExpr 0x3c <col:8> 'int' <LValueToRValue>
+    |   |-DeclRefExpr 0x3d <col:8> 'volatile int' lvalue Var 0x10 'flag' 'volatile int'
+    |-BinaryOperator 0x40 <line:7:3, col:8> 'int' '='
+    | |-UnaryOperator 0x41 <col:3, col:4> 'int' lvalue prefix '*' cannot overflow
+    | | |-ImplicitCastExpr 0x42 <col:4> 'int *' <LValueToRValue>
+    | |   |-DeclRefExpr 0x43 <col:4> 'int *volatile' lvalue ParmVar 0x22 'q' 'int *volatile'
+    | |-IntegerLiteral 0x44 <col:8> 'int' 3
+    |-ReturnStmt 0x50 <line:8:3, col:15>
+      |-BinaryOperator 0x51 <col:10, col:15> 'int' '+'
+        |-ImplicitCastExpr 0x52 <col:10, col:11> 'int' <LValueToRValue>
+        | |-UnaryOperator 0x53 <col:10, col:11> 'volatile int' lvalue prefix '*' cannot overflow
+        |   |-ImplicitCastExpr 0x54 <col:11> 'volatile int *' <LValueToRValue>
+        |     |-DeclRefExpr 0x55 <col:11> 'volatile int *' lvalue ParmVar 0x21 'p' 'volatile int *'
+        |-ImplicitCastExpr 0x56 <col:15, col:16> 'int' <LValueToRValue>
+          |-UnaryOperator 0x57 <col:15, col:16> 'int' lvalue prefix '*' cannot overflow
+            |-ImplicitCastExpr 0x58 <col:16> 'int *' <LValueToRValue>
+              |-DeclRefExpr 0x59 <col:16> 'int *volatile' lvalue ParmVar 0x22 'q' 'int *volatile'
+`
+
+	for _, tt := range []struct {
+		atomic bool
+		want   []string
+	}{
+		{false, []string{
+			"flag = int32(1)",
+			"flag += int32(2)",
+			"flag += 1",
+			"*p = flag",
+			"*q = int32(3)",
+			"return *p + *q",
+		}},
+		{true, []string{
+			"atomic.StoreInt32(&flag, int32(1))",
+			"atomic.StoreInt32(&flag, atomic.LoadInt32(&flag)+int32(2))",
+			"atomic.StoreInt32(&flag, atomic.LoadInt32(&flag)+int32(1))",
+			"atomic.StoreInt32(p, atomic.LoadInt32(&flag))",
+			// A volatile pointer is not atomic.
+			"*q = int32(3)",
+			"return atomic.LoadInt32(p) + *q",
+		}},
+	} {
+		p := program.NewProgram()
+		p.VolatileAtomic = tt.atomic
+		output := transpileDump(t, p, dump)
+
+		for _, want := range tt.want {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected %q in:\n%s", want, output)
+			}
+		}
+		if got := strings.Contains(output, `"sync/atomic"`); got != tt.atomic {
+			t.Errorf("Expected the import of sync/atomic to be %v in:\n%s", tt.atomic, output)
+		}
+	}
+}
+
+func TestAtomicType(t *testing.T) {
+	p := program.NewProgram()
+	p.VolatileAtomic = true
+
+	for _, tt := range []struct {
+		cType string
+		want  string
+	}{
+		{"volatile int", "Int32"},
+		{"int volatile", "Int32"},
+		{"volatile unsigned int", "Uint32"},
+		{"const volatile long long", "Int64"},
+		{"volatile unsigned long long", "Uint64"},
+		{"volatile int *", ""},
+		{"int *volatile", ""},
+		{"volatile int *volatile", ""},
+		{"volatile char", ""},
+		{"volatile double", ""},
+		{"int", ""},
+	} {
+		if got := atomicType(p, tt.cType); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.cType, tt.want, got)
+		}
+	}
+
+	p.VolatileAtomic = false
+	if got := atomicType(p, "volatile int"); got != "" {
+		t.Errorf("Expected no atomic type without Program.VolatileAtomic, got %q", got)
+	}
+}
@@ -26,6 +26,49 @@ func GetArrayTypeAndSize(s string) (string, int) {
 	return s, -1
 }
 
+// DecayArrayType returns the pointer type that an array decays to, like a
+// parameter of a function that is declared as an array. Only the first
+// dimension decays:
+//
+//     int [10]     -> int *
+//     int [][3]    -> int (*)[3]
+//     int [2][3]   -> int (*)[3]
+//
+// Any other type is returned unchanged.
+func DecayArrayType(s string) string {
+	match := util.GetRegex(`^([^\[\]]*?) ?\[[^\[\]]*\]((\[\d+\])*)$`).FindStringSubmatch(s)
+	if len(match) == 0 || strings.Contains(match[1], "(") {
+		return s
+	}
+
+	if match[2] == "" {
+		if strings.HasSuffix(match[1], "*") {
+			return match[1] + "*"
+		}
+		return match[1] + " *"
+	}
+
+	return match[1] + " (*)" + match[2]
+}
+
+// GetVariableArrayTypeAndSize returns the type of the elements and the size of
+// a variable-length array, like "int [n + 1]". The size is the C expression as
+// it is written in the type. ok is false if the type is not a variable-length
+// array.
+func GetVariableArrayTypeAndSize(s string) (elementType, size string, ok bool) {
+	match := util.GetRegex(`^([\w\* ]*?) ?\[([^\[\]]*[^\[\]\d ][^\[\]]*)\]((\[\d+\])*)$`).FindStringSubmatch(s)
+	if len(match) == 0 {
+		return s, "", false
+	}
+
+	elementType = strings.TrimSpace(match[1])
+	if match[3] != "" {
+		elementType += " " + match[3]
+	}
+
+	return elementType, strings.TrimSpace(match[2]), true
+}
+
 // CastExpr returns an expression that casts one type to another. For
 // reliability and flexability the existing type (fromType) must be structly
 // provided.
@@ -82,6 +125,14 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 		return nil, fmt.Errorf("Expr is nil")
 	}
 
+	if cFromType == "char" && isSignedCharPromotion(p, cToType) {
+		// A constant is folded, because int8(byte(255)) does not compile.
+		if value, ok := integerConstant(expr); ok {
+			return CastExpr(p, util.NewIntLit(int(int8(value))), "signed char", cToType)
+		}
+		return CastExpr(p, util.NewCallExpr("int8", expr), "signed char", cToType)
+	}
+
 	// Function casting
 	// Example :
 	// cFromType  : double (int, float, double)
@@ -146,63 +197,35 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 		}
 	}
 
-	// Checking registered typedef types in program
-	if v, ok := p.TypedefType[toType]; ok {
-		if fromType == v {
-			toType, err := ResolveType(p, toType)
-			if err != nil {
-				return expr, err
-			}
-
-			return &goast.CallExpr{
-				Fun: &goast.Ident{
-					Name: toType,
-				},
-				Lparen: 1,
-				Args: []goast.Expr{
-					&goast.ParenExpr{
-						Lparen: 1,
-						X:      expr,
-						Rparen: 2,
-					},
-				},
-				Rparen: 2,
-			}, nil
-		}
-		e, err := CastExpr(p, expr, fromType, v)
+	// Checking registered typedef types in program. The typedef chains are
+	// resolved to the C type at the end of them, so that the value is only
+	// converted once, and then converted to the Go type of the typedef if it
+	// is a different type.
+	if _, ok := p.TypedefType[toType]; ok {
+		base := resolveTypedef(p, toType)
+		e, err := CastExpr(p, expr, fromType, base)
 		if err != nil {
 			return nil, err
 		}
-		return CastExpr(p, e, v, toType)
+		return castBetweenTypedef(p, e, base, toType)
 	}
-	if v, ok := p.TypedefType[fromType]; ok {
-		t, err := ResolveType(p, v)
+	if _, ok := p.TypedefType[fromType]; ok {
+		base := resolveTypedef(p, fromType)
+		expr, err := castBetweenTypedef(p, expr, fromType, base)
 		if err != nil {
 			return expr, err
 		}
-		expr = &goast.CallExpr{
-			Fun: &goast.Ident{
-				Name: t,
-			},
-			Lparen: 1,
-			Args: []goast.Expr{
-				&goast.ParenExpr{
-					Lparen: 1,
-					X:      expr,
-					Rparen: 2,
-				},
-			},
-			Rparen: 2,
-		}
-		if toType == v {
+		if toType == base {
 			return expr, nil
 		}
-		return CastExpr(p, expr, v, toType)
+		return CastExpr(p, expr, base, toType)
 	}
 
-	// C null pointer can cast to any pointer
+	// C null pointer can cast to any pointer, including a function pointer
+	// that is a Go func.
 	if cFromType == NullPointer && len(cToType) > 0 {
-		if cToType[len(cToType)-1] == '*' {
+		if cToType[len(cToType)-1] == '*' || IsFunction(cToType) ||
+			IsFunction(p.TypedefType[cToType]) {
 			return expr, nil
 		}
 	}
@@ -277,6 +300,7 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 				}
 			}
 		}
+		p.AddImport("unsafe")
 		return util.NewCallExpr("unsafe.Pointer", expr), nil
 	}
 
@@ -297,6 +321,8 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 		return expr, nil
 	}
 
+	expr = wrapIntegerConstant(expr, fromType, toType)
+
 	if fromType == "null" && toType == "[]byte" {
 		return util.NewNil(), nil
 	}
@@ -309,7 +335,9 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 	if strings.HasPrefix(fromType, "[]") && strings.HasPrefix(toType, "*") &&
 		fromType[2:] == toType[1:] {
 		match := util.GetRegex(`\[(\d*)\]$`).FindStringSubmatch(cFromType)
-		if strings.HasSuffix(cToType, "*") && len(match) > 0 {
+		_, _, isVariableArray := GetVariableArrayTypeAndSize(cFromType)
+		isPointer := strings.HasSuffix(cToType, "*") || strings.Contains(cToType, "(*)")
+		if isPointer && (len(match) > 0 || isVariableArray) {
 			// we need to convert from array to pointer
 			return &goast.UnaryExpr{
 				Op: token.AND,
@@ -381,6 +409,18 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 		}
 	}
 
+	// The noarch.LongDouble is converted with the functions of the noarch
+	// package, like noarch.Int32ToLongDouble(). See Program.LongDoubleType.
+	if fromType == BigFloatLongDouble || toType == BigFloatLongDouble {
+		if fromType == "null" {
+			fromType = "int32"
+		}
+		if e, ok := castLongDouble(expr, fromType, toType); ok {
+			p.AddImport("github.com/elliotchance/c2go/noarch")
+			return e, nil
+		}
+	}
+
 	// In the forms of:
 	// - `string` -> `*byte`
 	// - `string` -> `char *[13]`
@@ -475,7 +515,12 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 		return util.NewCallExpr(toType, expr), nil
 	}
 
+	// The pointers to unrelated types, like "char *" and "struct X *", are
+	// converted through an unsafe.Pointer:
+	//
+	//     (*X)(unsafe.Pointer(buf))
 	if strings.HasPrefix(toType, "*") && strings.HasPrefix(fromType, "*") {
+		p.AddImport("unsafe")
 		return &goast.CallExpr{
 			Fun: &goast.ParenExpr{
 				X: util.NewTypeIdent(toType),
@@ -556,6 +601,56 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 	return util.NewCallExpr(functionName, expr), nil
 }
 
+// BigFloatLongDouble is the resolved Go type of the long double when
+// Program.LongDoubleType is program.LongDoubleBigFloat.
+const BigFloatLongDouble = "noarch.LongDouble"
+
+// longDoubleConversions are the Go types that can be converted to and from the
+// long double. Each of them has the name used by the functions of the noarch
+// package, like "Int32" for noarch.Int32ToLongDouble(), and the type of the
+// argument of those functions. The other types are converted to that type
+// first.
+var longDoubleConversions = map[string]struct{ name, goType string }{
+	"bool":    {"Bool", "bool"},
+	"byte":    {"Byte", "byte"},
+	"uint8":   {"Byte", "uint8"},
+	"int":     {"Int64", "int64"},
+	"int8":    {"Int8", "int8"},
+	"int16":   {"Int16", "int16"},
+	"int32":   {"Int32", "int32"},
+	"int64":   {"Int64", "int64"},
+	"uint16":  {"Uint16", "uint16"},
+	"uint32":  {"Uint32", "uint32"},
+	"uint64":  {"Uint64", "uint64"},
+	"float32": {"Float32", "float32"},
+	"float64": {"Float64", "float64"},
+
+	"__uint16_t":         {"Uint16", "uint16"},
+	"size_t":             {"Uint64", "uint64"},
+	"__darwin_ct_rune_t": {"Int32", "int32"},
+	"darwin.CtRuneT":     {"Int32", "int32"},
+}
+
+// castLongDouble converts a value between noarch.LongDouble and one of the
+// longDoubleConversions with the functions of the noarch package. It returns
+// false if neither of the types can be converted.
+func castLongDouble(expr goast.Expr, fromType, toType string) (goast.Expr, bool) {
+	if c, ok := longDoubleConversions[fromType]; ok && toType == BigFloatLongDouble {
+		if c.goType != fromType {
+			expr = util.NewCallExpr(c.goType, expr)
+		}
+		return util.NewCallExpr("noarch."+c.name+"ToLongDouble", expr), true
+	}
+	if c, ok := longDoubleConversions[toType]; ok && fromType == BigFloatLongDouble {
+		expr = util.NewCallExpr("noarch.LongDoubleTo"+c.name, expr)
+		if c.goType != toType {
+			expr = util.NewCallExpr(toType, expr)
+		}
+		return expr, true
+	}
+	return expr, false
+}
+
 func isArrayToPointerExpr(expr goast.Expr) bool {
 	if p1, ok := expr.(*goast.ParenExpr); ok {
 		if p2, ok := p1.X.(*goast.UnaryExpr); ok && p2.Op == token.AND {
@@ -600,3 +695,126 @@ func IsNullExpr(n goast.Expr) bool {
 
 	return false
 }
+
+// resolveTypedef returns the C type at the end of the typedef chain of the C
+// type. For example, "my_size" is resolved to "unsigned long" with:
+//
+//     typedef unsigned long size_t;
+//     typedef size_t my_size;
+func resolveTypedef(p *program.Program, cType string) string {
+	for i := 0; i < len(p.TypedefType); i++ {
+		v, ok := p.TypedefType[cType]
+		if !ok {
+			break
+		}
+		cType = CleanCType(v)
+	}
+	return cType
+}
+
+// castBetweenTypedef converts the expression between a typedef and the C type
+// at the end of its typedef chain. Nothing is converted when both of them are
+// the same type in Go, like "size_t" and "unsigned long".
+func castBetweenTypedef(p *program.Program, expr goast.Expr, cFromType, cToType string) (
+	goast.Expr, error) {
+	fromType, err := ResolveType(p, cFromType)
+	if err != nil {
+		return expr, err
+	}
+	toType, err := ResolveType(p, cToType)
+	if err != nil {
+		return expr, err
+	}
+	if fromType == toType {
+		return expr, nil
+	}
+
+	// A pointer type needs parentheses, "*byte(x)" would be a dereference.
+	var fun goast.Expr = goast.NewIdent(toType)
+	if strings.HasPrefix(toType, "*") {
+		fun = &goast.ParenExpr{X: fun}
+	}
+	return &goast.CallExpr{
+		Fun:    fun,
+		Lparen: 1,
+		Args:   []goast.Expr{expr},
+	}, nil
+}
+
+// isSignedCharPromotion returns true if a plain char that is converted to
+// cToType must be converted to int8 first, because char is signed on the
+// target platform and cToType is a wider number, see program.ABI.CharSigned.
+func isSignedCharPromotion(p *program.Program, cToType string) bool {
+	abi := p.ABI
+	if abi == nil {
+		abi = program.LP64
+	}
+	if !abi.CharSigned {
+		return false
+	}
+
+	toType, err := ResolveType(p, cToType)
+	if err != nil {
+		return false
+	}
+	switch toType {
+	case "int16", "int32", "int64", "uint16", "uint32", "uint64", "rune",
+		"float32", "float64":
+		return true
+	}
+	return false
+}
+
+// integerBits are the widths of the Go integer types.
+var integerBits = map[string]uint{
+	"int8": 8, "int16": 16, "int32": 32, "rune": 32, "int64": 64,
+	"byte": 8, "uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64,
+}
+
+// integerConstant returns the value of an integer constant, that may be
+// converted to a Go integer type, like "byte(255)".
+func integerConstant(expr goast.Expr) (int64, bool) {
+	if call, ok := expr.(*goast.CallExpr); ok && len(call.Args) == 1 {
+		if fun, ok := call.Fun.(*goast.Ident); ok && integerBits[fun.Name] > 0 {
+			expr = call.Args[0]
+		}
+	}
+	isConst, value := util.EvaluateConstExpr(expr)
+	return value, isConst
+}
+
+// wrapIntegerConstant returns the value of an integer constant that is
+// converted to a Go integer type that cannot hold it, like C does:
+//
+//     (unsigned char)300    =>    uint8(44)
+//     (unsigned int)-1      =>    uint32(4294967295)
+//
+// Go wraps the value of a variable when it is converted, but a constant that
+// overflows the type does not compile.
+func wrapIntegerConstant(expr goast.Expr, fromType, toType string) goast.Expr {
+	bits, ok := integerBits[toType]
+	if _, isInteger := integerBits[fromType]; !ok || !isInteger {
+		return expr
+	}
+	isConst, value := util.EvaluateConstExpr(expr)
+	if !isConst {
+		return expr
+	}
+
+	if strings.HasPrefix(toType, "u") || toType == "byte" {
+		wrapped := uint64(value)
+		if bits < 64 {
+			wrapped &= 1<<bits - 1
+		}
+		if value >= 0 && uint64(value) == wrapped {
+			return expr
+		}
+		return &goast.BasicLit{Kind: token.INT, Value: strconv.FormatUint(wrapped, 10)}
+	}
+
+	wrapped := value << (64 - bits) >> (64 - bits)
+	if wrapped == value {
+		return expr
+	}
+	return util.NewIntLit(int(wrapped))
+}
@@ -1,15 +1,19 @@
 package types
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/elliotchance/c2go/program"
 	"github.com/elliotchance/c2go/util"
 
 	goast "go/ast"
+	"go/format"
+	"go/parser"
 	"go/token"
 )
 
@@ -39,6 +43,17 @@ func TestCast(t *testing.T) {
 		{args{util.NewIntLit(1), "int", "double"}, util.NewCallExpr("float64", util.NewIntLit(1))},
 		{args{util.NewIntLit(1), "int", "__uint16_t"}, util.NewCallExpr("uint16", util.NewIntLit(1))},
 
+		// A plain char is signed on the default ABI.
+		{args{util.NewIdent("c"), "char", "int"}, util.NewCallExpr("int32", util.NewCallExpr("int8", util.NewIdent("c")))},
+		{args{util.NewIntLit(1), "char", "int"}, util.NewCallExpr("int32", util.NewIntLit(1))},
+		{args{util.NewIntLit(1), "char", "unsigned char"}, util.NewCallExpr("uint8", util.NewIntLit(1))},
+
+		// The constants of a char above 127 are negative.
+		{args{util.NewCallExpr("byte", util.NewIntLit(255)), "char", "int"}, util.NewCallExpr("int32", util.NewIntLit(-1))},
+		{args{util.NewIntLit(200), "char", "long"}, util.NewCallExpr("int64", util.NewIntLit(-56))},
+		{args{util.NewCallExpr("byte", util.NewIntLit(255)), "char", "unsigned int"},
+			util.NewCallExpr("uint32", &goast.BasicLit{Kind: token.INT, Value: "4294967295"})},
+
 		// Casting to bool
 		{args{util.NewIntLit(1), "int", "bool"}, util.NewBinaryExpr(util.NewIntLit(1), token.NEQ, util.NewIntLit(0), "bool", false)},
 	}
@@ -61,6 +76,140 @@ func TestCast(t *testing.T) {
 	}
 }
 
+func TestCastLongDouble(t *testing.T) {
+	p := program.NewProgram()
+	p.LongDoubleType = program.LongDoubleBigFloat
+
+	tests := []struct {
+		fromType string
+		toType   string
+		want     goast.Expr
+	}{
+		{"int", "long double", util.NewCallExpr("noarch.Int32ToLongDouble", util.NewIntLit(1))},
+		{"long double", "double", util.NewCallExpr("noarch.LongDoubleToFloat64", util.NewIntLit(1))},
+		{"long double", "bool", util.NewCallExpr("noarch.LongDoubleToBool", util.NewIntLit(1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fromType+" -> "+tt.toType, func(t *testing.T) {
+			got, err := CastExpr(p, util.NewIntLit(1), tt.fromType, tt.toType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cast()%s\n", util.ShowDiff(toJSON(got), toJSON(tt.want)))
+			}
+		})
+	}
+}
+
+func TestCastLongDoubleTypes(t *testing.T) {
+	// All of the conversions must call a function of noarch/longdouble.go.
+	file, err := parser.ParseFile(token.NewFileSet(), "../noarch/longdouble.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions := map[string]bool{}
+	for _, d := range file.Decls {
+		if f, ok := d.(*goast.FuncDecl); ok && f.Recv == nil {
+			functions["noarch."+f.Name.Name] = true
+		}
+	}
+
+	tests := []struct {
+		goType string
+		to     string
+		from   string
+	}{
+		{"bool", "noarch.BoolToLongDouble(x)", "noarch.LongDoubleToBool(x)"},
+		{"byte", "noarch.ByteToLongDouble(x)", "noarch.LongDoubleToByte(x)"},
+		{"int", "noarch.Int64ToLongDouble(int64(x))", "int(noarch.LongDoubleToInt64(x))"},
+		{"int8", "noarch.Int8ToLongDouble(x)", "noarch.LongDoubleToInt8(x)"},
+		{"int16", "noarch.Int16ToLongDouble(x)", "noarch.LongDoubleToInt16(x)"},
+		{"int32", "noarch.Int32ToLongDouble(x)", "noarch.LongDoubleToInt32(x)"},
+		{"int64", "noarch.Int64ToLongDouble(x)", "noarch.LongDoubleToInt64(x)"},
+		{"uint8", "noarch.ByteToLongDouble(x)", "noarch.LongDoubleToByte(x)"},
+		{"uint16", "noarch.Uint16ToLongDouble(x)", "noarch.LongDoubleToUint16(x)"},
+		{"uint32", "noarch.Uint32ToLongDouble(x)", "noarch.LongDoubleToUint32(x)"},
+		{"uint64", "noarch.Uint64ToLongDouble(x)", "noarch.LongDoubleToUint64(x)"},
+		{"float32", "noarch.Float32ToLongDouble(x)", "noarch.LongDoubleToFloat32(x)"},
+		{"float64", "noarch.Float64ToLongDouble(x)", "noarch.LongDoubleToFloat64(x)"},
+		{"__uint16_t", "noarch.Uint16ToLongDouble(uint16(x))", "__uint16_t(noarch.LongDoubleToUint16(x))"},
+		{"size_t", "noarch.Uint64ToLongDouble(uint64(x))", "size_t(noarch.LongDoubleToUint64(x))"},
+		{"__darwin_ct_rune_t", "noarch.Int32ToLongDouble(int32(x))", "__darwin_ct_rune_t(noarch.LongDoubleToInt32(x))"},
+		{"darwin.CtRuneT", "noarch.Int32ToLongDouble(int32(x))", "darwin.CtRuneT(noarch.LongDoubleToInt32(x))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			for _, c := range []struct {
+				fromType, toType, want string
+			}{
+				{tt.goType, BigFloatLongDouble, tt.to},
+				{BigFloatLongDouble, tt.goType, tt.from},
+			} {
+				got, ok := castLongDouble(util.NewIdent("x"), c.fromType, c.toType)
+				if !ok {
+					t.Fatalf("%s -> %s is not converted", c.fromType, c.toType)
+				}
+
+				var buf bytes.Buffer
+				if err := format.Node(&buf, token.NewFileSet(), got); err != nil {
+					t.Fatal(err)
+				}
+				if buf.String() != c.want {
+					t.Errorf("Expected %q, got %q", c.want, buf.String())
+				}
+				for _, name := range util.GetRegex(`noarch\.\w+`).FindAllString(c.want, -1) {
+					if !functions[name] {
+						t.Errorf("%s does not exist", name)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCastTypedef(t *testing.T) {
+	p := program.NewProgram()
+	p.TypedefType["size_t"] = "unsigned long"
+	p.TypedefType["length"] = "size_t"
+	p.TypedefType["text"] = "char *"
+
+	tests := []struct {
+		fromType string
+		toType   string
+		want     string
+	}{
+		{"int", "size_t", "uint64(x)"},
+		{"size_t", "int", "int32(x)"},
+		{"unsigned long", "size_t", "x"},
+		{"int", "length", "length(uint64(x))"},
+		{"length", "int", "int32(uint64(x))"},
+		{"length", "length", "x"},
+		{"char *", "text", "text(x)"},
+		{"text", "char *", "(*byte)(x)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fromType+" -> "+tt.toType, func(t *testing.T) {
+			got, err := CastExpr(p, util.NewIdent("x"), tt.fromType, tt.toType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), got); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
 func TestGetArrayTypeAndSize(t *testing.T) {
 	tests := []struct {
 		in    string
@@ -92,3 +241,125 @@ func TestGetArrayTypeAndSize(t *testing.T) {
 		}
 	}
 }
+
+func TestGetVariableArrayTypeAndSize(t *testing.T) {
+	tests := []struct {
+		in    string
+		cType string
+		size  string
+		ok    bool
+	}{
+		{"int", "int", "", false},
+		{"int [4]", "int [4]", "", false},
+		{"int [n]", "int", "n", true},
+		{"int [n * 2]", "int", "n * 2", true},
+		{"char *[len + 1]", "char *", "len + 1", true},
+		{"int [n][3]", "int [3]", "n", true},
+	}
+
+	for _, tt := range tests {
+		cType, size, ok := GetVariableArrayTypeAndSize(tt.in)
+		if cType != tt.cType || size != tt.size || ok != tt.ok {
+			t.Errorf("%s: expected (%q, %q, %v), got (%q, %q, %v)",
+				tt.in, tt.cType, tt.size, tt.ok, cType, size, ok)
+		}
+	}
+}
+
+func TestDecayArrayType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"int", "int"},
+		{"int *", "int *"},
+		{"int [10]", "int *"},
+		{"char *[]", "char **"},
+		{"int [n]", "int *"},
+		{"int [][3]", "int (*)[3]"},
+		{"int [2][3][4]", "int (*)[3][4]"},
+		{"int (*)[3]", "int (*)[3]"},
+		{"int (*)(int [2])", "int (*)(int [2])"},
+	}
+
+	for _, tt := range tests {
+		if got := DecayArrayType(tt.in); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestCastABI(t *testing.T) {
+	tests := []struct {
+		abi      *program.ABI
+		fromType string
+		toType   string
+		want     string
+	}{
+		{program.LP64, "int", "long", "int64(x)"},
+		{program.ILP32, "int", "long", "x"},
+		{program.LP64, "long", "long long", "x"},
+		{program.ILP32, "long", "long long", "int64(x)"},
+		{program.LLP64, "unsigned long", "size_t", "uint64(x)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.abi.Name+" "+tt.fromType+" -> "+tt.toType, func(t *testing.T) {
+			p := program.NewProgram()
+			p.ABI = tt.abi
+			got, err := CastExpr(p, util.NewIdent("x"), tt.fromType, tt.toType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), got); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestCastPointer(t *testing.T) {
+	tests := []struct {
+		fromType string
+		toType   string
+		want     string
+	}{
+		{"char *", "struct X *", "(*X)(unsafe.Pointer(x))"},
+		{"struct X *", "unsigned int *", "(*uint32)(unsafe.Pointer(x))"},
+		{"char *", "void *", "unsafe.Pointer(x)"},
+		{"void *", "struct X *", "(*X)(x)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fromType+" -> "+tt.toType, func(t *testing.T) {
+			p := program.NewProgram()
+			p.Structs["struct X"] = &program.Struct{
+				Name:       "X",
+				Fields:     map[string]interface{}{"a": "int"},
+				FieldNames: []string{"a"},
+			}
+			got, err := CastExpr(p, util.NewIdent("x"), tt.fromType, tt.toType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), got); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, buf.String())
+			}
+
+			usesUnsafe := strings.Contains(tt.want, "unsafe")
+			if imported := util.InStrings(`"unsafe"`, p.Imports()); imported != usesUnsafe {
+				t.Errorf("Expected the import of unsafe to be %v, got %v", usesUnsafe, imported)
+			}
+		})
+	}
+}
@@ -41,9 +41,9 @@ func IsCInteger(p *program.Program, cType string) bool {
 }
 
 // TODO: Some of these are based on assumptions that may not be true for all
-// architectures (like the size of an int). At some point in the future we will
-// need to find out the sizes of some of there and pick the most compatible
-// type.
+// architectures (like the size of an int). The types that are known to have a
+// different width, like long and size_t, are resolved with the ABI of the
+// program instead, see program.ABI.GoType().
 //
 // Please keep them sorted by name.
 var simpleResolveTypes = map[string]string{
@@ -55,25 +55,18 @@ var simpleResolveTypes = map[string]string{
 	"float":                  "float32",
 	"int":                    "int32",
 	"long double":            "float64",
-	"long int":               "int32",
 	"long long":              "int64",
 	"long long int":          "int64",
 	"long long unsigned int": "uint64",
-	"long unsigned int":      "uint32",
-	"long":                   "int32",
-	"ptrdiff_t":              "int32",
 	"short":                  "int16",
 	"signed char":            "int8",
-	"size_t":                 "uint32",
 	"uintptr_t":              "uintptr",
 	"unsigned char":          "uint8",
 	"unsigned int":           "uint32",
 	"unsigned long long":     "uint64",
-	"unsigned long":          "uint32",
 	"unsigned short":         "uint16",
 	"unsigned short int":     "uint16",
 	"void":                   "",
-	"wchar_t":                "rune",
 	"_Bool":                  "int8",
 
 	// void*
@@ -137,6 +130,11 @@ var otherStructType = map[string]string{
 	"struct tm": "github.com/elliotchance/c2go/noarch.Tm",
 	"time_t":    "github.com/elliotchance/c2go/noarch.TimeT",
 
+	// setjmp.h
+	"jmp_buf":              "github.com/elliotchance/c2go/noarch.JmpBuf",
+	"__jmp_buf_tag":        "github.com/elliotchance/c2go/noarch.JmpBuf",
+	"struct __jmp_buf_tag": "github.com/elliotchance/c2go/noarch.JmpBuf",
+
 	// Darwin specific
 	"__darwin_ct_rune_t":     "github.com/elliotchance/c2go/darwin.CtRuneT",
 	"fpos_t":                 "int32",
@@ -205,10 +203,6 @@ func ResolveType(p *program.Program, s string) (_ string, err error) {
 		return "unsafe.Pointer", errors.New("probably an incorrect type translation 4")
 	}
 
-	if s == "char *[]" {
-		return "unsafe.Pointer", errors.New("probably an incorrect type translation 2")
-	}
-
 	if s == "fpos_t" {
 		return ResolveType(p, "int")
 	}
@@ -224,15 +218,30 @@ func ResolveType(p *program.Program, s string) (_ string, err error) {
 	}
 
 	// function type is pointer in Go by default
+	// A pointer to a typedef of a function pointer, like "handler_t *" for
+	// "typedef int (*handler_t)(void *)", is a pointer to the Go func.
 	if len(s) > 2 {
 		base := s[:len(s)-2]
 		if ff, ok := p.TypedefType[base]; ok {
-			if IsFunction(ff) {
+			if IsFunction(ff) && !IsFunctionPointer(ff) {
 				return base, nil
 			}
 		}
 	}
 
+	if s == "long double" && p.LongDoubleType == program.LongDoubleBigFloat {
+		return p.ImportType(p.LongDoubleType), nil
+	}
+
+	// The width of long, size_t, etc. is the one of the target platform.
+	abi := p.ABI
+	if abi == nil {
+		abi = program.LP64
+	}
+	if v, ok := abi.GoType(s); ok {
+		return v, nil
+	}
+
 	// The simple resolve types are the types that we know there is an exact Go
 	// equivalent. For example float, int, etc.
 	if v, ok := simpleResolveTypes[s]; ok {
@@ -266,6 +275,16 @@ func ResolveType(p *program.Program, s string) (_ string, err error) {
 		}
 	}
 
+	// An array of pointers to arrays, like "int (*[10])[3]", is a slice of the
+	// pointers: []*[]int32.
+	if prefix, declarator, suffix, ok := splitArrayDeclarator(s); ok {
+		arrays := strings.TrimSpace(declarator[1:])
+		if util.GetRegex(`^(\[\d*\])+$`).MatchString(arrays) {
+			t, err := ResolveType(p, prefix+" (*)"+suffix)
+			return util.GetRegex(`[0-9]+`).ReplaceAllString(arrays, "") + t, err
+		}
+	}
+
 	// For function
 	if IsFunction(s) {
 		g, e := resolveFunction(p, s)
@@ -352,6 +371,14 @@ func ResolveType(p *program.Program, s string) (_ string, err error) {
 		return prefix + t, err
 	}
 
+	// A pointer to an array, like "int (*)[3]", is what a multidimensional
+	// array decays to. The array "int [2][3]" is a slice of slices, so the
+	// pointer points to one of the inner slices: *[]int.
+	if match := util.GetRegex(`^(.+?) ?\(\*\) ?((\[\d*\])+)$`).FindStringSubmatch(s); len(match) > 0 {
+		t, err := ResolveType(p, match[1]+" "+match[2])
+		return "*" + t, err
+	}
+
 	// Function pointers are not yet supported. In the mean time they will be
 	// replaced with a type that certainly wont work until we can fix this
 	// properly.
@@ -368,10 +395,12 @@ func ResolveType(p *program.Program, s string) (_ string, err error) {
 	}
 
 	// It could be an array of fixed length. These needs to be converted to
-	// slices.
+	// slices. The length of an array that is declared before its definition,
+	// like "extern const int table[];", is not known, it is a slice as well.
 	// int [2][3] -> [][]int
 	// int [2][3][4] -> [][][]int
-	search2 := util.GetRegex(`([\w\* ]+)((\[\d+\])+)`).FindStringSubmatch(s)
+	// int [][3] -> [][]int
+	search2 := util.GetRegex(`([\w\* ]+)((\[\d*\])+)`).FindStringSubmatch(s)
 	if len(search2) > 2 {
 		t, err := ResolveType(p, search2[1])
 
@@ -381,6 +410,12 @@ func ResolveType(p *program.Program, s string) (_ string, err error) {
 		return fmt.Sprintf("%s%s", arraysNoSize, t), err
 	}
 
+	// A variable-length array, like "int [n]", is a slice as well.
+	if elementType, _, ok := GetVariableArrayTypeAndSize(s); ok {
+		t, err := ResolveType(p, elementType)
+		return "[]" + t, err
+	}
+
 	errMsg := fmt.Sprintf(
 		"I couldn't find an appropriate Go type for the C type '%s'.", s)
 	return "unsafe.Pointer", errors.New(errMsg)
@@ -421,6 +456,10 @@ func SeparateFunction(p *program.Program, s string) (
 		return
 	}
 	for i := range f {
+		if f[i] == "..." {
+			fields = append(fields, "...interface{}")
+			continue
+		}
 		var t string
 		t, err = ResolveType(p, f[i])
 		if err != nil {
@@ -445,6 +484,26 @@ func IsFunction(s string) bool {
 	return strings.Contains(s, "(")
 }
 
+// IsFunctionPointer returns true for the type of a pointer to a function, like
+// "int (*)(void *)", and false for the function type "int (void *)".
+func IsFunctionPointer(s string) bool {
+	i := strings.Index(s, "(")
+	return i >= 0 && strings.HasPrefix(s[i:], "(*)") && IsFunction(s)
+}
+
+// IsVariadic - return true if function type has a variable number of
+// arguments, like "int (int, ...)" or "int (*)(const char *, ...)"
+func IsVariadic(s string) bool {
+	if !IsFunction(s) {
+		return false
+	}
+	f, _, err := ParseFunction(s)
+	if err != nil || len(f) == 0 {
+		return false
+	}
+	return f[len(f)-1] == "..."
+}
+
 // IsPointer - check type is pointer
 func IsPointer(p *program.Program, s string) bool {
 	if strings.ContainsAny(s, "*[]") {
@@ -456,6 +515,11 @@ func IsPointer(p *program.Program, s string) bool {
 	return false
 }
 
+// IsPointerToArray - check type is pointer to array, like "int (*)[10]"
+func IsPointerToArray(s string) bool {
+	return util.GetRegex(`\(\*\) ?(\[\d*\])+$`).MatchString(CleanCType(s))
+}
+
 // IsPurePointer - check type is pointer
 func IsPurePointer(p *program.Program, s string) bool {
 	if strings.ContainsAny(s, "*") {
@@ -512,6 +576,23 @@ func ParseFunction(s string) (f []string, r []string, err error) {
 		// int (int, float)
 		// int (*)(int (*)(int))
 		// void (*(*)(int *, void *, const char *))(void)
+		// int (*(void))[10]
+		//
+		// The function returns a pointer to an array, like:
+		//     int (*(int))[10]
+		// is a function with the argument "int" that returns "int (*)[10]".
+		if prefix, declarator, suffix, ok := splitArrayDeclarator(s); ok {
+			inner := strings.TrimSpace(declarator[1:])
+			if strings.HasPrefix(inner, "(") && IsFunction(inner) {
+				f, _, err = ParseFunction("void " + inner)
+				if err != nil {
+					return
+				}
+				r = append(r, prefix+" (*)"+suffix)
+				return
+			}
+		}
+
 		if s[len(s)-1] != ')' {
 			err = fmt.Errorf("function type |%s| haven't last symbol ')'", s)
 			return
@@ -534,8 +615,22 @@ func ParseFunction(s string) (f []string, r []string, err error) {
 				break
 			}
 		}
-		r = append(r, strings.Replace(s[:pos], "(*)", "", -1))
 		arguments = s[pos:]
+
+		// The function returns a function pointer, like:
+		//     int (*(int (*)(int), int))(int)
+		// is a function with arguments "int (*)(int)" and "int" that returns
+		// "int (*)(int)".
+		if inner, prefix, ok := splitReturnedFunction(s[:pos]); ok {
+			f, _, err = ParseFunction("void " + inner)
+			if err != nil {
+				return
+			}
+			r = append(r, strings.TrimSpace(prefix)+" (*)"+arguments)
+			return
+		}
+
+		r = append(r, strings.Replace(s[:pos], "(*)", "", -1))
 	}
 	arguments = strings.TrimSpace(arguments)
 	if arguments == "" {
@@ -571,11 +666,76 @@ func ParseFunction(s string) (f []string, r []string, err error) {
 	return
 }
 
+// splitReturnedFunction checks if the left part of a function type, like
+// "int (*(int (*)(int), int))", is the declarator of a function that returns a
+// function pointer. It returns the inner declarator "(int (*)(int), int)" and
+// the return type of the returned function "int".
+func splitReturnedFunction(s string) (inner, prefix string, ok bool) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 || s[len(s)-1] != ')' {
+		return
+	}
+	counter := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == ')' {
+			counter++
+		}
+		if s[i] == '(' {
+			counter--
+		}
+		if counter == 0 {
+			if !strings.HasPrefix(s[i:], "(*") {
+				return
+			}
+			inner = strings.TrimSpace(s[i+2 : len(s)-1])
+			if !strings.HasPrefix(inner, "(") || !IsFunction(inner) {
+				return
+			}
+			return inner, s[:i], true
+		}
+	}
+	return
+}
+
+// splitArrayDeclarator splits a type with a pointer declarator in parentheses
+// that is followed by array sizes, like "int (*(void))[10]", into the type
+// "int", the declarator "*(void)" and the sizes "[10]". ok is false if the type
+// does not have this form, the declarator must begin with "*".
+func splitArrayDeclarator(s string) (prefix, declarator, suffix string, ok bool) {
+	match := util.GetRegex(`^(.*\)) ?((\[\d*\])+)$`).FindStringSubmatch(s)
+	if len(match) == 0 {
+		return
+	}
+	left := match[1]
+	counter := 0
+	for i := len(left) - 1; i >= 0; i-- {
+		if left[i] == ')' {
+			counter++
+		}
+		if left[i] == '(' {
+			counter--
+		}
+		if counter == 0 {
+			declarator = strings.TrimSpace(left[i+1 : len(left)-1])
+			prefix = strings.TrimSpace(left[:i])
+			if prefix == "" || !strings.HasPrefix(declarator, "*") {
+				return
+			}
+			return prefix, declarator, match[2], true
+		}
+	}
+	return
+}
+
 var (
-	rxconst      = regexp.MustCompile(`\bconst\b`)
-	rxvolatile   = regexp.MustCompile(`\bvolatile\b`)
-	rxUUrestrict = regexp.MustCompile(`\b__restrict\b`)
-	rxrestrict   = regexp.MustCompile(`\brestrict\b`)
+	rxconst        = regexp.MustCompile(`\bconst\b`)
+	rxvolatile     = regexp.MustCompile(`\bvolatile\b`)
+	rxUUrestrictUU = regexp.MustCompile(`\b__restrict__\b`)
+	rxUUrestrict   = regexp.MustCompile(`\b__restrict\b`)
+	rxrestrict     = regexp.MustCompile(`\brestrict\b`)
+
+	// The storage classes and inline do not change a type.
+	rxstorage = regexp.MustCompile(`\b(register|auto|__inline__|__inline|inline)\b`)
 )
 
 // CleanCType - remove from C type not Go type
@@ -588,13 +748,13 @@ func CleanCType(s string) (out string) {
 	// add space for simplification redactoring
 	out = strings.Replace(out, "*", " *", -1)
 
-	out = strings.Replace(out, "( *)", "(*)", -1)
-
 	// Remove any whitespace or attributes that are not relevant to Go.
 	out = rxconst.ReplaceAllLiteralString(out, "")
 	out = rxvolatile.ReplaceAllLiteralString(out, "")
+	out = rxUUrestrictUU.ReplaceAllLiteralString(out, "")
 	out = rxUUrestrict.ReplaceAllLiteralString(out, "")
 	out = rxrestrict.ReplaceAllLiteralString(out, "")
+	out = rxstorage.ReplaceAllLiteralString(out, "")
 	out = strings.Replace(out, "\t", "", -1)
 	out = strings.Replace(out, "\n", "", -1)
 	out = strings.Replace(out, "\r", "", -1)
@@ -607,6 +767,10 @@ func CleanCType(s string) (out string) {
 	// remove addition spaces
 	out = strings.Replace(out, "  ", " ", -1)
 
+	// The qualifiers are removed first, so that a function pointer like
+	// "int (*restrict)(int)" is cleaned as well.
+	out = strings.Replace(out, "( *)", "(*)", -1)
+
 	// remove spaces around
 	out = strings.TrimSpace(out)
 
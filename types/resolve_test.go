@@ -31,6 +31,38 @@ var resolveTestCases = []resolveTestCase{
 	{"int [2][3]", "[][]int32"},
 	{"int [2][3][4]", "[][][]int32"},
 	{"int [2][3][4][5]", "[][][][]int32"},
+	{"int []", "[]int32"},
+	{"int [][3]", "[][]int32"},
+	{"char *[]", "[]*byte"},
+	{"int *restrict", "*int32"},
+	{"int * restrict", "*int32"},
+	{"char *__restrict", "*byte"},
+	{"double *__restrict__", "*float64"},
+	{"const char *restrict *restrict", "**byte"},
+	{"int (*restrict)(int)", "func(int32)(int32)"},
+	{"int [n]", "[]int32"},
+	{"double [n * 2 + 1]", "[]float64"},
+	{"int (*)[3]", "*[]int32"},
+	{"register int", "int32"},
+	{"register int *", "*int32"},
+	{"register const char *", "*byte"},
+	{"auto long", "int64"},
+	{"unsigned register short", "uint16"},
+	{"inline int (int)", "func(int32)(int32)"},
+	{"char (*)[2][4]", "*[][]byte"},
+	{"int *[10]", "[]*int32"},
+	{"int (*)[10]", "*[]int32"},
+	{"int (*[10])[3]", "[]*[]int32"},
+	{"int (*(void))[10]", "func()(*[]int32)"},
+	{"int (*(*)(int))[10]", "func(int32)(*[]int32)"},
+	{"volatile int", "int32"},
+	{"int volatile", "int32"},
+	{"volatile unsigned long long", "uint64"},
+	{"int *volatile", "*int32"},
+	{"volatile int *", "*int32"},
+	{"const volatile int *volatile", "*int32"},
+	{"volatile char [4]", "[]byte"},
+	{"int (*volatile)(int)", "func(int32)(int32)"},
 }
 
 func TestResolve(t *testing.T) {
@@ -51,6 +83,58 @@ func TestResolve(t *testing.T) {
 	}
 }
 
+func TestResolveLongDouble(t *testing.T) {
+	p := program.NewProgram()
+	if goType, _ := types.ResolveType(p, "long double"); goType != "float64" {
+		t.Errorf("Expected float64, got %s", goType)
+	}
+
+	p.LongDoubleType = program.LongDoubleBigFloat
+	if goType, _ := types.ResolveType(p, "long double"); goType != "noarch.LongDouble" {
+		t.Errorf("Expected noarch.LongDouble, got %s", goType)
+	}
+}
+
+func TestResolveABI(t *testing.T) {
+	tests := []struct {
+		abi    *program.ABI
+		cType  string
+		goType string
+	}{
+		{program.LP64, "long", "int64"},
+		{program.LP64, "long int", "int64"},
+		{program.LP64, "unsigned long", "uint64"},
+		{program.LP64, "long unsigned int", "uint64"},
+		{program.LP64, "size_t", "uint64"},
+		{program.LP64, "ptrdiff_t", "int64"},
+		{program.LP64, "wchar_t", "int32"},
+		{program.LP64, "const long *", "*int64"},
+		{program.ILP32, "long", "int32"},
+		{program.ILP32, "unsigned long int", "uint32"},
+		{program.ILP32, "size_t", "uint32"},
+		{program.ILP32, "ptrdiff_t", "int32"},
+		{program.LLP64, "long", "int32"},
+		{program.LLP64, "size_t", "uint64"},
+		{program.LLP64, "wchar_t", "uint16"},
+		{program.ILP32, "long long", "int64"},
+		{program.ILP32, "int", "int32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.abi.Name+" "+tt.cType, func(t *testing.T) {
+			p := program.NewProgram()
+			p.ABI = tt.abi
+			goType, err := types.ResolveType(p, tt.cType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if goType != tt.goType {
+				t.Errorf("Expected '%s' -> '%s', got '%s'", tt.cType, tt.goType, goType)
+			}
+		})
+	}
+}
+
 func TestResolveFunction(t *testing.T) {
 	var tcs = []struct {
 		input   string
@@ -153,6 +237,26 @@ func TestResolveFunction(t *testing.T) {
 				"void (*)(sqlite3_context *)",
 			},
 			returns: []string{"int"},
+		},
+		{
+			input:   "int (*(int (*)(int), int))(int)",
+			fields:  []string{"int (*)(int)", "int"},
+			returns: []string{"int (*)(int)"},
+		},
+		{
+			input:   "void (*(*)(int *, void *, const char *))(void)",
+			fields:  []string{"int *", "void *", "const char *"},
+			returns: []string{"void (*)(void)"},
+		},
+		{
+			input:   "int (*(int, char *))[10]",
+			fields:  []string{"int", "char *"},
+			returns: []string{"int (*)[10]"},
+		},
+		{
+			input:   "double (*(*)(void))[2][3]",
+			fields:  []string{"void"},
+			returns: []string{"double (*)[2][3]"},
 		}, /*
 			{
 				input: "int (*)(sqlite3_vtab *, int, const char *, void (**)(sqlite3_context *, int, sqlite3_value **), void **)",
@@ -199,3 +303,61 @@ func TestResolveFunction(t *testing.T) {
 	}
 
 }
+
+func TestIsVariadic(t *testing.T) {
+	var tcs = []struct {
+		input    string
+		variadic bool
+	}{
+		{"int (int, ...)", true},
+		{"int (...)", true},
+		{"char *(*)(const char *, ...)", true},
+		{"int (int, int)", false},
+		{"void (void (*)(int, ...))", false},
+		{"int", false},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("Test %d : %s", i, tc.input), func(t *testing.T) {
+			if actual := types.IsVariadic(tc.input); actual != tc.variadic {
+				t.Errorf("Expected %v, got %v", tc.variadic, actual)
+			}
+		})
+	}
+}
+
+func TestIsFunctionPointer(t *testing.T) {
+	var tcs = []struct {
+		input   string
+		pointer bool
+	}{
+		{"int (*)(void *)", true},
+		{"void (*)(int (*)(int))", true},
+		{"int (void *)", false},
+		{"int (int (*)(int))", false},
+		{"int *", false},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("Test %d : %s", i, tc.input), func(t *testing.T) {
+			if actual := types.IsFunctionPointer(tc.input); actual != tc.pointer {
+				t.Errorf("Expected %v, got %v", tc.pointer, actual)
+			}
+		})
+	}
+}
+
+func TestResolveFunctionTypedefPointer(t *testing.T) {
+	p := program.NewProgram()
+	p.TypedefType["handler_t"] = "int (*)(void *)"
+	p.TypedefType["handler_fn"] = "int (void *)"
+
+	// A pointer to a function type is the func, a pointer to a function
+	// pointer is a pointer to the func.
+	for cType, want := range map[string]string{
+		"handler_fn *": "handler_fn",
+		"handler_t *":  "*handler_t",
+	} {
+		if goType, err := types.ResolveType(p, cType); err != nil || goType != want {
+			t.Errorf("ResolveType(%q) = %q, %v; want %q", cType, goType, err, want)
+		}
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"strings"
 
 	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/util"
 )
 
 // SizeOf returns the number of bytes for a type. This the same as using the
-// sizeof operator/function in C.
+// sizeof operator/function in C. The sizes of the fundamental types are taken
+// from the target ABI of the program, see program.ABI.
 func SizeOf(p *program.Program, cType string) (size int, err error) {
 	defer func() {
 		if err != nil {
@@ -16,150 +18,250 @@ func SizeOf(p *program.Program, cType string) (size int, err error) {
 		}
 	}()
 
+	layout, err := layoutOf(p, cType)
+	return layout.Size, err
+}
+
+// AlignOf returns the alignment of a type in bytes, like _Alignof in C.
+func AlignOf(p *program.Program, cType string) (align int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot determine alignof : |%s|. err = %v", cType, err)
+		}
+	}()
+
+	layout, err := layoutOf(p, cType)
+	return layout.Align, err
+}
+
+func layoutOf(p *program.Program, cType string) (program.TypeLayout, error) {
+	abi := p.ABI
+	if abi == nil {
+		abi = program.LP64
+	}
+
 	// Remove keywords that do not effect the size.
 	cType = CleanCType(cType)
 	cType = strings.Replace(cType, "unsigned ", "", -1)
 	cType = strings.Replace(cType, "signed ", "", -1)
 
-	// FIXME: The pointer size will be different on different platforms. We
-	// should find out the correct size at runtime.
-	pointerSize := 8
-
 	// Enum with name
 	if strings.HasPrefix(cType, "enum") {
-		return SizeOf(p, "int")
+		return layoutOf(p, "int")
 	}
 
 	// typedef int Integer;
 	if v, ok := p.TypedefType[cType]; ok {
-		return SizeOf(p, v)
+		return layoutOf(p, v)
 	}
 
 	// typedef Enum
 	if _, ok := p.EnumTypedefName[cType]; ok {
-		return SizeOf(p, "int")
+		return layoutOf(p, "int")
 	}
 
-	// A structure will be the sum of its parts.
-	var isStruct, ok bool
-	var s *program.Struct
+	// A structure is laid out like the C compiler does it, with padding
+	// between the fields for their alignment.
 	cType = GenerateCorrectType(cType)
-	if s, ok = p.Structs[cType]; ok {
-		isStruct = true
-	} else if s, ok = p.Structs["struct "+cType]; ok {
-		isStruct = true
+	if s, ok := p.Structs[cType]; ok {
+		layout, _, err := structLayout(p, s)
+		return layout, err
+	}
+	if s, ok := p.Structs["struct "+cType]; ok {
+		layout, _, err := structLayout(p, s)
+		return layout, err
 	}
-	if isStruct {
-		totalBytes := 0
-
-		for _, t := range s.Fields {
-			var bytes int
-			var err error
 
-			switch f := t.(type) {
-			case string:
-				bytes, err = SizeOf(p, f)
+	// An union will be the max size of its parts.
+	if strings.HasPrefix(cType, "union ") {
+		s := p.Unions[cType]
+		if s == nil {
+			return program.TypeLayout{}, fmt.Errorf("error in union")
+		}
 
-			case *program.Struct:
-				bytes, err = SizeOf(p, f.Name)
-			}
+		layout, _, err := structLayout(p, s)
+		return layout, err
+	}
 
-			if err != nil {
-				return 0, err
-			}
-			totalBytes += bytes
-		}
+	// A function, like "int (int)", has a size of 1 for GCC and clang. A
+	// function pointer is a pointer.
+	if strings.Contains(cType, "(*)") {
+		return abi.Pointer, nil
+	}
+	if strings.Contains(cType, "(") {
+		return program.TypeLayout{Size: 1, Align: 1}, nil
+	}
 
-		// The size of a struct is rounded up to fit the size of the pointer of
-		// the OS.
-		if totalBytes < 32 && totalBytes%pointerSize != 0 {
-			totalBytes += pointerSize - (totalBytes % pointerSize)
-		}
+	if strings.HasSuffix(cType, "*") {
+		return abi.Pointer, nil
+	}
 
-		return totalBytes, nil
+	if layout, ok := abi.Layout(cType); ok {
+		return layout, nil
 	}
 
-	// An union will be the max size of its parts.
-	if strings.HasPrefix(cType, "union ") {
-		byteCount := 0
+	// Get size for array types like: `base_type [count]`
+	totalArraySize := 1
+	arrayType, arraySize := GetArrayTypeAndSize(cType)
+	if arraySize <= 0 {
+		return program.TypeLayout{}, fmt.Errorf("error in array size")
+	}
 
-		s := p.Unions[cType]
-		if s == nil {
-			return 0, fmt.Errorf("error in union")
-		}
+	for arraySize != -1 {
+		totalArraySize *= arraySize
+		arrayType, arraySize = GetArrayTypeAndSize(arrayType)
+	}
 
-		for _, t := range s.Fields {
-			var bytes int
-			var err error
+	base, err := layoutOf(p, arrayType)
+	if err != nil {
+		return program.TypeLayout{}, fmt.Errorf("error in sizeof baseSize")
+	}
 
-			switch f := t.(type) {
-			case string:
-				bytes, err = SizeOf(p, f)
+	return program.TypeLayout{Size: base.Size * totalArraySize, Align: base.Align}, nil
+}
 
-			case *program.Struct:
-				bytes, err = SizeOf(p, f.Name)
-			}
+// structLayout returns the layout of a struct or a union. The fields of a
+// struct are placed at the next offset that fits their alignment, and the size
+// is rounded up to the largest alignment so that the fields of an array of the
+// struct are aligned too:
+//
+//     struct s {
+//         char a;      // offset 0
+//         int b;       // offset 4
+//         char c;      // offset 8
+//     };               // size 12, alignment 4
+//
+// The alignment of the fields is limited by the packing of the struct, that is
+// Program.Pack when the struct has no packing of its own. With
+// "#pragma pack(1)" the same struct has no padding, a size of 6 and an
+// alignment of 1.
+//
+// A bit-field is added to the bits of the previous one, unless it would cross
+// a boundary of its type. A bit-field with a width of 0 moves the next one to
+// such a boundary.
+//
+// The offsets are the offsets of the fields in bytes, like offsetof in C. The
+// bit-fields have no offset.
+func structLayout(p *program.Program, s *program.Struct) (
+	_ program.TypeLayout, offsets map[string]int, _ error) {
+	offset := 0 // in bits
+	size := 0
+	align := 1
+	pack := s.Pack
+	if pack == 0 {
+		pack = p.Pack
+	}
+	offsets = map[string]int{}
 
-			if err != nil {
-				return 0, err
-			}
+	for _, name := range s.FieldNames {
+		cType, _ := s.Fields[name].(string)
+		field, err := layoutOf(p, cType)
+		if err != nil {
+			return program.TypeLayout{}, nil, err
+		}
+		if pack > 0 && field.Align > pack {
+			field.Align = pack
+		}
+		if field.Align > align {
+			align = field.Align
+		}
 
-			if byteCount < bytes {
-				byteCount = bytes
+		if s.IsUnion {
+			offsets[name] = 0
+			if field.Size > size {
+				size = field.Size
 			}
+			continue
 		}
 
-		// The size of an union is rounded up to fit the size of the pointer of
-		// the OS.
-		if byteCount < 32 && byteCount%pointerSize != 0 {
-			byteCount += pointerSize - (byteCount % pointerSize)
+		if width, ok := s.Bitfields[name]; ok {
+			unit := field.Size * 8
+			if width == 0 || offset/unit != (offset+width-1)/unit {
+				offset = roundUp(offset, unit)
+			}
+			offset += width
+			continue
 		}
 
-		return byteCount, nil
-	}
-
-	// Function pointers are one byte?
-	if strings.Contains(cType, "(") {
-		return 1, nil
+		offset = roundUp(offset, field.Align*8)
+		offsets[name] = offset / 8
+		offset += field.Size * 8
 	}
 
-	if strings.HasSuffix(cType, "*") {
-		return pointerSize, nil
+	if !s.IsUnion {
+		size = (offset + 7) / 8
 	}
 
-	switch cType {
-	case "char", "void", "bool":
-		return 1, nil
+	return program.TypeLayout{Size: roundUp(size, align), Align: align}, offsets, nil
+}
 
-	case "short":
-		return 2, nil
+// OffsetOf returns the offset of a member of a struct or a union in bytes, like
+// offsetof in C. The member can be a member of a nested struct or an element
+// of an array, like "b.c[2]".
+func OffsetOf(p *program.Program, cType, member string) (offset int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot determine offsetof : |%s, %s|. err = %v",
+				cType, member, err)
+		}
+	}()
 
-	case "int", "float":
-		return 4, nil
+	designator := util.GetRegex(`^\s*(\.?\s*\w+|\[\s*\d+\s*\])`)
+	for rest := member; strings.TrimSpace(rest) != ""; {
+		match := designator.FindStringSubmatch(rest)
+		if match == nil {
+			return 0, fmt.Errorf("unsupported member designator")
+		}
+		rest = rest[len(match[0]):]
+		part := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(match[1]), "."))
 
-	case "long", "double":
-		return 8, nil
+		if strings.HasPrefix(part, "[") {
+			elementType, _ := GetArrayTypeAndSize(cType)
+			size, err := SizeOf(p, elementType)
+			if err != nil {
+				return 0, err
+			}
+			offset += util.Atoi(strings.TrimSpace(part[1:len(part)-1])) * size
+			cType = elementType
+			continue
+		}
 
-	case "long double", "long long", "long long int", "long long unsigned int":
-		return 16, nil
+		s := findStruct(p, cType)
+		if s == nil {
+			return 0, fmt.Errorf("'%s' is not a struct or a union", cType)
+		}
+		_, offsets, err := structLayout(p, s)
+		if err != nil {
+			return 0, err
+		}
+		fieldOffset, ok := offsets[part]
+		if !ok {
+			return 0, fmt.Errorf("cannot find member '%s'", part)
+		}
+		offset += fieldOffset
+		cType, _ = s.Fields[part].(string)
 	}
 
-	// Get size for array types like: `base_type [count]`
-	totalArraySize := 1
-	arrayType, arraySize := GetArrayTypeAndSize(cType)
-	if arraySize <= 0 {
-		return 0, fmt.Errorf("error in array size")
-	}
+	return offset, nil
+}
 
-	for arraySize != -1 {
-		totalArraySize *= arraySize
-		arrayType, arraySize = GetArrayTypeAndSize(arrayType)
+// findStruct returns the struct or the union of a C type, like "struct s" or a
+// typedef of it. It returns nil if the type is not a struct or a union.
+func findStruct(p *program.Program, cType string) *program.Struct {
+	cType = GenerateCorrectType(resolveTypedef(p, CleanCType(cType)))
+	if s, ok := p.Structs[cType]; ok {
+		return s
+	}
+	if s, ok := p.Structs["struct "+cType]; ok {
+		return s
 	}
+	return p.Unions[cType]
+}
 
-	baseSize, err := SizeOf(p, arrayType)
-	if err != nil {
-		return 0, fmt.Errorf("error in sizeof baseSize")
+func roundUp(n, multiple int) int {
+	if multiple <= 1 {
+		return n
 	}
 
-	return baseSize * totalArraySize, nil
+	return (n + multiple - 1) / multiple * multiple
 }
@@ -49,3 +49,218 @@ func TestSizeOf(t *testing.T) {
 		}
 	}
 }
+
+func TestSizeOfStruct(t *testing.T) {
+	p := program.NewProgram()
+	p.Structs["struct s"] = &program.Struct{
+		Name:       "s",
+		Fields:     map[string]interface{}{"a": "char", "b": "int", "c": "char"},
+		FieldNames: []string{"a", "b", "c"},
+	}
+	p.Structs["struct chars"] = &program.Struct{
+		Name:       "chars",
+		Fields:     map[string]interface{}{"a": "char", "b": "char"},
+		FieldNames: []string{"a", "b"},
+	}
+	p.Structs["struct flags"] = &program.Struct{
+		Name: "flags",
+		Fields: map[string]interface{}{
+			"a": "unsigned int", "b": "unsigned int", "c": "unsigned int", "d": "char",
+		},
+		FieldNames: []string{"a", "b", "c", "d"},
+		Bitfields:  map[string]int{"a": 3, "b": 5, "c": 30},
+	}
+	p.Unions["union u"] = &program.Struct{
+		Name:       "u",
+		IsUnion:    true,
+		Fields:     map[string]interface{}{"a": "char [5]", "b": "int"},
+		FieldNames: []string{"a", "b"},
+	}
+
+	for _, tc := range []struct {
+		cType string
+		size  int
+		align int
+	}{
+		{"struct s", 12, 4},
+		{"struct s [3]", 36, 4},
+		{"struct chars", 2, 1},
+		// c does not fit in the first int, so it starts the second one.
+		{"struct flags", 12, 4},
+		{"union u", 8, 4},
+		{"long", 8, 8},
+		{"long double", 16, 16},
+		{"int (*)(int)", 8, 8},
+	} {
+		size, err := types.SizeOf(p, tc.cType)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		align, err := types.AlignOf(p, tc.cType)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if size != tc.size || align != tc.align {
+			t.Errorf("Expected '%s' -> %d aligned to %d, got %d aligned to %d",
+				tc.cType, tc.size, tc.align, size, align)
+		}
+	}
+}
+
+func TestSizeOfABI(t *testing.T) {
+	p := program.NewProgram()
+	p.Structs["struct s"] = &program.Struct{
+		Name:       "s",
+		Fields:     map[string]interface{}{"a": "char", "b": "double"},
+		FieldNames: []string{"a", "b"},
+	}
+
+	for _, tc := range []struct {
+		abi   string
+		cType string
+		size  int
+	}{
+		{"lp64", "long", 8},
+		{"lp64", "struct s", 16},
+		{"llp64", "long", 4},
+		{"llp64", "unsigned long int", 4},
+		{"llp64", "char *", 8},
+		{"ilp32", "char *", 4},
+		{"ilp32", "long double", 12},
+		// A double is only aligned to 4 bytes in a struct.
+		{"ilp32", "struct s", 12},
+	} {
+		abi, err := program.GetABI(tc.abi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ABI = abi
+
+		size, err := types.SizeOf(p, tc.cType)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if size != tc.size {
+			t.Errorf("Expected '%s' -> '%d' for %s, got '%d'",
+				tc.cType, tc.size, tc.abi, size)
+		}
+	}
+
+	if _, err := program.GetABI("pdp11"); err == nil {
+		t.Errorf("Expected an error for an unknown ABI")
+	}
+}
+
+func TestSizeOfPackedStruct(t *testing.T) {
+	p := program.NewProgram()
+	fields := map[string]interface{}{"a": "char", "b": "int"}
+	p.Structs["struct natural"] = &program.Struct{
+		Name:       "natural",
+		Fields:     fields,
+		FieldNames: []string{"a", "b"},
+	}
+	p.Structs["struct packed"] = &program.Struct{
+		Name:       "packed",
+		Fields:     fields,
+		FieldNames: []string{"a", "b"},
+		Pack:       1,
+	}
+	p.Structs["struct pack2"] = &program.Struct{
+		Name:       "pack2",
+		Fields:     map[string]interface{}{"a": "char", "b": "long", "c": "char"},
+		FieldNames: []string{"a", "b", "c"},
+		Pack:       2,
+	}
+	p.Structs["struct outer"] = &program.Struct{
+		Name:       "outer",
+		Fields:     map[string]interface{}{"a": "char", "b": "struct packed"},
+		FieldNames: []string{"a", "b"},
+	}
+
+	for _, tc := range []struct {
+		pack  int
+		cType string
+		size  int
+		align int
+	}{
+		{0, "struct natural", 8, 4},
+		{0, "struct packed", 5, 1},
+		{0, "struct packed [2]", 10, 1},
+		{0, "struct pack2", 12, 2},
+		{0, "struct outer", 6, 1},
+		// The packing of the program is only used for the structs that do
+		// not have their own.
+		{2, "struct natural", 6, 2},
+		{2, "struct packed", 5, 1},
+		{8, "struct natural", 8, 4},
+	} {
+		p.Pack = tc.pack
+		size, err := types.SizeOf(p, tc.cType)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		align, err := types.AlignOf(p, tc.cType)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if size != tc.size || align != tc.align {
+			t.Errorf("Expected '%s' -> %d aligned to %d for pack %d, got %d aligned to %d",
+				tc.cType, tc.size, tc.align, tc.pack, size, align)
+		}
+	}
+}
+
+func TestOffsetOf(t *testing.T) {
+	p := program.NewProgram()
+	p.Structs["struct s"] = &program.Struct{
+		Name:       "s",
+		Fields:     map[string]interface{}{"a": "char", "b": "int", "c": "char"},
+		FieldNames: []string{"a", "b", "c"},
+	}
+	p.Structs["struct flags"] = &program.Struct{
+		Name: "flags",
+		Fields: map[string]interface{}{
+			"a": "unsigned int", "b": "unsigned int", "c": "unsigned int", "d": "char",
+		},
+		FieldNames: []string{"a", "b", "c", "d"},
+		Bitfields:  map[string]int{"a": 3, "b": 5, "c": 30},
+	}
+	p.Structs["struct outer"] = &program.Struct{
+		Name:       "outer",
+		Fields:     map[string]interface{}{"n": "short", "s": "struct s [2]"},
+		FieldNames: []string{"n", "s"},
+	}
+	p.TypedefType["S"] = "struct s"
+
+	for _, tc := range []struct {
+		cType  string
+		member string
+		offset int
+	}{
+		{"struct s", "a", 0},
+		{"struct s", "c", 8},
+		{"S", " c", 8},
+		// d is after the second int of the bit-fields.
+		{"struct flags", "d", 8},
+		{"struct outer", "s[1].b", 20},
+	} {
+		offset, err := types.OffsetOf(p, tc.cType, tc.member)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if offset != tc.offset {
+			t.Errorf("Expected offsetof(%s, %s) -> %d, got %d",
+				tc.cType, tc.member, tc.offset, offset)
+		}
+	}
+
+	if _, err := types.OffsetOf(p, "struct flags", "a"); err == nil {
+		t.Errorf("Expected an error for a bit-field")
+	}
+}
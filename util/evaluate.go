@@ -9,6 +9,10 @@ import (
 // EvaluateConstExpr evaluates the given expression.
 // Returns whether the expr is an integer constant,
 // and the resulting number if constant.
+//
+// It is only for the Go expressions that have no C node anymore, like the
+// values of the enum constants. The C expressions are evaluated by
+// constantValue() of the transpiler, that also knows sizeof and the enums.
 func EvaluateConstExpr(expr goast.Expr) (isConst bool, value int64) {
 	calc := &calcVisitor{
 		isConst: true,
@@ -210,12 +210,7 @@ func isAssignishOperator(t token.Token) bool {
 
 // NewIdent - create a new Go ast Ident
 func NewIdent(name string) *goast.Ident {
-	// TODO: The name of a variable or field cannot be a reserved word
-	// https://github.com/elliotchance/c2go/issues/83
-	// Search for this issue in other areas of the codebase.
-	if IsGoKeyword(name) {
-		name += "_"
-	}
+	name = MangleGoKeyword(name, DefaultGoKeywordSuffix)
 
 	// Remove const prefix as it has no equivalent in Go.
 	name = strings.TrimPrefix(name, "const ")
@@ -240,6 +235,27 @@ func NewTypeIdent(name string) goast.Expr {
 	return typeToExpr(name)
 }
 
+// NewVarDecl returns the declaration of a variable with an explicit type, like
+// "var c2goArg0 int32 = a". Unlike "c2goArg0 := a" the declaration can be
+// separated from its value, so that a goto can jump over it once the
+// declaration is moved to the top of the block. The value may be nil.
+func NewVarDecl(name, goType string, value goast.Expr) *goast.DeclStmt {
+	spec := &goast.ValueSpec{
+		Names: []*goast.Ident{NewIdent(name)},
+		Type:  NewTypeIdent(goType),
+	}
+	if value != nil {
+		spec.Values = []goast.Expr{value}
+	}
+
+	return &goast.DeclStmt{
+		Decl: &goast.GenDecl{
+			Tok:   token.VAR,
+			Specs: []goast.Spec{spec},
+		},
+	}
+}
+
 // NewStringLit returns a new Go basic literal with a string value.
 func NewStringLit(value string) *goast.BasicLit {
 	return &goast.BasicLit{
@@ -323,6 +339,27 @@ func IsGoKeyword(w string) bool {
 	return false
 }
 
+// DefaultGoKeywordSuffix is appended by MangleGoKeyword to an identifier that
+// is a Go keyword when no other suffix is given.
+const DefaultGoKeywordSuffix = "_"
+
+// MangleGoKeyword returns the name that a C identifier has in Go. C
+// identifiers like "type" or "range" are valid in C but reserved in Go, so the
+// suffix is appended to them. Any other name is returned unchanged. An empty
+// suffix is replaced by DefaultGoKeywordSuffix.
+//
+// All of the declarations and the references of an identifier must use the
+// same suffix, otherwise the references will not resolve.
+func MangleGoKeyword(name, suffix string) string {
+	if !IsGoKeyword(name) {
+		return name
+	}
+	if suffix == "" {
+		suffix = DefaultGoKeywordSuffix
+	}
+	return name + suffix
+}
+
 // ConvertFunctionNameFromCtoGo - convert function name fromC to Go
 func ConvertFunctionNameFromCtoGo(name string) string {
 	if name == "_" {
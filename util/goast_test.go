@@ -36,3 +36,20 @@ func TestFloatLit(t *testing.T) {
 		}
 	}
 }
+
+func TestMangleGoKeyword(t *testing.T) {
+	tests := []struct {
+		name, suffix, out string
+	}{
+		{"type", "", "type_"},
+		{"range", "_", "range_"},
+		{"chan", "C", "chanC"},
+		{"foo", "C", "foo"},
+	}
+	for _, tt := range tests {
+		if actual := MangleGoKeyword(tt.name, tt.suffix); actual != tt.out {
+			t.Errorf("MangleGoKeyword(%q, %q): expected %q, got %q",
+				tt.name, tt.suffix, tt.out, actual)
+		}
+	}
+}